@@ -16,30 +16,97 @@ package bufapp
 
 import (
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/bufbuild/buf/private/pkg/app/appname"
 	"github.com/bufbuild/buf/private/pkg/cert/certclient"
 )
 
-const currentVersion = "v1"
+const (
+	currentVersion = "v1"
+
+	defaultCircuitBreakerOpenDuration   = 30 * time.Second
+	defaultCircuitBreakerAttemptTimeout = time.Minute
+)
 
 // ExternalConfig is an external config.
 type ExternalConfig struct {
 	// If editing ExternalConfig, make sure to update ExternalConfig.IsEmpty!
 
-	Version string                             `json:"version,omitempty" yaml:"version,omitempty"`
-	TLS     certclient.ExternalClientTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Version   string                             `json:"version,omitempty" yaml:"version,omitempty"`
+	TLS       certclient.ExternalClientTLSConfig `json:"tls,omitempty" yaml:"tls,omitempty"`
+	Registry  ExternalRegistryConfig             `json:"registry,omitempty" yaml:"registry,omitempty"`
+	Telemetry ExternalTelemetryConfig            `json:"telemetry,omitempty" yaml:"telemetry,omitempty"`
 }
 
 // IsEmpty returns true if the externalConfig is empty.
 func (e ExternalConfig) IsEmpty() bool {
-	return e.Version == "" && e.TLS.IsEmpty()
+	return e.Version == "" && e.TLS.IsEmpty() && e.Registry.IsEmpty() && e.Telemetry.IsEmpty()
+}
+
+// ExternalRegistryConfig is an external registry config.
+type ExternalRegistryConfig struct {
+	CircuitBreaker ExternalCircuitBreakerConfig `json:"circuit_breaker,omitempty" yaml:"circuit_breaker,omitempty"`
+	// Mirrors maps a registry host to the hosts to send requests to when the circuit
+	// for the registry host is open. Mirrors receive the registry's credentials.
+	Mirrors map[string][]string `json:"mirrors,omitempty" yaml:"mirrors,omitempty"`
+}
+
+// IsEmpty returns true if the externalRegistryConfig is empty.
+func (e ExternalRegistryConfig) IsEmpty() bool {
+	return e.CircuitBreaker.IsEmpty() && len(e.Mirrors) == 0
+}
+
+// ExternalCircuitBreakerConfig is an external circuit breaker config.
+type ExternalCircuitBreakerConfig struct {
+	FailureThreshold int    `json:"failure_threshold,omitempty" yaml:"failure_threshold,omitempty"`
+	OpenDuration     string `json:"open_duration,omitempty" yaml:"open_duration,omitempty"`
+	AttemptTimeout   string `json:"attempt_timeout,omitempty" yaml:"attempt_timeout,omitempty"`
+}
+
+// IsEmpty returns true if the externalCircuitBreakerConfig is empty.
+func (e ExternalCircuitBreakerConfig) IsEmpty() bool {
+	return e.FailureThreshold == 0 && e.OpenDuration == "" && e.AttemptTimeout == ""
+}
+
+// ExternalTelemetryConfig is an external telemetry config.
+type ExternalTelemetryConfig struct {
+	// Enabled records usage of the CLI locally.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Endpoint is a URL that usage is also sent to, such as an endpoint run by your
+	// organization. Usage is never sent anywhere unless this is set.
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+}
+
+// IsEmpty returns true if the externalTelemetryConfig is empty.
+func (e ExternalTelemetryConfig) IsEmpty() bool {
+	return !e.Enabled && e.Endpoint == ""
 }
 
 // Config is a config.
 type Config struct {
 	TLS *tls.Config
+	// CircuitBreakerFailureThreshold is the number of consecutive failures after which
+	// requests to a registry fail immediately. Zero means the circuit breaker is disabled.
+	CircuitBreakerFailureThreshold int
+	// CircuitBreakerOpenDuration is how long requests fail immediately before a registry
+	// is tried again.
+	CircuitBreakerOpenDuration time.Duration
+	// CircuitBreakerAttemptTimeout is how long to wait for a registry to accept a
+	// connection and send response headers before counting the request as a failure.
+	CircuitBreakerAttemptTimeout time.Duration
+	// RegistryMirrors maps registry hosts to their mirror hosts. May be empty.
+	RegistryMirrors map[string][]string
+	// TelemetryEnabled says whether usage of the CLI is recorded locally.
+	TelemetryEnabled bool
+	// TelemetryEndpoint is the URL that usage is sent to. May be empty, in which
+	// case usage is only recorded locally.
+	//
+	// Only set if TelemetryEnabled is true.
+	TelemetryEndpoint string
 }
 
 // NewConfig returns a new Config for the ExternalConfig.
@@ -54,7 +121,80 @@ func NewConfig(
 	if err != nil {
 		return nil, err
 	}
+	circuitBreakerOpenDuration, err := getCircuitBreakerOpenDuration(externalConfig.Registry.CircuitBreaker)
+	if err != nil {
+		return nil, err
+	}
+	circuitBreakerAttemptTimeout, err := getCircuitBreakerAttemptTimeout(externalConfig.Registry.CircuitBreaker)
+	if err != nil {
+		return nil, err
+	}
+	if len(externalConfig.Registry.Mirrors) > 0 && externalConfig.Registry.CircuitBreaker.FailureThreshold == 0 {
+		return nil, errors.New("registry.mirrors requires registry.circuit_breaker.failure_threshold to be set")
+	}
+	for host, mirrors := range externalConfig.Registry.Mirrors {
+		if len(mirrors) == 0 {
+			return nil, fmt.Errorf("registry.mirrors for %q must not be empty", host)
+		}
+	}
+	if err := validateExternalTelemetryConfig(externalConfig.Telemetry); err != nil {
+		return nil, err
+	}
 	return &Config{
-		TLS: tlsConfig,
+		TLS:                            tlsConfig,
+		CircuitBreakerFailureThreshold: externalConfig.Registry.CircuitBreaker.FailureThreshold,
+		CircuitBreakerOpenDuration:     circuitBreakerOpenDuration,
+		CircuitBreakerAttemptTimeout:   circuitBreakerAttemptTimeout,
+		RegistryMirrors:                externalConfig.Registry.Mirrors,
+		TelemetryEnabled:               externalConfig.Telemetry.Enabled,
+		TelemetryEndpoint:              externalConfig.Telemetry.Endpoint,
 	}, nil
 }
+
+func validateExternalTelemetryConfig(externalConfig ExternalTelemetryConfig) error {
+	if externalConfig.Endpoint == "" {
+		return nil
+	}
+	if !externalConfig.Enabled {
+		return errors.New("telemetry.endpoint requires telemetry.enabled to be set")
+	}
+	endpointURL, err := url.Parse(externalConfig.Endpoint)
+	if err != nil {
+		return fmt.Errorf("telemetry.endpoint is invalid: %w", err)
+	}
+	if endpointURL.Scheme != "https" && endpointURL.Scheme != "http" {
+		return fmt.Errorf("telemetry.endpoint must be an http or https URL: %s", externalConfig.Endpoint)
+	}
+	return nil
+}
+
+func getCircuitBreakerOpenDuration(externalConfig ExternalCircuitBreakerConfig) (time.Duration, error) {
+	if externalConfig.FailureThreshold < 0 {
+		return 0, fmt.Errorf("registry.circuit_breaker.failure_threshold must not be negative: %d", externalConfig.FailureThreshold)
+	}
+	if externalConfig.OpenDuration == "" {
+		return defaultCircuitBreakerOpenDuration, nil
+	}
+	openDuration, err := time.ParseDuration(externalConfig.OpenDuration)
+	if err != nil {
+		return 0, fmt.Errorf("registry.circuit_breaker.open_duration is invalid: %w", err)
+	}
+	if openDuration <= 0 {
+		return 0, fmt.Errorf("registry.circuit_breaker.open_duration must be positive: %s", externalConfig.OpenDuration)
+	}
+	return openDuration, nil
+}
+
+func getCircuitBreakerAttemptTimeout(externalConfig ExternalCircuitBreakerConfig) (time.Duration, error) {
+	if externalConfig.AttemptTimeout == "" {
+		return defaultCircuitBreakerAttemptTimeout, nil
+	}
+	attemptTimeout, err := time.ParseDuration(externalConfig.AttemptTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("registry.circuit_breaker.attempt_timeout is invalid: %w", err)
+	}
+	if attemptTimeout <= 0 {
+		return 0, fmt.Errorf("registry.circuit_breaker.attempt_timeout must be positive: %s", externalConfig.AttemptTimeout)
+	}
+	return attemptTimeout, nil
+}
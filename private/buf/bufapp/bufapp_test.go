@@ -16,11 +16,124 @@ package bufapp
 
 import (
 	"testing"
+	"time"
 
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appname"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestExternalConfigIsEmpty(t *testing.T) {
 	t.Parallel()
 	assert.True(t, ExternalConfig{}.IsEmpty())
 }
+
+func TestNewConfigRegistry(t *testing.T) {
+	t.Parallel()
+	container := newTestContainer(t)
+	config, err := NewConfig(container, ExternalConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, config.CircuitBreakerFailureThreshold)
+	assert.Equal(t, defaultCircuitBreakerOpenDuration, config.CircuitBreakerOpenDuration)
+	assert.Equal(t, defaultCircuitBreakerAttemptTimeout, config.CircuitBreakerAttemptTimeout)
+	config, err = NewConfig(
+		container,
+		ExternalConfig{
+			Version: "v1",
+			Registry: ExternalRegistryConfig{
+				CircuitBreaker: ExternalCircuitBreakerConfig{
+					FailureThreshold: 3,
+					OpenDuration:     "1m",
+					AttemptTimeout:   "10s",
+				},
+				Mirrors: map[string][]string{
+					"buf.example.com": {"mirror.example.com"},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.CircuitBreakerFailureThreshold)
+	assert.Equal(t, time.Minute, config.CircuitBreakerOpenDuration)
+	assert.Equal(t, 10*time.Second, config.CircuitBreakerAttemptTimeout)
+	assert.Equal(t, map[string][]string{"buf.example.com": {"mirror.example.com"}}, config.RegistryMirrors)
+}
+
+func TestNewConfigRegistryError(t *testing.T) {
+	t.Parallel()
+	container := newTestContainer(t)
+	for _, registryConfig := range []ExternalRegistryConfig{
+		{
+			CircuitBreaker: ExternalCircuitBreakerConfig{FailureThreshold: -1},
+		},
+		{
+			CircuitBreaker: ExternalCircuitBreakerConfig{FailureThreshold: 3, OpenDuration: "soon"},
+		},
+		{
+			CircuitBreaker: ExternalCircuitBreakerConfig{FailureThreshold: 3, OpenDuration: "-1s"},
+		},
+		{
+			CircuitBreaker: ExternalCircuitBreakerConfig{FailureThreshold: 3, AttemptTimeout: "0s"},
+		},
+		{
+			Mirrors: map[string][]string{"buf.example.com": {"mirror.example.com"}},
+		},
+		{
+			CircuitBreaker: ExternalCircuitBreakerConfig{FailureThreshold: 3},
+			Mirrors:        map[string][]string{"buf.example.com": {}},
+		},
+	} {
+		_, err := NewConfig(container, ExternalConfig{Version: "v1", Registry: registryConfig})
+		assert.Error(t, err)
+	}
+}
+
+func TestNewConfigTelemetry(t *testing.T) {
+	t.Parallel()
+	container := newTestContainer(t)
+	config, err := NewConfig(container, ExternalConfig{})
+	require.NoError(t, err)
+	assert.False(t, config.TelemetryEnabled)
+	assert.Empty(t, config.TelemetryEndpoint)
+	config, err = NewConfig(
+		container,
+		ExternalConfig{
+			Version: "v1",
+			Telemetry: ExternalTelemetryConfig{
+				Enabled:  true,
+				Endpoint: "https://telemetry.example.com/buf",
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.True(t, config.TelemetryEnabled)
+	assert.Equal(t, "https://telemetry.example.com/buf", config.TelemetryEndpoint)
+}
+
+func TestNewConfigTelemetryError(t *testing.T) {
+	t.Parallel()
+	container := newTestContainer(t)
+	for _, telemetryConfig := range []ExternalTelemetryConfig{
+		{
+			Endpoint: "https://telemetry.example.com/buf",
+		},
+		{
+			Enabled:  true,
+			Endpoint: "telemetry.example.com",
+		},
+		{
+			Enabled:  true,
+			Endpoint: "https://telemetry.example.com/%zz",
+		},
+	} {
+		_, err := NewConfig(container, ExternalConfig{Version: "v1", Telemetry: telemetryConfig})
+		assert.Error(t, err)
+	}
+}
+
+func newTestContainer(t *testing.T) appname.Container {
+	container, err := appname.NewContainer(app.NewEnvContainer(nil), "buf")
+	require.NoError(t, err)
+	return container
+}
@@ -0,0 +1,159 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufbench aggregates and compares timings of repeated builds.
+package bufbench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// PhaseFetch is the phase that reads the input and its dependencies.
+	PhaseFetch = "fetch"
+	// PhaseParse is the phase that parses the files.
+	PhaseParse = "parse"
+	// PhaseLink is the phase that links the parsed files into descriptors.
+	PhaseLink = "link"
+	// PhaseSerialize is the phase that serializes the image.
+	PhaseSerialize = "serialize"
+	// PhaseTotal is the sum of all phases.
+	PhaseTotal = "total"
+)
+
+// Sample is the timings of a single build.
+type Sample struct {
+	Fetch     time.Duration
+	Parse     time.Duration
+	Link      time.Duration
+	Serialize time.Duration
+}
+
+// Total returns the sum of the timings of all phases.
+func (s *Sample) Total() time.Duration {
+	return s.Fetch + s.Parse + s.Link + s.Serialize
+}
+
+// Result is the aggregated timings of repeated builds.
+type Result struct {
+	Iterations int            `json:"iterations"`
+	Phases     []*PhaseResult `json:"phases"`
+}
+
+// PhaseResult is the aggregated timings of a single phase.
+type PhaseResult struct {
+	Phase string        `json:"phase"`
+	Min   time.Duration `json:"min"`
+	Mean  time.Duration `json:"mean"`
+	Max   time.Duration `json:"max"`
+}
+
+// NewResult aggregates the Samples.
+//
+// Phases are in the order fetch, parse, link, serialize, total.
+func NewResult(samples []*Sample) *Result {
+	phaseToGetDuration := []struct {
+		phase       string
+		getDuration func(*Sample) time.Duration
+	}{
+		{PhaseFetch, func(sample *Sample) time.Duration { return sample.Fetch }},
+		{PhaseParse, func(sample *Sample) time.Duration { return sample.Parse }},
+		{PhaseLink, func(sample *Sample) time.Duration { return sample.Link }},
+		{PhaseSerialize, func(sample *Sample) time.Duration { return sample.Serialize }},
+		{PhaseTotal, (*Sample).Total},
+	}
+	result := &Result{
+		Iterations: len(samples),
+	}
+	for _, phaseAndGetDuration := range phaseToGetDuration {
+		phaseResult := &PhaseResult{
+			Phase: phaseAndGetDuration.phase,
+		}
+		var sum time.Duration
+		for i, sample := range samples {
+			duration := phaseAndGetDuration.getDuration(sample)
+			sum += duration
+			if i == 0 || duration < phaseResult.Min {
+				phaseResult.Min = duration
+			}
+			if duration > phaseResult.Max {
+				phaseResult.Max = duration
+			}
+		}
+		if len(samples) > 0 {
+			phaseResult.Mean = sum / time.Duration(len(samples))
+		}
+		result.Phases = append(result.Phases, phaseResult)
+	}
+	return result
+}
+
+// ReadResult reads a Result written by WriteResult.
+func ReadResult(reader io.Reader) (*Result, error) {
+	result := &Result{}
+	if err := json.NewDecoder(reader).Decode(result); err != nil {
+		return nil, fmt.Errorf("could not read benchmark result: %w", err)
+	}
+	return result, nil
+}
+
+// WriteResult writes the Result as JSON.
+func WriteResult(writer io.Writer, result *Result) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// Regression is a phase whose mean duration increased beyond the threshold.
+type Regression struct {
+	Phase    string        `json:"phase"`
+	Baseline time.Duration `json:"baseline"`
+	Current  time.Duration `json:"current"`
+	// Change is the relative increase of the mean, where 0.5 is an increase of 50%.
+	Change float64 `json:"change"`
+}
+
+// Compare returns the phases whose mean duration in current increased by more than
+// threshold relative to baseline, where a threshold of 0.2 is an increase of 20%.
+//
+// Phases that are not in baseline, or that took no time in baseline, are not compared.
+func Compare(baseline *Result, current *Result, threshold float64) []*Regression {
+	phaseToBaseline := make(map[string]*PhaseResult, len(baseline.Phases))
+	for _, phaseResult := range baseline.Phases {
+		phaseToBaseline[phaseResult.Phase] = phaseResult
+	}
+	var regressions []*Regression
+	for _, currentPhaseResult := range current.Phases {
+		baselinePhaseResult, ok := phaseToBaseline[currentPhaseResult.Phase]
+		if !ok || baselinePhaseResult.Mean <= 0 {
+			continue
+		}
+		change := float64(currentPhaseResult.Mean-baselinePhaseResult.Mean) / float64(baselinePhaseResult.Mean)
+		if change > threshold {
+			regressions = append(
+				regressions,
+				&Regression{
+					Phase:    currentPhaseResult.Phase,
+					Baseline: baselinePhaseResult.Mean,
+					Current:  currentPhaseResult.Mean,
+					Change:   change,
+				},
+			)
+		}
+	}
+	return regressions
+}
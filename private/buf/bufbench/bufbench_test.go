@@ -0,0 +1,114 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufbench
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+	result := NewResult(
+		[]*Sample{
+			{
+				Fetch:     1 * time.Second,
+				Parse:     2 * time.Second,
+				Link:      3 * time.Second,
+				Serialize: 4 * time.Second,
+			},
+			{
+				Fetch:     3 * time.Second,
+				Parse:     2 * time.Second,
+				Link:      1 * time.Second,
+				Serialize: 2 * time.Second,
+			},
+		},
+	)
+	assert.Equal(
+		t,
+		&Result{
+			Iterations: 2,
+			Phases: []*PhaseResult{
+				{Phase: PhaseFetch, Min: 1 * time.Second, Mean: 2 * time.Second, Max: 3 * time.Second},
+				{Phase: PhaseParse, Min: 2 * time.Second, Mean: 2 * time.Second, Max: 2 * time.Second},
+				{Phase: PhaseLink, Min: 1 * time.Second, Mean: 2 * time.Second, Max: 3 * time.Second},
+				{Phase: PhaseSerialize, Min: 2 * time.Second, Mean: 3 * time.Second, Max: 4 * time.Second},
+				{Phase: PhaseTotal, Min: 8 * time.Second, Mean: 9 * time.Second, Max: 10 * time.Second},
+			},
+		},
+		result,
+	)
+}
+
+func TestReadWriteResult(t *testing.T) {
+	t.Parallel()
+	result := NewResult(
+		[]*Sample{
+			{
+				Fetch: 1 * time.Millisecond,
+				Parse: 2 * time.Millisecond,
+				Link:  3 * time.Millisecond,
+			},
+		},
+	)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteResult(buffer, result))
+	readResult, err := ReadResult(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, result, readResult)
+	_, err = ReadResult(bytes.NewBufferString("{"))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+	baseline := &Result{
+		Iterations: 1,
+		Phases: []*PhaseResult{
+			{Phase: PhaseFetch, Mean: 0},
+			{Phase: PhaseParse, Mean: 10 * time.Millisecond},
+			{Phase: PhaseLink, Mean: 10 * time.Millisecond},
+			{Phase: PhaseSerialize, Mean: 10 * time.Millisecond},
+		},
+	}
+	current := &Result{
+		Iterations: 1,
+		Phases: []*PhaseResult{
+			{Phase: PhaseFetch, Mean: 10 * time.Millisecond},
+			{Phase: PhaseParse, Mean: 15 * time.Millisecond},
+			{Phase: PhaseLink, Mean: 11 * time.Millisecond},
+			{Phase: PhaseSerialize, Mean: 5 * time.Millisecond},
+			{Phase: PhaseTotal, Mean: 41 * time.Millisecond},
+		},
+	}
+	assert.Equal(
+		t,
+		[]*Regression{
+			{
+				Phase:    PhaseParse,
+				Baseline: 10 * time.Millisecond,
+				Current:  15 * time.Millisecond,
+				Change:   0.5,
+			},
+		},
+		Compare(baseline, current, 0.2),
+	)
+	assert.Empty(t, Compare(baseline, current, 0.5))
+}
@@ -0,0 +1,141 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufbisect finds the first revision in a range at which a check
+// started failing, such as the first commit at which a module stopped building.
+package bufbisect
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+const (
+	// StatusGood says that the check passed for a revision.
+	StatusGood Status = 1
+	// StatusBad says that the check failed for a revision.
+	StatusBad Status = 2
+	// StatusSkip says that a revision could not be checked, for example because
+	// the module did not exist at the revision.
+	StatusSkip Status = 3
+)
+
+// Status is the result of checking a revision.
+type Status int
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case StatusGood:
+		return "good"
+	case StatusBad:
+		return "bad"
+	case StatusSkip:
+		return "skip"
+	default:
+		return strconv.Itoa(int(s))
+	}
+}
+
+// Result is the result of a bisection.
+type Result struct {
+	// FirstBad is the index of the first revision known to be bad.
+	FirstBad int
+	// Skipped are the indexes of the revisions immediately before FirstBad that
+	// could not be checked, in ascending order. If not empty, any of these may be
+	// the actual first bad revision.
+	Skipped []int
+	// NumChecked is the number of revisions that were checked.
+	NumChecked int
+}
+
+// CheckFunc checks the revision at the index.
+type CheckFunc func(ctx context.Context, index int) (Status, error)
+
+// Bisect finds the first bad revision of numRevisions revisions ordered from
+// oldest to newest.
+//
+// The revision preceding the first revision is assumed to be good, and the
+// last revision is assumed to be bad, so neither is checked. As with git
+// bisect, the revisions are assumed to go from good to bad exactly once. If
+// they do not, the returned revision is a revision that is bad and preceded by
+// a good revision, but not necessarily the first such revision.
+func Bisect(ctx context.Context, numRevisions int, check CheckFunc) (*Result, error) {
+	if numRevisions <= 0 {
+		return nil, errors.New("no revisions to bisect")
+	}
+	// good and bad are the indexes of the latest revision known to be good and
+	// the earliest revision known to be bad. The revisions in between are unknown.
+	good := -1
+	bad := numRevisions - 1
+	skipped := make(map[int]struct{})
+	result := &Result{}
+	for {
+		index, ok := nextIndex(good, bad, skipped)
+		if !ok {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		status, err := check(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		result.NumChecked++
+		switch status {
+		case StatusGood:
+			good = index
+		case StatusBad:
+			bad = index
+		case StatusSkip:
+			skipped[index] = struct{}{}
+		default:
+			return nil, errors.New("unknown status: " + status.String())
+		}
+	}
+	result.FirstBad = bad
+	for index := good + 1; index < bad; index++ {
+		// All revisions in between are skipped once the loop ends.
+		result.Skipped = append(result.Skipped, index)
+	}
+	return result, nil
+}
+
+// nextIndex returns the index of the next revision to check between good and
+// bad, excluding both, which is the unskipped revision closest to the middle.
+func nextIndex(good int, bad int, skipped map[int]struct{}) (int, bool) {
+	if bad-good < 2 {
+		return 0, false
+	}
+	middle := good + (bad-good)/2
+	for offset := 0; ; offset++ {
+		below := middle - offset
+		above := middle + offset
+		if below <= good && above >= bad {
+			return 0, false
+		}
+		if below > good {
+			if _, ok := skipped[below]; !ok {
+				return below, true
+			}
+		}
+		if above < bad && offset > 0 {
+			if _, ok := skipped[above]; !ok {
+				return above, true
+			}
+		}
+	}
+}
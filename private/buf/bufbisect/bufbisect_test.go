@@ -0,0 +1,85 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufbisect
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBisect(t *testing.T) {
+	t.Parallel()
+	testBisect(t, "g", &Result{FirstBad: 0})
+	testBisect(t, "b", &Result{FirstBad: 0})
+	testBisect(t, "gb", &Result{FirstBad: 1, NumChecked: 1})
+	testBisect(t, "bb", &Result{FirstBad: 0, NumChecked: 1})
+	testBisect(t, "gggggggbbbbbbbbb", &Result{FirstBad: 7, NumChecked: 4})
+	testBisect(t, "gggggggggggggggb", &Result{FirstBad: 15, NumChecked: 4})
+	testBisect(t, "bbbbbbbbbbbbbbbb", &Result{FirstBad: 0, NumChecked: 4})
+	testBisect(t, "ggsggsbb", &Result{FirstBad: 6, Skipped: []int{5}, NumChecked: 4})
+	testBisect(t, "gggssbbb", &Result{FirstBad: 5, Skipped: []int{3, 4}, NumChecked: 4})
+	testBisect(t, "sssssssb", &Result{FirstBad: 7, Skipped: []int{0, 1, 2, 3, 4, 5, 6}, NumChecked: 7})
+}
+
+func TestBisectError(t *testing.T) {
+	t.Parallel()
+	_, err := Bisect(context.Background(), 0, nil)
+	assert.Error(t, err)
+	checkErr := errors.New("check")
+	_, err = Bisect(
+		context.Background(),
+		4,
+		func(context.Context, int) (Status, error) {
+			return 0, checkErr
+		},
+	)
+	assert.ErrorIs(t, err, checkErr)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Bisect(
+		ctx,
+		4,
+		func(context.Context, int) (Status, error) {
+			return StatusGood, nil
+		},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// testBisect bisects the revisions described by statuses, one character per
+// revision: g for good, b for bad, and s for skip.
+func testBisect(t *testing.T, statuses string, expectedResult *Result) {
+	result, err := Bisect(
+		context.Background(),
+		len(statuses),
+		func(_ context.Context, index int) (Status, error) {
+			require.Less(t, index, len(statuses)-1, "the last revision should not be checked")
+			switch statuses[index] {
+			case 'g':
+				return StatusGood, nil
+			case 'b':
+				return StatusBad, nil
+			default:
+				return StatusSkip, nil
+			}
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expectedResult, result, statuses)
+}
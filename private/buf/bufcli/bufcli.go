@@ -15,11 +15,9 @@
 package bufcli
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -48,29 +46,46 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/app/appname"
+	"github.com/bufbuild/buf/private/pkg/app/appprompt"
 	"github.com/bufbuild/buf/private/pkg/command"
 	"github.com/bufbuild/buf/private/pkg/connectclient"
+	"github.com/bufbuild/buf/private/pkg/diff/diffparse"
+	"github.com/bufbuild/buf/private/pkg/filelock"
 	"github.com/bufbuild/buf/private/pkg/git"
 	"github.com/bufbuild/buf/private/pkg/httpauth"
 	"github.com/bufbuild/buf/private/pkg/netrc"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/stringutil"
 	"github.com/bufbuild/buf/private/pkg/transport/http/httpclient"
 	"github.com/spf13/pflag"
 	"go.uber.org/zap"
-	"golang.org/x/term"
 )
 
 const (
 	// Version is the CLI version of buf.
 	Version = "1.30.2-dev"
 
-	inputHTTPSUsernameEnvKey      = "BUF_INPUT_HTTPS_USERNAME"
-	inputHTTPSPasswordEnvKey      = "BUF_INPUT_HTTPS_PASSWORD"
-	inputSSHKeyFileEnvKey         = "BUF_INPUT_SSH_KEY_FILE"
-	inputSSHKnownHostsFilesEnvKey = "BUF_INPUT_SSH_KNOWN_HOSTS_FILES"
+	inputHTTPSUsernameEnvKey           = "BUF_INPUT_HTTPS_USERNAME"
+	inputHTTPSPasswordEnvKey           = "BUF_INPUT_HTTPS_PASSWORD"
+	inputHTTPSOAuth2TokenURLEnvKey     = "BUF_INPUT_HTTPS_OAUTH2_TOKEN_URL"
+	inputHTTPSOAuth2ClientIDEnvKey     = "BUF_INPUT_HTTPS_OAUTH2_CLIENT_ID"
+	inputHTTPSOAuth2ClientSecretEnvKey = "BUF_INPUT_HTTPS_OAUTH2_CLIENT_SECRET"
+	inputHTTPSOAuth2ScopesEnvKey       = "BUF_INPUT_HTTPS_OAUTH2_SCOPES"
+	inputHTTPSOAuth2HostsEnvKey        = "BUF_INPUT_HTTPS_OAUTH2_HOSTS"
+	inputSSHKeyFileEnvKey              = "BUF_INPUT_SSH_KEY_FILE"
+	inputSSHKnownHostsFilesEnvKey      = "BUF_INPUT_SSH_KNOWN_HOSTS_FILES"
+	inputMirrorsEnvKey                 = "BUF_INPUT_MIRRORS"
+	githubTokenEnvKey                  = "GITHUB_TOKEN"
+
+	// debugRPCEnvKey is the environment variable that, if set, logs every RPC to the
+	// Buf Schema Registry.
+	debugRPCEnvKey = "BUF_DEBUG_RPC"
+	// cacheStatsEnvKey is the environment variable that, if set, prints the module
+	// cache hits and misses of the invocation to stderr.
+	cacheStatsEnvKey = "BUF_CACHE_STATS"
 
 	alphaSuppressWarningsEnvKey = "BUF_ALPHA_SUPPRESS_WARNINGS"
 	betaSuppressWarningsEnvKey  = "BUF_BETA_SUPPRESS_WARNINGS"
@@ -78,8 +93,6 @@ const (
 	inputHashtagFlagName      = "__hashtag__"
 	inputHashtagFlagShortName = "#"
 
-	userPromptAttempts = 3
-
 	publicVisibility  = "public"
 	privateVisibility = "private"
 )
@@ -97,6 +110,21 @@ var (
 			inputHTTPSPasswordEnvKey,
 			inputHTTPSPasswordEnvKey,
 		),
+		// used for github:// inputs, which are fetched from the GitHub API
+		httpauth.NewBearerTokenEnvAuthenticator(
+			githubTokenEnvKey,
+			buffetch.GitHubAPIHost,
+		),
+		httpauth.NewOAuth2ClientCredentialsAuthenticator(
+			defaultHTTPClient,
+			httpauth.OAuth2ClientCredentialsEnvKeys{
+				TokenURLKey:     inputHTTPSOAuth2TokenURLEnvKey,
+				ClientIDKey:     inputHTTPSOAuth2ClientIDEnvKey,
+				ClientSecretKey: inputHTTPSOAuth2ClientSecretEnvKey,
+				ScopesKey:       inputHTTPSOAuth2ScopesEnvKey,
+				HostsKey:        inputHTTPSOAuth2HostsEnvKey,
+			},
+		),
 	)
 	// defaultGitClonerOptions defines the default git clone options.
 	defaultGitClonerOptions = git.ClonerOptions{
@@ -119,10 +147,24 @@ var (
 		v1CacheModuleLockRelDirPath,
 		v1CacheModuleSumRelDirPath,
 		v2CacheModuleRelDirPath,
+		v2CacheModuleLockRelDirPath,
+	}
+
+	// LegacyCacheModuleRelDirPaths are the directory paths concerning the module cache that were
+	// used by older versions of buf, and are no longer used.
+	//
+	// These are normalized.
+	// These are relative to container.CacheDirPath().
+	LegacyCacheModuleRelDirPaths = []string{
+		v1beta1CacheModuleDataRelDirPath,
+		v1beta1CacheModuleLockRelDirPath,
+		v1CacheModuleDataRelDirPath,
+		v1CacheModuleLockRelDirPath,
+		v1CacheModuleSumRelDirPath,
 	}
 
 	// ErrNotATTY is returned when an input io.Reader is not a TTY where it is expected.
-	ErrNotATTY = errors.New("reader was not a TTY as expected")
+	ErrNotATTY = appprompt.ErrNotATTY
 
 	// v1CacheModuleDataRelDirPath is the relative path to the cache directory where module data
 	// was stored in v1beta1.
@@ -158,6 +200,12 @@ var (
 	// This directory replaces the use of v1CacheModuleDataRelDirPath, v1CacheModuleLockRelDirPath, and
 	// v1CacheModuleSumRelDirPath with a cache implementation using content addressable storage.
 	v2CacheModuleRelDirPath = normalpath.Join("v2", "module")
+	// v2CacheModuleLockRelDirPath is the relative path to the cache directory where module lock files are stored.
+	//
+	// Normalized.
+	// These lock files are used to make sure that multiple buf processes do not corrupt the cache
+	// or download the same module concurrently.
+	v2CacheModuleLockRelDirPath = normalpath.Join("v2", "lock", "module")
 
 	// allVisibiltyStrings are the possible options that a user can set the visibility flag with.
 	allVisibiltyStrings = []string{
@@ -229,6 +277,34 @@ func BindInputHashtag(flagSet *pflag.FlagSet, addr *string) {
 	_ = flagSet.MarkHidden(inputHashtagFlagName)
 }
 
+// BindStdinDiff binds the stdin-diff flag.
+func BindStdinDiff(flagSet *pflag.FlagSet, addr *bool, flagName string) {
+	flagSet.BoolVar(
+		addr,
+		flagName,
+		false,
+		`Read a unified diff from stdin, and only report violations on lines within the hunks of the diff
+This is useful to only report newly introduced violations, for example on pull requests
+Paths in the diff must be relative to the current directory, and are matched against the full paths of the violations`,
+	)
+}
+
+// ReadStdinDiff reads the unified diff from stdin for the stdin-diff flag.
+//
+// Returns an error if any of the inputs also read from stdin.
+func ReadStdinDiff(container app.StdinContainer, flagName string, inputs ...string) (*diffparse.ChangedLines, error) {
+	for _, input := range inputs {
+		if input == "-" || strings.HasPrefix(input, "-#") {
+			return nil, appcmd.NewInvalidArgumentErrorf("cannot read inputs from stdin when --%s is set", flagName)
+		}
+	}
+	changedLines, err := diffparse.ParseUnified(container.Stdin())
+	if err != nil {
+		return nil, fmt.Errorf("could not parse diff from stdin: %w", err)
+	}
+	return &changedLines, nil
+}
+
 // BindExcludePaths binds the exclude-path flag.
 func BindExcludePaths(
 	flagSet *pflag.FlagSet,
@@ -244,6 +320,21 @@ If specified multiple times, the union is taken`,
 	)
 }
 
+// BindModuleTags binds the module-tags flag.
+func BindModuleTags(
+	flagSet *pflag.FlagSet,
+	moduleTagsAddr *[]string,
+	moduleTagsFlagName string,
+) {
+	flagSet.StringSliceVar(
+		moduleTagsAddr,
+		moduleTagsFlagName,
+		nil,
+		`Limit to the workspace modules with any of the given tags, as set by "module_tags" in buf.work.yaml
+Only valid when the input is a workspace. If specified multiple times, the union is taken`,
+	)
+}
+
 // BindDisableSymlinks binds the disable-symlinks flag.
 func BindDisableSymlinks(flagSet *pflag.FlagSet, addr *bool, flagName string) {
 	flagSet.BoolVar(
@@ -255,6 +346,28 @@ By default, symlinks are followed in this CLI, but never followed on the Buf Sch
 	)
 }
 
+// BindYes binds the yes flag.
+func BindYes(flagSet *pflag.FlagSet, addr *bool, flagName string) {
+	flagSet.BoolVarP(
+		addr,
+		flagName,
+		"y",
+		false,
+		`Answer yes to all confirmation prompts. Use with caution`,
+	)
+}
+
+// BindNonInteractive binds the non-interactive flag.
+func BindNonInteractive(flagSet *pflag.FlagSet, addr *bool, flagName string) {
+	flagSet.BoolVar(
+		addr,
+		flagName,
+		false,
+		`Never prompt for input, and fail if input is required
+Confirmation prompts can still be answered with --yes`,
+	)
+}
+
 // BindVisibility binds the visibility flag.
 func BindVisibility(flagSet *pflag.FlagSet, addr *string, flagName string) {
 	flagSet.StringVar(
@@ -377,6 +490,26 @@ func NewStorageosProvider(disableSymlinks bool) storageos.Provider {
 	return storageos.NewProvider(storageos.ProviderWithSymlinks())
 }
 
+// NewRefParser returns a new buffetch.RefParser with the input mirrors
+// configured by the $BUF_INPUT_MIRRORS environment variable.
+func NewRefParser(container appflag.Container) (buffetch.RefParser, error) {
+	options, err := newRefParserOptions(container)
+	if err != nil {
+		return nil, err
+	}
+	return buffetch.NewRefParser(container.Logger(), options...), nil
+}
+
+// NewSourceRefParser returns a new buffetch.SourceRefParser with the input mirrors
+// configured by the $BUF_INPUT_MIRRORS environment variable.
+func NewSourceRefParser(container appflag.Container) (buffetch.SourceRefParser, error) {
+	options, err := newRefParserOptions(container)
+	if err != nil {
+		return nil, err
+	}
+	return buffetch.NewSourceRefParser(container.Logger(), options...), nil
+}
+
 // NewWireImageConfigReader returns a new ImageConfigReader.
 func NewWireImageConfigReader(
 	container appflag.Container,
@@ -396,7 +529,7 @@ func NewWireImageConfigReader(
 	return bufwire.NewImageConfigReader(
 		logger,
 		storageosProvider,
-		NewFetchReader(logger, storageosProvider, runner, moduleResolver, moduleReader),
+		NewFetchReader(logger, storageosProvider, runner, container.ProgressReporter(), moduleResolver, moduleReader),
 		bufmodulebuild.NewModuleBucketBuilder(),
 		bufimagebuild.NewBuilder(logger, moduleReader),
 	), nil
@@ -421,7 +554,7 @@ func NewWireModuleConfigReader(
 	return bufwire.NewModuleConfigReader(
 		logger,
 		storageosProvider,
-		NewFetchReader(logger, storageosProvider, runner, moduleResolver, moduleReader),
+		NewFetchReader(logger, storageosProvider, runner, container.ProgressReporter(), moduleResolver, moduleReader),
 		bufmodulebuild.NewModuleBucketBuilder(),
 	), nil
 }
@@ -443,7 +576,7 @@ func NewWireModuleConfigReaderForModuleReader(
 	return bufwire.NewModuleConfigReader(
 		logger,
 		storageosProvider,
-		NewFetchReader(logger, storageosProvider, runner, moduleResolver, moduleReader),
+		NewFetchReader(logger, storageosProvider, runner, container.ProgressReporter(), moduleResolver, moduleReader),
 		bufmodulebuild.NewModuleBucketBuilder(),
 	), nil
 }
@@ -467,7 +600,7 @@ func NewWireFileLister(
 	return bufwire.NewFileLister(
 		logger,
 		storageosProvider,
-		NewFetchReader(logger, storageosProvider, runner, moduleResolver, moduleReader),
+		NewFetchReader(logger, storageosProvider, runner, container.ProgressReporter(), moduleResolver, moduleReader),
 		bufmodulebuild.NewModuleBucketBuilder(),
 		bufimagebuild.NewBuilder(logger, moduleReader),
 	), nil
@@ -481,7 +614,7 @@ func NewWireImageReader(
 ) bufwire.ImageReader {
 	return bufwire.NewImageReader(
 		logger,
-		newFetchMessageReader(logger, storageosProvider, runner),
+		newFetchMessageReader(logger, storageosProvider, runner, progress.NopReporter),
 	)
 }
 
@@ -497,6 +630,16 @@ func NewWireImageWriter(
 	)
 }
 
+// NewFetchMessageReader returns a new buffetch.MessageReader with the default HTTP
+// client and git cloner.
+func NewFetchMessageReader(
+	logger *zap.Logger,
+	storageosProvider storageos.Provider,
+	runner command.Runner,
+) buffetch.MessageReader {
+	return newFetchMessageReader(logger, storageosProvider, runner, progress.NopReporter)
+}
+
 // NewWireProtoEncodingReader returns a new ProtoEncodingReader.
 func NewWireProtoEncodingReader(
 	logger *zap.Logger,
@@ -505,7 +648,7 @@ func NewWireProtoEncodingReader(
 ) bufwire.ProtoEncodingReader {
 	return bufwire.NewProtoEncodingReader(
 		logger,
-		newFetchMessageReader(logger, storageosProvider, runner),
+		newFetchMessageReader(logger, storageosProvider, runner, progress.NopReporter),
 	)
 }
 
@@ -521,6 +664,21 @@ func NewWireProtoEncodingWriter(
 	)
 }
 
+// ModuleCacheDirPath returns the path to the directory where modules are cached.
+func ModuleCacheDirPath(container appflag.Container) string {
+	return normalpath.Unnormalize(normalpath.Join(container.CacheDirPath(), v2CacheModuleRelDirPath))
+}
+
+// NewModuleCacheLocker returns a new Locker for the modules cached within
+// ModuleCacheDirPath, while creating the directory of the lock files.
+func NewModuleCacheLocker(container appflag.Container) (filelock.Locker, error) {
+	cacheModuleLockDirPathV2 := normalpath.Join(container.CacheDirPath(), v2CacheModuleLockRelDirPath)
+	if err := createCacheDirs(cacheModuleLockDirPathV2); err != nil {
+		return nil, err
+	}
+	return filelock.NewLocker(cacheModuleLockDirPathV2)
+}
+
 // NewModuleReaderAndCreateCacheDirs returns a new ModuleReader while creating the
 // required cache directories.
 func NewModuleReaderAndCreateCacheDirs(
@@ -535,10 +693,15 @@ func newModuleReaderAndCreateCacheDirs(
 	clientConfig *connectclient.Config,
 ) (bufmodule.ModuleReader, error) {
 	cacheModuleDirPathV2 := normalpath.Join(container.CacheDirPath(), v2CacheModuleRelDirPath)
-	if err := checkExistingCacheDirs(container.CacheDirPath(), cacheModuleDirPathV2); err != nil {
+	cacheModuleLockDirPathV2 := normalpath.Join(container.CacheDirPath(), v2CacheModuleLockRelDirPath)
+	if err := checkExistingCacheDirs(container.CacheDirPath(), cacheModuleDirPathV2, cacheModuleLockDirPathV2); err != nil {
 		return nil, err
 	}
-	if err := createCacheDirs(cacheModuleDirPathV2); err != nil {
+	if err := createCacheDirs(cacheModuleDirPathV2, cacheModuleLockDirPathV2); err != nil {
+		return nil, err
+	}
+	fileLocker, err := filelock.NewLocker(cacheModuleLockDirPathV2)
+	if err != nil {
 		return nil, err
 	}
 	delegateReader := bufapimodule.NewModuleReader(
@@ -547,6 +710,9 @@ func newModuleReaderAndCreateCacheDirs(
 		bufapimodule.ModuleReaderWithDeprecationWarning(
 			bufapimodule.NewRepositoryServiceClientFactory(clientConfig),
 		),
+		bufapimodule.ModuleReaderWithPartialDownload(
+			bufapimodule.NewDocServiceClientFactory(clientConfig),
+		),
 	)
 	storageosProvider := storageos.NewProvider(storageos.ProviderWithSymlinks())
 	var moduleReader bufmodule.ModuleReader
@@ -557,8 +723,11 @@ func newModuleReaderAndCreateCacheDirs(
 	moduleReader = bufmodulecache.NewModuleReader(
 		container.Logger(),
 		container.VerbosePrinter(),
+		container.ProgressReporter(),
 		casModuleBucket,
+		fileLocker,
 		delegateReader,
+		bufmodulecache.ModuleReaderWithCacheStatsRecorder(telemetryCacheStats),
 	)
 	return moduleReader, nil
 }
@@ -582,7 +751,26 @@ func newConnectClientConfigWithOptions(container appflag.Container, opts ...conn
 	if err != nil {
 		return nil, err
 	}
-	client := httpclient.NewClient(config.TLS)
+	client := httpclient.NewClient(
+		config.TLS,
+		httpclient.WithCircuitBreaker(
+			config.CircuitBreakerFailureThreshold,
+			config.CircuitBreakerOpenDuration,
+			config.CircuitBreakerAttemptTimeout,
+		),
+		httpclient.WithMirrors(config.RegistryMirrors),
+	)
+	var interceptors []connect.Interceptor
+	if container.Env(debugRPCEnvKey) != "" {
+		// First so that it measures and logs the entire call.
+		interceptors = append(interceptors, bufconnect.NewDebugLoggingInterceptor(container))
+	}
+	interceptors = append(
+		interceptors,
+		bufconnect.NewSetCLIVersionInterceptor(Version),
+		bufconnect.NewCLIWarningInterceptor(container),
+		otelconnectInterceptor,
+	)
 	options := []connectclient.ConfigOption{
 		connectclient.WithAddressMapper(func(address string) string {
 			if config.TLS == nil {
@@ -590,11 +778,7 @@ func newConnectClientConfigWithOptions(container appflag.Container, opts ...conn
 			}
 			return buftransport.PrependHTTPS(address)
 		}),
-		connectclient.WithInterceptors([]connect.Interceptor{
-			bufconnect.NewSetCLIVersionInterceptor(Version),
-			bufconnect.NewCLIWarningInterceptor(container),
-			otelconnectInterceptor,
-		}),
+		connectclient.WithInterceptors(interceptors),
 	}
 	options = append(options, opts...)
 
@@ -635,51 +819,66 @@ func NewConnectClientConfigWithToken(container appflag.Container, token string)
 
 // NewFetchReader creates a new buffetch.Reader with the default HTTP client
 // and git cloner.
+//
+// The progress of HTTP downloads and git clones is reported to the progressReporter.
 func NewFetchReader(
 	logger *zap.Logger,
 	storageosProvider storageos.Provider,
 	runner command.Runner,
+	progressReporter progress.Reporter,
 	moduleResolver bufmodule.ModuleResolver,
 	moduleReader bufmodule.ModuleReader,
 ) buffetch.Reader {
 	return buffetch.NewReader(
 		logger,
 		storageosProvider,
-		defaultHTTPClient,
+		newFetchHTTPClient(progressReporter),
 		defaultHTTPAuthenticator,
-		git.NewCloner(logger, storageosProvider, runner, defaultGitClonerOptions),
+		newFetchGitCloner(logger, storageosProvider, runner, progressReporter),
 		moduleResolver,
 		moduleReader,
 	)
 }
 
+// NewPrompter returns a new Prompter for the container.
+//
+// The yes and nonInteractive values should come from the flags bound by
+// BindYes and BindNonInteractive.
+func NewPrompter(container app.Container, yes bool, nonInteractive bool) appprompt.Prompter {
+	options := []appprompt.PrompterOption{
+		appprompt.PrompterWithIOErrorWrapper(NewInternalError),
+	}
+	if yes {
+		options = append(options, appprompt.PrompterWithAssumeYes())
+	}
+	if nonInteractive {
+		options = append(options, appprompt.PrompterWithNonInteractive())
+	}
+	return appprompt.NewPrompter(container, options...)
+}
+
 // PromptUserForDelete is used to receive user confirmation that a specific
 // entity should be deleted. If the user's answer does not match the expected
 // answer, an error is returned.
 // ErrNotATTY is returned if the input containers Stdin is not a terminal.
-func PromptUserForDelete(container app.Container, entityType string, expectedAnswer string) error {
-	confirmation, err := PromptUser(
-		container,
+func PromptUserForDelete(prompter appprompt.Prompter, entityType string, expectedAnswer string) error {
+	if err := prompter.ConfirmValue(
 		fmt.Sprintf(
 			"Please confirm that you want to DELETE this %s by entering its name (%s) again."+
 				"\nWARNING: This action is NOT reversible!\n",
 			entityType,
 			expectedAnswer,
 		),
-	)
-	if err != nil {
+		expectedAnswer,
+	); err != nil {
 		if errors.Is(err, ErrNotATTY) {
-			return errors.New("cannot perform an interactive delete from a non-TTY device")
+			return errors.New("cannot perform an interactive delete from a non-TTY device, use --yes to delete without confirming")
+		}
+		if errors.Is(err, appprompt.ErrNonInteractive) {
+			return errors.New("cannot confirm delete with --non-interactive, use --yes to delete without confirming")
 		}
 		return err
 	}
-	if confirmation != expectedAnswer {
-		return fmt.Errorf(
-			"expected %q, but received %q",
-			expectedAnswer,
-			confirmation,
-		)
-	}
 	return nil
 }
 
@@ -687,14 +886,14 @@ func PromptUserForDelete(container app.Container, entityType string, expectedAns
 // The prompt is repeatedly shown until the user provides a non-empty response.
 // ErrNotATTY is returned if the input containers Stdin is not a terminal.
 func PromptUser(container app.Container, prompt string) (string, error) {
-	return promptUser(container, prompt, false)
+	return NewPrompter(container, false, false).Input(prompt)
 }
 
 // PromptUserForPassword reads a line from Stdin, prompting the user with the prompt first.
 // The prompt is repeatedly shown until the user provides a non-empty response.
 // ErrNotATTY is returned if the input containers Stdin is not a terminal.
 func PromptUserForPassword(container app.Container, prompt string) (string, error) {
-	return promptUser(container, prompt, true)
+	return NewPrompter(container, false, false).Secret(prompt)
 }
 
 // BucketAndConfigForSource returns a bucket and config. The bucket contains
@@ -711,8 +910,13 @@ func BucketAndConfigForSource(
 	runner command.Runner,
 	source string,
 ) (storage.ReadBucketCloser, *bufconfig.Config, error) {
+	refParserOptions, err := newRefParserOptions(container)
+	if err != nil {
+		return nil, nil, err
+	}
 	sourceRef, err := buffetch.NewSourceRefParser(
 		logger,
+		refParserOptions...,
 	).GetSourceRef(
 		ctx,
 		source,
@@ -724,6 +928,7 @@ func BucketAndConfigForSource(
 		logger,
 		storageosProvider,
 		runner,
+		progress.NopReporter,
 	).GetSourceBucket(
 		ctx,
 		container,
@@ -767,8 +972,13 @@ func NewImageForSource(
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
 	excludeSourceCodeInfo bool,
+	options ...bufwire.GetImageConfigsOption,
 ) (bufimage.Image, error) {
-	ref, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, source)
+	refParser, err := NewRefParser(container)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refParser.GetRef(ctx, source)
 	if err != nil {
 		return nil, err
 	}
@@ -796,6 +1006,7 @@ func NewImageForSource(
 		externalExcludeDirOrFilePaths,
 		externalDirOrFilePathsAllowNotExist,
 		excludeSourceCodeInfo,
+		options...,
 	)
 	if err != nil {
 		return nil, err
@@ -929,68 +1140,14 @@ func validateErrorFormatFlag(validFormatStrings []string, errorFormatString stri
 	return appcmd.NewInvalidArgumentErrorf("--%s: invalid format: %q", errorFormatFlagName, errorFormatString)
 }
 
-// promptUser reads a line from Stdin, prompting the user with the prompt first.
-// The prompt is repeatedly shown until the user provides a non-empty response.
-// ErrNotATTY is returned if the input containers Stdin is not a terminal.
-func promptUser(container app.Container, prompt string, isPassword bool) (string, error) {
-	file, ok := container.Stdin().(*os.File)
-	if !ok || !term.IsTerminal(int(file.Fd())) {
-		return "", ErrNotATTY
-	}
-	var attempts int
-	for attempts < userPromptAttempts {
-		attempts++
-		if _, err := fmt.Fprint(
-			container.Stdout(),
-			prompt,
-		); err != nil {
-			return "", NewInternalError(err)
-		}
-		var value string
-		if isPassword {
-			data, err := term.ReadPassword(int(file.Fd()))
-			if err != nil {
-				// If the user submitted an EOF (e.g. via ^D) then we
-				// should not treat it as an internal error; returning
-				// the error directly makes it more clear as to
-				// why the command failed.
-				if errors.Is(err, io.EOF) {
-					return "", err
-				}
-				return "", NewInternalError(err)
-			}
-			value = string(data)
-		} else {
-			scanner := bufio.NewScanner(container.Stdin())
-			if !scanner.Scan() {
-				// scanner.Err() returns nil on EOF.
-				if err := scanner.Err(); err != nil {
-					return "", NewInternalError(err)
-				}
-				return "", io.EOF
-			}
-			value = scanner.Text()
-			if err := scanner.Err(); err != nil {
-				return "", NewInternalError(err)
-			}
-		}
-		if len(strings.TrimSpace(value)) != 0 {
-			// We want to preserve spaces in user input, so we only apply
-			// strings.TrimSpace to verify an answer was provided.
-			return value, nil
-		}
-		if attempts < userPromptAttempts {
-			// We only want to ask the user to try again if they actually
-			// have another attempt.
-			if _, err := fmt.Fprintln(
-				container.Stdout(),
-				"No answer was provided. Please try again.",
-			); err != nil {
-				return "", NewInternalError(err)
-			}
-		}
+// newRefParserOptions returns the buffetch.RefParserOptions for the input mirrors
+// configured by the $BUF_INPUT_MIRRORS environment variable.
+func newRefParserOptions(container app.EnvContainer) ([]buffetch.RefParserOption, error) {
+	options, err := buffetch.ParseMirrors(container.Env(inputMirrorsEnvKey))
+	if err != nil {
+		return nil, appcmd.NewInvalidArgumentErrorf("$%s: %v", inputMirrorsEnvKey, err)
 	}
-	return "", NewTooManyEmptyAnswersError(userPromptAttempts)
+	return options, nil
 }
 
 // newFetchSourceReader creates a new buffetch.SourceReader with the default HTTP client
@@ -999,13 +1156,14 @@ func newFetchSourceReader(
 	logger *zap.Logger,
 	storageosProvider storageos.Provider,
 	runner command.Runner,
+	progressReporter progress.Reporter,
 ) buffetch.SourceReader {
 	return buffetch.NewSourceReader(
 		logger,
 		storageosProvider,
-		defaultHTTPClient,
+		newFetchHTTPClient(progressReporter),
 		defaultHTTPAuthenticator,
-		git.NewCloner(logger, storageosProvider, runner, defaultGitClonerOptions),
+		newFetchGitCloner(logger, storageosProvider, runner, progressReporter),
 	)
 }
 
@@ -1015,16 +1173,38 @@ func newFetchMessageReader(
 	logger *zap.Logger,
 	storageosProvider storageos.Provider,
 	runner command.Runner,
+	progressReporter progress.Reporter,
 ) buffetch.MessageReader {
 	return buffetch.NewMessageReader(
 		logger,
 		storageosProvider,
-		defaultHTTPClient,
+		newFetchHTTPClient(progressReporter),
 		defaultHTTPAuthenticator,
-		git.NewCloner(logger, storageosProvider, runner, defaultGitClonerOptions),
+		newFetchGitCloner(logger, storageosProvider, runner, progressReporter),
 	)
 }
 
+// newFetchHTTPClient returns the default HTTP client, reporting the progress of
+// downloads to the progressReporter.
+func newFetchHTTPClient(progressReporter progress.Reporter) *http.Client {
+	return &http.Client{
+		Transport: progress.NewTransport(defaultHTTPClient.Transport, progressReporter),
+	}
+}
+
+// newFetchGitCloner returns a git.Cloner with the default options, reporting the
+// progress of fetches to the progressReporter.
+func newFetchGitCloner(
+	logger *zap.Logger,
+	storageosProvider storageos.Provider,
+	runner command.Runner,
+	progressReporter progress.Reporter,
+) git.Cloner {
+	options := defaultGitClonerOptions
+	options.ProgressReporter = progressReporter
+	return git.NewCloner(logger, storageosProvider, runner, options)
+}
+
 func checkExistingCacheDirs(baseCacheDirPath string, dirPaths ...string) error {
 	dirPathsToCheck := make([]string, 0, len(dirPaths)+1)
 	// Check base cache directory in addition to subdirectories
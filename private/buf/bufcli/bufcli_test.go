@@ -16,13 +16,16 @@ package bufcli_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
+	"connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
@@ -241,3 +244,18 @@ func testBucketAndConfigForSource(
 		}
 	})
 }
+
+func TestExitCodeSchemeUnavailableRemote(t *testing.T) {
+	t.Parallel()
+	exitCodeScheme := bufcli.ExitCodeSchemeDetailed
+	run := bufcli.NewExitCodeInterceptor(&exitCodeScheme)(
+		bufcli.NewErrorInterceptor()(
+			func(context.Context, appflag.Container) error {
+				return connect.NewError(connect.CodeUnavailable, errors.New("connection refused"))
+			},
+		),
+	)
+	err := run(context.Background(), nil)
+	assert.Equal(t, bufcli.ExitCodeNetwork, app.GetExitCode(err))
+	assert.EqualError(t, err, "Failure: the server hosted at that remote is unavailable.")
+}
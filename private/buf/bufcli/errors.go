@@ -94,12 +94,6 @@ func NewErrorInterceptor() appflag.Interceptor {
 	}
 }
 
-// NewTooManyEmptyAnswersError is used when the user does not answer a prompt in
-// the given number of attempts.
-func NewTooManyEmptyAnswersError(attempts int) error {
-	return fmt.Errorf("did not receive an answer in %d attempts", attempts)
-}
-
 // NewOrganizationNameAlreadyExistsError informs the user that an organization with
 // that name already exists.
 func NewOrganizationNameAlreadyExistsError(name string) error {
@@ -176,14 +170,14 @@ func wrapError(err error) error {
 			// If the returned error is Unavailable, then determine if this is a DNS error.  If so, get the address used
 			// so that we can display a more helpful error message.
 			if dnsError := (&net.DNSError{}); errors.As(err, &dnsError) && dnsError.IsNotFound {
-				return fmt.Errorf(`%s Are you sure "%s" is a valid remote address?`, msg, dnsError.Name)
+				return newUnavailableError(fmt.Sprintf(`%s Are you sure "%s" is a valid remote address?`, msg, dnsError.Name), err)
 			}
 			// If the unavailable error wraps a tls.CertificateVerificationError, show a more specific error message
 			// to the user to aid in troubleshooting.
 			if tlsErr := wrappedTLSError(err); tlsErr != nil {
-				return fmt.Errorf("tls certificate verification: %w", tlsErr)
+				return newUnavailableError("tls certificate verification: "+tlsErr.Error(), err)
 			}
-			return errors.New(msg)
+			return newUnavailableError(msg, err)
 		}
 		err = connectErr.Unwrap()
 	}
@@ -192,6 +186,31 @@ func wrapError(err error) error {
 	return fmt.Errorf("Failure: %w", err)
 }
 
+// unavailableError is an error for a remote that is unavailable.
+//
+// The message replaces the message of the original error, but the original error
+// can still be recovered via 'errors.As', so that it can be classified as a network
+// error by the exit code interceptor.
+type unavailableError struct {
+	message string
+	err     error
+}
+
+func newUnavailableError(message string, err error) *unavailableError {
+	return &unavailableError{
+		message: message,
+		err:     err,
+	}
+}
+
+func (u *unavailableError) Error() string {
+	return u.message
+}
+
+func (u *unavailableError) Unwrap() error {
+	return u.err
+}
+
 // asConnectError uses errors.As to unwrap any error and look for a *connect.Error.
 func asConnectError(err error) (*connect.Error, bool) {
 	var connectErr *connect.Error
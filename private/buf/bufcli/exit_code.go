@@ -0,0 +1,158 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcli
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/pflag"
+)
+
+// The exit codes of the detailed and report-only exit code schemes.
+//
+// With the default scheme, every error exits with 1, except for errors for which
+// file annotations were printed, which exit with ExitCodeFileAnnotation.
+//
+// These values are stable and must not be changed.
+const (
+	// ExitCodeInvalidConfig is the exit code used when a buf.yaml cannot be parsed or is not valid.
+	ExitCodeInvalidConfig = 2
+	// ExitCodeNetwork is the exit code used when a remote could not be reached.
+	ExitCodeNetwork = 3
+	// ExitCodeLintViolation is the exit code used when lint violations were found.
+	ExitCodeLintViolation = 101
+	// ExitCodeBreakingViolation is the exit code used when breaking changes were found.
+	ExitCodeBreakingViolation = 102
+)
+
+const (
+	// ExitCodeSchemeDefault is the default exit code scheme.
+	ExitCodeSchemeDefault = "default"
+	// ExitCodeSchemeDetailed is the exit code scheme that uses a distinct exit code
+	// for each kind of failure.
+	ExitCodeSchemeDetailed = "detailed"
+	// ExitCodeSchemeReportOnly is the exit code scheme that is the same as the detailed
+	// scheme, except that lint violations and breaking changes exit with 0.
+	ExitCodeSchemeReportOnly = "report-only"
+
+	exitCodeSchemeFlagName = "exit-code-scheme"
+)
+
+var (
+	// AllExitCodeSchemes are all exit code schemes.
+	AllExitCodeSchemes = []string{
+		ExitCodeSchemeDefault,
+		ExitCodeSchemeDetailed,
+		ExitCodeSchemeReportOnly,
+	}
+
+	// ErrLintViolation is used when we print lint violations and want to return an error.
+	//
+	// This exits with ExitCodeFileAnnotation with the default exit code scheme.
+	ErrLintViolation = app.NewError(ExitCodeFileAnnotation, "")
+	// ErrBreakingViolation is used when we print breaking changes and want to return an error.
+	//
+	// This exits with ExitCodeFileAnnotation with the default exit code scheme.
+	ErrBreakingViolation = app.NewError(ExitCodeFileAnnotation, "")
+)
+
+// BindExitCodeScheme binds the exit-code-scheme flag.
+func BindExitCodeScheme(flagSet *pflag.FlagSet, addr *string) {
+	flagSet.StringVar(
+		addr,
+		exitCodeSchemeFlagName,
+		ExitCodeSchemeDefault,
+		`The exit codes to use. Must be one of `+stringutil.SliceToString(AllExitCodeSchemes)+`.
+default exits with 100 if file annotations such as compile errors, lint violations, or breaking
+changes were printed, and 1 for any other error.
+detailed exits with 2 for an invalid buf.yaml, 3 if a remote could not be reached, 100 for
+compile errors and other file annotations, 101 for lint violations, 102 for breaking changes,
+and 1 for any other error.
+report-only is the same as detailed, except that lint violations and breaking changes exit with 0`,
+	)
+}
+
+// NewExitCodeInterceptor returns a CLI interceptor that sets the exit codes of the
+// errors of commands according to the exit code scheme.
+//
+// The scheme is read when the command is run, so that it can be bound to a flag
+// with BindExitCodeScheme.
+func NewExitCodeInterceptor(exitCodeScheme *string) appflag.Interceptor {
+	return func(next func(context.Context, appflag.Container) error) func(context.Context, appflag.Container) error {
+		return func(ctx context.Context, container appflag.Container) error {
+			switch *exitCodeScheme {
+			case ExitCodeSchemeDefault, ExitCodeSchemeDetailed, ExitCodeSchemeReportOnly:
+			default:
+				return appcmd.NewInvalidArgumentErrorf(
+					"--%s: must be one of %s, got %q",
+					exitCodeSchemeFlagName,
+					stringutil.SliceToString(AllExitCodeSchemes),
+					*exitCodeScheme,
+				)
+			}
+			return errorForExitCodeScheme(*exitCodeScheme, next(ctx, container))
+		}
+	}
+}
+
+// errorForExitCodeScheme returns the error with the exit code of the scheme.
+//
+// Errors that are not classified keep their exit code.
+func errorForExitCodeScheme(exitCodeScheme string, err error) error {
+	if err == nil || exitCodeScheme == ExitCodeSchemeDefault {
+		return err
+	}
+	var violationExitCode int
+	switch {
+	case errors.Is(err, ErrLintViolation):
+		violationExitCode = ExitCodeLintViolation
+	case errors.Is(err, ErrBreakingViolation):
+		violationExitCode = ExitCodeBreakingViolation
+	case errors.Is(err, ErrFileAnnotation):
+		return err
+	case bufconfig.IsInvalidConfigError(err):
+		return app.WrapError(ExitCodeInvalidConfig, err)
+	case isNetworkError(err):
+		return app.WrapError(ExitCodeNetwork, err)
+	default:
+		return err
+	}
+	if exitCodeScheme == ExitCodeSchemeReportOnly {
+		// The violations were already printed.
+		return nil
+	}
+	return app.WrapError(violationExitCode, err)
+}
+
+// isNetworkError returns true if the error is, or wraps, an error for a remote that
+// could not be reached.
+func isNetworkError(err error) bool {
+	if connectErr, ok := asConnectError(err); ok {
+		switch connectErr.Code() {
+		case connect.CodeUnavailable, connect.CodeDeadlineExceeded:
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,152 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bufbuild/buf/private/buf/bufapp"
+	"github.com/bufbuild/buf/private/buf/buftelemetry"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/app/appname"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/transport/http/httpclient"
+	"go.uber.org/zap"
+)
+
+const (
+	// telemetryEventsRelFilePath is the relative path to the file within the cache
+	// directory where telemetry events are recorded.
+	//
+	// Normalized.
+	telemetryEventsRelFilePath = "v1/telemetry/events.jsonl"
+	// telemetrySendTimeout is the maximum time spent sending an event to a configured
+	// telemetry endpoint.
+	telemetrySendTimeout = 2 * time.Second
+	// telemetryFlushTimeout is the maximum time a command waits for an event to be
+	// sent before returning. The event is sent in the background, so a slow endpoint
+	// does not delay the exit of the command by more than this.
+	telemetryFlushTimeout = 200 * time.Millisecond
+)
+
+// telemetryCacheStats records module cache hits and misses for telemetry.
+//
+// This is global as the module readers are created deep within commands, and only
+// a single command is run per process.
+var telemetryCacheStats = buftelemetry.NewCacheStats()
+
+// TelemetryEventsFilePath returns the path to the file where telemetry events are recorded.
+func TelemetryEventsFilePath(container appflag.Container) string {
+	return normalpath.Unnormalize(normalpath.Join(container.CacheDirPath(), telemetryEventsRelFilePath))
+}
+
+// NewTelemetryInterceptor returns a new Interceptor that records the usage of commands
+// if telemetry is enabled in the user configuration.
+//
+// Usage is recorded locally, and is only sent to an endpoint if one is configured.
+// Failures to record usage are logged at debug level and never fail the command.
+func NewTelemetryInterceptor() appflag.Interceptor {
+	return func(next func(context.Context, appflag.Container) error) func(context.Context, appflag.Container) error {
+		return func(ctx context.Context, container appflag.Container) error {
+			// Only read the configuration file here. Building the full configuration loads
+			// the TLS configuration, which we do not want to pay for on every command when
+			// telemetry is disabled.
+			externalConfig := bufapp.ExternalConfig{}
+			if err := appname.ReadConfig(container, &externalConfig); err != nil || !externalConfig.Telemetry.Enabled {
+				// Errors reading the configuration are surfaced by the command itself.
+				return next(ctx, container)
+			}
+			start := time.Now()
+			cacheHits, cacheMisses := telemetryCacheStats.Hits(), telemetryCacheStats.Misses()
+			runErr := next(ctx, container)
+			event := &buftelemetry.Event{
+				Command:     appcmd.CommandPath(ctx),
+				Time:        start.UTC(),
+				Duration:    time.Since(start),
+				Success:     runErr == nil,
+				CacheHits:   telemetryCacheStats.Hits() - cacheHits,
+				CacheMisses: telemetryCacheStats.Misses() - cacheMisses,
+			}
+			if err := buftelemetry.AppendEvent(ctx, TelemetryEventsFilePath(container), event); err != nil {
+				container.Logger().Debug("failed to record telemetry", zap.Error(err))
+			}
+			if externalConfig.Telemetry.Endpoint != "" {
+				sendTelemetryEvent(container, event)
+			}
+			return runErr
+		}
+	}
+}
+
+// sendTelemetryEvent sends the event to the configured telemetry endpoint in the
+// background, and waits at most telemetryFlushTimeout for it to be sent.
+func sendTelemetryEvent(container appflag.Container, event *buftelemetry.Event) {
+	config, err := NewConfig(container)
+	if err != nil {
+		container.Logger().Debug("failed to send telemetry", zap.Error(err))
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The command context may already be done, for example if it timed out.
+		ctx, cancel := context.WithTimeout(context.Background(), telemetrySendTimeout)
+		defer cancel()
+		// Use the same TLS configuration and proxy settings as other requests.
+		if err := buftelemetry.SendEvent(ctx, httpclient.NewClient(config.TLS), config.TelemetryEndpoint, event); err != nil {
+			container.Logger().Debug("failed to send telemetry", zap.Error(err))
+		}
+	}()
+	timer := time.NewTimer(telemetryFlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		container.Logger().Debug("telemetry not sent before exit", zap.Duration("timeout", telemetryFlushTimeout))
+	}
+}
+
+// NewCacheStatsInterceptor returns a new Interceptor that prints the module cache
+// hits and misses of the invocation to stderr if BUF_CACHE_STATS is set.
+func NewCacheStatsInterceptor() appflag.Interceptor {
+	return func(next func(context.Context, appflag.Container) error) func(context.Context, appflag.Container) error {
+		return func(ctx context.Context, container appflag.Container) error {
+			if container.Env(cacheStatsEnvKey) == "" {
+				return next(ctx, container)
+			}
+			cacheHits, cacheMisses := telemetryCacheStats.Hits(), telemetryCacheStats.Misses()
+			runErr := next(ctx, container)
+			cacheHits = telemetryCacheStats.Hits() - cacheHits
+			cacheMisses = telemetryCacheStats.Misses() - cacheMisses
+			cacheHitRate := "-"
+			if total := cacheHits + cacheMisses; total > 0 {
+				cacheHitRate = fmt.Sprintf("%.1f%%", float64(cacheHits)/float64(total)*100)
+			}
+			if _, err := fmt.Fprintf(
+				container.Stderr(),
+				"module cache: %d hits, %d misses, %s hit rate\n",
+				cacheHits,
+				cacheMisses,
+				cacheHitRate,
+			); err != nil {
+				container.Logger().Debug("failed to print cache stats", zap.Error(err))
+			}
+			return runErr
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufcompat builds compatibility matrices of a producer module against its consumers.
+package bufcompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+)
+
+const (
+	// FormatMarkdown is the Markdown format.
+	FormatMarkdown Format = iota + 1
+	// FormatJSON is the JSON format.
+	FormatJSON
+)
+
+const (
+	// StatusCompatible says that the changes to the producer do not break the consumer.
+	StatusCompatible Status = iota + 1
+	// StatusBreaking says that the changes to the producer break the consumer.
+	StatusBreaking
+	// StatusNotConsumer says that the consumer does not use the producer.
+	StatusNotConsumer
+)
+
+var (
+	// AllFormatsString is the string representation of all Formats.
+	AllFormatsString = stringutil.SliceToString([]string{FormatMarkdown.String(), FormatJSON.String()})
+
+	formatToString = map[Format]string{
+		FormatMarkdown: "markdown",
+		FormatJSON:     "json",
+	}
+	stringToFormat = map[string]Format{
+		"markdown": FormatMarkdown,
+		"json":     FormatJSON,
+	}
+	statusToString = map[Status]string{
+		StatusCompatible:  "compatible",
+		StatusBreaking:    "breaking",
+		StatusNotConsumer: "not a consumer",
+	}
+)
+
+// Format is a format to print a Matrix in.
+type Format int
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	s, ok := formatToString[f]
+	if !ok {
+		return strconv.Itoa(int(f))
+	}
+	return s
+}
+
+// ParseFormat parses the Format.
+//
+// If the empty string is provided, this is interpreted as FormatMarkdown.
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return FormatMarkdown, nil
+	}
+	f, ok := stringToFormat[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown format: %q", s)
+	}
+	return f, nil
+}
+
+// Status is the compatibility of a producer with a consumer.
+type Status int
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	str, ok := statusToString[s]
+	if !ok {
+		return strconv.Itoa(int(s))
+	}
+	return str
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Row is the compatibility of a producer with a single consumer.
+type Row struct {
+	// Consumer is the name of the consumer, such as the path to its buf.lock file or image.
+	Consumer string `json:"consumer"`
+	// Version is the version of the producer that the consumer uses, such as a commit.
+	//
+	// May be empty.
+	Version string `json:"version,omitempty"`
+	Status  Status `json:"status"`
+	// Violations is the number of breaking changes.
+	Violations int `json:"violations"`
+	// Rules are the sorted IDs of the breaking rules that were violated.
+	Rules []string `json:"rules,omitempty"`
+}
+
+// NewRow returns a new Row for a consumer of the producer, given the breaking change
+// file annotations of the producer's changes against the version the consumer uses.
+func NewRow(consumer string, version string, fileAnnotations []bufanalysis.FileAnnotation) *Row {
+	row := &Row{
+		Consumer:   consumer,
+		Version:    version,
+		Status:     StatusCompatible,
+		Violations: len(fileAnnotations),
+	}
+	if len(fileAnnotations) == 0 {
+		return row
+	}
+	row.Status = StatusBreaking
+	rules := make(map[string]struct{})
+	for _, fileAnnotation := range fileAnnotations {
+		rules[fileAnnotation.Type()] = struct{}{}
+	}
+	for rule := range rules {
+		row.Rules = append(row.Rules, rule)
+	}
+	sort.Strings(row.Rules)
+	return row
+}
+
+// NewNotConsumerRow returns a new Row for a consumer that does not use the producer.
+func NewNotConsumerRow(consumer string) *Row {
+	return &Row{
+		Consumer: consumer,
+		Status:   StatusNotConsumer,
+	}
+}
+
+// Matrix is the compatibility of a producer with its consumers.
+type Matrix struct {
+	// Producer is the name of the producer, such as its module name.
+	Producer string `json:"producer"`
+	Rows     []*Row `json:"rows"`
+}
+
+// HasBreaking returns true if the changes to the producer break any consumer.
+func (m *Matrix) HasBreaking() bool {
+	for _, row := range m.Rows {
+		if row.Status == StatusBreaking {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteMatrix writes the Matrix in the Format.
+func WriteMatrix(writer io.Writer, format Format, matrix *Matrix) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMatrixMarkdown(writer, matrix)
+	case FormatJSON:
+		return json.NewEncoder(writer).Encode(matrix)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func writeMatrixMarkdown(writer io.Writer, matrix *Matrix) error {
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "## Compatibility of %s\n\n", matrix.Producer)
+	builder.WriteString("| Consumer | Version | Status | Violations | Rules |\n")
+	builder.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, row := range matrix.Rows {
+		_, _ = fmt.Fprintf(
+			&builder,
+			"| %s | %s | %s | %d | %s |\n",
+			escapeMarkdownTableCell(row.Consumer),
+			escapeMarkdownTableCell(row.Version),
+			row.Status.String(),
+			row.Violations,
+			escapeMarkdownTableCell(strings.Join(row.Rules, ", ")),
+		)
+	}
+	_, err := writer.Write([]byte(builder.String()))
+	return err
+}
+
+func escapeMarkdownTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,116 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcompat
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRow(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		&Row{
+			Consumer: "a/buf.lock",
+			Version:  "abc",
+			Status:   StatusCompatible,
+		},
+		NewRow("a/buf.lock", "abc", nil),
+	)
+	assert.Equal(
+		t,
+		&Row{
+			Consumer:   "b/buf.lock",
+			Version:    "def",
+			Status:     StatusBreaking,
+			Violations: 3,
+			Rules:      []string{"FIELD_NO_DELETE", "FIELD_SAME_TYPE"},
+		},
+		NewRow(
+			"b/buf.lock",
+			"def",
+			[]bufanalysis.FileAnnotation{
+				bufanalysis.NewFileAnnotation(nil, 0, 0, 0, 0, "FIELD_SAME_TYPE", ""),
+				bufanalysis.NewFileAnnotation(nil, 0, 0, 0, 0, "FIELD_NO_DELETE", ""),
+				bufanalysis.NewFileAnnotation(nil, 0, 0, 0, 0, "FIELD_NO_DELETE", ""),
+			},
+		),
+	)
+}
+
+func TestParseFormat(t *testing.T) {
+	t.Parallel()
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, format)
+	format, err = ParseFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+	_, err = ParseFormat("text")
+	assert.Error(t, err)
+}
+
+func TestWriteMatrix(t *testing.T) {
+	t.Parallel()
+	matrix := &Matrix{
+		Producer: "buf.build/acme/petapis",
+		Rows: []*Row{
+			NewRow("a/buf.lock", "abc", nil),
+			NewRow(
+				"b|c.binpb",
+				"",
+				[]bufanalysis.FileAnnotation{
+					bufanalysis.NewFileAnnotation(nil, 0, 0, 0, 0, "FIELD_NO_DELETE", ""),
+				},
+			),
+			NewNotConsumerRow("d/buf.lock"),
+		},
+	}
+	assert.True(t, matrix.HasBreaking())
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteMatrix(buffer, FormatMarkdown, matrix))
+	assert.Equal(
+		t,
+		`## Compatibility of buf.build/acme/petapis
+
+| Consumer | Version | Status | Violations | Rules |
+| --- | --- | --- | --- | --- |
+| a/buf.lock | abc | compatible | 0 |  |
+| b\|c.binpb |  | breaking | 1 | FIELD_NO_DELETE |
+| d/buf.lock |  | not a consumer | 0 |  |
+`,
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, WriteMatrix(buffer, FormatJSON, matrix))
+	assert.JSONEq(
+		t,
+		`{
+  "producer": "buf.build/acme/petapis",
+  "rows": [
+    {"consumer": "a/buf.lock", "version": "abc", "status": "compatible", "violations": 0},
+    {"consumer": "b|c.binpb", "status": "breaking", "violations": 1, "rules": ["FIELD_NO_DELETE"]},
+    {"consumer": "d/buf.lock", "status": "not a consumer", "violations": 0}
+  ]
+}`,
+		buffer.String(),
+	)
+	assert.False(t, (&Matrix{Rows: matrix.Rows[:1]}).HasBreaking())
+}
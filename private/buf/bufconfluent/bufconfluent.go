@@ -0,0 +1,119 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufconfluent registers and reads Protobuf schemas in a Confluent-compatible
+// schema registry.
+package bufconfluent
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// SchemaTypeProtobuf is the schema type of Protobuf schemas.
+const SchemaTypeProtobuf = "PROTOBUF"
+
+// Schema is a schema in a schema registry.
+type Schema struct {
+	// Subject is the subject the schema is registered under.
+	//
+	// Empty when registering a schema.
+	Subject string `json:"subject,omitempty"`
+	// Version is the version of the schema within the subject.
+	//
+	// Zero when registering a schema.
+	Version int `json:"version,omitempty"`
+	// ID is the globally unique ID of the schema.
+	//
+	// Zero when registering a schema.
+	ID int `json:"id,omitempty"`
+	// SchemaType is the type of the schema.
+	//
+	// Empty for Avro schemas.
+	SchemaType string `json:"schemaType,omitempty"`
+	// Schema is the content of the schema.
+	//
+	// For Protobuf schemas, this is either the text of a .proto file or a base64-encoded
+	// FileDescriptorProto.
+	Schema string `json:"schema"`
+	// References are the schemas that this schema imports.
+	References []*Reference `json:"references,omitempty"`
+}
+
+// Reference is a reference from a schema to another schema.
+type Reference struct {
+	// Name is the import path of the referenced schema.
+	Name string `json:"name"`
+	// Subject is the subject of the referenced schema.
+	Subject string `json:"subject"`
+	// Version is the version of the referenced schema within the subject.
+	Version int `json:"version"`
+}
+
+// Client is a client for a Confluent-compatible schema registry.
+type Client interface {
+	// RegisterSchema registers the schema under the subject.
+	//
+	// If the schema is already registered under the subject, the existing schema is returned.
+	// The returned schema has its subject, version, and ID set.
+	RegisterSchema(ctx context.Context, subject string, schema *Schema) (*Schema, error)
+	// GetSchema gets the schema with the version within the subject.
+	//
+	// The version may be "latest".
+	GetSchema(ctx context.Context, subject string, version string) (*Schema, error)
+}
+
+// NewClient returns a new Client for the schema registry at the address.
+//
+// The address is the base URL of the schema registry, such as https://registry.example.com.
+func NewClient(httpClient *http.Client, address string, options ...ClientOption) Client {
+	return newClient(httpClient, address, options...)
+}
+
+// ClientOption is an option for a new Client.
+type ClientOption func(*client)
+
+// ClientWithBasicAuth returns a new ClientOption that authenticates requests
+// with the username and password.
+func ClientWithBasicAuth(username string, password string) ClientOption {
+	return func(client *client) {
+		client.username = username
+		client.password = password
+	}
+}
+
+// Export registers the files in the schema registry, each under a subject named
+// after the file path.
+//
+// The dependencies of each file are registered before the file and are set as its
+// references, so that the schema registry can resolve imports. The well-known types
+// are built into schema registries and are not registered. All dependencies of the
+// files must be within the files.
+//
+// Returns the registered schemas in the order they were registered.
+func Export(ctx context.Context, client Client, fileDescriptorProtos []*descriptorpb.FileDescriptorProto) ([]*Schema, error) {
+	return export(ctx, client, fileDescriptorProtos)
+}
+
+// Import gets the schema with the version within the subject and all the schemas it
+// references, transitively.
+//
+// The schemas must be in .proto text format, which schema registries return by default.
+// The schema for the subject is returned under the path, and references are returned
+// under their names. The well-known types are not returned.
+func Import(ctx context.Context, client Client, subject string, version string, path string) (map[string][]byte, error) {
+	return importSubject(ctx, client, subject, version, path)
+}
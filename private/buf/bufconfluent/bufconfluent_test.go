@@ -0,0 +1,210 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfluent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	client := NewClient(server.Client(), server.URL+"/", ClientWithBasicAuth("user", "pass"))
+	fileDescriptorProtos := []*descriptorpb.FileDescriptorProto{
+		{
+			Name:       proto.String("acme/order/v1/order.proto"),
+			Package:    proto.String("acme.order.v1"),
+			Dependency: []string{"acme/common/v1/money.proto", "google/protobuf/timestamp.proto"},
+		},
+		{
+			Name:    proto.String("acme/common/v1/money.proto"),
+			Package: proto.String("acme.common.v1"),
+		},
+	}
+	schemas, err := Export(context.Background(), client, fileDescriptorProtos)
+	require.NoError(t, err)
+	require.Len(t, schemas, 2)
+	assert.Equal(t, "acme/common/v1/money.proto", schemas[0].Subject)
+	assert.Equal(t, 1, schemas[0].Version)
+	assert.Empty(t, schemas[0].References)
+	assert.Equal(t, "acme/order/v1/order.proto", schemas[1].Subject)
+	assert.Equal(t, 1, schemas[1].Version)
+	assert.Equal(
+		t,
+		[]*Reference{
+			{
+				Name:    "acme/common/v1/money.proto",
+				Subject: "acme/common/v1/money.proto",
+				Version: 1,
+			},
+		},
+		schemas[1].References,
+	)
+	assert.Equal(t, "user", registry.username)
+
+	// Registering the same schemas again returns the existing versions.
+	schemas, err = Export(context.Background(), client, fileDescriptorProtos)
+	require.NoError(t, err)
+	assert.Equal(t, 1, schemas[0].Version)
+	assert.Equal(t, 1, schemas[1].Version)
+
+	_, err = Export(context.Background(), client, fileDescriptorProtos[:1])
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	t.Parallel()
+	registry := newTestRegistry()
+	server := httptest.NewServer(registry)
+	t.Cleanup(server.Close)
+	client := NewClient(server.Client(), server.URL)
+	ctx := context.Background()
+	moneySchema, err := client.RegisterSchema(
+		ctx,
+		"money",
+		&Schema{
+			SchemaType: SchemaTypeProtobuf,
+			Schema:     `syntax = "proto3"; package acme.common.v1; message Money {}`,
+		},
+	)
+	require.NoError(t, err)
+	_, err = client.RegisterSchema(
+		ctx,
+		"orders-value",
+		&Schema{
+			SchemaType: SchemaTypeProtobuf,
+			Schema:     `syntax = "proto3"; package acme.order.v1; import "acme/common/v1/money.proto"; import "google/protobuf/timestamp.proto";`,
+			References: []*Reference{
+				{
+					Name:    "acme/common/v1/money.proto",
+					Subject: moneySchema.Subject,
+					Version: moneySchema.Version,
+				},
+				{
+					Name:    "google/protobuf/timestamp.proto",
+					Subject: "google/protobuf/timestamp.proto",
+					Version: 1,
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+	pathToData, err := Import(ctx, client, "orders-value", "latest", "acme/order/v1/order.proto")
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string][]byte{
+			"acme/order/v1/order.proto":  []byte(`syntax = "proto3"; package acme.order.v1; import "acme/common/v1/money.proto"; import "google/protobuf/timestamp.proto";`),
+			"acme/common/v1/money.proto": []byte(`syntax = "proto3"; package acme.common.v1; message Money {}`),
+		},
+		pathToData,
+	)
+	_, err = Import(ctx, client, "unknown", "latest", "unknown.proto")
+	assert.Error(t, err)
+}
+
+// testRegistry is a minimal in-memory Confluent-compatible schema registry.
+type testRegistry struct {
+	lock              sync.Mutex
+	subjectToVersions map[string][]*Schema
+	nextID            int
+	username          string
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{
+		subjectToVersions: make(map[string][]*Schema),
+		nextID:            1,
+	}
+}
+
+func (r *testRegistry) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.username, _, _ = request.BasicAuth()
+	parts := strings.Split(strings.TrimPrefix(request.URL.EscapedPath(), "/subjects/"), "/")
+	subject, err := url.PathUnescape(parts[0])
+	if err != nil {
+		http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+		return
+	}
+	versions := r.subjectToVersions[subject]
+	switch {
+	case request.Method == http.MethodGet && len(parts) == 3 && parts[1] == "versions":
+		if len(versions) == 0 {
+			writeTestError(responseWriter, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		version := len(versions)
+		if parts[2] != "latest" {
+			version, err = strconv.Atoi(parts[2])
+			if err != nil || version < 1 || version > len(versions) {
+				writeTestError(responseWriter, http.StatusNotFound, 40402, "Version not found.")
+				return
+			}
+		}
+		writeTestJSON(responseWriter, versions[version-1])
+	case request.Method == http.MethodPost:
+		schema := &Schema{}
+		if err := json.NewDecoder(request.Body).Decode(schema); err != nil {
+			http.Error(responseWriter, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, existing := range versions {
+			if existing.Schema == schema.Schema {
+				writeTestJSON(responseWriter, existing)
+				return
+			}
+		}
+		if len(parts) == 1 {
+			writeTestError(responseWriter, http.StatusNotFound, 40403, "Schema not found.")
+			return
+		}
+		schema.Subject = subject
+		schema.Version = len(versions) + 1
+		schema.ID = r.nextID
+		r.nextID++
+		r.subjectToVersions[subject] = append(versions, schema)
+		writeTestJSON(responseWriter, &Schema{ID: schema.ID})
+	default:
+		http.NotFound(responseWriter, request)
+	}
+}
+
+func writeTestJSON(responseWriter http.ResponseWriter, value interface{}) {
+	responseWriter.Header().Set("Content-Type", contentType)
+	_ = json.NewEncoder(responseWriter).Encode(value)
+}
+
+func writeTestError(responseWriter http.ResponseWriter, statusCode int, errorCode int, message string) {
+	responseWriter.Header().Set("Content-Type", contentType)
+	responseWriter.WriteHeader(statusCode)
+	_ = json.NewEncoder(responseWriter).Encode(map[string]interface{}{"error_code": errorCode, "message": message})
+}
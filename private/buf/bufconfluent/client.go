@@ -0,0 +1,123 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfluent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+type client struct {
+	httpClient *http.Client
+	address    string
+	username   string
+	password   string
+}
+
+func newClient(httpClient *http.Client, address string, options ...ClientOption) *client {
+	client := &client{
+		httpClient: httpClient,
+		address:    strings.TrimSuffix(address, "/"),
+	}
+	for _, option := range options {
+		option(client)
+	}
+	return client
+}
+
+func (c *client) RegisterSchema(ctx context.Context, subject string, schema *Schema) (*Schema, error) {
+	request := &Schema{
+		SchemaType: schema.SchemaType,
+		Schema:     schema.Schema,
+		References: schema.References,
+	}
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", request, &Schema{}); err != nil {
+		return nil, err
+	}
+	// Registering only returns the ID, so look up the schema to get its version.
+	registeredSchema := &Schema{}
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject), request, registeredSchema); err != nil {
+		return nil, err
+	}
+	if registeredSchema.SchemaType == "" {
+		registeredSchema.SchemaType = schema.SchemaType
+	}
+	return registeredSchema, nil
+}
+
+func (c *client) GetSchema(ctx context.Context, subject string, version string) (*Schema, error) {
+	schema := &Schema{}
+	if err := c.do(ctx, http.MethodGet, "/subjects/"+url.PathEscape(subject)+"/versions/"+url.PathEscape(version), nil, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+func (c *client) do(ctx context.Context, method string, path string, request interface{}, response interface{}) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, method, c.address+path, body)
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Accept", contentType)
+	if request != nil {
+		httpRequest.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" || c.password != "" {
+		httpRequest.SetBasicAuth(c.username, c.password)
+	}
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return err
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return newResponseError(method, path, httpResponse.StatusCode, data)
+	}
+	if err := json.Unmarshal(data, response); err != nil {
+		return fmt.Errorf("could not parse schema registry response for %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func newResponseError(method string, path string, statusCode int, data []byte) error {
+	var errorResponse struct {
+		ErrorCode int    `json:"error_code"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &errorResponse); err == nil && errorResponse.Message != "" {
+		return fmt.Errorf("schema registry returned error %d for %s %s: %s", errorResponse.ErrorCode, method, path, errorResponse.Message)
+	}
+	return fmt.Errorf("schema registry returned status %d for %s %s", statusCode, method, path)
+}
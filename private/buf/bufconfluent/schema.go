@@ -0,0 +1,129 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfluent
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/bufbuild/buf/private/gen/data/datawkt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func export(ctx context.Context, client Client, fileDescriptorProtos []*descriptorpb.FileDescriptorProto) ([]*Schema, error) {
+	pathToFileDescriptorProto := make(map[string]*descriptorpb.FileDescriptorProto, len(fileDescriptorProtos))
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		pathToFileDescriptorProto[fileDescriptorProto.GetName()] = fileDescriptorProto
+	}
+	pathToSchema := make(map[string]*Schema, len(fileDescriptorProtos))
+	var schemas []*Schema
+	var register func(path string) error
+	register = func(path string) error {
+		if _, ok := pathToSchema[path]; ok || datawkt.Exists(path) {
+			return nil
+		}
+		fileDescriptorProto, ok := pathToFileDescriptorProto[path]
+		if !ok {
+			return fmt.Errorf("dependency %q is not in the files to export", path)
+		}
+		var references []*Reference
+		for _, dependency := range fileDescriptorProto.GetDependency() {
+			if err := register(dependency); err != nil {
+				return err
+			}
+			if dependencySchema, ok := pathToSchema[dependency]; ok {
+				references = append(
+					references,
+					&Reference{
+						Name:    dependency,
+						Subject: dependencySchema.Subject,
+						Version: dependencySchema.Version,
+					},
+				)
+			}
+		}
+		schemaContent, err := schemaContentForFileDescriptorProto(fileDescriptorProto)
+		if err != nil {
+			return err
+		}
+		schema, err := client.RegisterSchema(
+			ctx,
+			path,
+			&Schema{
+				SchemaType: SchemaTypeProtobuf,
+				Schema:     schemaContent,
+				References: references,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("could not register %q: %w", path, err)
+		}
+		pathToSchema[path] = schema
+		schemas = append(schemas, schema)
+		return nil
+	}
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		if err := register(fileDescriptorProto.GetName()); err != nil {
+			return nil, err
+		}
+	}
+	return schemas, nil
+}
+
+func importSubject(ctx context.Context, client Client, subject string, version string, path string) (map[string][]byte, error) {
+	pathToData := make(map[string][]byte)
+	var get func(subject string, version string, path string) error
+	get = func(subject string, version string, path string) error {
+		if _, ok := pathToData[path]; ok || datawkt.Exists(path) {
+			return nil
+		}
+		schema, err := client.GetSchema(ctx, subject, version)
+		if err != nil {
+			return fmt.Errorf("could not get version %s of subject %q: %w", version, subject, err)
+		}
+		if schema.SchemaType != SchemaTypeProtobuf {
+			return fmt.Errorf("subject %q is not a Protobuf schema", subject)
+		}
+		pathToData[path] = []byte(schema.Schema)
+		for _, reference := range schema.References {
+			if err := get(reference.Subject, strconv.Itoa(reference.Version), reference.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := get(subject, version, path); err != nil {
+		return nil, err
+	}
+	return pathToData, nil
+}
+
+// schemaContentForFileDescriptorProto returns the base64-encoded FileDescriptorProto
+// without source code info, which schema registries accept in place of .proto text.
+func schemaContentForFileDescriptorProto(fileDescriptorProto *descriptorpb.FileDescriptorProto) (string, error) {
+	clone, ok := proto.Clone(fileDescriptorProto).(*descriptorpb.FileDescriptorProto)
+	if !ok {
+		return "", fmt.Errorf("could not clone %q", fileDescriptorProto.GetName())
+	}
+	clone.SourceCodeInfo = nil
+	data, err := proto.Marshal(clone)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufconsumption reports which RPCs and types of a schema are used by a consumer.
+package bufconsumption
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// UsageManifestV1Version is the only supported version of a UsageManifest.
+const UsageManifestV1Version = "v1"
+
+// UsageManifest is the usage of a schema by a single consumer.
+//
+// Usage manifests are produced by generated SDKs, or written manually, as JSON or YAML:
+//
+//	version: v1
+//	consumer: acme-ios
+//	rpcs:
+//	  - acme.weather.v1.WeatherService.GetWeather
+//	types:
+//	  - acme.weather.v1.Location
+type UsageManifest struct {
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Consumer is the name of the consumer, such as the name of the application.
+	Consumer string `json:"consumer,omitempty" yaml:"consumer,omitempty"`
+	// RPCs are the RPCs called by the consumer.
+	//
+	// Names are either fully-qualified, such as "acme.weather.v1.WeatherService.GetWeather",
+	// or request paths, such as "/acme.weather.v1.WeatherService/GetWeather".
+	RPCs []string `json:"rpcs,omitempty" yaml:"rpcs,omitempty"`
+	// Types are the fully-qualified names of the messages and enums used directly by
+	// the consumer, outside of the requests and responses of RPCs.
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+}
+
+// ReadUsageManifest reads a UsageManifest from the Reader.
+func ReadUsageManifest(reader io.Reader) (*UsageManifest, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	usageManifest := &UsageManifest{}
+	if err := encoding.UnmarshalJSONOrYAMLStrict(data, usageManifest); err != nil {
+		return nil, fmt.Errorf("could not read usage manifest: %w", err)
+	}
+	switch usageManifest.Version {
+	case UsageManifestV1Version:
+	case "":
+		return nil, errors.New("could not read usage manifest: version is required")
+	default:
+		return nil, fmt.Errorf("could not read usage manifest: unknown version %q", usageManifest.Version)
+	}
+	return usageManifest, nil
+}
+
+// Report is the usage of a schema by a single consumer.
+type Report struct {
+	Consumer string `json:"consumer,omitempty"`
+	// UsedRPCs are the RPCs of the schema called by the consumer, sorted.
+	UsedRPCs []string `json:"used_rpcs"`
+	// UnusedRPCs are the RPCs of the schema not called by the consumer, sorted.
+	UnusedRPCs []string `json:"unused_rpcs"`
+	// UsedTypes are the messages and enums of the schema used by the consumer, sorted.
+	//
+	// This includes the types used by the requests and responses of used RPCs, and
+	// all types transitively referenced by the fields of used messages.
+	UsedTypes []string `json:"used_types"`
+	// UnusedTypes are the messages and enums of the schema not used by the consumer, sorted.
+	UnusedTypes []string `json:"unused_types"`
+	// DeprecatedInUse are the deprecated RPCs, types, and fields of used messages
+	// that the consumer uses, sorted.
+	DeprecatedInUse []string `json:"deprecated_in_use,omitempty"`
+	// Unknown are the names in the UsageManifest that are not in the schema, sorted.
+	//
+	// These are usually elements that were already removed, or that belong to another schema.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+// NewReport returns a new Report for the schema consisting of the given files.
+//
+// Only elements declared in the given files are reported, so callers should exclude
+// imports they do not wish to report on.
+func NewReport(fileDescriptors []protoreflect.FileDescriptor, usageManifest *UsageManifest) *Report {
+	return newReport(fileDescriptors, usageManifest)
+}
@@ -0,0 +1,140 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconsumption
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+	fileDescriptor := testCompile(t)
+	report := NewReport(
+		[]protoreflect.FileDescriptor{fileDescriptor},
+		&UsageManifest{
+			Version:  UsageManifestV1Version,
+			Consumer: "acme-ios",
+			RPCs: []string{
+				"/bufconsumption.test.WeatherService/GetWeather",
+				"bufconsumption.test.WeatherService.ListCities",
+				"bufconsumption.test.OldService.Old",
+			},
+			Types: []string{
+				"bufconsumption.test.Alert",
+				".bufconsumption.test.Gone",
+			},
+		},
+	)
+	assert.Equal(
+		t,
+		&Report{
+			Consumer: "acme-ios",
+			UsedRPCs: []string{
+				"bufconsumption.test.WeatherService.GetWeather",
+				"bufconsumption.test.WeatherService.ListCities",
+			},
+			UnusedRPCs: []string{},
+			UsedTypes: []string{
+				"bufconsumption.test.Alert",
+				"bufconsumption.test.Condition",
+				"bufconsumption.test.Condition.Kind",
+				"bufconsumption.test.GetWeatherRequest",
+				"bufconsumption.test.GetWeatherResponse",
+				"bufconsumption.test.ListCitiesRequest",
+				"bufconsumption.test.ListCitiesResponse",
+				"bufconsumption.test.Location",
+			},
+			UnusedTypes: []string{
+				"bufconsumption.test.Unused",
+			},
+			DeprecatedInUse: []string{
+				"bufconsumption.test.Alert",
+				"bufconsumption.test.GetWeatherRequest.city",
+				"bufconsumption.test.WeatherService.ListCities",
+			},
+			Unknown: []string{
+				"bufconsumption.test.Gone",
+				"bufconsumption.test.OldService.Old",
+			},
+		},
+		report,
+	)
+	report = NewReport(
+		[]protoreflect.FileDescriptor{fileDescriptor},
+		&UsageManifest{Version: UsageManifestV1Version},
+	)
+	assert.Empty(t, report.UsedRPCs)
+	assert.Len(t, report.UnusedRPCs, 2)
+	assert.Empty(t, report.UsedTypes)
+	assert.Len(t, report.UnusedTypes, 9)
+	assert.Empty(t, report.DeprecatedInUse)
+	assert.Empty(t, report.Unknown)
+}
+
+func TestReadUsageManifest(t *testing.T) {
+	t.Parallel()
+	usageManifest, err := ReadUsageManifest(
+		strings.NewReader(`{"version":"v1","consumer":"acme-web","rpcs":["acme.v1.FooService.Bar"]}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&UsageManifest{
+			Version:  UsageManifestV1Version,
+			Consumer: "acme-web",
+			RPCs:     []string{"acme.v1.FooService.Bar"},
+		},
+		usageManifest,
+	)
+	usageManifest, err = ReadUsageManifest(
+		strings.NewReader("version: v1\nconsumer: acme-ios\ntypes:\n  - acme.v1.Foo\n"),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&UsageManifest{
+			Version:  UsageManifestV1Version,
+			Consumer: "acme-ios",
+			Types:    []string{"acme.v1.Foo"},
+		},
+		usageManifest,
+	)
+	_, err = ReadUsageManifest(strings.NewReader(`{"consumer":"acme-web"}`))
+	assert.Error(t, err)
+	_, err = ReadUsageManifest(strings.NewReader(`{"version":"v2"}`))
+	assert.Error(t, err)
+	_, err = ReadUsageManifest(strings.NewReader(`{"version":"v1","unknown":true}`))
+	assert.Error(t, err)
+}
+
+func testCompile(t *testing.T) protoreflect.FileDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			&protocompile.SourceResolver{
+				ImportPaths: []string{"./testdata"},
+			},
+		),
+	}).Compile(context.Background(), "weather.proto")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	return files[0]
+}
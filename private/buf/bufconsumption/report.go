@@ -0,0 +1,188 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconsumption
+
+import (
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func newReport(fileDescriptors []protoreflect.FileDescriptor, usageManifest *UsageManifest) *Report {
+	nameToMethodDescriptor := make(map[string]protoreflect.MethodDescriptor)
+	nameToTypeDescriptor := make(map[string]protoreflect.Descriptor)
+	for _, fileDescriptor := range fileDescriptors {
+		serviceDescriptors := fileDescriptor.Services()
+		for i := 0; i < serviceDescriptors.Len(); i++ {
+			methodDescriptors := serviceDescriptors.Get(i).Methods()
+			for j := 0; j < methodDescriptors.Len(); j++ {
+				methodDescriptor := methodDescriptors.Get(j)
+				nameToMethodDescriptor[string(methodDescriptor.FullName())] = methodDescriptor
+			}
+		}
+		addTypeDescriptors(nameToTypeDescriptor, fileDescriptor.Messages(), fileDescriptor.Enums())
+	}
+	walker := newUsedWalker(nameToTypeDescriptor)
+	usedRPCs := make(map[string]struct{})
+	unknown := make(map[string]struct{})
+	for _, rpc := range usageManifest.RPCs {
+		rpc = normalizeRPCName(rpc)
+		methodDescriptor, ok := nameToMethodDescriptor[rpc]
+		if !ok {
+			unknown[rpc] = struct{}{}
+			continue
+		}
+		usedRPCs[rpc] = struct{}{}
+		if isDeprecated(methodDescriptor) || isDeprecated(methodDescriptor.Parent()) {
+			walker.deprecatedInUse[rpc] = struct{}{}
+		}
+		walker.walk(methodDescriptor.Input())
+		walker.walk(methodDescriptor.Output())
+	}
+	for _, typeName := range usageManifest.Types {
+		typeName = strings.TrimPrefix(strings.TrimSpace(typeName), ".")
+		typeDescriptor, ok := nameToTypeDescriptor[typeName]
+		if !ok {
+			unknown[typeName] = struct{}{}
+			continue
+		}
+		walker.walk(typeDescriptor)
+	}
+	report := &Report{
+		Consumer:        usageManifest.Consumer,
+		UsedRPCs:        []string{},
+		UnusedRPCs:      []string{},
+		UsedTypes:       []string{},
+		UnusedTypes:     []string{},
+		DeprecatedInUse: sortedKeys(walker.deprecatedInUse),
+		Unknown:         sortedKeys(unknown),
+	}
+	for rpc := range nameToMethodDescriptor {
+		if _, ok := usedRPCs[rpc]; ok {
+			report.UsedRPCs = append(report.UsedRPCs, rpc)
+		} else {
+			report.UnusedRPCs = append(report.UnusedRPCs, rpc)
+		}
+	}
+	for typeName := range nameToTypeDescriptor {
+		if _, ok := walker.usedTypes[typeName]; ok {
+			report.UsedTypes = append(report.UsedTypes, typeName)
+		} else {
+			report.UnusedTypes = append(report.UnusedTypes, typeName)
+		}
+	}
+	sort.Strings(report.UsedRPCs)
+	sort.Strings(report.UnusedRPCs)
+	sort.Strings(report.UsedTypes)
+	sort.Strings(report.UnusedTypes)
+	return report
+}
+
+// usedWalker walks the types transitively referenced by used types.
+type usedWalker struct {
+	// nameToTypeDescriptor are the types of the schema. Types outside of
+	// the schema, such as imports, are walked but not recorded as used.
+	nameToTypeDescriptor map[string]protoreflect.Descriptor
+	usedTypes            map[string]struct{}
+	deprecatedInUse      map[string]struct{}
+	seen                 map[protoreflect.FullName]struct{}
+}
+
+func newUsedWalker(nameToTypeDescriptor map[string]protoreflect.Descriptor) *usedWalker {
+	return &usedWalker{
+		nameToTypeDescriptor: nameToTypeDescriptor,
+		usedTypes:            make(map[string]struct{}),
+		deprecatedInUse:      make(map[string]struct{}),
+		seen:                 make(map[protoreflect.FullName]struct{}),
+	}
+}
+
+func (w *usedWalker) walk(descriptor protoreflect.Descriptor) {
+	if _, ok := w.seen[descriptor.FullName()]; ok {
+		return
+	}
+	w.seen[descriptor.FullName()] = struct{}{}
+	name := string(descriptor.FullName())
+	_, inSchema := w.nameToTypeDescriptor[name]
+	if inSchema {
+		w.usedTypes[name] = struct{}{}
+		if isDeprecated(descriptor) {
+			w.deprecatedInUse[name] = struct{}{}
+		}
+	}
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return
+	}
+	fieldDescriptors := messageDescriptor.Fields()
+	for i := 0; i < fieldDescriptors.Len(); i++ {
+		fieldDescriptor := fieldDescriptors.Get(i)
+		if inSchema && isDeprecated(fieldDescriptor) {
+			w.deprecatedInUse[string(fieldDescriptor.FullName())] = struct{}{}
+		}
+		switch fieldDescriptor.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+			// Map entries are walked through, but are not types of the schema.
+			w.walk(fieldDescriptor.Message())
+		case protoreflect.EnumKind:
+			w.walk(fieldDescriptor.Enum())
+		}
+	}
+}
+
+func addTypeDescriptors(
+	nameToTypeDescriptor map[string]protoreflect.Descriptor,
+	messageDescriptors protoreflect.MessageDescriptors,
+	enumDescriptors protoreflect.EnumDescriptors,
+) {
+	for i := 0; i < enumDescriptors.Len(); i++ {
+		enumDescriptor := enumDescriptors.Get(i)
+		nameToTypeDescriptor[string(enumDescriptor.FullName())] = enumDescriptor
+	}
+	for i := 0; i < messageDescriptors.Len(); i++ {
+		messageDescriptor := messageDescriptors.Get(i)
+		if messageDescriptor.IsMapEntry() {
+			continue
+		}
+		nameToTypeDescriptor[string(messageDescriptor.FullName())] = messageDescriptor
+		addTypeDescriptors(nameToTypeDescriptor, messageDescriptor.Messages(), messageDescriptor.Enums())
+	}
+}
+
+func isDeprecated(descriptor protoreflect.Descriptor) bool {
+	options, ok := descriptor.Options().(interface{ GetDeprecated() bool })
+	return ok && options.GetDeprecated()
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeRPCName converts request paths such as "/acme.v1.FooService/Bar"
+// to fully-qualified names such as "acme.v1.FooService.Bar".
+func normalizeRPCName(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	name = strings.TrimPrefix(name, ".")
+	return strings.ReplaceAll(name, "/", ".")
+}
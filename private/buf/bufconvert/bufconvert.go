@@ -0,0 +1,109 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufconvert transforms messages for data migrations.
+package bufconvert
+
+import (
+	"fmt"
+
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldMapping maps fully-qualified source field names to the names of the
+// fields they are converted to in the corresponding target message.
+//
+// An empty name means the source field is dropped.
+type FieldMapping map[protoreflect.FullName]string
+
+// ReadFieldMapping reads a FieldMapping from JSON or YAML data.
+//
+// The data has a single "fields" key, for example:
+//
+//	fields:
+//	  acme.user.v1.User.name: full_name
+//	  acme.user.v1.User.legacy_id: ""
+func ReadFieldMapping(data []byte) (FieldMapping, error) {
+	var externalFieldMapping externalFieldMapping
+	if err := encoding.UnmarshalJSONOrYAMLStrict(data, &externalFieldMapping); err != nil {
+		return nil, fmt.Errorf("could not read field mapping: %w", err)
+	}
+	fieldMapping := make(FieldMapping, len(externalFieldMapping.Fields))
+	for sourceFieldName, targetFieldName := range externalFieldMapping.Fields {
+		if !protoreflect.FullName(sourceFieldName).IsValid() {
+			return nil, fmt.Errorf("invalid field name %q in field mapping", sourceFieldName)
+		}
+		if targetFieldName != "" && !protoreflect.Name(targetFieldName).IsValid() {
+			return nil, fmt.Errorf("invalid field name %q in field mapping for %q", targetFieldName, sourceFieldName)
+		}
+		fieldMapping[protoreflect.FullName(sourceFieldName)] = targetFieldName
+	}
+	return fieldMapping, nil
+}
+
+// ApplyFieldMask clears all fields of the message that are not in the field mask.
+//
+// Each path is a "."-separated list of field names, as in google.protobuf.FieldMask.
+// Only the last field of a path may be a repeated or map field. Unknown fields
+// and extensions are always cleared.
+func ApplyFieldMask(message protoreflect.Message, paths []string) error {
+	return applyFieldMask(message, paths)
+}
+
+// Convert converts the source message to the target message, which is
+// typically a new message of a different but compatible type.
+//
+// Each populated field of the source message is copied to the field of the
+// same name in the target message, unless it is renamed or dropped by the
+// FieldMapping. Message fields are converted recursively. Fields are compatible
+// if they have the same cardinality and either both are messages, both are enums
+// (values are copied by number), or both have the same Go representation, such
+// as int32 and sint32. Unknown fields are dropped.
+//
+// Returns an error if a populated field has no compatible field in the target
+// message, or if the FieldMapping refers to fields that do not exist.
+func Convert(source protoreflect.Message, target protoreflect.Message, fieldMapping FieldMapping) error {
+	return newConverter(fieldMapping).convert(source, target)
+}
+
+type externalFieldMapping struct {
+	Fields map[string]string `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// TypeCandidate is a message type that a binary payload may be an instance of.
+type TypeCandidate struct {
+	// FullName is the full name of the message type.
+	FullName protoreflect.FullName
+	// Score is the fraction of the bytes of the payload that decode into known
+	// fields of the type, between 0 and 1.
+	Score float64
+	// KnownFieldCount is the number of populated known fields, including the fields
+	// of nested messages.
+	KnownFieldCount int
+	// UnknownByteCount is the number of bytes of the payload that decode into unknown
+	// fields, including the unknown fields of nested messages.
+	UnknownByteCount int
+}
+
+// RankMessageTypes ranks the given message types by how plausible it is that the binary
+// payload is an instance of each type.
+//
+// This is a best-effort heuristic. Types that the payload does not decode into, such as
+// when a string field has invalid UTF-8, and types that none of the payload decodes into
+// known fields of are excluded. The remaining types are sorted by Score, then by
+// KnownFieldCount, both descending. Map entry types are always excluded.
+func RankMessageTypes(data []byte, messageDescriptors []protoreflect.MessageDescriptor) []*TypeCandidate {
+	return rankMessageTypes(data, messageDescriptors)
+}
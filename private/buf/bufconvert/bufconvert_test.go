@@ -0,0 +1,157 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testUserV1JSON = `{
+  "name": "Jane",
+  "age": 42,
+  "status": "STATUS_V1_ACTIVE",
+  "address": {"street": "1 Main St", "city": "Springfield"},
+  "previousAddresses": [{"street": "2 Elm St", "city": "Shelbyville"}],
+  "addressesByLabel": {"work": {"street": "3 Oak St", "city": "Capital City"}},
+  "legacyId": "abc"
+}`
+
+func TestApplyFieldMask(t *testing.T) {
+	t.Parallel()
+	message := testNewMessage(t, "UserV1", testUserV1JSON)
+	require.NoError(t, ApplyFieldMask(message, []string{"name", "address.city", "previous_addresses"}))
+	testAssertMessageJSON(
+		t,
+		`{
+  "name": "Jane",
+  "address": {"city": "Springfield"},
+  "previousAddresses": [{"street": "2 Elm St", "city": "Shelbyville"}]
+}`,
+		message,
+	)
+	// A path to the entire field takes precedence over paths to its sub-fields.
+	message = testNewMessage(t, "UserV1", testUserV1JSON)
+	require.NoError(t, ApplyFieldMask(message, []string{"address.city", "address"}))
+	testAssertMessageJSON(t, `{"address": {"street": "1 Main St", "city": "Springfield"}}`, message)
+
+	message = testNewMessage(t, "UserV1", testUserV1JSON)
+	assert.Error(t, ApplyFieldMask(message, []string{"unknown"}))
+	assert.Error(t, ApplyFieldMask(message, []string{"address.unknown"}))
+	assert.Error(t, ApplyFieldMask(message, []string{"previous_addresses.city"}))
+	assert.Error(t, ApplyFieldMask(message, []string{"name."}))
+	assert.Error(t, ApplyFieldMask(message, nil))
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	fieldMapping, err := ReadFieldMapping(
+		[]byte(`
+fields:
+  test.UserV1.name: full_name
+  test.UserV1.legacy_id: ""
+  test.AddressV1.street: street_line
+`),
+	)
+	require.NoError(t, err)
+	source := testNewMessage(t, "UserV1", testUserV1JSON)
+	target := testNewMessage(t, "UserV2", "{}")
+	require.NoError(t, Convert(source, target, fieldMapping))
+	testAssertMessageJSON(
+		t,
+		`{
+  "fullName": "Jane",
+  "age": 42,
+  "status": "STATUS_V2_ACTIVE",
+  "address": {"streetLine": "1 Main St", "city": "Springfield"},
+  "previousAddresses": [{"streetLine": "2 Elm St", "city": "Shelbyville"}],
+  "addressesByLabel": {"work": {"streetLine": "3 Oak St", "city": "Capital City"}}
+}`,
+		target,
+	)
+}
+
+func TestConvertError(t *testing.T) {
+	t.Parallel()
+	source := testNewMessage(t, "UserV1", testUserV1JSON)
+	// Fields without a corresponding target field must be mapped.
+	assert.Error(t, Convert(source, testNewMessage(t, "UserV2", "{}"), nil))
+	// Fields in the mapping must exist.
+	assert.Error(t, Convert(source, testNewMessage(t, "UserV2", "{}"), FieldMapping{"test.UserV1.unknown": "name"}))
+	// Fields must have compatible types.
+	assert.Error(
+		t,
+		Convert(
+			testNewMessage(t, "UserV1", `{"age": 1}`),
+			testNewMessage(t, "Incompatible", "{}"),
+			nil,
+		),
+	)
+	_, err := ReadFieldMapping([]byte(`fields: {"test.UserV1.name": "not a name"}`))
+	assert.Error(t, err)
+}
+
+func TestRankMessageTypes(t *testing.T) {
+	t.Parallel()
+	data, err := proto.Marshal(testNewMessage(t, "UserV1", testUserV1JSON).Interface())
+	require.NoError(t, err)
+	messageDescriptors := []protoreflect.MessageDescriptor{
+		testNewMessage(t, "Incompatible", "{}").Descriptor(),
+		testNewMessage(t, "AddressV1", "{}").Descriptor(),
+		testNewMessage(t, "UserV2", "{}").Descriptor(),
+		testNewMessage(t, "UserV1", "{}").Descriptor(),
+	}
+	typeCandidates := RankMessageTypes(data, messageDescriptors)
+	require.Len(t, typeCandidates, 3)
+	assert.Equal(t, protoreflect.FullName("test.UserV1"), typeCandidates[0].FullName)
+	assert.Equal(t, 1.0, typeCandidates[0].Score)
+	assert.Equal(t, 0, typeCandidates[0].UnknownByteCount)
+	// UserV2 has no legacy_id field, so it is decoded as an unknown field.
+	assert.Equal(t, protoreflect.FullName("test.UserV2"), typeCandidates[1].FullName)
+	assert.Less(t, typeCandidates[1].Score, 1.0)
+	assert.Greater(t, typeCandidates[1].UnknownByteCount, 0)
+	assert.Equal(t, protoreflect.FullName("test.AddressV1"), typeCandidates[2].FullName)
+	assert.Less(t, typeCandidates[2].Score, typeCandidates[1].Score)
+}
+
+func testNewMessage(t *testing.T, name protoreflect.Name, json string) protoreflect.Message {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			&protocompile.SourceResolver{
+				ImportPaths: []string{"./testdata"},
+			},
+		),
+	}).Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	messageDescriptor := files[0].Messages().ByName(name)
+	require.NotNil(t, messageDescriptor)
+	message := dynamicpb.NewMessage(messageDescriptor)
+	require.NoError(t, protojson.Unmarshal([]byte(json), message))
+	return message
+}
+
+func testAssertMessageJSON(t *testing.T, expectedJSON string, message protoreflect.Message) {
+	data, err := protojson.Marshal(message.Interface())
+	require.NoError(t, err)
+	assert.JSONEq(t, expectedJSON, string(data))
+}
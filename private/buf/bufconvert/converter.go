@@ -0,0 +1,226 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type converter struct {
+	fieldMapping FieldMapping
+}
+
+func newConverter(fieldMapping FieldMapping) *converter {
+	return &converter{
+		fieldMapping: fieldMapping,
+	}
+}
+
+func (c *converter) convert(source protoreflect.Message, target protoreflect.Message) error {
+	if err := c.validateFieldMapping(source.Descriptor()); err != nil {
+		return err
+	}
+	return c.convertMessage(source, target)
+}
+
+// validateFieldMapping validates that all fields in the FieldMapping
+// are fields of the source message or its nested message fields.
+func (c *converter) validateFieldMapping(messageDescriptor protoreflect.MessageDescriptor) error {
+	if len(c.fieldMapping) == 0 {
+		return nil
+	}
+	fieldNames := make(map[protoreflect.FullName]struct{})
+	addFieldNames(messageDescriptor, fieldNames, make(map[protoreflect.FullName]struct{}))
+	for fieldName := range c.fieldMapping {
+		if _, ok := fieldNames[fieldName]; !ok {
+			return fmt.Errorf("field mapping: field %q is not a field of %q or its nested messages", fieldName, messageDescriptor.FullName())
+		}
+	}
+	return nil
+}
+
+func (c *converter) convertMessage(source protoreflect.Message, target protoreflect.Message) error {
+	var err error
+	source.Range(
+		func(sourceFieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+			err = c.convertField(sourceFieldDescriptor, value, target)
+			return err == nil
+		},
+	)
+	return err
+}
+
+func (c *converter) convertField(
+	sourceFieldDescriptor protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	target protoreflect.Message,
+) error {
+	if sourceFieldDescriptor.IsExtension() {
+		return fmt.Errorf("extension %q cannot be converted", sourceFieldDescriptor.FullName())
+	}
+	targetFieldName := string(sourceFieldDescriptor.Name())
+	if mappedFieldName, ok := c.fieldMapping[sourceFieldDescriptor.FullName()]; ok {
+		if mappedFieldName == "" {
+			return nil
+		}
+		targetFieldName = mappedFieldName
+	}
+	targetFieldDescriptor := target.Descriptor().Fields().ByName(protoreflect.Name(targetFieldName))
+	if targetFieldDescriptor == nil {
+		return fmt.Errorf(
+			"field %q has no corresponding field %q in %q, add it to the field mapping to rename or drop it",
+			sourceFieldDescriptor.FullName(),
+			targetFieldName,
+			target.Descriptor().FullName(),
+		)
+	}
+	if err := checkFieldsCompatible(sourceFieldDescriptor, targetFieldDescriptor); err != nil {
+		return err
+	}
+	switch {
+	case sourceFieldDescriptor.IsList():
+		sourceList := value.List()
+		targetList := target.Mutable(targetFieldDescriptor).List()
+		for i := 0; i < sourceList.Len(); i++ {
+			targetValue, err := c.convertValue(sourceFieldDescriptor, targetFieldDescriptor, sourceList.Get(i), targetList.NewElement)
+			if err != nil {
+				return err
+			}
+			targetList.Append(targetValue)
+		}
+	case sourceFieldDescriptor.IsMap():
+		sourceMapValueFieldDescriptor := sourceFieldDescriptor.MapValue()
+		targetMapValueFieldDescriptor := targetFieldDescriptor.MapValue()
+		targetMap := target.Mutable(targetFieldDescriptor).Map()
+		var err error
+		value.Map().Range(
+			func(key protoreflect.MapKey, mapValue protoreflect.Value) bool {
+				var targetValue protoreflect.Value
+				targetValue, err = c.convertValue(sourceMapValueFieldDescriptor, targetMapValueFieldDescriptor, mapValue, targetMap.NewValue)
+				if err != nil {
+					return false
+				}
+				targetMap.Set(key, targetValue)
+				return true
+			},
+		)
+		if err != nil {
+			return err
+		}
+	default:
+		targetValue, err := c.convertValue(
+			sourceFieldDescriptor,
+			targetFieldDescriptor,
+			value,
+			func() protoreflect.Value {
+				return target.NewField(targetFieldDescriptor)
+			},
+		)
+		if err != nil {
+			return err
+		}
+		target.Set(targetFieldDescriptor, targetValue)
+	}
+	return nil
+}
+
+// convertValue converts a singular value, list element, or map value.
+//
+// newValue returns a new empty value for the target, and is only called for messages.
+func (c *converter) convertValue(
+	sourceFieldDescriptor protoreflect.FieldDescriptor,
+	targetFieldDescriptor protoreflect.FieldDescriptor,
+	value protoreflect.Value,
+	newValue func() protoreflect.Value,
+) (protoreflect.Value, error) {
+	switch sourceFieldDescriptor.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		targetValue := newValue()
+		if err := c.convertMessage(value.Message(), targetValue.Message()); err != nil {
+			return protoreflect.Value{}, err
+		}
+		return targetValue, nil
+	default:
+		// Enums are copied by number, and all other compatible kinds share a Go representation.
+		return value, nil
+	}
+}
+
+func checkFieldsCompatible(sourceFieldDescriptor protoreflect.FieldDescriptor, targetFieldDescriptor protoreflect.FieldDescriptor) error {
+	if sourceFieldDescriptor.IsList() != targetFieldDescriptor.IsList() || sourceFieldDescriptor.IsMap() != targetFieldDescriptor.IsMap() {
+		return newIncompatibleFieldsError(sourceFieldDescriptor, targetFieldDescriptor)
+	}
+	if sourceFieldDescriptor.IsMap() {
+		if sourceFieldDescriptor.MapKey().Kind() != targetFieldDescriptor.MapKey().Kind() {
+			return newIncompatibleFieldsError(sourceFieldDescriptor, targetFieldDescriptor)
+		}
+		sourceFieldDescriptor = sourceFieldDescriptor.MapValue()
+		targetFieldDescriptor = targetFieldDescriptor.MapValue()
+	}
+	if kindGroup(sourceFieldDescriptor.Kind()) != kindGroup(targetFieldDescriptor.Kind()) {
+		return newIncompatibleFieldsError(sourceFieldDescriptor, targetFieldDescriptor)
+	}
+	return nil
+}
+
+func newIncompatibleFieldsError(sourceFieldDescriptor protoreflect.FieldDescriptor, targetFieldDescriptor protoreflect.FieldDescriptor) error {
+	return fmt.Errorf(
+		"field %q cannot be converted to field %q: incompatible types",
+		sourceFieldDescriptor.FullName(),
+		targetFieldDescriptor.FullName(),
+	)
+}
+
+// kindGroup returns the kind that represents all kinds with the same Go representation.
+func kindGroup(kind protoreflect.Kind) protoreflect.Kind {
+	switch kind {
+	case protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.Int32Kind
+	case protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.Int64Kind
+	case protoreflect.Fixed32Kind:
+		return protoreflect.Uint32Kind
+	case protoreflect.Fixed64Kind:
+		return protoreflect.Uint64Kind
+	case protoreflect.GroupKind:
+		return protoreflect.MessageKind
+	default:
+		return kind
+	}
+}
+
+func addFieldNames(
+	messageDescriptor protoreflect.MessageDescriptor,
+	fieldNames map[protoreflect.FullName]struct{},
+	seen map[protoreflect.FullName]struct{},
+) {
+	if _, ok := seen[messageDescriptor.FullName()]; ok {
+		return
+	}
+	seen[messageDescriptor.FullName()] = struct{}{}
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fieldDescriptor := fields.Get(i)
+		fieldNames[fieldDescriptor.FullName()] = struct{}{}
+		if fieldDescriptor.IsMap() {
+			fieldDescriptor = fieldDescriptor.MapValue()
+		}
+		if fieldMessageDescriptor := fieldDescriptor.Message(); fieldMessageDescriptor != nil {
+			addFieldNames(fieldMessageDescriptor, fieldNames, seen)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldMaskTree is a tree of field names.
+//
+// A nil fieldMaskTree for a field means the entire field is included.
+type fieldMaskTree map[protoreflect.Name]fieldMaskTree
+
+func applyFieldMask(message protoreflect.Message, paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("field mask must contain at least one path")
+	}
+	tree := make(fieldMaskTree)
+	for _, path := range paths {
+		names := strings.Split(path, ".")
+		for _, name := range names {
+			if !protoreflect.Name(name).IsValid() {
+				return fmt.Errorf("invalid field mask path %q", path)
+			}
+		}
+		tree.add(names)
+	}
+	if err := validateFieldMaskTree(message.Descriptor(), tree); err != nil {
+		return err
+	}
+	applyFieldMaskTree(message, tree)
+	return nil
+}
+
+func (t fieldMaskTree) add(names []string) {
+	name := protoreflect.Name(names[0])
+	child, ok := t[name]
+	if ok && child == nil {
+		// The entire field is already included.
+		return
+	}
+	if len(names) == 1 {
+		t[name] = nil
+		return
+	}
+	if child == nil {
+		child = make(fieldMaskTree)
+		t[name] = child
+	}
+	child.add(names[1:])
+}
+
+func validateFieldMaskTree(messageDescriptor protoreflect.MessageDescriptor, tree fieldMaskTree) error {
+	for name, child := range tree {
+		fieldDescriptor := messageDescriptor.Fields().ByName(name)
+		if fieldDescriptor == nil {
+			return fmt.Errorf("field mask: field %q not found in message %q", name, messageDescriptor.FullName())
+		}
+		if child == nil {
+			continue
+		}
+		if fieldDescriptor.Message() == nil || fieldDescriptor.IsList() || fieldDescriptor.IsMap() {
+			return fmt.Errorf("field mask: field %q is not a singular message field and cannot have sub-fields", fieldDescriptor.FullName())
+		}
+		if err := validateFieldMaskTree(fieldDescriptor.Message(), child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyFieldMaskTree(message protoreflect.Message, tree fieldMaskTree) {
+	message.Range(
+		func(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+			child, ok := tree[fieldDescriptor.Name()]
+			switch {
+			case !ok || fieldDescriptor.IsExtension():
+				message.Clear(fieldDescriptor)
+			case child != nil:
+				applyFieldMaskTree(value.Message(), child)
+			}
+			return true
+		},
+	)
+	message.SetUnknown(nil)
+}
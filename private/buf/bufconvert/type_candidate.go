@@ -0,0 +1,93 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconvert
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func rankMessageTypes(data []byte, messageDescriptors []protoreflect.MessageDescriptor) []*TypeCandidate {
+	var typeCandidates []*TypeCandidate
+	for _, messageDescriptor := range messageDescriptors {
+		if messageDescriptor.IsMapEntry() {
+			continue
+		}
+		message := dynamicpb.NewMessage(messageDescriptor)
+		if err := proto.Unmarshal(data, message); err != nil {
+			continue
+		}
+		typeCandidate := &TypeCandidate{
+			FullName: messageDescriptor.FullName(),
+			Score:    1,
+		}
+		countFields(message, typeCandidate)
+		if len(data) > 0 {
+			typeCandidate.Score = float64(len(data)-typeCandidate.UnknownByteCount) / float64(len(data))
+			if typeCandidate.KnownFieldCount == 0 {
+				// Nothing in the payload was decoded into a field of the type.
+				continue
+			}
+		}
+		typeCandidates = append(typeCandidates, typeCandidate)
+	}
+	sort.SliceStable(typeCandidates, func(i int, j int) bool {
+		left := typeCandidates[i]
+		right := typeCandidates[j]
+		if left.Score != right.Score {
+			return left.Score > right.Score
+		}
+		if left.KnownFieldCount != right.KnownFieldCount {
+			return left.KnownFieldCount > right.KnownFieldCount
+		}
+		return left.FullName < right.FullName
+	})
+	return typeCandidates
+}
+
+// countFields adds the populated known fields and the unknown bytes of the message,
+// including those of all nested messages, to the TypeCandidate.
+func countFields(message protoreflect.Message, typeCandidate *TypeCandidate) {
+	typeCandidate.UnknownByteCount += len(message.GetUnknown())
+	message.Range(
+		func(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+			typeCandidate.KnownFieldCount++
+			switch {
+			case fieldDescriptor.IsMap():
+				if fieldDescriptor.MapValue().Message() != nil {
+					value.Map().Range(
+						func(_ protoreflect.MapKey, mapValue protoreflect.Value) bool {
+							countFields(mapValue.Message(), typeCandidate)
+							return true
+						},
+					)
+				}
+			case fieldDescriptor.IsList():
+				if fieldDescriptor.Message() != nil {
+					list := value.List()
+					for i := 0; i < list.Len(); i++ {
+						countFields(list.Get(i).Message(), typeCandidate)
+					}
+				}
+			case fieldDescriptor.Message() != nil:
+				countFields(value.Message(), typeCandidate)
+			}
+			return true
+		},
+	)
+}
@@ -0,0 +1,183 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufcoverage contains logic for reporting which RPCs of an image
+// are exercised by tests.
+package bufcoverage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+)
+
+// Report is the RPC coverage of an image.
+type Report struct {
+	// Total is the number of RPCs.
+	Total int `json:"total"`
+	// Covered is the number of RPCs that were exercised.
+	Covered int `json:"covered"`
+	// Percent is the percentage of RPCs that were exercised.
+	//
+	// This is 100 if there are no RPCs.
+	Percent float64 `json:"percent"`
+	// Uncovered are the fully-qualified names of the RPCs that were not exercised, sorted.
+	Uncovered []string `json:"uncovered"`
+}
+
+// RPCNamesForImage returns the fully-qualified names of all RPCs within the
+// non-import files of the Image, such as "acme.weather.v1.WeatherService.GetWeather".
+//
+// Sorted.
+func RPCNamesForImage(image bufimage.Image) []string {
+	var rpcNames []string
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptorProto := imageFile.FileDescriptorProto()
+		prefix := ""
+		if pkg := fileDescriptorProto.GetPackage(); pkg != "" {
+			prefix = pkg + "."
+		}
+		for _, service := range fileDescriptorProto.GetService() {
+			for _, method := range service.GetMethod() {
+				rpcNames = append(rpcNames, prefix+service.GetName()+"."+method.GetName())
+			}
+		}
+	}
+	sort.Strings(rpcNames)
+	return rpcNames
+}
+
+// NewReport returns a new Report for the RPCs given the exercised RPCs.
+//
+// Exercised RPCs that are not in rpcNames are ignored.
+func NewReport(rpcNames []string, exercisedRPCNames map[string]struct{}) *Report {
+	report := &Report{
+		Total:     len(rpcNames),
+		Percent:   100,
+		Uncovered: []string{},
+	}
+	for _, rpcName := range rpcNames {
+		if _, ok := exercisedRPCNames[rpcName]; ok {
+			report.Covered++
+		} else {
+			report.Uncovered = append(report.Uncovered, rpcName)
+		}
+	}
+	sort.Strings(report.Uncovered)
+	if report.Total > 0 {
+		report.Percent = 100 * float64(report.Covered) / float64(report.Total)
+	}
+	return report
+}
+
+// ReadExercisedRPCNames reads the names of exercised RPCs from the Reader.
+//
+// The data is either a JSON array of names, or one entry per line. Each line is either
+// a name, or a JSON object with the name in the "method" or "rpc" key, as is typical of
+// structured test logs. Empty lines and lines starting with # are skipped.
+//
+// Names are either fully-qualified, such as "acme.weather.v1.WeatherService.GetWeather",
+// or request paths, such as "/acme.weather.v1.WeatherService/GetWeather".
+func ReadExercisedRPCNames(reader io.Reader) (map[string]struct{}, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	exercisedRPCNames := make(map[string]struct{})
+	if trimmedData := bytes.TrimSpace(data); len(trimmedData) > 0 && trimmedData[0] == '[' {
+		var names []string
+		if err := encoding.UnmarshalJSONNonStrict(trimmedData, &names); err != nil {
+			return nil, fmt.Errorf("could not read exercised RPCs: %w", err)
+		}
+		for _, name := range names {
+			exercisedRPCNames[normalizeRPCName(name)] = struct{}{}
+		}
+		return exercisedRPCNames, nil
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// Log lines may be long.
+	scanner.Buffer(nil, 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") {
+			exercisedRPCNames[normalizeRPCName(line)] = struct{}{}
+			continue
+		}
+		var entry exercisedRPCEntry
+		if err := encoding.UnmarshalJSONNonStrict([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("could not read exercised RPCs: line %d: %w", lineNumber, err)
+		}
+		name := entry.Method
+		if name == "" {
+			name = entry.RPC
+		}
+		if name == "" {
+			return nil, fmt.Errorf(`could not read exercised RPCs: line %d: no "method" or "rpc" key`, lineNumber)
+		}
+		exercisedRPCNames[normalizeRPCName(name)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return exercisedRPCNames, nil
+}
+
+// WriteReportText writes the Report to the Writer as text.
+func WriteReportText(writer io.Writer, report *Report) error {
+	for _, uncovered := range report.Uncovered {
+		if _, err := fmt.Fprintln(writer, uncovered); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(writer, "%d/%d RPCs covered (%.2f%%)\n", report.Covered, report.Total, report.Percent)
+	return err
+}
+
+// WriteReportJSON writes the Report to the Writer as JSON.
+func WriteReportJSON(writer io.Writer, report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+type exercisedRPCEntry struct {
+	Method string `json:"method,omitempty"`
+	RPC    string `json:"rpc,omitempty"`
+}
+
+// normalizeRPCName converts request paths such as "/acme.v1.FooService/Bar"
+// to fully-qualified names such as "acme.v1.FooService.Bar".
+func normalizeRPCName(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	name = strings.TrimPrefix(name, ".")
+	return strings.ReplaceAll(name, "/", ".")
+}
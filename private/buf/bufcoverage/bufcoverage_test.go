@@ -0,0 +1,122 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcoverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+	rpcNames := []string{
+		"a.v1.FooService.Bar",
+		"a.v1.FooService.Baz",
+		"a.v1.FooService.Qux",
+		"b.v1.BarService.Foo",
+	}
+	report := NewReport(
+		rpcNames,
+		map[string]struct{}{
+			"a.v1.FooService.Baz": {},
+			"b.v1.BarService.Foo": {},
+			"c.v1.Unknown.Foo":    {},
+		},
+	)
+	assert.Equal(
+		t,
+		&Report{
+			Total:     4,
+			Covered:   2,
+			Percent:   50,
+			Uncovered: []string{"a.v1.FooService.Bar", "a.v1.FooService.Qux"},
+		},
+		report,
+	)
+	assert.Equal(
+		t,
+		&Report{
+			Percent:   100,
+			Uncovered: []string{},
+		},
+		NewReport(nil, nil),
+	)
+}
+
+func TestReadExercisedRPCNames(t *testing.T) {
+	t.Parallel()
+	exercisedRPCNames, err := ReadExercisedRPCNames(
+		strings.NewReader(`
+# comment
+a.v1.FooService.Bar
+/a.v1.FooService/Baz
+{"level":"info","method":"/b.v1.BarService/Foo","duration":"1ms"}
+{"rpc":"b.v1.BarService.Bar"}
+`),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string]struct{}{
+			"a.v1.FooService.Bar": {},
+			"a.v1.FooService.Baz": {},
+			"b.v1.BarService.Foo": {},
+			"b.v1.BarService.Bar": {},
+		},
+		exercisedRPCNames,
+	)
+	exercisedRPCNames, err = ReadExercisedRPCNames(
+		strings.NewReader(` ["a.v1.FooService.Bar", "/a.v1.FooService/Baz"]`),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string]struct{}{
+			"a.v1.FooService.Bar": {},
+			"a.v1.FooService.Baz": {},
+		},
+		exercisedRPCNames,
+	)
+	_, err = ReadExercisedRPCNames(strings.NewReader(`{"level":"info"}`))
+	assert.ErrorContains(t, err, `line 1: no "method" or "rpc" key`)
+}
+
+func TestWriteReport(t *testing.T) {
+	t.Parallel()
+	report := &Report{
+		Total:     3,
+		Covered:   1,
+		Percent:   100.0 / 3,
+		Uncovered: []string{"a.v1.FooService.Bar", "a.v1.FooService.Baz"},
+	}
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteReportText(buffer, report))
+	assert.Equal(
+		t,
+		"a.v1.FooService.Bar\na.v1.FooService.Baz\n1/3 RPCs covered (33.33%)\n",
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, WriteReportJSON(buffer, &Report{Total: 1, Covered: 1, Percent: 100, Uncovered: []string{}}))
+	assert.Equal(
+		t,
+		`{"total":1,"covered":1,"percent":100,"uncovered":[]}`+"\n",
+		buffer.String(),
+	)
+}
@@ -0,0 +1,135 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufdoctor diagnoses common problems with the environment and workspace of buf,
+// and suggests how to fix them.
+package bufdoctor
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+	"github.com/bufbuild/buf/private/pkg/storage"
+)
+
+const (
+	// SeverityOK says that a check passed.
+	SeverityOK Severity = "ok"
+	// SeverityWarning says that a check found a problem that may cause failures.
+	SeverityWarning Severity = "warning"
+	// SeverityError says that a check found a problem that will cause failures.
+	SeverityError Severity = "error"
+)
+
+const (
+	// CheckCache checks that the cache directory is usable.
+	CheckCache = "cache"
+	// CheckModuleCache checks that the modules in the module cache are laid out as expected
+	// and match their digests.
+	CheckModuleCache = "module_cache"
+	// CheckConfig checks that configuration files exist and can be read.
+	CheckConfig = "config"
+	// CheckConfigVersions checks that all modules of a workspace use the same configuration version.
+	CheckConfigVersions = "config_versions"
+	// CheckDuplicatePaths checks that no two files of a workspace have the same path.
+	CheckDuplicatePaths = "duplicate_paths"
+	// CheckCaseCollisions checks that no two files of a workspace have paths that differ only in case.
+	CheckCaseCollisions = "case_collisions"
+	// CheckCredentials checks that credentials are available for a remote.
+	CheckCredentials = "credentials"
+	// CheckRegistry checks that a remote can be reached.
+	CheckRegistry = "registry"
+)
+
+// Severity is the severity of a Finding.
+type Severity string
+
+// String implements fmt.Stringer.
+func (s Severity) String() string {
+	return string(s)
+}
+
+// Finding is the result of a single check.
+type Finding struct {
+	// Check is the name of the check, such as CheckCache.
+	Check string `json:"check"`
+	// Severity is the severity of the finding.
+	Severity Severity `json:"severity"`
+	// Message describes what was found.
+	Message string `json:"message"`
+	// Suggestion describes how to fix the problem.
+	//
+	// Empty if Severity is SeverityOK.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// HasErrors returns true if any of the Findings have SeverityError.
+func HasErrors(findings []*Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// DiagnoseCache checks that the cache directory is a writable directory, and reports
+// legacy cache directories that are no longer used.
+//
+// The cache directory does not need to exist, as it is created when needed.
+// The legacy cache directories are relative to the cache directory.
+func DiagnoseCache(cacheDirPath string, legacyCacheRelDirPaths []string) []*Finding {
+	return diagnoseCache(cacheDirPath, legacyCacheRelDirPaths)
+}
+
+// DiagnoseModuleCache checks that the files within the module cache directory are laid out
+// as expected, that cached commits reference manifests whose files are cached, and that
+// cached blobs and partial modules match their digests.
+//
+// The module cache directory does not need to exist, as it is created when needed.
+func DiagnoseModuleCache(ctx context.Context, moduleCacheDirPath string) []*Finding {
+	return diagnoseModuleCache(ctx, moduleCacheDirPath)
+}
+
+// WorkspaceDiagnosis is the result of DiagnoseWorkspace.
+type WorkspaceDiagnosis struct {
+	Findings []*Finding
+	// Remotes are the remotes of the module names and dependencies declared by the
+	// modules of the workspace, sorted and unique.
+	Remotes []string
+}
+
+// DiagnoseWorkspace checks the workspace or module at the root of the ReadBucket.
+//
+// The root of the ReadBucket is expected to contain a buf.work.yaml or a buf.yaml.
+// Configuration that cannot be read is reported as a Finding, not as an error.
+func DiagnoseWorkspace(ctx context.Context, readBucket storage.ReadBucket) (*WorkspaceDiagnosis, error) {
+	return diagnoseWorkspace(ctx, readBucket)
+}
+
+// GetCurrentUserFunc returns the name of the user authenticated against the remote.
+//
+// The error should be a *connect.Error if the remote was reached.
+type GetCurrentUserFunc func(ctx context.Context, remote string) (string, error)
+
+// DiagnoseRegistry checks that credentials are available from one of the TokenProviders
+// for the remote, and that the remote can be reached and accepts the credentials.
+func DiagnoseRegistry(
+	ctx context.Context,
+	remote string,
+	getCurrentUser GetCurrentUserFunc,
+	tokenProviders ...bufconnect.TokenProvider,
+) []*Finding {
+	return diagnoseRegistry(ctx, remote, getCurrentUser, tokenProviders)
+}
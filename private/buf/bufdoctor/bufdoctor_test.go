@@ -0,0 +1,239 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufdoctor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseCache(t *testing.T) {
+	t.Parallel()
+	cacheDirPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDirPath, "v1", "module", "data"), 0755))
+	findings := DiagnoseCache(filepath.ToSlash(cacheDirPath), []string{"mod", "v1/module/data"})
+	require.Len(t, findings, 2)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+	assert.Equal(t, SeverityWarning, findings[1].Severity)
+	assert.Contains(t, findings[1].Message, filepath.Join(cacheDirPath, "v1", "module", "data"))
+	assert.NotContains(t, findings[1].Message, filepath.Join(cacheDirPath, "mod"))
+	assert.False(t, HasErrors(findings))
+
+	findings = DiagnoseCache(filepath.ToSlash(filepath.Join(cacheDirPath, "notexist")), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+
+	filePath := filepath.Join(cacheDirPath, "file")
+	require.NoError(t, os.WriteFile(filePath, nil, 0600))
+	findings = DiagnoseCache(filepath.ToSlash(filePath), nil)
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.True(t, HasErrors(findings))
+}
+
+func TestDiagnoseModuleCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	moduleCacheDirPath := t.TempDir()
+	findings := DiagnoseModuleCache(ctx, filepath.ToSlash(filepath.Join(moduleCacheDirPath, "notexist")))
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+
+	findings = DiagnoseModuleCache(ctx, filepath.ToSlash(moduleCacheDirPath))
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(moduleCacheDirPath, "buf.build", "acme", "a"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleCacheDirPath, "buf.build", "acme", "a", "file"), nil, 0600))
+	findings = DiagnoseModuleCache(ctx, filepath.ToSlash(moduleCacheDirPath))
+	require.Len(t, findings, 1)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Equal(t, CheckModuleCache, findings[0].Check)
+	assert.Contains(t, findings[0].Message, filepath.Join(moduleCacheDirPath, "buf.build", "acme", "a", "file"))
+	assert.True(t, HasErrors(findings))
+}
+
+func TestDiagnoseWorkspace(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"buf.work.yaml": []byte("version: v1\ndirectories:\n  - a\n  - b\n  - c\n"),
+			"a/buf.yaml":    []byte("version: v1\nname: buf.build/acme/a\ndeps:\n  - buf.build/acme/b\n  - example.com/acme/c\nbuild:\n  excludes:\n    - excluded\n"),
+			"b/buf.yaml":    []byte("version: v1beta1\nbuild:\n  roots:\n    - proto\n"),
+			// c has no configuration file, which is valid.
+			"a/foo/foo.proto":       nil,
+			"a/foo/Bar.proto":       nil,
+			"a/excluded/foo.proto":  nil,
+			"b/proto/foo/foo.proto": nil,
+			"b/other/foo/bar.proto": nil,
+			"c/foo/bar.proto":       nil,
+		},
+	)
+	require.NoError(t, err)
+	workspaceDiagnosis, err := DiagnoseWorkspace(context.Background(), readBucket)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"buf.build", "example.com"}, workspaceDiagnosis.Remotes)
+	assert.Equal(
+		t,
+		[]*Finding{
+			{
+				Check:      CheckConfigVersions,
+				Severity:   SeverityWarning,
+				Message:    `Modules use different configuration versions: "v1" (a/buf.yaml), "v1beta1" (b/buf.yaml).`,
+				Suggestion: `Run "buf beta migrate-v1beta1" to migrate configuration from version "v1beta1".`,
+			},
+			{
+				Check:      CheckDuplicatePaths,
+				Severity:   SeverityError,
+				Message:    `"foo/foo.proto" is provided by multiple files: "a/foo/foo.proto" and "b/proto/foo/foo.proto".`,
+				Suggestion: "Rename or remove all but one of the files, or exclude the others with build.excludes.",
+			},
+			{
+				Check:      CheckCaseCollisions,
+				Severity:   SeverityWarning,
+				Message:    `Paths "foo/Bar.proto" and "foo/bar.proto" differ only in case.`,
+				Suggestion: "Rename the files so that the workspace can be used on case-insensitive file systems, such as the defaults on macOS and Windows.",
+			},
+		},
+		workspaceDiagnosis.Findings,
+	)
+}
+
+func TestDiagnoseWorkspaceModule(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"buf.yaml":        []byte("version: v1\n"),
+			"foo/foo.proto":   nil,
+			"foo/bar.proto":   nil,
+			"foo/README.md":   nil,
+			"other/foo.proto": nil,
+		},
+	)
+	require.NoError(t, err)
+	workspaceDiagnosis, err := DiagnoseWorkspace(context.Background(), readBucket)
+	require.NoError(t, err)
+	assert.Empty(t, workspaceDiagnosis.Remotes)
+	assert.Equal(
+		t,
+		[]*Finding{
+			{
+				Check:    CheckConfigVersions,
+				Severity: SeverityOK,
+				Message:  `All modules use configuration version "v1".`,
+			},
+			{
+				Check:    CheckDuplicatePaths,
+				Severity: SeverityOK,
+				Message:  "All 3 files have unique paths.",
+			},
+			{
+				Check:    CheckCaseCollisions,
+				Severity: SeverityOK,
+				Message:  "No paths differ only in case.",
+			},
+		},
+		workspaceDiagnosis.Findings,
+	)
+}
+
+func TestDiagnoseWorkspaceConfigErrors(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"foo/foo.proto": nil,
+		},
+	)
+	require.NoError(t, err)
+	workspaceDiagnosis, err := DiagnoseWorkspace(context.Background(), readBucket)
+	require.NoError(t, err)
+	require.Len(t, workspaceDiagnosis.Findings, 1)
+	assert.Equal(t, CheckConfig, workspaceDiagnosis.Findings[0].Check)
+	assert.Equal(t, SeverityError, workspaceDiagnosis.Findings[0].Severity)
+
+	readBucket, err = storagemem.NewReadBucket(
+		map[string][]byte{
+			"buf.yaml": []byte("version: [v1\n"),
+		},
+	)
+	require.NoError(t, err)
+	workspaceDiagnosis, err = DiagnoseWorkspace(context.Background(), readBucket)
+	require.NoError(t, err)
+	require.Len(t, workspaceDiagnosis.Findings, 1)
+	assert.Equal(t, CheckConfig, workspaceDiagnosis.Findings[0].Check)
+	assert.Equal(t, SeverityError, workspaceDiagnosis.Findings[0].Severity)
+}
+
+func TestDiagnoseRegistry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	tokenProvider, err := bufconnect.NewTokenProviderFromString("token")
+	require.NoError(t, err)
+	getCurrentUserErr := func(err error) GetCurrentUserFunc {
+		return func(context.Context, string) (string, error) {
+			return "", err
+		}
+	}
+	getCurrentUserOK := func(context.Context, string) (string, error) {
+		return "alice", nil
+	}
+
+	findings := DiagnoseRegistry(ctx, "buf.build", getCurrentUserOK, tokenProvider)
+	require.Len(t, findings, 2)
+	assert.Equal(t, SeverityOK, findings[0].Severity)
+	assert.Equal(t, `Credentials for "buf.build" found in .netrc.`, findings[0].Message)
+	assert.Equal(t, `"buf.build" is reachable, authenticated as "alice".`, findings[1].Message)
+
+	findings = DiagnoseRegistry(
+		ctx,
+		"buf.build",
+		getCurrentUserErr(connect.NewError(connect.CodeUnauthenticated, errors.New("unauthenticated"))),
+	)
+	require.Len(t, findings, 2)
+	assert.Equal(t, CheckCredentials, findings[0].Check)
+	assert.Equal(t, SeverityWarning, findings[0].Severity)
+	assert.Equal(t, CheckRegistry, findings[1].Check)
+	assert.Equal(t, SeverityOK, findings[1].Severity)
+	assert.False(t, HasErrors(findings))
+
+	findings = DiagnoseRegistry(
+		ctx,
+		"buf.build",
+		getCurrentUserErr(connect.NewError(connect.CodeUnauthenticated, errors.New("unauthenticated"))),
+		tokenProvider,
+	)
+	require.Len(t, findings, 2)
+	assert.Equal(t, CheckCredentials, findings[1].Check)
+	assert.Equal(t, SeverityError, findings[1].Severity)
+
+	findings = DiagnoseRegistry(
+		ctx,
+		"buf.build",
+		getCurrentUserErr(connect.NewError(connect.CodeUnavailable, errors.New("dial tcp: no such host"))),
+		tokenProvider,
+	)
+	require.Len(t, findings, 2)
+	assert.Equal(t, CheckRegistry, findings[1].Check)
+	assert.Equal(t, SeverityError, findings[1].Severity)
+}
@@ -0,0 +1,178 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufdoctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulecache"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+)
+
+const (
+	cacheDirSuggestion    = "Set $BUF_CACHE_DIR to a writable directory to use a different cache directory."
+	clearCacheSuggestion  = `Run "buf mod clear-cache" to remove them.`
+	moduleCacheSuggestion = `Run "buf mod clear-cache" to clear the module cache. Modules are downloaded again when needed.`
+)
+
+func diagnoseCache(cacheDirPath string, legacyCacheRelDirPaths []string) []*Finding {
+	osCacheDirPath := normalpath.Unnormalize(cacheDirPath)
+	// OK to use os.Stat instead of os.LStat here as this is CLI-only
+	fileInfo, err := os.Stat(osCacheDirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Finding{
+				{
+					Check:    CheckCache,
+					Severity: SeverityOK,
+					Message:  fmt.Sprintf("Cache directory %q does not exist yet and will be created when needed.", osCacheDirPath),
+				},
+			}
+		}
+		return []*Finding{
+			{
+				Check:      CheckCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Cache directory %q could not be read: %v.", osCacheDirPath, err),
+				Suggestion: cacheDirSuggestion,
+			},
+		}
+	}
+	if !fileInfo.IsDir() {
+		return []*Finding{
+			{
+				Check:      CheckCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Cache directory %q is not a directory.", osCacheDirPath),
+				Suggestion: cacheDirSuggestion,
+			},
+		}
+	}
+	// Checking the permission bits is not enough, as the directory may be owned by another user.
+	file, err := os.CreateTemp(osCacheDirPath, ".buf-doctor-")
+	if err != nil {
+		return []*Finding{
+			{
+				Check:      CheckCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Cache directory %q is not writable: %v.", osCacheDirPath, err),
+				Suggestion: cacheDirSuggestion,
+			},
+		}
+	}
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+	findings := []*Finding{
+		{
+			Check:    CheckCache,
+			Severity: SeverityOK,
+			Message:  fmt.Sprintf("Cache directory %q is writable.", osCacheDirPath),
+		},
+	}
+	var existingLegacyCacheDirPaths []string
+	for _, legacyCacheRelDirPath := range legacyCacheRelDirPaths {
+		legacyCacheDirPath := normalpath.Unnormalize(normalpath.Join(cacheDirPath, legacyCacheRelDirPath))
+		if _, err := os.Stat(legacyCacheDirPath); err == nil {
+			existingLegacyCacheDirPaths = append(existingLegacyCacheDirPaths, legacyCacheDirPath)
+		}
+	}
+	if len(existingLegacyCacheDirPaths) > 0 {
+		findings = append(
+			findings,
+			&Finding{
+				Check:    CheckCache,
+				Severity: SeverityWarning,
+				Message: fmt.Sprintf(
+					"Cache directories %s were written by older versions of buf and are no longer used.",
+					stringutil.SliceToHumanStringQuoted(existingLegacyCacheDirPaths),
+				),
+				Suggestion: clearCacheSuggestion,
+			},
+		)
+	}
+	return findings
+}
+
+func diagnoseModuleCache(ctx context.Context, moduleCacheDirPath string) []*Finding {
+	osModuleCacheDirPath := normalpath.Unnormalize(moduleCacheDirPath)
+	// OK to use os.Stat instead of os.LStat here as this is CLI-only
+	if _, err := os.Stat(osModuleCacheDirPath); err != nil {
+		if os.IsNotExist(err) {
+			return []*Finding{
+				{
+					Check:    CheckModuleCache,
+					Severity: SeverityOK,
+					Message:  fmt.Sprintf("Module cache directory %q does not exist yet and will be created when needed.", osModuleCacheDirPath),
+				},
+			}
+		}
+		return []*Finding{
+			{
+				Check:      CheckModuleCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Module cache directory %q could not be read: %v.", osModuleCacheDirPath, err),
+				Suggestion: cacheDirSuggestion,
+			},
+		}
+	}
+	readBucket, err := storageos.NewProvider(storageos.ProviderWithSymlinks()).NewReadWriteBucket(osModuleCacheDirPath)
+	if err != nil {
+		return []*Finding{
+			{
+				Check:      CheckModuleCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Module cache directory %q could not be read: %v.", osModuleCacheDirPath, err),
+				Suggestion: cacheDirSuggestion,
+			},
+		}
+	}
+	cacheIssues, err := bufmodulecache.CheckCache(ctx, readBucket)
+	if err != nil {
+		return []*Finding{
+			{
+				Check:      CheckModuleCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Module cache directory %q could not be read: %v.", osModuleCacheDirPath, err),
+				Suggestion: moduleCacheSuggestion,
+			},
+		}
+	}
+	if len(cacheIssues) == 0 {
+		return []*Finding{
+			{
+				Check:    CheckModuleCache,
+				Severity: SeverityOK,
+				Message:  fmt.Sprintf("Module cache directory %q is consistent.", osModuleCacheDirPath),
+			},
+		}
+	}
+	findings := make([]*Finding, 0, len(cacheIssues))
+	for _, cacheIssue := range cacheIssues {
+		findings = append(
+			findings,
+			&Finding{
+				Check:      CheckModuleCache,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("Module cache file %q is invalid: %s.", cacheIssue.Path, cacheIssue.Message),
+				Suggestion: moduleCacheSuggestion,
+			},
+		)
+	}
+	return findings
+}
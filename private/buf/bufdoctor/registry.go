@@ -0,0 +1,111 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufdoctor
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+)
+
+func diagnoseRegistry(
+	ctx context.Context,
+	remote string,
+	getCurrentUser GetCurrentUserFunc,
+	tokenProviders []bufconnect.TokenProvider,
+) []*Finding {
+	var tokenSource string
+	for _, tokenProvider := range tokenProviders {
+		if tokenProvider.RemoteToken(remote) == "" {
+			continue
+		}
+		tokenSource = ".netrc"
+		if tokenProvider.IsFromEnvVar() {
+			tokenSource = "$BUF_TOKEN"
+		}
+		break
+	}
+	var findings []*Finding
+	if tokenSource == "" {
+		findings = append(
+			findings,
+			&Finding{
+				Check:    CheckCredentials,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("No credentials found for %q. Private modules cannot be used and modules cannot be pushed.", remote),
+				Suggestion: fmt.Sprintf(
+					`Run "buf registry login %s", or set $BUF_TOKEN.`,
+					remote,
+				),
+			},
+		)
+	} else {
+		findings = append(
+			findings,
+			&Finding{
+				Check:    CheckCredentials,
+				Severity: SeverityOK,
+				Message:  fmt.Sprintf("Credentials for %q found in %s.", remote, tokenSource),
+			},
+		)
+	}
+	username, err := getCurrentUser(ctx, remote)
+	switch {
+	case err == nil:
+		return append(
+			findings,
+			&Finding{
+				Check:    CheckRegistry,
+				Severity: SeverityOK,
+				Message:  fmt.Sprintf("%q is reachable, authenticated as %q.", remote, username),
+			},
+		)
+	case connect.CodeOf(err) == connect.CodeUnauthenticated:
+		if tokenSource == "" {
+			return append(
+				findings,
+				&Finding{
+					Check:    CheckRegistry,
+					Severity: SeverityOK,
+					Message:  fmt.Sprintf("%q is reachable.", remote),
+				},
+			)
+		}
+		return append(
+			findings,
+			&Finding{
+				Check:    CheckCredentials,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%q is reachable, but rejected the credentials found in %s.", remote, tokenSource),
+				Suggestion: fmt.Sprintf(
+					`Run "buf registry login %s" to replace the credentials, or unset $BUF_TOKEN if it is outdated.`,
+					remote,
+				),
+			},
+		)
+	default:
+		return append(
+			findings,
+			&Finding{
+				Check:      CheckRegistry,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("%q could not be reached: %v.", remote, err),
+				Suggestion: "Check your network connection and proxy settings, and that the remote is spelled correctly.",
+			},
+		)
+	}
+}
@@ -0,0 +1,347 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufdoctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufwork"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+)
+
+// externalModuleConfig is the subset of all versions of buf.yaml that is checked.
+//
+// We do not use bufconfig.GetConfigForBucket, as we want to diagnose configuration
+// that it would reject, such as a workspace that mixes configuration versions.
+type externalModuleConfig struct {
+	Version string   `json:"version,omitempty" yaml:"version,omitempty"`
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Deps    []string `json:"deps,omitempty" yaml:"deps,omitempty"`
+	Build   struct {
+		// Roots is only valid for v1beta1.
+		Roots    []string `json:"roots,omitempty" yaml:"roots,omitempty"`
+		Excludes []string `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	} `json:"build,omitempty" yaml:"build,omitempty"`
+}
+
+type moduleConfig struct {
+	dirPath string
+	// empty if the module has no configuration file.
+	configFilePath string
+	externalConfig *externalModuleConfig
+}
+
+func diagnoseWorkspace(ctx context.Context, readBucket storage.ReadBucket) (*WorkspaceDiagnosis, error) {
+	moduleConfigs, findings, err := readModuleConfigs(ctx, readBucket)
+	if err != nil {
+		return nil, err
+	}
+	if len(findings) > 0 {
+		return &WorkspaceDiagnosis{
+			Findings: findings,
+		}, nil
+	}
+	findings = append(findings, diagnoseConfigVersions(moduleConfigs)...)
+	pathToExternalPaths, err := getPathToExternalPaths(ctx, readBucket, moduleConfigs)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, diagnoseDuplicatePaths(pathToExternalPaths)...)
+	findings = append(findings, diagnoseCaseCollisions(pathToExternalPaths)...)
+	return &WorkspaceDiagnosis{
+		Findings: findings,
+		Remotes:  getRemotes(moduleConfigs),
+	}, nil
+}
+
+// readModuleConfigs returns the configuration of each module.
+//
+// If the configuration cannot be read, Findings are returned instead.
+func readModuleConfigs(ctx context.Context, readBucket storage.ReadBucket) ([]*moduleConfig, []*Finding, error) {
+	workConfigFilePath, err := bufwork.ExistingConfigFilePath(ctx, readBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	moduleDirPaths := []string{"."}
+	if workConfigFilePath != "" {
+		workConfig, err := bufwork.GetConfigForBucket(ctx, readBucket, ".")
+		if err != nil {
+			return nil, []*Finding{
+				{
+					Check:      CheckConfig,
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("%s could not be read: %v.", workConfigFilePath, err),
+					Suggestion: fmt.Sprintf("Fix %s so that it lists the directories of the modules of the workspace.", workConfigFilePath),
+				},
+			}, nil
+		}
+		moduleDirPaths = workConfig.Directories
+	}
+	var findings []*Finding
+	moduleConfigs := make([]*moduleConfig, 0, len(moduleDirPaths))
+	for _, moduleDirPath := range moduleDirPaths {
+		moduleReadBucket := storage.MapReadBucket(readBucket, storage.MapOnPrefix(moduleDirPath))
+		configFilePath, err := bufconfig.ExistingConfigFilePath(ctx, moduleReadBucket)
+		if err != nil {
+			return nil, nil, err
+		}
+		if configFilePath == "" {
+			if workConfigFilePath == "" {
+				return nil, []*Finding{
+					{
+						Check:      CheckConfig,
+						Severity:   SeverityError,
+						Message:    fmt.Sprintf("No %s or %s found.", bufwork.ExternalConfigV1FilePath, bufconfig.ExternalConfigV1FilePath),
+						Suggestion: `Run this command from the root of your workspace or module, or run "buf mod init" to create a module.`,
+					},
+				}, nil
+			}
+			// Directories of a workspace do not need to contain a configuration file.
+			moduleConfigs = append(
+				moduleConfigs,
+				&moduleConfig{
+					dirPath:        moduleDirPath,
+					externalConfig: &externalModuleConfig{},
+				},
+			)
+			continue
+		}
+		externalConfigFilePath := normalpath.Join(moduleDirPath, configFilePath)
+		data, err := storage.ReadPath(ctx, moduleReadBucket, configFilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		externalConfig := &externalModuleConfig{}
+		if err := encoding.UnmarshalYAMLNonStrict(data, externalConfig); err != nil {
+			findings = append(
+				findings,
+				&Finding{
+					Check:      CheckConfig,
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("%s could not be read: %v.", externalConfigFilePath, err),
+					Suggestion: fmt.Sprintf("Fix the syntax of %s.", externalConfigFilePath),
+				},
+			)
+			continue
+		}
+		moduleConfigs = append(
+			moduleConfigs,
+			&moduleConfig{
+				dirPath:        moduleDirPath,
+				configFilePath: externalConfigFilePath,
+				externalConfig: externalConfig,
+			},
+		)
+	}
+	return moduleConfigs, findings, nil
+}
+
+func diagnoseConfigVersions(moduleConfigs []*moduleConfig) []*Finding {
+	versionToConfigFilePaths := make(map[string][]string)
+	for _, moduleConfig := range moduleConfigs {
+		if moduleConfig.configFilePath == "" {
+			continue
+		}
+		version := moduleConfig.externalConfig.Version
+		versionToConfigFilePaths[version] = append(versionToConfigFilePaths[version], moduleConfig.configFilePath)
+	}
+	versions := make([]string, 0, len(versionToConfigFilePaths))
+	for version := range versionToConfigFilePaths {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	switch len(versions) {
+	case 0:
+		return nil
+	case 1:
+		return []*Finding{
+			{
+				Check:    CheckConfigVersions,
+				Severity: SeverityOK,
+				Message:  fmt.Sprintf("All modules use configuration version %q.", versions[0]),
+			},
+		}
+	}
+	versionStrings := make([]string, len(versions))
+	for i, version := range versions {
+		versionStrings[i] = fmt.Sprintf("%q (%s)", version, strings.Join(versionToConfigFilePaths[version], ", "))
+	}
+	suggestion := fmt.Sprintf("Use configuration version %q for all modules.", bufconfig.V1Version)
+	if _, ok := versionToConfigFilePaths[bufconfig.V1Beta1Version]; ok {
+		suggestion = `Run "buf beta migrate-v1beta1" to migrate configuration from version "v1beta1".`
+	}
+	return []*Finding{
+		{
+			Check:      CheckConfigVersions,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("Modules use different configuration versions: %s.", strings.Join(versionStrings, ", ")),
+			Suggestion: suggestion,
+		},
+	}
+}
+
+// getPathToExternalPaths returns a map from the path of each .proto file relative to its
+// root, to the paths of the files with that path relative to the root of the workspace.
+func getPathToExternalPaths(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	moduleConfigs []*moduleConfig,
+) (map[string][]string, error) {
+	pathToExternalPaths := make(map[string][]string)
+	for _, moduleConfig := range moduleConfigs {
+		roots := []string{"."}
+		if moduleConfig.externalConfig.Version == bufconfig.V1Beta1Version && len(moduleConfig.externalConfig.Build.Roots) > 0 {
+			roots = make([]string, len(moduleConfig.externalConfig.Build.Roots))
+			for i, root := range moduleConfig.externalConfig.Build.Roots {
+				roots[i] = normalpath.Normalize(root)
+			}
+		}
+		excludes := make([]string, len(moduleConfig.externalConfig.Build.Excludes))
+		for i, exclude := range moduleConfig.externalConfig.Build.Excludes {
+			excludes[i] = normalpath.Normalize(exclude)
+		}
+		moduleReadBucket := storage.MapReadBucket(
+			readBucket,
+			storage.MapOnPrefix(moduleConfig.dirPath),
+			storage.MatchPathExt(".proto"),
+		)
+		for _, root := range roots {
+			prefix := root
+			if prefix == "." {
+				prefix = ""
+			}
+			if err := moduleReadBucket.Walk(
+				ctx,
+				prefix,
+				func(objectInfo storage.ObjectInfo) error {
+					path := objectInfo.Path()
+					for _, exclude := range excludes {
+						if normalpath.EqualsOrContainsPath(exclude, path, normalpath.Relative) {
+							return nil
+						}
+					}
+					relPath, err := normalpath.Rel(root, path)
+					if err != nil {
+						return err
+					}
+					pathToExternalPaths[relPath] = append(
+						pathToExternalPaths[relPath],
+						normalpath.Join(moduleConfig.dirPath, path),
+					)
+					return nil
+				},
+			); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pathToExternalPaths, nil
+}
+
+func diagnoseDuplicatePaths(pathToExternalPaths map[string][]string) []*Finding {
+	var findings []*Finding
+	for _, path := range slicesext.MapKeysToSortedSlice(pathToExternalPaths) {
+		externalPaths := pathToExternalPaths[path]
+		if len(externalPaths) < 2 {
+			continue
+		}
+		findings = append(
+			findings,
+			&Finding{
+				Check:    CheckDuplicatePaths,
+				Severity: SeverityError,
+				Message: fmt.Sprintf(
+					"%q is provided by multiple files: %s.",
+					path,
+					stringutil.SliceToHumanStringQuoted(externalPaths),
+				),
+				Suggestion: "Rename or remove all but one of the files, or exclude the others with build.excludes.",
+			},
+		)
+	}
+	if len(findings) == 0 {
+		return []*Finding{
+			{
+				Check:    CheckDuplicatePaths,
+				Severity: SeverityOK,
+				Message:  fmt.Sprintf("All %d files have unique paths.", len(pathToExternalPaths)),
+			},
+		}
+	}
+	return findings
+}
+
+func diagnoseCaseCollisions(pathToExternalPaths map[string][]string) []*Finding {
+	lowerPathToPaths := make(map[string][]string)
+	for _, path := range slicesext.MapKeysToSortedSlice(pathToExternalPaths) {
+		lowerPath := strings.ToLower(path)
+		lowerPathToPaths[lowerPath] = append(lowerPathToPaths[lowerPath], path)
+	}
+	var findings []*Finding
+	for _, lowerPath := range slicesext.MapKeysToSortedSlice(lowerPathToPaths) {
+		paths := lowerPathToPaths[lowerPath]
+		if len(paths) < 2 {
+			continue
+		}
+		findings = append(
+			findings,
+			&Finding{
+				Check:      CheckCaseCollisions,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("Paths %s differ only in case.", stringutil.SliceToHumanStringQuoted(paths)),
+				Suggestion: "Rename the files so that the workspace can be used on case-insensitive file systems, such as the defaults on macOS and Windows.",
+			},
+		)
+	}
+	if len(findings) == 0 {
+		return []*Finding{
+			{
+				Check:    CheckCaseCollisions,
+				Severity: SeverityOK,
+				Message:  "No paths differ only in case.",
+			},
+		}
+	}
+	return findings
+}
+
+// getRemotes returns the remotes of the names and dependencies of the modules.
+//
+// Invalid names and dependencies are ignored, as they are reported when building.
+func getRemotes(moduleConfigs []*moduleConfig) []string {
+	remoteMap := make(map[string]struct{})
+	for _, moduleConfig := range moduleConfigs {
+		if name := moduleConfig.externalConfig.Name; name != "" {
+			if moduleIdentity, err := bufmoduleref.ModuleIdentityForString(name); err == nil {
+				remoteMap[moduleIdentity.Remote()] = struct{}{}
+			}
+		}
+		for _, dep := range moduleConfig.externalConfig.Deps {
+			if moduleReference, err := bufmoduleref.ModuleReferenceForString(dep); err == nil {
+				remoteMap[moduleReference.Remote()] = struct{}{}
+			}
+		}
+	}
+	return slicesext.MapKeysToSortedSlice(remoteMap)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufenum audits the value usage and reservations of enums.
+package bufenum
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Report is the audit of a single enum.
+type Report struct {
+	// Name is the fully-qualified name of the enum.
+	Name string `json:"name,omitempty"`
+	// Path is the path of the file the enum is declared in.
+	Path string `json:"path,omitempty"`
+	// NumValues is the number of values, including aliases.
+	NumValues int `json:"num_values"`
+	// MissingZeroValue is true if no value has the number zero.
+	MissingZeroValue bool `json:"missing_zero_value,omitempty"`
+	// Allocated is the range from the lowest to the highest value number.
+	//
+	// Nil if the enum has no values.
+	Allocated *Range `json:"allocated,omitempty"`
+	// Reserved are the reserved ranges, sorted.
+	Reserved []Range `json:"reserved,omitempty"`
+	// Gaps are the ranges within Allocated that are neither used nor reserved, sorted.
+	Gaps []Range `json:"gaps,omitempty"`
+	// RemovedValues are the values of the against enum that were removed
+	// without reserving both their number and name, sorted by number.
+	RemovedValues []RemovedValue `json:"removed_values,omitempty"`
+}
+
+// HasIssues returns true if the enum is missing a zero value, has gaps, or has
+// unreserved removed values.
+func (r *Report) HasIssues() bool {
+	return r.MissingZeroValue || len(r.Gaps) > 0 || len(r.RemovedValues) > 0
+}
+
+// Range is an inclusive range of enum value numbers.
+type Range struct {
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
+
+// RemovedValue is a value that was removed without being fully reserved.
+type RemovedValue struct {
+	Name           string `json:"name,omitempty"`
+	Number         int32  `json:"number"`
+	NumberReserved bool   `json:"number_reserved,omitempty"`
+	NameReserved   bool   `json:"name_reserved,omitempty"`
+}
+
+// NewReports returns the Reports for the enums.
+//
+// The against enums are matched to the enums by fully-qualified name to find removed values.
+// The against enums may be empty, in which case no removed values are reported.
+func NewReports(
+	enumDescriptors []protoreflect.EnumDescriptor,
+	againstEnumDescriptors []protoreflect.EnumDescriptor,
+) []*Report {
+	return newReports(enumDescriptors, againstEnumDescriptors)
+}
+
+// EnumDescriptorsForFile returns all enums declared in the file, including nested enums.
+func EnumDescriptorsForFile(fileDescriptor protoreflect.FileDescriptor) []protoreflect.EnumDescriptor {
+	enumDescriptors := appendEnumDescriptors(nil, fileDescriptor.Enums())
+	return appendMessageEnumDescriptors(enumDescriptors, fileDescriptor.Messages())
+}
@@ -0,0 +1,82 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufenum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestNewReports(t *testing.T) {
+	t.Parallel()
+	enumDescriptors := EnumDescriptorsForFile(testCompile(t, "./testdata/current"))
+	againstEnumDescriptors := EnumDescriptorsForFile(testCompile(t, "./testdata/against"))
+	assert.Equal(
+		t,
+		[]*Report{
+			{
+				Name:             "bufenum.test.Status",
+				Path:             "enum.proto",
+				NumValues:        3,
+				MissingZeroValue: true,
+				Allocated:        &Range{Start: 1, End: 10},
+				Reserved:         []Range{{Start: 3, End: 3}, {Start: 7, End: 8}},
+				Gaps:             []Range{{Start: 2, End: 2}, {Start: 4, End: 4}, {Start: 6, End: 6}, {Start: 9, End: 9}},
+				RemovedValues: []RemovedValue{
+					{Name: "STATUS_TWO", Number: 2},
+					{Name: "STATUS_SEVEN", Number: 7, NumberReserved: true},
+				},
+			},
+			{
+				Name:      "bufenum.test.Outer.Kind",
+				Path:      "enum.proto",
+				NumValues: 2,
+				Allocated: &Range{Start: 0, End: 1},
+			},
+		},
+		NewReports(enumDescriptors, againstEnumDescriptors),
+	)
+	reports := NewReports(enumDescriptors, nil)
+	require.Len(t, reports, 2)
+	assert.Empty(t, reports[0].RemovedValues)
+	assert.True(t, reports[0].HasIssues())
+	assert.False(t, reports[1].HasIssues())
+}
+
+func TestSubtractRanges(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []Range{{Start: 1, End: 10}}, subtractRanges(Range{Start: 1, End: 10}, nil))
+	assert.Nil(t, subtractRanges(Range{Start: 2, End: 4}, []Range{{Start: 1, End: 5}}))
+	assert.Equal(
+		t,
+		[]Range{{Start: 1, End: 1}, {Start: 4, End: 5}, {Start: 9, End: 10}},
+		subtractRanges(Range{Start: 1, End: 10}, []Range{{Start: 2, End: 3}, {Start: 6, End: 8}, {Start: 20, End: 30}}),
+	)
+}
+
+func testCompile(t *testing.T, dirPath string) protoreflect.FileDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			ImportPaths: []string{dirPath},
+		},
+	}).Compile(context.Background(), "enum.proto")
+	require.NoError(t, err)
+	return files[0]
+}
@@ -0,0 +1,177 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufenum
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func newReports(
+	enumDescriptors []protoreflect.EnumDescriptor,
+	againstEnumDescriptors []protoreflect.EnumDescriptor,
+) []*Report {
+	fullNameToAgainstEnumDescriptor := make(map[protoreflect.FullName]protoreflect.EnumDescriptor, len(againstEnumDescriptors))
+	for _, againstEnumDescriptor := range againstEnumDescriptors {
+		fullNameToAgainstEnumDescriptor[againstEnumDescriptor.FullName()] = againstEnumDescriptor
+	}
+	reports := make([]*Report, len(enumDescriptors))
+	for i, enumDescriptor := range enumDescriptors {
+		reports[i] = newReport(enumDescriptor, fullNameToAgainstEnumDescriptor[enumDescriptor.FullName()])
+	}
+	return reports
+}
+
+// newReport returns the Report for the enum. againstEnumDescriptor may be nil.
+func newReport(
+	enumDescriptor protoreflect.EnumDescriptor,
+	againstEnumDescriptor protoreflect.EnumDescriptor,
+) *Report {
+	values := enumDescriptor.Values()
+	report := &Report{
+		Name:      string(enumDescriptor.FullName()),
+		Path:      enumDescriptor.ParentFile().Path(),
+		NumValues: values.Len(),
+		Reserved:  getReservedRanges(enumDescriptor),
+	}
+	numberMap := make(map[int32]struct{}, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		numberMap[int32(values.Get(i).Number())] = struct{}{}
+	}
+	if _, ok := numberMap[0]; !ok {
+		report.MissingZeroValue = true
+	}
+	numbers := make([]int32, 0, len(numberMap))
+	for number := range numberMap {
+		numbers = append(numbers, number)
+	}
+	sort.Slice(numbers, func(i int, j int) bool { return numbers[i] < numbers[j] })
+	if len(numbers) > 0 {
+		report.Allocated = &Range{
+			Start: numbers[0],
+			End:   numbers[len(numbers)-1],
+		}
+	}
+	for i := 1; i < len(numbers); i++ {
+		if numbers[i]-numbers[i-1] > 1 {
+			report.Gaps = append(
+				report.Gaps,
+				subtractRanges(
+					Range{
+						Start: numbers[i-1] + 1,
+						End:   numbers[i] - 1,
+					},
+					report.Reserved,
+				)...,
+			)
+		}
+	}
+	if againstEnumDescriptor != nil {
+		report.RemovedValues = getRemovedValues(enumDescriptor, againstEnumDescriptor, numberMap)
+	}
+	return report
+}
+
+func getReservedRanges(enumDescriptor protoreflect.EnumDescriptor) []Range {
+	reservedRanges := enumDescriptor.ReservedRanges()
+	if reservedRanges.Len() == 0 {
+		return nil
+	}
+	ranges := make([]Range, reservedRanges.Len())
+	for i := 0; i < reservedRanges.Len(); i++ {
+		reservedRange := reservedRanges.Get(i)
+		ranges[i] = Range{
+			Start: int32(reservedRange[0]),
+			End:   int32(reservedRange[1]),
+		}
+	}
+	sort.Slice(ranges, func(i int, j int) bool { return ranges[i].Start < ranges[j].Start })
+	return ranges
+}
+
+// getRemovedValues returns the values of the against enum whose numbers are no longer
+// used by the enum, and whose number or name is not reserved.
+func getRemovedValues(
+	enumDescriptor protoreflect.EnumDescriptor,
+	againstEnumDescriptor protoreflect.EnumDescriptor,
+	numberMap map[int32]struct{},
+) []RemovedValue {
+	var removedValues []RemovedValue
+	againstValues := againstEnumDescriptor.Values()
+	for i := 0; i < againstValues.Len(); i++ {
+		againstValue := againstValues.Get(i)
+		number := int32(againstValue.Number())
+		if _, ok := numberMap[number]; ok {
+			continue
+		}
+		removedValue := RemovedValue{
+			Name:           string(againstValue.Name()),
+			Number:         number,
+			NumberReserved: enumDescriptor.ReservedRanges().Has(againstValue.Number()),
+			NameReserved:   enumDescriptor.ReservedNames().Has(againstValue.Name()),
+		}
+		if removedValue.NumberReserved && removedValue.NameReserved {
+			continue
+		}
+		removedValues = append(removedValues, removedValue)
+	}
+	sort.SliceStable(removedValues, func(i int, j int) bool { return removedValues[i].Number < removedValues[j].Number })
+	return removedValues
+}
+
+// subtractRanges returns the parts of the range not covered by the sorted ranges.
+func subtractRanges(r Range, sortedRanges []Range) []Range {
+	var result []Range
+	start := r.Start
+	for _, sortedRange := range sortedRanges {
+		if sortedRange.End < start {
+			continue
+		}
+		if sortedRange.Start > r.End {
+			break
+		}
+		if sortedRange.Start > start {
+			result = append(result, Range{Start: start, End: sortedRange.Start - 1})
+		}
+		if sortedRange.End >= r.End {
+			return result
+		}
+		start = sortedRange.End + 1
+	}
+	return append(result, Range{Start: start, End: r.End})
+}
+
+func appendEnumDescriptors(
+	enumDescriptors []protoreflect.EnumDescriptor,
+	enums protoreflect.EnumDescriptors,
+) []protoreflect.EnumDescriptor {
+	for i := 0; i < enums.Len(); i++ {
+		enumDescriptors = append(enumDescriptors, enums.Get(i))
+	}
+	return enumDescriptors
+}
+
+func appendMessageEnumDescriptors(
+	enumDescriptors []protoreflect.EnumDescriptor,
+	messages protoreflect.MessageDescriptors,
+) []protoreflect.EnumDescriptor {
+	for i := 0; i < messages.Len(); i++ {
+		message := messages.Get(i)
+		enumDescriptors = appendEnumDescriptors(enumDescriptors, message.Enums())
+		enumDescriptors = appendMessageEnumDescriptors(enumDescriptors, message.Messages())
+	}
+	return enumDescriptors
+}
@@ -0,0 +1,81 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufenvoy generates configuration for the Envoy gRPC-JSON transcoder.
+package bufenvoy
+
+import (
+	"io"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// TranscoderFilterName is the name of the Envoy gRPC-JSON transcoder filter.
+	TranscoderFilterName = "envoy.filters.http.grpc_json_transcoder"
+	// TranscoderConfigTypeURL is the type URL of the Envoy gRPC-JSON transcoder filter configuration.
+	TranscoderConfigTypeURL = "type.googleapis.com/envoy.extensions.filters.http.grpc_json_transcoder.v3.GrpcJsonTranscoder"
+)
+
+// HTTPRule is an HTTP binding of a method from a google.api.http annotation.
+type HTTPRule struct {
+	// Method is the HTTP method, such as GET, or the kind of a custom pattern.
+	Method string
+	// Path is the path template, such as /v1/{name=shelves/*}.
+	Path string
+	// Body is the request field mapped to the HTTP request body, "*" for all
+	// fields not bound by the path, or empty for no body.
+	Body string
+	// ResponseBody is the response field mapped to the HTTP response body, or
+	// empty for the entire response.
+	ResponseBody string
+}
+
+// HTTPRulesForMethod returns the HTTP bindings of the method.
+//
+// The google.api.http annotation is read from the method options, and its additional
+// bindings are returned after the primary binding. Returns an empty slice if the method
+// has no annotation.
+func HTTPRulesForMethod(methodDescriptor protoreflect.MethodDescriptor) ([]*HTTPRule, error) {
+	return httpRulesForMethod(methodDescriptor)
+}
+
+// ValidateServices validates that the services can be transcoded.
+//
+// Every method must have at least one HTTP binding, every path template must be
+// valid and its variables must refer to singular scalar fields of the request, and
+// the body and response body must refer to fields of the request and response. All
+// problems are returned together.
+func ValidateServices(serviceDescriptors []protoreflect.ServiceDescriptor) error {
+	return validateServices(serviceDescriptors)
+}
+
+// TranscoderConfig is the configuration to generate for the Envoy gRPC-JSON transcoder.
+type TranscoderConfig struct {
+	// ProtoDescriptorPath is the path of the descriptor set as seen by Envoy.
+	ProtoDescriptorPath string
+	// Services are the fully-qualified names of the services to transcode.
+	Services []string
+	// Cluster is the name of the Envoy cluster of the upstream gRPC server.
+	Cluster string
+}
+
+// WriteTranscoderConfigYAML writes the Envoy HTTP filter and route fragments for the
+// TranscoderConfig as YAML.
+//
+// The routes match the gRPC paths of the services, as the transcoder rewrites the paths
+// of transcoded requests before routes are matched.
+func WriteTranscoderConfigYAML(writer io.Writer, transcoderConfig *TranscoderConfig) error {
+	return writeTranscoderConfigYAML(writer, transcoderConfig)
+}
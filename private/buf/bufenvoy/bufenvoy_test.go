@@ -0,0 +1,172 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufenvoy
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestHTTPRulesForMethod(t *testing.T) {
+	t.Parallel()
+	methods := testGetServiceDescriptor(t, "test.BookService").Methods()
+	httpRules, err := HTTPRulesForMethod(methods.ByName("GetBook"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*HTTPRule{
+			{
+				Method: "GET",
+				Path:   "/v1/{name=shelves/*/books/*}",
+			},
+			{
+				Method: "HEAD",
+				Path:   "/v1/{name=shelves/*/books/*}",
+			},
+		},
+		httpRules,
+	)
+	httpRules, err = HTTPRulesForMethod(methods.ByName("UpdateBook"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*HTTPRule{
+			{
+				Method:       "PATCH",
+				Path:         "/v1/{book.name=shelves/*/books/*}",
+				Body:         "book",
+				ResponseBody: "title",
+			},
+		},
+		httpRules,
+	)
+	httpRules, err = HTTPRulesForMethod(testGetServiceDescriptor(t, "test.InvalidService").Methods().ByName("NoAnnotation"))
+	require.NoError(t, err)
+	assert.Empty(t, httpRules)
+}
+
+func TestHTTPRulesForMethodPublicImport(t *testing.T) {
+	t.Parallel()
+	// nopackage.proto has no package, and imports google/api/annotations.proto through
+	// a public import of public.proto.
+	httpRules, err := HTTPRulesForMethod(testGetServiceDescriptor(t, "NoPackageService").Methods().ByName("Ping"))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*HTTPRule{
+			{
+				Method: "POST",
+				Path:   "/v1/ping",
+				Body:   "*",
+			},
+		},
+		httpRules,
+	)
+}
+
+func TestPathTemplateVariables(t *testing.T) {
+	t.Parallel()
+	variables, err := pathTemplateVariables("/v1/{name=shelves/*}/books/{book.id}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "book.id"}, variables)
+	_, err = pathTemplateVariables("/v1/{name")
+	assert.EqualError(t, err, "path has unmatched {")
+	_, err = pathTemplateVariables("/v1/name}")
+	assert.EqualError(t, err, "path has unmatched }")
+	_, err = pathTemplateVariables("/v1/}{name}")
+	assert.EqualError(t, err, "path has unmatched }")
+	_, err = pathTemplateVariables("/v1/{{name}")
+	assert.EqualError(t, err, "path has nested variables")
+}
+
+func TestValidateServices(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, ValidateServices([]protoreflect.ServiceDescriptor{testGetServiceDescriptor(t, "test.BookService")}))
+	err := ValidateServices([]protoreflect.ServiceDescriptor{testGetServiceDescriptor(t, "test.InvalidService")})
+	require.Error(t, err)
+	errs := multierr.Errors(err)
+	require.Len(t, errs, 5)
+	assert.Contains(t, errs[0].Error(), "test.InvalidService.NoAnnotation: method has no google.api.http annotation")
+	assert.Contains(t, errs[1].Error(), `field "unknown" not found`)
+	assert.Contains(t, errs[2].Error(), `field "book" is a message`)
+	assert.Contains(t, errs[3].Error(), `body field "unknown" not found`)
+	assert.Contains(t, errs[4].Error(), "path must start with /")
+}
+
+func TestWriteTranscoderConfigYAML(t *testing.T) {
+	t.Parallel()
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(
+		t,
+		WriteTranscoderConfigYAML(
+			buffer,
+			&TranscoderConfig{
+				ProtoDescriptorPath: "/etc/envoy/descriptor.binpb",
+				Services:            []string{"test.BookService"},
+				Cluster:             "grpc",
+			},
+		),
+	)
+	assert.Equal(
+		t,
+		`http_filters:
+  - name: envoy.filters.http.grpc_json_transcoder
+    typed_config:
+      '@type': type.googleapis.com/envoy.extensions.filters.http.grpc_json_transcoder.v3.GrpcJsonTranscoder
+      proto_descriptor: /etc/envoy/descriptor.binpb
+      services:
+        - test.BookService
+routes:
+  - match:
+      prefix: /test.BookService/
+    route:
+      cluster: grpc
+`,
+		buffer.String(),
+	)
+}
+
+// testGetServiceDescriptor compiles the files of testdata and returns the service, after
+// converting the files to and from FileDescriptorProtos as is done for images.
+func testGetServiceDescriptor(t *testing.T, fullName protoreflect.FullName) protoreflect.ServiceDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			&protocompile.SourceResolver{
+				ImportPaths: []string{"./testdata"},
+			},
+		),
+	}).Compile(context.Background(), "google/protobuf/descriptor.proto", "google/api/http.proto", "google/api/annotations.proto", "test.proto", "public.proto", "nopackage.proto")
+	require.NoError(t, err)
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		fileDescriptorSet.File = append(fileDescriptorSet.File, protodesc.ToFileDescriptorProto(file))
+	}
+	registry, err := protodesc.NewFiles(fileDescriptorSet)
+	require.NoError(t, err)
+	descriptor, err := registry.FindDescriptorByName(fullName)
+	require.NoError(t, err)
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	require.True(t, ok)
+	return serviceDescriptor
+}
@@ -0,0 +1,88 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufenvoy
+
+import (
+	"errors"
+	"io"
+
+	"github.com/bufbuild/buf/private/pkg/encoding"
+)
+
+type externalConfig struct {
+	HTTPFilters []externalHTTPFilter `yaml:"http_filters"`
+	Routes      []externalRoute      `yaml:"routes"`
+}
+
+type externalHTTPFilter struct {
+	Name        string                   `yaml:"name"`
+	TypedConfig externalTranscoderConfig `yaml:"typed_config"`
+}
+
+type externalTranscoderConfig struct {
+	Type            string   `yaml:"@type"`
+	ProtoDescriptor string   `yaml:"proto_descriptor"`
+	Services        []string `yaml:"services"`
+}
+
+type externalRoute struct {
+	Match externalRouteMatch `yaml:"match"`
+	Route externalRouteRoute `yaml:"route"`
+}
+
+type externalRouteMatch struct {
+	Prefix string `yaml:"prefix"`
+}
+
+type externalRouteRoute struct {
+	Cluster string `yaml:"cluster"`
+}
+
+func writeTranscoderConfigYAML(writer io.Writer, transcoderConfig *TranscoderConfig) error {
+	if len(transcoderConfig.Services) == 0 {
+		return errors.New("no services to transcode")
+	}
+	externalConfig := externalConfig{
+		HTTPFilters: []externalHTTPFilter{
+			{
+				Name: TranscoderFilterName,
+				TypedConfig: externalTranscoderConfig{
+					Type:            TranscoderConfigTypeURL,
+					ProtoDescriptor: transcoderConfig.ProtoDescriptorPath,
+					Services:        transcoderConfig.Services,
+				},
+			},
+		},
+	}
+	for _, service := range transcoderConfig.Services {
+		externalConfig.Routes = append(
+			externalConfig.Routes,
+			externalRoute{
+				Match: externalRouteMatch{
+					Prefix: "/" + service + "/",
+				},
+				Route: externalRouteRoute{
+					Cluster: transcoderConfig.Cluster,
+				},
+			},
+		)
+	}
+	data, err := encoding.MarshalYAML(externalConfig)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
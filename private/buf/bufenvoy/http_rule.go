@@ -0,0 +1,247 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufenvoy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const httpExtensionName protoreflect.FullName = "google.api.http"
+
+var httpRuleMethodFieldNames = []protoreflect.Name{"get", "put", "post", "delete", "patch"}
+
+func httpRulesForMethod(methodDescriptor protoreflect.MethodDescriptor) ([]*HTTPRule, error) {
+	extensionDescriptor := findHTTPExtension(methodDescriptor.ParentFile())
+	if extensionDescriptor == nil {
+		// The file does not import google/api/annotations.proto, so there can be no annotation.
+		return nil, nil
+	}
+	data, err := proto.Marshal(methodDescriptor.Options())
+	if err != nil {
+		return nil, err
+	}
+	extensionType := dynamicpb.NewExtensionType(extensionDescriptor)
+	types := &protoregistry.Types{}
+	if err := types.RegisterExtension(extensionType); err != nil {
+		return nil, err
+	}
+	methodOptions := &descriptorpb.MethodOptions{}
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, methodOptions); err != nil {
+		return nil, err
+	}
+	optionsMessage := methodOptions.ProtoReflect()
+	if !optionsMessage.Has(extensionType.TypeDescriptor()) {
+		return nil, nil
+	}
+	httpRuleMessage := optionsMessage.Get(extensionType.TypeDescriptor()).Message()
+	httpRule, err := newHTTPRule(httpRuleMessage)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", methodDescriptor.FullName(), err)
+	}
+	httpRules := []*HTTPRule{httpRule}
+	additionalBindings := httpRuleMessage.Get(httpRuleMessage.Descriptor().Fields().ByName("additional_bindings")).List()
+	for i := 0; i < additionalBindings.Len(); i++ {
+		additionalBinding := additionalBindings.Get(i).Message()
+		if additionalBinding.Get(additionalBinding.Descriptor().Fields().ByName("additional_bindings")).List().Len() > 0 {
+			return nil, fmt.Errorf("%s: additional bindings cannot have additional bindings", methodDescriptor.FullName())
+		}
+		httpRule, err := newHTTPRule(additionalBinding)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", methodDescriptor.FullName(), err)
+		}
+		httpRules = append(httpRules, httpRule)
+	}
+	return httpRules, nil
+}
+
+// findHTTPExtension returns the google.api.http extension from the imports of the file,
+// or nil if the file does not import it.
+//
+// The public imports of the imported files are followed, as their extensions are
+// visible to the file.
+func findHTTPExtension(fileDescriptor protoreflect.FileDescriptor) protoreflect.ExtensionDescriptor {
+	imports := fileDescriptor.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if extensionDescriptor := findHTTPExtensionInImportedFile(imports.Get(i).FileDescriptor); extensionDescriptor != nil {
+			return extensionDescriptor
+		}
+	}
+	return nil
+}
+
+func findHTTPExtensionInImportedFile(importedFileDescriptor protoreflect.FileDescriptor) protoreflect.ExtensionDescriptor {
+	if importedFileDescriptor.Package() == httpExtensionName.Parent() {
+		if extensionDescriptor := importedFileDescriptor.Extensions().ByName(httpExtensionName.Name()); extensionDescriptor != nil {
+			return extensionDescriptor
+		}
+	}
+	imports := importedFileDescriptor.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		fileImport := imports.Get(i)
+		if !fileImport.IsPublic {
+			continue
+		}
+		if extensionDescriptor := findHTTPExtensionInImportedFile(fileImport.FileDescriptor); extensionDescriptor != nil {
+			return extensionDescriptor
+		}
+	}
+	return nil
+}
+
+func newHTTPRule(httpRuleMessage protoreflect.Message) (*HTTPRule, error) {
+	fields := httpRuleMessage.Descriptor().Fields()
+	httpRule := &HTTPRule{
+		Body:         httpRuleMessage.Get(fields.ByName("body")).String(),
+		ResponseBody: httpRuleMessage.Get(fields.ByName("response_body")).String(),
+	}
+	for _, fieldName := range httpRuleMethodFieldNames {
+		if fieldDescriptor := fields.ByName(fieldName); httpRuleMessage.Has(fieldDescriptor) {
+			httpRule.Method = strings.ToUpper(string(fieldName))
+			httpRule.Path = httpRuleMessage.Get(fieldDescriptor).String()
+		}
+	}
+	if customFieldDescriptor := fields.ByName("custom"); httpRuleMessage.Has(customFieldDescriptor) {
+		customMessage := httpRuleMessage.Get(customFieldDescriptor).Message()
+		customFields := customMessage.Descriptor().Fields()
+		httpRule.Method = customMessage.Get(customFields.ByName("kind")).String()
+		httpRule.Path = customMessage.Get(customFields.ByName("path")).String()
+	}
+	if httpRule.Method == "" {
+		return nil, errors.New("google.api.http annotation has no pattern")
+	}
+	return httpRule, nil
+}
+
+func validateServices(serviceDescriptors []protoreflect.ServiceDescriptor) error {
+	var err error
+	for _, serviceDescriptor := range serviceDescriptors {
+		methods := serviceDescriptor.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			err = multierr.Append(err, validateMethod(methods.Get(i)))
+		}
+	}
+	return err
+}
+
+func validateMethod(methodDescriptor protoreflect.MethodDescriptor) error {
+	httpRules, err := httpRulesForMethod(methodDescriptor)
+	if err != nil {
+		return err
+	}
+	if len(httpRules) == 0 {
+		return fmt.Errorf("%s: method has no google.api.http annotation", methodDescriptor.FullName())
+	}
+	for _, httpRule := range httpRules {
+		if ruleErr := validateHTTPRule(methodDescriptor, httpRule); ruleErr != nil {
+			err = multierr.Append(err, fmt.Errorf("%s: %s %s: %w", methodDescriptor.FullName(), httpRule.Method, httpRule.Path, ruleErr))
+		}
+	}
+	return err
+}
+
+func validateHTTPRule(methodDescriptor protoreflect.MethodDescriptor, httpRule *HTTPRule) error {
+	variables, err := pathTemplateVariables(httpRule.Path)
+	if err != nil {
+		return err
+	}
+	for _, variable := range variables {
+		if err := validatePathVariable(methodDescriptor.Input(), variable); err != nil {
+			return err
+		}
+	}
+	switch httpRule.Body {
+	case "", "*":
+	default:
+		if methodDescriptor.Input().Fields().ByName(protoreflect.Name(httpRule.Body)) == nil {
+			return fmt.Errorf("body field %q not found in %s", httpRule.Body, methodDescriptor.Input().FullName())
+		}
+	}
+	if httpRule.ResponseBody != "" && methodDescriptor.Output().Fields().ByName(protoreflect.Name(httpRule.ResponseBody)) == nil {
+		return fmt.Errorf("response body field %q not found in %s", httpRule.ResponseBody, methodDescriptor.Output().FullName())
+	}
+	return nil
+}
+
+// validatePathVariable validates that the variable is a path of fields to a singular
+// scalar field, through singular message fields.
+func validatePathVariable(messageDescriptor protoreflect.MessageDescriptor, variable string) error {
+	names := strings.Split(variable, ".")
+	for i, name := range names {
+		fieldDescriptor := messageDescriptor.Fields().ByName(protoreflect.Name(name))
+		if fieldDescriptor == nil {
+			return fmt.Errorf("path variable %q: field %q not found in %s", variable, name, messageDescriptor.FullName())
+		}
+		if fieldDescriptor.IsList() || fieldDescriptor.IsMap() {
+			return fmt.Errorf("path variable %q: field %q is repeated", variable, name)
+		}
+		isMessage := fieldDescriptor.Message() != nil
+		if i < len(names)-1 {
+			if !isMessage {
+				return fmt.Errorf("path variable %q: field %q is not a message", variable, name)
+			}
+			messageDescriptor = fieldDescriptor.Message()
+		} else if isMessage {
+			return fmt.Errorf("path variable %q: field %q is a message", variable, name)
+		}
+	}
+	return nil
+}
+
+// pathTemplateVariables returns the field paths of the variables in the path template.
+//
+// For example, /v1/{name=shelves/*}/books/{book.id} has the variables name and book.id.
+func pathTemplateVariables(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.New("path must start with /")
+	}
+	var variables []string
+	for remaining := path; ; {
+		start := strings.IndexByte(remaining, '{')
+		end := strings.IndexByte(remaining, '}')
+		if start < 0 {
+			if end >= 0 {
+				return nil, errors.New("path has unmatched }")
+			}
+			return variables, nil
+		}
+		if end < 0 {
+			return nil, errors.New("path has unmatched {")
+		}
+		if end < start {
+			return nil, errors.New("path has unmatched }")
+		}
+		variable := remaining[start+1 : end]
+		if strings.ContainsRune(variable, '{') {
+			return nil, errors.New("path has nested variables")
+		}
+		if index := strings.IndexByte(variable, '='); index >= 0 {
+			variable = variable[:index]
+		}
+		if variable == "" {
+			return nil, errors.New("path has a variable with no field")
+		}
+		variables = append(variables, variable)
+		remaining = remaining[end+1:]
+	}
+}
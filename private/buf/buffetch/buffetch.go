@@ -39,8 +39,13 @@ const (
 	// MessageEncodingYAML is the YAML message encoding.
 	MessageEncodingYAML
 
+	// GitHubAPIHost is the host that github:// inputs are fetched from.
+	GitHubAPIHost = "api.github.com"
+
 	useProtoNamesKey  = "use_proto_names"
 	useEnumNumbersKey = "use_enum_numbers"
+
+	githubSchemePrefix = "github://"
 )
 
 var (
@@ -140,6 +145,12 @@ type ModuleRef interface {
 type ProtoFileRef interface {
 	SourceRef
 	IncludePackageFiles() bool
+	// NumPaths returns the number of files referenced.
+	//
+	// Multiple files are referenced with a comma-separated list of paths,
+	// such as "foo/a.proto,bar/b.proto". A comma within a path is escaped
+	// with a backslash, and a path given more than once is counted once.
+	NumPaths() int
 	internalProtoFileRef() internal.ProtoFileRef
 }
 
@@ -184,8 +195,34 @@ type RefParser interface {
 // NewRefParser returns a new RefParser.
 //
 // This defaults to dir or module.
-func NewRefParser(logger *zap.Logger) RefParser {
-	return newRefParser(logger)
+func NewRefParser(logger *zap.Logger, options ...RefParserOption) RefParser {
+	return newRefParser(logger, options...)
+}
+
+// RefParserOption is an option for a new RefParser, SourceRefParser, or
+// SourceOrModuleRefParser.
+type RefParserOption func(*refParserOptions)
+
+// RefParserWithMirror returns a new RefParserOption that replaces the prefix
+// of remote input paths, such as "https://github.com/", with the mirror, such
+// as "https://git.example.com/github/", before the inputs are fetched.
+//
+// Prefixes match on path segment boundaries, so the prefix "https://github.com/foo"
+// matches "https://github.com/foo/bar" but not "https://github.com/foobar".
+// If the path of an input starts with the prefixes of multiple mirrors, the
+// mirror with the longest prefix is used. Mirrors apply to the path after
+// shorthands such as "github://" are expanded.
+func RefParserWithMirror(prefix string, mirror string) RefParserOption {
+	return func(refParserOptions *refParserOptions) {
+		refParserOptions.mirrors[prefix] = mirror
+	}
+}
+
+// ParseMirrors parses mirrors from a comma-separated list of prefix=mirror
+// pairs, such as "https://github.com/=https://git.example.com/github/", into
+// RefParserOptions.
+func ParseMirrors(value string) ([]RefParserOption, error) {
+	return parseMirrors(value)
 }
 
 // NewMessageRefParser returns a new RefParser for messages only.
@@ -208,8 +245,8 @@ func MessageRefParserWithDefaultMessageEncoding(defaultMessageEncoding MessageEn
 // NewSourceRefParser returns a new RefParser for sources only.
 //
 // This defaults to dir or module.
-func NewSourceRefParser(logger *zap.Logger) SourceRefParser {
-	return newSourceRefParser(logger)
+func NewSourceRefParser(logger *zap.Logger, options ...RefParserOption) SourceRefParser {
+	return newSourceRefParser(logger, options...)
 }
 
 // NewModuleRefParser returns a new RefParser for modules only.
@@ -220,8 +257,20 @@ func NewModuleRefParser(logger *zap.Logger) ModuleRefParser {
 // NewSourceOrModuleRefParser returns a new RefParser for sources or modules only.
 //
 // This defaults to dir or module.
-func NewSourceOrModuleRefParser(logger *zap.Logger) SourceOrModuleRefParser {
-	return newSourceOrModuleRefParser(logger)
+func NewSourceOrModuleRefParser(logger *zap.Logger, options ...RefParserOption) SourceOrModuleRefParser {
+	return newSourceOrModuleRefParser(logger, options...)
+}
+
+// IsLocalRef returns true if the Ref is a directory or proto file on the local filesystem.
+//
+// The external paths of the files read for local Refs are paths on the local filesystem.
+func IsLocalRef(ref Ref) bool {
+	switch ref.internalRef().(type) {
+	case internal.DirRef, internal.ProtoFileRef:
+		return true
+	default:
+		return false
+	}
 }
 
 // ReadBucketCloser is a bucket returned from GetBucket.
@@ -283,9 +332,25 @@ type ModuleFetcher interface {
 		ctx context.Context,
 		container app.EnvStdinContainer,
 		moduleRef ModuleRef,
+		options ...GetModuleOption,
 	) (bufmodule.Module, error)
 }
 
+// GetModuleOption is an option for GetModule.
+type GetModuleOption func(*getModuleOptions)
+
+// GetModuleWithTargetPaths says that only the .proto files at the given paths,
+// relative to the root of the module, and the files they transitively import
+// are needed.
+//
+// This allows the module to be partially downloaded. The returned Module may
+// still contain all files of the module, for example if it was already cached.
+func GetModuleWithTargetPaths(targetPaths []string) GetModuleOption {
+	return func(o *getModuleOptions) {
+		o.targetPaths = targetPaths
+	}
+}
+
 // Reader is a reader for Buf.
 type Reader interface {
 	MessageReader
@@ -385,3 +450,7 @@ func NewWriter(
 type getSourceBucketOptions struct {
 	workspacesDisabled bool
 }
+
+type getModuleOptions struct {
+	targetPaths []string
+}
@@ -119,6 +119,19 @@ func NewInvalidPathError(format string, path string) error {
 	return fmt.Errorf("invalid %spath: %q", format, path)
 }
 
+// NewProtoFilePathEmptyError is a fetch error.
+func NewProtoFilePathEmptyError(format string, path string) error {
+	if format != "" {
+		format = format + " "
+	}
+	return fmt.Errorf("invalid %spath: %q (protofile paths cannot be empty)", format, path)
+}
+
+// NewProtoFilesNotWithinSameRootError is a fetch error.
+func NewProtoFilesNotWithinSameRootError(path string, otherPath string) error {
+	return fmt.Errorf("protofiles %q and %q must be within the same workspace or module", path, otherPath)
+}
+
 // NewProtoFileCannotBeDevPathError is a fetch error.
 func NewProtoFileCannotBeDevPathError(format string, path string) error {
 	if format != "" {
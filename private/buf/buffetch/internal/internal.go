@@ -166,7 +166,15 @@ func NewDirRef(path string) (DirRef, error) {
 type ProtoFileRef interface {
 	BucketRef
 	// Path is the normalized path to the file reference.
+	//
+	// If the reference is to multiple files, this is the path to the first file.
 	Path() string
+	// Paths are the paths to all the files referenced.
+	//
+	// Multiple files are referenced with a comma-separated list of paths, and must
+	// be within the same workspace or module. Always contains at least one path,
+	// and contains each path once.
+	Paths() []string
 	// IncludePackageFiles says to include the same package files TODO update comment
 	IncludePackageFiles() bool
 	protoFileRef()
@@ -418,6 +426,17 @@ type ReadBucketCloser interface {
 	// This should only be called if a terminate file name was specified and found outside of
 	// a workspace where the bucket is originally closed.
 	SetSubDirPath(string)
+	// ExternalPathForPath returns the user-facing external path for the given
+	// path relative to SubDirPath, that is the path of a file within the module
+	// as reported in FileAnnotations.
+	//
+	// If the path exists, this is the external path of the object. If the
+	// path does not exist, the external path is derived from the location
+	// the bucket was read from, such as the directory, or the directory
+	// within an archive or git repository.
+	//
+	// The current SubDirPath is used, so the result reflects any call to SetSubDirPath.
+	ExternalPathForPath(ctx context.Context, path string) string
 }
 
 // ReadWriteBucketCloser is a bucket potentially returned from GetBucket.
@@ -497,6 +516,7 @@ func NewWriter(
 // This allows defaults to be inferred from the path.
 //
 // The Path will be the only value set when the RawRefProcessor is invoked, and is not normalized.
+// The RawRefProcessor may rewrite the Path, for example to expand a convenience scheme.
 // After the RawRefProcessor is called, options will be parsed.
 type RawRef struct {
 	// Will always be set
@@ -825,3 +845,14 @@ func WithPutFileNoFileCompression() PutFileOption {
 
 // GetModuleOption is a GetModule option.
 type GetModuleOption func(*getModuleOptions)
+
+// WithGetModuleTargetPaths says that only the .proto files at the given paths,
+// and the files they transitively import, are needed from the module.
+//
+// If the module reader is a bufmodule.PartialModuleReader, only these files
+// are read. Otherwise, the entire module is read.
+func WithGetModuleTargetPaths(targetPaths []string) GetModuleOption {
+	return func(getModuleOptions *getModuleOptions) {
+		getModuleOptions.targetPaths = targetPaths
+	}
+}
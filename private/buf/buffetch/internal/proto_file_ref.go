@@ -14,7 +14,12 @@
 
 package internal
 
-import "github.com/bufbuild/buf/private/pkg/app"
+import (
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+)
 
 var (
 	_ ParsedProtoFileRef = &protoFileRef{}
@@ -22,17 +27,36 @@ var (
 
 type protoFileRef struct {
 	format              string
-	path                string
+	paths               []string
 	includePackageFiles bool
 }
 
+// newProtoFileRef returns a new protoFileRef.
+//
+// The path may be a comma-separated list of paths to reference multiple files.
+// A comma within a path is escaped with a backslash, and paths given more than
+// once are only referenced once.
 func newProtoFileRef(format string, path string, includePackageFiles bool) (*protoFileRef, error) {
-	if app.IsDevPath(path) || path == "-" {
-		return nil, NewProtoFileCannotBeDevPathError(format, path)
+	var paths []string
+	seenPaths := make(map[string]struct{})
+	for _, splitPath := range splitProtoFilePaths(path) {
+		splitPath = strings.TrimSpace(splitPath)
+		if splitPath == "" {
+			return nil, NewProtoFilePathEmptyError(format, path)
+		}
+		if app.IsDevPath(splitPath) || splitPath == "-" {
+			return nil, NewProtoFileCannotBeDevPathError(format, splitPath)
+		}
+		normalizedPath := normalpath.Normalize(splitPath)
+		if _, ok := seenPaths[normalizedPath]; ok {
+			continue
+		}
+		seenPaths[normalizedPath] = struct{}{}
+		paths = append(paths, splitPath)
 	}
 	return &protoFileRef{
 		format:              format,
-		path:                path,
+		paths:               paths,
 		includePackageFiles: includePackageFiles,
 	}, nil
 }
@@ -42,7 +66,11 @@ func (s *protoFileRef) Format() string {
 }
 
 func (s *protoFileRef) Path() string {
-	return s.path
+	return s.paths[0]
+}
+
+func (s *protoFileRef) Paths() []string {
+	return s.paths
 }
 
 func (s *protoFileRef) IncludePackageFiles() bool {
@@ -52,3 +80,25 @@ func (s *protoFileRef) IncludePackageFiles() bool {
 func (*protoFileRef) ref()          {}
 func (*protoFileRef) bucketRef()    {}
 func (*protoFileRef) protoFileRef() {}
+
+// splitProtoFilePaths splits the path on commas that are not escaped with a backslash.
+//
+// A backslash that does not precede a comma is kept as is, so that Windows paths
+// do not need to be escaped.
+func splitProtoFilePaths(path string) []string {
+	var paths []string
+	var current strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case path[i] == ',':
+			paths = append(paths, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	return append(paths, current.String())
+}
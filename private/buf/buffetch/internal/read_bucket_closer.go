@@ -15,6 +15,8 @@
 package internal
 
 import (
+	"context"
+
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/storage"
 )
@@ -26,12 +28,14 @@ type readBucketCloser struct {
 
 	relativeRootPath string
 	subDirPath       string
+	externalPathFunc func(string) string
 }
 
 func newReadBucketCloser(
 	storageReadBucketCloser storage.ReadBucketCloser,
 	relativeRootPath string,
 	subDirPath string,
+	externalPathFunc func(string) string,
 ) (*readBucketCloser, error) {
 	normalizedSubDirPath, err := normalpath.NormalizeAndValidate(subDirPath)
 	if err != nil {
@@ -41,6 +45,7 @@ func newReadBucketCloser(
 		ReadBucketCloser: storageReadBucketCloser,
 		relativeRootPath: normalpath.Normalize(relativeRootPath),
 		subDirPath:       normalizedSubDirPath,
+		externalPathFunc: externalPathFunc,
 	}, nil
 }
 
@@ -55,3 +60,46 @@ func (r *readBucketCloser) SubDirPath() string {
 func (r *readBucketCloser) SetSubDirPath(subDirPath string) {
 	r.subDirPath = subDirPath
 }
+
+func (r *readBucketCloser) ExternalPathForPath(ctx context.Context, path string) string {
+	return externalPathForPath(ctx, r, r.externalPathFunc, normalpath.Join(r.subDirPath, path))
+}
+
+// externalPathForPath returns the external path of the object at path if it
+// exists, and otherwise falls back to externalPathFunc.
+func externalPathForPath(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	externalPathFunc func(string) string,
+	path string,
+) string {
+	path = normalpath.Normalize(path)
+	if objectInfo, err := readBucket.Stat(ctx, path); err == nil {
+		return objectInfo.ExternalPath()
+	}
+	if externalPathFunc == nil {
+		return path
+	}
+	return externalPathFunc(path)
+}
+
+// externalPathFuncForOS returns a function that maps paths within a bucket
+// rooted at rootPath on disk to their external paths.
+//
+// This mirrors the external paths produced by storageos.
+func externalPathFuncForOS(rootPath string) func(string) string {
+	return func(path string) string {
+		return normalpath.Unnormalize(normalpath.Join(rootPath, path))
+	}
+}
+
+// externalPathFuncForPrefix returns a function that maps paths within a bucket
+// that was mapped on prefix to their paths within the original bucket.
+//
+// This is used for archives and git repositories, where the external path is
+// the path within the archive or repository.
+func externalPathFuncForPrefix(prefix string) func(string) string {
+	return func(path string) string {
+		return normalpath.Join(prefix, path)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBucketCloserExternalPathForPath(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"a/b.proto": []byte(`syntax = "proto3";`),
+		},
+	)
+	require.NoError(t, err)
+	readBucketCloser, err := newReadBucketCloser(
+		storage.NopReadBucketCloser(readBucket),
+		"",
+		"a",
+		externalPathFuncForPrefix("proto"),
+	)
+	require.NoError(t, err)
+	// Existing objects use the external path of the object.
+	assert.Equal(t, "a/b.proto", readBucketCloser.ExternalPathForPath(ctx, "b.proto"))
+	// Paths that do not exist are derived from where the bucket was read from.
+	assert.Equal(t, "proto/a/c.proto", readBucketCloser.ExternalPathForPath(ctx, "c.proto"))
+	readBucketCloser.SetSubDirPath("d")
+	assert.Equal(t, "proto/d/c.proto", readBucketCloser.ExternalPathForPath(ctx, "c.proto"))
+}
+
+func TestReadWriteBucketCloserExternalPathForPath(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	readWriteBucketCloser, err := newReadWriteBucketCloser(
+		storage.NopReadWriteBucketCloser(storagemem.NewReadWriteBucket()),
+		"../root",
+		".",
+		externalPathFuncForOS("../root"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "../root/a/c.proto", readWriteBucketCloser.ExternalPathForPath(ctx, "a/c.proto"))
+	readWriteBucketCloser.SetSubDirPath("a")
+	assert.Equal(t, "../root/a/c.proto", readWriteBucketCloser.ExternalPathForPath(ctx, "c.proto"))
+}
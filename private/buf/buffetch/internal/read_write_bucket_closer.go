@@ -15,6 +15,8 @@
 package internal
 
 import (
+	"context"
+
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/storage"
 )
@@ -26,12 +28,14 @@ type readWriteBucketCloser struct {
 
 	relativeRootPath string
 	subDirPath       string
+	externalPathFunc func(string) string
 }
 
 func newReadWriteBucketCloser(
 	storageReadWriteBucketCloser storage.ReadWriteBucketCloser,
 	relativeRootPath string,
 	subDirPath string,
+	externalPathFunc func(string) string,
 ) (*readWriteBucketCloser, error) {
 	normalizedSubDirPath, err := normalpath.NormalizeAndValidate(subDirPath)
 	if err != nil {
@@ -41,6 +45,7 @@ func newReadWriteBucketCloser(
 		ReadWriteBucketCloser: storageReadWriteBucketCloser,
 		relativeRootPath:      normalpath.Normalize(relativeRootPath),
 		subDirPath:            normalizedSubDirPath,
+		externalPathFunc:      externalPathFunc,
 	}, nil
 }
 
@@ -55,3 +60,7 @@ func (r *readWriteBucketCloser) SubDirPath() string {
 func (r *readWriteBucketCloser) SetSubDirPath(subDirPath string) {
 	r.subDirPath = subDirPath
 }
+
+func (r *readWriteBucketCloser) ExternalPathForPath(ctx context.Context, path string) string {
+	return externalPathForPath(ctx, r, r.externalPathFunc, normalpath.Join(r.subDirPath, path))
+}
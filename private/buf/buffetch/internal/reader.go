@@ -159,14 +159,19 @@ func (r *reader) GetModule(
 	ctx context.Context,
 	container app.EnvStdinContainer,
 	moduleRef ModuleRef,
-	_ ...GetModuleOption,
+	options ...GetModuleOption,
 ) (bufmodule.Module, error) {
+	getModuleOptions := newGetModuleOptions()
+	for _, option := range options {
+		option(getModuleOptions)
+	}
 	switch t := moduleRef.(type) {
 	case ModuleRef:
 		return r.getModule(
 			ctx,
 			container,
 			t,
+			getModuleOptions.targetPaths,
 		)
 	default:
 		return nil, fmt.Errorf("unknown ModuleRef type: %T", moduleRef)
@@ -275,6 +280,7 @@ func (r *reader) getArchiveBucket(
 			storage.NopReadBucketCloser(storage.MapReadBucket(readWriteBucket, storage.MapOnPrefix(terminateFileDirectoryPath))),
 			terminateFileDirectoryPath,
 			relativeSubDirPath,
+			externalPathFuncForPrefix(terminateFileDirectoryPath),
 		)
 		if err != nil {
 			return nil, err
@@ -292,6 +298,7 @@ func (r *reader) getArchiveBucket(
 		storage.NopReadBucketCloser(readBucket),
 		"",
 		"",
+		externalPathFuncForPrefix(subDirPath),
 	)
 	if err != nil {
 		return nil, err
@@ -338,6 +345,7 @@ func (r *reader) getDirBucket(
 			storage.NopReadWriteBucketCloser(readWriteBucket),
 			rootPath,
 			dirRelativePath,
+			externalPathFuncForOS(rootPath),
 		)
 		if err != nil {
 			return nil, err
@@ -351,6 +359,7 @@ func (r *reader) getDirBucket(
 		storage.NopReadWriteBucketCloser(readWriteBucket),
 		"",
 		"",
+		externalPathFuncForOS(rootPath),
 	)
 	if err != nil {
 		return nil, err
@@ -378,6 +387,25 @@ func (r *reader) getProtoFileBucket(
 	if err != nil {
 		return nil, err
 	}
+	for _, otherPath := range protoFileRef.Paths()[1:] {
+		otherTerminateFileProvider, err := getTerminateFileProviderForOS(normalpath.Dir(otherPath), terminateFileNames)
+		if err != nil {
+			return nil, err
+		}
+		otherRootPath, _, err := r.getBucketRootPathAndRelativePath(ctx, container, normalpath.Dir(otherPath), otherTerminateFileProvider)
+		if err != nil {
+			return nil, err
+		}
+		if normalpath.Normalize(otherRootPath) != normalpath.Normalize(rootPath) {
+			return nil, NewProtoFilesNotWithinSameRootError(protoFileRef.Path(), otherPath)
+		}
+		if !terminateFilesEqual(terminateFileProvider.GetTerminateFiles(), otherTerminateFileProvider.GetTerminateFiles()) {
+			// The files share a root, such as a workspace, but are within different modules
+			// of that root. We only keep the terminate file of the root, so that every module
+			// within the root is built.
+			terminateFileProvider = newTerminateFileProvider(terminateFileProvider.GetTerminateFiles()[:1])
+		}
+	}
 	readWriteBucket, err := r.storageosProvider.NewReadWriteBucket(
 		rootPath,
 		storageos.ReadWriteBucketWithSymlinksIfSupported(),
@@ -389,6 +417,7 @@ func (r *reader) getProtoFileBucket(
 		storage.NopReadWriteBucketCloser(readWriteBucket),
 		rootPath,
 		"", // For ProtoFileRef, we default to using the working directory
+		externalPathFuncForOS(rootPath),
 	)
 	if err != nil {
 		return nil, err
@@ -399,6 +428,19 @@ func (r *reader) getProtoFileBucket(
 	), nil
 }
 
+// terminateFilesEqual returns true if the TerminateFiles have the same names and paths.
+func terminateFilesEqual(one []TerminateFile, two []TerminateFile) bool {
+	if len(one) != len(two) {
+		return false
+	}
+	for i := range one {
+		if one[i].Name() != two[i].Name() || one[i].Path() != two[i].Path() {
+			return false
+		}
+	}
+	return true
+}
+
 // getBucketRootPathAndRelativePath is a helper function that returns the rootPath and relative
 // path if available for the readWriteBucket based on the dirRef and protoFileRef.
 func (r *reader) getBucketRootPathAndRelativePath(
@@ -505,6 +547,7 @@ func (r *reader) getGitBucket(
 			storage.NopReadBucketCloser(storage.MapReadBucket(readWriteBucket, storage.MapOnPrefix(terminateFileDirectoryPath))),
 			terminateFileDirectoryPath,
 			relativeSubDirPath,
+			externalPathFuncForPrefix(terminateFileDirectoryPath),
 		)
 		if err != nil {
 			return nil, err
@@ -522,6 +565,7 @@ func (r *reader) getGitBucket(
 		storage.NopReadBucketCloser(readBucket),
 		"",
 		"",
+		externalPathFuncForPrefix(subDirPath),
 	)
 	if err != nil {
 		return nil, err
@@ -536,6 +580,7 @@ func (r *reader) getModule(
 	ctx context.Context,
 	container app.EnvStdinContainer,
 	moduleRef ModuleRef,
+	targetPaths []string,
 ) (bufmodule.Module, error) {
 	if !r.moduleEnabled {
 		return nil, NewReadModuleDisabledError()
@@ -550,6 +595,11 @@ func (r *reader) getModule(
 	if err != nil {
 		return nil, err
 	}
+	if len(targetPaths) > 0 {
+		if partialModuleReader, ok := r.moduleReader.(bufmodule.PartialModuleReader); ok {
+			return partialModuleReader.GetPartialModule(ctx, modulePin, targetPaths)
+		}
+	}
 	return r.moduleReader.GetModule(ctx, modulePin)
 }
 
@@ -912,4 +962,10 @@ func newGetBucketOptions() *getBucketOptions {
 	return &getBucketOptions{}
 }
 
-type getModuleOptions struct{}
+type getModuleOptions struct {
+	targetPaths []string
+}
+
+func newGetModuleOptions() *getModuleOptions {
+	return &getModuleOptions{}
+}
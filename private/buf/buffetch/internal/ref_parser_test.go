@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetRawPathAndOptionsError(t *testing.T) {
@@ -74,6 +75,66 @@ func TestGetRawPathAndOptionsError(t *testing.T) {
 	)
 }
 
+func TestGetProtoFileRef(t *testing.T) {
+	t.Parallel()
+	protoFileRef, err := getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   "foo/a.proto",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "foo/a.proto", protoFileRef.Path())
+	assert.Equal(t, []string{"foo/a.proto"}, protoFileRef.Paths())
+	protoFileRef, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   "foo/a.proto, bar/b.proto",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "foo/a.proto", protoFileRef.Path())
+	assert.Equal(t, []string{"foo/a.proto", "bar/b.proto"}, protoFileRef.Paths())
+	_, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   "foo/a.proto,",
+		},
+	)
+	assert.EqualError(t, err, NewProtoFilePathEmptyError("protofile", "foo/a.proto,").Error())
+	_, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   "foo/a.proto,-",
+		},
+	)
+	assert.EqualError(t, err, NewProtoFileCannotBeDevPathError("protofile", "-").Error())
+	protoFileRef, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   `foo/a\,b.proto,bar/b.proto`,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/a,b.proto", "bar/b.proto"}, protoFileRef.Paths())
+	protoFileRef, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   `foo\a.proto`,
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`foo\a.proto`}, protoFileRef.Paths())
+	protoFileRef, err = getProtoFileRef(
+		&RawRef{
+			Format: "protofile",
+			Path:   "foo/a.proto,bar/b.proto,./foo/a.proto",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo/a.proto", "bar/b.proto"}, protoFileRef.Paths())
+}
+
 func testGetRawPathAndOptionsError(
 	t *testing.T,
 	expectedErr error,
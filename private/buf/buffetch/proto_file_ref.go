@@ -17,6 +17,7 @@ package buffetch
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/bufbuild/buf/private/buf/buffetch/internal"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
@@ -35,22 +36,28 @@ func newProtoFileRef(internalProtoFileRef internal.ProtoFileRef) *protoFileRef {
 }
 
 // PathForExternalPath for a proto file ref will only ever have one successful case, which
-// is `".", <nil>` and will error on all other paths. The `Path()` of the internal.ProtoFileRef
-// will always point to a specific proto file, e.g. `foo/bar/baz.proto`, thus the function
-// errors against inputs that are not matching to the proto file ref input.
+// is `".", <nil>` and will error on all other paths. The `Paths()` of the internal.ProtoFileRef
+// will always point to specific proto files, e.g. `foo/bar/baz.proto`, thus the function
+// errors against inputs that are not matching to one of the proto file ref paths.
 func (r *protoFileRef) PathForExternalPath(externalPath string) (string, error) {
 	externalPathAbs, err := filepath.Abs(normalpath.Unnormalize(externalPath))
 	if err != nil {
 		return "", err
 	}
-	internalRefPathAbs, err := filepath.Abs(normalpath.Unnormalize(r.protoFileRef.Path()))
-	if err != nil {
-		return "", err
-	}
-	if externalPathAbs != internalRefPathAbs {
-		return "", fmt.Errorf(`path provided "%s" does not match ref path "%s"`, externalPath, r.protoFileRef.Path())
+	for _, path := range r.protoFileRef.Paths() {
+		internalRefPathAbs, err := filepath.Abs(normalpath.Unnormalize(path))
+		if err != nil {
+			return "", err
+		}
+		if externalPathAbs == internalRefPathAbs {
+			return ".", nil
+		}
 	}
-	return ".", nil
+	return "", fmt.Errorf(`path provided "%s" does not match ref path "%s"`, externalPath, strings.Join(r.protoFileRef.Paths(), ","))
+}
+
+func (r *protoFileRef) NumPaths() int {
+	return len(r.protoFileRef.Paths())
 }
 
 func (r *protoFileRef) IncludePackageFiles() bool {
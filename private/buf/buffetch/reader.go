@@ -164,6 +164,18 @@ func (a *reader) GetModule(
 	ctx context.Context,
 	container app.EnvStdinContainer,
 	moduleRef ModuleRef,
+	options ...GetModuleOption,
 ) (bufmodule.Module, error) {
-	return a.internalReader.GetModule(ctx, container, moduleRef.internalModuleRef())
+	getModuleOptions := &getModuleOptions{}
+	for _, option := range options {
+		option(getModuleOptions)
+	}
+	var internalGetModuleOptions []internal.GetModuleOption
+	if len(getModuleOptions.targetPaths) > 0 {
+		internalGetModuleOptions = append(
+			internalGetModuleOptions,
+			internal.WithGetModuleTargetPaths(getModuleOptions.targetPaths),
+		)
+	}
+	return a.internalReader.GetModule(ctx, container, moduleRef.internalModuleRef(), internalGetModuleOptions...)
 }
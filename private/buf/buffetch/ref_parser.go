@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/bufbuild/buf/private/buf/buffetch/internal"
@@ -42,13 +43,13 @@ type refParser struct {
 	tracer         trace.Tracer
 }
 
-func newRefParser(logger *zap.Logger) *refParser {
+func newRefParser(logger *zap.Logger, options ...RefParserOption) *refParser {
 	return &refParser{
 		logger: logger.Named(loggerName),
 		tracer: otel.GetTracerProvider().Tracer(tracerName),
 		fetchRefParser: internal.NewRefParser(
 			logger,
-			internal.WithRawRefProcessor(processRawRef),
+			internal.WithRawRefProcessor(newProcessRawRefWithMirrors(logger.Named(loggerName), processRawRef, options...)),
 			internal.WithSingleFormat(formatBin),
 			internal.WithSingleFormat(formatBinpb),
 			internal.WithSingleFormat(
@@ -137,12 +138,12 @@ func newMessageRefParser(logger *zap.Logger, options ...MessageRefParserOption)
 	}
 }
 
-func newSourceRefParser(logger *zap.Logger) *refParser {
+func newSourceRefParser(logger *zap.Logger, options ...RefParserOption) *refParser {
 	return &refParser{
 		logger: logger.Named(loggerName),
 		fetchRefParser: internal.NewRefParser(
 			logger,
-			internal.WithRawRefProcessor(processRawRefSource),
+			internal.WithRawRefProcessor(newProcessRawRefWithMirrors(logger.Named(loggerName), processRawRefSource, options...)),
 			internal.WithArchiveFormat(
 				formatTar,
 				internal.ArchiveTypeTar,
@@ -177,12 +178,12 @@ func newModuleRefParser(logger *zap.Logger) *refParser {
 	}
 }
 
-func newSourceOrModuleRefParser(logger *zap.Logger) *refParser {
+func newSourceOrModuleRefParser(logger *zap.Logger, options ...RefParserOption) *refParser {
 	return &refParser{
 		logger: logger.Named(loggerName),
 		fetchRefParser: internal.NewRefParser(
 			logger,
-			internal.WithRawRefProcessor(processRawRefSourceOrModule),
+			internal.WithRawRefProcessor(newProcessRawRefWithMirrors(logger.Named(loggerName), processRawRefSourceOrModule, options...)),
 			internal.WithArchiveFormat(
 				formatTar,
 				internal.ArchiveTypeTar,
@@ -382,6 +383,9 @@ func (a *refParser) checkDeprecated(parsedRef internal.ParsedRef) {
 }
 
 func processRawRef(rawRef *internal.RawRef) error {
+	if ok, err := processRawRefGitHub(rawRef); ok || err != nil {
+		return err
+	}
 	// if format option is not set and path is "-", default to bin
 	var format string
 	var compressionType internal.CompressionType
@@ -463,6 +467,9 @@ func processRawRef(rawRef *internal.RawRef) error {
 }
 
 func processRawRefSource(rawRef *internal.RawRef) error {
+	if ok, err := processRawRefGitHub(rawRef); ok || err != nil {
+		return err
+	}
 	// if format option is not set and path is "-", default to bin
 	var format string
 	var compressionType internal.CompressionType
@@ -501,6 +508,9 @@ func processRawRefSource(rawRef *internal.RawRef) error {
 }
 
 func processRawRefSourceOrModule(rawRef *internal.RawRef) error {
+	if ok, err := processRawRefGitHub(rawRef); ok || err != nil {
+		return err
+	}
 	// if format option is not set and path is "-", default to bin
 	var format string
 	var compressionType internal.CompressionType
@@ -542,6 +552,111 @@ func processRawRefSourceOrModule(rawRef *internal.RawRef) error {
 	return nil
 }
 
+// processRawRefGitHub processes github://owner/repository[@ref] paths into tarballs
+// fetched from the GitHub API, which does not require git and is much faster than cloning.
+//
+// If ref is not set, the default branch is fetched. The subdir option can be used to
+// select a directory within the repository as with any other archive.
+//
+// Returns false if the path is not a github:// path.
+func processRawRefGitHub(rawRef *internal.RawRef) (bool, error) {
+	if !strings.HasPrefix(rawRef.Path, githubSchemePrefix) {
+		return false, nil
+	}
+	repositoryPath, ref, hasRef := strings.Cut(strings.TrimPrefix(rawRef.Path, githubSchemePrefix), "@")
+	owner, repository, ok := strings.Cut(repositoryPath, "/")
+	if !ok || owner == "" || repository == "" || strings.Contains(repository, "/") || (hasRef && ref == "") {
+		return true, fmt.Errorf("invalid GitHub path %q: must be in the form %sowner/repository[@ref]", rawRef.Path, githubSchemePrefix)
+	}
+	path := "https://" + GitHubAPIHost + "/repos/" + owner + "/" + repository + "/tarball"
+	if ref != "" {
+		path = path + "/" + ref
+	}
+	rawRef.Path = path
+	rawRef.Format = formatTar
+	rawRef.CompressionType = internal.CompressionTypeGzip
+	// GitHub tarballs contain a single top-level directory named after the repository and commit.
+	rawRef.ArchiveStripComponents = 1
+	return true, nil
+}
+
+// newProcessRawRefWithMirrors returns a raw ref processor that applies the
+// mirrors of the options to the path processed by processRawRef.
+func newProcessRawRefWithMirrors(
+	logger *zap.Logger,
+	processRawRef func(*internal.RawRef) error,
+	options ...RefParserOption,
+) func(*internal.RawRef) error {
+	refParserOptions := newRefParserOptions()
+	for _, option := range options {
+		option(refParserOptions)
+	}
+	if len(refParserOptions.mirrors) == 0 {
+		return processRawRef
+	}
+	prefixes := make([]string, 0, len(refParserOptions.mirrors))
+	for prefix := range refParserOptions.mirrors {
+		prefixes = append(prefixes, prefix)
+	}
+	// Longest prefix first, so that the most specific mirror is used.
+	sort.Slice(
+		prefixes,
+		func(i int, j int) bool {
+			if len(prefixes[i]) != len(prefixes[j]) {
+				return len(prefixes[i]) > len(prefixes[j])
+			}
+			return prefixes[i] < prefixes[j]
+		},
+	)
+	return func(rawRef *internal.RawRef) error {
+		if err := processRawRef(rawRef); err != nil {
+			return err
+		}
+		for _, prefix := range prefixes {
+			if hasMirrorPrefix(rawRef.Path, prefix) {
+				mirroredPath := refParserOptions.mirrors[prefix] + strings.TrimPrefix(rawRef.Path, prefix)
+				logger.Debug(
+					"mirror",
+					zap.String("path", rawRef.Path),
+					zap.String("mirrored_path", mirroredPath),
+				)
+				rawRef.Path = mirroredPath
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// hasMirrorPrefix returns true if the path starts with the prefix and the prefix
+// ends on a path segment boundary, so that "https://github.com/foo" matches
+// "https://github.com/foo/bar" but not "https://github.com/foobar".
+func hasMirrorPrefix(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	if strings.HasSuffix(prefix, "/") || len(path) == len(prefix) {
+		return true
+	}
+	return path[len(prefix)] == '/'
+}
+
+func parseMirrors(value string) ([]RefParserOption, error) {
+	var options []RefParserOption
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		prefix, mirror, ok := strings.Cut(pair, "=")
+		if !ok || prefix == "" || mirror == "" {
+			return nil, fmt.Errorf("invalid mirror %q: must be in the form prefix=mirror", pair)
+		}
+		options = append(options, RefParserWithMirror(prefix, mirror))
+	}
+	return options, nil
+}
+
 func newProcessRawRefMessage(defaultMessageEncoding MessageEncoding) func(*internal.RawRef) error {
 	return func(rawRef *internal.RawRef) error {
 		defaultFormat, ok := messageEncodingToFormat[defaultMessageEncoding]
@@ -643,6 +758,17 @@ func assumeModuleOrDir(path string) (string, error) {
 	return formatDir, nil
 }
 
+type refParserOptions struct {
+	// prefix -> mirror
+	mirrors map[string]string
+}
+
+func newRefParserOptions() *refParserOptions {
+	return &refParserOptions{
+		mirrors: make(map[string]string),
+	}
+}
+
 type messageRefParserOptions struct {
 	defaultMessageEncoding MessageEncoding
 }
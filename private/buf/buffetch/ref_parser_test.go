@@ -16,6 +16,7 @@ package buffetch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -1173,10 +1174,161 @@ func TestGetParsedRefSuccess(t *testing.T) {
 		),
 		"https://gitlab.com/api/v4/projects/foo/packages/generic/proto/0.0.1/proto.binpb?private_token=bar#format=binpb",
 	)
+	testGetParsedRefSuccess(
+		t,
+		internal.NewDirectParsedArchiveRef(
+			formatTar,
+			"api.github.com/repos/acme/weather/tarball",
+			internal.FileSchemeHTTPS,
+			internal.ArchiveTypeTar,
+			internal.CompressionTypeGzip,
+			1,
+			"",
+		),
+		"github://acme/weather",
+	)
+	testGetParsedRefSuccess(
+		t,
+		internal.NewDirectParsedArchiveRef(
+			formatTar,
+			"api.github.com/repos/acme/weather/tarball/v1.2.3",
+			internal.FileSchemeHTTPS,
+			internal.ArchiveTypeTar,
+			internal.CompressionTypeGzip,
+			1,
+			"proto",
+		),
+		"github://acme/weather@v1.2.3#subdir=proto",
+	)
+}
+
+func TestGetParsedRefMirror(t *testing.T) {
+	t.Parallel()
+	refParser := newRefParser(
+		zap.NewNop(),
+		RefParserWithMirror("https://github.com/", "https://git.example.com/github/"),
+		RefParserWithMirror("https://github.com/acme/", "https://git.example.com/acme/"),
+		RefParserWithMirror("https://api.github.com/", "https://github.example.com/api/"),
+	)
+	parsedRef, err := refParser.getParsedRef(
+		context.Background(),
+		"https://github.com/foo/bar.git#branch=main",
+		allFormats,
+	)
+	require.NoError(t, err)
+	gitRef, ok := parsedRef.(internal.ParsedGitRef)
+	require.True(t, ok)
+	assert.Equal(t, "git.example.com/github/foo/bar.git", gitRef.Path())
+	parsedRef, err = refParser.getParsedRef(
+		context.Background(),
+		"https://github.com/acme/weather.git#branch=main",
+		allFormats,
+	)
+	require.NoError(t, err)
+	gitRef, ok = parsedRef.(internal.ParsedGitRef)
+	require.True(t, ok)
+	assert.Equal(t, "git.example.com/acme/weather.git", gitRef.Path())
+	parsedRef, err = refParser.getParsedRef(
+		context.Background(),
+		"github://acme/weather",
+		allFormats,
+	)
+	require.NoError(t, err)
+	archiveRef, ok := parsedRef.(internal.ParsedArchiveRef)
+	require.True(t, ok)
+	assert.Equal(t, "github.example.com/api/repos/acme/weather/tarball", archiveRef.Path())
+	parsedRef, err = refParser.getParsedRef(
+		context.Background(),
+		"https://gitlab.com/foo/bar.git#branch=main",
+		allFormats,
+	)
+	require.NoError(t, err)
+	gitRef, ok = parsedRef.(internal.ParsedGitRef)
+	require.True(t, ok)
+	assert.Equal(t, "gitlab.com/foo/bar.git", gitRef.Path())
+}
+
+func TestGetParsedRefMirrorSegmentBoundary(t *testing.T) {
+	t.Parallel()
+	refParser := newRefParser(
+		zap.NewNop(),
+		RefParserWithMirror("https://github.com/foo", "https://git.example.com/foo"),
+	)
+	parsedRef, err := refParser.getParsedRef(
+		context.Background(),
+		"https://github.com/foo/bar.git#branch=main",
+		allFormats,
+	)
+	require.NoError(t, err)
+	gitRef, ok := parsedRef.(internal.ParsedGitRef)
+	require.True(t, ok)
+	assert.Equal(t, "git.example.com/foo/bar.git", gitRef.Path())
+	parsedRef, err = refParser.getParsedRef(
+		context.Background(),
+		"https://github.com/foobar/bar.git#branch=main",
+		allFormats,
+	)
+	require.NoError(t, err)
+	gitRef, ok = parsedRef.(internal.ParsedGitRef)
+	require.True(t, ok)
+	assert.Equal(t, "github.com/foobar/bar.git", gitRef.Path())
+}
+
+func TestParseMirrors(t *testing.T) {
+	t.Parallel()
+	options, err := ParseMirrors("")
+	require.NoError(t, err)
+	assert.Empty(t, options)
+	options, err = ParseMirrors("https://github.com/=https://git.example.com/github/, https://api.github.com/=https://github.example.com/api/")
+	require.NoError(t, err)
+	refParserOptions := newRefParserOptions()
+	for _, option := range options {
+		option(refParserOptions)
+	}
+	assert.Equal(
+		t,
+		map[string]string{
+			"https://github.com/":     "https://git.example.com/github/",
+			"https://api.github.com/": "https://github.example.com/api/",
+		},
+		refParserOptions.mirrors,
+	)
+	_, err = ParseMirrors("https://github.com/")
+	require.Error(t, err)
+	_, err = ParseMirrors("=https://git.example.com/")
+	require.Error(t, err)
+}
+
+func TestIsLocalRef(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	refParser := NewRefParser(zap.NewNop())
+	for input, expected := range map[string]bool{
+		".":                              true,
+		"path/to/foo.proto":              true,
+		"path/to/foo.tar.gz":             false,
+		"path/to/foo.binpb":              false,
+		"https://github.com/foo/bar.git": false,
+		"buf.build/foo/bar":              false,
+	} {
+		ref, err := refParser.GetRef(ctx, input)
+		require.NoError(t, err)
+		assert.Equal(t, expected, IsLocalRef(ref), input)
+	}
 }
 
 func TestGetParsedRefError(t *testing.T) {
 	t.Parallel()
+	testGetParsedRefError(
+		t,
+		errors.New(`invalid GitHub path "github://acme": must be in the form github://owner/repository[@ref]`),
+		"github://acme",
+	)
+	testGetParsedRefError(
+		t,
+		errors.New(`invalid GitHub path "github://acme/weather@": must be in the form github://owner/repository[@ref]`),
+		"github://acme/weather@",
+	)
 	testGetParsedRefError(
 		t,
 		internal.NewInvalidPathError(formatDir, "-"),
@@ -0,0 +1,80 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buffieldnumber manages the field numbers of messages.
+package buffieldnumber
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Report is the field number allocation of a single message.
+type Report struct {
+	// Name is the fully-qualified name of the message.
+	Name string `json:"name,omitempty"`
+	// Used are the ranges of numbers used by fields, sorted.
+	Used []Range `json:"used,omitempty"`
+	// Reserved are the reserved ranges, sorted.
+	Reserved []Range `json:"reserved,omitempty"`
+	// Extensions are the extension ranges, sorted.
+	Extensions []Range `json:"extensions,omitempty"`
+	// RemovedFields are the fields of the against message that were removed
+	// without reserving both their number and name, sorted by number.
+	RemovedFields []RemovedField `json:"removed_fields,omitempty"`
+	// NextNumber is the number after the highest number that is used, reserved,
+	// within an extension range, or was used by a removed field, skipping the
+	// numbers reserved for the Protobuf implementation.
+	//
+	// Zero if there is no such number.
+	NextNumber int32 `json:"next_number,omitempty"`
+	// LowestAvailableNumber is the lowest number that is not used, reserved, within
+	// an extension range, or was used by a removed field.
+	//
+	// Zero if there is no such number.
+	LowestAvailableNumber int32 `json:"lowest_available_number,omitempty"`
+}
+
+// Range is an inclusive range of field numbers.
+type Range struct {
+	Start int32 `json:"start"`
+	End   int32 `json:"end"`
+}
+
+// RemovedField is a field that was removed without being fully reserved.
+type RemovedField struct {
+	Name           string `json:"name,omitempty"`
+	Number         int32  `json:"number"`
+	NumberReserved bool   `json:"number_reserved,omitempty"`
+	NameReserved   bool   `json:"name_reserved,omitempty"`
+}
+
+// NewReport returns the Report for the message.
+//
+// The against message is the same message at a previous point in its history,
+// and is used to find removed fields. It may be nil.
+func NewReport(
+	messageDescriptor protoreflect.MessageDescriptor,
+	againstMessageDescriptor protoreflect.MessageDescriptor,
+) *Report {
+	return newReport(messageDescriptor, againstMessageDescriptor)
+}
+
+// ReservedStatements returns the reserved statements that reserve the numbers and
+// names of the removed fields of the report.
+//
+// The statements are written for the syntax of the file the message is declared in,
+// and are empty if there is nothing to reserve.
+func ReservedStatements(report *Report, syntax protoreflect.Syntax) []string {
+	return reservedStatements(report, syntax)
+}
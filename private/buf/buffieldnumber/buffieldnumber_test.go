@@ -0,0 +1,90 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffieldnumber
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+	file := testCompile(t, "./testdata/current")
+	againstFile := testCompile(t, "./testdata/against")
+	report := NewReport(file.Messages().ByName("Foo"), againstFile.Messages().ByName("Foo"))
+	assert.Equal(
+		t,
+		&Report{
+			Name:       "buffieldnumber.test.Foo",
+			Used:       []Range{{Start: 1, End: 2}, {Start: 5, End: 5}},
+			Reserved:   []Range{{Start: 3, End: 3}},
+			Extensions: []Range{{Start: 100, End: 199}},
+			RemovedFields: []RemovedField{
+				{Name: "e", Number: 4},
+				{Name: "f", Number: 6},
+			},
+			NextNumber:            200,
+			LowestAvailableNumber: 7,
+		},
+		report,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			`reserved 4, 6;`,
+			`reserved "e", "f";`,
+		},
+		ReservedStatements(report, protoreflect.Proto2),
+	)
+	assert.Equal(t, []string{`reserved 4, 6;`, `reserved e, f;`}, ReservedStatements(report, protoreflect.Editions))
+
+	report = NewReport(file.Messages().ByName("Foo"), nil)
+	assert.Empty(t, report.RemovedFields)
+	assert.Equal(t, int32(200), report.NextNumber)
+	assert.Equal(t, int32(4), report.LowestAvailableNumber)
+	assert.Empty(t, ReservedStatements(report, protoreflect.Proto2))
+}
+
+func TestNewReportSkipsImplementationReserved(t *testing.T) {
+	t.Parallel()
+	report := NewReport(testCompile(t, "./testdata/current").Messages().ByName("Bar"), nil)
+	assert.Equal(t, int32(20000), report.NextNumber)
+	assert.Equal(t, int32(1), report.LowestAvailableNumber)
+}
+
+func TestNumbersToRanges(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, numbersToRanges(nil))
+	assert.Equal(
+		t,
+		[]Range{{Start: 1, End: 3}, {Start: 5, End: 5}, {Start: 7, End: 8}},
+		numbersToRanges([]int32{8, 1, 2, 3, 5, 7, 2}),
+	)
+}
+
+func testCompile(t *testing.T, dirPath string) protoreflect.FileDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			ImportPaths: []string{dirPath},
+		},
+	}).Compile(context.Background(), "message.proto")
+	require.NoError(t, err)
+	return files[0]
+}
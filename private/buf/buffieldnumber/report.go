@@ -0,0 +1,205 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffieldnumber
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func newReport(
+	messageDescriptor protoreflect.MessageDescriptor,
+	againstMessageDescriptor protoreflect.MessageDescriptor,
+) *Report {
+	fields := messageDescriptor.Fields()
+	numbers := make([]int32, fields.Len())
+	numberMap := make(map[protoreflect.FieldNumber]struct{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		numbers[i] = int32(fields.Get(i).Number())
+		numberMap[fields.Get(i).Number()] = struct{}{}
+	}
+	report := &Report{
+		Name:       string(messageDescriptor.FullName()),
+		Used:       numbersToRanges(numbers),
+		Reserved:   fieldRangesToRanges(messageDescriptor.ReservedRanges()),
+		Extensions: fieldRangesToRanges(messageDescriptor.ExtensionRanges()),
+	}
+	if againstMessageDescriptor != nil {
+		report.RemovedFields = getRemovedFields(messageDescriptor, againstMessageDescriptor, numberMap)
+	}
+	allocated := append(append(append([]Range{}, report.Used...), report.Reserved...), report.Extensions...)
+	for _, removedField := range report.RemovedFields {
+		allocated = append(allocated, Range{Start: removedField.Number, End: removedField.Number})
+	}
+	allocated = append(
+		allocated,
+		Range{
+			Start: int32(protowire.FirstReservedNumber),
+			End:   int32(protowire.LastReservedNumber),
+		},
+	)
+	sort.Slice(allocated, func(i int, j int) bool { return allocated[i].Start < allocated[j].Start })
+	report.NextNumber = getNextNumber(allocated)
+	report.LowestAvailableNumber = getLowestAvailableNumber(allocated)
+	return report
+}
+
+// getRemovedFields returns the fields of the against message whose numbers are no longer
+// used by the message, and whose number or name is not reserved.
+func getRemovedFields(
+	messageDescriptor protoreflect.MessageDescriptor,
+	againstMessageDescriptor protoreflect.MessageDescriptor,
+	numberMap map[protoreflect.FieldNumber]struct{},
+) []RemovedField {
+	var removedFields []RemovedField
+	againstFields := againstMessageDescriptor.Fields()
+	for i := 0; i < againstFields.Len(); i++ {
+		againstField := againstFields.Get(i)
+		if _, ok := numberMap[againstField.Number()]; ok {
+			continue
+		}
+		removedField := RemovedField{
+			Name:           string(againstField.Name()),
+			Number:         int32(againstField.Number()),
+			NumberReserved: messageDescriptor.ReservedRanges().Has(againstField.Number()),
+			NameReserved:   messageDescriptor.ReservedNames().Has(againstField.Name()),
+		}
+		if removedField.NumberReserved && removedField.NameReserved {
+			continue
+		}
+		removedFields = append(removedFields, removedField)
+	}
+	sort.Slice(removedFields, func(i int, j int) bool { return removedFields[i].Number < removedFields[j].Number })
+	return removedFields
+}
+
+// getNextNumber returns the number after the highest allocated number, or zero.
+//
+// The allocated ranges must be sorted by start.
+func getNextNumber(allocated []Range) int32 {
+	var highest int32
+	for _, r := range allocated {
+		if r.End > highest && !isImplementationReserved(r) {
+			highest = r.End
+		}
+	}
+	next := highest + 1
+	if next >= int32(protowire.FirstReservedNumber) && next <= int32(protowire.LastReservedNumber) {
+		next = int32(protowire.LastReservedNumber) + 1
+	}
+	if next > int32(protowire.MaxValidNumber) {
+		return 0
+	}
+	return next
+}
+
+// getLowestAvailableNumber returns the lowest number not in the allocated ranges, or zero.
+//
+// The allocated ranges must be sorted by start.
+func getLowestAvailableNumber(allocated []Range) int32 {
+	lowest := int32(protowire.MinValidNumber)
+	for _, r := range allocated {
+		if r.Start > lowest {
+			break
+		}
+		if r.End >= lowest {
+			lowest = r.End + 1
+		}
+	}
+	if lowest > int32(protowire.MaxValidNumber) {
+		return 0
+	}
+	return lowest
+}
+
+func isImplementationReserved(r Range) bool {
+	return r.Start == int32(protowire.FirstReservedNumber) && r.End == int32(protowire.LastReservedNumber)
+}
+
+func reservedStatements(report *Report, syntax protoreflect.Syntax) []string {
+	var numbers []int32
+	var names []string
+	for _, removedField := range report.RemovedFields {
+		if !removedField.NumberReserved {
+			numbers = append(numbers, removedField.Number)
+		}
+		if !removedField.NameReserved {
+			if syntax == protoreflect.Editions {
+				// Reserved names are identifiers in editions.
+				names = append(names, removedField.Name)
+			} else {
+				names = append(names, strconv.Quote(removedField.Name))
+			}
+		}
+	}
+	var statements []string
+	if len(numbers) > 0 {
+		ranges := numbersToRanges(numbers)
+		rangeStrings := make([]string, len(ranges))
+		for i, r := range ranges {
+			rangeStrings[i] = strconv.Itoa(int(r.Start))
+			if r.End != r.Start {
+				rangeStrings[i] += " to " + strconv.Itoa(int(r.End))
+			}
+		}
+		statements = append(statements, "reserved "+strings.Join(rangeStrings, ", ")+";")
+	}
+	if len(names) > 0 {
+		statements = append(statements, "reserved "+strings.Join(names, ", ")+";")
+	}
+	return statements
+}
+
+// numbersToRanges returns the sorted ranges of consecutive numbers.
+func numbersToRanges(numbers []int32) []Range {
+	if len(numbers) == 0 {
+		return nil
+	}
+	sorted := append([]int32{}, numbers...)
+	sort.Slice(sorted, func(i int, j int) bool { return sorted[i] < sorted[j] })
+	ranges := []Range{{Start: sorted[0], End: sorted[0]}}
+	for _, number := range sorted[1:] {
+		last := &ranges[len(ranges)-1]
+		if number <= last.End+1 {
+			if number > last.End {
+				last.End = number
+			}
+			continue
+		}
+		ranges = append(ranges, Range{Start: number, End: number})
+	}
+	return ranges
+}
+
+// fieldRangesToRanges converts the half-open field ranges to sorted inclusive ranges.
+func fieldRangesToRanges(fieldRanges protoreflect.FieldRanges) []Range {
+	if fieldRanges.Len() == 0 {
+		return nil
+	}
+	ranges := make([]Range, fieldRanges.Len())
+	for i := 0; i < fieldRanges.Len(); i++ {
+		fieldRange := fieldRanges.Get(i)
+		ranges[i] = Range{
+			Start: int32(fieldRange[0]),
+			End:   int32(fieldRange[1]) - 1,
+		}
+	}
+	sort.Slice(ranges, func(i int, j int) bool { return ranges[i].Start < ranges[j].Start })
+	return ranges
+}
@@ -0,0 +1,68 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buffuzz generates random but schema-valid messages for fuzzing and load testing.
+package buffuzz
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultMaxDepth is the default maximum depth of nested messages.
+const DefaultMaxDepth = 4
+
+// Generator generates random messages of a single type.
+//
+// Generators are deterministic: two Generators created with the same message
+// descriptor, seed, and options generate the same sequence of messages.
+type Generator interface {
+	// Generate generates the next message.
+	Generate() (proto.Message, error)
+}
+
+// NewGenerator returns a new Generator for the message type.
+func NewGenerator(
+	messageDescriptor protoreflect.MessageDescriptor,
+	seed int64,
+	options ...GeneratorOption,
+) Generator {
+	return newGenerator(messageDescriptor, seed, options...)
+}
+
+// GeneratorOption is an option for a new Generator.
+type GeneratorOption func(*generator)
+
+// GeneratorWithProtovalidate returns a new GeneratorOption that makes the
+// Generator respect protovalidate constraints where possible.
+//
+// The required, const, in, not_in, range, length, prefix, suffix, contains, item
+// and pair count constraints are respected, as are the email, hostname, ip, ipv4,
+// ipv6, uri, and uuid string formats. CEL expressions and patterns are not.
+func GeneratorWithProtovalidate() GeneratorOption {
+	return func(generator *generator) {
+		generator.protovalidate = true
+	}
+}
+
+// GeneratorWithMaxDepth returns a new GeneratorOption that sets the maximum
+// depth of nested messages.
+//
+// Message fields are never set beyond this depth, even if required.
+// The default is DefaultMaxDepth.
+func GeneratorWithMaxDepth(maxDepth int) GeneratorOption {
+	return func(generator *generator) {
+		generator.maxDepth = maxDepth
+	}
+}
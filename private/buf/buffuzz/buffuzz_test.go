@@ -0,0 +1,174 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffuzz
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/bufbuild/protocompile"
+	"github.com/gofrs/uuid/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "Item")
+	first := testGenerateJSON(t, NewGenerator(messageDescriptor, 1), 10)
+	assert.Equal(t, first, testGenerateJSON(t, NewGenerator(messageDescriptor, 1), 10))
+	assert.NotEqual(t, first, testGenerateJSON(t, NewGenerator(messageDescriptor, 2), 10))
+}
+
+func TestGenerateProtovalidate(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "Item")
+	fields := messageDescriptor.Fields()
+	generator := NewGenerator(messageDescriptor, 1, GeneratorWithProtovalidate())
+	for i := 0; i < 200; i++ {
+		generated, err := generator.Generate()
+		require.NoError(t, err)
+		message := generated.ProtoReflect()
+		name := message.Get(fields.ByName("name")).String()
+		assert.True(t, strings.HasPrefix(name, "it"))
+		assert.GreaterOrEqual(t, len(name), 3)
+		assert.LessOrEqual(t, len(name), 5)
+		count := message.Get(fields.ByName("count")).Int()
+		assert.Greater(t, count, int64(10))
+		assert.LessOrEqual(t, count, int64(20))
+		assert.Contains(t, []uint64{1, 2, 3}, message.Get(fields.ByName("id")).Uint())
+		ratio := message.Get(fields.ByName("ratio")).Float()
+		assert.GreaterOrEqual(t, ratio, 0.0)
+		assert.Less(t, ratio, 1.0)
+		assert.Contains(t, []protoreflect.EnumNumber{1, 2}, message.Get(fields.ByName("status")).Enum())
+		emails := message.Get(fields.ByName("emails")).List()
+		assert.GreaterOrEqual(t, emails.Len(), 1)
+		assert.LessOrEqual(t, emails.Len(), 2)
+		for j := 0; j < emails.Len(); j++ {
+			assert.Contains(t, emails.Get(j).String(), "@")
+		}
+		counts := message.Get(fields.ByName("counts")).Map()
+		assert.GreaterOrEqual(t, counts.Len(), 1)
+		counts.Range(
+			func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				_, err := uuid.FromString(key.String())
+				assert.NoError(t, err)
+				assert.Less(t, value.Int(), int64(0))
+				return true
+			},
+		)
+		assert.Len(t, message.Get(fields.ByName("data")).Bytes(), 4)
+		assert.NotNil(t, message.WhichOneof(messageDescriptor.Oneofs().ByName("kind")))
+		assert.True(t, message.Has(fields.ByName("time")))
+		assert.NotNil(t, net.ParseIP(message.Get(fields.ByName("address")).String()))
+		negative := message.Get(fields.ByName("negative")).Int()
+		assert.Greater(t, negative, int64(-10))
+		assert.Less(t, negative, int64(-5))
+		_, err = url.Parse(message.Get(fields.ByName("uri")).String())
+		assert.NoError(t, err)
+	}
+}
+
+func TestGenerateMaxDepth(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "Item")
+	nextFieldDescriptor := messageDescriptor.Fields().ByName("next")
+	generator := NewGenerator(messageDescriptor, 1, GeneratorWithMaxDepth(0))
+	for i := 0; i < 50; i++ {
+		message, err := generator.Generate()
+		require.NoError(t, err)
+		assert.False(t, message.ProtoReflect().Has(nextFieldDescriptor))
+	}
+}
+
+func TestGenerateUnsatisfiable(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "Unsatisfiable")
+	_, err := NewGenerator(messageDescriptor, 1, GeneratorWithProtovalidate()).Generate()
+	assert.ErrorContains(t, err, `"buffuzz.test.Unsatisfiable.name"`)
+}
+
+func TestGenerateLargeBounds(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "LargeBounds")
+	fields := messageDescriptor.Fields()
+	generator := NewGenerator(messageDescriptor, 1, GeneratorWithProtovalidate())
+	for i := 0; i < 20; i++ {
+		generated, err := generator.Generate()
+		require.NoError(t, err)
+		message := generated.ProtoReflect()
+		names := message.Get(fields.ByName("names")).List()
+		assert.GreaterOrEqual(t, names.Len(), 1)
+		assert.LessOrEqual(t, names.Len(), maxSize)
+		assert.LessOrEqual(t, len(message.Get(fields.ByName("name")).String()), maxSize)
+		data := message.Get(fields.ByName("data")).Bytes()
+		assert.True(t, strings.HasPrefix(string(data), "ab"))
+		assert.LessOrEqual(t, len(data), maxSize)
+	}
+}
+
+func TestGenerateTooLarge(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "TooLarge")
+	_, err := NewGenerator(messageDescriptor, 1, GeneratorWithProtovalidate()).Generate()
+	assert.ErrorContains(t, err, `"buffuzz.test.TooLarge.names"`)
+}
+
+func testGetMessageDescriptor(t *testing.T, name protoreflect.Name) protoreflect.MessageDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			protocompile.CompositeResolver{
+				&protocompile.SourceResolver{
+					ImportPaths: []string{"./testdata"},
+				},
+				protocompile.ResolverFunc(
+					func(path string) (protocompile.SearchResult, error) {
+						fileDescriptor, err := protoregistry.GlobalFiles.FindFileByPath(path)
+						if err != nil {
+							return protocompile.SearchResult{}, err
+						}
+						return protocompile.SearchResult{Desc: fileDescriptor}, nil
+					},
+				),
+			},
+		),
+	}).Compile(context.Background(), "test.proto")
+	require.NoError(t, err)
+	messageDescriptor := files[0].Messages().ByName(name)
+	require.NotNil(t, messageDescriptor)
+	return messageDescriptor
+}
+
+func testGenerateJSON(t *testing.T, generator Generator, count int) []string {
+	var values []string
+	for i := 0; i < count; i++ {
+		message, err := generator.Generate()
+		require.NoError(t, err)
+		data, err := protojson.Marshal(message)
+		require.NoError(t, err)
+		wireData, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		require.NoError(t, err)
+		values = append(values, string(data), string(wireData))
+	}
+	return values
+}
@@ -0,0 +1,875 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buffuzz
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/bufbuild/protovalidate-go/resolver"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// defaultMaxCount is the maximum number of repeated items or map pairs
+	// when not constrained.
+	defaultMaxCount = 3
+	// defaultMaxLen is the maximum length of strings and bytes when not constrained.
+	defaultMaxLen = 16
+	// defaultFloatRange is the magnitude of floats and doubles when not constrained.
+	defaultFloatRange = 1e6
+	// maxAttempts is the number of values to try to find one not in a not_in constraint.
+	maxAttempts = 100
+	// maxSize is the maximum number of repeated items, map pairs, or characters
+	// generated for a field, so that large constraints do not exhaust memory.
+	maxSize = 1 << 16
+
+	letters       = "abcdefghijklmnopqrstuvwxyz"
+	alphanumerics = letters + "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+type generator struct {
+	messageDescriptor protoreflect.MessageDescriptor
+	rand              *rand.Rand
+	protovalidate     bool
+	maxDepth          int
+}
+
+func newGenerator(
+	messageDescriptor protoreflect.MessageDescriptor,
+	seed int64,
+	options ...GeneratorOption,
+) *generator {
+	generator := &generator{
+		messageDescriptor: messageDescriptor,
+		rand:              rand.New(rand.NewSource(seed)),
+		maxDepth:          DefaultMaxDepth,
+	}
+	for _, option := range options {
+		option(generator)
+	}
+	return generator
+}
+
+func (g *generator) Generate() (proto.Message, error) {
+	message := dynamicpb.NewMessage(g.messageDescriptor)
+	if err := g.populateMessage(message, 0); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (g *generator) populateMessage(message protoreflect.Message, depth int) error {
+	messageDescriptor := message.Descriptor()
+	switch messageDescriptor.FullName() {
+	case "google.protobuf.Any":
+		// An Any can only be serialized to JSON if its type can be resolved,
+		// so we leave it empty.
+		return nil
+	case "google.protobuf.Timestamp":
+		// Between 0001-01-01 and 9999-12-31, as required by the JSON mapping.
+		g.setInt64(message, "seconds", g.int64InRange(-62135596800, 253402300799))
+		g.setInt32(message, "nanos", int32(g.rand.Int63n(1e9)))
+		return nil
+	case "google.protobuf.Duration":
+		// Seconds and nanos must have the same sign.
+		seconds := g.int64InRange(-315576000000, 315576000000)
+		nanos := int32(g.rand.Int63n(1e9))
+		if seconds < 0 || (seconds == 0 && g.rand.Intn(2) == 0) {
+			nanos = -nanos
+		}
+		g.setInt64(message, "seconds", seconds)
+		g.setInt32(message, "nanos", nanos)
+		return nil
+	case "google.protobuf.FieldMask":
+		// Paths must be lower_snake_case to be serialized to JSON.
+		paths := message.Mutable(messageDescriptor.Fields().ByName("paths")).List()
+		for i := g.rand.Intn(defaultMaxCount + 1); i > 0; i-- {
+			paths.Append(protoreflect.ValueOfString(g.lowerSnakeCaseString()))
+		}
+		return nil
+	}
+	oneofs := messageDescriptor.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneofDescriptor := oneofs.Get(i)
+		if oneofDescriptor.IsSynthetic() {
+			continue
+		}
+		if err := g.populateOneof(message, oneofDescriptor, depth); err != nil {
+			return err
+		}
+	}
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fieldDescriptor := fields.Get(i)
+		if oneofDescriptor := fieldDescriptor.ContainingOneof(); oneofDescriptor != nil && !oneofDescriptor.IsSynthetic() {
+			continue
+		}
+		constraints := g.fieldConstraints(fieldDescriptor)
+		if constraints.GetSkipped() {
+			continue
+		}
+		if err := g.populateField(message, fieldDescriptor, constraints, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) populateOneof(message protoreflect.Message, oneofDescriptor protoreflect.OneofDescriptor, depth int) error {
+	// A google.protobuf.Value must have its kind set to be serialized to JSON.
+	required := message.Descriptor().FullName() == "google.protobuf.Value"
+	if g.protovalidate {
+		required = required || resolver.DefaultResolver{}.ResolveOneofConstraints(oneofDescriptor).GetRequired()
+	}
+	var candidates []protoreflect.FieldDescriptor
+	fields := oneofDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fieldDescriptor := fields.Get(i)
+		if fieldDescriptor.Message() != nil && depth >= g.maxDepth {
+			continue
+		}
+		candidates = append(candidates, fieldDescriptor)
+	}
+	numChoices := len(candidates)
+	if !required {
+		// Leave the oneof unset.
+		numChoices++
+	}
+	if numChoices == 0 {
+		return nil
+	}
+	choice := g.rand.Intn(numChoices)
+	if choice >= len(candidates) {
+		return nil
+	}
+	fieldDescriptor := candidates[choice]
+	return g.setSingular(message, fieldDescriptor, g.fieldConstraints(fieldDescriptor), depth)
+}
+
+func (g *generator) populateField(
+	message protoreflect.Message,
+	fieldDescriptor protoreflect.FieldDescriptor,
+	constraints *validate.FieldConstraints,
+	depth int,
+) error {
+	required := constraints.GetRequired() || fieldDescriptor.Cardinality() == protoreflect.Required
+	switch {
+	case fieldDescriptor.IsMap():
+		return g.populateMap(message, fieldDescriptor, constraints, depth)
+	case fieldDescriptor.IsList():
+		return g.populateList(message, fieldDescriptor, constraints, depth)
+	case fieldDescriptor.Message() != nil:
+		if depth >= g.maxDepth || (!required && g.rand.Intn(2) == 0) {
+			return nil
+		}
+	case fieldDescriptor.HasPresence():
+		if !required && g.rand.Intn(2) == 0 {
+			return nil
+		}
+	}
+	return g.setSingular(message, fieldDescriptor, constraints, depth)
+}
+
+func (g *generator) setSingular(
+	message protoreflect.Message,
+	fieldDescriptor protoreflect.FieldDescriptor,
+	constraints *validate.FieldConstraints,
+	depth int,
+) error {
+	if fieldDescriptor.Message() != nil {
+		return g.populateMessage(message.Mutable(fieldDescriptor).Message(), depth+1)
+	}
+	value, err := g.newScalarValue(fieldDescriptor, constraints)
+	if err != nil {
+		return err
+	}
+	message.Set(fieldDescriptor, value)
+	return nil
+}
+
+func (g *generator) populateList(
+	message protoreflect.Message,
+	fieldDescriptor protoreflect.FieldDescriptor,
+	constraints *validate.FieldConstraints,
+	depth int,
+) error {
+	if fieldDescriptor.Message() != nil && depth >= g.maxDepth {
+		return nil
+	}
+	var minItems, maxItems *uint64
+	repeatedRules := constraints.GetRepeated()
+	if repeatedRules != nil {
+		minItems, maxItems = repeatedRules.MinItems, repeatedRules.MaxItems
+	}
+	count, err := g.count(fieldDescriptor, minItems, maxItems)
+	if err != nil {
+		return err
+	}
+	itemConstraints := repeatedRules.GetItems()
+	list := message.Mutable(fieldDescriptor).List()
+	for i := 0; i < count; i++ {
+		if fieldDescriptor.Message() != nil {
+			value := list.NewElement()
+			if err := g.populateMessage(value.Message(), depth+1); err != nil {
+				return err
+			}
+			list.Append(value)
+			continue
+		}
+		value, err := g.newScalarValue(fieldDescriptor, itemConstraints)
+		if err != nil {
+			return err
+		}
+		list.Append(value)
+	}
+	return nil
+}
+
+func (g *generator) populateMap(
+	message protoreflect.Message,
+	fieldDescriptor protoreflect.FieldDescriptor,
+	constraints *validate.FieldConstraints,
+	depth int,
+) error {
+	valueDescriptor := fieldDescriptor.MapValue()
+	if valueDescriptor.Message() != nil && depth >= g.maxDepth {
+		return nil
+	}
+	var minPairs, maxPairs *uint64
+	mapRules := constraints.GetMap()
+	if mapRules != nil {
+		minPairs, maxPairs = mapRules.MinPairs, mapRules.MaxPairs
+	}
+	count, err := g.count(fieldDescriptor, minPairs, maxPairs)
+	if err != nil {
+		return err
+	}
+	keyConstraints := mapRules.GetKeys()
+	valueConstraints := mapRules.GetValues()
+	m := message.Mutable(fieldDescriptor).Map()
+	// Keys may collide, so we stop trying after a fixed number of attempts.
+	for attempts := 0; m.Len() < count && attempts < count*maxAttempts; attempts++ {
+		key, err := g.newScalarValue(fieldDescriptor.MapKey(), keyConstraints)
+		if err != nil {
+			return err
+		}
+		mapKey := key.MapKey()
+		if m.Has(mapKey) {
+			continue
+		}
+		if valueDescriptor.Message() != nil {
+			value := m.NewValue()
+			if err := g.populateMessage(value.Message(), depth+1); err != nil {
+				return err
+			}
+			m.Set(mapKey, value)
+			continue
+		}
+		value, err := g.newScalarValue(valueDescriptor, valueConstraints)
+		if err != nil {
+			return err
+		}
+		m.Set(mapKey, value)
+	}
+	return nil
+}
+
+func (g *generator) count(fieldDescriptor protoreflect.FieldDescriptor, minCount *uint64, maxCount *uint64) (int, error) {
+	low := uint64(0)
+	if minCount != nil {
+		low = *minCount
+	}
+	high := saturatingAdd(low, defaultMaxCount)
+	if maxCount != nil {
+		high = *maxCount
+	}
+	return g.size(fieldDescriptor, low, high)
+}
+
+func (g *generator) newScalarValue(
+	fieldDescriptor protoreflect.FieldDescriptor,
+	constraints *validate.FieldConstraints,
+) (protoreflect.Value, error) {
+	switch kind := fieldDescriptor.Kind(); kind {
+	case protoreflect.BoolKind:
+		if boolRules := constraints.GetBool(); boolRules != nil && boolRules.Const != nil {
+			return protoreflect.ValueOfBool(boolRules.GetConst()), nil
+		}
+		return protoreflect.ValueOfBool(g.rand.Intn(2) == 0), nil
+	case protoreflect.EnumKind:
+		return g.newEnumValue(fieldDescriptor, constraints.GetEnum())
+	case protoreflect.StringKind:
+		value, err := g.newString(fieldDescriptor, constraints.GetString_())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		value, err := g.newBytes(fieldDescriptor, constraints.GetBytes())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfBytes(value), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		value, err := g.newInt(fieldDescriptor, numericRules(constraints), math.MinInt32, math.MaxInt32)
+		return protoreflect.ValueOfInt32(int32(value)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		value, err := g.newInt(fieldDescriptor, numericRules(constraints), math.MinInt64, math.MaxInt64)
+		return protoreflect.ValueOfInt64(value), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		value, err := g.newUint(fieldDescriptor, numericRules(constraints), math.MaxUint32)
+		return protoreflect.ValueOfUint32(uint32(value)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		value, err := g.newUint(fieldDescriptor, numericRules(constraints), math.MaxUint64)
+		return protoreflect.ValueOfUint64(value), err
+	case protoreflect.FloatKind:
+		value, err := g.newFloat(fieldDescriptor, numericRules(constraints), math.MaxFloat32)
+		return protoreflect.ValueOfFloat32(float32(value)), err
+	case protoreflect.DoubleKind:
+		value, err := g.newFloat(fieldDescriptor, numericRules(constraints), math.MaxFloat64)
+		return protoreflect.ValueOfFloat64(value), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unknown kind for field %q: %v", fieldDescriptor.FullName(), kind)
+	}
+}
+
+func (g *generator) newEnumValue(fieldDescriptor protoreflect.FieldDescriptor, enumRules *validate.EnumRules) (protoreflect.Value, error) {
+	if enumRules != nil && enumRules.Const != nil {
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(enumRules.GetConst())), nil
+	}
+	notIn := make(map[int32]struct{})
+	for _, number := range enumRules.GetNotIn() {
+		notIn[number] = struct{}{}
+	}
+	var candidates []int32
+	if in := enumRules.GetIn(); len(in) > 0 {
+		candidates = in
+	} else {
+		values := fieldDescriptor.Enum().Values()
+		for i := 0; i < values.Len(); i++ {
+			candidates = append(candidates, int32(values.Get(i).Number()))
+		}
+	}
+	var allowed []int32
+	for _, candidate := range candidates {
+		if _, ok := notIn[candidate]; !ok {
+			allowed = append(allowed, candidate)
+		}
+	}
+	if len(allowed) == 0 {
+		return protoreflect.Value{}, newUnsatisfiableError(fieldDescriptor)
+	}
+	return protoreflect.ValueOfEnum(protoreflect.EnumNumber(allowed[g.rand.Intn(len(allowed))])), nil
+}
+
+func (g *generator) newString(fieldDescriptor protoreflect.FieldDescriptor, stringRules *validate.StringRules) (string, error) {
+	if stringRules == nil {
+		return g.alphanumericString(g.rand.Intn(defaultMaxLen + 1)), nil
+	}
+	if stringRules.Const != nil {
+		return stringRules.GetConst(), nil
+	}
+	notIn := make(map[string]struct{})
+	for _, value := range stringRules.GetNotIn() {
+		notIn[value] = struct{}{}
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		value, err := g.newStringCandidate(fieldDescriptor, stringRules)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := notIn[value]; !ok {
+			return value, nil
+		}
+	}
+	return "", newUnsatisfiableError(fieldDescriptor)
+}
+
+func (g *generator) newStringCandidate(fieldDescriptor protoreflect.FieldDescriptor, stringRules *validate.StringRules) (string, error) {
+	if in := stringRules.GetIn(); len(in) > 0 {
+		return in[g.rand.Intn(len(in))], nil
+	}
+	switch {
+	case stringRules.GetEmail():
+		return g.lowerString(1+g.rand.Intn(8)) + "@" + g.hostname(), nil
+	case stringRules.GetHostname():
+		return g.hostname(), nil
+	case stringRules.GetUri():
+		return "https://" + g.hostname() + "/" + g.lowerString(g.rand.Intn(8)), nil
+	case stringRules.GetUuid():
+		return g.uuid(), nil
+	case stringRules.GetIpv4():
+		return g.ipv4(), nil
+	case stringRules.GetIpv6():
+		return g.ipv6(), nil
+	case stringRules.GetIp():
+		if g.rand.Intn(2) == 0 {
+			return g.ipv4(), nil
+		}
+		return g.ipv6(), nil
+	}
+	affixes := stringRules.GetPrefix() + stringRules.GetContains() + stringRules.GetSuffix()
+	length, err := g.length(fieldDescriptor, stringRules.Len, stringRules.MinLen, stringRules.MaxLen, uint64(utf8.RuneCountInString(affixes)))
+	if err != nil {
+		return "", err
+	}
+	return stringRules.GetPrefix() + stringRules.GetContains() + g.alphanumericString(length) + stringRules.GetSuffix(), nil
+}
+
+func (g *generator) newBytes(fieldDescriptor protoreflect.FieldDescriptor, bytesRules *validate.BytesRules) ([]byte, error) {
+	if bytesRules == nil {
+		return g.bytes(g.rand.Intn(defaultMaxLen + 1)), nil
+	}
+	if bytesRules.Const != nil {
+		return bytesRules.GetConst(), nil
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		value, err := g.newBytesCandidate(fieldDescriptor, bytesRules)
+		if err != nil {
+			return nil, err
+		}
+		if !containsBytes(bytesRules.GetNotIn(), value) {
+			return value, nil
+		}
+	}
+	return nil, newUnsatisfiableError(fieldDescriptor)
+}
+
+func (g *generator) newBytesCandidate(fieldDescriptor protoreflect.FieldDescriptor, bytesRules *validate.BytesRules) ([]byte, error) {
+	if in := bytesRules.GetIn(); len(in) > 0 {
+		return in[g.rand.Intn(len(in))], nil
+	}
+	affixesLen := len(bytesRules.GetPrefix()) + len(bytesRules.GetContains()) + len(bytesRules.GetSuffix())
+	length, err := g.length(fieldDescriptor, bytesRules.Len, bytesRules.MinLen, bytesRules.MaxLen, uint64(affixesLen))
+	if err != nil {
+		return nil, err
+	}
+	value := make([]byte, 0, affixesLen+length)
+	value = append(value, bytesRules.GetPrefix()...)
+	value = append(value, bytesRules.GetContains()...)
+	value = append(value, g.bytes(length)...)
+	return append(value, bytesRules.GetSuffix()...), nil
+}
+
+// length returns the number of random characters to generate, given that
+// affixesLen characters are already fixed.
+func (g *generator) length(
+	fieldDescriptor protoreflect.FieldDescriptor,
+	exactLen *uint64,
+	minLen *uint64,
+	maxLen *uint64,
+	affixesLen uint64,
+) (int, error) {
+	low := affixesLen
+	if minLen != nil && *minLen > low {
+		low = *minLen
+	}
+	high := saturatingAdd(low, defaultMaxLen)
+	if maxLen != nil {
+		high = *maxLen
+	}
+	if exactLen != nil {
+		low = *exactLen
+		high = *exactLen
+	}
+	if affixesLen > high {
+		return 0, newUnsatisfiableError(fieldDescriptor)
+	}
+	if low < affixesLen {
+		low = affixesLen
+	}
+	size, err := g.size(fieldDescriptor, low, high)
+	if err != nil {
+		return 0, err
+	}
+	return size - int(affixesLen), nil
+}
+
+// size returns a random size between low and high inclusive.
+//
+// The size is at most maxSize, and it is an error if low is larger than maxSize.
+func (g *generator) size(fieldDescriptor protoreflect.FieldDescriptor, low uint64, high uint64) (int, error) {
+	if low > high {
+		return 0, newUnsatisfiableError(fieldDescriptor)
+	}
+	if low > maxSize {
+		return 0, newTooLargeError(fieldDescriptor, low)
+	}
+	if high > maxSize {
+		high = maxSize
+	}
+	return int(low) + g.rand.Intn(int(high-low)+1), nil
+}
+
+func (g *generator) newInt(fieldDescriptor protoreflect.FieldDescriptor, rules protoreflect.Message, low int64, high int64) (int64, error) {
+	if rules == nil {
+		return g.int64InRange(low, high), nil
+	}
+	if value, ok := getRule(rules, "const"); ok {
+		return value.Int(), nil
+	}
+	var notIn []int64
+	if value, ok := getRule(rules, "not_in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			notIn = append(notIn, list.Get(i).Int())
+		}
+	}
+	var in []int64
+	if value, ok := getRule(rules, "in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			in = append(in, list.Get(i).Int())
+		}
+	}
+	typeLow, typeHigh := low, high
+	if value, ok := getRule(rules, "gt"); ok {
+		if value.Int() == typeHigh {
+			return 0, newUnsatisfiableError(fieldDescriptor)
+		}
+		low = value.Int() + 1
+	}
+	if value, ok := getRule(rules, "gte"); ok {
+		low = value.Int()
+	}
+	if value, ok := getRule(rules, "lt"); ok {
+		if value.Int() == typeLow {
+			return 0, newUnsatisfiableError(fieldDescriptor)
+		}
+		high = value.Int() - 1
+	}
+	if value, ok := getRule(rules, "lte"); ok {
+		high = value.Int()
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var value int64
+		switch {
+		case len(in) > 0:
+			value = in[g.rand.Intn(len(in))]
+		case low <= high:
+			value = g.int64InRange(low, high)
+		case g.rand.Intn(2) == 0:
+			// An exclusive range, such as gt: 10, lt: 5.
+			value = g.int64InRange(low, typeHigh)
+		default:
+			value = g.int64InRange(typeLow, high)
+		}
+		if !containsInt64(notIn, value) {
+			return value, nil
+		}
+	}
+	return 0, newUnsatisfiableError(fieldDescriptor)
+}
+
+func (g *generator) newUint(fieldDescriptor protoreflect.FieldDescriptor, rules protoreflect.Message, high uint64) (uint64, error) {
+	low := uint64(0)
+	if rules == nil {
+		return g.uint64InRange(low, high), nil
+	}
+	if value, ok := getRule(rules, "const"); ok {
+		return value.Uint(), nil
+	}
+	var notIn []uint64
+	if value, ok := getRule(rules, "not_in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			notIn = append(notIn, list.Get(i).Uint())
+		}
+	}
+	var in []uint64
+	if value, ok := getRule(rules, "in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			in = append(in, list.Get(i).Uint())
+		}
+	}
+	typeHigh := high
+	if value, ok := getRule(rules, "gt"); ok {
+		if value.Uint() == typeHigh {
+			return 0, newUnsatisfiableError(fieldDescriptor)
+		}
+		low = value.Uint() + 1
+	}
+	if value, ok := getRule(rules, "gte"); ok {
+		low = value.Uint()
+	}
+	if value, ok := getRule(rules, "lt"); ok {
+		if value.Uint() == 0 {
+			return 0, newUnsatisfiableError(fieldDescriptor)
+		}
+		high = value.Uint() - 1
+	}
+	if value, ok := getRule(rules, "lte"); ok {
+		high = value.Uint()
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var value uint64
+		switch {
+		case len(in) > 0:
+			value = in[g.rand.Intn(len(in))]
+		case low <= high:
+			value = g.uint64InRange(low, high)
+		case g.rand.Intn(2) == 0:
+			// An exclusive range, such as gt: 10, lt: 5.
+			value = g.uint64InRange(low, typeHigh)
+		default:
+			value = g.uint64InRange(0, high)
+		}
+		if !containsUint64(notIn, value) {
+			return value, nil
+		}
+	}
+	return 0, newUnsatisfiableError(fieldDescriptor)
+}
+
+func (g *generator) newFloat(fieldDescriptor protoreflect.FieldDescriptor, rules protoreflect.Message, typeMax float64) (float64, error) {
+	low, high := -defaultFloatRange, defaultFloatRange
+	if rules == nil {
+		return low + g.rand.Float64()*(high-low), nil
+	}
+	if value, ok := getRule(rules, "const"); ok {
+		return value.Float(), nil
+	}
+	var notIn []float64
+	if value, ok := getRule(rules, "not_in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			notIn = append(notIn, list.Get(i).Float())
+		}
+	}
+	var in []float64
+	if value, ok := getRule(rules, "in"); ok {
+		list := value.List()
+		for i := 0; i < list.Len(); i++ {
+			in = append(in, list.Get(i).Float())
+		}
+	}
+	var hasLow, hasHigh bool
+	for _, name := range []protoreflect.Name{"gt", "gte"} {
+		if value, ok := getRule(rules, name); ok {
+			low, hasLow = value.Float(), true
+		}
+	}
+	for _, name := range []protoreflect.Name{"lt", "lte"} {
+		if value, ok := getRule(rules, name); ok {
+			high, hasHigh = value.Float(), true
+		}
+	}
+	switch {
+	case hasLow && !hasHigh:
+		high = math.Min(low+2*defaultFloatRange, typeMax)
+	case hasHigh && !hasLow:
+		low = math.Max(high-2*defaultFloatRange, -typeMax)
+	}
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var value float64
+		switch {
+		case len(in) > 0:
+			value = in[g.rand.Intn(len(in))]
+		case low <= high:
+			value = low + g.rand.Float64()*(high-low)
+		case g.rand.Intn(2) == 0:
+			// An exclusive range, such as gt: 10, lt: 5.
+			value = low + g.rand.Float64()*defaultFloatRange
+		default:
+			value = high - g.rand.Float64()*defaultFloatRange
+		}
+		// Exclusive bounds are only hit if the random float is exactly 0.
+		if _, ok := getRule(rules, "gt"); ok && value == low {
+			continue
+		}
+		if _, ok := getRule(rules, "lt"); ok && value == high {
+			continue
+		}
+		if !containsFloat64(notIn, value) {
+			return value, nil
+		}
+	}
+	return 0, newUnsatisfiableError(fieldDescriptor)
+}
+
+// fieldConstraints returns the protovalidate constraints for the field.
+//
+// Returns nil if protovalidate is not enabled or the field has no constraints.
+func (g *generator) fieldConstraints(fieldDescriptor protoreflect.FieldDescriptor) *validate.FieldConstraints {
+	if !g.protovalidate {
+		return nil
+	}
+	return resolver.DefaultResolver{}.ResolveFieldConstraints(fieldDescriptor)
+}
+
+func (g *generator) int64InRange(low int64, high int64) int64 {
+	return int64(uint64(low) + g.uint64InRange(0, uint64(high)-uint64(low)))
+}
+
+func (g *generator) uint64InRange(low uint64, high uint64) uint64 {
+	span := high - low
+	if span == math.MaxUint64 {
+		return g.rand.Uint64()
+	}
+	return low + g.rand.Uint64()%(span+1)
+}
+
+func (g *generator) setInt64(message protoreflect.Message, name protoreflect.Name, value int64) {
+	message.Set(message.Descriptor().Fields().ByName(name), protoreflect.ValueOfInt64(value))
+}
+
+func (g *generator) setInt32(message protoreflect.Message, name protoreflect.Name, value int32) {
+	message.Set(message.Descriptor().Fields().ByName(name), protoreflect.ValueOfInt32(value))
+}
+
+func (g *generator) alphanumericString(length int) string {
+	return g.stringFrom(alphanumerics, length)
+}
+
+func (g *generator) lowerString(length int) string {
+	return g.stringFrom(letters, length)
+}
+
+func (g *generator) stringFrom(characters string, length int) string {
+	var builder strings.Builder
+	builder.Grow(length)
+	for i := 0; i < length; i++ {
+		builder.WriteByte(characters[g.rand.Intn(len(characters))])
+	}
+	return builder.String()
+}
+
+func (g *generator) lowerSnakeCaseString() string {
+	words := make([]string, 1+g.rand.Intn(2))
+	for i := range words {
+		words[i] = g.lowerString(1 + g.rand.Intn(8))
+	}
+	return strings.Join(words, "_")
+}
+
+func (g *generator) bytes(length int) []byte {
+	value := make([]byte, length)
+	// Read on a *rand.Rand never returns an error.
+	_, _ = g.rand.Read(value)
+	return value
+}
+
+func (g *generator) hostname() string {
+	return g.lowerString(1+g.rand.Intn(8)) + "." + g.lowerString(2+g.rand.Intn(2))
+}
+
+func (g *generator) uuid() string {
+	value := g.bytes(16)
+	// Version 4, variant 1.
+	value[6] = (value[6] & 0x0f) | 0x40
+	value[8] = (value[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", value[0:4], value[4:6], value[6:8], value[8:10], value[10:16])
+}
+
+func (g *generator) ipv4() string {
+	parts := make([]string, 4)
+	for i := range parts {
+		parts[i] = strconv.Itoa(g.rand.Intn(256))
+	}
+	return strings.Join(parts, ".")
+}
+
+func (g *generator) ipv6() string {
+	parts := make([]string, 8)
+	for i := range parts {
+		parts[i] = strconv.FormatInt(int64(g.rand.Intn(1<<16)), 16)
+	}
+	return strings.Join(parts, ":")
+}
+
+// numericRules returns the rules for the numeric type of the constraints, if any.
+//
+// All numeric rules share the const, in, not_in, gt, gte, lt, and lte fields.
+func numericRules(constraints *validate.FieldConstraints) protoreflect.Message {
+	if constraints == nil {
+		return nil
+	}
+	message := constraints.ProtoReflect()
+	fieldDescriptor := message.WhichOneof(message.Descriptor().Oneofs().ByName("type"))
+	if fieldDescriptor == nil || fieldDescriptor.Message() == nil {
+		return nil
+	}
+	return message.Get(fieldDescriptor).Message()
+}
+
+func getRule(rules protoreflect.Message, name protoreflect.Name) (protoreflect.Value, bool) {
+	fieldDescriptor := rules.Descriptor().Fields().ByName(name)
+	if fieldDescriptor == nil || !rules.Has(fieldDescriptor) {
+		return protoreflect.Value{}, false
+	}
+	return rules.Get(fieldDescriptor), true
+}
+
+func containsInt64(values []int64, value int64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint64(values []uint64, value uint64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFloat64(values []float64, value float64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsBytes(values [][]byte, value []byte) bool {
+	for _, v := range values {
+		if bytes.Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func newUnsatisfiableError(fieldDescriptor protoreflect.FieldDescriptor) error {
+	return fmt.Errorf("could not generate a value for field %q that satisfies its protovalidate constraints", fieldDescriptor.FullName())
+}
+
+func newTooLargeError(fieldDescriptor protoreflect.FieldDescriptor, size uint64) error {
+	return fmt.Errorf("protovalidate constraints of field %q require a size of at least %d, the maximum that can be generated is %d", fieldDescriptor.FullName(), size, maxSize)
+}
+
+// saturatingAdd returns a+b, or math.MaxUint64 if the sum overflows.
+func saturatingAdd(a uint64, b uint64) uint64 {
+	if sum := a + b; sum >= a {
+		return sum
+	}
+	return math.MaxUint64
+}
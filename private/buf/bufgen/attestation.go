@@ -0,0 +1,109 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+)
+
+// containerImageDigestPrefix is the only supported algorithm of container image digests.
+const containerImageDigestPrefix = "sha256:"
+
+// verifyPluginAttestations returns an error if the registry resolves any of the plugin
+// references to a plugin with a container image digest that is not attested to by the
+// attestation config of the plugin.
+//
+// The plugin references are resolved the same way as by GenerateCode, so this must be
+// called before the code is generated.
+func verifyPluginAttestations(
+	ctx context.Context,
+	pluginCurationService registryv1alpha1connect.PluginCurationServiceClient,
+	remote string,
+	pluginConfigs []*remotePluginExecArgs,
+	requests []*registryv1alpha1.PluginGenerationRequest,
+) error {
+	for i, request := range requests {
+		attestationConfig := pluginConfigs[i].PluginConfig.AttestationConfig
+		if attestationConfig == nil {
+			continue
+		}
+		pluginReference := request.GetPluginReference()
+		response, err := pluginCurationService.GetLatestCuratedPlugin(
+			ctx,
+			connect.NewRequest(
+				&registryv1alpha1.GetLatestCuratedPluginRequest{
+					Owner:    pluginReference.GetOwner(),
+					Name:     pluginReference.GetName(),
+					Version:  pluginReference.GetVersion(),
+					Revision: pluginReference.GetRevision(),
+				},
+			),
+		)
+		if err != nil {
+			return err
+		}
+		if err := verifyPluginAttestation(
+			attestationConfig,
+			remote+"/"+pluginReference.GetOwner()+"/"+pluginReference.GetName(),
+			response.Msg.GetPlugin().GetContainerImageDigest(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyPluginAttestation returns an error if the container image digest is not one of
+// the container image digests of the attestation config.
+func verifyPluginAttestation(
+	attestationConfig *PluginAttestationConfig,
+	plugin string,
+	containerImageDigest string,
+) error {
+	for _, attestedContainerImageDigest := range attestationConfig.ContainerImageDigests {
+		if containerImageDigest == attestedContainerImageDigest {
+			return nil
+		}
+	}
+	if containerImageDigest == "" {
+		return fmt.Errorf("remote plugin %s: the registry did not return a container image digest, not generating code", plugin)
+	}
+	return fmt.Errorf(
+		"remote plugin %s: container image digest %s is not %s, not generating code",
+		plugin,
+		containerImageDigest,
+		stringutil.SliceToHumanStringOr(attestationConfig.ContainerImageDigests),
+	)
+}
+
+// validateContainerImageDigest returns an error if the container image digest is
+// not a sha256 digest.
+func validateContainerImageDigest(containerImageDigest string) error {
+	hexDigest, ok := strings.CutPrefix(containerImageDigest, containerImageDigestPrefix)
+	if ok && len(hexDigest) == 64 && strings.ToLower(hexDigest) == hexDigest {
+		if _, err := hex.DecodeString(hexDigest); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid container image digest %q, must be of the form %s<hex>", containerImageDigest, containerImageDigestPrefix)
+}
@@ -0,0 +1,113 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testContainerImageDigest      = "sha256:000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testOtherContainerImageDigest = "sha256:1f1e1d1c1b1a191817161514131211100f0e0d0c0b0a09080706050403020100"
+)
+
+func TestVerifyPluginAttestations(t *testing.T) {
+	t.Parallel()
+	pluginCurationService := &testPluginCurationService{
+		versionToContainerImageDigest: map[string]string{
+			"v1.0.0": testContainerImageDigest,
+			"v2.0.0": testOtherContainerImageDigest,
+			"v3.0.0": "",
+		},
+	}
+	verify := func(version string, attestationConfig *PluginAttestationConfig) error {
+		return verifyPluginAttestations(
+			context.Background(),
+			pluginCurationService,
+			"buf.build",
+			[]*remotePluginExecArgs{
+				{
+					PluginConfig: &PluginConfig{
+						Plugin:            "buf.build/protocolbuffers/go:" + version,
+						AttestationConfig: attestationConfig,
+					},
+				},
+			},
+			[]*registryv1alpha1.PluginGenerationRequest{
+				{
+					PluginReference: &registryv1alpha1.CuratedPluginReference{
+						Owner:   "protocolbuffers",
+						Name:    "go",
+						Version: version,
+					},
+				},
+			},
+		)
+	}
+	attestationConfig := &PluginAttestationConfig{
+		ContainerImageDigests: []string{testContainerImageDigest},
+	}
+	assert.NoError(t, verify("v1.0.0", attestationConfig))
+	err := verify("v2.0.0", attestationConfig)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "remote plugin buf.build/protocolbuffers/go: container image digest "+testOtherContainerImageDigest)
+	assert.Error(t, verify("v3.0.0", attestationConfig))
+	// Plugins without an attestation config are not resolved.
+	assert.NoError(t, verify("v4.0.0", nil))
+	// Errors resolving the plugin are returned.
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(verify("v4.0.0", attestationConfig)))
+}
+
+func TestValidateContainerImageDigest(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, validateContainerImageDigest(testContainerImageDigest))
+	assert.Error(t, validateContainerImageDigest("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"))
+	assert.Error(t, validateContainerImageDigest("sha512:000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"))
+	assert.Error(t, validateContainerImageDigest("sha256:000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F"))
+	assert.Error(t, validateContainerImageDigest("sha256:abc"))
+}
+
+type testPluginCurationService struct {
+	registryv1alpha1connect.UnimplementedPluginCurationServiceHandler
+
+	versionToContainerImageDigest map[string]string
+}
+
+func (s *testPluginCurationService) GetLatestCuratedPlugin(
+	_ context.Context,
+	request *connect.Request[registryv1alpha1.GetLatestCuratedPluginRequest],
+) (*connect.Response[registryv1alpha1.GetLatestCuratedPluginResponse], error) {
+	containerImageDigest, ok := s.versionToContainerImageDigest[request.Msg.Version]
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, nil)
+	}
+	return connect.NewResponse(
+		&registryv1alpha1.GetLatestCuratedPluginResponse{
+			Plugin: &registryv1alpha1.CuratedPlugin{
+				Owner:                request.Msg.Owner,
+				Name:                 request.Msg.Name,
+				Version:              request.Msg.Version,
+				ContainerImageDigest: containerImageDigest,
+			},
+		},
+	), nil
+}
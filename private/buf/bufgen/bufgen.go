@@ -22,6 +22,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
@@ -54,6 +55,30 @@ const (
 	StrategyAll Strategy = 2
 )
 
+const (
+	// ErrorPolicyFailFast is the error policy that says to stop generation at the
+	// first plugin that fails.
+	//
+	// This is the default value.
+	ErrorPolicyFailFast ErrorPolicy = 1
+	// ErrorPolicyCollectAll is the error policy that says to run all plugins and
+	// report all of the plugins that failed, with the stderr of each local plugin
+	// labeled with the name of the plugin.
+	ErrorPolicyCollectAll ErrorPolicy = 2
+)
+
+const (
+	// LayoutGoModule is the layout that arranges outputs as a Go module, with
+	// a go.mod file at the root.
+	LayoutGoModule Layout = 1
+	// LayoutJavaMaven is the layout that arranges outputs as a Maven project, with
+	// a pom.xml file at the root and sources in src/main/java.
+	LayoutJavaMaven Layout = 2
+	// LayoutNpmPackage is the layout that arranges outputs as an npm package, with
+	// a package.json file at the root and sources in src.
+	LayoutNpmPackage Layout = 3
+)
+
 // Strategy is a generation stategy.
 type Strategy int
 
@@ -83,6 +108,66 @@ func (s Strategy) String() string {
 	}
 }
 
+// ErrorPolicy is a policy for how generation handles plugins that fail.
+type ErrorPolicy int
+
+// ParseErrorPolicy parses the ErrorPolicy.
+//
+// If the empty string is provided, this is interpreted as ErrorPolicyFailFast.
+func ParseErrorPolicy(s string) (ErrorPolicy, error) {
+	switch s {
+	case "", "fail-fast":
+		return ErrorPolicyFailFast, nil
+	case "collect-all":
+		return ErrorPolicyCollectAll, nil
+	default:
+		return 0, fmt.Errorf("unknown error policy: %s", s)
+	}
+}
+
+// String implements fmt.Stringer.
+func (e ErrorPolicy) String() string {
+	switch e {
+	case ErrorPolicyFailFast:
+		return "fail-fast"
+	case ErrorPolicyCollectAll:
+		return "collect-all"
+	default:
+		return strconv.Itoa(int(e))
+	}
+}
+
+// Layout is a preset that arranges plugin outputs into a conventional project structure.
+type Layout int
+
+// ParseLayout parses the Layout.
+func ParseLayout(s string) (Layout, error) {
+	switch s {
+	case "go-module":
+		return LayoutGoModule, nil
+	case "java-maven":
+		return LayoutJavaMaven, nil
+	case "npm-package":
+		return LayoutNpmPackage, nil
+	default:
+		return 0, fmt.Errorf("unknown layout: %s", s)
+	}
+}
+
+// String implements fmt.Stringer.
+func (l Layout) String() string {
+	switch l {
+	case LayoutGoModule:
+		return "go-module"
+	case LayoutJavaMaven:
+		return "java-maven"
+	case LayoutNpmPackage:
+		return "npm-package"
+	default:
+		return strconv.Itoa(int(l))
+	}
+}
+
 // Provider is a provider.
 type Provider interface {
 	// GetConfig gets the Config for the YAML data at ExternalConfigFilePath.
@@ -149,6 +234,25 @@ func GenerateWithIncludeImports() GenerateOption {
 	}
 }
 
+// GenerateWithPluginGracePeriod returns a new GenerateOption that interrupts
+// local plugins when generation is cancelled, and only kills them if they do
+// not exit within the grace period.
+//
+// The default is to kill local plugins immediately when generation is cancelled.
+func GenerateWithPluginGracePeriod(pluginGracePeriod time.Duration) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.pluginGracePeriod = pluginGracePeriod
+	}
+}
+
+// GenerateWithErrorPolicy returns a new GenerateOption that uses the given
+// ErrorPolicy instead of the ErrorPolicy of the Config.
+func GenerateWithErrorPolicy(errorPolicy ErrorPolicy) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.errorPolicy = errorPolicy
+	}
+}
+
 // GenerateWithIncludeWellKnownTypes says to also generate well known types.
 //
 // This option has no effect if GenerateWithIncludeImports is not set.
@@ -166,6 +270,10 @@ type Config struct {
 	ManagedConfig *ManagedConfig
 	// Optional
 	TypesConfig *TypesConfig
+	// Optional
+	LayoutConfig *LayoutConfig
+	// Optional, the zero value is treated as ErrorPolicyFailFast
+	ErrorPolicy ErrorPolicy
 }
 
 // PluginConfig is a plugin configuration.
@@ -186,6 +294,46 @@ type PluginConfig struct {
 	Strategy Strategy
 	// Optional
 	ProtocPath string
+	// Optional, only used for local plugins
+	Timeout time.Duration
+	// Optional, only used for local plugins
+	SandboxConfig *PluginSandboxConfig
+	// Optional, overrides GenerateWithIncludeImports for this plugin if set
+	IncludeImports *bool
+	// Optional, overrides GenerateWithIncludeWellKnownTypes for this plugin if set
+	IncludeWKT *bool
+	// Optional, only used for remote plugins
+	AttestationConfig *PluginAttestationConfig
+}
+
+// PluginAttestationConfig is the attestation policy for a remote plugin.
+//
+// The registry runs each version of a remote plugin from a container image, and reports
+// the digest of the image with the plugin version. If set, code is only generated if the
+// registry resolves the plugin to a version built from one of the ContainerImageDigests.
+type PluginAttestationConfig struct {
+	// ContainerImageDigests are the attested container image digests, such as
+	// sha256:<hex>.
+	//
+	// Always non-empty.
+	ContainerImageDigests []string
+}
+
+// PluginSandboxConfig is the sandbox configuration for a local plugin.
+//
+// Resource limits and network denial are only supported on Linux, generation
+// fails if they are set on other platforms.
+type PluginSandboxConfig struct {
+	// MaxMemoryBytes is the maximum virtual memory of the plugin process, or 0 for no limit.
+	MaxMemoryBytes uint64
+	// MaxCPUTime is the maximum CPU time of the plugin process, or 0 for no limit.
+	MaxCPUTime time.Duration
+	// IsolateTempDir says to point TMPDIR, TMP, and TEMP at a new temporary
+	// directory that is removed after the plugin exits. This does not prevent
+	// the plugin from accessing other directories.
+	IsolateTempDir bool
+	// DenyNetwork says to run the plugin without network access.
+	DenyNetwork bool
 }
 
 // PluginName returns this PluginConfig's plugin name.
@@ -206,6 +354,21 @@ func (p *PluginConfig) PluginName() string {
 	return ""
 }
 
+// includeImportsAndWKT returns whether imports and Well-Known Types should be generated
+// for this plugin, given the values passed to Generate.
+//
+// Values set on the PluginConfig take precedence. Well-Known Types are never generated
+// without imports.
+func (p *PluginConfig) includeImportsAndWKT(includeImports bool, includeWKT bool) (bool, bool) {
+	if p.IncludeImports != nil {
+		includeImports = *p.IncludeImports
+	}
+	if p.IncludeWKT != nil {
+		includeWKT = *p.IncludeWKT
+	}
+	return includeImports, includeImports && includeWKT
+}
+
 // IsRemote returns true if the PluginConfig uses a remotely executed plugin.
 func (p *PluginConfig) IsRemote() bool {
 	return p.GetRemoteHostname() != ""
@@ -295,6 +458,23 @@ type TypesConfig struct {
 	Include []string
 }
 
+// LayoutConfig is a layout configuration.
+//
+// With a layout, the out directory of each plugin is relative to the source
+// directory of the layout, and the files of the project that do not exist yet,
+// such as go.mod, are created from templates.
+type LayoutConfig struct {
+	// Required
+	Layout Layout
+	// Required
+	//
+	// The Go module path for LayoutGoModule, the groupId:artifactId for
+	// LayoutJavaMaven, and the package name for LayoutNpmPackage.
+	Name string
+	// Optional, not used for LayoutGoModule
+	Version string
+}
+
 // ReadConfig reads the configuration from the OS or an override, if any.
 //
 // Only use in CLI tools.
@@ -341,19 +521,47 @@ type ExternalConfigV1 struct {
 	Plugins []ExternalPluginConfigV1 `json:"plugins,omitempty" yaml:"plugins,omitempty"`
 	Managed ExternalManagedConfigV1  `json:"managed,omitempty" yaml:"managed,omitempty"`
 	Types   ExternalTypesConfigV1    `json:"types,omitempty" yaml:"types,omitempty"`
+	Layout  ExternalLayoutConfigV1   `json:"layout,omitempty" yaml:"layout,omitempty"`
+	// ErrorPolicy is either fail-fast or collect-all, and defaults to fail-fast.
+	ErrorPolicy string `json:"error_policy,omitempty" yaml:"error_policy,omitempty"`
 }
 
 // ExternalPluginConfigV1 is an external plugin configuration.
 type ExternalPluginConfigV1 struct {
-	Plugin     string      `json:"plugin,omitempty" yaml:"plugin,omitempty"`
-	Revision   int         `json:"revision,omitempty" yaml:"revision,omitempty"`
-	Name       string      `json:"name,omitempty" yaml:"name,omitempty"`
-	Remote     string      `json:"remote,omitempty" yaml:"remote,omitempty"`
-	Out        string      `json:"out,omitempty" yaml:"out,omitempty"`
-	Opt        interface{} `json:"opt,omitempty" yaml:"opt,omitempty"`
-	Path       interface{} `json:"path,omitempty" yaml:"path,omitempty"`
-	ProtocPath string      `json:"protoc_path,omitempty" yaml:"protoc_path,omitempty"`
-	Strategy   string      `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	Plugin     string                         `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+	Revision   int                            `json:"revision,omitempty" yaml:"revision,omitempty"`
+	Name       string                         `json:"name,omitempty" yaml:"name,omitempty"`
+	Remote     string                         `json:"remote,omitempty" yaml:"remote,omitempty"`
+	Out        string                         `json:"out,omitempty" yaml:"out,omitempty"`
+	Opt        interface{}                    `json:"opt,omitempty" yaml:"opt,omitempty"`
+	Path       interface{}                    `json:"path,omitempty" yaml:"path,omitempty"`
+	ProtocPath string                         `json:"protoc_path,omitempty" yaml:"protoc_path,omitempty"`
+	Strategy   string                         `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	Timeout    string                         `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Sandbox    *ExternalPluginSandboxConfigV1 `json:"sandbox,omitempty" yaml:"sandbox,omitempty"`
+	// IncludeImports and IncludeWKT override --include-imports and --include-wkt for
+	// this plugin if set.
+	IncludeImports *bool `json:"include_imports,omitempty" yaml:"include_imports,omitempty"`
+	IncludeWKT     *bool `json:"include_wkt,omitempty" yaml:"include_wkt,omitempty"`
+	// Attestation is only valid for remote plugins.
+	Attestation *ExternalPluginAttestationConfigV1 `json:"attestation,omitempty" yaml:"attestation,omitempty"`
+}
+
+// ExternalPluginAttestationConfigV1 is an external plugin attestation configuration.
+//
+// Only use outside of this package for testing.
+type ExternalPluginAttestationConfigV1 struct {
+	ContainerImageDigests []string `json:"container_image_digests,omitempty" yaml:"container_image_digests,omitempty"`
+}
+
+// ExternalPluginSandboxConfigV1 is an external plugin sandbox configuration.
+//
+// Only use outside of this package for testing.
+type ExternalPluginSandboxConfigV1 struct {
+	MaxMemoryBytes uint64 `json:"max_memory_bytes,omitempty" yaml:"max_memory_bytes,omitempty"`
+	MaxCPUTime     string `json:"max_cpu_time,omitempty" yaml:"max_cpu_time,omitempty"`
+	IsolateTempDir bool   `json:"isolate_temp_dir,omitempty" yaml:"isolate_temp_dir,omitempty"`
+	DenyNetwork    bool   `json:"deny_network,omitempty" yaml:"deny_network,omitempty"`
 }
 
 // ExternalManagedConfigV1 is an external managed mode configuration.
@@ -569,3 +777,49 @@ type ExternalTypesConfigV1 struct {
 func (e ExternalTypesConfigV1) IsEmpty() bool {
 	return len(e.Include) == 0
 }
+
+// ExternalLayoutConfigV1 is an external layout configuration.
+type ExternalLayoutConfigV1 struct {
+	Preset  string `json:"preset,omitempty" yaml:"preset,omitempty"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// IsEmpty returns true if e is empty.
+func (e ExternalLayoutConfigV1) IsEmpty() bool {
+	return e.Preset == "" &&
+		e.Name == "" &&
+		e.Version == ""
+}
+
+// UnmarshalYAML satisfies the yaml.Unmarshaler interface. This is done to accept
+// a plain string value for layout as shorthand for the preset.
+func (e *ExternalLayoutConfigV1) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return e.unmarshalWith(unmarshal)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. This is done to accept
+// a plain string value for layout as shorthand for the preset.
+func (e *ExternalLayoutConfigV1) UnmarshalJSON(data []byte) error {
+	unmarshal := func(v interface{}) error {
+		return json.Unmarshal(data, v)
+	}
+
+	return e.unmarshalWith(unmarshal)
+}
+
+// unmarshalWith is used to unmarshal into json/yaml. See https://abhinavg.net/posts/flexible-yaml for details.
+func (e *ExternalLayoutConfigV1) unmarshalWith(unmarshal func(interface{}) error) error {
+	var preset string
+	if err := unmarshal(&preset); err == nil {
+		e.Preset = preset
+		return nil
+	}
+
+	type rawExternalLayoutConfigV1 ExternalLayoutConfigV1
+	if err := unmarshal((*rawExternalLayoutConfigV1)(e)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -15,8 +15,13 @@
 package bufgen
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"testing"
 
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -31,3 +36,64 @@ func TestPluginConfig_GetRemoteHostname(t *testing.T) {
 	assertPluginConfigRemoteHostname(&PluginConfig{Remote: "buf.build/protocolbuffers/plugins/go:v1.28.1-1"}, "buf.build")
 	assertPluginConfigRemoteHostname(&PluginConfig{Remote: "buf.build/protocolbuffers/plugins/go"}, "buf.build")
 }
+
+func TestPluginConfig_includeImportsAndWKT(t *testing.T) {
+	t.Parallel()
+	truth := true
+	falsehood := false
+	assertIncludeImportsAndWKT := func(
+		config *PluginConfig,
+		includeImports bool,
+		includeWKT bool,
+		expectedIncludeImports bool,
+		expectedIncludeWKT bool,
+	) {
+		t.Helper()
+		actualIncludeImports, actualIncludeWKT := config.includeImportsAndWKT(includeImports, includeWKT)
+		assert.Equal(t, expectedIncludeImports, actualIncludeImports)
+		assert.Equal(t, expectedIncludeWKT, actualIncludeWKT)
+	}
+	assertIncludeImportsAndWKT(&PluginConfig{}, false, false, false, false)
+	assertIncludeImportsAndWKT(&PluginConfig{}, true, true, true, true)
+	assertIncludeImportsAndWKT(&PluginConfig{IncludeImports: &truth}, false, false, true, false)
+	assertIncludeImportsAndWKT(&PluginConfig{IncludeImports: &truth, IncludeWKT: &truth}, false, false, true, true)
+	assertIncludeImportsAndWKT(&PluginConfig{IncludeWKT: &falsehood}, true, true, true, false)
+	// Well-Known Types are never generated without imports.
+	assertIncludeImportsAndWKT(&PluginConfig{IncludeImports: &falsehood}, true, true, false, false)
+}
+
+func TestCollectedPluginsError(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, newCollectedPluginsError(make([]error, 3)))
+	remoteErr := connect.NewError(connect.CodeUnavailable, errors.New("unavailable"))
+	err := newCollectedPluginsError(
+		[]error{
+			errors.New("plugin go: exit status 1"),
+			nil,
+			fmt.Errorf("plugin buf.build/grpc/go: %w", remoteErr),
+		},
+	)
+	assert.EqualError(t, err, "2 of 3 plugins failed:\nplugin go: exit status 1\nplugin buf.build/grpc/go: unavailable: unavailable")
+	// The errors of the plugins are wrapped, so that they can still be classified.
+	assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+	assert.ErrorIs(t, err, remoteErr)
+}
+
+func TestWritePluginStderrs(t *testing.T) {
+	t.Parallel()
+	stderr := bytes.NewBuffer(nil)
+	writePluginStderrs(
+		app.NewStderrContainer(stderr),
+		[]*PluginConfig{
+			{Name: "go"},
+			{Plugin: "buf.build/grpc/go"},
+			{Name: "ts"},
+		},
+		[]*bytes.Buffer{
+			bytes.NewBufferString("warning: one\nwarning: two\n"),
+			nil,
+			bytes.NewBufferString("error: three"),
+		},
+	)
+	assert.Equal(t, "[go] warning: one\n[go] warning: two\n[ts] error: three\n", stderr.String())
+}
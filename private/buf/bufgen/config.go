@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/bufpkg/bufplugin/bufpluginref"
@@ -148,28 +149,59 @@ func newConfigV1(logger *zap.Logger, externalConfig ExternalConfigV1, id string)
 			return nil, err
 		}
 		pluginConfig := &PluginConfig{
-			Plugin:     plugin.Plugin,
-			Revision:   plugin.Revision,
-			Name:       plugin.Name,
-			Remote:     plugin.Remote,
-			Out:        plugin.Out,
-			Opt:        opt,
-			Path:       path,
-			ProtocPath: plugin.ProtocPath,
-			Strategy:   strategy,
+			Plugin:         plugin.Plugin,
+			Revision:       plugin.Revision,
+			Name:           plugin.Name,
+			Remote:         plugin.Remote,
+			Out:            plugin.Out,
+			Opt:            opt,
+			Path:           path,
+			ProtocPath:     plugin.ProtocPath,
+			Strategy:       strategy,
+			IncludeImports: plugin.IncludeImports,
+			IncludeWKT:     plugin.IncludeWKT,
+		}
+		if plugin.Timeout != "" {
+			pluginConfig.Timeout, err = time.ParseDuration(plugin.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid timeout %q for plugin %s: %w", id, plugin.Timeout, pluginConfig.PluginName(), err)
+			}
+		}
+		pluginConfig.SandboxConfig, err = newPluginSandboxConfigV1(plugin.Sandbox)
+		if err != nil {
+			return nil, fmt.Errorf("%s: plugin %s: %w", id, pluginConfig.PluginName(), err)
+		}
+		pluginConfig.AttestationConfig, err = newPluginAttestationConfigV1(plugin.Attestation)
+		if err != nil {
+			return nil, fmt.Errorf("%s: plugin %s: %w", id, pluginConfig.PluginName(), err)
 		}
 		if pluginConfig.IsRemote() {
 			// Always use StrategyAll for remote plugins
 			pluginConfig.Strategy = StrategyAll
+		} else if pluginConfig.AttestationConfig != nil {
+			return nil, fmt.Errorf("%s: local plugin %s cannot specify an attestation", id, pluginConfig.PluginName())
 		}
 		pluginConfigs = append(pluginConfigs, pluginConfig)
 	}
 	typesConfig := newTypesConfigV1(externalConfig.Types)
-	return &Config{
+	layoutConfig, err := newLayoutConfigV1(externalConfig.Layout)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", id, err)
+	}
+	applyLayoutToPluginConfigs(layoutConfig, pluginConfigs)
+	config := &Config{
 		PluginConfigs: pluginConfigs,
 		ManagedConfig: managedConfig,
 		TypesConfig:   typesConfig,
-	}, nil
+		LayoutConfig:  layoutConfig,
+	}
+	if externalConfig.ErrorPolicy != "" {
+		config.ErrorPolicy, err = ParseErrorPolicy(externalConfig.ErrorPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return config, nil
 }
 
 func validateExternalConfigV1(externalConfig ExternalConfigV1, id string) error {
@@ -195,6 +227,9 @@ func validateExternalConfigV1(externalConfig ExternalConfigV1, id string) error
 		if plugin.Out == "" {
 			return fmt.Errorf("%s: plugin %s out is required", id, pluginIdentifier)
 		}
+		if plugin.IncludeWKT != nil && *plugin.IncludeWKT && (plugin.IncludeImports == nil || !*plugin.IncludeImports) {
+			return fmt.Errorf("%s: plugin %s cannot set include_wkt without include_imports", id, pluginIdentifier)
+		}
 		switch {
 		case plugin.Plugin != "":
 			if bufpluginref.IsPluginReferenceOrIdentity(pluginIdentifier) {
@@ -242,9 +277,52 @@ func checkPathAndStrategyUnset(id string, plugin ExternalPluginConfigV1, pluginI
 	if plugin.ProtocPath != "" {
 		return fmt.Errorf("%s: remote plugin %s cannot specify a protoc path", id, pluginIdentifier)
 	}
+	if plugin.Timeout != "" {
+		return fmt.Errorf("%s: remote plugin %s cannot specify a timeout", id, pluginIdentifier)
+	}
+	if plugin.Sandbox != nil {
+		return fmt.Errorf("%s: remote plugin %s cannot specify a sandbox", id, pluginIdentifier)
+	}
 	return nil
 }
 
+func newPluginSandboxConfigV1(externalSandboxConfig *ExternalPluginSandboxConfigV1) (*PluginSandboxConfig, error) {
+	if externalSandboxConfig == nil {
+		return nil, nil
+	}
+	var maxCPUTime time.Duration
+	if externalSandboxConfig.MaxCPUTime != "" {
+		var err error
+		maxCPUTime, err = time.ParseDuration(externalSandboxConfig.MaxCPUTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sandbox max_cpu_time %q: %w", externalSandboxConfig.MaxCPUTime, err)
+		}
+	}
+	return &PluginSandboxConfig{
+		MaxMemoryBytes: externalSandboxConfig.MaxMemoryBytes,
+		MaxCPUTime:     maxCPUTime,
+		IsolateTempDir: externalSandboxConfig.IsolateTempDir,
+		DenyNetwork:    externalSandboxConfig.DenyNetwork,
+	}, nil
+}
+
+func newPluginAttestationConfigV1(externalAttestationConfig *ExternalPluginAttestationConfigV1) (*PluginAttestationConfig, error) {
+	if externalAttestationConfig == nil {
+		return nil, nil
+	}
+	if len(externalAttestationConfig.ContainerImageDigests) == 0 {
+		return nil, errors.New("attestation requires at least one container image digest")
+	}
+	for _, containerImageDigest := range externalAttestationConfig.ContainerImageDigests {
+		if err := validateContainerImageDigest(containerImageDigest); err != nil {
+			return nil, err
+		}
+	}
+	return &PluginAttestationConfig{
+		ContainerImageDigests: externalAttestationConfig.ContainerImageDigests,
+	}, nil
+}
+
 func newManagedConfigV1(logger *zap.Logger, externalManagedConfig ExternalManagedConfigV1) (*ManagedConfig, error) {
 	if !externalManagedConfig.Enabled {
 		if !externalManagedConfig.IsEmpty() && logger != nil {
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagemodify"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
@@ -650,6 +651,161 @@ func TestReadConfigV1(t *testing.T) {
 	testReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "go_gen_error6.yaml"))
 }
 
+func TestReadConfigV1PluginSandbox(t *testing.T) {
+	t.Parallel()
+	successConfig := &Config{
+		PluginConfigs: []*PluginConfig{
+			{
+				Name:     "go",
+				Out:      "gen/go",
+				Path:     []string{"/path/to/foo"},
+				Strategy: StrategyDirectory,
+				Timeout:  30 * time.Second,
+				SandboxConfig: &PluginSandboxConfig{
+					MaxMemoryBytes: 1073741824,
+					MaxCPUTime:     10 * time.Second,
+					IsolateTempDir: true,
+					DenyNetwork:    true,
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	nopLogger := zap.NewNop()
+	provider := NewProvider(zap.NewNop())
+	readBucket, err := storagemem.NewReadBucket(nil)
+	require.NoError(t, err)
+	config, err := ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success10.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error16.yaml"), "cannot specify a timeout")
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error17.yaml"), `invalid timeout "thirty"`)
+}
+
+func TestReadConfigV1Layout(t *testing.T) {
+	t.Parallel()
+	successConfig := &Config{
+		PluginConfigs: []*PluginConfig{
+			{
+				Plugin:   "buf.build/protocolbuffers/java",
+				Out:      ".",
+				Strategy: StrategyAll,
+			},
+		},
+		LayoutConfig: &LayoutConfig{
+			Layout:  LayoutJavaMaven,
+			Name:    "com.acme:weather-sdk",
+			Version: "1.2.0",
+		},
+	}
+	ctx := context.Background()
+	nopLogger := zap.NewNop()
+	provider := NewProvider(zap.NewNop())
+	readBucket, err := storagemem.NewReadBucket(nil)
+	require.NoError(t, err)
+	config, err := ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success11.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+	config, err = ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success11.json")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error18.yaml"), "layout go-module requires a name")
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error19.yaml"), "cannot specify a version")
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error20.yaml"), "must be of the form groupId:artifactId")
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error21.yaml"), "unknown layout: rubygem")
+}
+
+func TestReadConfigV1PluginIncludeImportsAndWKT(t *testing.T) {
+	t.Parallel()
+	truth := true
+	falsehood := false
+	successConfig := &Config{
+		PluginConfigs: []*PluginConfig{
+			{
+				Plugin:         "go",
+				Out:            "gen/go",
+				Strategy:       StrategyDirectory,
+				IncludeImports: &truth,
+				IncludeWKT:     &truth,
+			},
+			{
+				Plugin:         "buf.build/protocolbuffers/python",
+				Out:            "gen/python",
+				Strategy:       StrategyAll,
+				IncludeImports: &falsehood,
+			},
+		},
+	}
+	ctx := context.Background()
+	nopLogger := zap.NewNop()
+	provider := NewProvider(zap.NewNop())
+	readBucket, err := storagemem.NewReadBucket(nil)
+	require.NoError(t, err)
+	config, err := ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success12.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error22.yaml"), "cannot set include_wkt without include_imports")
+}
+
+func TestReadConfigV1PluginAttestation(t *testing.T) {
+	t.Parallel()
+	successConfig := &Config{
+		PluginConfigs: []*PluginConfig{
+			{
+				Plugin:   "buf.build/protocolbuffers/go",
+				Out:      "gen/go",
+				Strategy: StrategyAll,
+				AttestationConfig: &PluginAttestationConfig{
+					ContainerImageDigests: []string{"sha256:000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"},
+				},
+			},
+		},
+	}
+	ctx := context.Background()
+	nopLogger := zap.NewNop()
+	provider := NewProvider(zap.NewNop())
+	readBucket, err := storagemem.NewReadBucket(nil)
+	require.NoError(t, err)
+	config, err := ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success13.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error23.yaml"), "local plugin go cannot specify an attestation")
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error24.yaml"), `invalid container image digest "sha256:abc"`)
+}
+
+func TestReadConfigV1ErrorPolicy(t *testing.T) {
+	t.Parallel()
+	successConfig := &Config{
+		PluginConfigs: []*PluginConfig{
+			{
+				Plugin:   "buf.build/protocolbuffers/go",
+				Out:      "gen/go",
+				Strategy: StrategyAll,
+			},
+			{
+				Name:     "go-grpc",
+				Out:      "gen/go",
+				Strategy: StrategyDirectory,
+			},
+		},
+		ErrorPolicy: ErrorPolicyCollectAll,
+	}
+	ctx := context.Background()
+	nopLogger := zap.NewNop()
+	provider := NewProvider(zap.NewNop())
+	readBucket, err := storagemem.NewReadBucket(nil)
+	require.NoError(t, err)
+	config, err := ReadConfig(ctx, nopLogger, provider, readBucket, ReadConfigWithOverride(filepath.Join("testdata", "v1", "gen_success14.yaml")))
+	require.NoError(t, err)
+	require.Equal(t, successConfig, config)
+
+	assertContainsReadConfigError(t, nopLogger, provider, readBucket, filepath.Join("testdata", "v1", "gen_error25.yaml"), "unknown error policy: keep-going")
+}
+
 func testReadConfigError(t *testing.T, logger *zap.Logger, provider Provider, readBucket storage.ReadBucket, testFilePath string) {
 	ctx := context.Background()
 	_, err := ReadConfig(ctx, logger, provider, readBucket, ReadConfigWithOverride(testFilePath))
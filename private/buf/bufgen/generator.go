@@ -15,10 +15,14 @@
 package bufgen
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
+	"strings"
+	"time"
 
 	connect "connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
@@ -34,6 +38,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app/appproto/appprotoos"
 	"github.com/bufbuild/buf/private/pkg/command"
 	"github.com/bufbuild/buf/private/pkg/connectclient"
+	"github.com/bufbuild/buf/private/pkg/interrupt"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/thread"
 	"go.uber.org/multierr"
@@ -90,6 +95,10 @@ func (g *generator) Generate(
 	for _, option := range options {
 		option(generateOptions)
 	}
+	errorPolicy := config.ErrorPolicy
+	if generateOptions.errorPolicy != 0 {
+		errorPolicy = generateOptions.errorPolicy
+	}
 	return g.generate(
 		ctx,
 		container,
@@ -98,6 +107,8 @@ func (g *generator) Generate(
 		generateOptions.baseOutDirPath,
 		generateOptions.includeImports,
 		generateOptions.includeWellKnownTypes,
+		generateOptions.pluginGracePeriod,
+		errorPolicy,
 	)
 }
 
@@ -109,6 +120,8 @@ func (g *generator) generate(
 	baseOutDirPath string,
 	includeImports bool,
 	includeWellKnownTypes bool,
+	pluginGracePeriod time.Duration,
+	errorPolicy ErrorPolicy,
 ) error {
 	if err := modifyImage(ctx, g.logger, config, image); err != nil {
 		return err
@@ -120,6 +133,8 @@ func (g *generator) generate(
 		image,
 		includeImports,
 		includeWellKnownTypes,
+		pluginGracePeriod,
+		errorPolicy,
 	)
 	if err != nil {
 		return err
@@ -131,7 +146,7 @@ func (g *generator) generate(
 		appprotoos.ResponseWriterWithCreateOutDirIfNotExists(),
 	)
 	for i, pluginConfig := range config.PluginConfigs {
-		out := pluginConfig.Out
+		out := getLayoutOut(config.LayoutConfig, pluginConfig.Out)
 		if baseOutDirPath != "" && baseOutDirPath != "." {
 			out = filepath.Join(baseOutDirPath, out)
 		}
@@ -150,6 +165,9 @@ func (g *generator) generate(
 	if err := responseWriter.Close(); err != nil {
 		return err
 	}
+	if config.LayoutConfig != nil {
+		return writeLayoutFiles(ctx, g.storageosProvider, config.LayoutConfig, baseOutDirPath)
+	}
 	return nil
 }
 
@@ -160,11 +178,19 @@ func (g *generator) execPlugins(
 	image bufimage.Image,
 	includeImports bool,
 	includeWellKnownTypes bool,
+	pluginGracePeriod time.Duration,
+	errorPolicy ErrorPolicy,
 ) ([]*pluginpb.CodeGeneratorResponse, error) {
 	imageProvider := newImageProvider(image)
 	// Collect all the plugin jobs so that they can be executed in parallel.
 	jobs := make([]func(context.Context) error, 0, len(config.PluginConfigs))
 	responses := make([]*pluginpb.CodeGeneratorResponse, len(config.PluginConfigs))
+	// With ErrorPolicyCollectAll, the error and the stderr of each plugin are
+	// recorded by index so that they can be reported in order once all plugins
+	// have run.
+	collectAll := errorPolicy == ErrorPolicyCollectAll
+	pluginErrs := make([]error, len(config.PluginConfigs))
+	pluginStderrs := make([]*bytes.Buffer, len(config.PluginConfigs))
 	requiredFeatures := computeRequiredFeatures(image)
 	remotePluginConfigTable := make(map[string][]*remotePluginExecArgs, len(config.PluginConfigs))
 	for i, pluginConfig := range config.PluginConfigs {
@@ -180,16 +206,23 @@ func (g *generator) execPlugins(
 				},
 			)
 		} else {
+			pluginContainer := container
+			if collectAll {
+				pluginStderrs[index] = bytes.NewBuffer(nil)
+				pluginContainer = newStderrEnvStdioContainer(container, pluginStderrs[index])
+			}
 			jobs = append(jobs, func(ctx context.Context) error {
 				response, err := g.execLocalPlugin(
 					ctx,
-					container,
+					pluginContainer,
 					imageProvider,
 					currentPluginConfig,
 					includeImports,
 					includeWellKnownTypes,
+					pluginGracePeriod,
 				)
 				if err != nil {
+					pluginErrs[index] = err
 					return err
 				}
 				responses[index] = response
@@ -220,6 +253,9 @@ func (g *generator) execPlugins(
 					includeWellKnownTypes,
 				)
 				if err != nil {
+					for _, v2Arg := range v2Args {
+						pluginErrs[v2Arg.Index] = fmt.Errorf("plugin %s: %w", v2Arg.PluginConfig.PluginName(), err)
+					}
 					return err
 				}
 				for _, result := range results {
@@ -241,18 +277,34 @@ func (g *generator) execPlugins(
 	//      out: gen/proto
 	//    - name: insertion-point-writer
 	//      out: gen/proto
+	//
+	// With ErrorPolicyFailFast, the first plugin that fails cancels the others.
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	var parallelizeOptions []thread.ParallelizeOption
+	if !collectAll {
+		parallelizeOptions = append(parallelizeOptions, thread.ParallelizeWithCancel(cancel))
+	}
 	if err := thread.Parallelize(
 		ctx,
 		jobs,
-		thread.ParallelizeWithCancel(cancel),
+		parallelizeOptions...,
 	); err != nil {
+		if collectAll {
+			writePluginStderrs(container, config.PluginConfigs, pluginStderrs)
+			if collectedErr := newCollectedPluginsError(pluginErrs); collectedErr != nil {
+				return nil, collectedErr
+			}
+			return nil, err
+		}
 		if errs := multierr.Errors(err); len(errs) > 0 {
 			return nil, errs[0]
 		}
 		return nil, err
 	}
+	if collectAll {
+		writePluginStderrs(container, config.PluginConfigs, pluginStderrs)
+	}
 	if err := validateResponses(responses, config.PluginConfigs); err != nil {
 		return nil, err
 	}
@@ -267,11 +319,14 @@ func (g *generator) execLocalPlugin(
 	pluginConfig *PluginConfig,
 	includeImports bool,
 	includeWellKnownTypes bool,
+	pluginGracePeriod time.Duration,
 ) (*pluginpb.CodeGeneratorResponse, error) {
+	defer interrupt.StartPhase(ctx, "running plugin "+pluginConfig.PluginName())()
 	pluginImages, err := imageProvider.GetImages(pluginConfig.Strategy)
 	if err != nil {
 		return nil, err
 	}
+	includeImports, includeWellKnownTypes = pluginConfig.includeImportsAndWKT(includeImports, includeWellKnownTypes)
 	requests, err := bufimage.ImagesToCodeGeneratorRequests(
 		pluginImages,
 		pluginConfig.Opt,
@@ -289,6 +344,7 @@ func (g *generator) execLocalPlugin(
 		requests,
 		bufpluginexec.GenerateWithPluginPath(pluginConfig.Path...),
 		bufpluginexec.GenerateWithProtocPath(pluginConfig.ProtocPath),
+		bufpluginexec.GenerateWithRunOptions(getPluginRunOptions(pluginConfig, pluginGracePeriod)...),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("plugin %s: %v", pluginConfig.PluginName(), err)
@@ -296,6 +352,33 @@ func (g *generator) execLocalPlugin(
 	return response, nil
 }
 
+// getPluginRunOptions returns the command.RunOptions for the timeout and
+// sandbox configuration of a local plugin.
+func getPluginRunOptions(pluginConfig *PluginConfig, pluginGracePeriod time.Duration) []command.RunOption {
+	var runOptions []command.RunOption
+	if pluginGracePeriod > 0 {
+		runOptions = append(runOptions, command.RunWithGracePeriod(pluginGracePeriod))
+	}
+	if pluginConfig.Timeout > 0 {
+		runOptions = append(runOptions, command.RunWithTimeout(pluginConfig.Timeout))
+	}
+	if sandboxConfig := pluginConfig.SandboxConfig; sandboxConfig != nil {
+		if sandboxConfig.MaxMemoryBytes > 0 {
+			runOptions = append(runOptions, command.RunWithMaxMemoryBytes(sandboxConfig.MaxMemoryBytes))
+		}
+		if sandboxConfig.MaxCPUTime > 0 {
+			runOptions = append(runOptions, command.RunWithMaxCPUTime(sandboxConfig.MaxCPUTime))
+		}
+		if sandboxConfig.IsolateTempDir {
+			runOptions = append(runOptions, command.RunWithIsolatedTempDir())
+		}
+		if sandboxConfig.DenyNetwork {
+			runOptions = append(runOptions, command.RunWithNoNetwork())
+		}
+	}
+	return runOptions
+}
+
 type remotePluginExecArgs struct {
 	Index        int
 	PluginConfig *PluginConfig
@@ -315,14 +398,24 @@ func (g *generator) execRemotePluginsV2(
 	includeImports bool,
 	includeWellKnownTypes bool,
 ) ([]*remotePluginExecutionResult, error) {
+	defer interrupt.StartPhase(ctx, "running remote plugins on "+remote)()
 	requests := make([]*registryv1alpha1.PluginGenerationRequest, len(pluginConfigs))
 	for i, pluginConfig := range pluginConfigs {
-		request, err := getPluginGenerationRequest(pluginConfig.PluginConfig)
+		request, err := getPluginGenerationRequest(pluginConfig.PluginConfig, includeImports, includeWellKnownTypes)
 		if err != nil {
 			return nil, err
 		}
 		requests[i] = request
 	}
+	if err := verifyPluginAttestations(
+		ctx,
+		connectclient.Make(g.clientConfig, remote, registryv1alpha1connect.NewPluginCurationServiceClient),
+		remote,
+		pluginConfigs,
+		requests,
+	); err != nil {
+		return nil, err
+	}
 	codeGenerationService := connectclient.Make(g.clientConfig, remote, registryv1alpha1connect.NewCodeGenerationServiceClient)
 	response, err := codeGenerationService.GenerateCode(
 		ctx,
@@ -358,6 +451,8 @@ func (g *generator) execRemotePluginsV2(
 
 func getPluginGenerationRequest(
 	pluginConfig *PluginConfig,
+	includeImports bool,
+	includeWellKnownTypes bool,
 ) (*registryv1alpha1.PluginGenerationRequest, error) {
 	var curatedPluginReference *registryv1alpha1.CuratedPluginReference
 	if reference, err := bufpluginref.PluginReferenceForString(pluginConfig.Plugin, pluginConfig.Revision); err == nil {
@@ -375,10 +470,17 @@ func getPluginGenerationRequest(
 		// Only include parameters if they're not empty.
 		options = []string{pluginConfig.Opt}
 	}
-	return &registryv1alpha1.PluginGenerationRequest{
+	request := &registryv1alpha1.PluginGenerationRequest{
 		PluginReference: curatedPluginReference,
 		Options:         options,
-	}, nil
+	}
+	if pluginConfig.IncludeImports != nil || pluginConfig.IncludeWKT != nil {
+		// Only override the values of the GenerateCodeRequest if the plugin configures them.
+		includeImports, includeWellKnownTypes = pluginConfig.includeImportsAndWKT(includeImports, includeWellKnownTypes)
+		request.IncludeImports = &includeImports
+		request.IncludeWellKnownTypes = &includeWellKnownTypes
+	}
+	return request, nil
 }
 
 // modifyImage modifies the image according to the given configuration (i.e. managed mode).
@@ -600,10 +702,65 @@ func validateResponses(
 	return nil
 }
 
+// writePluginStderrs writes the captured stderr of each plugin to the stderr of
+// the container in the order the plugins were specified, with each line labeled
+// with the name of the plugin.
+func writePluginStderrs(
+	container app.StderrContainer,
+	pluginConfigs []*PluginConfig,
+	pluginStderrs []*bytes.Buffer,
+) {
+	for i, pluginStderr := range pluginStderrs {
+		if pluginStderr == nil || pluginStderr.Len() == 0 {
+			continue
+		}
+		pluginName := pluginConfigs[i].PluginName()
+		for _, line := range strings.Split(strings.TrimRight(pluginStderr.String(), "\n"), "\n") {
+			_, _ = fmt.Fprintf(container.Stderr(), "[%s] %s\n", pluginName, line)
+		}
+	}
+}
+
+// newCollectedPluginsError returns an error that lists the errors of all of the
+// plugins that failed in the order the plugins were specified, or nil if no
+// plugin failed.
+func newCollectedPluginsError(pluginErrs []error) error {
+	var errs []error
+	for _, pluginErr := range pluginErrs {
+		if pluginErr != nil {
+			errs = append(errs, pluginErr)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d plugins failed:\n%w", len(errs), len(pluginErrs), errors.Join(errs...))
+}
+
+// stderrEnvStdioContainer is an app.EnvStdioContainer with a replacement stderr.
+type stderrEnvStdioContainer struct {
+	app.EnvStdioContainer
+
+	stderr io.Writer
+}
+
+func newStderrEnvStdioContainer(container app.EnvStdioContainer, stderr io.Writer) *stderrEnvStdioContainer {
+	return &stderrEnvStdioContainer{
+		EnvStdioContainer: container,
+		stderr:            stderr,
+	}
+}
+
+func (c *stderrEnvStdioContainer) Stderr() io.Writer {
+	return c.stderr
+}
+
 type generateOptions struct {
 	baseOutDirPath        string
 	includeImports        bool
 	includeWellKnownTypes bool
+	pluginGracePeriod     time.Duration
+	errorPolicy           ErrorPolicy
 }
 
 func newGenerateOptions() *generateOptions {
@@ -0,0 +1,307 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufplugin/bufpluginref"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+const (
+	// defaultLayoutGoVersion is the go directive of go.mod files created for LayoutGoModule.
+	defaultLayoutGoVersion = "1.20"
+	// defaultLayoutJavaMavenVersion is the version of pom.xml files created for
+	// LayoutJavaMaven if no version is set.
+	defaultLayoutJavaMavenVersion = "0.1.0-SNAPSHOT"
+	// defaultLayoutNpmPackageVersion is the version of package.json files created for
+	// LayoutNpmPackage if no version is set.
+	defaultLayoutNpmPackageVersion = "0.1.0"
+)
+
+var (
+	// See https://maven.apache.org/guides/mini/guide-naming-conventions.html.
+	mavenIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-.]+$`)
+	// See https://docs.npmjs.com/cli/configuring-npm/package-json#name.
+	npmPackageNameRegexp = regexp.MustCompile(`^(@[a-z0-9\-~][a-z0-9\-._~]*/)?[a-z0-9\-~][a-z0-9\-._~]*$`)
+
+	pomTemplate = template.Must(template.New("pom.xml").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd">
+  <modelVersion>4.0.0</modelVersion>
+
+  <groupId>{{.GroupID}}</groupId>
+  <artifactId>{{.ArtifactID}}</artifactId>
+  <version>{{.Version}}</version>
+  <packaging>jar</packaging>
+
+  <properties>
+    <project.build.sourceEncoding>UTF-8</project.build.sourceEncoding>
+  </properties>
+
+  <!-- Add the runtime libraries of the plugins used, such as com.google.protobuf:protobuf-java. -->
+  <dependencies>
+  </dependencies>
+</project>
+`))
+)
+
+// sourceDirPath returns the directory relative to the root of the layout that
+// plugin outputs are written to.
+func (l Layout) sourceDirPath() string {
+	switch l {
+	case LayoutJavaMaven:
+		return filepath.Join("src", "main", "java")
+	case LayoutNpmPackage:
+		return "src"
+	default:
+		return "."
+	}
+}
+
+func newLayoutConfigV1(externalLayoutConfig ExternalLayoutConfigV1) (*LayoutConfig, error) {
+	if externalLayoutConfig.IsEmpty() {
+		return nil, nil
+	}
+	if externalLayoutConfig.Preset == "" {
+		return nil, errors.New("layout preset is required")
+	}
+	layout, err := ParseLayout(externalLayoutConfig.Preset)
+	if err != nil {
+		return nil, err
+	}
+	name := externalLayoutConfig.Name
+	if name == "" {
+		return nil, fmt.Errorf("layout %s requires a name", layout)
+	}
+	switch layout {
+	case LayoutGoModule:
+		if err := module.CheckPath(name); err != nil {
+			return nil, fmt.Errorf("layout %s: invalid name: %w", layout, err)
+		}
+		if externalLayoutConfig.Version != "" {
+			return nil, fmt.Errorf("layout %s cannot specify a version, Go module versions are set by tags", layout)
+		}
+	case LayoutJavaMaven:
+		groupID, artifactID, ok := strings.Cut(name, ":")
+		if !ok || !mavenIDRegexp.MatchString(groupID) || !mavenIDRegexp.MatchString(artifactID) {
+			return nil, fmt.Errorf("layout %s: invalid name %q, must be of the form groupId:artifactId", layout, name)
+		}
+		if externalLayoutConfig.Version != "" && !mavenIDRegexp.MatchString(externalLayoutConfig.Version) {
+			return nil, fmt.Errorf("layout %s: invalid version %q", layout, externalLayoutConfig.Version)
+		}
+	case LayoutNpmPackage:
+		if !npmPackageNameRegexp.MatchString(name) || len(name) > 214 {
+			return nil, fmt.Errorf("layout %s: invalid name %q", layout, name)
+		}
+	}
+	return &LayoutConfig{
+		Layout:  layout,
+		Name:    name,
+		Version: externalLayoutConfig.Version,
+	}, nil
+}
+
+// applyLayoutToPluginConfigs sets the options of the plugins that are needed for
+// their outputs to match the layout.
+//
+// For LayoutGoModule, the module option is set on Go plugins so that outputs are
+// written relative to the root of the Go module instead of by full import path. The
+// option is not set if the plugin already sets the module or paths option.
+func applyLayoutToPluginConfigs(layoutConfig *LayoutConfig, pluginConfigs []*PluginConfig) {
+	if layoutConfig == nil || layoutConfig.Layout != LayoutGoModule {
+		return
+	}
+	for _, pluginConfig := range pluginConfigs {
+		if !isGoPlugin(pluginConfig) || hasGoOutputOption(pluginConfig.Opt) {
+			continue
+		}
+		moduleOpt := "module=" + layoutConfig.Name
+		if pluginConfig.Opt == "" {
+			pluginConfig.Opt = moduleOpt
+		} else {
+			pluginConfig.Opt = pluginConfig.Opt + "," + moduleOpt
+		}
+	}
+}
+
+// isGoPlugin returns true if the plugin is a Go plugin that accepts the module option.
+//
+// Go plugins are recognized by name, such as go, go-grpc, and connect-go locally, or
+// buf.build/protocolbuffers/go and buf.build/grpc/go remotely.
+func isGoPlugin(pluginConfig *PluginConfig) bool {
+	pluginName := pluginConfig.PluginName()
+	if pluginIdentity, _, err := bufpluginref.ParsePluginIdentityOptionalVersion(pluginName); err == nil {
+		pluginName = pluginIdentity.Plugin()
+	}
+	pluginName = strings.TrimPrefix(pluginName, "protoc-gen-")
+	return pluginName == "go" ||
+		strings.HasPrefix(pluginName, "go-") ||
+		strings.HasSuffix(pluginName, "-go")
+}
+
+// hasGoOutputOption returns true if the comma-separated options set the module or
+// paths option, which control where Go plugins write their outputs.
+func hasGoOutputOption(opt string) bool {
+	for _, option := range strings.Split(opt, ",") {
+		key, _, _ := strings.Cut(option, "=")
+		if key == "module" || key == "paths" {
+			return true
+		}
+	}
+	return false
+}
+
+// getLayoutOut returns the out directory of a plugin within the layout.
+func getLayoutOut(layoutConfig *LayoutConfig, out string) string {
+	if layoutConfig == nil {
+		return out
+	}
+	return filepath.Join(layoutConfig.Layout.sourceDirPath(), out)
+}
+
+// writeLayoutFiles writes the project files of the layout to the base output
+// directory, skipping those that already exist so that they can be edited.
+func writeLayoutFiles(
+	ctx context.Context,
+	storageosProvider storageos.Provider,
+	layoutConfig *LayoutConfig,
+	baseOutDirPath string,
+) error {
+	if baseOutDirPath == "" {
+		baseOutDirPath = "."
+	}
+	layoutFiles, err := getLayoutFiles(layoutConfig)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(baseOutDirPath, 0755); err != nil {
+		return err
+	}
+	readWriteBucket, err := storageosProvider.NewReadWriteBucket(baseOutDirPath)
+	if err != nil {
+		return err
+	}
+	for path, data := range layoutFiles {
+		exists, err := storage.Exists(ctx, readWriteBucket, path)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if err := storage.PutPath(ctx, readWriteBucket, path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLayoutFiles returns the project files of the layout by path.
+func getLayoutFiles(layoutConfig *LayoutConfig) (map[string][]byte, error) {
+	switch layoutConfig.Layout {
+	case LayoutGoModule:
+		data, err := getGoModData(layoutConfig.Name)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{"go.mod": data}, nil
+	case LayoutJavaMaven:
+		data, err := getPomData(layoutConfig.Name, layoutConfig.Version)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{"pom.xml": data}, nil
+	case LayoutNpmPackage:
+		data, err := getPackageJSONData(layoutConfig.Name, layoutConfig.Version)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{"package.json": data}, nil
+	default:
+		return nil, fmt.Errorf("unknown layout: %v", layoutConfig.Layout)
+	}
+}
+
+func getGoModData(modulePath string) ([]byte, error) {
+	goModFile := &modfile.File{}
+	if err := goModFile.AddModuleStmt(modulePath); err != nil {
+		return nil, err
+	}
+	if err := goModFile.AddGoStmt(defaultLayoutGoVersion); err != nil {
+		return nil, err
+	}
+	return goModFile.Format()
+}
+
+func getPomData(name string, version string) ([]byte, error) {
+	groupID, artifactID, _ := strings.Cut(name, ":")
+	if version == "" {
+		version = defaultLayoutJavaMavenVersion
+	}
+	buffer := bytes.NewBuffer(nil)
+	if err := pomTemplate.Execute(
+		buffer,
+		struct {
+			GroupID    string
+			ArtifactID string
+			Version    string
+		}{
+			GroupID:    groupID,
+			ArtifactID: artifactID,
+			Version:    version,
+		},
+	); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func getPackageJSONData(name string, version string) ([]byte, error) {
+	if version == "" {
+		version = defaultLayoutNpmPackageVersion
+	}
+	data, err := json.MarshalIndent(
+		struct {
+			Name    string   `json:"name"`
+			Version string   `json:"version"`
+			Files   []string `json:"files"`
+		}{
+			Name:    name,
+			Version: version,
+			Files:   []string{LayoutNpmPackage.sourceDirPath()},
+		},
+		"",
+		"  ",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
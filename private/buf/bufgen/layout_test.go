@@ -0,0 +1,129 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufgen
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLayoutOut(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "gen", getLayoutOut(nil, "gen"))
+	assert.Equal(t, "gen", getLayoutOut(&LayoutConfig{Layout: LayoutGoModule}, "gen"))
+	assert.Equal(t, filepath.Join("src", "main", "java"), getLayoutOut(&LayoutConfig{Layout: LayoutJavaMaven}, "."))
+	assert.Equal(t, filepath.Join("src", "gen"), getLayoutOut(&LayoutConfig{Layout: LayoutNpmPackage}, "gen"))
+}
+
+func TestApplyLayoutToPluginConfigs(t *testing.T) {
+	t.Parallel()
+	pluginConfigs := []*PluginConfig{
+		{Plugin: "buf.build/protocolbuffers/go:v1.31.0"},
+		{Plugin: "buf.build/connectrpc/go", Opt: "simple"},
+		{Name: "go-grpc", Opt: "require_unimplemented_servers=false"},
+		{Name: "go", Opt: "paths=source_relative"},
+		{Plugin: "connect-go", Opt: "module=github.com/acme/other"},
+		{Plugin: "buf.build/protocolbuffers/java"},
+		{Name: "gotemplate"},
+	}
+	applyLayoutToPluginConfigs(&LayoutConfig{Layout: LayoutGoModule, Name: "github.com/acme/weather-sdk"}, pluginConfigs)
+	opts := make([]string, 0, len(pluginConfigs))
+	for _, pluginConfig := range pluginConfigs {
+		opts = append(opts, pluginConfig.Opt)
+	}
+	assert.Equal(
+		t,
+		[]string{
+			"module=github.com/acme/weather-sdk",
+			"simple,module=github.com/acme/weather-sdk",
+			"require_unimplemented_servers=false,module=github.com/acme/weather-sdk",
+			"paths=source_relative",
+			"module=github.com/acme/other",
+			"",
+			"",
+		},
+		opts,
+	)
+
+	pluginConfigs = []*PluginConfig{{Plugin: "buf.build/protocolbuffers/go"}}
+	applyLayoutToPluginConfigs(&LayoutConfig{Layout: LayoutNpmPackage, Name: "@acme/weather-sdk"}, pluginConfigs)
+	assert.Empty(t, pluginConfigs[0].Opt)
+}
+
+func TestWriteLayoutFiles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	storageosProvider := storageos.NewProvider()
+	baseOutDirPath := filepath.Join(t.TempDir(), "out")
+	require.NoError(
+		t,
+		writeLayoutFiles(
+			ctx,
+			storageosProvider,
+			&LayoutConfig{Layout: LayoutGoModule, Name: "github.com/acme/weather-sdk"},
+			baseOutDirPath,
+		),
+	)
+	data, err := os.ReadFile(filepath.Join(baseOutDirPath, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module github.com/acme/weather-sdk\n\ngo 1.20\n", string(data))
+
+	// Existing files are not overwritten.
+	require.NoError(t, os.WriteFile(filepath.Join(baseOutDirPath, "go.mod"), []byte("module edited\n"), 0600))
+	require.NoError(
+		t,
+		writeLayoutFiles(
+			ctx,
+			storageosProvider,
+			&LayoutConfig{Layout: LayoutGoModule, Name: "github.com/acme/weather-sdk"},
+			baseOutDirPath,
+		),
+	)
+	data, err = os.ReadFile(filepath.Join(baseOutDirPath, "go.mod"))
+	require.NoError(t, err)
+	assert.Equal(t, "module edited\n", string(data))
+}
+
+func TestGetLayoutFiles(t *testing.T) {
+	t.Parallel()
+	layoutFiles, err := getLayoutFiles(&LayoutConfig{Layout: LayoutNpmPackage, Name: "@acme/weather-sdk"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string][]byte{
+			"package.json": []byte(`{
+  "name": "@acme/weather-sdk",
+  "version": "0.1.0",
+  "files": [
+    "src"
+  ]
+}
+`),
+		},
+		layoutFiles,
+	)
+	layoutFiles, err = getLayoutFiles(&LayoutConfig{Layout: LayoutJavaMaven, Name: "com.acme:weather-sdk", Version: "1.2.0"})
+	require.NoError(t, err)
+	pomData := string(layoutFiles["pom.xml"])
+	assert.Contains(t, pomData, "<groupId>com.acme</groupId>")
+	assert.Contains(t, pomData, "<artifactId>weather-sdk</artifactId>")
+	assert.Contains(t, pomData, "<version>1.2.0</version>")
+}
@@ -16,6 +16,15 @@
 // configuration file versions.
 package bufmigrate
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+)
+
 // Migrator describes the interface used to migrate
 // a set of files in a directory from one version to another.
 type Migrator interface {
@@ -40,3 +49,121 @@ func V1Beta1MigratorWithNotifier(notifier func(message string) error) V1Beta1Mig
 		migrateOptions.notifier = notifier
 	}
 }
+
+// RuleMigrateOption defines the type used to configure the rule migrator.
+type RuleMigrateOption func(*ruleMigrator)
+
+// NewRuleMigrator creates a new migrator that replaces lint rules and categories
+// that are no longer valid in a v1 configuration file with the rules that replace them.
+//
+// The configuration file is edited in place, and comments are preserved.
+func NewRuleMigrator(options ...RuleMigrateOption) Migrator {
+	return newRuleMigrator(options...)
+}
+
+// RuleMigratorWithNotifier instruments the migrator with
+// a callback to call whenever an event that should notify the
+// user occurs during the migration.
+func RuleMigratorWithNotifier(notifier func(message string) error) RuleMigrateOption {
+	return func(ruleMigrator *ruleMigrator) {
+		ruleMigrator.notifier = notifier
+	}
+}
+
+// ImportMapping maps import paths and packages to their new values.
+type ImportMapping struct {
+	// Paths maps normalized import paths to their new import paths.
+	//
+	// A path may be a file or a directory, in which case all files within the
+	// directory are mapped.
+	Paths map[string]string
+	// Packages maps packages to their new packages.
+	//
+	// Sub-packages of a package are also mapped.
+	Packages map[string]string
+}
+
+// ReadImportMapping reads an ImportMapping from JSON or YAML data.
+//
+//	paths:
+//	  acme/weather/v1: acme/forecast/v1
+//	packages:
+//	  acme.weather.v1: acme.forecast.v1
+func ReadImportMapping(data []byte) (*ImportMapping, error) {
+	var externalImportMapping externalImportMapping
+	if err := encoding.UnmarshalJSONOrYAMLStrict(data, &externalImportMapping); err != nil {
+		return nil, fmt.Errorf("could not read import mapping: %w", err)
+	}
+	importMapping := &ImportMapping{
+		Paths:    make(map[string]string, len(externalImportMapping.Paths)),
+		Packages: make(map[string]string, len(externalImportMapping.Packages)),
+	}
+	for oldPath, newPath := range externalImportMapping.Paths {
+		normalizedOldPath, err := normalpath.NormalizeAndValidate(oldPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q in import mapping: %w", oldPath, err)
+		}
+		normalizedNewPath, err := normalpath.NormalizeAndValidate(newPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q in import mapping for %q: %w", newPath, oldPath, err)
+		}
+		importMapping.Paths[normalizedOldPath] = normalizedNewPath
+	}
+	for oldPackage, newPackage := range externalImportMapping.Packages {
+		if !isValidPackage(oldPackage) {
+			return nil, fmt.Errorf("invalid package %q in import mapping", oldPackage)
+		}
+		if !isValidPackage(newPackage) {
+			return nil, fmt.Errorf("invalid package %q in import mapping for %q", newPackage, oldPackage)
+		}
+		importMapping.Packages[oldPackage] = newPackage
+	}
+	if len(importMapping.Paths) == 0 && len(importMapping.Packages) == 0 {
+		return nil, errors.New("import mapping must contain at least one path or package")
+	}
+	return importMapping, nil
+}
+
+// ImportMigrateOption defines the type used to configure the import migrator.
+type ImportMigrateOption func(*importMigrator)
+
+// NewImportMigrator creates a new migrator that rewrites import paths, package
+// declarations, and fully-qualified references in all .proto files in the module
+// or workspace in a directory, according to the ImportMapping.
+//
+// The build.excludes of each buf.yaml are also updated. Files are not moved, this
+// is used to update references to files that were moved or will be moved.
+func NewImportMigrator(importMapping *ImportMapping, options ...ImportMigrateOption) Migrator {
+	return newImportMigrator(importMapping, options...)
+}
+
+// ImportMigratorWithNotifier instruments the migrator with
+// a callback to call whenever an event that should notify the
+// user occurs during the migration.
+func ImportMigratorWithNotifier(notifier func(message string) error) ImportMigrateOption {
+	return func(importMigrator *importMigrator) {
+		importMigrator.notifier = notifier
+	}
+}
+
+type externalImportMapping struct {
+	Paths    map[string]string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	Packages map[string]string `json:"packages,omitempty" yaml:"packages,omitempty"`
+}
+
+func isValidPackage(pkg string) bool {
+	if pkg == "" {
+		return false
+	}
+	for _, component := range strings.Split(pkg, ".") {
+		if component == "" || !isIdentifierStart(component[0]) {
+			return false
+		}
+		for i := 1; i < len(component); i++ {
+			if !isIdentifierPart(component[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,421 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmigrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufwork"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"gopkg.in/yaml.v3"
+)
+
+type importMigrator struct {
+	importMapping *ImportMapping
+	notifier      func(string) error
+}
+
+func newImportMigrator(importMapping *ImportMapping, options ...ImportMigrateOption) *importMigrator {
+	migrator := importMigrator{
+		importMapping: importMapping,
+		notifier:      func(string) error { return nil },
+	}
+	for _, option := range options {
+		option(&migrator)
+	}
+	return &migrator
+}
+
+func (m *importMigrator) Migrate(dirPath string) error {
+	moduleDirPaths, err := getModuleDirPaths(dirPath)
+	if err != nil {
+		return err
+	}
+	var numFiles int
+	for _, moduleDirPath := range moduleDirPaths {
+		if err := m.migrateConfig(moduleDirPath); err != nil {
+			return err
+		}
+		if err := filepath.WalkDir(moduleDirPath, func(path string, dirEntry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if dirEntry.IsDir() || filepath.Ext(path) != ".proto" {
+				return nil
+			}
+			migrated, err := m.migrateFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", path, err)
+			}
+			if migrated {
+				numFiles++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if numFiles > 0 {
+		if err := m.notifier(fmt.Sprintf("Updated imports and packages in %d files.\n", numFiles)); err != nil {
+			return fmt.Errorf("failed to write success message: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *importMigrator) migrateFile(path string) (bool, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	migratedData, err := migrateImports(data, m.importMapping)
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(data, migratedData) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, migratedData, fileInfo.Mode().Perm())
+}
+
+// migrateConfig updates the build.excludes of the buf.yaml in the module directory,
+// if one exists.
+func (m *importMigrator) migrateConfig(moduleDirPath string) error {
+	configPath, err := getExistingConfigPath(moduleDirPath)
+	if err != nil {
+		// Modules in a workspace are not required to have a configuration file.
+		return nil
+	}
+	configFileInfo, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	var versionedConfig bufconfig.ExternalConfigVersion
+	if err := encoding.UnmarshalYAMLNonStrict(configBytes, &versionedConfig); err != nil {
+		return fmt.Errorf("failed to read %s version: %w", configPath, err)
+	}
+	if versionedConfig.Version != bufconfig.V1Version {
+		return fmt.Errorf(`%s must be version %s to migrate imports, run "buf beta migrate-v1beta1" first`, configPath, bufconfig.V1Version)
+	}
+	migratedConfigBytes, replaced, err := migrateExcludes(configBytes, m.importMapping)
+	if err != nil {
+		return fmt.Errorf("failed to migrate excludes in %s: %w", configPath, err)
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(configPath, migratedConfigBytes, configFileInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	oldExcludes := make([]string, 0, len(replaced))
+	for oldExclude := range replaced {
+		oldExcludes = append(oldExcludes, oldExclude)
+	}
+	sort.Strings(oldExcludes)
+	for _, oldExclude := range oldExcludes {
+		if err := m.notifier(fmt.Sprintf("Replaced exclude %s with %s in %s.\n", oldExclude, replaced[oldExclude], configPath)); err != nil {
+			return fmt.Errorf("failed to write success message: %w", err)
+		}
+	}
+	return nil
+}
+
+// getModuleDirPaths returns the directories of the modules in the workspace in
+// the directory, or the directory itself if it does not contain a workspace.
+func getModuleDirPaths(dirPath string) ([]string, error) {
+	for _, workspaceConfigFilePath := range bufwork.AllConfigFilePaths {
+		workspaceConfigBytes, err := os.ReadFile(filepath.Join(dirPath, workspaceConfigFilePath))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var workspaceConfig bufwork.ExternalConfigV1
+		if err := encoding.UnmarshalYAMLNonStrict(workspaceConfigBytes, &workspaceConfig); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", workspaceConfigFilePath, err)
+		}
+		moduleDirPaths := make([]string, 0, len(workspaceConfig.Directories))
+		for _, directory := range workspaceConfig.Directories {
+			normalizedDirectory, err := normalpath.NormalizeAndValidate(directory)
+			if err != nil {
+				return nil, fmt.Errorf("invalid directory %q in %s: %w", directory, workspaceConfigFilePath, err)
+			}
+			moduleDirPaths = append(moduleDirPaths, filepath.Join(dirPath, normalpath.Unnormalize(normalizedDirectory)))
+		}
+		return moduleDirPaths, nil
+	}
+	return []string{dirPath}, nil
+}
+
+// migrateExcludes replaces the build.excludes in the given configuration file data
+// that are mapped to new paths.
+//
+// Returns the new data and the excludes that were replaced, mapped to their replacements.
+func migrateExcludes(data []byte, importMapping *ImportMapping) ([]byte, map[string]string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	if len(document.Content) == 0 {
+		return data, nil, nil
+	}
+	buildNode := getMappingValue(document.Content[0], "build")
+	if buildNode == nil {
+		return data, nil, nil
+	}
+	excludesNode := getMappingValue(buildNode, "excludes")
+	if excludesNode == nil || excludesNode.Kind != yaml.SequenceNode {
+		return data, nil, nil
+	}
+	replaced := make(map[string]string)
+	for _, excludeNode := range excludesNode.Content {
+		if excludeNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		// Excludes may have a trailing slash, which is not part of the mapping.
+		exclude := normalpath.Normalize(excludeNode.Value)
+		if newExclude, ok := importMapping.mapPath(exclude); ok {
+			replaced[excludeNode.Value] = newExclude
+			excludeNode.Value = newExclude
+		}
+	}
+	if len(replaced) == 0 {
+		return data, nil, nil
+	}
+	buffer := bytes.NewBuffer(nil)
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buffer.Bytes(), replaced, nil
+}
+
+// migrateImports rewrites the import paths, package declaration, and fully-qualified
+// references in the given .proto file data.
+//
+// Comments and string literals other than import paths are left untouched.
+func migrateImports(data []byte, importMapping *ImportMapping) ([]byte, error) {
+	tokens, err := tokenizeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	buffer := bytes.NewBuffer(make([]byte, 0, len(data)))
+	var last int
+	// The previous two significant tokens, used to detect import and package statements.
+	var previous, previousPrevious string
+	for _, token := range tokens {
+		value := string(data[token.start:token.end])
+		var replacement string
+		var replace bool
+		switch token.kind {
+		case protoTokenKindString:
+			if previous == "import" || ((previous == "public" || previous == "weak") && previousPrevious == "import") {
+				importPath, err := strconv.Unquote(`"` + value[1:len(value)-1] + `"`)
+				if err != nil {
+					return nil, fmt.Errorf("invalid import path %s: %w", value, err)
+				}
+				var newImportPath string
+				if newImportPath, replace = importMapping.mapPath(importPath); replace {
+					replacement = strconv.Quote(newImportPath)
+				}
+			}
+		case protoTokenKindIdentifier:
+			if previous == "package" {
+				replacement, replace = importMapping.mapPackage(value)
+				break
+			}
+			// Only fully-qualified references are mapped, as relative references
+			// are resolved against the package of the file.
+			name := strings.TrimPrefix(value, ".")
+			if newName, ok := importMapping.mapPackagePrefix(name); ok {
+				replacement, replace = strings.TrimSuffix(value, name)+newName, true
+			}
+		}
+		if token.kind != protoTokenKindComment {
+			previousPrevious, previous = previous, value
+		}
+		if !replace || replacement == value {
+			continue
+		}
+		buffer.Write(data[last:token.start])
+		buffer.WriteString(replacement)
+		last = token.end
+	}
+	buffer.Write(data[last:])
+	return buffer.Bytes(), nil
+}
+
+// mapPath returns the new path for the path, if the path or one of its parent
+// directories is mapped.
+func (i *ImportMapping) mapPath(path string) (string, bool) {
+	oldPrefix, ok := getLongestPrefix(i.Paths, path, "/")
+	if !ok {
+		return "", false
+	}
+	return i.Paths[oldPrefix] + strings.TrimPrefix(path, oldPrefix), true
+}
+
+// mapPackage returns the new package for the package, if the package or one of its
+// parent packages is mapped.
+func (i *ImportMapping) mapPackage(pkg string) (string, bool) {
+	oldPrefix, ok := getLongestPrefix(i.Packages, pkg, ".")
+	if !ok {
+		return "", false
+	}
+	return i.Packages[oldPrefix] + strings.TrimPrefix(pkg, oldPrefix), true
+}
+
+// mapPackagePrefix returns the new name for the fully-qualified name of an element,
+// if the package of the element is mapped.
+//
+// Unlike mapPackage, the name must not be equal to a mapped package.
+func (i *ImportMapping) mapPackagePrefix(name string) (string, bool) {
+	newName, ok := i.mapPackage(name)
+	if !ok || i.Packages[name] != "" {
+		return "", false
+	}
+	return newName, true
+}
+
+// getLongestPrefix returns the longest key of the map that is equal to the value or
+// a prefix of the value followed by the separator.
+func getLongestPrefix(m map[string]string, value string, separator string) (string, bool) {
+	var longestPrefix string
+	var found bool
+	for prefix := range m {
+		if value != prefix && !strings.HasPrefix(value, prefix+separator) {
+			continue
+		}
+		if !found || len(prefix) > len(longestPrefix) {
+			longestPrefix = prefix
+			found = true
+		}
+	}
+	return longestPrefix, found
+}
+
+type protoTokenKind int
+
+const (
+	protoTokenKindOther protoTokenKind = iota + 1
+	protoTokenKindComment
+	protoTokenKindString
+	protoTokenKindIdentifier
+)
+
+type protoToken struct {
+	kind  protoTokenKind
+	start int
+	end   int
+}
+
+// tokenizeProto splits .proto file data into the tokens needed to migrate imports.
+//
+// Whitespace is skipped. Identifiers include any dots and a leading dot, so that
+// fully-qualified names are a single token. Numbers and punctuation are returned
+// as protoTokenKindOther.
+func tokenizeProto(data []byte) ([]protoToken, error) {
+	var tokens []protoToken
+	for i := 0; i < len(data); {
+		c := data[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			tokens = append(tokens, protoToken{kind: protoTokenKindComment, start: start, end: i})
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, errors.New("unterminated block comment")
+			}
+			i += 2 + end + 2
+			tokens = append(tokens, protoToken{kind: protoTokenKindComment, start: start, end: i})
+		case c == '"' || c == '\'':
+			i++
+			for ; i < len(data) && data[i] != c; i++ {
+				if data[i] == '\n' {
+					return nil, errors.New("unterminated string literal")
+				}
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(data) {
+				return nil, errors.New("unterminated string literal")
+			}
+			i++
+			tokens = append(tokens, protoToken{kind: protoTokenKindString, start: start, end: i})
+		case isIdentifierStart(c) || (c == '.' && i+1 < len(data) && isIdentifierStart(data[i+1])):
+			i++
+			for i < len(data) {
+				if isIdentifierPart(data[i]) {
+					i++
+				} else if data[i] == '.' && i+1 < len(data) && isIdentifierStart(data[i+1]) {
+					i += 2
+				} else {
+					break
+				}
+			}
+			tokens = append(tokens, protoToken{kind: protoTokenKindIdentifier, start: start, end: i})
+		case c >= '0' && c <= '9':
+			// Consume the whole number so that exponents and hex digits are not
+			// treated as identifiers.
+			for i < len(data) && (isIdentifierPart(data[i]) || data[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, protoToken{kind: protoTokenKindOther, start: start, end: i})
+		default:
+			i++
+			tokens = append(tokens, protoToken{kind: protoTokenKindOther, start: start, end: i})
+		}
+	}
+	return tokens, nil
+}
+
+func isIdentifierStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentifierPart(c byte) bool {
+	return isIdentifierStart(c) || (c >= '0' && c <= '9')
+}
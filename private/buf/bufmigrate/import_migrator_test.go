@@ -0,0 +1,151 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmigrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testImportMappingData = `paths:
+  acme/weather/v1: acme/forecast/v1
+  acme/weather/v1/units.proto: acme/units/v1/units.proto
+packages:
+  acme.weather.v1: acme.forecast.v1
+`
+
+func TestMigrateImports(t *testing.T) {
+	t.Parallel()
+	importMapping, err := ReadImportMapping([]byte(testImportMappingData))
+	require.NoError(t, err)
+	migratedData, err := migrateImports(
+		[]byte(`syntax = "proto3";
+
+// See acme/weather/v1/weather.proto and acme.weather.v1.Weather.
+package acme.weather.v1.internal;
+
+import "acme/weather/v1/weather.proto";
+import public 'acme/weather/v1/units.proto';
+import "acme/weather/v10/weather.proto";
+
+option go_package = "example.com/acme/weather/v1";
+
+message Report {
+  acme.weather.v1.Weather weather = 1;
+  .acme.weather.v1.Units units = 2 [(acme.weather.v1.unit) = 1.5e-3];
+  Local local = 3;
+  string description = 4 [default = "acme.weather.v1.Weather"];
+  acme.weather.v10.Weather other = 5;
+}
+`),
+		importMapping,
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		`syntax = "proto3";
+
+// See acme/weather/v1/weather.proto and acme.weather.v1.Weather.
+package acme.forecast.v1.internal;
+
+import "acme/forecast/v1/weather.proto";
+import public "acme/units/v1/units.proto";
+import "acme/weather/v10/weather.proto";
+
+option go_package = "example.com/acme/weather/v1";
+
+message Report {
+  acme.forecast.v1.Weather weather = 1;
+  .acme.forecast.v1.Units units = 2 [(acme.forecast.v1.unit) = 1.5e-3];
+  Local local = 3;
+  string description = 4 [default = "acme.weather.v1.Weather"];
+  acme.weather.v10.Weather other = 5;
+}
+`,
+		string(migratedData),
+	)
+}
+
+func TestImportMigrator(t *testing.T) {
+	t.Parallel()
+	importMapping, err := ReadImportMapping([]byte(testImportMappingData))
+	require.NoError(t, err)
+	dirPath := t.TempDir()
+	writeTestFile(t, dirPath, "buf.work.yaml", "version: v1\ndirectories:\n  - proto\n  - vendor\n")
+	writeTestFile(t, dirPath, "proto/buf.yaml", "version: v1\n# Excluded until the API is stable.\nbuild:\n  excludes:\n    - acme/weather/v1/beta\n")
+	writeTestFile(t, dirPath, "proto/acme/forecast/v1/forecast.proto", "syntax = \"proto3\";\n\npackage acme.weather.v1;\n")
+	writeTestFile(t, dirPath, "vendor/acme/report/v1/report.proto", "syntax = \"proto3\";\n\nimport \"acme/weather/v1/forecast.proto\";\n")
+	writeTestFile(t, dirPath, "vendor/acme/other/v1/other.proto", "syntax = \"proto3\";\n\npackage acme.other.v1;\n")
+	var messages []string
+	err = NewImportMigrator(
+		importMapping,
+		ImportMigratorWithNotifier(func(message string) error {
+			messages = append(messages, message)
+			return nil
+		}),
+	).Migrate(dirPath)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"version: v1\n# Excluded until the API is stable.\nbuild:\n  excludes:\n    - acme/forecast/v1/beta\n",
+		readTestFile(t, dirPath, "proto/buf.yaml"),
+	)
+	assert.Equal(
+		t,
+		"syntax = \"proto3\";\n\npackage acme.forecast.v1;\n",
+		readTestFile(t, dirPath, "proto/acme/forecast/v1/forecast.proto"),
+	)
+	assert.Equal(
+		t,
+		"syntax = \"proto3\";\n\nimport \"acme/forecast/v1/forecast.proto\";\n",
+		readTestFile(t, dirPath, "vendor/acme/report/v1/report.proto"),
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"Replaced exclude acme/weather/v1/beta with acme/forecast/v1/beta in " + filepath.Join(dirPath, "proto", "buf.yaml") + ".\n",
+			"Updated imports and packages in 2 files.\n",
+		},
+		messages,
+	)
+}
+
+func TestReadImportMappingInvalid(t *testing.T) {
+	t.Parallel()
+	_, err := ReadImportMapping([]byte("paths:\n  ../acme: acme\n"))
+	assert.Error(t, err)
+	_, err = ReadImportMapping([]byte("packages:\n  acme.1weather: acme.forecast\n"))
+	assert.Error(t, err)
+	_, err = ReadImportMapping([]byte("{}"))
+	assert.Error(t, err)
+}
+
+func writeTestFile(t *testing.T, dirPath string, path string, content string) {
+	t.Helper()
+	filePath := filepath.Join(dirPath, filepath.FromSlash(path))
+	require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+}
+
+func readTestFile(t *testing.T, dirPath string, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dirPath, filepath.FromSlash(path)))
+	require.NoError(t, err)
+	return string(data)
+}
@@ -0,0 +1,260 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmigrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"gopkg.in/yaml.v3"
+)
+
+type ruleMigrator struct {
+	notifier func(string) error
+}
+
+func newRuleMigrator(options ...RuleMigrateOption) *ruleMigrator {
+	migrator := ruleMigrator{
+		notifier: func(string) error { return nil },
+	}
+	for _, option := range options {
+		option(&migrator)
+	}
+	return &migrator
+}
+
+func (m *ruleMigrator) Migrate(dirPath string) error {
+	configPath, err := getExistingConfigPath(dirPath)
+	if err != nil {
+		return err
+	}
+	configFileInfo, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+	configBytes, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	var versionedConfig bufconfig.ExternalConfigVersion
+	if err := encoding.UnmarshalYAMLNonStrict(configBytes, &versionedConfig); err != nil {
+		return fmt.Errorf("failed to read %s version: %w", configPath, err)
+	}
+	if versionedConfig.Version != bufconfig.V1Version {
+		return fmt.Errorf(`%s must be version %s to migrate rules, run "buf beta migrate-v1beta1" first`, configPath, bufconfig.V1Version)
+	}
+	migratedConfigBytes, replaced, err := migrateRules(configBytes, buflint.GetReplacedRulesAndCategoriesV1())
+	if err != nil {
+		return fmt.Errorf("failed to migrate rules in %s: %w", configPath, err)
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+	if err := os.WriteFile(configPath, migratedConfigBytes, configFileInfo.Mode().Perm()); err != nil {
+		return err
+	}
+	replacedIDsOrCategories := make([]string, 0, len(replaced))
+	for idOrCategory := range replaced {
+		replacedIDsOrCategories = append(replacedIDsOrCategories, idOrCategory)
+	}
+	sort.Strings(replacedIDsOrCategories)
+	for _, idOrCategory := range replacedIDsOrCategories {
+		message := fmt.Sprintf("Removed %s from %s.\n", idOrCategory, configPath)
+		if replacements := replaced[idOrCategory]; len(replacements) > 0 {
+			message = fmt.Sprintf("Replaced %s with %s in %s.\n", idOrCategory, strings.Join(replacements, ", "), configPath)
+		}
+		if err := m.notifier(message); err != nil {
+			return fmt.Errorf("failed to write success message: %w", err)
+		}
+	}
+	return nil
+}
+
+func getExistingConfigPath(dirPath string) (string, error) {
+	for _, configFilePath := range bufconfig.AllConfigFilePaths {
+		configPath := filepath.Join(dirPath, configFilePath)
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no %s found in %s", bufconfig.ExternalConfigV1FilePath, dirPath)
+}
+
+// migrateRules replaces the IDs and categories in the lint section of the given
+// configuration file data.
+//
+// Returns the new data and the IDs and categories that were replaced, mapped to
+// their replacements.
+func migrateRules(data []byte, replacedIDsOrCategories map[string][]string) ([]byte, map[string][]string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	if len(document.Content) == 0 {
+		return data, nil, nil
+	}
+	lintNode := getMappingValue(document.Content[0], "lint")
+	if lintNode == nil {
+		return data, nil, nil
+	}
+	replaced := make(map[string][]string)
+	if useNode := getMappingValue(lintNode, "use"); useNode != nil {
+		hadUse := len(useNode.Content) > 0
+		migrateSequence(useNode, replacedIDsOrCategories, replaced)
+		if hadUse && len(useNode.Content) == 0 {
+			// An empty use means the default rules are used, which would enable other rules.
+			return nil, nil, errors.New("all rules and categories in lint.use were removed, update lint.use manually")
+		}
+	}
+	if exceptNode := getMappingValue(lintNode, "except"); exceptNode != nil {
+		migrateSequence(exceptNode, replacedIDsOrCategories, replaced)
+	}
+	if ignoreOnlyNode := getMappingValue(lintNode, "ignore_only"); ignoreOnlyNode != nil {
+		migrateIgnoreOnly(ignoreOnlyNode, replacedIDsOrCategories, replaced)
+	}
+	if len(replaced) == 0 {
+		return data, nil, nil
+	}
+	buffer := bytes.NewBuffer(nil)
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buffer.Bytes(), replaced, nil
+}
+
+func migrateSequence(sequenceNode *yaml.Node, replacedIDsOrCategories map[string][]string, replaced map[string][]string) {
+	if sequenceNode.Kind != yaml.SequenceNode {
+		return
+	}
+	seen := make(map[string]struct{}, len(sequenceNode.Content))
+	for _, node := range sequenceNode.Content {
+		if _, ok := replacedIDsOrCategories[node.Value]; !ok {
+			seen[node.Value] = struct{}{}
+		}
+	}
+	content := make([]*yaml.Node, 0, len(sequenceNode.Content))
+	for _, node := range sequenceNode.Content {
+		replacements, ok := replacedIDsOrCategories[node.Value]
+		if !ok || node.Kind != yaml.ScalarNode {
+			content = append(content, node)
+			continue
+		}
+		replaced[node.Value] = replacements
+		headComment := node.HeadComment
+		for _, replacement := range replacements {
+			if _, ok := seen[replacement]; ok {
+				continue
+			}
+			seen[replacement] = struct{}{}
+			content = append(
+				content,
+				&yaml.Node{
+					Kind:        yaml.ScalarNode,
+					Tag:         "!!str",
+					Value:       replacement,
+					HeadComment: headComment,
+				},
+			)
+			headComment = ""
+		}
+	}
+	sequenceNode.Content = content
+}
+
+func migrateIgnoreOnly(mappingNode *yaml.Node, replacedIDsOrCategories map[string][]string, replaced map[string][]string) {
+	if mappingNode.Kind != yaml.MappingNode {
+		return
+	}
+	var content []*yaml.Node
+	keyToValueNode := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		keyNode, valueNode := mappingNode.Content[i], mappingNode.Content[i+1]
+		if _, ok := replacedIDsOrCategories[keyNode.Value]; !ok {
+			content = append(content, keyNode, valueNode)
+			keyToValueNode[keyNode.Value] = valueNode
+		}
+	}
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		keyNode, valueNode := mappingNode.Content[i], mappingNode.Content[i+1]
+		replacements, ok := replacedIDsOrCategories[keyNode.Value]
+		if !ok {
+			continue
+		}
+		replaced[keyNode.Value] = replacements
+		for _, replacement := range replacements {
+			if existingValueNode, ok := keyToValueNode[replacement]; ok {
+				appendMissingScalars(existingValueNode, valueNode)
+				continue
+			}
+			newValueNode := *valueNode
+			newValueNode.Content = append([]*yaml.Node{}, valueNode.Content...)
+			content = append(
+				content,
+				&yaml.Node{
+					Kind:  yaml.ScalarNode,
+					Tag:   "!!str",
+					Value: replacement,
+				},
+				&newValueNode,
+			)
+			keyToValueNode[replacement] = &newValueNode
+		}
+	}
+	mappingNode.Content = content
+}
+
+func appendMissingScalars(toSequenceNode *yaml.Node, fromSequenceNode *yaml.Node) {
+	if toSequenceNode.Kind != yaml.SequenceNode || fromSequenceNode.Kind != yaml.SequenceNode {
+		return
+	}
+	seen := make(map[string]struct{}, len(toSequenceNode.Content))
+	for _, node := range toSequenceNode.Content {
+		seen[node.Value] = struct{}{}
+	}
+	for _, node := range fromSequenceNode.Content {
+		if _, ok := seen[node.Value]; !ok {
+			seen[node.Value] = struct{}{}
+			toSequenceNode.Content = append(toSequenceNode.Content, node)
+		}
+	}
+}
+
+// getMappingValue returns the value node for the key in the mapping node, or nil.
+func getMappingValue(mappingNode *yaml.Node, key string) *yaml.Node {
+	if mappingNode.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mappingNode.Content); i += 2 {
+		if mappingNode.Content[i].Value == key {
+			return mappingNode.Content[i+1]
+		}
+	}
+	return nil
+}
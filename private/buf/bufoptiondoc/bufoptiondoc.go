@@ -0,0 +1,116 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufoptiondoc extracts the documentation of custom options and where
+// they are used, so that generated documentation can explain the options set on
+// each element.
+package bufoptiondoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Option is a custom option and its documentation.
+type Option struct {
+	// Name is the fully-qualified name of the extension that defines the option,
+	// such as "acme.options.v1.routing".
+	Name string `json:"name"`
+	// Extendee is the fully-qualified name of the options message that the option
+	// extends, such as "google.protobuf.MethodOptions".
+	Extendee string `json:"extendee"`
+	// FilePath is the path of the file that defines the option.
+	FilePath string `json:"file_path"`
+	// Documentation is the comment attached to the definition of the option.
+	//
+	// Empty if the option is not documented, or the source code info of the
+	// defining file is not available.
+	Documentation string `json:"documentation,omitempty"`
+	// Usages are the elements that set the option, in the order they are declared.
+	Usages []*Usage `json:"usages"`
+}
+
+// Usage is an element that sets a custom option.
+type Usage struct {
+	// FilePath is the path of the file that contains the element.
+	FilePath string `json:"file_path"`
+	// ElementName is the fully-qualified name of the element.
+	//
+	// Empty for file options.
+	ElementName string `json:"element_name,omitempty"`
+}
+
+// GetOptions returns the custom options used within the given files, sorted by name.
+//
+// The fileDescriptorProtos must be self-contained, that is include all the
+// files that define the options, which are commonly dependencies. Only
+// usages within the files with the given targetFilePaths are returned. If
+// targetFilePaths is empty, usages within all files are returned.
+func GetOptions(
+	fileDescriptorProtos []*descriptorpb.FileDescriptorProto,
+	targetFilePaths []string,
+) ([]*Option, error) {
+	return getOptions(fileDescriptorProtos, targetFilePaths)
+}
+
+// WriteOptionsText writes the Options as human-readable text.
+func WriteOptionsText(writer io.Writer, options []*Option) error {
+	for i, option := range options {
+		if i > 0 {
+			if _, err := fmt.Fprintln(writer); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(writer, "(%s) on %s, defined in %s\n", option.Name, option.Extendee, option.FilePath); err != nil {
+			return err
+		}
+		if option.Documentation != "" {
+			for _, line := range strings.Split(option.Documentation, "\n") {
+				if _, err := fmt.Fprintln(writer, strings.TrimRight("  "+line, " ")); err != nil {
+					return err
+				}
+			}
+		}
+		if _, err := fmt.Fprintln(writer, "  Used by:"); err != nil {
+			return err
+		}
+		for _, usage := range option.Usages {
+			elementName := usage.ElementName
+			if elementName == "" {
+				elementName = "file"
+			}
+			if _, err := fmt.Fprintf(writer, "    %s (%s)\n", elementName, usage.FilePath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteOptionsJSON writes the Options as a JSON array.
+func WriteOptionsJSON(writer io.Writer, options []*Option) error {
+	if options == nil {
+		options = []*Option{}
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,177 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufoptiondoc
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGetOptions(t *testing.T) {
+	t.Parallel()
+	options, err := GetOptions(testGetFileDescriptorProtos(t), []string{"acme/weather/v1/weather.proto"})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]*Option{
+			{
+				Name:          "acme.options.v1.Routing.deprecated_value",
+				Extendee:      "google.protobuf.EnumValueOptions",
+				FilePath:      "acme/options/v1/options.proto",
+				Documentation: "deprecated_value marks the value as scheduled for removal.",
+				Usages: []*Usage{
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.Condition.CONDITION_HAIL",
+					},
+				},
+			},
+			{
+				Name:          "acme.options.v1.idempotent",
+				Extendee:      "google.protobuf.MethodOptions",
+				FilePath:      "acme/options/v1/options.proto",
+				Documentation: "idempotent marks the method as safe to retry.",
+				Usages: []*Usage{
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.WeatherService.GetWeather",
+					},
+				},
+			},
+			{
+				Name:          "acme.options.v1.owner",
+				Extendee:      "google.protobuf.FileOptions",
+				FilePath:      "acme/options/v1/options.proto",
+				Documentation: "owner is the team that owns the file.",
+				Usages: []*Usage{
+					{
+						FilePath: "acme/weather/v1/weather.proto",
+					},
+				},
+			},
+			{
+				Name:          "acme.options.v1.routing",
+				Extendee:      "google.protobuf.MethodOptions",
+				FilePath:      "acme/options/v1/options.proto",
+				Documentation: "routing configures how the method is routed\nto backends.\n\nUnset methods are routed to the default backend.",
+				Usages: []*Usage{
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.WeatherService.GetWeather",
+					},
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.WeatherService.SetWeather",
+					},
+				},
+			},
+			{
+				Name:          "acme.options.v1.sensitive",
+				Extendee:      "google.protobuf.FieldOptions",
+				FilePath:      "acme/options/v1/options.proto",
+				Documentation: "sensitive marks the field as containing sensitive data.",
+				Usages: []*Usage{
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.GetWeatherRequest.location",
+					},
+					{
+						FilePath:    "acme/weather/v1/weather.proto",
+						ElementName: "acme.weather.v1.SetWeatherRequest.location",
+					},
+				},
+			},
+		},
+		options,
+	)
+}
+
+func TestGetOptionsOptionsFileOnly(t *testing.T) {
+	t.Parallel()
+	options, err := GetOptions(testGetFileDescriptorProtos(t), []string{"acme/options/v1/options.proto"})
+	require.NoError(t, err)
+	assert.Empty(t, options)
+}
+
+func TestWriteOptionsText(t *testing.T) {
+	t.Parallel()
+	options, err := GetOptions(testGetFileDescriptorProtos(t), nil)
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteOptionsText(buffer, options[2:4]))
+	assert.Equal(
+		t,
+		`(acme.options.v1.owner) on google.protobuf.FileOptions, defined in acme/options/v1/options.proto
+  owner is the team that owns the file.
+  Used by:
+    file (acme/weather/v1/weather.proto)
+
+(acme.options.v1.routing) on google.protobuf.MethodOptions, defined in acme/options/v1/options.proto
+  routing configures how the method is routed
+  to backends.
+
+  Unset methods are routed to the default backend.
+  Used by:
+    acme.weather.v1.WeatherService.GetWeather (acme/weather/v1/weather.proto)
+    acme.weather.v1.WeatherService.SetWeather (acme/weather/v1/weather.proto)
+`,
+		buffer.String(),
+	)
+}
+
+func TestWriteOptionsJSON(t *testing.T) {
+	t.Parallel()
+	options, err := GetOptions(testGetFileDescriptorProtos(t), nil)
+	require.NoError(t, err)
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteOptionsJSON(buffer, options[2:3]))
+	assert.Equal(
+		t,
+		`[{"name":"acme.options.v1.owner","extendee":"google.protobuf.FileOptions","file_path":"acme/options/v1/options.proto","documentation":"owner is the team that owns the file.","usages":[{"file_path":"acme/weather/v1/weather.proto"}]}]`+"\n",
+		buffer.String(),
+	)
+	buffer.Reset()
+	require.NoError(t, WriteOptionsJSON(buffer, nil))
+	assert.Equal(t, "[]\n", buffer.String())
+}
+
+func testGetFileDescriptorProtos(t *testing.T) []*descriptorpb.FileDescriptorProto {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			&protocompile.SourceResolver{
+				ImportPaths: []string{"./testdata"},
+			},
+		),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}).Compile(
+		context.Background(),
+		"google/protobuf/descriptor.proto",
+		"acme/options/v1/options.proto",
+		"acme/weather/v1/weather.proto",
+	)
+	require.NoError(t, err)
+	fileDescriptorProtos := make([]*descriptorpb.FileDescriptorProto, len(files))
+	for i, file := range files {
+		fileDescriptorProtos[i] = protodesc.ToFileDescriptorProto(file)
+	}
+	return fileDescriptorProtos
+}
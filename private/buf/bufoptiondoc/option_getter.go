@@ -0,0 +1,304 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufoptiondoc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Field numbers within descriptor.proto used to build source code info paths.
+const (
+	fileMessageTypeFieldNumber   = 4
+	fileExtensionFieldNumber     = 7
+	messageNestedTypeFieldNumber = 3
+	messageExtensionFieldNumber  = 6
+)
+
+// extensionKey identifies an extension by the options message it extends and its number.
+type extensionKey struct {
+	extendee string
+	number   protoreflect.FieldNumber
+}
+
+func getOptions(
+	fileDescriptorProtos []*descriptorpb.FileDescriptorProto,
+	targetFilePaths []string,
+) ([]*Option, error) {
+	keyToOption := make(map[extensionKey]*Option)
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		addExtensions(keyToOption, fileDescriptorProto)
+	}
+	targetFilePathMap := make(map[string]struct{}, len(targetFilePaths))
+	for _, targetFilePath := range targetFilePaths {
+		targetFilePathMap[targetFilePath] = struct{}{}
+	}
+	var usedOptions []*Option
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		if len(targetFilePathMap) > 0 {
+			if _, ok := targetFilePathMap[fileDescriptorProto.GetName()]; !ok {
+				continue
+			}
+		}
+		if err := walkElements(fileDescriptorProto, func(elementName string, options proto.Message) error {
+			numbers, err := getOptionNumbers(options)
+			if err != nil {
+				return fmt.Errorf("%s: %w", fileDescriptorProto.GetName(), err)
+			}
+			extendee := string(options.ProtoReflect().Descriptor().FullName())
+			for _, number := range numbers {
+				option, ok := keyToOption[extensionKey{extendee: extendee, number: number}]
+				if !ok {
+					// Not a custom option, or the file that defines it was not provided.
+					continue
+				}
+				if len(option.Usages) == 0 {
+					usedOptions = append(usedOptions, option)
+				}
+				option.Usages = append(
+					option.Usages,
+					&Usage{
+						FilePath:    fileDescriptorProto.GetName(),
+						ElementName: elementName,
+					},
+				)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(usedOptions, func(i int, j int) bool { return usedOptions[i].Name < usedOptions[j].Name })
+	return usedOptions, nil
+}
+
+// addExtensions adds all extensions defined in the file to keyToOption.
+func addExtensions(keyToOption map[extensionKey]*Option, fileDescriptorProto *descriptorpb.FileDescriptorProto) {
+	pathToDocumentation := getPathToDocumentation(fileDescriptorProto)
+	addExtension := func(scope string, path []int32, extension *descriptorpb.FieldDescriptorProto) {
+		extendee := strings.TrimPrefix(extension.GetExtendee(), ".")
+		keyToOption[extensionKey{extendee: extendee, number: protoreflect.FieldNumber(extension.GetNumber())}] = &Option{
+			Name:          scope + extension.GetName(),
+			Extendee:      extendee,
+			FilePath:      fileDescriptorProto.GetName(),
+			Documentation: pathToDocumentation[getPathKey(path)],
+		}
+	}
+	scope := getScope(fileDescriptorProto.GetPackage())
+	for i, extension := range fileDescriptorProto.GetExtension() {
+		addExtension(scope, []int32{fileExtensionFieldNumber, int32(i)}, extension)
+	}
+	var addMessageExtensions func(string, []int32, *descriptorpb.DescriptorProto)
+	addMessageExtensions = func(scope string, path []int32, message *descriptorpb.DescriptorProto) {
+		scope = scope + message.GetName() + "."
+		for i, extension := range message.GetExtension() {
+			addExtension(scope, appendPath(path, messageExtensionFieldNumber, i), extension)
+		}
+		for i, nestedMessage := range message.GetNestedType() {
+			addMessageExtensions(scope, appendPath(path, messageNestedTypeFieldNumber, i), nestedMessage)
+		}
+	}
+	for i, message := range fileDescriptorProto.GetMessageType() {
+		addMessageExtensions(scope, []int32{fileMessageTypeFieldNumber, int32(i)}, message)
+	}
+}
+
+// walkElements calls f for every element in the file that has options, with the
+// fully-qualified name of the element and its options.
+//
+// The element name is empty for the file itself.
+func walkElements(
+	fileDescriptorProto *descriptorpb.FileDescriptorProto,
+	f func(elementName string, options proto.Message) error,
+) error {
+	visit := func(elementName string, options proto.Message) error {
+		if !options.ProtoReflect().IsValid() {
+			return nil
+		}
+		return f(elementName, options)
+	}
+	if err := visit("", fileDescriptorProto.GetOptions()); err != nil {
+		return err
+	}
+	scope := getScope(fileDescriptorProto.GetPackage())
+	visitFields := func(scope string, fields []*descriptorpb.FieldDescriptorProto) error {
+		for _, field := range fields {
+			if err := visit(scope+field.GetName(), field.GetOptions()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	visitEnums := func(scope string, enums []*descriptorpb.EnumDescriptorProto) error {
+		for _, enum := range enums {
+			enumName := scope + enum.GetName()
+			if err := visit(enumName, enum.GetOptions()); err != nil {
+				return err
+			}
+			for _, value := range enum.GetValue() {
+				// Enum values are scoped to the parent of the enum, but are
+				// clearer with the name of the enum attached.
+				if err := visit(enumName+"."+value.GetName(), value.GetOptions()); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	var visitMessages func(string, []*descriptorpb.DescriptorProto) error
+	visitMessages = func(scope string, messages []*descriptorpb.DescriptorProto) error {
+		for _, message := range messages {
+			messageName := scope + message.GetName()
+			if err := visit(messageName, message.GetOptions()); err != nil {
+				return err
+			}
+			messageScope := messageName + "."
+			if err := visitFields(messageScope, message.GetField()); err != nil {
+				return err
+			}
+			for _, oneof := range message.GetOneofDecl() {
+				if err := visit(messageScope+oneof.GetName(), oneof.GetOptions()); err != nil {
+					return err
+				}
+			}
+			if err := visitFields(messageScope, message.GetExtension()); err != nil {
+				return err
+			}
+			if err := visitEnums(messageScope, message.GetEnumType()); err != nil {
+				return err
+			}
+			if err := visitMessages(messageScope, message.GetNestedType()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visitMessages(scope, fileDescriptorProto.GetMessageType()); err != nil {
+		return err
+	}
+	if err := visitEnums(scope, fileDescriptorProto.GetEnumType()); err != nil {
+		return err
+	}
+	if err := visitFields(scope, fileDescriptorProto.GetExtension()); err != nil {
+		return err
+	}
+	for _, service := range fileDescriptorProto.GetService() {
+		serviceName := scope + service.GetName()
+		if err := visit(serviceName, service.GetOptions()); err != nil {
+			return err
+		}
+		for _, method := range service.GetMethod() {
+			if err := visit(serviceName+"."+method.GetName(), method.GetOptions()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getOptionNumbers returns the numbers of the extensions set on the options, in
+// the order they first appear.
+//
+// Custom options are usually unknown fields, as the extensions are not linked into
+// the binary, but may also have been resolved into known extensions.
+func getOptionNumbers(options proto.Message) ([]protoreflect.FieldNumber, error) {
+	var numbers []protoreflect.FieldNumber
+	seen := make(map[protoreflect.FieldNumber]struct{})
+	add := func(number protoreflect.FieldNumber) {
+		if _, ok := seen[number]; !ok {
+			seen[number] = struct{}{}
+			numbers = append(numbers, number)
+		}
+	}
+	message := options.ProtoReflect()
+	message.Range(func(fieldDescriptor protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fieldDescriptor.IsExtension() {
+			add(fieldDescriptor.Number())
+		}
+		return true
+	})
+	unknown := message.GetUnknown()
+	for len(unknown) > 0 {
+		number, wireType, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid %s: %w", message.Descriptor().FullName(), protowire.ParseError(n))
+		}
+		unknown = unknown[n:]
+		n = protowire.ConsumeFieldValue(number, wireType, unknown)
+		if n < 0 {
+			return nil, fmt.Errorf("invalid %s: %w", message.Descriptor().FullName(), protowire.ParseError(n))
+		}
+		unknown = unknown[n:]
+		add(number)
+	}
+	return numbers, nil
+}
+
+// getPathToDocumentation returns the documentation of each location in the file
+// that has comments, keyed by getPathKey.
+func getPathToDocumentation(fileDescriptorProto *descriptorpb.FileDescriptorProto) map[string]string {
+	pathToDocumentation := make(map[string]string)
+	for _, location := range fileDescriptorProto.GetSourceCodeInfo().GetLocation() {
+		comments := location.GetLeadingComments()
+		if strings.TrimSpace(comments) == "" {
+			comments = location.GetTrailingComments()
+		}
+		if documentation := cleanComments(comments); documentation != "" {
+			pathToDocumentation[getPathKey(location.GetPath())] = documentation
+		}
+	}
+	return pathToDocumentation
+}
+
+// cleanComments removes the single space that commonly follows the comment
+// marker from each line, and any surrounding blank lines.
+func cleanComments(comments string) string {
+	lines := strings.Split(comments, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(strings.TrimPrefix(line, " "), " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func getPathKey(path []int32) string {
+	elements := make([]string, len(path))
+	for i, element := range path {
+		elements[i] = strconv.Itoa(int(element))
+	}
+	return strings.Join(elements, ".")
+}
+
+func appendPath(path []int32, elements ...int) []int32 {
+	newPath := make([]int32, len(path), len(path)+len(elements))
+	copy(newPath, path)
+	for _, element := range elements {
+		newPath = append(newPath, int32(element))
+	}
+	return newPath
+}
+
+func getScope(pkg string) string {
+	if pkg == "" {
+		return ""
+	}
+	return pkg + "."
+}
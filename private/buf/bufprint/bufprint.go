@@ -124,8 +124,22 @@ type RepositoryCommitPrinter interface {
 }
 
 // NewRepositoryCommitPrinter returns a new RepositoryCommitPrinter.
-func NewRepositoryCommitPrinter(writer io.Writer) RepositoryCommitPrinter {
-	return newRepositoryCommitPrinter(writer)
+func NewRepositoryCommitPrinter(writer io.Writer, options ...RepositoryCommitPrinterOption) RepositoryCommitPrinter {
+	return newRepositoryCommitPrinter(writer, options...)
+}
+
+// RepositoryCommitPrinterOption is an option for a new RepositoryCommitPrinter.
+type RepositoryCommitPrinterOption func(*repositoryCommitPrinter)
+
+// RepositoryCommitPrinterWithDetails returns a new RepositoryCommitPrinterOption that
+// adds the create time, digest, labels, author, and source of each commit to the text
+// output.
+//
+// The JSON output always includes these.
+func RepositoryCommitPrinterWithDetails() RepositoryCommitPrinterOption {
+	return func(repositoryCommitPrinter *repositoryCommitPrinter) {
+		repositoryCommitPrinter.details = true
+	}
 }
 
 // RepositoryDraftPrinter is a repository draft printer.
@@ -19,20 +19,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
 )
 
 type repositoryCommitPrinter struct {
-	writer io.Writer
+	writer  io.Writer
+	details bool
 }
 
 func newRepositoryCommitPrinter(
 	writer io.Writer,
+	options ...RepositoryCommitPrinterOption,
 ) *repositoryCommitPrinter {
-	return &repositoryCommitPrinter{
+	repositoryCommitPrinter := &repositoryCommitPrinter{
 		writer: writer,
 	}
+	for _, option := range options {
+		option(repositoryCommitPrinter)
+	}
+	return repositoryCommitPrinter
 }
 
 func (p *repositoryCommitPrinter) PrintRepositoryCommit(ctx context.Context, format Format, message *registryv1alpha1.RepositoryCommit) error {
@@ -70,6 +78,9 @@ func (p *repositoryCommitPrinter) PrintRepositoryCommits(ctx context.Context, fo
 }
 
 func (p *repositoryCommitPrinter) printRepositoryCommitsText(outputRepositoryCommits []outputRepositoryCommit) error {
+	if p.details {
+		return p.printRepositoryCommitsTextWithDetails(outputRepositoryCommits)
+	}
 	return WithTabWriter(
 		p.writer,
 		[]string{
@@ -88,16 +99,93 @@ func (p *repositoryCommitPrinter) printRepositoryCommitsText(outputRepositoryCom
 	)
 }
 
+func (p *repositoryCommitPrinter) printRepositoryCommitsTextWithDetails(outputRepositoryCommits []outputRepositoryCommit) error {
+	return WithTabWriter(
+		p.writer,
+		[]string{
+			"Commit",
+			"Created",
+			"Digest",
+			"Labels",
+			"Author",
+			"Source",
+		},
+		func(tabWriter TabWriter) error {
+			for _, outputRepositoryCommit := range outputRepositoryCommits {
+				labels := make([]string, 0, len(outputRepositoryCommit.Tags))
+				for _, tag := range outputRepositoryCommit.Tags {
+					labels = append(labels, tag.Name)
+				}
+				if err := tabWriter.Write(
+					outputRepositoryCommit.Commit,
+					createTimeString(outputRepositoryCommit.CreateTime),
+					outputRepositoryCommit.Digest,
+					strings.Join(labels, ","),
+					outputRepositoryCommit.Author,
+					commitSourceString(outputRepositoryCommit.Branch, outputRepositoryCommit.DraftName, outputRepositoryCommit.GitCommitsCount),
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
 type outputRepositoryCommit struct {
-	ID     string                `json:"id,omitempty"`
-	Commit string                `json:"commit,omitempty"`
-	Tags   []outputRepositoryTag `json:"tags,omitempty"`
+	ID              string                `json:"id,omitempty"`
+	Commit          string                `json:"commit,omitempty"`
+	Tags            []outputRepositoryTag `json:"tags,omitempty"`
+	Digest          string                `json:"digest,omitempty"`
+	CreateTime      time.Time             `json:"create_time,omitempty"`
+	Author          string                `json:"author,omitempty"`
+	Branch          string                `json:"branch,omitempty"`
+	DraftName       string                `json:"draft_name,omitempty"`
+	GitCommitsCount int64                 `json:"git_commits_count,omitempty"`
 }
 
 func registryCommitToOutputCommit(repositoryCommit *registryv1alpha1.RepositoryCommit) outputRepositoryCommit {
-	return outputRepositoryCommit{
-		ID:     repositoryCommit.Id,
-		Commit: repositoryCommit.Name,
-		Tags:   registryTagsToOutputTags(repositoryCommit.Tags),
+	outputRepositoryCommit := outputRepositoryCommit{
+		ID:              repositoryCommit.Id,
+		Commit:          repositoryCommit.Name,
+		Tags:            registryTagsToOutputTags(repositoryCommit.Tags),
+		Digest:          repositoryCommit.ManifestDigest,
+		Author:          repositoryCommit.Author,
+		Branch:          repositoryCommit.Branch,
+		DraftName:       repositoryCommit.DraftName,
+		GitCommitsCount: repositoryCommit.GitCommitsCount,
+	}
+	if repositoryCommit.CreateTime != nil {
+		outputRepositoryCommit.CreateTime = repositoryCommit.CreateTime.AsTime()
+	}
+	return outputRepositoryCommit
+}
+
+// createTimeString returns the create time for text output, or the empty string
+// if the create time is not set.
+func createTimeString(createTime time.Time) string {
+	if createTime.IsZero() {
+		return ""
+	}
+	return createTime.Format(time.RFC3339)
+}
+
+// commitSourceString describes where a commit came from for text output.
+//
+// Commits synced from git are reported as such, drafts and non-default branches
+// are reported by name, and all other commits were pushed directly.
+func commitSourceString(branch string, draftName string, gitCommitsCount int64) string {
+	var source string
+	switch {
+	case draftName != "":
+		source = "draft:" + draftName
+	case branch != "":
+		source = "branch:" + branch
+	default:
+		source = "push"
+	}
+	if gitCommitsCount > 0 {
+		source += fmt.Sprintf(" (git sync, %d git commits)", gitCommitsCount)
 	}
+	return source
 }
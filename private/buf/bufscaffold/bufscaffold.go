@@ -0,0 +1,90 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufscaffold generates server skeletons for services.
+package bufscaffold
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TemplateExt is the extension of files that are executed as templates by GenerateTemplates.
+const TemplateExt = ".tmpl"
+
+// Data is the data that templates are executed with.
+type Data struct {
+	Services []*Service
+}
+
+// Service is a service to scaffold.
+type Service struct {
+	// Name is the name of the service, such as "FooService".
+	Name string
+	// FullName is the fully-qualified name of the service, such as "foo.v1.FooService".
+	FullName string
+	// Package is the package of the service, such as "foo.v1".
+	Package string
+	Methods []*Method
+}
+
+// Method is a method of a service to scaffold.
+type Method struct {
+	// Name is the name of the method, such as "GetFoo".
+	Name string
+	// Procedure is the path of the method, such as "/foo.v1.FooService/GetFoo".
+	Procedure string
+	// InputType is the fully-qualified name of the input message.
+	InputType string
+	// OutputType is the fully-qualified name of the output message.
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// NewData returns the Data for the services.
+func NewData(serviceDescriptors []protoreflect.ServiceDescriptor) *Data {
+	return newData(serviceDescriptors)
+}
+
+// GenerateGo generates a runnable Connect server in Go for the services.
+//
+// The server serves all of the services, along with the gRPC health checking and server
+// reflection protocols. Each method of each service returns an unimplemented error, with
+// a TODO to implement it.
+//
+// The code generated by protoc-gen-go and protoc-gen-connect-go for the files of the
+// services must be importable, so all files must have the go_package option set.
+//
+// Returns a map from path to file content.
+func GenerateGo(serviceDescriptors []protoreflect.ServiceDescriptor) (map[string][]byte, error) {
+	return generateGo(serviceDescriptors)
+}
+
+// GenerateTemplates generates files from the templates in the bucket.
+//
+// Files in the bucket with the TemplateExt extension are executed as text/template
+// templates with the Data, and written to the path without the extension. All other
+// files are copied as-is.
+//
+// Returns a map from path to file content.
+func GenerateTemplates(
+	ctx context.Context,
+	templateReadBucket storage.ReadBucket,
+	data *Data,
+) (map[string][]byte, error) {
+	return generateTemplates(ctx, templateReadBucket, data)
+}
@@ -0,0 +1,133 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufscaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestNewData(t *testing.T) {
+	t.Parallel()
+	data := NewData(testGetServiceDescriptors(t, "test.proto", "BarService"))
+	assert.Equal(
+		t,
+		&Data{
+			Services: []*Service{
+				{
+					Name:     "BarService",
+					FullName: "test.v1.BarService",
+					Package:  "test.v1",
+					Methods: []*Method{
+						{
+							Name:       "GetBar",
+							Procedure:  "/test.v1.BarService/GetBar",
+							InputType:  "test.v1.GetFooRequest",
+							OutputType: "test.v1.GetFooResponse",
+						},
+					},
+				},
+			},
+		},
+		data,
+	)
+}
+
+func TestGenerateGo(t *testing.T) {
+	t.Parallel()
+	pathToContent, err := GenerateGo(testGetServiceDescriptors(t, "test.proto", "FooService", "BarService"))
+	require.NoError(t, err)
+	expected, err := os.ReadFile(filepath.Join("testdata", "main.go.golden"))
+	require.NoError(t, err)
+	require.Len(t, pathToContent, 1)
+	assert.Equal(t, string(expected), string(pathToContent["main.go"]))
+}
+
+func TestGenerateGoNoGoPackage(t *testing.T) {
+	t.Parallel()
+	_, err := GenerateGo(testGetServiceDescriptors(t, "nogopackage.proto", "NoGoPackageService"))
+	assert.EqualError(t, err, "nogopackage.proto: go_package is not set, which is required to scaffold a Go server")
+}
+
+func TestGenerateTemplates(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"README.md":            []byte("# Server\n"),
+			"src/services.ts.tmpl": []byte(`{{range .Services}}{{.FullName}}:{{range .Methods}} {{.Name}}{{end}}` + "\n" + `{{end}}`),
+		},
+	)
+	require.NoError(t, err)
+	pathToContent, err := GenerateTemplates(
+		context.Background(),
+		readBucket,
+		NewData(testGetServiceDescriptors(t, "test.proto", "FooService", "BarService")),
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		map[string][]byte{
+			"README.md":       []byte("# Server\n"),
+			"src/services.ts": []byte("test.v1.FooService: GetFoo ListFoos UploadFoos SyncFoos\ntest.v1.BarService: GetBar\n"),
+		},
+		pathToContent,
+	)
+	readBucket, err = storagemem.NewReadBucket(
+		map[string][]byte{
+			"bad.tmpl": []byte(`{{.Unknown}}`),
+		},
+	)
+	require.NoError(t, err)
+	_, err = GenerateTemplates(context.Background(), readBucket, &Data{})
+	assert.ErrorContains(t, err, "could not execute template bad.tmpl")
+}
+
+// testGetServiceDescriptors compiles the file in testdata and returns the services,
+// after converting the files to and from FileDescriptorProtos as is done for images.
+func testGetServiceDescriptors(t *testing.T, path string, names ...protoreflect.Name) []protoreflect.ServiceDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(
+			&protocompile.SourceResolver{
+				ImportPaths: []string{"./testdata"},
+			},
+		),
+	}).Compile(context.Background(), "google/protobuf/empty.proto", path)
+	require.NoError(t, err)
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		fileDescriptorSet.File = append(fileDescriptorSet.File, protodesc.ToFileDescriptorProto(file))
+	}
+	registry, err := protodesc.NewFiles(fileDescriptorSet)
+	require.NoError(t, err)
+	serviceDescriptors := make([]protoreflect.ServiceDescriptor, len(names))
+	for i, name := range names {
+		descriptor, err := registry.FindDescriptorByName(protoreflect.FullName("test.v1").Append(name))
+		require.NoError(t, err)
+		serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+		require.True(t, ok)
+		serviceDescriptors[i] = serviceDescriptor
+	}
+	return serviceDescriptors
+}
@@ -0,0 +1,98 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufscaffold
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func newData(serviceDescriptors []protoreflect.ServiceDescriptor) *Data {
+	services := make([]*Service, len(serviceDescriptors))
+	for i, serviceDescriptor := range serviceDescriptors {
+		service := &Service{
+			Name:     string(serviceDescriptor.Name()),
+			FullName: string(serviceDescriptor.FullName()),
+			Package:  string(serviceDescriptor.ParentFile().Package()),
+		}
+		methodDescriptors := serviceDescriptor.Methods()
+		for j := 0; j < methodDescriptors.Len(); j++ {
+			methodDescriptor := methodDescriptors.Get(j)
+			service.Methods = append(
+				service.Methods,
+				&Method{
+					Name:            string(methodDescriptor.Name()),
+					Procedure:       "/" + service.FullName + "/" + string(methodDescriptor.Name()),
+					InputType:       string(methodDescriptor.Input().FullName()),
+					OutputType:      string(methodDescriptor.Output().FullName()),
+					ClientStreaming: methodDescriptor.IsStreamingClient(),
+					ServerStreaming: methodDescriptor.IsStreamingServer(),
+				},
+			)
+		}
+		services[i] = service
+	}
+	return &Data{
+		Services: services,
+	}
+}
+
+func generateTemplates(
+	ctx context.Context,
+	templateReadBucket storage.ReadBucket,
+	data *Data,
+) (map[string][]byte, error) {
+	pathToContent := make(map[string][]byte)
+	if err := storage.WalkReadObjects(
+		ctx,
+		templateReadBucket,
+		"",
+		func(readObject storage.ReadObject) error {
+			content, err := io.ReadAll(readObject)
+			if err != nil {
+				return err
+			}
+			path := readObject.Path()
+			if !strings.HasSuffix(path, TemplateExt) {
+				pathToContent[path] = content
+				return nil
+			}
+			tmpl, err := template.New(path).Parse(string(content))
+			if err != nil {
+				return fmt.Errorf("could not parse template %s: %w", readObject.ExternalPath(), err)
+			}
+			buffer := bytes.NewBuffer(nil)
+			if err := tmpl.Execute(buffer, data); err != nil {
+				return fmt.Errorf("could not execute template %s: %w", readObject.ExternalPath(), err)
+			}
+			pathToContent[strings.TrimSuffix(path, TemplateExt)] = buffer.Bytes()
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	if len(pathToContent) == 0 {
+		return nil, errors.New("no templates found")
+	}
+	return pathToContent, nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufscaffold
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const goMainFilePath = "main.go"
+
+var (
+	//go:embed go_main.go.tmpl
+	goMainTemplateString string
+	goMainTemplate       = template.Must(template.New(goMainFilePath).Parse(goMainTemplateString))
+
+	// goReservedIdents are the identifiers used by the main.go template, which imports
+	// must not be aliased to.
+	goReservedIdents = []string{
+		"address",
+		"connect",
+		"context",
+		"errors",
+		"grpchealth",
+		"grpcreflect",
+		"h2c",
+		"http",
+		"http2",
+		"log",
+		"main",
+		"mux",
+		"reflector",
+		"serviceNames",
+	}
+)
+
+type goData struct {
+	Imports  []*goImport
+	Services []*goService
+}
+
+type goImport struct {
+	Alias string
+	Path  string
+}
+
+type goService struct {
+	// ConnectAlias is the alias of the import of the package generated by protoc-gen-connect-go.
+	ConnectAlias string
+	Name         string
+	HandlerName  string
+	Methods      []*goMethod
+}
+
+type goMethod struct {
+	Name       string
+	FullName   string
+	Parameters string
+	Results    string
+	// ZeroResults are the results to return before the error.
+	ZeroResults string
+}
+
+func generateGo(serviceDescriptors []protoreflect.ServiceDescriptor) (map[string][]byte, error) {
+	if len(serviceDescriptors) == 0 {
+		return nil, fmt.Errorf("no services to scaffold")
+	}
+	importer := newGoImporter()
+	handlerNames := make(map[string]struct{})
+	data := &goData{}
+	for _, serviceDescriptor := range serviceDescriptors {
+		goImportPath, goPackageName, err := getGoImportPathAndPackageName(serviceDescriptor.ParentFile())
+		if err != nil {
+			return nil, err
+		}
+		connectAlias := importer.alias(
+			goImportPath+"/"+goPackageName+"connect",
+			goPackageName+"connect",
+		)
+		handlerName := lowerFirst(string(serviceDescriptor.Name())) + "Handler"
+		if _, ok := handlerNames[handlerName]; ok {
+			handlerName = lowerFirst(goPackageName) + string(serviceDescriptor.Name()) + "Handler"
+		}
+		handlerNames[handlerName] = struct{}{}
+		service := &goService{
+			ConnectAlias: connectAlias,
+			Name:         string(serviceDescriptor.Name()),
+			HandlerName:  handlerName,
+		}
+		methodDescriptors := serviceDescriptor.Methods()
+		for i := 0; i < methodDescriptors.Len(); i++ {
+			method, err := newGoMethod(importer, methodDescriptors.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			service.Methods = append(service.Methods, method)
+		}
+		data.Services = append(data.Services, service)
+	}
+	data.Imports = importer.imports
+	buffer := bytes.NewBuffer(nil)
+	if err := goMainTemplate.Execute(buffer, data); err != nil {
+		return nil, err
+	}
+	content, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("could not format generated Go code: %w", err)
+	}
+	return map[string][]byte{
+		goMainFilePath: content,
+	}, nil
+}
+
+func newGoMethod(importer *goImporter, methodDescriptor protoreflect.MethodDescriptor) (*goMethod, error) {
+	inputType, err := getGoType(importer, methodDescriptor.Input())
+	if err != nil {
+		return nil, err
+	}
+	outputType, err := getGoType(importer, methodDescriptor.Output())
+	if err != nil {
+		return nil, err
+	}
+	method := &goMethod{
+		Name:     string(methodDescriptor.Name()),
+		FullName: string(methodDescriptor.FullName()),
+	}
+	switch {
+	case methodDescriptor.IsStreamingClient() && methodDescriptor.IsStreamingServer():
+		method.Parameters = fmt.Sprintf("ctx context.Context, stream *connect.BidiStream[%s, %s]", inputType, outputType)
+		method.Results = "error"
+	case methodDescriptor.IsStreamingClient():
+		method.Parameters = fmt.Sprintf("ctx context.Context, stream *connect.ClientStream[%s]", inputType)
+		method.Results = fmt.Sprintf("(*connect.Response[%s], error)", outputType)
+		method.ZeroResults = "nil, "
+	case methodDescriptor.IsStreamingServer():
+		method.Parameters = fmt.Sprintf("ctx context.Context, request *connect.Request[%s], stream *connect.ServerStream[%s]", inputType, outputType)
+		method.Results = "error"
+	default:
+		method.Parameters = fmt.Sprintf("ctx context.Context, request *connect.Request[%s]", inputType)
+		method.Results = fmt.Sprintf("(*connect.Response[%s], error)", outputType)
+		method.ZeroResults = "nil, "
+	}
+	return method, nil
+}
+
+// getGoType returns the Go type generated by protoc-gen-go for the message, qualified
+// by the alias of its package.
+func getGoType(importer *goImporter, messageDescriptor protoreflect.MessageDescriptor) (string, error) {
+	goImportPath, goPackageName, err := getGoImportPathAndPackageName(messageDescriptor.ParentFile())
+	if err != nil {
+		return "", err
+	}
+	// Nested messages are named Parent_Child.
+	relativeName := strings.TrimPrefix(
+		string(messageDescriptor.FullName()),
+		string(messageDescriptor.ParentFile().Package())+".",
+	)
+	return importer.alias(goImportPath, goPackageName) + "." + strings.ReplaceAll(relativeName, ".", "_"), nil
+}
+
+// getGoImportPathAndPackageName returns the import path and package name of the code
+// generated by protoc-gen-go for the file, from the go_package option.
+func getGoImportPathAndPackageName(fileDescriptor protoreflect.FileDescriptor) (string, string, error) {
+	fileOptions, _ := fileDescriptor.Options().(*descriptorpb.FileOptions)
+	goPackage := fileOptions.GetGoPackage()
+	if goPackage == "" {
+		return "", "", fmt.Errorf("%s: go_package is not set, which is required to scaffold a Go server", fileDescriptor.Path())
+	}
+	goImportPath, goPackageName, ok := strings.Cut(goPackage, ";")
+	if !ok {
+		goPackageName = goImportPath[strings.LastIndex(goImportPath, "/")+1:]
+	}
+	return goImportPath, sanitizeGoIdent(goPackageName), nil
+}
+
+// goImporter assigns unique aliases to imports.
+type goImporter struct {
+	imports     []*goImport
+	pathToAlias map[string]string
+	usedAliases map[string]struct{}
+}
+
+func newGoImporter() *goImporter {
+	usedAliases := make(map[string]struct{}, len(goReservedIdents))
+	for _, goReservedIdent := range goReservedIdents {
+		usedAliases[goReservedIdent] = struct{}{}
+	}
+	return &goImporter{
+		pathToAlias: make(map[string]string),
+		usedAliases: usedAliases,
+	}
+}
+
+// alias returns the alias of the import path, adding the import if it was not already added.
+func (i *goImporter) alias(path string, packageName string) string {
+	if alias, ok := i.pathToAlias[path]; ok {
+		return alias
+	}
+	alias := packageName
+	for n := 2; ; n++ {
+		if _, ok := i.usedAliases[alias]; !ok {
+			break
+		}
+		alias = packageName + strconv.Itoa(n)
+	}
+	i.usedAliases[alias] = struct{}{}
+	i.pathToAlias[path] = alias
+	i.imports = append(i.imports, &goImport{Alias: alias, Path: path})
+	return alias
+}
+
+// sanitizeGoIdent replaces all characters that are not valid in a Go identifier with underscores.
+func sanitizeGoIdent(s string) string {
+	ident := strings.Map(
+		func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		},
+		s,
+	)
+	if r, _ := utf8.DecodeRuneInString(ident); unicode.IsDigit(r) {
+		return "_" + ident
+	}
+	return ident
+}
+
+func lowerFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
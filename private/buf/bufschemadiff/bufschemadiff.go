@@ -0,0 +1,76 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufschemadiff computes the semantic differences between two schemas.
+package bufschemadiff
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// ChangeTypeAdded is an element that only exists in the to schema.
+	ChangeTypeAdded ChangeType = "added"
+	// ChangeTypeRemoved is an element that only exists in the from schema.
+	ChangeTypeRemoved ChangeType = "removed"
+	// ChangeTypeModified is an element that exists in both schemas with different properties.
+	ChangeTypeModified ChangeType = "modified"
+
+	// ElementTypeMessage is a message.
+	ElementTypeMessage ElementType = "message"
+	// ElementTypeField is a field of a message.
+	ElementTypeField ElementType = "field"
+	// ElementTypeEnum is an enum.
+	ElementTypeEnum ElementType = "enum"
+	// ElementTypeEnumValue is a value of an enum.
+	ElementTypeEnumValue ElementType = "enum_value"
+	// ElementTypeService is a service.
+	ElementTypeService ElementType = "service"
+	// ElementTypeMethod is a method of a service.
+	ElementTypeMethod ElementType = "method"
+	// ElementTypeExtension is an extension.
+	ElementTypeExtension ElementType = "extension"
+)
+
+// ChangeType is the type of a Change.
+type ChangeType string
+
+// ElementType is the type of element a Change applies to.
+type ElementType string
+
+// Change is a single semantic change between two schemas.
+type Change struct {
+	Type        ChangeType  `json:"type,omitempty"`
+	ElementType ElementType `json:"element_type,omitempty"`
+	// Name is the fully-qualified name of the element.
+	//
+	// Enum values are qualified by their enum instead of the enum's parent scope,
+	// so that values with the same name in different enums are distinguished.
+	Name string `json:"name,omitempty"`
+	// Details describe each property that changed, such as "number changed from 1 to 2".
+	//
+	// Only set for ChangeTypeModified.
+	Details []string `json:"details,omitempty"`
+}
+
+// Diff returns the Changes between the from and to files, sorted by name.
+//
+// Elements are matched by fully-qualified name. Only elements declared in the given
+// files are compared, so callers should exclude imports they do not wish to compare.
+func Diff(
+	fromFileDescriptors []protoreflect.FileDescriptor,
+	toFileDescriptors []protoreflect.FileDescriptor,
+) []*Change {
+	return diff(fromFileDescriptors, toFileDescriptors)
+}
@@ -0,0 +1,112 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufschemadiff
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	fromFileDescriptors := testCompile(t, "./testdata/from")
+	toFileDescriptors := testCompile(t, "./testdata/to")
+	assert.Equal(
+		t,
+		[]*Change{
+			{
+				Type:        ChangeTypeAdded,
+				ElementType: ElementTypeEnumValue,
+				Name:        "bufschemadiff.test.Kind.KIND_BIRD",
+			},
+			{
+				Type:        ChangeTypeModified,
+				ElementType: ElementTypeEnumValue,
+				Name:        "bufschemadiff.test.Kind.KIND_CAT",
+				Details:     []string{"number changed from 2 to 3"},
+			},
+			{
+				Type:        ChangeTypeModified,
+				ElementType: ElementTypeField,
+				Name:        "bufschemadiff.test.Pet.age",
+				Details:     []string{"type changed from int32 to int64"},
+			},
+			{
+				Type:        ChangeTypeAdded,
+				ElementType: ElementTypeField,
+				Name:        "bufschemadiff.test.Pet.kind",
+			},
+			{
+				Type:        ChangeTypeModified,
+				ElementType: ElementTypeField,
+				Name:        "bufschemadiff.test.Pet.labels",
+				Details:     []string{"type changed from map<string, string> to map<string, int32>"},
+			},
+			{
+				Type:        ChangeTypeModified,
+				ElementType: ElementTypeField,
+				Name:        "bufschemadiff.test.Pet.name",
+				Details:     []string{"number changed from 2 to 7"},
+			},
+			{
+				Type:        ChangeTypeModified,
+				ElementType: ElementTypeField,
+				Name:        "bufschemadiff.test.Pet.person",
+				Details:     []string{"oneof changed from owner to none"},
+			},
+			{
+				Type:        ChangeTypeRemoved,
+				ElementType: ElementTypeMethod,
+				Name:        "bufschemadiff.test.PetService.ListPets",
+			},
+			{
+				Type:        ChangeTypeAdded,
+				ElementType: ElementTypeMethod,
+				Name:        "bufschemadiff.test.PetService.WatchPets",
+			},
+			{
+				Type:        ChangeTypeRemoved,
+				ElementType: ElementTypeMessage,
+				Name:        "bufschemadiff.test.Removed",
+			},
+		},
+		Diff(fromFileDescriptors, toFileDescriptors),
+	)
+	assert.Empty(t, Diff(fromFileDescriptors, fromFileDescriptors))
+	changes := Diff(nil, toFileDescriptors)
+	require.NotEmpty(t, changes)
+	for _, change := range changes {
+		assert.Equal(t, ChangeTypeAdded, change.Type)
+	}
+}
+
+func testCompile(t *testing.T, dirPath string) []protoreflect.FileDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			ImportPaths: []string{dirPath},
+		},
+	}).Compile(context.Background(), "schema.proto")
+	require.NoError(t, err)
+	fileDescriptors := make([]protoreflect.FileDescriptor, len(files))
+	for i, file := range files {
+		fileDescriptors[i] = file
+	}
+	return fileDescriptors
+}
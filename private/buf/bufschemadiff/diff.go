@@ -0,0 +1,298 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufschemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type element struct {
+	elementType ElementType
+	// attributes are the comparable properties of the element, in a stable
+	// order for a given elementType.
+	attributes []*attribute
+}
+
+type attribute struct {
+	name  string
+	value string
+}
+
+func diff(
+	fromFileDescriptors []protoreflect.FileDescriptor,
+	toFileDescriptors []protoreflect.FileDescriptor,
+) []*Change {
+	fromNameToElement := getNameToElement(fromFileDescriptors)
+	toNameToElement := getNameToElement(toFileDescriptors)
+	names := make([]string, 0, len(fromNameToElement)+len(toNameToElement))
+	for name := range fromNameToElement {
+		names = append(names, name)
+	}
+	for name := range toNameToElement {
+		if _, ok := fromNameToElement[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var changes []*Change
+	for _, name := range names {
+		fromElement, inFrom := fromNameToElement[name]
+		toElement, inTo := toNameToElement[name]
+		switch {
+		case !inTo:
+			changes = append(changes, newChange(ChangeTypeRemoved, fromElement.elementType, name))
+		case !inFrom:
+			changes = append(changes, newChange(ChangeTypeAdded, toElement.elementType, name))
+		case fromElement.elementType != toElement.elementType:
+			// For example, a message was replaced by an enum with the same name.
+			changes = append(
+				changes,
+				newChange(ChangeTypeRemoved, fromElement.elementType, name),
+				newChange(ChangeTypeAdded, toElement.elementType, name),
+			)
+		default:
+			if details := getDetails(fromElement.attributes, toElement.attributes); len(details) > 0 {
+				change := newChange(ChangeTypeModified, toElement.elementType, name)
+				change.Details = details
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+func newChange(changeType ChangeType, elementType ElementType, name string) *Change {
+	return &Change{
+		Type:        changeType,
+		ElementType: elementType,
+		Name:        name,
+	}
+}
+
+func getDetails(fromAttributes []*attribute, toAttributes []*attribute) []string {
+	toNameToValue := make(map[string]string, len(toAttributes))
+	for _, toAttribute := range toAttributes {
+		toNameToValue[toAttribute.name] = toAttribute.value
+	}
+	var details []string
+	for _, fromAttribute := range fromAttributes {
+		toValue := toNameToValue[fromAttribute.name]
+		if fromAttribute.value != toValue {
+			details = append(
+				details,
+				fmt.Sprintf(
+					"%s changed from %s to %s",
+					fromAttribute.name,
+					attributeValueString(fromAttribute.value),
+					attributeValueString(toValue),
+				),
+			)
+		}
+	}
+	return details
+}
+
+func attributeValueString(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func getNameToElement(fileDescriptors []protoreflect.FileDescriptor) map[string]*element {
+	nameToElement := make(map[string]*element)
+	for _, fileDescriptor := range fileDescriptors {
+		addMessages(nameToElement, fileDescriptor.Messages())
+		addEnums(nameToElement, fileDescriptor.Enums())
+		addExtensions(nameToElement, fileDescriptor.Extensions())
+		services := fileDescriptor.Services()
+		for i := 0; i < services.Len(); i++ {
+			addService(nameToElement, services.Get(i))
+		}
+	}
+	return nameToElement
+}
+
+func addMessages(nameToElement map[string]*element, messageDescriptors protoreflect.MessageDescriptors) {
+	for i := 0; i < messageDescriptors.Len(); i++ {
+		messageDescriptor := messageDescriptors.Get(i)
+		// Map entries are described by the type of their map field.
+		if messageDescriptor.IsMapEntry() {
+			continue
+		}
+		nameToElement[string(messageDescriptor.FullName())] = &element{
+			elementType: ElementTypeMessage,
+			attributes:  getFileAttributes(messageDescriptor),
+		}
+		fieldDescriptors := messageDescriptor.Fields()
+		for j := 0; j < fieldDescriptors.Len(); j++ {
+			fieldDescriptor := fieldDescriptors.Get(j)
+			nameToElement[string(fieldDescriptor.FullName())] = &element{
+				elementType: ElementTypeField,
+				attributes:  getFieldAttributes(fieldDescriptor),
+			}
+		}
+		addMessages(nameToElement, messageDescriptor.Messages())
+		addEnums(nameToElement, messageDescriptor.Enums())
+		addExtensions(nameToElement, messageDescriptor.Extensions())
+	}
+}
+
+func addEnums(nameToElement map[string]*element, enumDescriptors protoreflect.EnumDescriptors) {
+	for i := 0; i < enumDescriptors.Len(); i++ {
+		enumDescriptor := enumDescriptors.Get(i)
+		nameToElement[string(enumDescriptor.FullName())] = &element{
+			elementType: ElementTypeEnum,
+			attributes:  getFileAttributes(enumDescriptor),
+		}
+		enumValueDescriptors := enumDescriptor.Values()
+		for j := 0; j < enumValueDescriptors.Len(); j++ {
+			enumValueDescriptor := enumValueDescriptors.Get(j)
+			// The full name of an enum value is a sibling of its enum, we qualify it by
+			// the enum instead so that it is clear which enum the value belongs to.
+			nameToElement[string(enumDescriptor.FullName())+"."+string(enumValueDescriptor.Name())] = &element{
+				elementType: ElementTypeEnumValue,
+				attributes: []*attribute{
+					{
+						name:  "number",
+						value: strconv.Itoa(int(enumValueDescriptor.Number())),
+					},
+				},
+			}
+		}
+	}
+}
+
+func addExtensions(nameToElement map[string]*element, extensionDescriptors protoreflect.ExtensionDescriptors) {
+	for i := 0; i < extensionDescriptors.Len(); i++ {
+		extensionDescriptor := extensionDescriptors.Get(i)
+		attributes := append(
+			getFileAttributes(extensionDescriptor),
+			&attribute{
+				name:  "extendee",
+				value: string(extensionDescriptor.ContainingMessage().FullName()),
+			},
+		)
+		nameToElement[string(extensionDescriptor.FullName())] = &element{
+			elementType: ElementTypeExtension,
+			attributes:  append(attributes, getFieldAttributes(extensionDescriptor)...),
+		}
+	}
+}
+
+func addService(nameToElement map[string]*element, serviceDescriptor protoreflect.ServiceDescriptor) {
+	nameToElement[string(serviceDescriptor.FullName())] = &element{
+		elementType: ElementTypeService,
+		attributes:  getFileAttributes(serviceDescriptor),
+	}
+	methodDescriptors := serviceDescriptor.Methods()
+	for i := 0; i < methodDescriptors.Len(); i++ {
+		methodDescriptor := methodDescriptors.Get(i)
+		nameToElement[string(methodDescriptor.FullName())] = &element{
+			elementType: ElementTypeMethod,
+			attributes: []*attribute{
+				{
+					name:  "input",
+					value: string(methodDescriptor.Input().FullName()),
+				},
+				{
+					name:  "output",
+					value: string(methodDescriptor.Output().FullName()),
+				},
+				{
+					name:  "client_streaming",
+					value: strconv.FormatBool(methodDescriptor.IsStreamingClient()),
+				},
+				{
+					name:  "server_streaming",
+					value: strconv.FormatBool(methodDescriptor.IsStreamingServer()),
+				},
+			},
+		}
+	}
+}
+
+// getFileAttributes returns the file attribute for top-level elements, so that
+// moving an element between files is reported.
+func getFileAttributes(descriptor protoreflect.Descriptor) []*attribute {
+	if _, ok := descriptor.Parent().(protoreflect.FileDescriptor); !ok {
+		return nil
+	}
+	return []*attribute{
+		{
+			name:  "file",
+			value: descriptor.ParentFile().Path(),
+		},
+	}
+}
+
+func getFieldAttributes(fieldDescriptor protoreflect.FieldDescriptor) []*attribute {
+	var oneofName string
+	if oneofDescriptor := fieldDescriptor.ContainingOneof(); oneofDescriptor != nil && !oneofDescriptor.IsSynthetic() {
+		oneofName = string(oneofDescriptor.Name())
+	}
+	presence := "implicit"
+	if fieldDescriptor.HasPresence() {
+		presence = "explicit"
+	}
+	return []*attribute{
+		{
+			name:  "number",
+			value: strconv.Itoa(int(fieldDescriptor.Number())),
+		},
+		{
+			name:  "type",
+			value: getFieldTypeString(fieldDescriptor),
+		},
+		{
+			name:  "cardinality",
+			value: fieldDescriptor.Cardinality().String(),
+		},
+		{
+			name:  "presence",
+			value: presence,
+		},
+		{
+			name:  "oneof",
+			value: oneofName,
+		},
+		{
+			name:  "json_name",
+			value: fieldDescriptor.JSONName(),
+		},
+	}
+}
+
+func getFieldTypeString(fieldDescriptor protoreflect.FieldDescriptor) string {
+	if fieldDescriptor.IsMap() {
+		return fmt.Sprintf(
+			"map<%s, %s>",
+			getFieldTypeString(fieldDescriptor.MapKey()),
+			getFieldTypeString(fieldDescriptor.MapValue()),
+		)
+	}
+	switch fieldDescriptor.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fieldDescriptor.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fieldDescriptor.Enum().FullName())
+	default:
+		return fieldDescriptor.Kind().String()
+	}
+}
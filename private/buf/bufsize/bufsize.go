@@ -0,0 +1,116 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufsize estimates the encoded size of messages.
+package bufsize
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// Unbounded is the Max of an Estimate for a message type whose encoded size
+	// has no upper bound, such as one with repeated, string, or bytes fields.
+	Unbounded = -1
+
+	// DefaultPresence is the default fraction of fields assumed to be set.
+	DefaultPresence = 1.0
+	// DefaultRepeatedCount is the default number of elements assumed for repeated and map fields.
+	DefaultRepeatedCount = 1
+	// DefaultStringLength is the default length in bytes assumed for string and bytes fields.
+	DefaultStringLength = 16
+	// DefaultMaxDepth is the default maximum depth of nested messages assumed to be set.
+	DefaultMaxDepth = 4
+)
+
+// Estimate is an estimate of the encoded size in bytes of a message type.
+type Estimate struct {
+	TypeName string `json:"type_name,omitempty"`
+	// Min is the size of the smallest valid message, which is zero unless the
+	// message has required fields.
+	Min int `json:"min"`
+	// Typical is the size of a message under the presence assumptions, or the
+	// mean size of the samples if any were given.
+	Typical int `json:"typical"`
+	// Max is the size of the largest message, or Unbounded.
+	Max int `json:"max"`
+	// Samples is the number of samples Typical was computed from.
+	Samples int `json:"samples,omitempty"`
+}
+
+// EstimateMessage estimates the encoded size of the message type.
+//
+// Typical values are assumed to be small, that is enums and bools are assumed to
+// encode as one byte and other varints as two bytes.
+func EstimateMessage(
+	messageDescriptor protoreflect.MessageDescriptor,
+	options ...EstimateOption,
+) *Estimate {
+	return estimateMessage(messageDescriptor, options...)
+}
+
+// EstimateOption is an option for EstimateMessage.
+type EstimateOption func(*estimateOptions)
+
+// EstimateWithPresence returns a new EstimateOption that sets the fraction of
+// fields, between 0 and 1, assumed to be set for the typical size.
+//
+// The default is DefaultPresence.
+func EstimateWithPresence(presence float64) EstimateOption {
+	return func(estimateOptions *estimateOptions) {
+		estimateOptions.presence = presence
+	}
+}
+
+// EstimateWithRepeatedCount returns a new EstimateOption that sets the number of
+// elements assumed for repeated and map fields for the typical size.
+//
+// The default is DefaultRepeatedCount.
+func EstimateWithRepeatedCount(repeatedCount int) EstimateOption {
+	return func(estimateOptions *estimateOptions) {
+		estimateOptions.repeatedCount = repeatedCount
+	}
+}
+
+// EstimateWithStringLength returns a new EstimateOption that sets the length in
+// bytes assumed for string and bytes fields for the typical size.
+//
+// The default is DefaultStringLength.
+func EstimateWithStringLength(stringLength int) EstimateOption {
+	return func(estimateOptions *estimateOptions) {
+		estimateOptions.stringLength = stringLength
+	}
+}
+
+// EstimateWithMaxDepth returns a new EstimateOption that sets the maximum depth
+// of nested messages assumed to be set for the typical size.
+//
+// The default is DefaultMaxDepth.
+func EstimateWithMaxDepth(maxDepth int) EstimateOption {
+	return func(estimateOptions *estimateOptions) {
+		estimateOptions.maxDepth = maxDepth
+	}
+}
+
+// EstimateWithSamples returns a new EstimateOption that computes the typical size
+// as the mean encoded size of the sample messages, instead of from the presence
+// assumptions.
+//
+// The samples must be of the estimated message type.
+func EstimateWithSamples(samples ...proto.Message) EstimateOption {
+	return func(estimateOptions *estimateOptions) {
+		estimateOptions.samples = append(estimateOptions.samples, samples...)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufsize
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestEstimateFixed(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "test.proto", "Fixed")
+	assert.Equal(
+		t,
+		&Estimate{
+			TypeName: "bufsize.test.Fixed",
+			Min:      0,
+			Typical:  20,
+			Max:      31,
+		},
+		EstimateMessage(messageDescriptor),
+	)
+	assert.Equal(t, 10, EstimateMessage(messageDescriptor, EstimateWithPresence(0.5)).Typical)
+}
+
+func TestEstimateNested(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "test.proto", "Nested")
+	estimate := EstimateMessage(messageDescriptor)
+	assert.Equal(t, 0, estimate.Min)
+	// fixed: 1+1+20, values: 1+1+2, name: 1+1+16, labels: 1+1+(1+1+16)+(1+2)
+	assert.Equal(t, 67, estimate.Typical)
+	assert.Equal(t, Unbounded, estimate.Max)
+	estimate = EstimateMessage(
+		messageDescriptor,
+		EstimateWithRepeatedCount(3),
+		EstimateWithStringLength(4),
+	)
+	// fixed: 1+1+20, values: 1+1+6, name: 1+1+4, labels: 3*(1+1+(1+1+4)+(1+2))
+	assert.Equal(t, 69, estimate.Typical)
+}
+
+func TestEstimateRecursive(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "test.proto", "Recursive")
+	estimate := EstimateMessage(messageDescriptor)
+	assert.Equal(t, 23, estimate.Typical)
+	assert.Equal(t, Unbounded, estimate.Max)
+	assert.Equal(t, 3, EstimateMessage(messageDescriptor, EstimateWithMaxDepth(0)).Typical)
+}
+
+func TestEstimateRequired(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "required.proto", "Required")
+	estimate := EstimateMessage(messageDescriptor)
+	// a: 1+1, b: 1+1, inner: 1+1+(1+1)
+	assert.Equal(t, 8, estimate.Min)
+	assert.Equal(t, Unbounded, estimate.Max)
+}
+
+func TestEstimateSamples(t *testing.T) {
+	t.Parallel()
+	messageDescriptor := testGetMessageDescriptor(t, "test.proto", "Fixed")
+	sample := dynamicpb.NewMessage(messageDescriptor)
+	sample.Set(messageDescriptor.Fields().ByName("c"), protoreflect.ValueOfUint64(1))
+	estimate := EstimateMessage(
+		messageDescriptor,
+		EstimateWithSamples(sample, dynamicpb.NewMessage(messageDescriptor)),
+	)
+	assert.Equal(t, 9, proto.Size(sample))
+	assert.Equal(t, 5, estimate.Typical)
+	assert.Equal(t, 2, estimate.Samples)
+	assert.Equal(t, 31, estimate.Max)
+}
+
+func testGetMessageDescriptor(t *testing.T, path string, name protoreflect.Name) protoreflect.MessageDescriptor {
+	files, err := (&protocompile.Compiler{
+		Resolver: &protocompile.SourceResolver{
+			ImportPaths: []string{"./testdata"},
+		},
+	}).Compile(context.Background(), path)
+	require.NoError(t, err)
+	messageDescriptor := files[0].Messages().ByName(name)
+	require.NotNil(t, messageDescriptor)
+	return messageDescriptor
+}
@@ -0,0 +1,291 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufsize
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	typicalSmallVarintSize = 1
+	typicalVarintSize      = 2
+)
+
+func estimateMessage(
+	messageDescriptor protoreflect.MessageDescriptor,
+	options ...EstimateOption,
+) *Estimate {
+	estimateOptions := newEstimateOptions()
+	for _, option := range options {
+		option(estimateOptions)
+	}
+	estimate := &Estimate{
+		TypeName: string(messageDescriptor.FullName()),
+		Min:      minMessageSize(messageDescriptor, make(map[protoreflect.FullName]struct{})),
+		Max:      maxMessageSize(messageDescriptor, make(map[protoreflect.FullName]struct{})),
+	}
+	if len(estimateOptions.samples) > 0 {
+		var total int
+		for _, sample := range estimateOptions.samples {
+			total += proto.Size(sample)
+		}
+		estimate.Typical = int(math.Round(float64(total) / float64(len(estimateOptions.samples))))
+		estimate.Samples = len(estimateOptions.samples)
+		return estimate
+	}
+	estimate.Typical = int(math.Round(estimateOptions.typicalMessageSize(messageDescriptor, 0)))
+	return estimate
+}
+
+// minMessageSize returns the size of the message with only its required fields set,
+// each to its smallest value.
+func minMessageSize(
+	messageDescriptor protoreflect.MessageDescriptor,
+	seen map[protoreflect.FullName]struct{},
+) int {
+	if _, ok := seen[messageDescriptor.FullName()]; ok {
+		// A message that recursively requires itself cannot be constructed.
+		return 0
+	}
+	seen[messageDescriptor.FullName()] = struct{}{}
+	defer delete(seen, messageDescriptor.FullName())
+	var size int
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Cardinality() != protoreflect.Required {
+			continue
+		}
+		switch field.Kind() {
+		case protoreflect.MessageKind:
+			size += protowire.SizeTag(field.Number()) + protowire.SizeBytes(minMessageSize(field.Message(), seen))
+		case protoreflect.GroupKind:
+			size += protowire.SizeTag(field.Number()) + protowire.SizeGroup(field.Number(), minMessageSize(field.Message(), seen))
+		case protoreflect.StringKind, protoreflect.BytesKind:
+			size += protowire.SizeTag(field.Number()) + protowire.SizeBytes(0)
+		default:
+			size += protowire.SizeTag(field.Number()) + scalarSize(field.Kind(), typicalSmallVarintSize)
+		}
+	}
+	return size
+}
+
+// maxMessageSize returns the size of the message with all of its fields set,
+// each to its largest value, or Unbounded.
+func maxMessageSize(
+	messageDescriptor protoreflect.MessageDescriptor,
+	seen map[protoreflect.FullName]struct{},
+) int {
+	if _, ok := seen[messageDescriptor.FullName()]; ok {
+		// Recursive messages can be nested arbitrarily deep.
+		return Unbounded
+	}
+	seen[messageDescriptor.FullName()] = struct{}{}
+	defer delete(seen, messageDescriptor.FullName())
+	var size int
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			// Oneofs are handled below, as only one of their fields can be set.
+			continue
+		}
+		fieldSize := maxFieldSize(field, seen)
+		if fieldSize == Unbounded {
+			return Unbounded
+		}
+		size += fieldSize
+	}
+	oneofs := messageDescriptor.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		var oneofSize int
+		oneofFields := oneof.Fields()
+		for j := 0; j < oneofFields.Len(); j++ {
+			fieldSize := maxFieldSize(oneofFields.Get(j), seen)
+			if fieldSize == Unbounded {
+				return Unbounded
+			}
+			if fieldSize > oneofSize {
+				oneofSize = fieldSize
+			}
+		}
+		size += oneofSize
+	}
+	return size
+}
+
+func maxFieldSize(
+	field protoreflect.FieldDescriptor,
+	seen map[protoreflect.FullName]struct{},
+) int {
+	if field.IsList() || field.IsMap() {
+		return Unbounded
+	}
+	switch field.Kind() {
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return Unbounded
+	case protoreflect.MessageKind:
+		messageSize := maxMessageSize(field.Message(), seen)
+		if messageSize == Unbounded {
+			return Unbounded
+		}
+		return protowire.SizeTag(field.Number()) + protowire.SizeBytes(messageSize)
+	case protoreflect.GroupKind:
+		messageSize := maxMessageSize(field.Message(), seen)
+		if messageSize == Unbounded {
+			return Unbounded
+		}
+		return protowire.SizeTag(field.Number()) + protowire.SizeGroup(field.Number(), messageSize)
+	default:
+		return protowire.SizeTag(field.Number()) + maxScalarSize(field.Kind())
+	}
+}
+
+type estimateOptions struct {
+	presence      float64
+	repeatedCount int
+	stringLength  int
+	maxDepth      int
+	samples       []proto.Message
+}
+
+func newEstimateOptions() *estimateOptions {
+	return &estimateOptions{
+		presence:      DefaultPresence,
+		repeatedCount: DefaultRepeatedCount,
+		stringLength:  DefaultStringLength,
+		maxDepth:      DefaultMaxDepth,
+	}
+}
+
+// typicalMessageSize returns the typical size of the message at the given depth.
+func (e *estimateOptions) typicalMessageSize(
+	messageDescriptor protoreflect.MessageDescriptor,
+	depth int,
+) float64 {
+	var size float64
+	fields := messageDescriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			continue
+		}
+		size += e.presence * e.typicalFieldSize(field, depth)
+	}
+	oneofs := messageDescriptor.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		// Any one of the fields is assumed to be equally likely to be set.
+		var oneofSize float64
+		oneofFields := oneof.Fields()
+		for j := 0; j < oneofFields.Len(); j++ {
+			oneofSize += e.typicalFieldSize(oneofFields.Get(j), depth)
+		}
+		size += e.presence * oneofSize / float64(oneofFields.Len())
+	}
+	return size
+}
+
+func (e *estimateOptions) typicalFieldSize(field protoreflect.FieldDescriptor, depth int) float64 {
+	tagSize := float64(protowire.SizeTag(field.Number()))
+	switch {
+	case field.IsMap():
+		entrySize := e.typicalFieldSize(field.MapKey(), depth) + e.typicalFieldSize(field.MapValue(), depth)
+		return float64(e.repeatedCount) * (tagSize + typicalBytesSize(entrySize))
+	case field.IsList():
+		valueSize, ok := e.typicalValueSize(field, depth)
+		if !ok {
+			return 0
+		}
+		if field.IsPacked() {
+			return tagSize + typicalBytesSize(float64(e.repeatedCount)*valueSize)
+		}
+		return float64(e.repeatedCount) * (tagSize + valueSize)
+	default:
+		valueSize, ok := e.typicalValueSize(field, depth)
+		if !ok {
+			return 0
+		}
+		return tagSize + valueSize
+	}
+}
+
+// typicalValueSize returns the typical size of a single value of the field without its tag.
+//
+// Returns false if the field is a message that is nested too deep to be set.
+func (e *estimateOptions) typicalValueSize(field protoreflect.FieldDescriptor, depth int) (float64, bool) {
+	switch field.Kind() {
+	case protoreflect.MessageKind:
+		if depth >= e.maxDepth {
+			return 0, false
+		}
+		return typicalBytesSize(e.typicalMessageSize(field.Message(), depth+1)), true
+	case protoreflect.GroupKind:
+		if depth >= e.maxDepth {
+			return 0, false
+		}
+		// The end group tag is the same size as the start group tag.
+		return e.typicalMessageSize(field.Message(), depth+1) + float64(protowire.SizeTag(field.Number())), true
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return float64(protowire.SizeBytes(e.stringLength)), true
+	case protoreflect.BoolKind, protoreflect.EnumKind:
+		return float64(scalarSize(field.Kind(), typicalSmallVarintSize)), true
+	default:
+		return float64(scalarSize(field.Kind(), typicalVarintSize)), true
+	}
+}
+
+// typicalBytesSize returns the size of length-delimited data of the given size.
+func typicalBytesSize(size float64) float64 {
+	return float64(protowire.SizeVarint(uint64(math.Round(size)))) + size
+}
+
+// scalarSize returns the size of a scalar of the kind, where varints are of the given size.
+func scalarSize(kind protoreflect.Kind, varintSize int) int {
+	switch kind {
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return protowire.SizeFixed32()
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return protowire.SizeFixed64()
+	default:
+		return varintSize
+	}
+}
+
+// maxScalarSize returns the largest size of a scalar of the kind.
+func maxScalarSize(kind protoreflect.Kind) int {
+	switch kind {
+	case protoreflect.BoolKind:
+		return 1
+	case protoreflect.Uint32Kind, protoreflect.Sint32Kind:
+		return protowire.SizeVarint(math.MaxUint32)
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint64Kind, protoreflect.Sint64Kind, protoreflect.EnumKind:
+		// Negative int32 and enum values are sign-extended to 64 bits.
+		return protowire.SizeVarint(math.MaxUint64)
+	default:
+		return scalarSize(kind, 0)
+	}
+}
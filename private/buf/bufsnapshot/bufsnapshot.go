@@ -0,0 +1,359 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufsnapshot contains logic for capturing the resolved state of a
+// workspace so that a later build can be verified against it, or so that the
+// dependencies and configuration of the workspace can be restored from it.
+package bufsnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/buflock"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage"
+)
+
+// V1Version is the only currently supported snapshot version.
+const V1Version = "v1"
+
+// Snapshot is the resolved state of a workspace.
+type Snapshot struct {
+	// Version is the version of the snapshot format.
+	Version string `json:"version,omitempty"`
+	// BufVersion is the version of buf that created the snapshot.
+	BufVersion string `json:"buf_version,omitempty"`
+	// Modules are the modules in the workspace, sorted by Key.
+	Modules []*Module `json:"modules,omitempty"`
+}
+
+// Module is the resolved state of a single module within a workspace.
+type Module struct {
+	// Name is the module identity, if the module is named.
+	Name string `json:"name,omitempty"`
+	// Directory is the directory of the module relative to the root of the
+	// workspace, if the module was built from a workspace and is not at the root.
+	Directory string `json:"directory,omitempty"`
+	// Digest is the b3 digest of the module.
+	//
+	// This covers the module sources, configuration, documentation, license,
+	// and dependency commits.
+	Digest string `json:"digest,omitempty"`
+	// Dependencies are the resolved dependencies of the module, sorted by name.
+	Dependencies []*Dependency `json:"dependencies,omitempty"`
+	// ConfigPath is the path of the configuration file of the module relative
+	// to Directory, if the configuration file was captured.
+	ConfigPath string `json:"config_path,omitempty"`
+	// Config is the content of the configuration file of the module, if the
+	// configuration file was captured.
+	Config string `json:"config,omitempty"`
+}
+
+// Key returns the key used to match modules between snapshots.
+//
+// This is the directory if set, otherwise the name.
+func (m *Module) Key() string {
+	if directory := normalizeDirectory(m.Directory); directory != "" {
+		return directory
+	}
+	return m.Name
+}
+
+// Dependency is a resolved dependency of a module.
+type Dependency struct {
+	// Name is the module identity of the dependency.
+	Name   string `json:"name,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Digest string `json:"digest,omitempty"`
+}
+
+// NewSnapshot returns a new Snapshot for the given Modules.
+func NewSnapshot(ctx context.Context, bufVersion string, modules []bufmodule.Module) (*Snapshot, error) {
+	snapshotModules := make([]*Module, 0, len(modules))
+	for _, module := range modules {
+		digest, err := bufmodule.ModuleDigestB3(ctx, module)
+		if err != nil {
+			return nil, err
+		}
+		snapshotModule := &Module{
+			Directory: normalizeDirectory(module.WorkspaceDirectory()),
+			Digest:    digest,
+		}
+		if moduleIdentity := module.ModuleIdentity(); moduleIdentity != nil {
+			snapshotModule.Name = moduleIdentity.IdentityString()
+		}
+		for _, modulePin := range module.DependencyModulePins() {
+			snapshotModule.Dependencies = append(
+				snapshotModule.Dependencies,
+				&Dependency{
+					Name:   modulePin.IdentityString(),
+					Commit: modulePin.Commit(),
+					Digest: modulePin.Digest(),
+				},
+			)
+		}
+		snapshotModules = append(snapshotModules, snapshotModule)
+	}
+	sort.Slice(
+		snapshotModules,
+		func(i int, j int) bool {
+			return snapshotModules[i].Key() < snapshotModules[j].Key()
+		},
+	)
+	return &Snapshot{
+		Version:    V1Version,
+		BufVersion: bufVersion,
+		Modules:    snapshotModules,
+	}, nil
+}
+
+// AddConfigs adds the content of the configuration file of each module in the
+// Snapshot, read from the workspace in the ReadBucket.
+//
+// The root of the ReadBucket must be the root of the workspace. Modules without
+// a configuration file are left as-is.
+func AddConfigs(ctx context.Context, readBucket storage.ReadBucket, snapshot *Snapshot) error {
+	for _, module := range snapshot.Modules {
+		directory, err := validateDirectory(module)
+		if err != nil {
+			return err
+		}
+		moduleReadBucket := readBucket
+		if directory != "" {
+			moduleReadBucket = storage.MapReadBucket(readBucket, storage.MapOnPrefix(directory))
+		}
+		configPath, err := bufconfig.ExistingConfigFilePath(ctx, moduleReadBucket)
+		if err != nil {
+			return err
+		}
+		if configPath == "" {
+			continue
+		}
+		data, err := storage.ReadPath(ctx, moduleReadBucket, configPath)
+		if err != nil {
+			return err
+		}
+		module.ConfigPath = configPath
+		module.Config = string(data)
+	}
+	return nil
+}
+
+// Restore writes the dependencies and configuration of each module in the
+// Snapshot to the workspace in the ReadWriteBucket.
+//
+// The buf.lock file of each module is rewritten to pin the dependencies in the
+// Snapshot. A buf.lock file is not created for a module without dependencies,
+// but an existing buf.lock file is emptied. The configuration file of each
+// module is rewritten if it was captured in the Snapshot. Sources are not
+// restored, use Diff to check them.
+//
+// The root of the ReadWriteBucket must be the root of the workspace. Returns the
+// paths written, relative to the root of the ReadWriteBucket.
+func Restore(ctx context.Context, readWriteBucket storage.ReadWriteBucket, snapshot *Snapshot) ([]string, error) {
+	var writtenPaths []string
+	for _, module := range snapshot.Modules {
+		directory, err := validateDirectory(module)
+		if err != nil {
+			return nil, err
+		}
+		moduleReadWriteBucket := readWriteBucket
+		if directory != "" {
+			moduleReadWriteBucket = storage.MapReadWriteBucket(readWriteBucket, storage.MapOnPrefix(directory))
+		}
+		if module.Config != "" {
+			configPath, err := normalpath.NormalizeAndValidate(module.ConfigPath)
+			if err != nil {
+				return nil, fmt.Errorf("module %q: invalid config path: %w", module.Key(), err)
+			}
+			if err := storage.PutPath(ctx, moduleReadWriteBucket, configPath, []byte(module.Config)); err != nil {
+				return nil, err
+			}
+			writtenPaths = append(writtenPaths, normalpath.Join(directory, configPath))
+		}
+		lockFileExists, err := storage.Exists(ctx, moduleReadWriteBucket, buflock.ExternalConfigFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(module.Dependencies) == 0 && !lockFileExists {
+			continue
+		}
+		lockConfig := &buflock.Config{
+			Dependencies: make([]buflock.Dependency, 0, len(module.Dependencies)),
+		}
+		for _, dependency := range module.Dependencies {
+			moduleIdentity, err := bufmoduleref.ModuleIdentityForString(dependency.Name)
+			if err != nil {
+				return nil, fmt.Errorf("module %q: invalid dependency: %w", module.Key(), err)
+			}
+			lockConfig.Dependencies = append(
+				lockConfig.Dependencies,
+				buflock.Dependency{
+					Remote:     moduleIdentity.Remote(),
+					Owner:      moduleIdentity.Owner(),
+					Repository: moduleIdentity.Repository(),
+					Commit:     dependency.Commit,
+					Digest:     dependency.Digest,
+				},
+			)
+		}
+		if err := buflock.WriteConfig(ctx, moduleReadWriteBucket, lockConfig); err != nil {
+			return nil, err
+		}
+		writtenPaths = append(writtenPaths, normalpath.Join(directory, buflock.ExternalConfigFilePath))
+	}
+	return writtenPaths, nil
+}
+
+// normalizeDirectory normalizes the workspace directory of a module, returning
+// empty if the module is at the root of the workspace.
+func normalizeDirectory(directory string) string {
+	if directory == "" {
+		return ""
+	}
+	directory = normalpath.Normalize(directory)
+	if directory == "." {
+		return ""
+	}
+	return directory
+}
+
+// validateDirectory returns the normalized directory of the module, validating
+// that it is a relative path within the workspace.
+func validateDirectory(module *Module) (string, error) {
+	directory := normalizeDirectory(module.Directory)
+	if directory == "" {
+		return "", nil
+	}
+	directory, err := normalpath.NormalizeAndValidate(directory)
+	if err != nil {
+		return "", fmt.Errorf("module %q: invalid directory: %w", module.Key(), err)
+	}
+	return directory, nil
+}
+
+// ReadSnapshot reads a Snapshot from the Reader.
+func ReadSnapshot(reader io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if err := encoding.UnmarshalJSONStrict(data, snapshot); err != nil {
+		return nil, fmt.Errorf("could not read snapshot: %w", err)
+	}
+	if snapshot.Version != V1Version {
+		return nil, fmt.Errorf("unknown snapshot version %q, expected %q", snapshot.Version, V1Version)
+	}
+	return snapshot, nil
+}
+
+// WriteSnapshot writes the Snapshot to the Writer as indented JSON.
+func WriteSnapshot(writer io.Writer, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(data, '\n'))
+	return err
+}
+
+// Diff returns the differences between the expected and actual Snapshots.
+//
+// The BufVersion is not compared, as the same workspace should resolve
+// identically across buf versions. An empty result means the Snapshots match.
+func Diff(expected *Snapshot, actual *Snapshot) []string {
+	var diffs []string
+	expectedModules := modulesByKey(expected.Modules)
+	actualModules := modulesByKey(actual.Modules)
+	for _, expectedModule := range expected.Modules {
+		key := expectedModule.Key()
+		actualModule, ok := actualModules[key]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("module %q is in the snapshot but not in the workspace", key))
+			continue
+		}
+		diffs = append(diffs, diffModule(key, expectedModule, actualModule)...)
+	}
+	for _, actualModule := range actual.Modules {
+		key := actualModule.Key()
+		if _, ok := expectedModules[key]; !ok {
+			diffs = append(diffs, fmt.Sprintf("module %q is in the workspace but not in the snapshot", key))
+		}
+	}
+	return diffs
+}
+
+func diffModule(key string, expected *Module, actual *Module) []string {
+	var diffs []string
+	if expected.Name != actual.Name {
+		diffs = append(diffs, fmt.Sprintf("module %q: name changed from %q to %q", key, expected.Name, actual.Name))
+	}
+	expectedDependencies := dependenciesByName(expected.Dependencies)
+	actualDependencies := dependenciesByName(actual.Dependencies)
+	for _, expectedDependency := range expected.Dependencies {
+		actualDependency, ok := actualDependencies[expectedDependency.Name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("module %q: dependency %q was removed", key, expectedDependency.Name))
+			continue
+		}
+		if expectedDependency.Commit != actualDependency.Commit || expectedDependency.Digest != actualDependency.Digest {
+			diffs = append(
+				diffs,
+				fmt.Sprintf(
+					"module %q: dependency %q changed from commit %q to commit %q",
+					key,
+					expectedDependency.Name,
+					expectedDependency.Commit,
+					actualDependency.Commit,
+				),
+			)
+		}
+	}
+	for _, actualDependency := range actual.Dependencies {
+		if _, ok := expectedDependencies[actualDependency.Name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("module %q: dependency %q was added", key, actualDependency.Name))
+		}
+	}
+	// Only report a digest change if the dependencies did not change, as
+	// dependency changes always result in a digest change.
+	if len(diffs) == 0 && expected.Digest != actual.Digest {
+		diffs = append(diffs, fmt.Sprintf("module %q: digest changed from %q to %q", key, expected.Digest, actual.Digest))
+	}
+	return diffs
+}
+
+func modulesByKey(modules []*Module) map[string]*Module {
+	keyToModule := make(map[string]*Module, len(modules))
+	for _, module := range modules {
+		keyToModule[module.Key()] = module
+	}
+	return keyToModule
+}
+
+func dependenciesByName(dependencies []*Dependency) map[string]*Dependency {
+	nameToDependency := make(map[string]*Dependency, len(dependencies))
+	for _, dependency := range dependencies {
+		nameToDependency[dependency.Name] = dependency
+	}
+	return nameToDependency
+}
@@ -0,0 +1,170 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufsnapshot
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWriteSnapshot(t *testing.T) {
+	t.Parallel()
+	snapshot := testNewSnapshot()
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, WriteSnapshot(buffer, snapshot))
+	readSnapshot, err := ReadSnapshot(buffer)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, readSnapshot)
+}
+
+func TestReadSnapshotUnknownVersion(t *testing.T) {
+	t.Parallel()
+	_, err := ReadSnapshot(strings.NewReader(`{"version":"v2"}`))
+	assert.ErrorContains(t, err, `unknown snapshot version "v2"`)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, Diff(testNewSnapshot(), testNewSnapshot()))
+
+	actual := testNewSnapshot()
+	actual.BufVersion = "2.0.0"
+	assert.Empty(t, Diff(testNewSnapshot(), actual))
+
+	actual = testNewSnapshot()
+	actual.Modules[0].Digest = "b3-changed"
+	assert.Equal(
+		t,
+		[]string{`module "a": digest changed from "b3-a" to "b3-changed"`},
+		Diff(testNewSnapshot(), actual),
+	)
+
+	actual = testNewSnapshot()
+	actual.Modules[0].Digest = "b3-changed"
+	actual.Modules[0].Dependencies[0].Commit = "commit2"
+	actual.Modules[0].Dependencies = append(
+		actual.Modules[0].Dependencies,
+		&Dependency{
+			Name:   "buf.build/acme/extra",
+			Commit: "commit3",
+			Digest: "shake256:extra",
+		},
+	)
+	assert.Equal(
+		t,
+		[]string{
+			`module "a": dependency "buf.build/acme/dep" changed from commit "commit1" to commit "commit2"`,
+			`module "a": dependency "buf.build/acme/extra" was added`,
+		},
+		Diff(testNewSnapshot(), actual),
+	)
+
+	actual = testNewSnapshot()
+	actual.Modules[1].Directory = "c"
+	assert.Equal(
+		t,
+		[]string{
+			`module "b" is in the snapshot but not in the workspace`,
+			`module "c" is in the workspace but not in the snapshot`,
+		},
+		Diff(testNewSnapshot(), actual),
+	)
+}
+
+func TestAddConfigsAndRestore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	readWriteBucket := storagemem.NewReadWriteBucket()
+	require.NoError(t, storage.PutPath(ctx, readWriteBucket, "a/buf.yaml", []byte("version: v1\nname: buf.build/acme/a\n")))
+	require.NoError(t, storage.PutPath(ctx, readWriteBucket, "b/buf.lock", []byte("version: v1\n")))
+	snapshot := testNewSnapshot()
+	require.NoError(t, AddConfigs(ctx, readWriteBucket, snapshot))
+	assert.Equal(t, "buf.yaml", snapshot.Modules[0].ConfigPath)
+	assert.Equal(t, "version: v1\nname: buf.build/acme/a\n", snapshot.Modules[0].Config)
+	assert.Empty(t, snapshot.Modules[1].ConfigPath)
+
+	restoreBucket := storagemem.NewReadWriteBucket()
+	require.NoError(t, storage.PutPath(ctx, restoreBucket, "a/buf.yaml", []byte("version: v1\n")))
+	require.NoError(t, storage.PutPath(ctx, restoreBucket, "b/buf.lock", []byte("version: v1\n")))
+	writtenPaths, err := Restore(ctx, restoreBucket, snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/buf.yaml", "a/buf.lock", "b/buf.lock"}, writtenPaths)
+	data, err := storage.ReadPath(ctx, restoreBucket, "a/buf.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "version: v1\nname: buf.build/acme/a\n", string(data))
+	data, err = storage.ReadPath(ctx, restoreBucket, "a/buf.lock")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "repository: dep")
+	assert.Contains(t, string(data), "commit: commit1")
+	data, err = storage.ReadPath(ctx, restoreBucket, "b/buf.lock")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "commit:")
+}
+
+func TestAddConfigsRootDirectory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	readWriteBucket := storagemem.NewReadWriteBucket()
+	require.NoError(t, storage.PutPath(ctx, readWriteBucket, "buf.yaml", []byte("version: v1\n")))
+	snapshot := &Snapshot{
+		Version: V1Version,
+		Modules: []*Module{
+			{
+				Name:      "buf.build/acme/a",
+				Directory: ".",
+			},
+		},
+	}
+	assert.Equal(t, "buf.build/acme/a", snapshot.Modules[0].Key())
+	require.NoError(t, AddConfigs(ctx, readWriteBucket, snapshot))
+	assert.Equal(t, "buf.yaml", snapshot.Modules[0].ConfigPath)
+
+	snapshot.Modules[0].Directory = "../a"
+	assert.ErrorContains(t, AddConfigs(ctx, readWriteBucket, snapshot), "invalid directory")
+	_, err := Restore(ctx, readWriteBucket, snapshot)
+	assert.ErrorContains(t, err, "invalid directory")
+}
+
+func testNewSnapshot() *Snapshot {
+	return &Snapshot{
+		Version:    V1Version,
+		BufVersion: "1.0.0",
+		Modules: []*Module{
+			{
+				Name:      "buf.build/acme/a",
+				Directory: "a",
+				Digest:    "b3-a",
+				Dependencies: []*Dependency{
+					{
+						Name:   "buf.build/acme/dep",
+						Commit: "commit1",
+						Digest: "shake256:dep",
+					},
+				},
+			},
+			{
+				Directory: "b",
+				Digest:    "b3-b",
+			},
+		},
+	}
+}
@@ -22,10 +22,12 @@ import (
 	"github.com/bufbuild/buf/private/buf/bufsync"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas/bufcasalpha"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/cache"
 	"github.com/bufbuild/buf/private/pkg/git"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"go.uber.org/zap"
@@ -52,9 +54,9 @@ type syncHandler struct {
 	repositoryTagServiceClientFactory    RepositoryTagServiceClientFactory
 	repositoryCommitServiceClientFactory RepositoryCommitServiceClientFactory
 
-	moduleIdentityToRepositoryIDCache  map[string]string
-	moduleIdentityToDefaultBranchCache map[string]string
-	existingModuleIdentityCache        map[string]struct{}
+	moduleIdentityToRepositoryIDCache  *cache.SingleflightCache[string, string]
+	moduleIdentityToDefaultBranchCache *cache.SingleflightCache[string, string]
+	existingModuleIdentityCache        *cache.SingleflightCache[string, struct{}]
 }
 
 func newSyncHandler(
@@ -74,9 +76,9 @@ func newSyncHandler(
 		container:                            container,
 		repo:                                 repo,
 		createWithVisibility:                 createWithVisibility,
-		moduleIdentityToRepositoryIDCache:    make(map[string]string),
-		moduleIdentityToDefaultBranchCache:   make(map[string]string),
-		existingModuleIdentityCache:          make(map[string]struct{}),
+		moduleIdentityToRepositoryIDCache:    cache.NewSingleflightCache[string, string](),
+		moduleIdentityToDefaultBranchCache:   cache.NewSingleflightCache[string, string](),
+		existingModuleIdentityCache:          cache.NewSingleflightCache[string, struct{}](),
 		syncServiceClientFactory:             syncServiceClientFactory,
 		referenceServiceClientFactory:        referenceServiceClientFactory,
 		repositoryServiceClientFactory:       repositoryServiceClientFactory,
@@ -304,22 +306,29 @@ func (h *syncHandler) IsReleaseBranch(
 ) (bool, error) {
 	// We cache a repository's release branch even though it can change because it's _extremely_ unlikely that it changes.
 	cacheKey := moduleIdentity.IdentityString()
-	if _, ok := h.moduleIdentityToDefaultBranchCache[cacheKey]; !ok {
-		service := h.repositoryServiceClientFactory(moduleIdentity.Remote())
-		res, err := service.GetRepositoryByFullName(ctx, connect.NewRequest(&registryv1alpha1.GetRepositoryByFullNameRequest{
-			FullName: moduleIdentity.Owner() + "/" + moduleIdentity.Repository(),
-		}))
-		if err != nil {
-			if connect.CodeOf(err) == connect.CodeNotFound {
-				// Repo not created, no branch is protected because no branches exist. We cache this
-				// because it shouldn't change during the lifetime of sync.
-				h.moduleIdentityToDefaultBranchCache[cacheKey] = ""
+	defaultBranch, err := h.moduleIdentityToDefaultBranchCache.GetOrAdd(
+		ctx,
+		cacheKey,
+		func(ctx context.Context) (string, error) {
+			service := h.repositoryServiceClientFactory(moduleIdentity.Remote())
+			res, err := service.GetRepositoryByFullName(ctx, connect.NewRequest(&registryv1alpha1.GetRepositoryByFullNameRequest{
+				FullName: moduleIdentity.Owner() + "/" + moduleIdentity.Repository(),
+			}))
+			if err != nil {
+				return "", err
 			}
-			return false, fmt.Errorf("load repository %q: %w", cacheKey, err)
+			return res.Msg.Repository.DefaultBranch, nil
+		},
+	)
+	if err != nil {
+		if connect.CodeOf(err) == connect.CodeNotFound {
+			// Repo not created, no branch is protected because no branches exist. We cache this
+			// because it shouldn't change during the lifetime of sync.
+			h.moduleIdentityToDefaultBranchCache.Add(cacheKey, "")
 		}
-		h.moduleIdentityToDefaultBranchCache[cacheKey] = res.Msg.Repository.DefaultBranch
+		return false, fmt.Errorf("load repository %q: %w", cacheKey, err)
 	}
-	return branchName == h.moduleIdentityToDefaultBranchCache[cacheKey], nil
+	return branchName == defaultBranch, nil
 }
 
 func (h *syncHandler) GetBranchHead(
@@ -398,21 +407,23 @@ func (h *syncHandler) IsBranchSynced(
 }
 
 func (h *syncHandler) getRepositoryID(ctx context.Context, moduleIdentity bufmoduleref.ModuleIdentity) (string, error) {
-	if repositoryID, hit := h.moduleIdentityToRepositoryIDCache[moduleIdentity.IdentityString()]; hit {
-		return repositoryID, nil
-	}
-	repoService := h.repositoryServiceClientFactory(moduleIdentity.Remote())
-	if repoRes, err := repoService.GetRepositoryByFullName(ctx, connect.NewRequest(&registryv1alpha1.GetRepositoryByFullNameRequest{
-		FullName: moduleIdentity.Owner() + "/" + moduleIdentity.Repository(),
-	})); err != nil {
-		if connect.CodeOf(err) == connect.CodeNotFound {
-			return "", fmt.Errorf("repository for module %q does not exist", moduleIdentity.IdentityString())
-		}
-		return "", fmt.Errorf("get repository for module identity: %w", err)
-	} else {
-		h.moduleIdentityToRepositoryIDCache[moduleIdentity.IdentityString()] = repoRes.Msg.Repository.Id
-	}
-	return h.moduleIdentityToRepositoryIDCache[moduleIdentity.IdentityString()], nil
+	return h.moduleIdentityToRepositoryIDCache.GetOrAdd(
+		ctx,
+		moduleIdentity.IdentityString(),
+		func(ctx context.Context) (string, error) {
+			repoService := h.repositoryServiceClientFactory(moduleIdentity.Remote())
+			repoRes, err := repoService.GetRepositoryByFullName(ctx, connect.NewRequest(&registryv1alpha1.GetRepositoryByFullNameRequest{
+				FullName: moduleIdentity.Owner() + "/" + moduleIdentity.Repository(),
+			}))
+			if err != nil {
+				if connect.CodeOf(err) == connect.CodeNotFound {
+					return "", fmt.Errorf("repository for module %q does not exist", moduleIdentity.IdentityString())
+				}
+				return "", fmt.Errorf("get repository for module identity: %w", err)
+			}
+			return repoRes.Msg.Repository.Id, nil
+		},
+	)
 }
 
 func (h *syncHandler) bsrTagExists(
@@ -451,7 +462,7 @@ func (h *syncHandler) syncCommitModule(
 	if err != nil {
 		return nil, err
 	}
-	resp, err := service.SyncGitCommit(ctx, connect.NewRequest(&registryv1alpha1.SyncGitCommitRequest{
+	request := connect.NewRequest(&registryv1alpha1.SyncGitCommitRequest{
 		Owner:      moduleIdentity.Owner(),
 		Repository: moduleIdentity.Repository(),
 		Manifest:   protoManifestBlob,
@@ -469,7 +480,15 @@ func (h *syncHandler) syncCommitModule(
 			Email: commit.Committer().Email(),
 			Time:  timestamppb.New(commit.Committer().Timestamp()),
 		},
-	}))
+	})
+	// The key identifies this request, so that a registry that receives it more than
+	// once, such as when it is retried by a proxy, only syncs the commit once.
+	idempotencyKey, err := bufconnect.NewIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	request.Header().Set(bufconnect.IdempotencyKeyHeaderName, idempotencyKey)
+	resp, err := service.SyncGitCommit(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -480,22 +499,25 @@ func (h *syncHandler) createRepository(
 	ctx context.Context,
 	moduleIdentity bufmoduleref.ModuleIdentity,
 ) error {
-	if _, alreadyExists := h.existingModuleIdentityCache[moduleIdentity.IdentityString()]; alreadyExists {
-		return nil
-	}
-	service := h.repositoryServiceClientFactory(moduleIdentity.Remote())
-	fullName := moduleIdentity.Owner() + "/" + moduleIdentity.Repository()
-	_, err := service.CreateRepositoryByFullName(
+	// if created successfully or if it already existed, cache it
+	_, err := h.existingModuleIdentityCache.GetOrAdd(
 		ctx,
-		connect.NewRequest(&registryv1alpha1.CreateRepositoryByFullNameRequest{
-			FullName:   fullName,
-			Visibility: *h.createWithVisibility,
-		}),
+		moduleIdentity.IdentityString(),
+		func(ctx context.Context) (struct{}, error) {
+			service := h.repositoryServiceClientFactory(moduleIdentity.Remote())
+			fullName := moduleIdentity.Owner() + "/" + moduleIdentity.Repository()
+			_, err := service.CreateRepositoryByFullName(
+				ctx,
+				connect.NewRequest(&registryv1alpha1.CreateRepositoryByFullNameRequest{
+					FullName:   fullName,
+					Visibility: *h.createWithVisibility,
+				}),
+			)
+			if err != nil && connect.CodeOf(err) != connect.CodeAlreadyExists {
+				return struct{}{}, err
+			}
+			return struct{}{}, nil
+		},
 	)
-	if err != nil && connect.CodeOf(err) != connect.CodeAlreadyExists {
-		return err
-	}
-	// if created successfully or if it already existed, cache it
-	h.existingModuleIdentityCache[moduleIdentity.IdentityString()] = struct{}{}
-	return nil
+	return err
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/cache"
 	"github.com/bufbuild/buf/private/pkg/git"
 )
 
@@ -38,22 +39,27 @@ type isProtectedBranchCacheKey struct {
 	branchName           string
 }
 
+type isReleaseBranchCacheKey struct {
+	moduleIdentityString string
+	branchName           string
+}
+
 type cachedHandler struct {
 	delegate Handler
 
-	isBranchSyncedCache    map[isBranchSyncedCacheKey]struct{}
-	isGitCommitSynedCache  map[isGitCommitSyncedCacheKey]struct{}
-	isProtectedBranchCache map[isProtectedBranchCacheKey]bool
-	isReleaseBranchCache   map[string]bool
+	isBranchSyncedCache    *cache.SingleflightCache[isBranchSyncedCacheKey, bool]
+	isGitCommitSynedCache  *cache.SingleflightCache[isGitCommitSyncedCacheKey, bool]
+	isProtectedBranchCache *cache.SingleflightCache[isProtectedBranchCacheKey, bool]
+	isReleaseBranchCache   *cache.SingleflightCache[isReleaseBranchCacheKey, bool]
 }
 
 func newCachedHandler(delegate Handler) *cachedHandler {
 	return &cachedHandler{
 		delegate:               delegate,
-		isBranchSyncedCache:    make(map[isBranchSyncedCacheKey]struct{}),
-		isGitCommitSynedCache:  make(map[isGitCommitSyncedCacheKey]struct{}),
-		isProtectedBranchCache: make(map[isProtectedBranchCacheKey]bool),
-		isReleaseBranchCache:   make(map[string]bool),
+		isBranchSyncedCache:    cache.NewSingleflightCache[isBranchSyncedCacheKey, bool](),
+		isGitCommitSynedCache:  cache.NewSingleflightCache[isGitCommitSyncedCacheKey, bool](),
+		isProtectedBranchCache: cache.NewSingleflightCache[isProtectedBranchCacheKey, bool](),
+		isReleaseBranchCache:   cache.NewSingleflightCache[isReleaseBranchCacheKey, bool](),
 	}
 }
 
@@ -74,18 +80,17 @@ func (c *cachedHandler) IsBranchSynced(
 ) (bool, error) {
 	// Only synced branches can be cached, as non-synced branches may become synced
 	// during the lifetime of Sync or across Sync runs.
-	cacheKey := isBranchSyncedCacheKey{
-		moduleIdentityString: moduleIdentity.IdentityString(),
-		branchName:           branchName,
-	}
-	if _, ok := c.isBranchSyncedCache[cacheKey]; ok {
-		return true, nil
-	}
-	yes, err := c.delegate.IsBranchSynced(ctx, moduleIdentity, branchName)
-	if err != nil && yes {
-		c.isBranchSyncedCache[cacheKey] = struct{}{}
-	}
-	return yes, err
+	return c.isBranchSyncedCache.GetOrAddIf(
+		ctx,
+		isBranchSyncedCacheKey{
+			moduleIdentityString: moduleIdentity.IdentityString(),
+			branchName:           branchName,
+		},
+		func(ctx context.Context) (bool, error) {
+			return c.delegate.IsBranchSynced(ctx, moduleIdentity, branchName)
+		},
+		isTrue,
+	)
 }
 
 func (c *cachedHandler) IsGitCommitSynced(
@@ -95,18 +100,17 @@ func (c *cachedHandler) IsGitCommitSynced(
 ) (bool, error) {
 	// Only synced commits can be cached, as non-synced commits may become synced during
 	// the lifetime of Sync or across Sync runs.
-	cacheKey := isGitCommitSyncedCacheKey{
-		moduleIdentityString: moduleIdentity.IdentityString(),
-		gitHash:              hash.Hex(),
-	}
-	if _, ok := c.isGitCommitSynedCache[cacheKey]; ok {
-		return true, nil
-	}
-	yes, err := c.delegate.IsGitCommitSynced(ctx, moduleIdentity, hash)
-	if err != nil && yes {
-		c.isGitCommitSynedCache[cacheKey] = struct{}{}
-	}
-	return yes, err
+	return c.isGitCommitSynedCache.GetOrAddIf(
+		ctx,
+		isGitCommitSyncedCacheKey{
+			moduleIdentityString: moduleIdentity.IdentityString(),
+			gitHash:              hash.Hex(),
+		},
+		func(ctx context.Context) (bool, error) {
+			return c.delegate.IsGitCommitSynced(ctx, moduleIdentity, hash)
+		},
+		isTrue,
+	)
 }
 
 func (c *cachedHandler) IsGitCommitSyncedToBranch(
@@ -117,22 +121,27 @@ func (c *cachedHandler) IsGitCommitSyncedToBranch(
 ) (bool, error) {
 	// Only synced commits on branches can be cached, as non-synced commits may
 	// become synced to the branch during the lifetime of Sync or across Sync runs.
-	cacheKey := isGitCommitSyncedCacheKey{
-		moduleIdentityString: moduleIdentity.IdentityString(),
-		branchName:           branchName,
-		gitHash:              hash.Hex(),
-	}
-	if _, ok := c.isGitCommitSynedCache[cacheKey]; ok {
-		return true, nil
-	}
-	yes, err := c.delegate.IsGitCommitSyncedToBranch(ctx, moduleIdentity, branchName, hash)
-	if err != nil && yes {
-		c.isGitCommitSynedCache[cacheKey] = struct{}{}
-		// also cache that the commit is synced in general
-		c.isGitCommitSynedCache[isGitCommitSyncedCacheKey{
+	yes, err := c.isGitCommitSynedCache.GetOrAddIf(
+		ctx,
+		isGitCommitSyncedCacheKey{
 			moduleIdentityString: moduleIdentity.IdentityString(),
+			branchName:           branchName,
 			gitHash:              hash.Hex(),
-		}] = struct{}{}
+		},
+		func(ctx context.Context) (bool, error) {
+			return c.delegate.IsGitCommitSyncedToBranch(ctx, moduleIdentity, branchName, hash)
+		},
+		isTrue,
+	)
+	if err == nil && yes {
+		// also cache that the commit is synced in general
+		c.isGitCommitSynedCache.Add(
+			isGitCommitSyncedCacheKey{
+				moduleIdentityString: moduleIdentity.IdentityString(),
+				gitHash:              hash.Hex(),
+			},
+			true,
+		)
 	}
 	return yes, err
 }
@@ -144,15 +153,16 @@ func (c *cachedHandler) IsReleaseBranch(
 ) (bool, error) {
 	// All branch protection status can be cached, as this is _extremely_ unlikely to change
 	// during the lifetime of Sync or across Sync runs.
-	cacheKey := moduleIdentity.IdentityString()
-	if value, cached := c.isReleaseBranchCache[cacheKey]; cached {
-		return value, nil
-	}
-	yes, err := c.delegate.IsReleaseBranch(ctx, moduleIdentity, branchName)
-	if err != nil {
-		c.isReleaseBranchCache[cacheKey] = yes
-	}
-	return yes, err
+	return c.isReleaseBranchCache.GetOrAdd(
+		ctx,
+		isReleaseBranchCacheKey{
+			moduleIdentityString: moduleIdentity.IdentityString(),
+			branchName:           branchName,
+		},
+		func(ctx context.Context) (bool, error) {
+			return c.delegate.IsReleaseBranch(ctx, moduleIdentity, branchName)
+		},
+	)
 }
 
 func (c *cachedHandler) IsProtectedBranch(
@@ -162,18 +172,16 @@ func (c *cachedHandler) IsProtectedBranch(
 ) (bool, error) {
 	// All branch protection status can be cached, as this is _extremely_ unlikely to change
 	// during the lifetime of Sync or across Sync runs.
-	cacheKey := isProtectedBranchCacheKey{
-		moduleIdentityString: moduleIdentity.IdentityString(),
-		branchName:           branchName,
-	}
-	if value, cached := c.isProtectedBranchCache[cacheKey]; cached {
-		return value, nil
-	}
-	isProtected, err := c.delegate.IsProtectedBranch(ctx, moduleIdentity, branchName)
-	if err != nil {
-		c.isProtectedBranchCache[cacheKey] = isProtected
-	}
-	return isProtected, err
+	return c.isProtectedBranchCache.GetOrAdd(
+		ctx,
+		isProtectedBranchCacheKey{
+			moduleIdentityString: moduleIdentity.IdentityString(),
+			branchName:           branchName,
+		},
+		func(ctx context.Context) (bool, error) {
+			return c.delegate.IsProtectedBranch(ctx, moduleIdentity, branchName)
+		},
+	)
 }
 
 func (c *cachedHandler) GetReleaseHead(
@@ -210,4 +218,8 @@ func (c *cachedHandler) SyncModuleTags(
 	return c.delegate.SyncModuleTags(ctx, moduleTags)
 }
 
+func isTrue(value bool) bool {
+	return value
+}
+
 var _ Handler = (*cachedHandler)(nil)
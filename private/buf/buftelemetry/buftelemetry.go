@@ -0,0 +1,334 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buftelemetry records and aggregates usage of the CLI.
+//
+// Usage is only recorded if enabled, and is recorded locally. It is only sent
+// anywhere if an endpoint is explicitly configured.
+package buftelemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"go.uber.org/multierr"
+)
+
+// Event is the usage of a single invocation of a command.
+type Event struct {
+	// Command is the full path of the command, such as "buf lint".
+	Command string `json:"command"`
+	// Time is when the command started.
+	Time time.Time `json:"time"`
+	// Duration is how long the command ran.
+	Duration time.Duration `json:"duration"`
+	// Success says whether the command succeeded.
+	Success bool `json:"success"`
+	// CacheHits is the number of modules read from the module cache.
+	CacheHits int `json:"cache_hits,omitempty"`
+	// CacheMisses is the number of modules not found in the module cache.
+	CacheMisses int `json:"cache_misses,omitempty"`
+}
+
+// CacheStats counts module cache hits and misses.
+//
+// CacheStats is safe for concurrent use.
+type CacheStats struct {
+	lock   sync.RWMutex
+	hits   int
+	misses int
+}
+
+// NewCacheStats returns a new CacheStats.
+func NewCacheStats() *CacheStats {
+	return &CacheStats{}
+}
+
+// MarkHit records a cache hit.
+func (s *CacheStats) MarkHit() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hits++
+}
+
+// MarkMiss records a cache miss.
+func (s *CacheStats) MarkMiss() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.misses++
+}
+
+// Hits returns the number of cache hits.
+func (s *CacheStats) Hits() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.hits
+}
+
+// Misses returns the number of cache misses.
+func (s *CacheStats) Misses() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.misses
+}
+
+// MaxEventsFileSize is the size in bytes at which the events file is rotated.
+//
+// When appending an Event would grow the file past this size, the file is moved
+// to the rotated path, replacing any previously rotated file, and a new file is
+// started. At most twice this size is kept on disk.
+const MaxEventsFileSize = 1 << 20
+
+// RotatedEventsFilePath returns the path that the events file at filePath is
+// rotated to.
+func RotatedEventsFilePath(filePath string) string {
+	return filePath + ".1"
+}
+
+// AppendEvent appends the Event to the file at the path, creating the file and its
+// directory if they do not exist.
+//
+// Events are written as JSON, one per line. The file is rotated once it reaches
+// MaxEventsFileSize. The file is locked while appending and rotating, so that
+// concurrent invocations do not lose events.
+func AppendEvent(ctx context.Context, filePath string, event *Event) error {
+	return appendEvent(ctx, filePath, event, MaxEventsFileSize)
+}
+
+// ReadEventsFile reads the Events from the file at the path and from its rotated
+// file, oldest first.
+//
+// Files that do not exist are skipped.
+func ReadEventsFile(filePath string) ([]*Event, error) {
+	var events []*Event
+	for _, path := range []string{RotatedEventsFilePath(filePath), filePath} {
+		fileEvents, err := readEventsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, fileEvents...)
+	}
+	return events, nil
+}
+
+// ReadEvents reads the Events written by AppendEvent.
+//
+// Lines that cannot be parsed, such as those partially written by an interrupted
+// invocation, are skipped.
+func ReadEvents(reader io.Reader) ([]*Event, error) {
+	var events []*Event
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		event := &Event{}
+		if err := json.Unmarshal(line, event); err != nil || event.Command == "" {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// SendEvent sends the Event as JSON in the body of a POST request to the endpoint.
+func SendEvent(ctx context.Context, client *http.Client, endpoint string, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("telemetry endpoint returned %s", response.Status)
+	}
+	return nil
+}
+
+// CommandReport is the aggregated usage of a single command.
+type CommandReport struct {
+	Command string `json:"command"`
+	// Count is the number of invocations.
+	Count int `json:"count"`
+	// Failures is the number of invocations that did not succeed.
+	Failures int `json:"failures"`
+	// TotalDuration is the sum of the durations of all invocations.
+	TotalDuration time.Duration `json:"total_duration"`
+	// MeanDuration is the mean duration of the invocations.
+	MeanDuration time.Duration `json:"mean_duration"`
+	// MaxDuration is the longest duration of the invocations.
+	MaxDuration time.Duration `json:"max_duration"`
+	CacheHits   int           `json:"cache_hits"`
+	CacheMisses int           `json:"cache_misses"`
+}
+
+// CacheHitRate returns the fraction of module cache reads that were hits.
+//
+// Returns false if there were no module cache reads.
+func (c *CommandReport) CacheHitRate() (float64, bool) {
+	return cacheHitRate(c.CacheHits, c.CacheMisses)
+}
+
+// Report is the aggregated usage of all commands.
+type Report struct {
+	// Since is the time of the earliest Event. Zero if there are no Events.
+	Since time.Time `json:"since"`
+	// Commands are sorted by descending TotalDuration, then by Command.
+	Commands    []*CommandReport `json:"commands"`
+	Count       int              `json:"count"`
+	Failures    int              `json:"failures"`
+	CacheHits   int              `json:"cache_hits"`
+	CacheMisses int              `json:"cache_misses"`
+}
+
+// CacheHitRate returns the fraction of module cache reads that were hits.
+//
+// Returns false if there were no module cache reads.
+func (r *Report) CacheHitRate() (float64, bool) {
+	return cacheHitRate(r.CacheHits, r.CacheMisses)
+}
+
+// NewReport aggregates the Events.
+//
+// Only Events at or after since are included. If since is zero, all Events are included.
+func NewReport(events []*Event, since time.Time) *Report {
+	report := &Report{}
+	commandToCommandReport := make(map[string]*CommandReport)
+	for _, event := range events {
+		if event.Time.Before(since) {
+			continue
+		}
+		commandReport, ok := commandToCommandReport[event.Command]
+		if !ok {
+			commandReport = &CommandReport{
+				Command: event.Command,
+			}
+			commandToCommandReport[event.Command] = commandReport
+			report.Commands = append(report.Commands, commandReport)
+		}
+		commandReport.Count++
+		commandReport.TotalDuration += event.Duration
+		if event.Duration > commandReport.MaxDuration {
+			commandReport.MaxDuration = event.Duration
+		}
+		commandReport.CacheHits += event.CacheHits
+		commandReport.CacheMisses += event.CacheMisses
+		report.Count++
+		report.CacheHits += event.CacheHits
+		report.CacheMisses += event.CacheMisses
+		if !event.Success {
+			commandReport.Failures++
+			report.Failures++
+		}
+		if report.Since.IsZero() || event.Time.Before(report.Since) {
+			report.Since = event.Time
+		}
+	}
+	for _, commandReport := range report.Commands {
+		commandReport.MeanDuration = commandReport.TotalDuration / time.Duration(commandReport.Count)
+	}
+	sort.Slice(
+		report.Commands,
+		func(i int, j int) bool {
+			if report.Commands[i].TotalDuration != report.Commands[j].TotalDuration {
+				return report.Commands[i].TotalDuration > report.Commands[j].TotalDuration
+			}
+			return report.Commands[i].Command < report.Commands[j].Command
+		},
+	)
+	return report
+}
+
+func cacheHitRate(hits int, misses int) (float64, bool) {
+	if hits+misses == 0 {
+		return 0, false
+	}
+	return float64(hits) / float64(hits+misses), true
+}
+
+func appendEvent(ctx context.Context, filePath string, event *Event, maxFileSize int64) (retErr error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	unlocker, err := filelock.Lock(ctx, eventsLockFilePath(filePath))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, unlocker.Unlock())
+	}()
+	if fileInfo, err := os.Stat(filePath); err == nil && fileInfo.Size()+int64(len(data)) > maxFileSize {
+		if err := os.Rename(filePath, RotatedEventsFilePath(filePath)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	_, err = file.Write(data)
+	return err
+}
+
+// eventsLockFilePath returns the path of the lock file of the events file at filePath.
+func eventsLockFilePath(filePath string) string {
+	return filePath + ".lock"
+}
+
+func readEventsFile(filePath string) (_ []*Event, retErr error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	return ReadEvents(file)
+}
@@ -0,0 +1,259 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buftelemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendAndReadEvents(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "telemetry", "events.jsonl")
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []*Event{
+		{
+			Command:  "buf lint",
+			Time:     now,
+			Duration: time.Second,
+			Success:  true,
+		},
+		{
+			Command:     "buf build",
+			Time:        now.Add(time.Minute),
+			Duration:    2 * time.Second,
+			CacheHits:   3,
+			CacheMisses: 1,
+		},
+	}
+	for _, event := range events {
+		require.NoError(t, AppendEvent(context.Background(), filePath, event))
+	}
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+	readEvents, err := ReadEvents(file)
+	require.NoError(t, err)
+	assert.Equal(t, events, readEvents)
+}
+
+func TestAppendEventRotates(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "events.jsonl")
+	event := &Event{
+		Command:  "buf lint",
+		Time:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration: time.Second,
+	}
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	// Room for two events per file.
+	maxFileSize := int64(2 * (len(data) + 1))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, appendEvent(context.Background(), filePath, event, maxFileSize))
+	}
+	fileInfo, err := os.Stat(filePath)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, fileInfo.Size(), maxFileSize)
+	rotatedFileInfo, err := os.Stat(RotatedEventsFilePath(filePath))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, rotatedFileInfo.Size(), maxFileSize)
+	// The oldest events were dropped with the second rotation.
+	readEvents, err := ReadEventsFile(filePath)
+	require.NoError(t, err)
+	assert.Len(t, readEvents, 3)
+}
+
+func TestAppendEventConcurrent(t *testing.T) {
+	t.Parallel()
+	filePath := filepath.Join(t.TempDir(), "events.jsonl")
+	event := &Event{
+		Command:  "buf lint",
+		Time:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration: time.Second,
+	}
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	// Room for four events per file, so that exactly one rotation happens.
+	maxFileSize := int64(4 * (len(data) + 1))
+	var waitGroup sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			assert.NoError(t, appendEvent(context.Background(), filePath, event, maxFileSize))
+		}()
+	}
+	waitGroup.Wait()
+	readEvents, err := ReadEventsFile(filePath)
+	require.NoError(t, err)
+	assert.Len(t, readEvents, 8)
+}
+
+func TestReadEventsFileNotExist(t *testing.T) {
+	t.Parallel()
+	readEvents, err := ReadEventsFile(filepath.Join(t.TempDir(), "events.jsonl"))
+	require.NoError(t, err)
+	assert.Empty(t, readEvents)
+}
+
+func TestReadEventsSkipsMalformedLines(t *testing.T) {
+	t.Parallel()
+	readEvents, err := ReadEvents(
+		strings.NewReader(
+			`{"command":"buf lint","duration":1000}` + "\n" +
+				"\n" +
+				`{"command":"buf bu` + "\n" +
+				`{"duration":1000}` + "\n" +
+				`{"command":"buf build","duration":2000}` + "\n",
+		),
+	)
+	require.NoError(t, err)
+	require.Len(t, readEvents, 2)
+	assert.Equal(t, "buf lint", readEvents[0].Command)
+	assert.Equal(t, "buf build", readEvents[1].Command)
+}
+
+func TestNewReport(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []*Event{
+		{
+			Command:  "buf lint",
+			Time:     now.Add(-time.Hour),
+			Duration: 10 * time.Second,
+			Success:  true,
+		},
+		{
+			Command:     "buf lint",
+			Time:        now,
+			Duration:    time.Second,
+			Success:     true,
+			CacheHits:   3,
+			CacheMisses: 1,
+		},
+		{
+			Command:  "buf lint",
+			Time:     now.Add(time.Minute),
+			Duration: 3 * time.Second,
+		},
+		{
+			Command:   "buf build",
+			Time:      now.Add(2 * time.Minute),
+			Duration:  5 * time.Second,
+			Success:   true,
+			CacheHits: 4,
+		},
+	}
+	report := NewReport(events, now)
+	assert.Equal(
+		t,
+		&Report{
+			Since: now,
+			Commands: []*CommandReport{
+				{
+					Command:       "buf build",
+					Count:         1,
+					TotalDuration: 5 * time.Second,
+					MeanDuration:  5 * time.Second,
+					MaxDuration:   5 * time.Second,
+					CacheHits:     4,
+				},
+				{
+					Command:       "buf lint",
+					Count:         2,
+					Failures:      1,
+					TotalDuration: 4 * time.Second,
+					MeanDuration:  2 * time.Second,
+					MaxDuration:   3 * time.Second,
+					CacheHits:     3,
+					CacheMisses:   1,
+				},
+			},
+			Count:       3,
+			Failures:    1,
+			CacheHits:   7,
+			CacheMisses: 1,
+		},
+		report,
+	)
+	cacheHitRate, ok := report.CacheHitRate()
+	assert.True(t, ok)
+	assert.Equal(t, 0.875, cacheHitRate)
+	_, ok = NewReport(nil, time.Time{}).CacheHitRate()
+	assert.False(t, ok)
+}
+
+func TestCacheStats(t *testing.T) {
+	t.Parallel()
+	cacheStats := NewCacheStats()
+	cacheStats.MarkHit()
+	cacheStats.MarkHit()
+	cacheStats.MarkMiss()
+	assert.Equal(t, 2, cacheStats.Hits())
+	assert.Equal(t, 1, cacheStats.Misses())
+}
+
+func TestSendEvent(t *testing.T) {
+	t.Parallel()
+	var received *Event
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, request *http.Request) {
+				assert.Equal(t, http.MethodPost, request.Method)
+				assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+				data, err := io.ReadAll(request.Body)
+				assert.NoError(t, err)
+				received = &Event{}
+				assert.NoError(t, json.Unmarshal(data, received))
+			},
+		),
+	)
+	defer server.Close()
+	event := &Event{
+		Command:  "buf lint",
+		Time:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration: time.Second,
+		Success:  true,
+	}
+	require.NoError(t, SendEvent(context.Background(), server.Client(), server.URL, event))
+	assert.Equal(t, event, received)
+}
+
+func TestSendEventError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(writer http.ResponseWriter, request *http.Request) {
+				writer.WriteHeader(http.StatusForbidden)
+			},
+		),
+	)
+	defer server.Close()
+	err := SendEvent(context.Background(), server.Client(), server.URL, &Event{Command: "buf lint"})
+	assert.EqualError(t, err, "telemetry endpoint returned 403 Forbidden")
+}
@@ -19,8 +19,10 @@ package bufwire
 
 import (
 	"context"
+	"errors"
 
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufwork"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
@@ -38,6 +40,31 @@ import (
 type ImageConfig interface {
 	Image() bufimage.Image
 	Config() *bufconfig.Config
+	// ExternalPathForPath returns the user-facing external path for the path
+	// of a file within the Image.
+	//
+	// This is the same as ModuleConfig.ExternalPathForPath for the ModuleConfig the
+	// Image was built from. For Images that were not built from a source input, the
+	// path is returned as-is.
+	ExternalPathForPath(ctx context.Context, path string) string
+}
+
+// MapFileAnnotationExternalPaths maps the external paths of the FileAnnotations
+// of the Image of the ImageConfig, such as lint and breaking change FileAnnotations,
+// with ExternalPathForPath.
+//
+// See bufanalysis.MapFileAnnotationExternalPaths for which FileAnnotations are mapped.
+func MapFileAnnotationExternalPaths(
+	ctx context.Context,
+	imageConfig ImageConfig,
+	fileAnnotations []bufanalysis.FileAnnotation,
+) []bufanalysis.FileAnnotation {
+	return bufanalysis.MapFileAnnotationExternalPaths(
+		fileAnnotations,
+		func(path string) string {
+			return imageConfig.ExternalPathForPath(ctx, path)
+		},
+	)
 }
 
 // ImageConfigReader is an ImageConfig reader.
@@ -54,9 +81,43 @@ type ImageConfigReader interface {
 		externalExcludeDirOrFilePaths []string,
 		externalDirOrFilePathsAllowNotExist bool,
 		excludeSourceCodeInfo bool,
+		options ...GetImageConfigsOption,
 	) ([]ImageConfig, []bufanalysis.FileAnnotation, error)
 }
 
+// GetImageConfigsOption is an option for GetImageConfigs.
+type GetImageConfigsOption func(*getImageConfigsOptions)
+
+// GetImageConfigsWithModuleTags returns a new GetImageConfigsOption that only
+// builds the workspace modules that have at least one of the given tags.
+//
+// This is only valid for workspace inputs. Tags only select modules when the input
+// is the workspace root; an input that targets a single workspace directory is
+// always built.
+func GetImageConfigsWithModuleTags(moduleTags []string) GetImageConfigsOption {
+	return func(getImageConfigsOptions *getImageConfigsOptions) {
+		getImageConfigsOptions.moduleTagsFilter = moduleTagsFilter{
+			moduleTags: moduleTags,
+		}
+	}
+}
+
+// GetImageConfigsWithModuleTagsIfWorkspace returns a new GetImageConfigsOption that
+// is the same as GetImageConfigsWithModuleTags, except that inputs that are not
+// workspaces, such as images and modules, are built in full instead of resulting
+// in an error.
+//
+// This is used for the against input of breaking change detection, which is only
+// a workspace if the input is compared against the same workspace at another commit.
+func GetImageConfigsWithModuleTagsIfWorkspace(moduleTags []string) GetImageConfigsOption {
+	return func(getImageConfigsOptions *getImageConfigsOptions) {
+		getImageConfigsOptions.moduleTagsFilter = moduleTagsFilter{
+			moduleTags:        moduleTags,
+			allowNonWorkspace: true,
+		}
+	}
+}
+
 // NewImageConfigReader returns a new ImageConfigReader.
 func NewImageConfigReader(
 	logger *zap.Logger,
@@ -78,6 +139,13 @@ func NewImageConfigReader(
 type ModuleConfig interface {
 	Module() bufmodule.Module
 	Config() *bufconfig.Config
+	// ExternalPathForPath returns the user-facing external path for the path
+	// of a file within the Module.
+	//
+	// This resolves paths through archives, git repositories, and subdirectories
+	// to the location the Module was read from, including for paths that do not
+	// exist. This is the path that should be reported in errors and FileAnnotations.
+	ExternalPathForPath(ctx context.Context, path string) string
 }
 
 // ModuleConfigSet is a set of ModuleConfigs with a potentially associated Workspace.
@@ -85,6 +153,11 @@ type ModuleConfigSet interface {
 	ModuleConfigs() []ModuleConfig
 	// Optional. May be nil.
 	Workspace() bufmodule.Workspace
+	// OptionDefaults are the default values of custom file options from the
+	// workspace configuration, which are set on the files of built images.
+	//
+	// Optional. May be empty.
+	OptionDefaults() []*bufwork.OptionDefault
 }
 
 // ModuleConfigReader is a ModuleConfig reader.
@@ -103,9 +176,27 @@ type ModuleConfigReader interface {
 		externalDirOrFilePaths []string,
 		externalExcludeDirOrFilePaths []string,
 		externalDirOrFilePathsAllowNotExist bool,
+		options ...GetModuleConfigSetOption,
 	) (ModuleConfigSet, error)
 }
 
+// GetModuleConfigSetOption is an option for GetModuleConfigSet.
+type GetModuleConfigSetOption func(*getModuleConfigSetOptions)
+
+// GetModuleConfigSetWithModuleTags returns a new GetModuleConfigSetOption that only
+// includes the workspace modules that have at least one of the given tags.
+//
+// This is only valid for workspace inputs. Tags only select modules when the input
+// is the workspace root; an input that targets a single workspace directory is
+// always included.
+func GetModuleConfigSetWithModuleTags(moduleTags []string) GetModuleConfigSetOption {
+	return func(getModuleConfigSetOptions *getModuleConfigSetOptions) {
+		getModuleConfigSetOptions.moduleTagsFilter = moduleTagsFilter{
+			moduleTags: moduleTags,
+		}
+	}
+}
+
 // NewModuleConfigReader returns a new ModuleConfigReader
 func NewModuleConfigReader(
 	logger *zap.Logger,
@@ -254,3 +345,45 @@ func NewProtoEncodingWriter(
 		fetchWriter,
 	)
 }
+
+var errModuleTagsWithoutWorkspace = errors.New("module tags can only be used with workspace inputs")
+
+type getImageConfigsOptions struct {
+	moduleTagsFilter moduleTagsFilter
+}
+
+func newGetImageConfigsOptions() *getImageConfigsOptions {
+	return &getImageConfigsOptions{}
+}
+
+type getModuleConfigSetOptions struct {
+	moduleTagsFilter moduleTagsFilter
+}
+
+func newGetModuleConfigSetOptions() *getModuleConfigSetOptions {
+	return &getModuleConfigSetOptions{}
+}
+
+// moduleTagsFilter selects the workspace modules to build by their tags.
+type moduleTagsFilter struct {
+	moduleTags []string
+	// allowNonWorkspace results in inputs that are not workspaces being built
+	// in full, instead of resulting in an error.
+	allowNonWorkspace bool
+}
+
+// checkNonWorkspace returns an error if module tags cannot be applied to an
+// input that is not a workspace.
+func (f moduleTagsFilter) checkNonWorkspace() error {
+	if len(f.moduleTags) > 0 && !f.allowNonWorkspace {
+		return errModuleTagsWithoutWorkspace
+	}
+	return nil
+}
+
+// option returns the GetModuleConfigSetOption for the filter.
+func (f moduleTagsFilter) option() GetModuleConfigSetOption {
+	return func(getModuleConfigSetOptions *getModuleConfigSetOptions) {
+		getModuleConfigSetOptions.moduleTagsFilter = f
+	}
+}
@@ -15,19 +15,27 @@
 package bufwire
 
 import (
+	"context"
+
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 )
 
 type imageConfig struct {
-	image  bufimage.Image
-	config *bufconfig.Config
+	image               bufimage.Image
+	config              *bufconfig.Config
+	externalPathForPath func(context.Context, string) string
 }
 
-func newImageConfig(image bufimage.Image, config *bufconfig.Config) *imageConfig {
+func newImageConfig(
+	image bufimage.Image,
+	config *bufconfig.Config,
+	externalPathForPath func(context.Context, string) string,
+) *imageConfig {
 	return &imageConfig{
-		image:  image,
-		config: config,
+		image:               image,
+		config:              config,
+		externalPathForPath: externalPathForPath,
 	}
 }
 
@@ -38,3 +46,10 @@ func (i *imageConfig) Image() bufimage.Image {
 func (i *imageConfig) Config() *bufconfig.Config {
 	return i.config
 }
+
+func (i *imageConfig) ExternalPathForPath(ctx context.Context, path string) string {
+	if i.externalPathForPath == nil {
+		return path
+	}
+	return i.externalPathForPath(ctx, path)
+}
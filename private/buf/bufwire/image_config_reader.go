@@ -18,13 +18,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufwork"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
-	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagemodify"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
@@ -76,9 +78,17 @@ func (i *imageConfigReader) GetImageConfigs(
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
 	excludeSourceCodeInfo bool,
+	options ...GetImageConfigsOption,
 ) ([]ImageConfig, []bufanalysis.FileAnnotation, error) {
+	getImageConfigsOptions := newGetImageConfigsOptions()
+	for _, option := range options {
+		option(getImageConfigsOptions)
+	}
 	switch t := ref.(type) {
 	case buffetch.MessageRef:
+		if err := getImageConfigsOptions.moduleTagsFilter.checkNonWorkspace(); err != nil {
+			return nil, nil, err
+		}
 		env, err := i.getImageImageConfig(
 			ctx,
 			container,
@@ -100,6 +110,7 @@ func (i *imageConfigReader) GetImageConfigs(
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
 			excludeSourceCodeInfo,
+			getImageConfigsOptions.moduleTagsFilter,
 		)
 	case buffetch.ModuleRef:
 		return i.getSourceOrModuleImageConfigs(
@@ -111,6 +122,7 @@ func (i *imageConfigReader) GetImageConfigs(
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
 			excludeSourceCodeInfo,
+			getImageConfigsOptions.moduleTagsFilter,
 		)
 	default:
 		return nil, nil, fmt.Errorf("invalid ref: %T", ref)
@@ -126,6 +138,7 @@ func (i *imageConfigReader) getSourceOrModuleImageConfigs(
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
 	excludeSourceCodeInfo bool,
+	moduleTagsFilter moduleTagsFilter,
 ) ([]ImageConfig, []bufanalysis.FileAnnotation, error) {
 	moduleConfigSet, err := i.moduleConfigReader.GetModuleConfigSet(
 		ctx,
@@ -135,6 +148,7 @@ func (i *imageConfigReader) getSourceOrModuleImageConfigs(
 		externalDirOrFilePaths,
 		externalExcludeDirOrFilePaths,
 		externalDirOrFilePathsAllowNotExist,
+		moduleTagsFilter.option(),
 	)
 	if err != nil {
 		return nil, nil, err
@@ -161,16 +175,24 @@ func (i *imageConfigReader) getSourceOrModuleImageConfigs(
 		}
 		imageConfig, fileAnnotations, err := i.buildModule(
 			ctx,
-			moduleConfig.Config(),
-			moduleConfig.Module(),
+			moduleConfig,
 			buildOpts...,
 		)
 		if err != nil {
 			return nil, nil, err
 		}
 		if imageConfig != nil {
+			if err := applyOptionDefaults(ctx, i.logger, imageConfig.Image(), moduleConfigSet.OptionDefaults()); err != nil {
+				return nil, nil, err
+			}
 			imageConfigs = append(imageConfigs, imageConfig)
 		}
+		fileAnnotations = bufanalysis.MapFileAnnotationExternalPaths(
+			fileAnnotations,
+			func(path string) string {
+				return moduleConfig.ExternalPathForPath(ctx, path)
+			},
+		)
 		allFileAnnotations = append(allFileAnnotations, fileAnnotations...)
 	}
 	if len(allFileAnnotations) > 0 {
@@ -227,18 +249,18 @@ func (i *imageConfigReader) getImageImageConfig(
 	if err != nil {
 		return nil, err
 	}
-	return newImageConfig(image, config), nil
+	warnConfig(i.logger, config)
+	return newImageConfig(image, config, nil), nil
 }
 
 func (i *imageConfigReader) buildModule(
 	ctx context.Context,
-	config *bufconfig.Config,
-	module bufmodule.Module,
+	moduleConfig ModuleConfig,
 	buildOpts ...bufimagebuild.BuildOption,
 ) (ImageConfig, []bufanalysis.FileAnnotation, error) {
 	image, fileAnnotations, err := i.imageBuilder.Build(
 		ctx,
-		module,
+		moduleConfig.Module(),
 		buildOpts...,
 	)
 	if err != nil {
@@ -247,16 +269,43 @@ func (i *imageConfigReader) buildModule(
 	if len(fileAnnotations) > 0 {
 		return nil, fileAnnotations, nil
 	}
-	return newImageConfig(image, config), nil, nil
+	return newImageConfig(image, moduleConfig.Config(), moduleConfig.ExternalPathForPath), nil, nil
+}
+
+// applyOptionDefaults sets the default values of custom file options on the files
+// of the image that do not already set them.
+func applyOptionDefaults(
+	ctx context.Context,
+	logger *zap.Logger,
+	image bufimage.Image,
+	optionDefaults []*bufwork.OptionDefault,
+) error {
+	var modifier bufimagemodify.Modifier
+	for _, optionDefault := range optionDefaults {
+		modifier = bufimagemodify.Merge(
+			modifier,
+			bufimagemodify.FileOptionDefault(
+				logger,
+				optionDefault.Option,
+				optionDefault.Value,
+				optionDefault.Paths,
+			),
+		)
+	}
+	if modifier == nil {
+		return nil
+	}
+	return modifier.Modify(ctx, image)
 }
 
 // filterImageConfigs takes in image configs and filters them based on the proto file ref.
-// First, we get the package, path, and config for the file ref. And then we merge the images
-// across the ImageConfigs, then filter them based on the paths for the package.
+// First, we get the packages, paths, and config for the files of the ref. And then we merge the images
+// across the ImageConfigs, then filter them based on the paths for the packages.
 //
 // The image merge is needed because if the `include_package_files=true` option is set, we
 // need to gather all the files for the package, including files spread out across workspace
-// directories, which would result in multiple image configs.
+// directories, which would result in multiple image configs. Likewise, if the ref references
+// multiple files, they may be within different directories of a workspace.
 //
 // As a reminder, with ProtoFileRefs, we actually return an Image that contains all the files
 // in the same package as the referenced file. filterImageConfigs deals with an edge case where
@@ -267,47 +316,67 @@ func (i *imageConfigReader) buildModule(
 // takes an option that includes files within the package. Even better, create functions such that
 // you can do bufimage.ImageWithOnlyPackages, bufimage.ImageWithOnlyPaths, and then reuse bufimage.MergeImage?
 func filterImageConfigs(imageConfigs []ImageConfig, protoFileRef buffetch.ProtoFileRef) ([]ImageConfig, error) {
-	var pkg string
-	var path string
+	// A file may be in multiple images, for example as an import of another module in a workspace,
+	// so we key by path to only count each file once.
+	pathToPackage := make(map[string]string)
+	// The merged image maps each path through the ImageConfig the file was first found in.
+	pathToImageConfig := make(map[string]ImageConfig)
 	var config *bufconfig.Config
 	var images []bufimage.Image
 	for _, imageConfig := range imageConfigs {
 		for _, imageFile := range imageConfig.Image().Files() {
+			if _, ok := pathToImageConfig[imageFile.Path()]; !ok {
+				pathToImageConfig[imageFile.Path()] = imageConfig
+			}
 			// TODO: Ideally, we have the path returned from PathForExternalPath, however for a protoFileRef,
 			// PathForExternalPath returns only ".", <nil> when matched on the exact path of the proto file
 			// provided as the ref. This is expected since `PathForExternalPath` is meant to return the relative
 			// path based on the reference, which in this case will always be a specific file.
 			if _, err := protoFileRef.PathForExternalPath(imageFile.ExternalPath()); err == nil {
-				pkg = imageFile.FileDescriptorProto().GetPackage()
-				path = imageFile.Path()
+				pathToPackage[imageFile.Path()] = imageFile.FileDescriptorProto().GetPackage()
 				config = imageConfig.Config()
-				break
 			}
 		}
 		images = append(images, imageConfig.Image())
 	}
-	if path == "" {
+	if len(pathToPackage) == 0 {
 		return nil, errors.New("did not find a matching image file for the ProtoFileRef")
 	}
+	if len(pathToPackage) < protoFileRef.NumPaths() {
+		return nil, errors.New("did not find a matching image file for every file of the ProtoFileRef")
+	}
 	image, err := bufimage.MergeImages(images...)
 	if err != nil {
 		return nil, err
 	}
-	// If include_package_files is set, we then need to go get the rest of the files for the package,
-	// and see comment on Godoc. Otherwise, we just return an image that contains the given file.
+	// If include_package_files is set, we then need to go get the rest of the files for the packages,
+	// and see comment on Godoc. Otherwise, we just return an image that contains the given files.
 	var paths []string
 	if protoFileRef.IncludePackageFiles() {
+		pkgs := make(map[string]struct{}, len(pathToPackage))
+		for _, pkg := range pathToPackage {
+			pkgs[pkg] = struct{}{}
+		}
 		for _, imageFile := range image.Files() {
-			if imageFile.FileDescriptorProto().GetPackage() == pkg {
+			if _, ok := pkgs[imageFile.FileDescriptorProto().GetPackage()]; ok {
 				paths = append(paths, imageFile.Path())
 			}
 		}
 	} else {
-		paths = []string{path}
+		for path := range pathToPackage {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
 	}
 	prunedImage, err := bufimage.ImageWithOnlyPaths(image, paths, nil)
 	if err != nil {
 		return nil, err
 	}
-	return []ImageConfig{newImageConfig(prunedImage, config)}, nil
+	externalPathForPath := func(ctx context.Context, path string) string {
+		if imageConfig, ok := pathToImageConfig[path]; ok {
+			return imageConfig.ExternalPathForPath(ctx, path)
+		}
+		return path
+	}
+	return []ImageConfig{newImageConfig(prunedImage, config, externalPathForPath)}, nil
 }
@@ -0,0 +1,77 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufwire
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapFileAnnotationExternalPaths(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	imageConfig := newImageConfig(
+		nil,
+		nil,
+		func(_ context.Context, path string) string {
+			return normalpath.Join("archive", "proto", path)
+		},
+	)
+	fileAnnotations := MapFileAnnotationExternalPaths(
+		ctx,
+		imageConfig,
+		[]bufanalysis.FileAnnotation{
+			bufanalysis.NewFileAnnotation(&testFileInfo{path: "a.proto", externalPath: "a.proto"}, 1, 1, 1, 1, "FIELD_LOWER_SNAKE_CASE", "message"),
+			bufanalysis.NewFileAnnotation(&testFileInfo{path: "b.proto", externalPath: "proto/b.proto"}, 1, 1, 1, 1, "FILE_NO_DELETE", "message"),
+			bufanalysis.NewFileAnnotation(nil, 0, 0, 0, 0, "PACKAGE_NO_DELETE", "message"),
+		},
+	)
+	require.Len(t, fileAnnotations, 3)
+	assert.Equal(t, "archive/proto/a.proto", fileAnnotations[0].FileInfo().ExternalPath())
+	assert.Equal(t, "a.proto", fileAnnotations[0].FileInfo().Path())
+	assert.Equal(t, "FIELD_LOWER_SNAKE_CASE", fileAnnotations[0].Type())
+	// Already resolved external paths are kept.
+	assert.Equal(t, "proto/b.proto", fileAnnotations[1].FileInfo().ExternalPath())
+	assert.Nil(t, fileAnnotations[2].FileInfo())
+
+	// Without a mapping, the paths are kept.
+	fileAnnotations = MapFileAnnotationExternalPaths(
+		ctx,
+		newImageConfig(nil, nil, nil),
+		[]bufanalysis.FileAnnotation{
+			bufanalysis.NewFileAnnotation(&testFileInfo{path: "a.proto", externalPath: "a.proto"}, 1, 1, 1, 1, "FIELD_LOWER_SNAKE_CASE", "message"),
+		},
+	)
+	require.Len(t, fileAnnotations, 1)
+	assert.Equal(t, "a.proto", fileAnnotations[0].FileInfo().ExternalPath())
+}
+
+type testFileInfo struct {
+	path         string
+	externalPath string
+}
+
+func (f *testFileInfo) Path() string {
+	return f.path
+}
+
+func (f *testFileInfo) ExternalPath() string {
+	return f.externalPath
+}
@@ -15,19 +15,27 @@
 package bufwire
 
 import (
+	"context"
+
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 )
 
 type moduleConfig struct {
-	module bufmodule.Module
-	config *bufconfig.Config
+	module              bufmodule.Module
+	config              *bufconfig.Config
+	externalPathForPath func(context.Context, string) string
 }
 
-func newModuleConfig(module bufmodule.Module, config *bufconfig.Config) *moduleConfig {
+func newModuleConfig(
+	module bufmodule.Module,
+	config *bufconfig.Config,
+	externalPathForPath func(context.Context, string) string,
+) *moduleConfig {
 	return &moduleConfig{
-		module: module,
-		config: config,
+		module:              module,
+		config:              config,
+		externalPathForPath: externalPathForPath,
 	}
 }
 
@@ -38,3 +46,10 @@ func (m *moduleConfig) Module() bufmodule.Module {
 func (m *moduleConfig) Config() *bufconfig.Config {
 	return m.config
 }
+
+func (m *moduleConfig) ExternalPathForPath(ctx context.Context, path string) string {
+	if m.externalPathForPath == nil {
+		return path
+	}
+	return m.externalPathForPath(ctx, path)
+}
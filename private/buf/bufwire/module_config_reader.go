@@ -39,6 +39,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// inferWorkspaceEnvKey is the environment variable that, if set, infers the workspace
+// directories of a source input that has neither a buf.work.yaml nor a buf.yaml.
+const inferWorkspaceEnvKey = "BUF_INFER_WORKSPACE"
+
 type moduleConfigReader struct {
 	logger              *zap.Logger
 	storageosProvider   storageos.Provider
@@ -70,7 +74,12 @@ func (m *moduleConfigReader) GetModuleConfigSet(
 	externalDirOrFilePaths []string,
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
+	options ...GetModuleConfigSetOption,
 ) (_ ModuleConfigSet, retErr error) {
+	getModuleConfigSetOptions := newGetModuleConfigSetOptions()
+	for _, option := range options {
+		option(getModuleConfigSetOptions)
+	}
 	ctx, span := m.tracer.Start(ctx, "get_module_config")
 	defer span.End()
 	defer func() {
@@ -92,6 +101,7 @@ func (m *moduleConfigReader) GetModuleConfigSet(
 			externalDirOrFilePaths,
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
+			getModuleConfigSetOptions.moduleTagsFilter,
 		)
 	case buffetch.SourceRef:
 		return m.getSourceModuleConfigSet(
@@ -103,8 +113,12 @@ func (m *moduleConfigReader) GetModuleConfigSet(
 			externalDirOrFilePaths,
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
+			getModuleConfigSetOptions.moduleTagsFilter,
 		)
 	case buffetch.ModuleRef:
+		if err := getModuleConfigSetOptions.moduleTagsFilter.checkNonWorkspace(); err != nil {
+			return nil, err
+		}
 		moduleConfig, err := m.getModuleModuleConfig(
 			ctx,
 			container,
@@ -122,6 +136,7 @@ func (m *moduleConfigReader) GetModuleConfigSet(
 				moduleConfig,
 			},
 			nil,
+			nil,
 		), nil
 	default:
 		return nil, fmt.Errorf("invalid ref: %T", sourceOrModuleRef)
@@ -137,6 +152,7 @@ func (m *moduleConfigReader) getSourceModuleConfigSet(
 	externalDirOrFilePaths []string,
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
+	moduleTagsFilter moduleTagsFilter,
 ) (_ ModuleConfigSet, retErr error) {
 	readBucketCloser, err := m.fetchReader.GetSourceBucket(ctx, container, sourceRef)
 	if err != nil {
@@ -150,10 +166,15 @@ func (m *moduleConfigReader) getSourceModuleConfigSet(
 		return nil, err
 	}
 	if existingConfigFilePath != "" {
+		workspaceConfig, err := bufwork.GetConfigForBucket(ctx, readBucketCloser, readBucketCloser.RelativeRootPath())
+		if err != nil {
+			return nil, err
+		}
 		return m.getWorkspaceModuleConfigSet(
 			ctx,
 			sourceRef,
 			workspaceBuilder,
+			workspaceConfig,
 			readBucketCloser,
 			readBucketCloser.RelativeRootPath(),
 			readBucketCloser.SubDirPath(),
@@ -161,8 +182,34 @@ func (m *moduleConfigReader) getSourceModuleConfigSet(
 			externalDirOrFilePaths,
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
+			moduleTagsFilter,
 		)
 	}
+	if container.Env(inferWorkspaceEnvKey) != "" && configOverride == "" && readBucketCloser.SubDirPath() == "." {
+		workspaceConfig, err := m.inferWorkspaceConfig(ctx, readBucketCloser)
+		if err != nil {
+			return nil, err
+		}
+		if workspaceConfig != nil {
+			return m.getWorkspaceModuleConfigSet(
+				ctx,
+				sourceRef,
+				workspaceBuilder,
+				workspaceConfig,
+				readBucketCloser,
+				readBucketCloser.RelativeRootPath(),
+				readBucketCloser.SubDirPath(),
+				configOverride,
+				externalDirOrFilePaths,
+				externalExcludeDirOrFilePaths,
+				externalDirOrFilePathsAllowNotExist,
+				moduleTagsFilter,
+			)
+		}
+	}
+	if err := moduleTagsFilter.checkNonWorkspace(); err != nil {
+		return nil, err
+	}
 	moduleConfig, err := m.getSourceModuleConfig(
 		ctx,
 		sourceRef,
@@ -185,6 +232,7 @@ func (m *moduleConfigReader) getSourceModuleConfigSet(
 			moduleConfig,
 		},
 		nil,
+		nil,
 	), nil
 }
 
@@ -197,10 +245,6 @@ func (m *moduleConfigReader) getModuleModuleConfig(
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
 ) (_ ModuleConfig, retErr error) {
-	module, err := m.fetchReader.GetModule(ctx, container, moduleRef)
-	if err != nil {
-		return nil, err
-	}
 	var targetPaths []string
 	if len(externalDirOrFilePaths) > 0 {
 		targetPaths = make([]string, len(externalDirOrFilePaths))
@@ -212,6 +256,16 @@ func (m *moduleConfigReader) getModuleModuleConfig(
 			targetPaths[i] = targetPath
 		}
 	}
+	var getModuleOptions []buffetch.GetModuleOption
+	// If we are only targeting specific .proto files that must exist, we only need
+	// these files and their imports, so we allow the module to be partially downloaded.
+	if len(targetPaths) > 0 && !externalDirOrFilePathsAllowNotExist && allProtoFilePaths(targetPaths) {
+		getModuleOptions = append(getModuleOptions, buffetch.GetModuleWithTargetPaths(targetPaths))
+	}
+	module, err := m.fetchReader.GetModule(ctx, container, moduleRef, getModuleOptions...)
+	if err != nil {
+		return nil, err
+	}
 	var excludePaths []string
 	if len(externalExcludeDirOrFilePaths) > 0 {
 		excludePaths = make([]string, len(externalExcludeDirOrFilePaths))
@@ -251,7 +305,7 @@ func (m *moduleConfigReader) getModuleModuleConfig(
 	if err != nil {
 		return nil, err
 	}
-	return newModuleConfig(module, config), nil
+	return newModuleConfig(module, config, nil), nil
 }
 
 func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
@@ -263,6 +317,7 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 	externalDirOrFilePaths []string,
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
+	moduleTagsFilter moduleTagsFilter,
 ) (_ ModuleConfigSet, retErr error) {
 	readBucketCloser, err := m.fetchReader.GetSourceBucket(ctx, container, protoFileRef)
 	if err != nil {
@@ -285,6 +340,10 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 	// If a workspace and module are both found, then we need to check of the module is within
 	// the workspace. If it is, we use the workspace. Otherwise, we use the module.
 	if workspaceConfigDirectory != "" {
+		workspaceConfig, err := bufwork.GetConfigForBucket(ctx, readBucketCloser, readBucketCloser.RelativeRootPath())
+		if err != nil {
+			return nil, err
+		}
 		if moduleConfigDirectory != "" {
 			relativePath, err := normalpath.Rel(workspaceConfigDirectory, moduleConfigDirectory)
 			if err != nil {
@@ -296,10 +355,6 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 			// proto file ref is contained within one of the workspace directories.
 			// If yes, we can set the `SubDirPath` for the bucket to the directory, to ensure we build all the
 			// dependencies for the directory. If not, then we will keep the `SubDirPath` as the working directory.
-			workspaceConfig, err := bufwork.GetConfigForBucket(ctx, readBucketCloser, readBucketCloser.RelativeRootPath())
-			if err != nil {
-				return nil, err
-			}
 			for _, directory := range workspaceConfig.Directories {
 				if normalpath.EqualsOrContainsPath(directory, readBucketCloser.SubDirPath(), normalpath.Relative) {
 					readBucketCloser.SetSubDirPath(normalpath.Normalize(directory))
@@ -311,6 +366,7 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 			ctx,
 			protoFileRef,
 			workspaceBuilder,
+			workspaceConfig,
 			readBucketCloser,
 			readBucketCloser.RelativeRootPath(),
 			readBucketCloser.SubDirPath(),
@@ -318,8 +374,12 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 			externalDirOrFilePaths,
 			externalExcludeDirOrFilePaths,
 			externalDirOrFilePathsAllowNotExist,
+			moduleTagsFilter,
 		)
 	}
+	if err := moduleTagsFilter.checkNonWorkspace(); err != nil {
+		return nil, err
+	}
 	moduleConfig, err := m.getSourceModuleConfig(
 		ctx,
 		protoFileRef,
@@ -342,6 +402,7 @@ func (m *moduleConfigReader) getProtoFileModuleSourceConfigSet(
 			moduleConfig,
 		},
 		nil,
+		nil,
 	), nil
 }
 
@@ -349,6 +410,7 @@ func (m *moduleConfigReader) getWorkspaceModuleConfigSet(
 	ctx context.Context,
 	sourceRef buffetch.SourceRef,
 	workspaceBuilder bufwork.WorkspaceBuilder,
+	workspaceConfig *bufwork.Config,
 	readBucket storage.ReadBucket,
 	relativeRootPath string,
 	subDirPath string,
@@ -356,11 +418,8 @@ func (m *moduleConfigReader) getWorkspaceModuleConfigSet(
 	externalDirOrFilePaths []string,
 	externalExcludeDirOrFilePaths []string,
 	externalDirOrFilePathsAllowNotExist bool,
+	moduleTagsFilter moduleTagsFilter,
 ) (ModuleConfigSet, error) {
-	workspaceConfig, err := bufwork.GetConfigForBucket(ctx, readBucket, relativeRootPath)
-	if err != nil {
-		return nil, err
-	}
 	workspace, err := workspaceBuilder.BuildWorkspace(
 		ctx,
 		workspaceConfig,
@@ -398,11 +457,16 @@ func (m *moduleConfigReader) getWorkspaceModuleConfigSet(
 				moduleConfig,
 			},
 			workspace,
+			workspaceConfig.OptionDefaults,
 		), nil
 	}
 	if configOverride != "" {
 		return nil, errors.New("the --config flag is not compatible with workspaces")
 	}
+	directories := workspaceConfig.DirectoriesForModuleTags(moduleTagsFilter.moduleTags)
+	if len(directories) == 0 {
+		return nil, fmt.Errorf("no workspace directories have any of the module tags %s", strings.Join(moduleTagsFilter.moduleTags, ", "))
+	}
 	// The target subDirPath points to the workspace configuration,
 	// so we construct a separate workspace for each of the configured
 	// directories.
@@ -412,7 +476,7 @@ func (m *moduleConfigReader) getWorkspaceModuleConfigSet(
 	// have been provided at the top level have been accounted for across the workspace.
 	externalPathToRelPaths := make(map[string]string)
 	externalExcludePathToRelPaths := make(map[string]string)
-	for _, directory := range workspaceConfig.Directories {
+	for _, directory := range directories {
 		// We are unfortunately adding this logic in two difference places, once at the top level
 		// here, and when we build each workspace for the build options. We need to do the work
 		// at this level because we need to check across all workspaces once.
@@ -474,7 +538,7 @@ func (m *moduleConfigReader) getWorkspaceModuleConfigSet(
 			}
 		}
 	}
-	return newModuleConfigSet(moduleConfigs, workspace), nil
+	return newModuleConfigSet(moduleConfigs, workspace, workspaceConfig.OptionDefaults), nil
 }
 
 func (m *moduleConfigReader) getSourceModuleConfig(
@@ -499,6 +563,7 @@ func (m *moduleConfigReader) getSourceModuleConfig(
 		return nil, err
 	}
 	if module, moduleConfig, ok := workspaceBuilder.GetModuleConfig(subDirPath); ok {
+		warnConfig(m.logger, moduleConfig)
 		// The module was already built while we were constructing the workspace.
 		// However, we still need to perform some additional validation based on
 		// the sourceRef.
@@ -513,7 +578,7 @@ func (m *moduleConfigReader) getSourceModuleConfig(
 				}
 			}
 		}
-		return newModuleConfig(module, moduleConfig), nil
+		return newModuleConfig(module, moduleConfig, externalPathForPathFunc(readBucket, subDirPath)), nil
 	}
 	moduleConfig, err := bufconfig.ReadConfigOS(
 		ctx,
@@ -523,6 +588,7 @@ func (m *moduleConfigReader) getSourceModuleConfig(
 	if err != nil {
 		return nil, err
 	}
+	warnConfig(m.logger, moduleConfig)
 	var buildOptions []bufmodulebuild.BuildOption
 	if len(externalDirOrFilePaths) > 0 {
 		if workspaceDirectoryEqualsOrContainsSubDirPath(workspaceConfig, subDirPath) {
@@ -648,7 +714,56 @@ func (m *moduleConfigReader) getSourceModuleConfig(
 		}
 		m.logger.Warn(builder.String())
 	}
-	return newModuleConfig(module, moduleConfig), nil
+	return newModuleConfig(module, moduleConfig, externalPathForPathFunc(readBucket, subDirPath)), nil
+}
+
+// inferWorkspaceConfig infers the workspace configuration for a bucket that has neither
+// a buf.work.yaml nor a buf.yaml, and prints the inferred directories.
+//
+// Returns nil if the bucket is a single module.
+func (m *moduleConfigReader) inferWorkspaceConfig(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+) (*bufwork.Config, error) {
+	existingConfigFilePath, err := bufconfig.ExistingConfigFilePath(ctx, readBucket)
+	if err != nil {
+		return nil, err
+	}
+	if existingConfigFilePath != "" {
+		return nil, nil
+	}
+	workspaceConfig, err := bufwork.InferConfig(ctx, readBucket)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceConfig != nil {
+		m.logger.Warn(
+			`no buf.work.yaml or buf.yaml found, using inferred workspace, run "buf beta workspace infer --write" to write this configuration`,
+			zap.Strings("directories", workspaceConfig.Directories),
+		)
+	}
+	return workspaceConfig, nil
+}
+
+// externalPathForPathFunc returns a function that maps paths within the module
+// at subDirPath to their external paths.
+//
+// This returns nil if readBucket was not read by buffetch, in which case the
+// paths of the module are already the external paths.
+func externalPathForPathFunc(readBucket storage.ReadBucket, subDirPath string) func(context.Context, string) string {
+	readBucketCloser, ok := readBucket.(buffetch.ReadBucketCloser)
+	if !ok {
+		return nil
+	}
+	return func(ctx context.Context, path string) string {
+		// ExternalPathForPath is relative to the current SubDirPath of the bucket,
+		// which is not subDirPath for the other directories of a workspace.
+		relativeSubDirPath, err := normalpath.Rel(readBucketCloser.SubDirPath(), subDirPath)
+		if err != nil {
+			return path
+		}
+		return readBucketCloser.ExternalPathForPath(ctx, normalpath.Join(relativeSubDirPath, path))
+	}
 }
 
 func workspaceDirectoryEqualsOrContainsSubDirPath(workspaceConfig *bufwork.Config, subDirPath string) bool {
@@ -687,3 +802,13 @@ func detectMissingDependencies(
 	}
 	return missingReferences
 }
+
+// allProtoFilePaths returns true if all paths are paths to .proto files.
+func allProtoFilePaths(paths []string) bool {
+	for _, path := range paths {
+		if normalpath.Ext(path) != ".proto" {
+			return false
+		}
+	}
+	return true
+}
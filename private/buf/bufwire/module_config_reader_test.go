@@ -187,6 +187,7 @@ func (r *fakeModuleFetcher) GetModule(
 	ctx context.Context,
 	container app.EnvStdinContainer,
 	moduleRef buffetch.ModuleRef,
+	options ...buffetch.GetModuleOption,
 ) (bufmodule.Module, error) {
 	moduleBucket, err := storagemem.NewReadBucket(
 		r.fileContent,
@@ -15,18 +15,25 @@
 package bufwire
 
 import (
+	"github.com/bufbuild/buf/private/buf/bufwork"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 )
 
 type moduleConfigSet struct {
-	moduleConfigs []ModuleConfig
-	workspace     bufmodule.Workspace
+	moduleConfigs  []ModuleConfig
+	workspace      bufmodule.Workspace
+	optionDefaults []*bufwork.OptionDefault
 }
 
-func newModuleConfigSet(moduleConfigs []ModuleConfig, workspace bufmodule.Workspace) *moduleConfigSet {
+func newModuleConfigSet(
+	moduleConfigs []ModuleConfig,
+	workspace bufmodule.Workspace,
+	optionDefaults []*bufwork.OptionDefault,
+) *moduleConfigSet {
 	return &moduleConfigSet{
-		moduleConfigs: moduleConfigs,
-		workspace:     workspace,
+		moduleConfigs:  moduleConfigs,
+		workspace:      workspace,
+		optionDefaults: optionDefaults,
 	}
 }
 
@@ -37,3 +44,7 @@ func (m *moduleConfigSet) ModuleConfigs() []ModuleConfig {
 func (m *moduleConfigSet) Workspace() bufmodule.Workspace {
 	return m.workspace
 }
+
+func (m *moduleConfigSet) OptionDefaults() []*bufwork.OptionDefault {
+	return m.optionDefaults
+}
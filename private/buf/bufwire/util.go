@@ -16,9 +16,40 @@ package bufwire
 
 import (
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"go.uber.org/zap"
 )
 
+// warnConfig logs the deprecations and ineffective settings of a user-controlled config.
+//
+// This is called once when the config is read, so that replaced lint rules
+// are reported once per invocation instead of once per check.
+func warnConfig(logger *zap.Logger, config *bufconfig.Config) {
+	bufconfig.WarnDeprecations(logger, config)
+	var warnings []*bufconfig.Warning
+	if config.Lint != nil {
+		for _, deprecation := range buflint.GetDeprecations(config.Lint) {
+			logger.Warn(
+				`configured lint rule or category has been replaced, run "buf beta config migrate-rules" to update your configuration`,
+				bufconfig.DeprecationZapFields(deprecation)...,
+			)
+		}
+		warnings = append(warnings, buflint.GetConfigWarnings(config.Lint)...)
+	}
+	if config.Breaking != nil {
+		warnings = append(warnings, bufbreaking.GetConfigWarnings(config.Breaking)...)
+	}
+	for _, warning := range warnings {
+		logger.Warn(
+			`configuration setting has no effect, run "buf beta config validate" for details`,
+			bufconfig.WarningZapFields(warning)...,
+		)
+	}
+}
+
 func newJSONMarshaler(
 	resolver protoencoding.Resolver,
 	messageRef buffetch.MessageRef,
@@ -0,0 +1,83 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufwire
+
+import (
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking/bufbreakingconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWarnConfigReplacedLintRules(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.WarnLevel)
+	warnConfig(
+		zap.New(core),
+		&bufconfig.Config{
+			Version: bufconfig.V1Version,
+			Lint: &buflintconfig.Config{
+				Version: bufconfig.V1Version,
+				Use:     []string{"DEFAULT", "FIELD_NO_DESCRIPTOR"},
+				Except:  []string{"FIELD_NO_DESCRIPTOR"},
+			},
+		},
+	)
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "FIELD_NO_DESCRIPTOR", entries[0].ContextMap()["name"])
+	assert.Equal(t, "lint_rule", entries[0].ContextMap()["type"])
+}
+
+func TestWarnConfigIneffectiveSettings(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.WarnLevel)
+	warnConfig(
+		zap.New(core),
+		&bufconfig.Config{
+			Version: bufconfig.V1Version,
+			Lint: &buflintconfig.Config{
+				Version:       bufconfig.V1Version,
+				Use:           []string{"MINIMAL"},
+				ServiceSuffix: "API",
+			},
+			Breaking: &bufbreakingconfig.Config{
+				Version: bufconfig.V1Version,
+				Use:     []string{"FILE"},
+				Except:  []string{"FILE"},
+			},
+		},
+	)
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "lint.service_suffix", entries[0].ContextMap()["key"])
+	assert.Equal(t, "breaking.except", entries[1].ContextMap()["key"])
+	assert.Equal(t, "removes every rule in use, so no rules are run", entries[1].ContextMap()["reason"])
+	assert.Equal(t, "breaking.except", entries[2].ContextMap()["key"])
+	assert.Equal(t, "FILE", entries[2].ContextMap()["value"])
+}
+
+func TestWarnConfigNoLintConfig(t *testing.T) {
+	t.Parallel()
+	core, logs := observer.New(zapcore.WarnLevel)
+	warnConfig(zap.New(core), &bufconfig.Config{Version: bufconfig.V1Version})
+	assert.Empty(t, logs.All())
+}
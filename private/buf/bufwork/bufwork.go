@@ -68,6 +68,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/buf/private/pkg/storage"
 )
 
@@ -328,6 +329,62 @@ type Config struct {
 	//
 	// Must be non-empty to be a valid configuration.
 	Directories []string
+	// ModuleTags maps normalized directories to their sorted, unique tags.
+	//
+	// Every key is guaranteed to be present in Directories. May be empty.
+	ModuleTags map[string][]string
+	// Patches maps the IdentityString of remote modules to the normalized directory
+	// containing local .proto files that are overlaid onto the remote module when it
+	// is used as a dependency.
+	//
+	// No directory overlaps with Directories. May be empty.
+	Patches map[string]string
+	// Frozen maps normalized directories to the digest that the module in the directory
+	// must have. These directories are copies of published modules, and building the
+	// workspace fails if their content changes.
+	//
+	// Every key is guaranteed to be present in Directories. May be empty.
+	Frozen map[string]string
+	// OptionDefaults are the default values of custom file options, in the order
+	// they are listed. They are set on the files of built images that do not already
+	// set the option.
+	//
+	// May be empty.
+	OptionDefaults []*OptionDefault
+}
+
+// OptionDefault is the default value of a custom file option.
+type OptionDefault struct {
+	// Option is the fully-qualified name of the extension of google.protobuf.FileOptions,
+	// without a leading dot.
+	Option string
+	// Value is the value of the option. Enum values are given by name.
+	Value string
+	// Paths are the normalized and validated paths of the files or directories within
+	// the modules of the workspace that the default applies to, relative to their module
+	// roots. If empty, the default applies to all files.
+	Paths []string
+}
+
+// DirectoriesForModuleTags returns the directories that have at least one of the
+// given tags, in the same order as Directories.
+//
+// If moduleTags is empty, all directories are returned.
+func (c *Config) DirectoriesForModuleTags(moduleTags []string) []string {
+	if len(moduleTags) == 0 {
+		return c.Directories
+	}
+	tagSet := slicesext.ToStructMap(moduleTags)
+	var directories []string
+	for _, directory := range c.Directories {
+		for _, tag := range c.ModuleTags[directory] {
+			if _, ok := tagSet[tag]; ok {
+				directories = append(directories, directory)
+				break
+			}
+		}
+	}
+	return directories
 }
 
 // GetConfigForBucket gets the Config for the YAML data at ConfigFilePath.
@@ -361,11 +418,57 @@ func ExistingConfigFilePath(ctx context.Context, readBucket storage.ReadBucket)
 	return "", nil
 }
 
+// InferDirectories infers the directories of a workspace from the .proto files in the
+// bucket, for use when the bucket has neither a buf.work.yaml nor a buf.yaml.
+//
+// Each directory is a package root, that is the directory of a file without the trailing
+// directories that match the file's package. For example, a file in "proto/acme/pet/v1" with
+// package "acme.pet.v1" results in the directory "proto". Files with a directory that does not
+// match their package are rooted at their directory, unless another package root contains them.
+// Files within hidden directories, such as .git, are ignored.
+//
+// Returns an empty slice if all files are rooted at the root of the bucket, in which case the
+// bucket is a single module. Returns an error if the inferred directories overlap.
+func InferDirectories(ctx context.Context, readBucket storage.ReadBucket) ([]string, error) {
+	return inferDirectories(ctx, readBucket)
+}
+
+// InferConfig returns the Config with the directories returned by InferDirectories.
+//
+// Returns nil if no directories were inferred.
+func InferConfig(ctx context.Context, readBucket storage.ReadBucket) (*Config, error) {
+	directories, err := inferDirectories(ctx, readBucket)
+	if err != nil {
+		return nil, err
+	}
+	if len(directories) == 0 {
+		return nil, nil
+	}
+	return newConfigV1(
+		ExternalConfigV1{
+			Version:     V1Version,
+			Directories: directories,
+		},
+		inferredWorkspaceID,
+	)
+}
+
 // ExternalConfigV1 represents the on-disk representation
 // of the workspace configuration at version v1.
 type ExternalConfigV1 struct {
-	Version     string   `json:"version,omitempty" yaml:"version,omitempty"`
-	Directories []string `json:"directories,omitempty" yaml:"directories,omitempty"`
+	Version        string                    `json:"version,omitempty" yaml:"version,omitempty"`
+	Directories    []string                  `json:"directories,omitempty" yaml:"directories,omitempty"`
+	ModuleTags     map[string][]string       `json:"module_tags,omitempty" yaml:"module_tags,omitempty"`
+	Patches        map[string]string         `json:"patches,omitempty" yaml:"patches,omitempty"`
+	Frozen         map[string]string         `json:"frozen,omitempty" yaml:"frozen,omitempty"`
+	OptionDefaults []ExternalOptionDefaultV1 `json:"option_defaults,omitempty" yaml:"option_defaults,omitempty"`
+}
+
+// ExternalOptionDefaultV1 is an external default value of a custom file option.
+type ExternalOptionDefaultV1 struct {
+	Option string   `json:"option,omitempty" yaml:"option,omitempty"`
+	Value  string   `json:"value,omitempty" yaml:"value,omitempty"`
+	Paths  []string `json:"paths,omitempty" yaml:"paths,omitempty"`
 }
 
 type externalConfigVersion struct {
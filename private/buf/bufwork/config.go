@@ -17,7 +17,9 @@ package bufwork
 import (
 	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/slicesext"
 )
@@ -56,11 +58,203 @@ func newConfigV1(externalConfig ExternalConfigV1, workspaceID string) (*Config,
 	if err := validateConfigurationOverlap(directories, workspaceID); err != nil {
 		return nil, err
 	}
+	moduleTags, err := newModuleTags(externalConfig.ModuleTags, directorySet, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	patches, err := newPatches(externalConfig.Patches, directories, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	frozen, err := newFrozen(externalConfig.Frozen, directorySet, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	optionDefaults, err := newOptionDefaults(externalConfig.OptionDefaults, workspaceID)
+	if err != nil {
+		return nil, err
+	}
 	return &Config{
-		Directories: directories,
+		Directories:    directories,
+		ModuleTags:     moduleTags,
+		Patches:        patches,
+		Frozen:         frozen,
+		OptionDefaults: optionDefaults,
 	}, nil
 }
 
+// newPatches normalizes and validates the patches key. Every key must be a module
+// name, and every directory must not overlap with the workspace directories.
+func newPatches(externalPatches map[string]string, directories []string, workspaceID string) (map[string]string, error) {
+	if len(externalPatches) == 0 {
+		return nil, nil
+	}
+	patches := make(map[string]string, len(externalPatches))
+	for moduleName, patchDirectory := range externalPatches {
+		moduleIdentity, err := bufmoduleref.ModuleIdentityForString(moduleName)
+		if err != nil {
+			return nil, fmt.Errorf(`patches module "%s" listed in %s is invalid: %w`, moduleName, workspaceID, err)
+		}
+		if _, ok := patches[moduleIdentity.IdentityString()]; ok {
+			return nil, fmt.Errorf(`patches module "%s" is listed more than once in %s`, moduleIdentity.IdentityString(), workspaceID)
+		}
+		normalizedPatchDirectory, err := normalpath.NormalizeAndValidate(patchDirectory)
+		if err != nil {
+			return nil, fmt.Errorf(`patches directory "%s" listed in %s is invalid: %w`, normalpath.Unnormalize(patchDirectory), workspaceID, err)
+		}
+		if normalizedPatchDirectory == "." {
+			return nil, fmt.Errorf(`patches directory "." listed in %s, patches must be in a subdirectory`, workspaceID)
+		}
+		for _, directory := range directories {
+			if normalpath.EqualsOrContainsPath(directory, normalizedPatchDirectory, normalpath.Relative) ||
+				normalpath.EqualsOrContainsPath(normalizedPatchDirectory, directory, normalpath.Relative) {
+				return nil, fmt.Errorf(
+					`patches directory "%s" overlaps with directory "%s" in %s`,
+					normalpath.Unnormalize(normalizedPatchDirectory),
+					normalpath.Unnormalize(directory),
+					workspaceID,
+				)
+			}
+		}
+		patches[moduleIdentity.IdentityString()] = normalizedPatchDirectory
+	}
+	return patches, nil
+}
+
+// newModuleTags normalizes and validates the module_tags key. Every key must be
+// one of the workspace directories, and tags must be non-empty.
+func newModuleTags(externalModuleTags map[string][]string, directorySet map[string]struct{}, workspaceID string) (map[string][]string, error) {
+	if len(externalModuleTags) == 0 {
+		return nil, nil
+	}
+	moduleTags := make(map[string][]string, len(externalModuleTags))
+	for directory, tags := range externalModuleTags {
+		normalizedDirectory, err := normalpath.NormalizeAndValidate(directory)
+		if err != nil {
+			return nil, fmt.Errorf(`module_tags directory "%s" listed in %s is invalid: %w`, normalpath.Unnormalize(directory), workspaceID, err)
+		}
+		if _, ok := directorySet[normalizedDirectory]; !ok {
+			return nil, fmt.Errorf(
+				`module_tags directory "%s" in %s is not listed in directories`,
+				normalpath.Unnormalize(normalizedDirectory),
+				workspaceID,
+			)
+		}
+		if _, ok := moduleTags[normalizedDirectory]; ok {
+			return nil, fmt.Errorf(
+				`module_tags directory "%s" is listed more than once in %s`,
+				normalpath.Unnormalize(normalizedDirectory),
+				workspaceID,
+			)
+		}
+		for _, tag := range tags {
+			if strings.TrimSpace(tag) == "" {
+				return nil, fmt.Errorf(
+					`module_tags for directory "%s" in %s contains an empty tag`,
+					normalpath.Unnormalize(normalizedDirectory),
+					workspaceID,
+				)
+			}
+		}
+		moduleTags[normalizedDirectory] = slicesext.ToUniqueSorted(tags)
+	}
+	return moduleTags, nil
+}
+
+// newFrozen normalizes and validates the frozen key. Every key must be one of the
+// workspace directories, and every digest must be non-empty.
+func newFrozen(externalFrozen map[string]string, directorySet map[string]struct{}, workspaceID string) (map[string]string, error) {
+	if len(externalFrozen) == 0 {
+		return nil, nil
+	}
+	frozen := make(map[string]string, len(externalFrozen))
+	for directory, digest := range externalFrozen {
+		normalizedDirectory, err := normalpath.NormalizeAndValidate(directory)
+		if err != nil {
+			return nil, fmt.Errorf(`frozen directory "%s" listed in %s is invalid: %w`, normalpath.Unnormalize(directory), workspaceID, err)
+		}
+		if _, ok := directorySet[normalizedDirectory]; !ok {
+			return nil, fmt.Errorf(
+				`frozen directory "%s" in %s is not listed in directories`,
+				normalpath.Unnormalize(normalizedDirectory),
+				workspaceID,
+			)
+		}
+		if _, ok := frozen[normalizedDirectory]; ok {
+			return nil, fmt.Errorf(
+				`frozen directory "%s" is listed more than once in %s`,
+				normalpath.Unnormalize(normalizedDirectory),
+				workspaceID,
+			)
+		}
+		if strings.TrimSpace(digest) == "" {
+			return nil, fmt.Errorf(
+				`frozen directory "%s" in %s has no digest, set it to the digest of the published module`,
+				normalpath.Unnormalize(normalizedDirectory),
+				workspaceID,
+			)
+		}
+		frozen[normalizedDirectory] = strings.TrimSpace(digest)
+	}
+	return frozen, nil
+}
+
+// newOptionDefaults normalizes and validates the option_defaults key. Every option
+// must be a fully-qualified name with a value, and every path must be relative.
+func newOptionDefaults(externalOptionDefaults []ExternalOptionDefaultV1, workspaceID string) ([]*OptionDefault, error) {
+	if len(externalOptionDefaults) == 0 {
+		return nil, nil
+	}
+	optionDefaults := make([]*OptionDefault, 0, len(externalOptionDefaults))
+	for _, externalOptionDefault := range externalOptionDefaults {
+		option := strings.TrimPrefix(strings.TrimSpace(externalOptionDefault.Option), ".")
+		if option == "" {
+			return nil, fmt.Errorf(`option_defaults in %s contains an entry with no option`, workspaceID)
+		}
+		if !isFullyQualifiedName(option) {
+			return nil, fmt.Errorf(`option_defaults option "%s" in %s is not a fully-qualified name`, option, workspaceID)
+		}
+		if externalOptionDefault.Value == "" {
+			return nil, fmt.Errorf(`option_defaults option "%s" in %s has no value`, option, workspaceID)
+		}
+		optionDefault := &OptionDefault{
+			Option: option,
+			Value:  externalOptionDefault.Value,
+		}
+		for _, path := range externalOptionDefault.Paths {
+			normalizedPath, err := normalpath.NormalizeAndValidate(path)
+			if err != nil {
+				return nil, fmt.Errorf(`option_defaults path "%s" for option "%s" in %s is invalid: %w`, normalpath.Unnormalize(path), option, workspaceID, err)
+			}
+			optionDefault.Paths = append(optionDefault.Paths, normalizedPath)
+		}
+		if len(optionDefault.Paths) > 0 {
+			optionDefault.Paths = slicesext.ToUniqueSorted(optionDefault.Paths)
+		}
+		optionDefaults = append(optionDefaults, optionDefault)
+	}
+	return optionDefaults, nil
+}
+
+// isFullyQualifiedName returns true if the name is a valid fully-qualified
+// Protobuf name without a leading dot, such as acme.service.tier.
+func isFullyQualifiedName(name string) bool {
+	for _, component := range strings.Split(name, ".") {
+		if component == "" {
+			return false
+		}
+		for i, r := range component {
+			switch {
+			case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+			case '0' <= r && r <= '9' && i > 0:
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // validateOverlap returns a non-nil error if any of the directories overlap
 // with each other. The given directories are expected to be sorted.
 func validateConfigurationOverlap(directories []string, workspaceID string) error {
@@ -45,3 +45,191 @@ func TestNewConfigV1RootDirectoryError(t *testing.T) {
 	)
 	require.Error(t, err)
 }
+
+func TestNewConfigV1ModuleTags(t *testing.T) {
+	t.Parallel()
+	config, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"foo", "bar", "baz"},
+			ModuleTags: map[string][]string{
+				"./foo": {"public", "internal", "public"},
+				"bar":   {"internal"},
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		map[string][]string{
+			"foo": {"internal", "public"},
+			"bar": {"internal"},
+		},
+		config.ModuleTags,
+	)
+	require.Equal(t, []string{"bar", "baz", "foo"}, config.DirectoriesForModuleTags(nil))
+	require.Equal(t, []string{"foo"}, config.DirectoriesForModuleTags([]string{"public"}))
+	require.Equal(t, []string{"bar", "foo"}, config.DirectoriesForModuleTags([]string{"internal", "public"}))
+	require.Empty(t, config.DirectoriesForModuleTags([]string{"unknown"}))
+}
+
+func TestNewConfigV1ModuleTagsUnknownDirectoryError(t *testing.T) {
+	t.Parallel()
+	_, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"foo"},
+			ModuleTags: map[string][]string{
+				"bar": {"public"},
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.Error(t, err)
+}
+
+func TestNewConfigV1ModuleTagsEmptyTagError(t *testing.T) {
+	t.Parallel()
+	_, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"foo"},
+			ModuleTags: map[string][]string{
+				"foo": {" "},
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.Error(t, err)
+}
+
+func TestNewConfigV1Patches(t *testing.T) {
+	t.Parallel()
+	config, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"proto"},
+			Patches: map[string]string{
+				"buf.build/acme/petapis": "./patches/petapis",
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"buf.build/acme/petapis": "patches/petapis"}, config.Patches)
+}
+
+func TestNewConfigV1PatchesErrors(t *testing.T) {
+	t.Parallel()
+	for _, patches := range []map[string]string{
+		{"acme/petapis": "patches"},
+		{"buf.build/acme/petapis": "."},
+		{"buf.build/acme/petapis": "proto/patches"},
+		{"buf.build/acme/petapis": "../patches"},
+	} {
+		_, err := newConfigV1(
+			ExternalConfigV1{
+				Version:     "v1",
+				Directories: []string{"proto"},
+				Patches:     patches,
+			},
+			"buf.work.yaml",
+		)
+		require.Error(t, err, patches)
+	}
+}
+
+func TestNewConfigV1Frozen(t *testing.T) {
+	t.Parallel()
+	config, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"proto", "vendor/googleapis"},
+			Frozen: map[string]string{
+				"./vendor/googleapis": "b3-abc",
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"vendor/googleapis": "b3-abc"}, config.Frozen)
+}
+
+func TestNewConfigV1FrozenErrors(t *testing.T) {
+	t.Parallel()
+	for _, frozen := range []map[string]string{
+		{"vendor": "b3-abc"},
+		{"proto": ""},
+		{"../proto": "b3-abc"},
+	} {
+		_, err := newConfigV1(
+			ExternalConfigV1{
+				Version:     "v1",
+				Directories: []string{"proto"},
+				Frozen:      frozen,
+			},
+			"buf.work.yaml",
+		)
+		require.Error(t, err, frozen)
+	}
+}
+
+func TestNewConfigV1OptionDefaults(t *testing.T) {
+	t.Parallel()
+	config, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"proto"},
+			OptionDefaults: []ExternalOptionDefaultV1{
+				{
+					Option: ".acme.service.tier",
+					Value:  "TIER_GOLD",
+					Paths:  []string{"./acme/payments", "acme/billing"},
+				},
+				{
+					Option: "acme.service.owner",
+					Value:  "platform",
+				},
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.NoError(t, err)
+	require.Equal(
+		t,
+		[]*OptionDefault{
+			{
+				Option: "acme.service.tier",
+				Value:  "TIER_GOLD",
+				Paths:  []string{"acme/billing", "acme/payments"},
+			},
+			{
+				Option: "acme.service.owner",
+				Value:  "platform",
+			},
+		},
+		config.OptionDefaults,
+	)
+}
+
+func TestNewConfigV1OptionDefaultsErrors(t *testing.T) {
+	t.Parallel()
+	for _, optionDefault := range []ExternalOptionDefaultV1{
+		{Value: "TIER_GOLD"},
+		{Option: "acme.service.tier"},
+		{Option: "acme..tier", Value: "TIER_GOLD"},
+		{Option: "acme.service.(tier)", Value: "TIER_GOLD"},
+		{Option: "acme.service.tier", Value: "TIER_GOLD", Paths: []string{"../acme"}},
+	} {
+		_, err := newConfigV1(
+			ExternalConfigV1{
+				Version:        "v1",
+				Directories:    []string{"proto"},
+				OptionDefaults: []ExternalOptionDefaultV1{optionDefault},
+			},
+			"buf.work.yaml",
+		)
+		require.Error(t, err, optionDefault)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufwork
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage"
+)
+
+// inferredWorkspaceID is used in place of the path of a buf.work.yaml in errors
+// for configurations returned by InferConfig.
+const inferredWorkspaceID = "inferred workspace configuration"
+
+var packageRegexp = regexp.MustCompile(`(?m)^\s*package\s+([A-Za-z_][A-Za-z0-9_.]*)\s*;`)
+
+func inferDirectories(ctx context.Context, readBucket storage.ReadBucket) ([]string, error) {
+	// Roots of the files whose directory matches their package, and the directories
+	// of all other files. The latter only become roots if no root contains them.
+	rootSet := make(map[string]struct{})
+	otherDirPathSet := make(map[string]struct{})
+	if err := storage.WalkReadObjects(
+		ctx,
+		storage.MapReadBucket(readBucket, storage.MatchPathExt(".proto")),
+		"",
+		func(readObject storage.ReadObject) error {
+			path := readObject.Path()
+			if isHiddenPath(path) {
+				return nil
+			}
+			data, err := io.ReadAll(readObject)
+			if err != nil {
+				return err
+			}
+			dirPath := normalpath.Dir(path)
+			if root, ok := packageRoot(dirPath, data); ok {
+				rootSet[root] = struct{}{}
+			} else {
+				otherDirPathSet[dirPath] = struct{}{}
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	for otherDirPath := range otherDirPathSet {
+		if !containedInAny(otherDirPath, rootSet) {
+			rootSet[otherDirPath] = struct{}{}
+		}
+	}
+	roots := make([]string, 0, len(rootSet))
+	for root := range rootSet {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	if len(roots) == 0 || (len(roots) == 1 && roots[0] == ".") {
+		return nil, nil
+	}
+	for i, root := range roots {
+		for _, other := range roots[i+1:] {
+			if normalpath.EqualsOrContainsPath(root, other, normalpath.Relative) {
+				return nil, fmt.Errorf(
+					`could not infer a workspace, as the package roots "%s" and "%s" overlap, add a buf.work.yaml to configure the workspace`,
+					normalpath.Unnormalize(root),
+					normalpath.Unnormalize(other),
+				)
+			}
+		}
+	}
+	return roots, nil
+}
+
+// packageRoot returns the directory that dirPath is relative to if the package of the
+// file with the given data matches the trailing directories of dirPath.
+//
+// For example, a file in "proto/acme/pet/v1" with package "acme.pet.v1" has the root "proto".
+func packageRoot(dirPath string, data []byte) (string, bool) {
+	match := packageRegexp.FindSubmatch(stripComments(data))
+	if match == nil {
+		return "", false
+	}
+	packagePath := strings.ReplaceAll(string(match[1]), ".", "/")
+	if dirPath == packagePath {
+		return ".", true
+	}
+	if root, ok := strings.CutSuffix(dirPath, "/"+packagePath); ok {
+		return root, true
+	}
+	return "", false
+}
+
+// stripComments returns a copy of data with all comments replaced by spaces, so that
+// a package statement within a comment is not matched. Newlines are kept, as the
+// package statement is matched at the start of a line.
+func stripComments(data []byte) []byte {
+	stripped := make([]byte, len(data))
+	copy(stripped, data)
+	for i := 0; i < len(stripped); i++ {
+		switch c := stripped[i]; {
+		case c == '"' || c == '\'':
+			// Skip string literals, which may contain "//" or "/*".
+			for i++; i < len(stripped) && stripped[i] != c && stripped[i] != '\n'; i++ {
+				if stripped[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(stripped) && stripped[i+1] == '/':
+			for ; i < len(stripped) && stripped[i] != '\n'; i++ {
+				stripped[i] = ' '
+			}
+		case c == '/' && i+1 < len(stripped) && stripped[i+1] == '*':
+			stripped[i], stripped[i+1] = ' ', ' '
+			for i += 2; i < len(stripped); i++ {
+				if stripped[i] == '*' && i+1 < len(stripped) && stripped[i+1] == '/' {
+					stripped[i], stripped[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if stripped[i] != '\n' {
+					stripped[i] = ' '
+				}
+			}
+		}
+	}
+	return stripped
+}
+
+func containedInAny(path string, rootSet map[string]struct{}) bool {
+	for root := range rootSet {
+		if normalpath.EqualsOrContainsPath(root, path, normalpath.Relative) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHiddenPath returns true if any component of the path starts with a ".", such
+// as files within a .git or .cache directory.
+func isHiddenPath(path string) bool {
+	for _, component := range normalpath.Components(path) {
+		if strings.HasPrefix(component, ".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufwork
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferDirectories(t *testing.T) {
+	t.Parallel()
+	testInferDirectories(
+		t,
+		map[string]string{
+			"petapis/acme/pet/v1/pet.proto":             "syntax = \"proto3\";\npackage acme.pet.v1;\n",
+			"petapis/acme/pet/v1/pet_service.proto":     "syntax = \"proto3\";\npackage acme.pet.v1;\n",
+			"paymentapis/acme/payment/v2/payment.proto": "syntax = \"proto3\";\n\n  package acme.payment.v2 ;\n",
+			// Does not match its directory, but is within the root of petapis.
+			"petapis/acme/pet/v1/internal/other.proto": "syntax = \"proto3\";\npackage other;\n",
+			// No package, rooted at its directory.
+			"legacy/common.proto": "syntax = \"proto3\";\n",
+			// Hidden directories are ignored.
+			".cache/foo/v1/foo.proto": "syntax = \"proto3\";\npackage foo.v1;\n",
+		},
+		[]string{"legacy", "paymentapis", "petapis"},
+	)
+}
+
+func TestInferDirectoriesSingleModule(t *testing.T) {
+	t.Parallel()
+	testInferDirectories(
+		t,
+		map[string]string{
+			"acme/pet/v1/pet.proto":             "syntax = \"proto3\";\npackage acme.pet.v1;\n",
+			"acme/payment/v2/payment.proto":     "syntax = \"proto3\";\npackage acme.payment.v2;\n",
+			"acme/payment/v2/other/other.proto": "syntax = \"proto3\";\npackage misc;\n",
+		},
+		nil,
+	)
+}
+
+func TestInferDirectoriesCommentedPackage(t *testing.T) {
+	t.Parallel()
+	testInferDirectories(
+		t,
+		map[string]string{
+			"proto/acme/pet/v1/pet.proto": "syntax = \"proto3\";\n// package foo;\npackage acme.pet.v1;\n",
+			// The package statements in comments are not the package of the file.
+			"proto/acme/pet/v1/old/old.proto": "syntax = \"proto3\";\n/*\npackage acme.pet.v1.old;\n*/\n",
+			"other/old/v1/old.proto":          "syntax = \"proto3\";\n/* a\n  package old.v1; */\noption go_package = \"a/*b\";\n",
+		},
+		[]string{"other/old/v1", "proto"},
+	)
+}
+
+func TestInferDirectoriesOverlap(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"proto/acme/pet/v1/pet.proto":             []byte("syntax = \"proto3\";\npackage acme.pet.v1;\n"),
+			"proto/vendor/acme/pay/v1/payment.proto":  []byte("syntax = \"proto3\";\npackage acme.pay.v1;\n"),
+			"proto/vendor/acme/pay/v1/payment2.proto": []byte("syntax = \"proto3\";\npackage acme.pay.v1;\n"),
+		},
+	)
+	require.NoError(t, err)
+	_, err = InferDirectories(context.Background(), readBucket)
+	assert.ErrorContains(t, err, `package roots "proto" and "proto/vendor" overlap`)
+}
+
+func TestInferConfig(t *testing.T) {
+	t.Parallel()
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"a/foo/v1/foo.proto": []byte("syntax = \"proto3\";\npackage foo.v1;\n"),
+			"b/bar/v1/bar.proto": []byte("syntax = \"proto3\";\npackage bar.v1;\n"),
+		},
+	)
+	require.NoError(t, err)
+	config, err := InferConfig(context.Background(), readBucket)
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, []string{"a", "b"}, config.Directories)
+}
+
+func testInferDirectories(t *testing.T, pathToContent map[string]string, expected []string) {
+	pathToData := make(map[string][]byte, len(pathToContent))
+	for path, content := range pathToContent {
+		pathToData[path] = []byte(content)
+	}
+	readBucket, err := storagemem.NewReadBucket(pathToData)
+	require.NoError(t, err)
+	directories, err := InferDirectories(context.Background(), readBucket)
+	require.NoError(t, err)
+	assert.Equal(t, expected, directories)
+}
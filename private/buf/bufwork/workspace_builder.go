@@ -151,6 +151,11 @@ func (w *workspaceBuilder) BuildWorkspace(
 				err,
 			)
 		}
+		if digest, ok := workspaceConfig.Frozen[directory]; ok {
+			if err := validateFrozenModule(ctx, module, directory, digest, workspaceID); err != nil {
+				return nil, err
+			}
+		}
 		w.moduleCache[directory] = newCachedModule(
 			module,
 			moduleConfig,
@@ -167,10 +172,29 @@ func (w *workspaceBuilder) BuildWorkspace(
 		}
 		allModules = append(allModules, module)
 	}
+	var modulePatches map[string]storage.ReadBucket
+	if len(workspaceConfig.Patches) > 0 {
+		modulePatches = make(map[string]storage.ReadBucket, len(workspaceConfig.Patches))
+		for identityString, patchDirectory := range workspaceConfig.Patches {
+			if _, ok := namedModules[identityString]; ok {
+				return nil, fmt.Errorf(
+					"module %q is provided by a workspace directory and cannot also be patched in %s",
+					identityString,
+					workspaceID,
+				)
+			}
+			patchReadBucket := storage.MapReadBucket(readBucket, storage.MapOnPrefix(patchDirectory))
+			if err := validatePatchDirectoryNonEmpty(ctx, patchReadBucket, identityString, patchDirectory, workspaceID); err != nil {
+				return nil, err
+			}
+			modulePatches[identityString] = patchReadBucket
+		}
+	}
 	return bufmodule.NewWorkspace(
 		ctx,
 		namedModules,
 		allModules,
+		bufmodule.WorkspaceWithModulePatches(modulePatches),
 	)
 }
 
@@ -208,6 +232,32 @@ func validateWorkspaceDirectoryNonEmpty(
 	return nil
 }
 
+func validatePatchDirectoryNonEmpty(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	identityString string,
+	patchDirectory string,
+	workspaceID string,
+) error {
+	isEmpty, err := storage.IsEmpty(
+		ctx,
+		storage.MapReadBucket(readBucket, storage.MatchPathExt(".proto")),
+		"",
+	)
+	if err != nil {
+		return err
+	}
+	if isEmpty {
+		return fmt.Errorf(
+			`patches directory "%s" for module %q listed in %s does not exist or contains no .proto files`,
+			normalpath.Unnormalize(patchDirectory),
+			identityString,
+			workspaceID,
+		)
+	}
+	return nil
+}
+
 // validateInputOverlap returns a non-nil error if the given directories
 // overlap in either direction. The last argument is only used for
 // error reporting.
@@ -276,3 +326,29 @@ func newCachedModule(
 		moduleConfig: moduleConfig,
 	}
 }
+
+// validateFrozenModule returns an error if the digest of the module built from a frozen
+// directory is not the expected digest.
+func validateFrozenModule(
+	ctx context.Context,
+	module bufmodule.Module,
+	directory string,
+	expectedDigest string,
+	workspaceID string,
+) error {
+	digest, err := bufmodule.ModuleDigestB3(ctx, module)
+	if err != nil {
+		return err
+	}
+	if digest != expectedDigest {
+		return fmt.Errorf(
+			`directory "%s" is frozen in %s, but its content has changed: expected digest %s, got %s. Frozen directories are copies of published modules and must not be edited locally, restore the directory from the published module or update its digest in %s`,
+			normalpath.Unnormalize(directory),
+			workspaceID,
+			expectedDigest,
+			digest,
+			workspaceID,
+		)
+	}
+	return nil
+}
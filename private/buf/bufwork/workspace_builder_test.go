@@ -0,0 +1,88 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufwork
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildWorkspacePatches(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	config, err := newConfigV1(
+		ExternalConfigV1{
+			Version:     "v1",
+			Directories: []string{"proto"},
+			Patches: map[string]string{
+				"buf.build/acme/petapis": "patches/petapis",
+			},
+		},
+		"buf.work.yaml",
+	)
+	require.NoError(t, err)
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"proto/a.proto":                           []byte(`syntax = "proto3";`),
+			"patches/petapis/acme/pet/v1/pet.proto":   []byte(`syntax = "proto3";`),
+			"patches/petapis/acme/pet/v1/README.md":   []byte("not a proto file"),
+			"patches/storeapis/acme/store/v1/a.proto": []byte(`syntax = "proto3";`),
+		},
+	)
+	require.NoError(t, err)
+	workspace, err := NewWorkspaceBuilder().BuildWorkspace(ctx, config, readBucket, ".", "proto", "", nil, nil, false)
+	require.NoError(t, err)
+	moduleIdentity, err := bufmoduleref.ModuleIdentityForString("buf.build/acme/petapis")
+	require.NoError(t, err)
+	patchReadBucket, ok := workspace.GetModulePatch(moduleIdentity)
+	require.True(t, ok)
+	exists, err := storage.Exists(ctx, patchReadBucket, "acme/pet/v1/pet.proto")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestBuildWorkspacePatchesEmptyDirectory(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, patchDirectory := range []string{"patches/missing", "patches/docs"} {
+		config, err := newConfigV1(
+			ExternalConfigV1{
+				Version:     "v1",
+				Directories: []string{"proto"},
+				Patches: map[string]string{
+					"buf.build/acme/petapis": patchDirectory,
+				},
+			},
+			"buf.work.yaml",
+		)
+		require.NoError(t, err)
+		readBucket, err := storagemem.NewReadBucket(
+			map[string][]byte{
+				"proto/a.proto":          []byte(`syntax = "proto3";`),
+				"patches/docs/README.md": []byte("not a proto file"),
+			},
+		)
+		require.NoError(t, err)
+		_, err = NewWorkspaceBuilder().BuildWorkspace(ctx, config, readBucket, ".", "proto", "", nil, nil, false)
+		require.Error(t, err, patchDirectory)
+		assert.Contains(t, err.Error(), "does not exist or contains no .proto files")
+	}
+}
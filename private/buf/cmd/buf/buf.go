@@ -30,9 +30,30 @@ import (
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/alpha/registry/token/tokenlist"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/alpha/repo/reposync"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/alpha/workspace/workspacepush"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/anonymize"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/bench"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/cache/cacheexplain"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/cache/cachestats"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/codeowners"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/compatibilitymatrix"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/config/configmigraterules"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/config/configupgradereadiness"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/config/configvalidate"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/confluent/confluentexport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/confluent/confluentimport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/consumptionreport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/coverage"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/enumreport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/envoytranscoder"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/explainimport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/fieldnumber"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/fuzz"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/graph"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/migrateimports"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/migratev1beta1"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/optiondocs"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/price"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/prunesourceinfo"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/commit/commitget"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/commit/commitlist"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/draft/draftdelete"
@@ -54,8 +75,20 @@ import (
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/webhook/webhookcreate"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/webhook/webhookdelete"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/registry/webhook/webhooklist"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/scaffold"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/sizereport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/snapshot/snapshotcreate"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/snapshot/snapshotrestore"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/snapshot/snapshotverify"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/specserver"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/stats"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/studioagent"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/sunsetreport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/telemetry/telemetryreport"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/verifybuild"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/whoami"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/workspace/workspacedoctor"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/beta/workspace/workspaceinfer"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/breaking"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/build"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/convert"
@@ -73,10 +106,12 @@ import (
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/mod/modprune"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/mod/modupdate"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/push"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/command/registry/registrycommitdiff"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/registry/registrylogin"
 	"github.com/bufbuild/buf/private/buf/cmd/buf/command/registry/registrylogout"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/spf13/pflag"
 )
 
 // Main is the entrypoint to the buf CLI.
@@ -88,17 +123,28 @@ func Main(name string) {
 //
 // This is public for use in testing.
 func NewRootCommand(name string) *appcmd.Command {
+	var exitCodeScheme string
 	builder := appflag.NewBuilder(
 		name,
 		appflag.BuilderWithTimeout(120*time.Second),
 		appflag.BuilderWithTracing(),
+		// This is first so that the other interceptors see the errors of commands unchanged.
+		appflag.BuilderWithInterceptor(bufcli.NewExitCodeInterceptor(&exitCodeScheme)),
+		appflag.BuilderWithInterceptor(bufcli.NewTelemetryInterceptor()),
+		appflag.BuilderWithInterceptor(bufcli.NewCacheStatsInterceptor()),
+	)
+	bindRootFlags := appcmd.BindMultiple(
+		builder.BindRoot,
+		func(flagSet *pflag.FlagSet) {
+			bufcli.BindExitCodeScheme(flagSet, &exitCodeScheme)
+		},
 	)
 	return &appcmd.Command{
 		Use:                 name,
 		Short:               "The Buf CLI",
 		Long:                "A tool for working with Protocol Buffers and managing resources on the Buf Schema Registry (BSR)",
 		Version:             bufcli.Version,
-		BindPersistentFlags: builder.BindRoot,
+		BindPersistentFlags: bindRootFlags,
 		SubCommands: []*appcmd.Command{
 			build.NewCommand("build", builder),
 			export.NewCommand("export", builder),
@@ -129,17 +175,93 @@ func NewRootCommand(name string) *appcmd.Command {
 				SubCommands: []*appcmd.Command{
 					registrylogin.NewCommand("login", builder),
 					registrylogout.NewCommand("logout", builder),
+					{
+						Use:   "commit",
+						Short: "Manage a module's commits",
+						SubCommands: []*appcmd.Command{
+							registrycommitdiff.NewCommand("diff", builder),
+						},
+					},
 				},
 			},
 			{
 				Use:   "beta",
 				Short: "Beta commands. Unstable and likely to change",
 				SubCommands: []*appcmd.Command{
+					anonymize.NewCommand("anonymize", builder),
+					bench.NewCommand("bench", builder),
+					codeowners.NewCommand("codeowners", builder),
+					coverage.NewCommand("coverage", builder),
+					envoytranscoder.NewCommand("envoy-transcoder", builder),
+					fuzz.NewCommand("fuzz", builder),
 					graph.NewCommand("graph", builder),
+					explainimport.NewCommand("explain-import", builder),
+					optiondocs.NewCommand("option-docs", builder),
 					price.NewCommand("price", builder),
 					stats.NewCommand("stats", builder),
+					sizereport.NewCommand("size-report", builder),
+					enumreport.NewCommand("enum-report", builder),
+					fieldnumber.NewCommand("field-number", builder),
+					consumptionreport.NewCommand("consumption-report", builder),
+					sunsetreport.NewCommand("sunset-report", builder),
+					migrateimports.NewCommand("migrate-imports", builder),
 					migratev1beta1.NewCommand("migrate-v1beta1", builder),
 					studioagent.NewCommand("studio-agent", builder),
+					specserver.NewCommand("spec-server", builder),
+					verifybuild.NewCommand("verify-build", builder),
+					scaffold.NewCommand("scaffold", builder),
+					compatibilitymatrix.NewCommand("compatibility-matrix", builder),
+					prunesourceinfo.NewCommand("prune-source-info", builder),
+					whoami.NewCommand("whoami", builder),
+					{
+						Use:   "config",
+						Short: "Work with configuration files",
+						SubCommands: []*appcmd.Command{
+							configmigraterules.NewCommand("migrate-rules", builder),
+							configupgradereadiness.NewCommand("upgrade-readiness", builder),
+							configvalidate.NewCommand("validate", builder),
+						},
+					},
+					{
+						Use:   "confluent",
+						Short: "Exchange schemas with a Confluent Schema Registry",
+						SubCommands: []*appcmd.Command{
+							confluentexport.NewCommand("export", builder),
+							confluentimport.NewCommand("import", builder),
+						},
+					},
+					{
+						Use:   "snapshot",
+						Short: "Capture, verify, and restore the resolved state of a workspace",
+						SubCommands: []*appcmd.Command{
+							snapshotcreate.NewCommand("create", builder),
+							snapshotrestore.NewCommand("restore", builder),
+							snapshotverify.NewCommand("verify", builder),
+						},
+					},
+					{
+						Use:   "workspace",
+						Short: "Inspect workspaces",
+						SubCommands: []*appcmd.Command{
+							workspacedoctor.NewCommand("doctor", builder),
+							workspaceinfer.NewCommand("infer", builder),
+						},
+					},
+					{
+						Use:   "cache",
+						Short: "Inspect the module cache",
+						SubCommands: []*appcmd.Command{
+							cacheexplain.NewCommand("explain", builder),
+							cachestats.NewCommand("stats", builder),
+						},
+					},
+					{
+						Use:   "telemetry",
+						Short: "Inspect locally recorded usage",
+						SubCommands: []*appcmd.Command{
+							telemetryreport.NewCommand("report", builder),
+						},
+					},
 					{
 						Use:   "registry",
 						Short: "Manage assets on the Buf Schema Registry",
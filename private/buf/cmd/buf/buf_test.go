@@ -17,6 +17,7 @@ package buf
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -398,6 +399,125 @@ func TestFail13(t *testing.T) {
 	)
 }
 
+func TestExitCodeScheme(t *testing.T) {
+	t.Parallel()
+	lintStdout := filepath.FromSlash(`testdata/fail_buf_mod/buf/buf.proto:3:1:Files with package "other" must be within a directory "other" relative to root but were in directory "buf".`)
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeLintViolation,
+		lintStdout,
+		"lint",
+		filepath.Join("testdata", "fail_buf_mod"),
+		"--exit-code-scheme",
+		"detailed",
+	)
+	testRunStdout(
+		t,
+		nil,
+		0,
+		lintStdout,
+		"lint",
+		filepath.Join("testdata", "fail_buf_mod"),
+		"--exit-code-scheme",
+		"report-only",
+	)
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeBreakingViolation,
+		filepath.FromSlash(`testdata/protofileref/breaking/a/foo.proto:7:3:Field "2" on message "Foo" changed type from "int32" to "string".`),
+		"breaking",
+		filepath.Join("testdata", "protofileref", "breaking", "a", "foo.proto"),
+		"--against",
+		filepath.Join("testdata", "protofileref", "breaking", "b", "foo.proto"),
+		"--exit-code-scheme",
+		"detailed",
+	)
+	// Compile errors are not lint violations.
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeFileAnnotation,
+		fmt.Sprintf("%v:5:8:read buf/buf.proto: file does not exist", filepath.FromSlash("testdata/fail2/buf/buf2.proto")),
+		"lint",
+		"--path",
+		filepath.Join("testdata", "fail2", "buf", "buf2.proto"),
+		filepath.Join("testdata"),
+		"--exit-code-scheme",
+		"report-only",
+	)
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeInvalidConfig,
+		``,
+		"lint",
+		filepath.Join("testdata", "success"),
+		"--config",
+		`{"version":"v2"}`,
+		"--exit-code-scheme",
+		"detailed",
+	)
+	// The default exit code scheme is unchanged.
+	testRunStdout(
+		t,
+		nil,
+		1,
+		``,
+		"lint",
+		filepath.Join("testdata", "success"),
+		"--config",
+		`{"version":"v2"}`,
+	)
+	testRunStdout(
+		t,
+		nil,
+		1,
+		``,
+		"lint",
+		filepath.Join("testdata", "success"),
+		"--exit-code-scheme",
+		"unknown",
+	)
+}
+
+func TestLintAgainst(t *testing.T) {
+	t.Parallel()
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeFileAnnotation,
+		filepath.FromSlash(`
+		../../../bufpkg/bufcheck/buflint/testdata/field_removed_reserved/a.proto:5:1:Field "2" with name "two" on message "One" was removed without reserving the number "2".
+		../../../bufpkg/bufcheck/buflint/testdata/field_removed_reserved/a.proto:13:1:Field "2" with name "two" on message "Two" was removed without reserving the number "2" and the name "two".
+		`),
+		"lint",
+		filepath.Join("..", "..", "..", "bufpkg", "bufcheck", "buflint", "testdata", "field_removed_reserved"),
+		"--against",
+		filepath.Join("..", "..", "..", "bufpkg", "bufcheck", "buflint", "testdata_previous", "field_removed_reserved"),
+	)
+}
+
+func TestLintFixJSON(t *testing.T) {
+	t.Parallel()
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeFileAnnotation,
+		filepath.FromSlash(`
+		{"path":"../../../bufpkg/bufcheck/buflint/testdata/service_pascal_case/a.proto","start_line":8,"start_column":9,"end_line":8,"end_column":13,"type":"SERVICE_PASCAL_CASE","message":"Service name \"fail\" should be PascalCase, such as \"Fail\".","fix":{"start_offset":108,"end_offset":112,"replacement":"Fail"}}
+		{"path":"../../../bufpkg/bufcheck/buflint/testdata/service_pascal_case/a.proto","start_line":9,"start_column":9,"end_line":9,"end_column":16,"type":"SERVICE_PASCAL_CASE","message":"Service name \"failTwo\" should be PascalCase, such as \"FailTwo\".","fix":{"start_offset":124,"end_offset":131,"replacement":"FailTwo"}}
+		{"path":"../../../bufpkg/bufcheck/buflint/testdata/service_pascal_case/a.proto","start_line":10,"start_column":9,"end_line":10,"end_column":19,"type":"SERVICE_PASCAL_CASE","message":"Service name \"fail_three\" should be PascalCase, such as \"FailThree\".","fix":{"start_offset":143,"end_offset":153,"replacement":"FailThree"}}
+		{"path":"../../../bufpkg/bufcheck/buflint/testdata/service_pascal_case/a.proto","start_line":11,"start_column":9,"end_line":11,"end_column":18,"type":"SERVICE_PASCAL_CASE","message":"Service name \"Fail_four\" should be PascalCase, such as \"FailFour\".","fix":{"start_offset":165,"end_offset":174,"replacement":"FailFour"}}
+		`),
+		"lint",
+		filepath.Join("..", "..", "..", "bufpkg", "bufcheck", "buflint", "testdata", "service_pascal_case"),
+		"--error-format",
+		"json",
+	)
+}
+
 func TestFailCheckBreaking1(t *testing.T) {
 	t.Parallel()
 	testRunStdoutStderrNoWarn(
@@ -532,7 +652,10 @@ COMMENT_RPC                       COMMENTS                 Checks that RPCs have
 COMMENT_SERVICE                   COMMENTS                 Checks that services have non-empty comments.
 RPC_NO_CLIENT_STREAMING           UNARY_RPC                Checks that RPCs are not client streaming.
 RPC_NO_SERVER_STREAMING           UNARY_RPC                Checks that RPCs are not server streaming.
+FIELD_REMOVED_RESERVED                                     Checks that fields removed since the against input have their numbers and names reserved.
 PACKAGE_NO_IMPORT_CYCLE                                    Checks that packages do not have import cycles.
+PACKAGE_OWNER_DEFINED                                      Checks that all packages have an owner defined in package_owners.
+SUNSET_NOT_PASSED                                          Checks that no deprecated elements in sunset_dates are past their sunset date.
 		`
 	testRunStdout(
 		t,
@@ -563,6 +686,73 @@ func TestCheckLsLintRules2(t *testing.T) {
 	)
 }
 
+func TestCheckLsLintRulesJSON(t *testing.T) {
+	t.Parallel()
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+		{"id":"PACKAGE_DIRECTORY_MATCH","categories":["MINIMAL","BASIC","DEFAULT","FILE_LAYOUT"],"default":true,"purpose":"Checks that all files are in a directory that matches their package name.","documentation_url":"https://buf.build/docs/lint/rules#package_directory_match"}
+		{"id":"ENUM_NO_ALLOW_ALIAS","categories":["MINIMAL","BASIC","DEFAULT","SENSIBLE"],"default":true,"purpose":"Checks that enums do not have the allow_alias option set.","documentation_url":"https://buf.build/docs/lint/rules#enum_no_allow_alias"}
+		`,
+		"mod",
+		"ls-lint-rules",
+		"--format",
+		"json",
+		"--config",
+		filepath.Join("testdata", "small_list_rules", bufconfig.ExternalConfigV1FilePath),
+	)
+}
+
+func TestCheckLsLintRulesMarkdown(t *testing.T) {
+	t.Parallel()
+	testRunStdout(
+		t,
+		nil,
+		0,
+		`
+		# Rules
+
+		| ID | Categories | Default | Purpose |
+		| --- | --- | --- | --- |
+		| [`+"`PACKAGE_DIRECTORY_MATCH`"+`](https://buf.build/docs/lint/rules#package_directory_match) | MINIMAL, BASIC, DEFAULT, FILE_LAYOUT | yes | Checks that all files are in a directory that matches their package name. |
+		| [`+"`ENUM_NO_ALLOW_ALIAS`"+`](https://buf.build/docs/lint/rules#enum_no_allow_alias) | MINIMAL, BASIC, DEFAULT, SENSIBLE | yes | Checks that enums do not have the allow_alias option set. |
+
+		# Categories
+
+		## MINIMAL
+
+		- [`+"`PACKAGE_DIRECTORY_MATCH`"+`](https://buf.build/docs/lint/rules#package_directory_match)
+		- [`+"`ENUM_NO_ALLOW_ALIAS`"+`](https://buf.build/docs/lint/rules#enum_no_allow_alias)
+
+		## BASIC
+
+		- [`+"`PACKAGE_DIRECTORY_MATCH`"+`](https://buf.build/docs/lint/rules#package_directory_match)
+		- [`+"`ENUM_NO_ALLOW_ALIAS`"+`](https://buf.build/docs/lint/rules#enum_no_allow_alias)
+
+		## DEFAULT
+
+		- [`+"`PACKAGE_DIRECTORY_MATCH`"+`](https://buf.build/docs/lint/rules#package_directory_match)
+		- [`+"`ENUM_NO_ALLOW_ALIAS`"+`](https://buf.build/docs/lint/rules#enum_no_allow_alias)
+
+		## FILE_LAYOUT
+
+		- [`+"`PACKAGE_DIRECTORY_MATCH`"+`](https://buf.build/docs/lint/rules#package_directory_match)
+
+		## SENSIBLE
+
+		- [`+"`ENUM_NO_ALLOW_ALIAS`"+`](https://buf.build/docs/lint/rules#enum_no_allow_alias)
+		`,
+		"mod",
+		"ls-lint-rules",
+		"--format",
+		"markdown",
+		"--config",
+		filepath.Join("testdata", "small_list_rules", bufconfig.ExternalConfigV1FilePath),
+	)
+}
+
 func TestCheckLsLintRules3(t *testing.T) {
 	t.Parallel()
 	expectedStdout := `
@@ -1190,6 +1380,49 @@ lint:
 	)
 }
 
+func TestModUpdateAutoGoogleapisIgnoresExcludedFiles(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	require.NoError(
+		t,
+		os.WriteFile(
+			filepath.Join(tempDir, "buf.yaml"),
+			[]byte(`version: v1
+build:
+  excludes:
+    - excluded
+  auto_googleapis: true
+`),
+			0600,
+		),
+	)
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "excluded"), 0700))
+	require.NoError(
+		t,
+		os.WriteFile(
+			filepath.Join(tempDir, "excluded", "a.proto"),
+			[]byte(`syntax = "proto3";
+import "google/type/date.proto";
+`),
+			0600,
+		),
+	)
+	// The only import of googleapis is in an excluded file, so no dependency is added
+	// and nothing has to be resolved on the BSR.
+	testRunStdout(
+		t,
+		nil,
+		0,
+		``,
+		"mod",
+		"update",
+		tempDir,
+	)
+	lockData, err := os.ReadFile(filepath.Join(tempDir, "buf.lock"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(lockData), "googleapis")
+}
+
 func TestExportProto(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
@@ -1266,6 +1499,101 @@ func TestExportAll(t *testing.T) {
 	)
 }
 
+func TestExportAgainstLock(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	testRunStdout(
+		t,
+		nil,
+		0,
+		``,
+		"export",
+		"-o",
+		tempDir,
+		"--against-lock",
+		filepath.Join("testdata", "export"),
+	)
+	readWriteBucket, err := storageos.NewProvider().NewReadWriteBucket(tempDir)
+	require.NoError(t, err)
+	storagetesting.AssertPaths(
+		t,
+		readWriteBucket,
+		"",
+		"another.proto",
+		"buf.export.json",
+		"request.proto",
+		"rpc.proto",
+		"unimported.proto",
+	)
+	data, err := storage.ReadPath(context.Background(), readWriteBucket, "buf.export.json")
+	require.NoError(t, err)
+	var manifest struct {
+		Version string `json:"version"`
+		Files   []struct {
+			Path   string `json:"path"`
+			Digest string `json:"digest"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "v1", manifest.Version)
+	paths := make([]string, 0, len(manifest.Files))
+	for _, file := range manifest.Files {
+		paths = append(paths, file.Path)
+		assert.True(t, strings.HasPrefix(file.Digest, "shake256:"), file.Digest)
+	}
+	assert.Equal(t, []string{"another.proto", "request.proto", "rpc.proto", "unimported.proto"}, paths)
+}
+
+func TestExportAgainstLockPinnedDependency(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	testRunStderrWithCache(
+		t,
+		nil,
+		0,
+		nil,
+		"export",
+		"-o",
+		tempDir,
+		"--against-lock",
+		filepath.Join("testdata", "imports", "success", "students"),
+	)
+	readWriteBucket, err := storageos.NewProvider().NewReadWriteBucket(tempDir)
+	require.NoError(t, err)
+	storagetesting.AssertPaths(
+		t,
+		readWriteBucket,
+		"",
+		"buf.export.json",
+		"people/v1/people1.proto",
+		"people/v1/people2.proto",
+		"students/v1/students.proto",
+	)
+	data, err := storage.ReadPath(context.Background(), readWriteBucket, "buf.export.json")
+	require.NoError(t, err)
+	var manifest struct {
+		Dependencies []struct {
+			Name   string `json:"name"`
+			Commit string `json:"commit"`
+		} `json:"dependencies"`
+		Files []struct {
+			Path   string `json:"path"`
+			Module string `json:"module"`
+			Commit string `json:"commit"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	require.Len(t, manifest.Dependencies, 1)
+	assert.Equal(t, "bufbuild.test/bufbot/people", manifest.Dependencies[0].Name)
+	assert.Equal(t, "00000000000000000000000000000001", manifest.Dependencies[0].Commit)
+	require.Len(t, manifest.Files, 3)
+	for _, file := range manifest.Files[:2] {
+		assert.Equal(t, "bufbuild.test/bufbot/people", file.Module, file.Path)
+		assert.Equal(t, "00000000000000000000000000000001", file.Commit, file.Path)
+	}
+	assert.Empty(t, manifest.Files[2].Module)
+}
+
 func TestExportExcludeImports(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
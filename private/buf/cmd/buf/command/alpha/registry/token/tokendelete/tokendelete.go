@@ -30,8 +30,10 @@ import (
 )
 
 const (
-	forceFlagName   = "force"
-	tokenIDFlagName = "token-id"
+	forceFlagName          = "force"
+	yesFlagName            = "yes"
+	nonInteractiveFlagName = "non-interactive"
+	tokenIDFlagName        = "token-id"
 )
 
 // NewCommand returns a new Command
@@ -55,8 +57,10 @@ func NewCommand(
 }
 
 type flags struct {
-	Force   bool
-	TokenID string
+	Force          bool
+	Yes            bool
+	NonInteractive bool
+	TokenID        string
 }
 
 func newFlags() *flags {
@@ -70,6 +74,9 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		false,
 		"Force deletion without confirming. Use with caution",
 	)
+	_ = flagSet.MarkHidden(forceFlagName)
+	bufcli.BindYes(flagSet, &f.Yes, yesFlagName)
+	bufcli.BindNonInteractive(flagSet, &f.NonInteractive, nonInteractiveFlagName)
 	flagSet.StringVar(
 		&f.TokenID,
 		tokenIDFlagName,
@@ -97,10 +104,12 @@ func run(
 		return err
 	}
 	service := connectclient.Make(clientConfig, remote, registryv1alpha1connect.NewTokenServiceClient)
-	if !flags.Force {
-		if err := bufcli.PromptUserForDelete(container, "token", flags.TokenID); err != nil {
-			return err
-		}
+	if err := bufcli.PromptUserForDelete(
+		bufcli.NewPrompter(container, flags.Yes || flags.Force, flags.NonInteractive),
+		"token",
+		flags.TokenID,
+	); err != nil {
+		return err
 	}
 	if _, err := service.DeleteToken(
 		ctx,
@@ -0,0 +1,171 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anonymize
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	asFileDescriptorSetFlagName = "as-file-descriptor-set"
+	errorFormatFlagName         = "error-format"
+	outputFlagName              = "output"
+	outputFlagShortName         = "o"
+	configFlagName              = "config"
+	pathsFlagName               = "path"
+	excludePathsFlagName        = "exclude-path"
+	disableSymlinksFlagName     = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Build an image with all comments and names removed, to share reproductions of issues",
+		Long: `All comments are removed, and packages, files, messages, enums, enum values, fields, oneofs,
+extensions, services, and methods are consistently renamed to opaque names such as Message1 and
+field_2. The structure of the schema, including field numbers, types, and options, is preserved,
+so the image reproduces issues without revealing the schema. Version components of packages, such
+as v1, and the well-known types are kept.
+
+Options whose values are derived from names, such as go_package, custom options, and string and
+bytes default values are removed, as they may contain anything. The values of other options, such
+as deprecated, are kept.
+
+    $ buf beta anonymize -o repro.binpb
+
+` + bufcli.GetInputLong(`the source, module, or image to anonymize`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	AsFileDescriptorSet bool
+	ErrorFormat         string
+	Output              string
+	Config              string
+	Paths               []string
+	ExcludePaths        []string
+	DisableSymlinks     bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindAsFileDescriptorSet(flagSet, &f.AsFileDescriptorSet, asFileDescriptorSetFlagName)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVarP(
+		&f.Output,
+		outputFlagName,
+		outputFlagShortName,
+		"",
+		fmt.Sprintf(
+			`The output location for the anonymized image. Must be one of format %s`,
+			buffetch.MessageFormatsString,
+		),
+	)
+	_ = cobra.MarkFlagRequired(flagSet, outputFlagName)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	messageRef, err := buffetch.NewMessageRefParser(container.Logger()).GetMessageRef(ctx, flags.Output)
+	if err != nil {
+		return fmt.Errorf("--%s: %v", outputFlagName, err)
+	}
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		true, // comments are removed anyway
+	)
+	if err != nil {
+		return err
+	}
+	image, err = bufimageutil.ImageAnonymized(image)
+	if err != nil {
+		return err
+	}
+	return bufcli.NewWireImageWriter(
+		container.Logger(),
+	).PutImage(
+		ctx,
+		container,
+		messageRef,
+		image,
+		flags.AsFileDescriptorSet,
+		false,
+	)
+}
@@ -0,0 +1,411 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bufbuild/buf/private/buf/bufbench"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/buf/bufwire"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+)
+
+const (
+	iterationsFlagName      = "iterations"
+	baselineFlagName        = "baseline"
+	writeBaselineFlagName   = "write-baseline"
+	thresholdFlagName       = "threshold"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Benchmark building the input",
+		Long: `The input is built --iterations times, and the minimum, mean, and maximum time spent in each
+phase of the build is printed:
+
+    fetch:      reading the input and its dependencies
+    parse:      parsing the files of the input and its dependencies
+    link:       linking the parsed files into descriptors
+    serialize:  serializing the image
+
+Use --write-baseline to store the result, and --baseline to compare against a stored result.
+When comparing, exits with a non-zero exit code if the mean time of any phase increased by more
+than --threshold.
+
+` + bufcli.GetSourceOrModuleLong(`the source or module to benchmark`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Iterations      int
+	Baseline        string
+	WriteBaseline   string
+	Threshold       float64
+	Format          string
+	ErrorFormat     string
+	Config          string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.IntVar(
+		&f.Iterations,
+		iterationsFlagName,
+		5,
+		"The number of times to build the input",
+	)
+	flagSet.StringVar(
+		&f.Baseline,
+		baselineFlagName,
+		"",
+		fmt.Sprintf("A file written by --%s to compare the result against", writeBaselineFlagName),
+	)
+	flagSet.StringVar(
+		&f.WriteBaseline,
+		writeBaselineFlagName,
+		"",
+		"The file to write the result to as JSON, for later comparison",
+	)
+	flagSet.Float64Var(
+		&f.Threshold,
+		thresholdFlagName,
+		0.2,
+		"The relative increase of the mean time of a phase over the baseline at which to fail, where 0.2 is 20%",
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stdout. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Iterations < 1 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be at least 1", iterationsFlagName)
+	}
+	if flags.Threshold < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must not be negative", thresholdFlagName)
+	}
+	var baseline *bufbench.Result
+	if flags.Baseline != "" {
+		baseline, err = readResult(flags.Baseline)
+		if err != nil {
+			return err
+		}
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, input)
+	if err != nil {
+		return err
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	moduleReader, err := bufcli.NewModuleReaderAndCreateCacheDirs(container, clientConfig)
+	if err != nil {
+		return err
+	}
+	moduleConfigReader, err := bufcli.NewWireModuleConfigReaderForModuleReader(
+		container,
+		bufcli.NewStorageosProvider(flags.DisableSymlinks),
+		command.NewRunner(),
+		clientConfig,
+		moduleReader,
+	)
+	if err != nil {
+		return err
+	}
+	benchmarker := &benchmarker{
+		container:          container,
+		moduleConfigReader: moduleConfigReader,
+		imageBuilder:       bufimagebuild.NewBuilder(container.Logger(), moduleReader),
+		sourceOrModuleRef:  sourceOrModuleRef,
+		configOverride:     flags.Config,
+	}
+	samples := make([]*bufbench.Sample, 0, flags.Iterations)
+	for i := 0; i < flags.Iterations; i++ {
+		sample, fileAnnotations, err := benchmarker.benchmark(ctx)
+		if err != nil {
+			return err
+		}
+		if len(fileAnnotations) > 0 {
+			if err := bufanalysis.PrintFileAnnotations(container.Stdout(), fileAnnotations, flags.ErrorFormat); err != nil {
+				return err
+			}
+			return bufcli.ErrFileAnnotation
+		}
+		samples = append(samples, sample)
+	}
+	result := bufbench.NewResult(samples)
+	if flags.WriteBaseline != "" {
+		if err := writeResult(flags.WriteBaseline, result); err != nil {
+			return err
+		}
+	}
+	var regressions []*bufbench.Regression
+	if baseline != nil {
+		regressions = bufbench.Compare(baseline, result, flags.Threshold)
+	}
+	if err := printResult(container, format, result, baseline, regressions); err != nil {
+		return err
+	}
+	if len(regressions) > 0 {
+		return bufcli.ErrFileAnnotation
+	}
+	return nil
+}
+
+type benchmarker struct {
+	container          appflag.Container
+	moduleConfigReader bufwire.ModuleConfigReader
+	imageBuilder       bufimagebuild.Builder
+	sourceOrModuleRef  buffetch.SourceOrModuleRef
+	configOverride     string
+}
+
+// benchmark builds the input once and returns the timings of the build.
+func (b *benchmarker) benchmark(ctx context.Context) (*bufbench.Sample, []bufanalysis.FileAnnotation, error) {
+	sample := &bufbench.Sample{}
+	start := time.Now()
+	moduleConfigSet, err := b.moduleConfigReader.GetModuleConfigSet(
+		ctx,
+		b.container,
+		b.sourceOrModuleRef,
+		b.configOverride,
+		nil,
+		nil,
+		false,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	sample.Fetch = time.Since(start)
+	for _, moduleConfig := range moduleConfigSet.ModuleConfigs() {
+		module := moduleConfig.Module()
+		targetFileInfos, err := module.TargetFileInfos(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(targetFileInfos) == 0 {
+			continue
+		}
+		timings := &bufimagebuild.Timings{}
+		image, fileAnnotations, err := b.imageBuilder.Build(
+			ctx,
+			module,
+			bufimagebuild.WithExpectedDirectDependencies(module.DeclaredDirectDependencies()),
+			bufimagebuild.WithWorkspace(moduleConfigSet.Workspace()),
+			bufimagebuild.WithTimings(timings),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(fileAnnotations) > 0 {
+			return nil, fileAnnotations, nil
+		}
+		sample.Fetch += timings.Dependencies
+		sample.Parse += timings.Parse
+		sample.Link += timings.Link
+		start = time.Now()
+		if _, err := protoencoding.NewWireMarshaler().Marshal(bufimage.ImageToProtoImage(image)); err != nil {
+			return nil, nil, err
+		}
+		sample.Serialize += time.Since(start)
+	}
+	return sample, nil, nil
+}
+
+func readResult(filePath string) (_ *bufbench.Result, retErr error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	return bufbench.ReadResult(file)
+}
+
+func writeResult(filePath string, result *bufbench.Result) (retErr error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	return bufbench.WriteResult(file, result)
+}
+
+func printResult(
+	container appflag.Container,
+	format bufprint.Format,
+	result *bufbench.Result,
+	baseline *bufbench.Result,
+	regressions []*bufbench.Regression,
+) error {
+	switch format {
+	case bufprint.FormatText:
+		phaseToBaseline := make(map[string]*bufbench.PhaseResult)
+		if baseline != nil {
+			for _, phaseResult := range baseline.Phases {
+				phaseToBaseline[phaseResult.Phase] = phaseResult
+			}
+		}
+		phaseToRegression := make(map[string]*bufbench.Regression, len(regressions))
+		for _, regression := range regressions {
+			phaseToRegression[regression.Phase] = regression
+		}
+		headers := []string{"Phase", "Min", "Mean", "Max"}
+		if baseline != nil {
+			headers = append(headers, "Baseline", "Change")
+		}
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			headers,
+			func(tabWriter bufprint.TabWriter) error {
+				for _, phaseResult := range result.Phases {
+					values := []string{
+						phaseResult.Phase,
+						phaseResult.Min.String(),
+						phaseResult.Mean.String(),
+						phaseResult.Max.String(),
+					}
+					if baseline != nil {
+						values = append(values, getBaselineValues(phaseResult, phaseToBaseline, phaseToRegression)...)
+					}
+					if err := tabWriter.Write(values...); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		return json.NewEncoder(container.Stdout()).Encode(
+			&externalResult{
+				Result:      result,
+				Regressions: regressions,
+			},
+		)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// getBaselineValues returns the baseline mean and change of the mean for the phase.
+func getBaselineValues(
+	phaseResult *bufbench.PhaseResult,
+	phaseToBaseline map[string]*bufbench.PhaseResult,
+	phaseToRegression map[string]*bufbench.Regression,
+) []string {
+	baselinePhaseResult, ok := phaseToBaseline[phaseResult.Phase]
+	if !ok || baselinePhaseResult.Mean <= 0 {
+		return []string{"", ""}
+	}
+	change := float64(phaseResult.Mean-baselinePhaseResult.Mean) / float64(baselinePhaseResult.Mean)
+	changeString := strconv.FormatFloat(change*100, 'f', 1, 64) + "%"
+	if change > 0 {
+		changeString = "+" + changeString
+	}
+	if _, ok := phaseToRegression[phaseResult.Phase]; ok {
+		changeString += " (regression)"
+	}
+	return []string{baselinePhaseResult.Mean.String(), changeString}
+}
+
+type externalResult struct {
+	*bufbench.Result
+	Regressions []*bufbench.Regression `json:"regressions,omitempty"`
+}
@@ -0,0 +1,180 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cacheexplain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulecache"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const formatFlagName = "format"
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <buf.build/owner/repository:commit>",
+		Short: "Explain whether a module commit is in the module cache",
+		Long: `Explain whether a module commit is in the module cache, and where on disk its commit, manifest, and files are stored.
+
+The commit is cached only if the commit, its manifest, and all of its files are present. Missing files
+are listed, and will be downloaded again the next time the commit is used.`,
+		Args: cobra.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	moduleReference, err := bufmoduleref.ModuleReferenceForString(container.Arg(0))
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if !bufmoduleref.IsCommitModuleReference(moduleReference) {
+		return appcmd.NewInvalidArgumentErrorf("%q does not reference a commit", container.Arg(0))
+	}
+	commitExplanation := &bufmodulecache.CommitExplanation{}
+	moduleCacheDirPath := bufcli.ModuleCacheDirPath(container)
+	if _, err := os.Stat(moduleCacheDirPath); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		// Nothing has been cached yet, the commit path is left empty.
+	} else {
+		bucket, err := storageos.NewProvider().NewReadWriteBucket(moduleCacheDirPath)
+		if err != nil {
+			return err
+		}
+		locker, err := bufcli.NewModuleCacheLocker(container)
+		if err != nil {
+			return err
+		}
+		commitExplanation, err = bufmodulecache.ExplainCommit(ctx, bucket, locker, moduleReference, moduleReference.Reference())
+		if err != nil {
+			return err
+		}
+		// Paths of missing entries are relative to the cache directory.
+		if !commitExplanation.CommitCached {
+			commitExplanation.CommitPath = filepath.Join(moduleCacheDirPath, filepath.FromSlash(commitExplanation.CommitPath))
+		}
+		if commitExplanation.CommitCached && !commitExplanation.ManifestCached {
+			commitExplanation.ManifestPath = filepath.Join(moduleCacheDirPath, filepath.FromSlash(commitExplanation.ManifestPath))
+		}
+	}
+	switch format {
+	case bufprint.FormatText:
+		return printText(container, moduleReference, commitExplanation)
+	case bufprint.FormatJSON:
+		return json.NewEncoder(container.Stdout()).Encode(commitExplanation)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func printText(
+	container appflag.Container,
+	moduleReference bufmoduleref.ModuleReference,
+	commitExplanation *bufmodulecache.CommitExplanation,
+) error {
+	var lines []string
+	switch {
+	case !commitExplanation.CommitCached:
+		lines = append(
+			lines,
+			fmt.Sprintf("%s is not cached.", moduleReference.String()),
+		)
+		if commitExplanation.CommitPath != "" {
+			lines = append(lines, fmt.Sprintf("commit: missing (%s)", commitExplanation.CommitPath))
+		}
+	case !commitExplanation.ManifestCached:
+		lines = append(
+			lines,
+			fmt.Sprintf("%s is partially cached.", moduleReference.String()),
+			fmt.Sprintf("commit: %s", commitExplanation.CommitPath),
+			fmt.Sprintf("manifest: missing %s (%s)", commitExplanation.ManifestDigest, commitExplanation.ManifestPath),
+		)
+	default:
+		summary := fmt.Sprintf("%s is cached.", moduleReference.String())
+		if !commitExplanation.Complete() {
+			summary = fmt.Sprintf("%s is partially cached.", moduleReference.String())
+		}
+		lines = append(
+			lines,
+			summary,
+			fmt.Sprintf("commit: %s", commitExplanation.CommitPath),
+			fmt.Sprintf("manifest: %s (%s)", commitExplanation.ManifestDigest, commitExplanation.ManifestPath),
+			"files: "+strconv.Itoa(commitExplanation.FileCount-len(commitExplanation.MissingFilePaths))+
+				"/"+strconv.Itoa(commitExplanation.FileCount)+" cached",
+		)
+		for _, missingFilePath := range commitExplanation.MissingFilePaths {
+			lines = append(lines, "  missing: "+missingFilePath)
+		}
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(container.Stdout(), line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
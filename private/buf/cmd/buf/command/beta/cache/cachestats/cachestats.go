@@ -0,0 +1,154 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cachestats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulecache"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const formatFlagName = "format"
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Print the size of the module cache by module",
+		Long: `Print the number of commits, the number of blobs, and the total size on disk of each module
+within the module cache, largest first.
+
+To print the module cache hits and misses of any other invocation, set BUF_CACHE_STATS:
+
+    $ BUF_CACHE_STATS=1 buf build`,
+		Args: cobra.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	var moduleStats []*bufmodulecache.ModuleStats
+	moduleCacheDirPath := bufcli.ModuleCacheDirPath(container)
+	if _, err := os.Stat(moduleCacheDirPath); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		// Nothing has been cached yet.
+	} else {
+		bucket, err := storageos.NewProvider().NewReadWriteBucket(moduleCacheDirPath)
+		if err != nil {
+			return err
+		}
+		moduleStats, err = bufmodulecache.GetModuleStats(ctx, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Module",
+				"Commits",
+				"Blobs",
+				"Size",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				var totalSizeBytes int64
+				for _, moduleStat := range moduleStats {
+					totalSizeBytes += moduleStat.SizeBytes
+					if err := tabWriter.Write(
+						moduleStat.ModuleIdentity,
+						strconv.Itoa(moduleStat.CommitCount),
+						strconv.Itoa(moduleStat.BlobCount),
+						formatSize(moduleStat.SizeBytes),
+					); err != nil {
+						return err
+					}
+				}
+				return tabWriter.Write("total", "", "", formatSize(totalSizeBytes))
+			},
+		)
+	case bufprint.FormatJSON:
+		if moduleStats == nil {
+			moduleStats = []*bufmodulecache.ModuleStats{}
+		}
+		return json.NewEncoder(container.Stdout()).Encode(moduleStats)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func formatSize(sizeBytes int64) string {
+	const unit = 1024
+	if sizeBytes < unit {
+		return fmt.Sprintf("%d B", sizeBytes)
+	}
+	div, exp := int64(unit), 0
+	for n := sizeBytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(sizeBytes)/float64(div), "KMGTPE"[exp])
+}
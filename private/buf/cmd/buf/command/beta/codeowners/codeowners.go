@@ -0,0 +1,245 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+
+	header = "# Code generated by buf beta codeowners. DO NOT EDIT."
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <source>",
+		Short: "Generate a CODEOWNERS fragment from the package owners of a source",
+		Long: `The owners of each package are read from the lint.package_owners key of the buf.yaml of each module.
+The generated paths are relative to the current directory, so this command should be run from the root of the repository.
+Files whose package has no owner are omitted; use the PACKAGE_OWNER_DEFINED lint rule to require an owner for every package.
+
+` + bufcli.GetSourceLong(`the source to generate a CODEOWNERS fragment for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	ErrorFormat     string
+	Config          string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewSourceRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceRef, err := refParser.GetSourceRef(ctx, input)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		sourceRef,
+		flags.Config,
+		nil,
+		nil,
+		false,
+		true, // source code info is not needed
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		// stderr since we output to stdout
+		if err := bufanalysis.PrintFileAnnotations(
+			container.Stderr(),
+			fileAnnotations,
+			flags.ErrorFormat,
+		); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	// dir -> path -> owners
+	dirToPathToOwners := make(map[string]map[string]string)
+	for _, imageConfig := range imageConfigs {
+		lintConfig := imageConfig.Config().Lint
+		if lintConfig == nil {
+			continue
+		}
+		for _, imageFile := range imageConfig.Image().Files() {
+			if imageFile.IsImport() {
+				continue
+			}
+			owners := buflintconfig.OwnersForPackage(lintConfig.PackageOwners, imageFile.FileDescriptorProto().GetPackage())
+			if len(owners) == 0 {
+				continue
+			}
+			path, err := codeownersPath(wd, imageFile.ExternalPath())
+			if err != nil {
+				return err
+			}
+			dir := normalpath.Dir(path)
+			pathToOwners, ok := dirToPathToOwners[dir]
+			if !ok {
+				pathToOwners = make(map[string]string)
+				dirToPathToOwners[dir] = pathToOwners
+			}
+			pathToOwners[path] = strings.Join(owners, " ")
+		}
+	}
+	lines := linesForDirToPathToOwners(dirToPathToOwners)
+	if len(lines) == 0 {
+		return nil
+	}
+	_, err = fmt.Fprintln(container.Stdout(), header+"\n"+strings.Join(lines, "\n"))
+	return err
+}
+
+// linesForDirToPathToOwners returns the CODEOWNERS lines, sorted by directory.
+//
+// A single line matching all .proto files in a directory is used when every file in
+// the directory has the same owners, otherwise there is a line for each file.
+func linesForDirToPathToOwners(dirToPathToOwners map[string]map[string]string) []string {
+	dirs := make([]string, 0, len(dirToPathToOwners))
+	for dir := range dirToPathToOwners {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var lines []string
+	for _, dir := range dirs {
+		pathToOwners := dirToPathToOwners[dir]
+		ownersSet := make(map[string]struct{})
+		paths := make([]string, 0, len(pathToOwners))
+		for path, owners := range pathToOwners {
+			ownersSet[owners] = struct{}{}
+			paths = append(paths, path)
+		}
+		if len(ownersSet) == 1 {
+			lines = append(lines, fmt.Sprintf("/%s %s", normalpath.Join(dir, "*.proto"), pathToOwners[paths[0]]))
+			continue
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			lines = append(lines, fmt.Sprintf("/%s %s", path, pathToOwners[path]))
+		}
+	}
+	return lines
+}
+
+// codeownersPath returns the normalized path of the file relative to the current directory.
+func codeownersPath(wd string, externalPath string) (string, error) {
+	if !filepath.IsAbs(externalPath) {
+		return normalpath.Normalize(externalPath), nil
+	}
+	path, err := normalpath.Rel(normalpath.Normalize(wd), normalpath.Normalize(externalPath))
+	if err != nil {
+		return "", err
+	}
+	if path == ".." || strings.HasPrefix(path, "../") {
+		return "", fmt.Errorf("%s is not within the current directory", externalPath)
+	}
+	return path, nil
+}
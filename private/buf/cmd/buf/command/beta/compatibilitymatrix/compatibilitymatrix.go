@@ -0,0 +1,327 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compatibilitymatrix
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufcompat"
+	"github.com/bufbuild/buf/private/buf/bufwire"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking/bufbreakingconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/buflock"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	consumerFlagName        = "consumer"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Report which consumers of a module would break if its local changes were published",
+		Long: `The input is the producer module with local changes. Each --consumer is either a buf.lock
+file of a consumer, or an image of a consumer built with its dependencies included.
+
+For a buf.lock file, the commit of the producer pinned in the lock file is read from the
+registry, and the local changes are checked for breaking changes against it. For an image, the
+local changes are checked against the files of the producer included in the image. Consumers
+that do not depend on the producer are reported as such.
+
+The breaking change rules configured for the producer are used. The matrix is printed as a
+Markdown table or as JSON, and the command exits with a non-zero exit code if any consumer
+would break.
+
+    $ buf beta compatibility-matrix --consumer ../billing/buf.lock --consumer ../orders/image.binpb
+
+` + bufcli.GetSourceOrModuleLong(`the producer module`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Consumers       []string
+	Format          string
+	ErrorFormat     string
+	Config          string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Consumers,
+		consumerFlagName,
+		nil,
+		fmt.Sprintf(
+			"A %s file or image of a consumer of the module. May be provided multiple times",
+			buflock.ExternalConfigFilePath,
+		),
+	)
+	_ = cobra.MarkFlagRequired(flagSet, consumerFlagName)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufcompat.FormatMarkdown.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufcompat.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stdout. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration of the producer`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufcompat.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	// Validates that the input is a source or module, as images do not have configuration.
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	if _, err := refParser.GetSourceOrModuleRef(ctx, input); err != nil {
+		return err
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		storageosProvider,
+		command.NewRunner(),
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	checker := &checker{
+		container:         container,
+		storageosProvider: storageosProvider,
+		imageConfigReader: imageConfigReader,
+		errorFormat:       flags.ErrorFormat,
+	}
+	producerImageConfig, err := checker.getImageConfig(ctx, input, flags.Config)
+	if err != nil {
+		return err
+	}
+	checker.producerImage = bufimage.ImageWithoutImports(producerImageConfig.Image())
+	checker.producerModuleIdentity = producerImageConfig.Config().ModuleIdentity
+	checker.breakingConfig = producerImageConfig.Config().Breaking
+	matrix := &bufcompat.Matrix{
+		Producer: input,
+	}
+	if checker.producerModuleIdentity != nil {
+		matrix.Producer = checker.producerModuleIdentity.IdentityString()
+	}
+	for _, consumer := range flags.Consumers {
+		var row *bufcompat.Row
+		if filepath.Base(consumer) == buflock.ExternalConfigFilePath {
+			row, err = checker.checkLockFile(ctx, consumer)
+		} else {
+			row, err = checker.checkImage(ctx, consumer)
+		}
+		if err != nil {
+			return fmt.Errorf("--%s %s: %w", consumerFlagName, consumer, err)
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	if err := bufcompat.WriteMatrix(container.Stdout(), format, matrix); err != nil {
+		return err
+	}
+	if matrix.HasBreaking() {
+		return bufcli.ErrFileAnnotation
+	}
+	return nil
+}
+
+type checker struct {
+	container              appflag.Container
+	storageosProvider      storageos.Provider
+	imageConfigReader      bufwire.ImageConfigReader
+	errorFormat            string
+	producerImage          bufimage.Image
+	producerModuleIdentity bufmoduleref.ModuleIdentity
+	breakingConfig         *bufbreakingconfig.Config
+}
+
+// checkLockFile checks the producer against the commit of the producer pinned in the buf.lock file.
+func (c *checker) checkLockFile(ctx context.Context, lockFilePath string) (*bufcompat.Row, error) {
+	if c.producerModuleIdentity == nil {
+		return nil, fmt.Errorf("the producer must have a name in its configuration to be compared against %s files", buflock.ExternalConfigFilePath)
+	}
+	readWriteBucket, err := c.storageosProvider.NewReadWriteBucket(
+		filepath.Dir(lockFilePath),
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	lockFile, err := buflock.ReadConfig(ctx, readWriteBucket)
+	if err != nil {
+		return nil, err
+	}
+	for _, dependency := range lockFile.Dependencies {
+		if dependency.Remote != c.producerModuleIdentity.Remote() ||
+			dependency.Owner != c.producerModuleIdentity.Owner() ||
+			dependency.Repository != c.producerModuleIdentity.Repository() {
+			continue
+		}
+		imageConfig, err := c.getImageConfig(ctx, c.producerModuleIdentity.IdentityString()+":"+dependency.Commit, "")
+		if err != nil {
+			return nil, err
+		}
+		fileAnnotations, err := c.checkBreaking(ctx, bufimage.ImageWithoutImports(imageConfig.Image()))
+		if err != nil {
+			return nil, err
+		}
+		return bufcompat.NewRow(lockFilePath, dependency.Commit, fileAnnotations), nil
+	}
+	return bufcompat.NewNotConsumerRow(lockFilePath), nil
+}
+
+// checkImage checks the producer against the files of the producer in the image.
+func (c *checker) checkImage(ctx context.Context, imagePath string) (*bufcompat.Row, error) {
+	imageConfig, err := c.getImageConfig(ctx, imagePath, "")
+	if err != nil {
+		return nil, err
+	}
+	image := imageConfig.Image()
+	var paths []string
+	for _, producerImageFile := range c.producerImage.Files() {
+		if image.GetFile(producerImageFile.Path()) != nil {
+			paths = append(paths, producerImageFile.Path())
+		}
+	}
+	if len(paths) == 0 {
+		return bufcompat.NewNotConsumerRow(imagePath), nil
+	}
+	image, err = bufimage.ImageWithOnlyPaths(image, paths, nil)
+	if err != nil {
+		return nil, err
+	}
+	fileAnnotations, err := c.checkBreaking(ctx, bufimage.ImageWithoutImports(image))
+	if err != nil {
+		return nil, err
+	}
+	return bufcompat.NewRow(imagePath, "", fileAnnotations), nil
+}
+
+func (c *checker) checkBreaking(ctx context.Context, againstImage bufimage.Image) ([]bufanalysis.FileAnnotation, error) {
+	return bufbreaking.NewHandler(c.container.Logger()).Check(
+		ctx,
+		c.breakingConfig,
+		againstImage,
+		c.producerImage,
+	)
+}
+
+// getImageConfig builds the input, which must result in a single image.
+//
+// Build errors are printed.
+func (c *checker) getImageConfig(ctx context.Context, input string, configOverride string) (bufwire.ImageConfig, error) {
+	refParser, err := bufcli.NewRefParser(c.container)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refParser.GetRef(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	imageConfigs, fileAnnotations, err := c.imageConfigReader.GetImageConfigs(
+		ctx,
+		c.container,
+		ref,
+		configOverride,
+		nil,
+		nil,
+		false,
+		true, // source code info is not needed for breaking change detection
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileAnnotations) > 0 {
+		if err := bufanalysis.PrintFileAnnotations(c.container.Stdout(), fileAnnotations, c.errorFormat); err != nil {
+			return nil, err
+		}
+		return nil, bufcli.ErrFileAnnotation
+	}
+	if len(imageConfigs) != 1 {
+		return nil, fmt.Errorf("%s must be a single module, but contained %d modules", input, len(imageConfigs))
+	}
+	return imageConfigs[0], nil
+}
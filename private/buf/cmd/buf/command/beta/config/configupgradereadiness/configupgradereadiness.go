@@ -0,0 +1,210 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configupgradereadiness
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatFlagName = "format"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory...>",
+		Short: "Report deprecated configuration used by the modules within directories",
+		Long: `Search the directories for buf.yaml and buf.mod files, and report every deprecated
+configuration version, file name, key, and lint rule or category that they use, along with
+the configuration version it was deprecated in and what replaces it.
+
+This is intended to be run over many repositories at once to check whether they are ready
+to upgrade. Hidden directories are not searched.
+
+Defaults to the current directory if not specified.`,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	bufcli.WarnBetaCommand(ctx, container)
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	dirPaths := app.Args(container)
+	if len(dirPaths) == 0 {
+		dirPaths = []string{"."}
+	}
+	storageosProvider := storageos.NewProvider()
+	var outputDeprecations []outputDeprecation
+	for _, dirPath := range dirPaths {
+		configDirPaths, err := getConfigDirPaths(dirPath)
+		if err != nil {
+			return err
+		}
+		for _, configDirPath := range configDirPaths {
+			readWriteBucket, err := storageosProvider.NewReadWriteBucket(configDirPath)
+			if err != nil {
+				return err
+			}
+			config, err := bufconfig.GetConfigForBucket(ctx, readWriteBucket)
+			if err != nil {
+				return err
+			}
+			deprecations := buflint.GetDeprecations(config.Lint)
+			for _, deprecation := range append(config.Deprecations, deprecations...) {
+				outputDeprecations = append(
+					outputDeprecations,
+					outputDeprecation{
+						Path:            configDirPath,
+						Type:            deprecation.Type.String(),
+						Name:            deprecation.Name,
+						DeprecatedSince: deprecation.DeprecatedSince,
+						RemovalVersion:  deprecation.RemovalVersion,
+						Replacements:    deprecation.Replacements,
+					},
+				)
+			}
+		}
+	}
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Path",
+				"Type",
+				"Name",
+				"Deprecated Since",
+				"Removal Version",
+				"Replacements",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, outputDeprecation := range outputDeprecations {
+					if err := tabWriter.Write(
+						outputDeprecation.Path,
+						outputDeprecation.Type,
+						outputDeprecation.Name,
+						outputDeprecation.DeprecatedSince,
+						outputDeprecation.RemovalVersion,
+						strings.Join(outputDeprecation.Replacements, ","),
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		for _, outputDeprecation := range outputDeprecations {
+			if err := json.NewEncoder(container.Stdout()).Encode(outputDeprecation); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// getConfigDirPaths returns the sorted directories within dirPath that contain a configuration file.
+func getConfigDirPaths(dirPath string) ([]string, error) {
+	configDirPathMap := make(map[string]struct{})
+	if err := filepath.WalkDir(
+		dirPath,
+		func(path string, dirEntry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if dirEntry.IsDir() {
+				if path != dirPath && strings.HasPrefix(dirEntry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			for _, configFilePath := range bufconfig.AllConfigFilePaths {
+				if dirEntry.Name() == configFilePath {
+					configDirPathMap[filepath.Dir(path)] = struct{}{}
+				}
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	configDirPaths := make([]string, 0, len(configDirPathMap))
+	for configDirPath := range configDirPathMap {
+		configDirPaths = append(configDirPaths, configDirPath)
+	}
+	sort.Strings(configDirPaths)
+	return configDirPaths, nil
+}
+
+type outputDeprecation struct {
+	Path            string   `json:"path,omitempty"`
+	Type            string   `json:"type,omitempty"`
+	Name            string   `json:"name,omitempty"`
+	DeprecatedSince string   `json:"deprecated_since,omitempty"`
+	RemovalVersion  string   `json:"removal_version,omitempty"`
+	Replacements    []string `json:"replacements,omitempty"`
+}
@@ -0,0 +1,190 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configvalidate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatFlagName = "format"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory>",
+		Short: "Report lint and breaking settings that have no effect",
+		Long: `Read the buf.yaml in the directory, and report the lint and breaking settings
+that are valid but have no effect, or that contradict other settings. This includes:
+
+- except entries whose rules are not in use, or that are also listed in use.
+- ignore and ignore_only paths that do not match any file in the module.
+- ignore_only entries whose rules are not run, or whose paths are already ignored.
+- lint options such as service_suffix whose rules are not run.
+
+Everything except the ignore paths that do not match any file is also logged as a
+warning whenever the configuration is read by other commands.
+
+Exits with code 100 if any settings are reported.
+
+Defaults to the current directory if not specified.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	bufcli.WarnBetaCommand(ctx, container)
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	dirPath, err := bufcli.GetInputValue(container, "", ".")
+	if err != nil {
+		return err
+	}
+	readWriteBucket, err := storageos.NewProvider().NewReadWriteBucket(dirPath)
+	if err != nil {
+		return err
+	}
+	existingConfigFilePath, err := bufconfig.ExistingConfigFilePath(ctx, readWriteBucket)
+	if err != nil {
+		return err
+	}
+	if existingConfigFilePath == "" {
+		return bufcli.ErrNoConfigFile
+	}
+	config, err := bufconfig.GetConfigForBucket(ctx, readWriteBucket)
+	if err != nil {
+		return err
+	}
+	module, err := bufmodulebuild.NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		readWriteBucket,
+		config.Build,
+	)
+	if err != nil {
+		return err
+	}
+	fileInfos, err := module.TargetFileInfos(ctx)
+	if err != nil {
+		return err
+	}
+	filePaths := make([]string, len(fileInfos))
+	for i, fileInfo := range fileInfos {
+		filePaths[i] = fileInfo.Path()
+	}
+	var warnings []*bufconfig.Warning
+	if config.Lint != nil {
+		warnings = append(warnings, buflint.GetConfigWarnings(config.Lint)...)
+		warnings = append(warnings, buflint.GetIgnorePathWarnings(config.Lint, filePaths)...)
+	}
+	if config.Breaking != nil {
+		warnings = append(warnings, bufbreaking.GetConfigWarnings(config.Breaking)...)
+		warnings = append(warnings, bufbreaking.GetIgnorePathWarnings(config.Breaking, filePaths)...)
+	}
+	if err := printWarnings(container, format, existingConfigFilePath, warnings); err != nil {
+		return err
+	}
+	if len(warnings) > 0 {
+		return bufcli.ErrFileAnnotation
+	}
+	return nil
+}
+
+func printWarnings(
+	container appflag.Container,
+	format bufprint.Format,
+	configFilePath string,
+	warnings []*bufconfig.Warning,
+) error {
+	switch format {
+	case bufprint.FormatText:
+		for _, warning := range warnings {
+			if _, err := fmt.Fprintf(container.Stdout(), "%s: %s\n", configFilePath, warning.String()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case bufprint.FormatJSON:
+		for _, warning := range warnings {
+			if err := json.NewEncoder(container.Stdout()).Encode(
+				outputWarning{
+					Path:    configFilePath,
+					Key:     warning.Key,
+					Value:   warning.Value,
+					Message: warning.Message,
+				},
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+type outputWarning struct {
+	Path    string `json:"path,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message,omitempty"`
+}
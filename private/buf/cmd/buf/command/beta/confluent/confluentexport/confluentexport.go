@@ -0,0 +1,173 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confluentexport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufconfluent"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/bufbuild/buf/private/pkg/transport/http/httpclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	urlFlagName             = "url"
+	userFlagName            = "user"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	pathsFlagName           = "path"
+	excludePathsFlagName    = "exclude-path"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Register the Protobuf files of an input in a Confluent Schema Registry",
+		Long: `Each file, including imported files, is registered under a subject named after the file path,
+such as "acme/weather/v1/weather.proto". The imports of each file are registered first and set as
+its schema references, so that the schema registry can resolve them. The well-known types are built
+into the schema registry and are not registered.
+
+Files that are already registered are not registered again. The subject, version, and ID of each
+schema are printed to stdout.
+
+` + bufcli.GetInputLong(`the source, module, or image to export`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	URL             string
+	User            string
+	ErrorFormat     string
+	Config          string
+	Paths           []string
+	ExcludePaths    []string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.URL,
+		urlFlagName,
+		"",
+		`The base URL of the schema registry, such as https://schema-registry.example.com`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, urlFlagName)
+	flagSet.StringVar(
+		&f.User,
+		userFlagName,
+		"",
+		`The user credentials to send via basic authentication, in the format "username:password". If the value has no colon, you will be prompted to enter a password`,
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	var options []bufconfluent.ClientOption
+	if flags.User != "" {
+		username, password, ok := strings.Cut(flags.User, ":")
+		if !ok {
+			password, err = bufcli.PromptUserForPassword(container, fmt.Sprintf("Password for user %q: ", username))
+			if err != nil {
+				return err
+			}
+		}
+		options = append(options, bufconfluent.ClientWithBasicAuth(username, password))
+	}
+	client := bufconfluent.NewClient(httpclient.NewClient(nil), flags.URL, options...)
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		true, // source code info is not registered
+	)
+	if err != nil {
+		return err
+	}
+	schemas, err := bufconfluent.Export(ctx, client, bufimage.ImageToFileDescriptorProtos(image))
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemas {
+		if _, err := fmt.Fprintf(container.Stdout(), "%s\tversion %d\tid %d\n", schema.Subject, schema.Version, schema.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
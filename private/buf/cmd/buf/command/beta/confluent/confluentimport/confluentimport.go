@@ -0,0 +1,169 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confluentimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufconfluent"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/transport/http/httpclient"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	urlFlagName         = "url"
+	userFlagName        = "user"
+	versionFlagName     = "version"
+	pathFlagName        = "path"
+	outputFlagName      = "output"
+	outputFlagShortName = "o"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <subject>",
+		Short: "Write a Protobuf subject of a Confluent Schema Registry and its references to a directory",
+		Long: `The schema of the subject is written to the file at --path within the output directory, and
+the schemas it references, transitively, are written to the files named by the references. The
+well-known types are not written, as they are provided by buf.
+
+The output directory can then be used as the root of a module in a buf workspace.
+
+    $ buf beta confluent import orders-value --url=https://schema-registry.example.com --path=acme/order/v1/order.proto --output=proto
+`,
+		Args: cobra.ExactArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	URL     string
+	User    string
+	Version string
+	Path    string
+	Output  string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.URL,
+		urlFlagName,
+		"",
+		`The base URL of the schema registry, such as https://schema-registry.example.com`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, urlFlagName)
+	flagSet.StringVar(
+		&f.User,
+		userFlagName,
+		"",
+		`The user credentials to send via basic authentication, in the format "username:password". If the value has no colon, you will be prompted to enter a password`,
+	)
+	flagSet.StringVar(
+		&f.Version,
+		versionFlagName,
+		"latest",
+		`The version of the subject to import`,
+	)
+	flagSet.StringVar(
+		&f.Path,
+		pathFlagName,
+		"",
+		`The path to write the schema of the subject to, relative to the output directory. Defaults to the subject if it ends in .proto`,
+	)
+	flagSet.StringVarP(
+		&f.Output,
+		outputFlagName,
+		outputFlagShortName,
+		".",
+		`The output directory for imported files`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	subject := container.Arg(0)
+	path := flags.Path
+	if path == "" {
+		if normalpath.Ext(subject) != ".proto" {
+			return appcmd.NewInvalidArgumentErrorf("--%s is required when the subject does not end in .proto", pathFlagName)
+		}
+		path = subject
+	}
+	path, err := normalpath.NormalizeAndValidate(path)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %v", pathFlagName, err)
+	}
+	var options []bufconfluent.ClientOption
+	if flags.User != "" {
+		username, password, ok := strings.Cut(flags.User, ":")
+		if !ok {
+			password, err = bufcli.PromptUserForPassword(container, fmt.Sprintf("Password for user %q: ", username))
+			if err != nil {
+				return err
+			}
+		}
+		options = append(options, bufconfluent.ClientWithBasicAuth(username, password))
+	}
+	client := bufconfluent.NewClient(httpclient.NewClient(nil), flags.URL, options...)
+	pathToData, err := bufconfluent.Import(ctx, client, subject, flags.Version, path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(flags.Output, 0755); err != nil {
+		return err
+	}
+	readWriteBucket, err := storageos.NewProvider().NewReadWriteBucket(flags.Output)
+	if err != nil {
+		return err
+	}
+	for path, data := range pathToData {
+		path, err := normalpath.NormalizeAndValidate(path)
+		if err != nil {
+			return fmt.Errorf("invalid schema reference name: %w", err)
+		}
+		if err := storage.PutPath(ctx, readWriteBucket, path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumptionreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufconsumption"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	usageFlagName           = "usage"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Report which RPCs and types of an input are used by consumers",
+		Long: `The usage of each consumer is read from a usage manifest given by --usage, or stdin if "-".
+Usage manifests are produced by generated SDKs, or written manually, as JSON or YAML:
+
+    version: v1
+    consumer: acme-ios
+    rpcs:
+      - acme.weather.v1.WeatherService.GetWeather
+    types:
+      - acme.weather.v1.Location
+
+RPC names are either fully-qualified, or request paths such as "/acme.weather.v1.WeatherService/GetWeather".
+Types are the messages and enums used by the consumer outside of the requests and responses of RPCs.
+
+For each consumer, the used and unused RPCs and types are reported. The types used by the requests
+and responses of used RPCs, and all types transitively referenced by used messages, are considered used.
+Deprecated RPCs, types, and fields that are still in use are reported, as are names in the usage
+manifest that no longer exist in the input.
+
+Imports are not reported.
+
+` + bufcli.GetInputLong(`the source, module, or image to report usage for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Usage           []string
+	Format          string
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Usage,
+		usageFlagName,
+		nil,
+		`The usage manifest of a consumer, or "-" for stdin. May be provided multiple times`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, usageFlagName)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	var numStdin int
+	for _, usage := range flags.Usage {
+		if usage == "-" {
+			numStdin++
+		}
+	}
+	if numStdin > 1 {
+		return appcmd.NewInvalidArgumentErrorf(`--%s: "-" can only be provided once`, usageFlagName)
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	usageManifests := make([]*bufconsumption.UsageManifest, len(flags.Usage))
+	for i, usage := range flags.Usage {
+		usageManifest, err := readUsageManifest(container, usage)
+		if err != nil {
+			return err
+		}
+		usageManifests[i] = usageManifest
+	}
+	fileDescriptors, err := getFileDescriptors(ctx, container, input, flags)
+	if err != nil {
+		return err
+	}
+	reports := make([]*bufconsumption.Report, len(usageManifests))
+	for i, usageManifest := range usageManifests {
+		reports[i] = bufconsumption.NewReport(fileDescriptors, usageManifest)
+	}
+	switch format {
+	case bufprint.FormatText:
+		for i, report := range reports {
+			if i > 0 {
+				if _, err := fmt.Fprintln(container.Stdout()); err != nil {
+					return err
+				}
+			}
+			if err := printReportText(container.Stdout(), report); err != nil {
+				return err
+			}
+		}
+		return nil
+	case bufprint.FormatJSON:
+		encoder := json.NewEncoder(container.Stdout())
+		for _, report := range reports {
+			if err := encoder.Encode(report); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func readUsageManifest(container appflag.Container, path string) (*bufconsumption.UsageManifest, error) {
+	var reader io.Reader = container.Stdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not open usage manifest: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	usageManifest, err := bufconsumption.ReadUsageManifest(reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return usageManifest, nil
+}
+
+// getFileDescriptors returns the non-import files of the input.
+func getFileDescriptors(
+	ctx context.Context,
+	container appflag.Container,
+	input string,
+	flags *flags,
+) ([]protoreflect.FileDescriptor, error) {
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return nil, err
+	}
+	var fileDescriptors []protoreflect.FileDescriptor
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		fileDescriptors = append(fileDescriptors, fileDescriptor)
+	}
+	return fileDescriptors, nil
+}
+
+func printReportText(writer io.Writer, report *bufconsumption.Report) error {
+	consumer := report.Consumer
+	if consumer == "" {
+		consumer = "<unnamed>"
+	}
+	if _, err := fmt.Fprintf(
+		writer,
+		"Consumer: %s\nUsed RPCs: %d/%d\nUsed types: %d/%d\n",
+		consumer,
+		len(report.UsedRPCs),
+		len(report.UsedRPCs)+len(report.UnusedRPCs),
+		len(report.UsedTypes),
+		len(report.UsedTypes)+len(report.UnusedTypes),
+	); err != nil {
+		return err
+	}
+	for _, section := range []struct {
+		title string
+		names []string
+	}{
+		{title: "Unused RPCs", names: report.UnusedRPCs},
+		{title: "Unused types", names: report.UnusedTypes},
+		{title: "Deprecated in use", names: report.DeprecatedInUse},
+		{title: "Unknown", names: report.Unknown},
+	} {
+		if len(section.names) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(writer, "%s:\n", section.title); err != nil {
+			return err
+		}
+		for _, name := range section.names {
+			if _, err := fmt.Fprintf(writer, "  %s\n", name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
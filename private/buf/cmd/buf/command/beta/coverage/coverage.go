@@ -0,0 +1,237 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package coverage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufcoverage"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	exercisedFlagName       = "exercised"
+	thresholdFlagName       = "threshold"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	pathsFlagName           = "path"
+	excludePathsFlagName    = "exclude-path"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Report the RPCs of an input that are not exercised by tests",
+		Long: `The exercised RPCs are read from the file given by --exercised, or stdin if "-".
+The file is either a JSON array of RPC names, or one entry per line. Each line is either an RPC name,
+or a JSON object with the RPC name in the "method" or "rpc" key, as is typical of structured test logs.
+RPC names are either fully-qualified, such as "acme.weather.v1.WeatherService.GetWeather",
+or request paths, such as "/acme.weather.v1.WeatherService/GetWeather".
+
+If --threshold is set, the command exits with a non-zero exit code if the percentage of RPCs
+exercised is below the threshold.
+
+` + bufcli.GetInputLong(`the source, module, or image to report coverage for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Exercised       string
+	Threshold       float64
+	Format          string
+	ErrorFormat     string
+	Config          string
+	Paths           []string
+	ExcludePaths    []string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Exercised,
+		exercisedFlagName,
+		"",
+		`The file containing the exercised RPCs, or "-" for stdin`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, exercisedFlagName)
+	flagSet.Float64Var(
+		&f.Threshold,
+		thresholdFlagName,
+		0,
+		"The minimum percentage of RPCs that must be exercised, between 0 and 100",
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Threshold < 0 || flags.Threshold > 100 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must be between 0 and 100 but was %v", thresholdFlagName, flags.Threshold)
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
+	if err != nil {
+		return err
+	}
+	exercisedRPCNames, err := readExercisedRPCNames(container, flags.Exercised)
+	if err != nil {
+		return err
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		true, // source code info is not needed
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		// stderr since we output to stdout
+		if err := bufanalysis.PrintFileAnnotations(
+			container.Stderr(),
+			fileAnnotations,
+			flags.ErrorFormat,
+		); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	var rpcNames []string
+	for _, imageConfig := range imageConfigs {
+		rpcNames = append(rpcNames, bufcoverage.RPCNamesForImage(imageConfig.Image())...)
+	}
+	report := bufcoverage.NewReport(rpcNames, exercisedRPCNames)
+	switch format {
+	case bufprint.FormatText:
+		err = bufcoverage.WriteReportText(container.Stdout(), report)
+	case bufprint.FormatJSON:
+		err = bufcoverage.WriteReportJSON(container.Stdout(), report)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+	if err != nil {
+		return err
+	}
+	if report.Percent < flags.Threshold {
+		return fmt.Errorf("RPC coverage of %.2f%% is below the threshold of %.2f%%", report.Percent, flags.Threshold)
+	}
+	return nil
+}
+
+func readExercisedRPCNames(container appflag.Container, path string) (map[string]struct{}, error) {
+	var reader io.Reader = container.Stdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not open exercised RPCs: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	return bufcoverage.ReadExercisedRPCNames(reader)
+}
@@ -0,0 +1,248 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enumreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufenum"
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	againstFlagName         = "against"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Audit the value usage and reservations of enums",
+		Long: `Report, for every enum in the input, the range of allocated value numbers, the reserved
+ranges, whether the enum is missing a zero value, and the gaps in numbering that are neither
+used nor reserved.
+
+If --against is set, values of the enums in the against input that were removed without
+reserving both their number and name are also reported.
+
+Imports are not reported.
+
+` + bufcli.GetInputLong(`the source, module, or image to audit`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Against         string
+	Format          string
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Against,
+		againstFlagName,
+		"",
+		fmt.Sprintf(
+			`The source, module, or image to find removed enum values against. Must be one of format %s`,
+			buffetch.AllFormatsString,
+		),
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	enumDescriptors, err := getEnumDescriptors(ctx, container, input, flags)
+	if err != nil {
+		return err
+	}
+	var againstEnumDescriptors []protoreflect.EnumDescriptor
+	if flags.Against != "" {
+		againstEnumDescriptors, err = getEnumDescriptors(ctx, container, flags.Against, flags)
+		if err != nil {
+			return err
+		}
+	}
+	reports := bufenum.NewReports(enumDescriptors, againstEnumDescriptors)
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Enum",
+				"Values",
+				"Allocated",
+				"Reserved",
+				"Gaps",
+				"Missing Zero",
+				"Unreserved Removed",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, report := range reports {
+					var allocated string
+					if report.Allocated != nil {
+						allocated = rangesString(*report.Allocated)
+					}
+					removedValueStrings := make([]string, len(report.RemovedValues))
+					for i, removedValue := range report.RemovedValues {
+						removedValueStrings[i] = removedValue.Name + "=" + strconv.Itoa(int(removedValue.Number))
+					}
+					if err := tabWriter.Write(
+						report.Name,
+						strconv.Itoa(report.NumValues),
+						allocated,
+						rangesString(report.Reserved...),
+						rangesString(report.Gaps...),
+						strconv.FormatBool(report.MissingZeroValue),
+						strings.Join(removedValueStrings, ","),
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		for _, report := range reports {
+			if err := json.NewEncoder(container.Stdout()).Encode(report); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// getEnumDescriptors returns the enums declared in the non-import files of the input.
+func getEnumDescriptors(
+	ctx context.Context,
+	container appflag.Container,
+	input string,
+	flags *flags,
+) ([]protoreflect.EnumDescriptor, error) {
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return nil, err
+	}
+	var enumDescriptors []protoreflect.EnumDescriptor
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		enumDescriptors = append(enumDescriptors, bufenum.EnumDescriptorsForFile(fileDescriptor)...)
+	}
+	return enumDescriptors, nil
+}
+
+// rangesString returns the ranges as a comma-separated string, where each range
+// is either a single number or "start-end".
+func rangesString(ranges ...bufenum.Range) string {
+	rangeStrings := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.Start == r.End {
+			rangeStrings[i] = strconv.Itoa(int(r.Start))
+		} else {
+			rangeStrings[i] = strconv.Itoa(int(r.Start)) + "-" + strconv.Itoa(int(r.End))
+		}
+	}
+	return strings.Join(rangeStrings, ",")
+}
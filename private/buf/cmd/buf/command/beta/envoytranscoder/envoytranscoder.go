@@ -0,0 +1,255 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envoytranscoder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufenvoy"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	serviceFlagName          = "service"
+	descriptorOutputFlagName = "descriptor-output"
+	descriptorPathFlagName   = "descriptor-path"
+	clusterFlagName          = "cluster"
+	errorFormatFlagName      = "error-format"
+	configFlagName           = "config"
+	pathsFlagName            = "path"
+	excludePathsFlagName     = "exclude-path"
+	disableSymlinksFlagName  = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Generate Envoy gRPC-JSON transcoder configuration from google.api.http annotations",
+		Long: `The descriptor set required by the transcoder is written to --descriptor-output, and the
+HTTP filter and route fragments for the services are printed to stdout as YAML.
+
+Every method of the services must have a google.api.http annotation, and the annotations are
+validated for completeness: path variables must refer to singular scalar fields of the request,
+and the body and response body must refer to fields of the request and response. All problems
+are reported, and nothing is written if there are any.
+
+If no --service flags are given, all services in the input are transcoded, excluding services
+in dependencies.
+
+    $ buf beta envoy-transcoder --descriptor-output=descriptor.binpb --descriptor-path=/etc/envoy/descriptor.binpb > transcoder.yaml
+
+` + bufcli.GetInputLong(`the source, module, or image to generate configuration for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Services         []string
+	DescriptorOutput string
+	DescriptorPath   string
+	Cluster          string
+	ErrorFormat      string
+	Config           string
+	Paths            []string
+	ExcludePaths     []string
+	DisableSymlinks  bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Services,
+		serviceFlagName,
+		nil,
+		`The fully-qualified names of the services to transcode. Defaults to all services in the input`,
+	)
+	flagSet.StringVar(
+		&f.DescriptorOutput,
+		descriptorOutputFlagName,
+		"",
+		`The file to write the binary descriptor set for the transcoder to`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, descriptorOutputFlagName)
+	flagSet.StringVar(
+		&f.DescriptorPath,
+		descriptorPathFlagName,
+		"",
+		fmt.Sprintf(
+			`The path of the descriptor set as seen by Envoy. Defaults to the absolute path of --%s`,
+			descriptorOutputFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.Cluster,
+		clusterFlagName,
+		"grpc",
+		`The name of the Envoy cluster of the upstream gRPC server`,
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	if flags.DescriptorOutput == "-" {
+		return appcmd.NewInvalidArgumentErrorf("--%s must be a file, as the configuration is printed to stdout", descriptorOutputFlagName)
+	}
+	descriptorPath := flags.DescriptorPath
+	if descriptorPath == "" {
+		var err error
+		descriptorPath, err = filepath.Abs(flags.DescriptorOutput)
+		if err != nil {
+			return err
+		}
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		true, // source code info is not needed by the transcoder
+	)
+	if err != nil {
+		return err
+	}
+	services := flags.Services
+	if len(services) == 0 {
+		for _, imageFile := range image.Files() {
+			if imageFile.IsImport() {
+				continue
+			}
+			for _, serviceDescriptorProto := range imageFile.FileDescriptorProto().GetService() {
+				// Services of files without a package have no package prefix.
+				services = append(
+					services,
+					string(protoreflect.FullName(imageFile.FileDescriptorProto().GetPackage()).Append(protoreflect.Name(serviceDescriptorProto.GetName()))),
+				)
+			}
+		}
+		if len(services) == 0 {
+			return errors.New("input has no services")
+		}
+	}
+	// The filtered image keeps the options of the services, including google.api.http.
+	image, err = bufimageutil.ImageFilteredByTypes(image, services...)
+	if err != nil {
+		return err
+	}
+	fileDescriptorSet := bufimage.ImageToFileDescriptorSet(image)
+	files, err := protodesc.NewFiles(fileDescriptorSet)
+	if err != nil {
+		return err
+	}
+	serviceDescriptors := make([]protoreflect.ServiceDescriptor, 0, len(services))
+	for _, service := range services {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return err
+		}
+		serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %q is not a service", serviceFlagName, service)
+		}
+		serviceDescriptors = append(serviceDescriptors, serviceDescriptor)
+	}
+	if err := bufenvoy.ValidateServices(serviceDescriptors); err != nil {
+		problems := make([]string, 0, len(multierr.Errors(err)))
+		for _, problem := range multierr.Errors(err) {
+			problems = append(problems, problem.Error())
+		}
+		return fmt.Errorf("services cannot be transcoded:\n%s", strings.Join(problems, "\n"))
+	}
+	data, err := proto.Marshal(fileDescriptorSet)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(flags.DescriptorOutput, data, 0644); err != nil {
+		return err
+	}
+	return bufenvoy.WriteTranscoderConfigYAML(
+		container.Stdout(),
+		&bufenvoy.TranscoderConfig{
+			ProtoDescriptorPath: descriptorPath,
+			Services:            services,
+			Cluster:             flags.Cluster,
+		},
+	)
+}
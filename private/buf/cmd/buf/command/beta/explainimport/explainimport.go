@@ -0,0 +1,190 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package explainimport
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	fromFlagName            = "from"
+	toFlagName              = "to"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input> --from <path> --to <path>",
+		Short: "Print the chain of imports that makes a file import another",
+		Long: `Print the shortest chain of imports from the file given by --from to the file given by --to,
+one file per line, each file importing the file on the next line. This explains why building
+the file given by --from requires the file given by --to.
+
+Both files are paths relative to the roots of the input, and --to may be a file of a dependency.
+
+` + bufcli.GetInputLong(`the source, module, or image to explain the import for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	From            string
+	To              string
+	ErrorFormat     string
+	Config          string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.From,
+		fromFlagName,
+		"",
+		"The path of the importing file. Required",
+	)
+	flagSet.StringVar(
+		&f.To,
+		toFlagName,
+		"",
+		"The path of the imported file. Required",
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if flags.From == "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s is required", fromFlagName)
+	}
+	if flags.To == "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s is required", toFlagName)
+	}
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
+	if err != nil {
+		return err
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		bufcli.NewStorageosProvider(flags.DisableSymlinks),
+		command.NewRunner(),
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		flags.Config,
+		nil,
+		nil,
+		false,
+		true, // source code info is not needed to follow imports
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		if err := bufanalysis.PrintFileAnnotations(container.Stderr(), fileAnnotations, flags.ErrorFormat); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	images := make([]bufimage.Image, 0, len(imageConfigs))
+	for _, imageConfig := range imageConfigs {
+		images = append(images, imageConfig.Image())
+	}
+	image, err := bufimage.MergeImages(images...)
+	if err != nil {
+		return err
+	}
+	chain, err := bufimage.ExplainImport(image, flags.From, flags.To)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if len(chain) == 0 {
+		_, err := fmt.Fprintf(container.Stdout(), "%s does not import %s, directly or transitively.\n", flags.From, flags.To)
+		return err
+	}
+	_, err = fmt.Fprintln(container.Stdout(), strings.Join(chain, "\n"))
+	return err
+}
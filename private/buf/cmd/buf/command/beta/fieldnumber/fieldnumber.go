@@ -0,0 +1,338 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fieldnumber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/buffieldnumber"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufreflect"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	typeFlagName            = "type"
+	againstFlagName         = "against"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input> --type=<type>",
+		Short: "Suggest the next safe field number for messages",
+		Long: `Report the field numbers of messages that are used, reserved, or within extension ranges,
+and suggest the next safe number for a new field.
+
+The next number is the number after the highest allocated number, skipping the numbers 19000
+to 19999 that are reserved for the Protobuf implementation. The lowest available number is
+also reported, as numbers 1 to 15 encode in one byte.
+
+If --against is set, the fields of the messages in the against input, such as a previous
+commit of a module on the BSR, are considered allocated as well. Fields that were removed
+without reserving their number and name are reported, along with reserved statements that
+reserve them. Removed fields that are not reserved are also reported by the breaking rules
+FIELD_NO_DELETE_UNLESS_NUMBER_RESERVED and FIELD_NO_DELETE_UNLESS_NAME_RESERVED.
+
+If --type is not set, all messages in the input are reported. Imports are not reported.
+
+` + bufcli.GetInputLong(`the source, module, or image containing the types`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Types           []string
+	Against         string
+	Format          string
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Types,
+		typeFlagName,
+		nil,
+		`The full type name of a message within the input (e.g. acme.weather.v1.GetWeatherRequest)
+May be provided multiple times. If not set, all messages are reported`,
+	)
+	flagSet.StringVar(
+		&f.Against,
+		againstFlagName,
+		"",
+		fmt.Sprintf(
+			`The source, module, or image with the previous version of the messages. Must be one of format %s`,
+			buffetch.AllFormatsString,
+		),
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	for _, typeName := range flags.Types {
+		if err := bufreflect.ValidateTypeName(typeName); err != nil {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %v", typeFlagName, err)
+		}
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	image, resolver, err := getImageAndResolver(ctx, container, input, flags)
+	if err != nil {
+		return err
+	}
+	messageDescriptors, err := getMessageDescriptors(image, resolver, flags.Types)
+	if err != nil {
+		return err
+	}
+	var againstResolver protoencoding.Resolver
+	if flags.Against != "" {
+		_, againstResolver, err = getImageAndResolver(ctx, container, flags.Against, flags)
+		if err != nil {
+			return err
+		}
+	}
+	outputReports := make([]*outputReport, len(messageDescriptors))
+	for i, messageDescriptor := range messageDescriptors {
+		var againstMessageDescriptor protoreflect.MessageDescriptor
+		if againstResolver != nil {
+			// Messages that did not exist in the against input have no history.
+			if descriptor, err := againstResolver.FindDescriptorByName(messageDescriptor.FullName()); err == nil {
+				againstMessageDescriptor, _ = descriptor.(protoreflect.MessageDescriptor)
+			}
+		}
+		report := buffieldnumber.NewReport(messageDescriptor, againstMessageDescriptor)
+		outputReports[i] = &outputReport{
+			Report:             report,
+			ReservedStatements: buffieldnumber.ReservedStatements(report, messageDescriptor.ParentFile().Syntax()),
+		}
+	}
+	switch format {
+	case bufprint.FormatText:
+		for _, outputReport := range outputReports {
+			if err := writeOutputReportText(container.Stdout(), outputReport); err != nil {
+				return err
+			}
+		}
+		return nil
+	case bufprint.FormatJSON:
+		for _, outputReport := range outputReports {
+			if err := json.NewEncoder(container.Stdout()).Encode(outputReport); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+type outputReport struct {
+	*buffieldnumber.Report
+
+	ReservedStatements []string `json:"reserved_statements,omitempty"`
+}
+
+func writeOutputReportText(writer io.Writer, outputReport *outputReport) error {
+	var builder strings.Builder
+	builder.WriteString(outputReport.Name + "\n")
+	writeNumber := func(name string, number int32) {
+		value := "none"
+		if number != 0 {
+			value = strconv.Itoa(int(number))
+		}
+		builder.WriteString("  " + name + ": " + value + "\n")
+	}
+	writeNumber("next number", outputReport.NextNumber)
+	writeNumber("lowest available number", outputReport.LowestAvailableNumber)
+	builder.WriteString("  used: " + rangesString(outputReport.Used) + "\n")
+	if len(outputReport.Reserved) > 0 {
+		builder.WriteString("  reserved: " + rangesString(outputReport.Reserved) + "\n")
+	}
+	if len(outputReport.Extensions) > 0 {
+		builder.WriteString("  extensions: " + rangesString(outputReport.Extensions) + "\n")
+	}
+	if len(outputReport.RemovedFields) > 0 {
+		removedFieldStrings := make([]string, len(outputReport.RemovedFields))
+		for i, removedField := range outputReport.RemovedFields {
+			removedFieldStrings[i] = removedField.Name + "=" + strconv.Itoa(int(removedField.Number))
+		}
+		builder.WriteString("  unreserved removed fields: " + strings.Join(removedFieldStrings, ",") + "\n")
+	}
+	if len(outputReport.ReservedStatements) > 0 {
+		builder.WriteString("  suggested reservations:\n")
+		for _, reservedStatement := range outputReport.ReservedStatements {
+			builder.WriteString("    " + reservedStatement + "\n")
+		}
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+func getImageAndResolver(
+	ctx context.Context,
+	container appflag.Container,
+	input string,
+	flags *flags,
+) (bufimage.Image, protoencoding.Resolver, error) {
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return image, resolver, nil
+}
+
+// getMessageDescriptors returns the descriptors for the type names, or for all
+// messages in the non-import files of the image if there are no type names.
+func getMessageDescriptors(
+	image bufimage.Image,
+	resolver protoencoding.Resolver,
+	typeNames []string,
+) ([]protoreflect.MessageDescriptor, error) {
+	var messageDescriptors []protoreflect.MessageDescriptor
+	if len(typeNames) > 0 {
+		for _, typeName := range typeNames {
+			descriptor, err := resolver.FindDescriptorByName(protoreflect.FullName(typeName))
+			if err != nil {
+				return nil, fmt.Errorf("could not find type %q: %w", typeName, err)
+			}
+			messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+			if !ok {
+				return nil, appcmd.NewInvalidArgumentErrorf("--%s: %q must be a message", typeFlagName, typeName)
+			}
+			messageDescriptors = append(messageDescriptors, messageDescriptor)
+		}
+		return messageDescriptors, nil
+	}
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, fileDescriptor.Messages())
+	}
+	return messageDescriptors, nil
+}
+
+func appendMessageDescriptors(
+	messageDescriptors []protoreflect.MessageDescriptor,
+	messages protoreflect.MessageDescriptors,
+) []protoreflect.MessageDescriptor {
+	for i := 0; i < messages.Len(); i++ {
+		messageDescriptor := messages.Get(i)
+		if messageDescriptor.IsMapEntry() {
+			continue
+		}
+		messageDescriptors = append(messageDescriptors, messageDescriptor)
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, messageDescriptor.Messages())
+	}
+	return messageDescriptors
+}
+
+// rangesString returns the ranges as a comma-separated string, where each range
+// is either a single number or "start-end".
+func rangesString(ranges []buffieldnumber.Range) string {
+	rangeStrings := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.Start == r.End {
+			rangeStrings[i] = strconv.Itoa(int(r.Start))
+		} else {
+			rangeStrings[i] = strconv.Itoa(int(r.Start)) + "-" + strconv.Itoa(int(r.End))
+		}
+	}
+	return strings.Join(rangeStrings, ",")
+}
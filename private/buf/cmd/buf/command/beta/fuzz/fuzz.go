@@ -0,0 +1,242 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fuzz
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/buffuzz"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufreflect"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	typeFlagName            = "type"
+	countFlagName           = "count"
+	seedFlagName            = "seed"
+	formatFlagName          = "format"
+	protovalidateFlagName   = "protovalidate"
+	maxDepthFlagName        = "max-depth"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+
+	formatJSONL = "jsonl"
+	formatBinpb = "binpb"
+)
+
+var allFormats = []string{formatJSONL, formatBinpb}
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input> --type=<type>",
+		Short: "Generate random payloads for a message type",
+		Long: `Generate random but schema-valid payloads for a message type, to feed fuzzing and load-test harnesses.
+
+The payloads are written to stdout, either as one JSON message per line with --format=jsonl,
+or as a stream of binary messages each prefixed with its varint-encoded size with --format=binpb.
+
+The same input, type, seed, and flags always generate the same payloads, so that failures can be
+reproduced. With --protovalidate, the payloads also satisfy most protovalidate constraints; CEL
+expressions and patterns are not considered.
+
+Generate 100 payloads for a message:
+
+    $ buf beta fuzz --type acme.weather.v1.GetWeatherRequest --count 100 --seed 42
+
+` + bufcli.GetInputLong(`the source, module, or image containing the type`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Type            string
+	Count           int
+	Seed            int64
+	Format          string
+	Protovalidate   bool
+	MaxDepth        int
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Type,
+		typeFlagName,
+		"",
+		`The full type name of the message within the input (e.g. acme.weather.v1.Units)`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, typeFlagName)
+	flagSet.IntVar(
+		&f.Count,
+		countFlagName,
+		1,
+		"The number of payloads to generate",
+	)
+	flagSet.Int64Var(
+		&f.Seed,
+		seedFlagName,
+		0,
+		"The seed for the random generator",
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		formatJSONL,
+		fmt.Sprintf(
+			"The format of the payloads. Must be one of %s",
+			stringutil.SliceToString(allFormats),
+		),
+	)
+	flagSet.BoolVar(
+		&f.Protovalidate,
+		protovalidateFlagName,
+		false,
+		"Generate payloads that satisfy the protovalidate constraints of the type where possible",
+	)
+	flagSet.IntVar(
+		&f.MaxDepth,
+		maxDepthFlagName,
+		buffuzz.DefaultMaxDepth,
+		"The maximum depth of nested messages",
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	if flags.Format != formatJSONL && flags.Format != formatBinpb {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must be one of %s but was %q", formatFlagName, stringutil.SliceToString(allFormats), flags.Format)
+	}
+	if flags.Count < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must not be negative", countFlagName)
+	}
+	if flags.MaxDepth < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must not be negative", maxDepthFlagName)
+	}
+	if err := bufreflect.ValidateTypeName(flags.Type); err != nil {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %v", typeFlagName, err)
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return err
+	}
+	descriptor, err := resolver.FindDescriptorByName(protoreflect.FullName(flags.Type))
+	if err != nil {
+		return fmt.Errorf("could not find type %q: %w", flags.Type, err)
+	}
+	messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %q must be a message", typeFlagName, flags.Type)
+	}
+	generatorOptions := []buffuzz.GeneratorOption{
+		buffuzz.GeneratorWithMaxDepth(flags.MaxDepth),
+	}
+	if flags.Protovalidate {
+		generatorOptions = append(generatorOptions, buffuzz.GeneratorWithProtovalidate())
+	}
+	generator := buffuzz.NewGenerator(messageDescriptor, flags.Seed, generatorOptions...)
+	var marshaler protoencoding.Marshaler
+	switch flags.Format {
+	case formatJSONL:
+		marshaler = protoencoding.NewJSONMarshaler(resolver)
+	case formatBinpb:
+		marshaler = protoencoding.NewWireMarshaler()
+	}
+	for i := 0; i < flags.Count; i++ {
+		message, err := generator.Generate()
+		if err != nil {
+			return err
+		}
+		data, err := marshaler.Marshal(message)
+		if err != nil {
+			return err
+		}
+		switch flags.Format {
+		case formatJSONL:
+			data = append(data, '\n')
+		case formatBinpb:
+			data = append(protowire.AppendVarint(nil, uint64(len(data))), data...)
+		}
+		if _, err := container.Stdout().Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -19,7 +19,6 @@ import (
 	"fmt"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
-	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/buf/bufwire"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufapimodule"
@@ -105,7 +104,11 @@ func run(
 	if err != nil {
 		return err
 	}
-	sourceOrModuleRef, err := buffetch.NewRefParser(container.Logger()).GetSourceOrModuleRef(ctx, input)
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -126,7 +129,7 @@ func run(
 	moduleConfigReader := bufwire.NewModuleConfigReader(
 		container.Logger(),
 		storageosProvider,
-		bufcli.NewFetchReader(container.Logger(), storageosProvider, runner, moduleResolver, moduleReader),
+		bufcli.NewFetchReader(container.Logger(), storageosProvider, runner, container.ProgressReporter(), moduleResolver, moduleReader),
 		bufmodulebuild.NewModuleBucketBuilder(),
 	)
 	graphBuilder := bufgraph.NewBuilder(
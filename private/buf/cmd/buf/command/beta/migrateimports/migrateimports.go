@@ -0,0 +1,125 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrateimports
+
+import (
+	"context"
+	"os"
+
+	"github.com/bufbuild/buf/private/buf/bufmigrate"
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	mappingFlagName = "mapping"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory>",
+		Short: "Rewrite import paths and packages after moving files",
+		Long: `Rewrite the import paths, package declarations, and fully-qualified references in
+all .proto files of the module or workspace in the directory, and update the build.excludes
+of each buf.yaml, according to a mapping file.
+
+The mapping file maps old import paths and packages to new ones. A path may be a file or
+a directory, and a package also maps all of its sub-packages. For example:
+
+  paths:
+    acme/weather/v1: acme/forecast/v1
+  packages:
+    acme.weather.v1: acme.forecast.v1
+
+Files are not moved, this command only updates the references to them. Relative references
+to types and file options such as go_package are not updated.
+
+Defaults to the current directory if not specified.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Mapping string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Mapping,
+		mappingFlagName,
+		"",
+		"The path to a YAML or JSON file that maps old import paths and packages to new ones",
+	)
+	_ = cobra.MarkFlagRequired(flagSet, mappingFlagName)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	dirPath, err := getDirPath(container)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(flags.Mapping)
+	if err != nil {
+		return err
+	}
+	importMapping, err := bufmigrate.ReadImportMapping(data)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %v", mappingFlagName, err)
+	}
+	return bufmigrate.NewImportMigrator(
+		importMapping,
+		bufmigrate.ImportMigratorWithNotifier(newWriteMessageFunc(container)),
+	).Migrate(dirPath)
+}
+
+func getDirPath(container app.Container) (string, error) {
+	switch numArgs := container.NumArgs(); numArgs {
+	case 0:
+		return ".", nil
+	case 1:
+		return container.Arg(0), nil
+	default:
+		return "", appcmd.NewInvalidArgumentErrorf("only 1 argument allowed but %d arguments specified", numArgs)
+	}
+}
+
+func newWriteMessageFunc(container app.StderrContainer) func(string) error {
+	return func(message string) error {
+		_, err := container.Stderr().Write([]byte(message))
+		return err
+	}
+}
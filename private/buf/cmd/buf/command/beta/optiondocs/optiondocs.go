@@ -0,0 +1,217 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package optiondocs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufoptiondoc"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	pathsFlagName           = "path"
+	excludePathsFlagName    = "exclude-path"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Print the documentation of the custom options used by an input",
+		Long: `For each custom option set within the input, such as (acme.v1.routing), this prints
+the comments attached to the extension that defines the option, and the elements that set it.
+Options defined in dependencies are documented as long as the dependency was built with source
+code info, which is the case for sources and modules.
+
+` + bufcli.GetInputLong(`the source, module, or image to document`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format          string
+	ErrorFormat     string
+	Config          string
+	Paths           []string
+	ExcludePaths    []string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
+	if err != nil {
+		return err
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		false, // source code info is needed for the documentation
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		// stderr since we output to stdout
+		if err := bufanalysis.PrintFileAnnotations(
+			container.Stderr(),
+			fileAnnotations,
+			flags.ErrorFormat,
+		); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	var options []*bufoptiondoc.Option
+	for _, imageConfig := range imageConfigs {
+		image := imageConfig.Image()
+		var targetFilePaths []string
+		for _, imageFile := range image.Files() {
+			if !imageFile.IsImport() {
+				targetFilePaths = append(targetFilePaths, imageFile.Path())
+			}
+		}
+		imageOptions, err := bufoptiondoc.GetOptions(bufimage.ImageToFileDescriptorProtos(image), targetFilePaths)
+		if err != nil {
+			return err
+		}
+		options = mergeOptions(options, imageOptions)
+	}
+	switch format {
+	case bufprint.FormatText:
+		return bufoptiondoc.WriteOptionsText(container.Stdout(), options)
+	case bufprint.FormatJSON:
+		return bufoptiondoc.WriteOptionsJSON(container.Stdout(), options)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// mergeOptions merges the options of multiple images of a workspace, which may
+// use the same options.
+func mergeOptions(options []*bufoptiondoc.Option, newOptions []*bufoptiondoc.Option) []*bufoptiondoc.Option {
+	nameToOption := make(map[string]*bufoptiondoc.Option, len(options))
+	for _, option := range options {
+		nameToOption[option.Name] = option
+	}
+	for _, newOption := range newOptions {
+		if option, ok := nameToOption[newOption.Name]; ok {
+			option.Usages = append(option.Usages, newOption.Usages...)
+			continue
+		}
+		options = append(options, newOption)
+	}
+	sort.Slice(options, func(i int, j int) bool { return options[i].Name < options[j].Name })
+	return options
+}
@@ -21,7 +21,6 @@ import (
 	"text/template"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
-	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulestat"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
@@ -111,7 +110,11 @@ func run(
 	if err != nil {
 		return err
 	}
-	sourceOrModuleRef, err := buffetch.NewRefParser(container.Logger()).GetSourceOrModuleRef(ctx, input)
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, input)
 	if err != nil {
 		return err
 	}
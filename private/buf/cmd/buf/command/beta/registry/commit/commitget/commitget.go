@@ -31,7 +31,10 @@ import (
 	"github.com/spf13/pflag"
 )
 
-const formatFlagName = "format"
+const (
+	formatFlagName  = "format"
+	detailsFlagName = "details"
+)
 
 // NewCommand returns a new Command
 func NewCommand(
@@ -54,7 +57,8 @@ func NewCommand(
 }
 
 type flags struct {
-	Format string
+	Format  string
+	Details bool
 }
 
 func newFlags() *flags {
@@ -68,6 +72,11 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		bufprint.FormatText.String(),
 		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
 	)
+	flagSet.BoolVar(&f.Details,
+		detailsFlagName,
+		false,
+		`Include the create time, digest, labels, author, and source of the commit in the text output. The JSON output always includes these`,
+	)
 }
 
 func run(
@@ -108,6 +117,10 @@ func run(
 		}
 		return err
 	}
-	return bufprint.NewRepositoryCommitPrinter(container.Stdout()).
+	var printerOptions []bufprint.RepositoryCommitPrinterOption
+	if flags.Details {
+		printerOptions = append(printerOptions, bufprint.RepositoryCommitPrinterWithDetails())
+	}
+	return bufprint.NewRepositoryCommitPrinter(container.Stdout(), printerOptions...).
 		PrintRepositoryCommit(ctx, format, resp.Msg.RepositoryCommit)
 }
@@ -36,6 +36,7 @@ const (
 	pageTokenFlagName = "page-token"
 	reverseFlagName   = "reverse"
 	formatFlagName    = "format"
+	detailsFlagName   = "details"
 )
 
 // NewCommand returns a new Command
@@ -63,6 +64,7 @@ type flags struct {
 	PageSize  uint32
 	PageToken string
 	Reverse   bool
+	Details   bool
 }
 
 func newFlags() *flags {
@@ -91,6 +93,11 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		bufprint.FormatText.String(),
 		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
 	)
+	flagSet.BoolVar(&f.Details,
+		detailsFlagName,
+		false,
+		`Include the create time, digest, labels, author, and source of each commit in the text output. The JSON output always includes these`,
+	)
 }
 
 func run(
@@ -140,6 +147,10 @@ func run(
 		}
 		return err
 	}
-	return bufprint.NewRepositoryCommitPrinter(container.Stdout()).
+	var printerOptions []bufprint.RepositoryCommitPrinterOption
+	if flags.Details {
+		printerOptions = append(printerOptions, bufprint.RepositoryCommitPrinterWithDetails())
+	}
+	return bufprint.NewRepositoryCommitPrinter(container.Stdout(), printerOptions...).
 		PrintRepositoryCommits(ctx, format, resp.Msg.NextPageToken, resp.Msg.RepositoryCommits...)
 }
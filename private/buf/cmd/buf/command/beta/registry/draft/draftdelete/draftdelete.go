@@ -31,7 +31,11 @@ import (
 	"github.com/spf13/pflag"
 )
 
-const forceFlagName = "force"
+const (
+	forceFlagName          = "force"
+	yesFlagName            = "yes"
+	nonInteractiveFlagName = "non-interactive"
+)
 
 // NewCommand returns a new Command
 func NewCommand(
@@ -54,7 +58,9 @@ func NewCommand(
 }
 
 type flags struct {
-	Force bool
+	Force          bool
+	Yes            bool
+	NonInteractive bool
 }
 
 func newFlags() *flags {
@@ -68,6 +74,9 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		false,
 		"Force deletion without confirming. Use with caution",
 	)
+	_ = flagSet.MarkHidden(forceFlagName)
+	bufcli.BindYes(flagSet, &f.Yes, yesFlagName)
+	bufcli.BindNonInteractive(flagSet, &f.NonInteractive, nonInteractiveFlagName)
 }
 
 func run(
@@ -97,14 +106,12 @@ func run(
 		moduleReference.Remote(),
 		registryv1alpha1connect.NewRepositoryCommitServiceClient,
 	)
-	if !flags.Force {
-		if err := bufcli.PromptUserForDelete(
-			container,
-			"draft",
-			moduleReference.Reference(),
-		); err != nil {
-			return err
-		}
+	if err := bufcli.PromptUserForDelete(
+		bufcli.NewPrompter(container, flags.Yes || flags.Force, flags.NonInteractive),
+		"draft",
+		moduleReference.Reference(),
+	); err != nil {
+		return err
 	}
 	if _, err := service.DeleteRepositoryDraftCommit(
 		ctx,
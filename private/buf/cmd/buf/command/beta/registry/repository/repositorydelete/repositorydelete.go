@@ -30,7 +30,11 @@ import (
 	"github.com/spf13/pflag"
 )
 
-const forceFlagName = "force"
+const (
+	forceFlagName          = "force"
+	yesFlagName            = "yes"
+	nonInteractiveFlagName = "non-interactive"
+)
 
 // NewCommand returns a new Command
 func NewCommand(
@@ -53,7 +57,9 @@ func NewCommand(
 }
 
 type flags struct {
-	Force bool
+	Force          bool
+	Yes            bool
+	NonInteractive bool
 }
 
 func newFlags() *flags {
@@ -67,6 +73,9 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		false,
 		"Force deletion without confirming. Use with caution",
 	)
+	_ = flagSet.MarkHidden(forceFlagName)
+	bufcli.BindYes(flagSet, &f.Yes, yesFlagName)
+	bufcli.BindNonInteractive(flagSet, &f.NonInteractive, nonInteractiveFlagName)
 }
 
 func run(
@@ -88,10 +97,12 @@ func run(
 		moduleIdentity.Remote(),
 		registryv1alpha1connect.NewRepositoryServiceClient,
 	)
-	if !flags.Force {
-		if err := bufcli.PromptUserForDelete(container, "repository", moduleIdentity.Repository()); err != nil {
-			return err
-		}
+	if err := bufcli.PromptUserForDelete(
+		bufcli.NewPrompter(container, flags.Yes || flags.Force, flags.NonInteractive),
+		"repository",
+		moduleIdentity.Repository(),
+	); err != nil {
+		return err
 	}
 	if _, err := service.DeleteRepositoryByFullName(
 		ctx,
@@ -0,0 +1,292 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffold
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufscaffold"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	outputFlagName          = "output"
+	outputFlagShortName     = "o"
+	templateFlagName        = "template"
+	serviceFlagName         = "service"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	pathsFlagName           = "path"
+	excludePathsFlagName    = "exclude-path"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Generate a server skeleton for the services of the input",
+		Long: `By default, a runnable Connect server in Go is written to main.go in the --output directory.
+The server serves all of the services, which also makes them available over gRPC and gRPC-Web,
+along with the gRPC health checking and server reflection protocols. Each method returns an
+unimplemented error and is marked with a TODO.
+
+The server imports the code generated by protoc-gen-go and protoc-gen-connect-go for the
+services, so all files with services must have the go_package option set.
+
+To scaffold a server in another language, or with another layout, use --template with a
+directory of templates. Files in the directory ending in ` + bufscaffold.TemplateExt + ` are executed as Go
+text/template templates and written to the --output directory without the extension, and all
+other files are copied as-is. Templates are executed with the following data:
+
+    .Services                      the services
+    .Services[].Name               the name of the service, such as FooService
+    .Services[].FullName           the fully-qualified name of the service, such as foo.v1.FooService
+    .Services[].Package            the package of the service, such as foo.v1
+    .Services[].Methods            the methods of the service
+    .Services[].Methods[].Name             the name of the method, such as GetFoo
+    .Services[].Methods[].Procedure        the path of the method, such as /foo.v1.FooService/GetFoo
+    .Services[].Methods[].InputType        the fully-qualified name of the input message
+    .Services[].Methods[].OutputType       the fully-qualified name of the output message
+    .Services[].Methods[].ClientStreaming  whether the method is client streaming
+    .Services[].Methods[].ServerStreaming  whether the method is server streaming
+
+Existing files in the --output directory are never overwritten.
+
+If no --service flags are given, all services in the input are scaffolded, excluding services
+in dependencies.
+
+` + bufcli.GetInputLong(`the source, module, or image to scaffold a server for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Output          string
+	Template        string
+	Services        []string
+	ErrorFormat     string
+	Config          string
+	Paths           []string
+	ExcludePaths    []string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
+	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVarP(
+		&f.Output,
+		outputFlagName,
+		outputFlagShortName,
+		"",
+		`The directory to write the server to`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, outputFlagName)
+	flagSet.StringVar(
+		&f.Template,
+		templateFlagName,
+		"",
+		`A directory of templates to scaffold the server with, instead of the built-in Go server`,
+	)
+	flagSet.StringSliceVar(
+		&f.Services,
+		serviceFlagName,
+		nil,
+		`The fully-qualified names of the services to scaffold. Defaults to all services in the input`,
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		flags.Config,
+		flags.Paths,
+		flags.ExcludePaths,
+		false,
+		true, // source code info is not needed to scaffold
+	)
+	if err != nil {
+		return err
+	}
+	serviceDescriptors, err := getServiceDescriptors(image, flags.Services)
+	if err != nil {
+		return err
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	var pathToContent map[string][]byte
+	if flags.Template != "" {
+		templateReadBucket, err := storageosProvider.NewReadWriteBucket(
+			flags.Template,
+			storageos.ReadWriteBucketWithSymlinksIfSupported(),
+		)
+		if err != nil {
+			return err
+		}
+		pathToContent, err = bufscaffold.GenerateTemplates(ctx, templateReadBucket, bufscaffold.NewData(serviceDescriptors))
+		if err != nil {
+			return err
+		}
+	} else {
+		pathToContent, err = bufscaffold.GenerateGo(serviceDescriptors)
+		if err != nil {
+			return err
+		}
+	}
+	outputReadWriteBucket, err := storageosProvider.NewReadWriteBucket(
+		flags.Output,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return err
+	}
+	return writeFiles(ctx, container, outputReadWriteBucket, pathToContent)
+}
+
+func getServiceDescriptors(image bufimage.Image, services []string) ([]protoreflect.ServiceDescriptor, error) {
+	if len(services) == 0 {
+		for _, imageFile := range image.Files() {
+			if imageFile.IsImport() {
+				continue
+			}
+			for _, serviceDescriptorProto := range imageFile.FileDescriptorProto().GetService() {
+				services = append(services, imageFile.FileDescriptorProto().GetPackage()+"."+serviceDescriptorProto.GetName())
+			}
+		}
+		if len(services) == 0 {
+			return nil, errors.New("input has no services")
+		}
+	}
+	files, err := protodesc.NewFiles(bufimage.ImageToFileDescriptorSet(image))
+	if err != nil {
+		return nil, err
+	}
+	serviceDescriptors := make([]protoreflect.ServiceDescriptor, 0, len(services))
+	for _, service := range services {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			return nil, appcmd.NewInvalidArgumentErrorf("--%s: %q not found", serviceFlagName, service)
+		}
+		serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, appcmd.NewInvalidArgumentErrorf("--%s: %q is not a service", serviceFlagName, service)
+		}
+		serviceDescriptors = append(serviceDescriptors, serviceDescriptor)
+	}
+	return serviceDescriptors, nil
+}
+
+// writeFiles writes the files to the bucket, and prints the path of each file written.
+//
+// Nothing is written if any of the files already exist.
+func writeFiles(
+	ctx context.Context,
+	container appflag.Container,
+	readWriteBucket storage.ReadWriteBucket,
+	pathToContent map[string][]byte,
+) error {
+	paths := make([]string, 0, len(pathToContent))
+	for path := range pathToContent {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var existingExternalPaths []string
+	for _, path := range paths {
+		objectInfo, err := readWriteBucket.Stat(ctx, path)
+		if err == nil {
+			existingExternalPaths = append(existingExternalPaths, objectInfo.ExternalPath())
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if len(existingExternalPaths) > 0 {
+		return fmt.Errorf("files already exist, remove them to scaffold again: %s", stringutil.SliceToHumanString(existingExternalPaths))
+	}
+	for _, path := range paths {
+		if err := storage.PutPath(ctx, readWriteBucket, path, pathToContent[path]); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(container.Stdout(), path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizereport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/buf/bufsize"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufreflect"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	typeFlagName            = "type"
+	presenceFlagName        = "presence"
+	repeatedCountFlagName   = "repeated-count"
+	stringLengthFlagName    = "string-length"
+	maxDepthFlagName        = "max-depth"
+	samplesFlagName         = "samples"
+	samplesFormatFlagName   = "samples-format"
+	formatFlagName          = "format"
+	errorFormatFlagName     = "error-format"
+	disableSymlinksFlagName = "disable-symlinks"
+
+	samplesFormatJSONL = "jsonl"
+	samplesFormatBinpb = "binpb"
+
+	// maxSampleLineSize is the maximum size of a single JSON sample.
+	maxSampleLineSize = 64 * 1024 * 1024
+)
+
+var allSamplesFormats = []string{samplesFormatJSONL, samplesFormatBinpb}
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Estimate the encoded sizes of message types",
+		Long: `Estimate the minimum, typical, and maximum encoded sizes in bytes of message types.
+
+The minimum size is that of a message with only its required fields set. The maximum size
+is that of a message with all of its fields set to their largest values, and is unbounded
+if the message has repeated, map, string, or bytes fields, or is recursive.
+
+The typical size is computed from assumptions that can be tuned with flags: the fraction of
+fields that are set, the number of elements of repeated and map fields, the length of string
+and bytes fields, and the depth to which nested messages are set. Enums and bools are assumed
+to encode as one byte, and other varints as two bytes. Alternatively, the typical size is the
+mean size of the sample payloads given by --samples, either as one JSON message per line with
+--samples-format=jsonl, or as a stream of binary messages each prefixed with its varint-encoded
+size with --samples-format=binpb, as written by "buf beta fuzz".
+
+If --type is not set, all message types in the input are reported. Imports are not reported.
+
+` + bufcli.GetInputLong(`the source, module, or image containing the types`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Types           []string
+	Presence        float64
+	RepeatedCount   int
+	StringLength    int
+	MaxDepth        int
+	Samples         string
+	SamplesFormat   string
+	Format          string
+	ErrorFormat     string
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Types,
+		typeFlagName,
+		nil,
+		`The full type name of a message within the input (e.g. acme.weather.v1.Units)
+May be provided multiple times. If not set, all messages are reported`,
+	)
+	flagSet.Float64Var(
+		&f.Presence,
+		presenceFlagName,
+		bufsize.DefaultPresence,
+		"The fraction of fields assumed to be set for the typical size, between 0 and 1",
+	)
+	flagSet.IntVar(
+		&f.RepeatedCount,
+		repeatedCountFlagName,
+		bufsize.DefaultRepeatedCount,
+		"The number of elements assumed for repeated and map fields for the typical size",
+	)
+	flagSet.IntVar(
+		&f.StringLength,
+		stringLengthFlagName,
+		bufsize.DefaultStringLength,
+		"The length in bytes assumed for string and bytes fields for the typical size",
+	)
+	flagSet.IntVar(
+		&f.MaxDepth,
+		maxDepthFlagName,
+		bufsize.DefaultMaxDepth,
+		"The maximum depth of nested messages assumed to be set for the typical size",
+	)
+	flagSet.StringVar(
+		&f.Samples,
+		samplesFlagName,
+		"",
+		fmt.Sprintf(
+			`The file containing sample payloads to compute the typical size from, or "-" for stdin
+Requires exactly one --%s`,
+			typeFlagName,
+		),
+	)
+	flagSet.StringVar(
+		&f.SamplesFormat,
+		samplesFormatFlagName,
+		samplesFormatJSONL,
+		fmt.Sprintf(
+			"The format of the sample payloads. Must be one of %s",
+			stringutil.SliceToString(allSamplesFormats),
+		),
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Presence < 0 || flags.Presence > 1 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must be between 0 and 1 but was %v", presenceFlagName, flags.Presence)
+	}
+	if flags.RepeatedCount < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must not be negative", repeatedCountFlagName)
+	}
+	if flags.StringLength < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must not be negative", stringLengthFlagName)
+	}
+	if flags.MaxDepth < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must not be negative", maxDepthFlagName)
+	}
+	if flags.SamplesFormat != samplesFormatJSONL && flags.SamplesFormat != samplesFormatBinpb {
+		return appcmd.NewInvalidArgumentErrorf("--%s: must be one of %s but was %q", samplesFormatFlagName, stringutil.SliceToString(allSamplesFormats), flags.SamplesFormat)
+	}
+	if flags.Samples != "" && len(flags.Types) != 1 {
+		return appcmd.NewInvalidArgumentErrorf("--%s requires exactly one --%s", samplesFlagName, typeFlagName)
+	}
+	for _, typeName := range flags.Types {
+		if err := bufreflect.ValidateTypeName(typeName); err != nil {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %v", typeFlagName, err)
+		}
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		flags.DisableSymlinks,
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return err
+	}
+	messageDescriptors, err := getMessageDescriptors(image, resolver, flags.Types)
+	if err != nil {
+		return err
+	}
+	estimateOptions := []bufsize.EstimateOption{
+		bufsize.EstimateWithPresence(flags.Presence),
+		bufsize.EstimateWithRepeatedCount(flags.RepeatedCount),
+		bufsize.EstimateWithStringLength(flags.StringLength),
+		bufsize.EstimateWithMaxDepth(flags.MaxDepth),
+	}
+	if flags.Samples != "" {
+		samples, err := readSamples(container, resolver, messageDescriptors[0], flags.Samples, flags.SamplesFormat)
+		if err != nil {
+			return err
+		}
+		estimateOptions = append(estimateOptions, bufsize.EstimateWithSamples(samples...))
+	}
+	estimates := make([]*bufsize.Estimate, len(messageDescriptors))
+	for i, messageDescriptor := range messageDescriptors {
+		estimates[i] = bufsize.EstimateMessage(messageDescriptor, estimateOptions...)
+	}
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Type",
+				"Min",
+				"Typical",
+				"Max",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, estimate := range estimates {
+					maxString := "unbounded"
+					if estimate.Max != bufsize.Unbounded {
+						maxString = strconv.Itoa(estimate.Max)
+					}
+					if err := tabWriter.Write(
+						estimate.TypeName,
+						strconv.Itoa(estimate.Min),
+						strconv.Itoa(estimate.Typical),
+						maxString,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		for _, estimate := range estimates {
+			if err := json.NewEncoder(container.Stdout()).Encode(estimate); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+// getMessageDescriptors returns the descriptors for the type names, or for all
+// messages in the non-import files of the image if there are no type names.
+func getMessageDescriptors(
+	image bufimage.Image,
+	resolver protoencoding.Resolver,
+	typeNames []string,
+) ([]protoreflect.MessageDescriptor, error) {
+	var messageDescriptors []protoreflect.MessageDescriptor
+	if len(typeNames) > 0 {
+		for _, typeName := range typeNames {
+			descriptor, err := resolver.FindDescriptorByName(protoreflect.FullName(typeName))
+			if err != nil {
+				return nil, fmt.Errorf("could not find type %q: %w", typeName, err)
+			}
+			messageDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+			if !ok {
+				return nil, appcmd.NewInvalidArgumentErrorf("--%s: %q must be a message", typeFlagName, typeName)
+			}
+			messageDescriptors = append(messageDescriptors, messageDescriptor)
+		}
+		return messageDescriptors, nil
+	}
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, fileDescriptor.Messages())
+	}
+	return messageDescriptors, nil
+}
+
+func appendMessageDescriptors(
+	messageDescriptors []protoreflect.MessageDescriptor,
+	messages protoreflect.MessageDescriptors,
+) []protoreflect.MessageDescriptor {
+	for i := 0; i < messages.Len(); i++ {
+		messageDescriptor := messages.Get(i)
+		if messageDescriptor.IsMapEntry() {
+			continue
+		}
+		messageDescriptors = append(messageDescriptors, messageDescriptor)
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, messageDescriptor.Messages())
+	}
+	return messageDescriptors
+}
+
+func readSamples(
+	container appflag.Container,
+	resolver protoencoding.Resolver,
+	messageDescriptor protoreflect.MessageDescriptor,
+	path string,
+	samplesFormat string,
+) ([]proto.Message, error) {
+	var reader io.Reader = container.Stdin()
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not open samples: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	var samples []proto.Message
+	switch samplesFormat {
+	case samplesFormatJSONL:
+		unmarshaler := protoencoding.NewJSONUnmarshaler(resolver)
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(nil, maxSampleLineSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			sample := dynamicpb.NewMessage(messageDescriptor)
+			if err := unmarshaler.Unmarshal(line, sample); err != nil {
+				return nil, fmt.Errorf("could not parse sample %d: %w", len(samples)+1, err)
+			}
+			samples = append(samples, sample)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	case samplesFormatBinpb:
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		unmarshaler := protoencoding.NewWireUnmarshaler(resolver)
+		for len(data) > 0 {
+			sampleData, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, fmt.Errorf("could not read sample %d: %w", len(samples)+1, protowire.ParseError(n))
+			}
+			data = data[n:]
+			sample := dynamicpb.NewMessage(messageDescriptor)
+			if err := unmarshaler.Unmarshal(sampleData, sample); err != nil {
+				return nil, fmt.Errorf("could not parse sample %d: %w", len(samples)+1, err)
+			}
+			samples = append(samples, sample)
+		}
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no samples were found")
+	}
+	return samples, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotcreate
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufsnapshot"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+)
+
+const (
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <source>",
+		Short: "Capture the resolved state of a workspace to stdout",
+		Long: `The snapshot contains the digest of each module, which covers its sources, configuration,
+documentation, and license, along with the resolved commit and digest of each dependency and the version
+of buf used to create it. If the source is a local directory, the configuration file of each module
+is also captured. The snapshot can later be checked with "buf beta snapshot verify", and the dependencies
+and configuration can be restored with "buf beta snapshot restore".
+
+` + bufcli.GetSourceLong(`the source to snapshot`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Config          string
+	DisableSymlinks bool
+
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) (retErr error) {
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewSourceRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceRef, err := refParser.GetSourceRef(ctx, input)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	moduleReader, err := bufcli.NewModuleReaderAndCreateCacheDirs(container, clientConfig)
+	if err != nil {
+		return err
+	}
+	moduleConfigReader, err := bufcli.NewWireModuleConfigReaderForModuleReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+		moduleReader,
+	)
+	if err != nil {
+		return err
+	}
+	moduleConfigSet, err := moduleConfigReader.GetModuleConfigSet(
+		ctx,
+		container,
+		sourceRef,
+		flags.Config,
+		nil,
+		nil,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	moduleConfigs := moduleConfigSet.ModuleConfigs()
+	modules := make([]bufmodule.Module, len(moduleConfigs))
+	for i, moduleConfig := range moduleConfigs {
+		modules[i] = moduleConfig.Module()
+	}
+	snapshot, err := bufsnapshot.NewSnapshot(ctx, bufcli.Version, modules)
+	if err != nil {
+		return err
+	}
+	if isLocalDir(input) {
+		// The directories of the modules are relative to the root of the workspace,
+		// which may be a parent of the input directory.
+		readBucketCloser, err := bufcli.NewFetchReader(
+			container.Logger(),
+			storageosProvider,
+			runner,
+			container.ProgressReporter(),
+			nil,
+			nil,
+		).GetSourceBucket(ctx, container, sourceRef)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = multierr.Append(retErr, readBucketCloser.Close())
+		}()
+		if err := bufsnapshot.AddConfigs(ctx, readBucketCloser, snapshot); err != nil {
+			return err
+		}
+	}
+	return bufsnapshot.WriteSnapshot(container.Stdout(), snapshot)
+}
+
+// isLocalDir returns true if the input is a local directory.
+func isLocalDir(input string) bool {
+	path, _, _ := strings.Cut(input, "#")
+	fileInfo, err := os.Stat(path)
+	return err == nil && fileInfo.IsDir()
+}
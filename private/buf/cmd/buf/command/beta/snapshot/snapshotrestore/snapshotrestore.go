@@ -0,0 +1,139 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotrestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufsnapshot"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+)
+
+const (
+	snapshotFlagName = "snapshot"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory>",
+		Short: "Restore the dependencies and configuration of a workspace from a snapshot",
+		Long: `The buf.lock file of each module in the snapshot is rewritten to pin the dependency commits
+recorded in the snapshot, and the configuration file of each module is rewritten if it was captured
+when the snapshot was created. The paths of the written files are printed to stdout.
+
+Sources are not restored. Run "buf beta snapshot verify" afterwards to check that the sources also
+match the snapshot.
+
+The first argument is the directory of the workspace or module to restore.
+Defaults to "." if no argument is specified.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Snapshot string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Snapshot,
+		snapshotFlagName,
+		"",
+		`The path to the snapshot file to restore from`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, snapshotFlagName)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	snapshot, err := readSnapshot(flags.Snapshot)
+	if err != nil {
+		return err
+	}
+	if snapshot.BufVersion != bufcli.Version {
+		container.Logger().Sugar().Warnf(
+			"snapshot was created with buf %s but the current version is %s",
+			snapshot.BufVersion,
+			bufcli.Version,
+		)
+	}
+	directoryInput, err := bufcli.GetInputValue(container, "", ".")
+	if err != nil {
+		return err
+	}
+	fileInfo, err := os.Stat(directoryInput)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if !fileInfo.IsDir() {
+		return appcmd.NewInvalidArgumentErrorf("%q is not a directory", directoryInput)
+	}
+	storageosProvider := storageos.NewProvider(storageos.ProviderWithSymlinks())
+	readWriteBucket, err := storageosProvider.NewReadWriteBucket(
+		directoryInput,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return bufcli.NewInternalError(err)
+	}
+	writtenPaths, err := bufsnapshot.Restore(ctx, readWriteBucket, snapshot)
+	if err != nil {
+		return err
+	}
+	if len(writtenPaths) == 0 {
+		return nil
+	}
+	_, err = container.Stdout().Write([]byte(strings.Join(writtenPaths, "\n") + "\n"))
+	return err
+}
+
+func readSnapshot(snapshotPath string) (_ *bufsnapshot.Snapshot, retErr error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	return bufsnapshot.ReadSnapshot(file)
+}
@@ -0,0 +1,186 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotverify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufsnapshot"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+)
+
+const (
+	snapshotFlagName        = "snapshot"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <source>",
+		Short: "Verify that a workspace matches a snapshot",
+		Long: `The workspace is resolved and compared against a snapshot created with "buf beta snapshot create".
+Any module whose sources, configuration, or dependencies differ from the snapshot is printed to stdout,
+and the command exits with a non-zero exit code.
+
+If the dependencies or configuration differ, they can be restored with "buf beta snapshot restore".
+
+` + bufcli.GetSourceLong(`the source to verify`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Snapshot        string
+	Config          string
+	DisableSymlinks bool
+
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.Snapshot,
+		snapshotFlagName,
+		"",
+		`The path to the snapshot file to verify against`,
+	)
+	_ = cobra.MarkFlagRequired(flagSet, snapshotFlagName)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) (retErr error) {
+	expectedSnapshot, err := readSnapshot(flags.Snapshot)
+	if err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewSourceRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceRef, err := refParser.GetSourceRef(ctx, input)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	moduleReader, err := bufcli.NewModuleReaderAndCreateCacheDirs(container, clientConfig)
+	if err != nil {
+		return err
+	}
+	moduleConfigReader, err := bufcli.NewWireModuleConfigReaderForModuleReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+		moduleReader,
+	)
+	if err != nil {
+		return err
+	}
+	moduleConfigSet, err := moduleConfigReader.GetModuleConfigSet(
+		ctx,
+		container,
+		sourceRef,
+		flags.Config,
+		nil,
+		nil,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	moduleConfigs := moduleConfigSet.ModuleConfigs()
+	modules := make([]bufmodule.Module, len(moduleConfigs))
+	for i, moduleConfig := range moduleConfigs {
+		modules[i] = moduleConfig.Module()
+	}
+	actualSnapshot, err := bufsnapshot.NewSnapshot(ctx, bufcli.Version, modules)
+	if err != nil {
+		return err
+	}
+	if expectedSnapshot.BufVersion != actualSnapshot.BufVersion {
+		container.Logger().Sugar().Warnf(
+			"snapshot was created with buf %s but the current version is %s",
+			expectedSnapshot.BufVersion,
+			actualSnapshot.BufVersion,
+		)
+	}
+	diffs := bufsnapshot.Diff(expectedSnapshot, actualSnapshot)
+	if len(diffs) == 0 {
+		return nil
+	}
+	if _, err := container.Stdout().Write([]byte(strings.Join(diffs, "\n") + "\n")); err != nil {
+		return err
+	}
+	return bufcli.ErrFileAnnotation
+}
+
+func readSnapshot(snapshotPath string) (_ *bufsnapshot.Snapshot, retErr error) {
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open snapshot: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, file.Close())
+	}()
+	return bufsnapshot.ReadSnapshot(file)
+}
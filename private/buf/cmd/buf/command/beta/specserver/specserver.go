@@ -0,0 +1,213 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package specserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufspecserver"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/bufbuild/buf/private/pkg/transport/http/httpserver"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	bindFlagName            = "bind"
+	portFlagName            = "port"
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	excludeImportsFlagName  = "exclude-imports"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <input>",
+		Short: "Run an HTTP server that serves the packages and types of an input as JSON",
+		Long: `The input is built once at startup, and the server serves the following endpoints, which only accept GET requests:
+
+    /v1/packages                        All packages, with their files and number of types.
+    /v1/packages/{package}              The messages, enums, and services of a package.
+    /v1/types/{name}                    The message, enum, or service with the fully-qualified name.
+    /v1/search?q={query}&limit={limit}  The messages, enums, and services whose fully-qualified name
+                                        contains the query, ignoring case. The limit defaults to ` + fmt.Sprint(bufspecserver.DefaultSearchLimit) + `.
+
+Examples:
+
+Serve the current directory and look up a message.
+
+    $ buf beta ` + name + ` &
+    $ curl http://127.0.0.1:8080/v1/types/acme.user.v1.User
+
+` + bufcli.GetInputLong(`the input to serve`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	BindAddress     string
+	Port            string
+	ErrorFormat     string
+	Config          string
+	ExcludeImports  bool
+	DisableSymlinks bool
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindExcludeImports(flagSet, &f.ExcludeImports, excludeImportsFlagName)
+	flagSet.StringVar(
+		&f.BindAddress,
+		bindFlagName,
+		"127.0.0.1",
+		"The address to be exposed to accept HTTP requests",
+	)
+	flagSet.StringVar(
+		&f.Port,
+		portFlagName,
+		"8080",
+		"The port to be exposed to accept HTTP requests",
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
+	if err != nil {
+		return err
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		bufcli.NewStorageosProvider(flags.DisableSymlinks),
+		command.NewRunner(),
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		flags.Config,
+		nil,
+		nil,
+		false,
+		false, // source info is kept to serve comments
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		if err := bufanalysis.PrintFileAnnotations(
+			container.Stderr(),
+			fileAnnotations,
+			flags.ErrorFormat,
+		); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	images := make([]bufimage.Image, 0, len(imageConfigs))
+	for _, imageConfig := range imageConfigs {
+		images = append(images, imageConfig.Image())
+	}
+	image, err := bufimage.MergeImages(images...)
+	if err != nil {
+		return err
+	}
+	var handlerOptions []bufspecserver.HandlerOption
+	if flags.ExcludeImports {
+		handlerOptions = append(handlerOptions, bufspecserver.HandlerWithExcludeImports())
+	}
+	handler, err := bufspecserver.NewHandler(container.Logger(), image, handlerOptions...)
+	if err != nil {
+		return err
+	}
+	var httpListenConfig net.ListenConfig
+	httpListener, err := httpListenConfig.Listen(ctx, "tcp", fmt.Sprintf("%s:%s", flags.BindAddress, flags.Port))
+	if err != nil {
+		return err
+	}
+	container.Logger().Info("listening", zap.String("address", httpListener.Addr().String()))
+	return httpserver.Run(
+		ctx,
+		container.Logger(),
+		httpListener,
+		handler,
+	)
+}
@@ -19,7 +19,6 @@ import (
 	"fmt"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
-	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/buf/bufprint"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulestat"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
@@ -92,7 +91,11 @@ func run(
 	if err != nil {
 		return err
 	}
-	sourceOrModuleRef, err := buffetch.NewRefParser(container.Logger()).GetSourceOrModuleRef(ctx, input)
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, input)
 	if err != nil {
 		return err
 	}
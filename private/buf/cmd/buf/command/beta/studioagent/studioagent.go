@@ -19,30 +19,40 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"os"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufstudioagent"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/cert/certclient"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
 	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/buf/private/pkg/transport/http/httpserver"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
 )
 
 const (
-	bindFlagName              = "bind"
-	portFlagName              = "port"
-	originFlagName            = "origin"
-	disallowedHeadersFlagName = "disallowed-header"
-	forwardHeadersFlagName    = "forward-header"
-	caCertFlagName            = "ca-cert"
-	clientCertFlagName        = "client-cert"
-	clientKeyFlagName         = "client-key"
-	serverCertFlagName        = "server-cert"
-	serverKeyFlagName         = "server-key"
-	privateNetworkFlagName    = "private-network"
+	bindFlagName               = "bind"
+	portFlagName               = "port"
+	originFlagName             = "origin"
+	disallowedHeadersFlagName  = "disallowed-header"
+	forwardHeadersFlagName     = "forward-header"
+	caCertFlagName             = "ca-cert"
+	clientCertFlagName         = "client-cert"
+	clientKeyFlagName          = "client-key"
+	serverCertFlagName         = "server-cert"
+	serverKeyFlagName          = "server-key"
+	privateNetworkFlagName     = "private-network"
+	recordFlagName             = "record"
+	recordSchemaFlagName       = "record-schema"
+	recordRedactFieldFlagName  = "record-redact-field"
+	recordRedactHeaderFlagName = "record-redact-header"
 )
 
 // NewCommand returns a new Command.
@@ -66,17 +76,21 @@ func NewCommand(
 }
 
 type flags struct {
-	BindAddress       string
-	Port              string
-	Origin            string
-	DisallowedHeaders []string
-	ForwardHeaders    map[string]string
-	CACert            string
-	ClientCert        string
-	ClientKey         string
-	ServerCert        string
-	ServerKey         string
-	PrivateNetwork    bool
+	BindAddress        string
+	Port               string
+	Origin             string
+	DisallowedHeaders  []string
+	ForwardHeaders     map[string]string
+	CACert             string
+	ClientCert         string
+	ClientKey          string
+	ServerCert         string
+	ServerKey          string
+	PrivateNetwork     bool
+	Record             string
+	RecordSchema       string
+	RecordRedactField  []string
+	RecordRedactHeader []string
 }
 
 func newFlags() *flags {
@@ -150,13 +164,52 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		false,
 		`Use the agent with private network CORS`,
 	)
+	flagSet.StringVar(
+		&f.Record,
+		recordFlagName,
+		"",
+		`The path of a session file to record forwarded requests and responses to. Each line of the session file is a JSON object describing one request, its response, and the buf curl invocation that replays the request`,
+	)
+	flagSet.StringVar(
+		&f.RecordSchema,
+		recordSchemaFlagName,
+		"",
+		fmt.Sprintf(
+			`The input to use as the schema of recorded requests and responses. If the method of a request is found in the schema, its request and response types are recorded and the messages are recorded as JSON. Requires --%s`,
+			recordFlagName,
+		),
+	)
+	flagSet.StringSliceVar(
+		&f.RecordRedactField,
+		recordRedactFieldFlagName,
+		nil,
+		fmt.Sprintf(
+			`The fully-qualified names of fields to clear from recorded messages, such as "acme.user.v1.LoginRequest.password". Messages that cannot be decoded with the --%s are not recorded if any fields are redacted. Multiple fields are appended if specified multiple times`,
+			recordSchemaFlagName,
+		),
+	)
+	flagSet.StringSliceVar(
+		&f.RecordRedactHeader,
+		recordRedactHeaderFlagName,
+		nil,
+		`The header names whose values are replaced in recorded requests and responses, in addition to the Authorization, Cookie, Proxy-Authorization, and Set-Cookie headers, which are always replaced. Multiple headers are appended if specified multiple times`,
+	)
 }
 
 func run(
 	ctx context.Context,
 	container appflag.Container,
 	flags *flags,
-) error {
+) (retErr error) {
+	if flags.Record == "" && (flags.RecordSchema != "" || len(flags.RecordRedactField) > 0 || len(flags.RecordRedactHeader) > 0) {
+		return appcmd.NewInvalidArgumentErrorf(
+			"--%s is required if --%s, --%s, or --%s is set",
+			recordFlagName,
+			recordSchemaFlagName,
+			recordRedactFieldFlagName,
+			recordRedactHeaderFlagName,
+		)
+	}
 	// CA cert pool is optional. If it is nil, TLS uses the host's root CA set.
 	var rootCAConfig *tls.Config
 	var err error
@@ -182,6 +235,33 @@ func run(
 			return fmt.Errorf("cannot create new server TLS config: %w", err)
 		}
 	}
+	var handlerOptions []bufstudioagent.HandlerOption
+	if flags.Record != "" {
+		recorderOptions := []bufstudioagent.RecorderOption{
+			bufstudioagent.RecorderWithRedactedFields(flags.RecordRedactField...),
+			bufstudioagent.RecorderWithRedactedHeaders(flags.RecordRedactHeader...),
+		}
+		if flags.RecordSchema != "" {
+			resolver, err := getResolver(ctx, container, flags.RecordSchema)
+			if err != nil {
+				return err
+			}
+			recorderOptions = append(recorderOptions, bufstudioagent.RecorderWithSchema(flags.RecordSchema, resolver))
+		}
+		sessionFile, err := os.OpenFile(flags.Record, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = multierr.Append(retErr, sessionFile.Close())
+		}()
+		handlerOptions = append(
+			handlerOptions,
+			bufstudioagent.HandlerWithRecorder(
+				bufstudioagent.NewRecorder(sessionFile, recorderOptions...),
+			),
+		)
+	}
 	mux := bufstudioagent.NewHandler(
 		container.Logger(),
 		flags.Origin,
@@ -189,6 +269,7 @@ func run(
 		slicesext.ToStructMap(flags.DisallowedHeaders),
 		flags.ForwardHeaders,
 		flags.PrivateNetwork,
+		handlerOptions...,
 	)
 	var httpListenConfig net.ListenConfig
 	httpListener, err := httpListenConfig.Listen(ctx, "tcp", fmt.Sprintf("%s:%s", flags.BindAddress, flags.Port))
@@ -207,6 +288,62 @@ func run(
 	)
 }
 
+func getResolver(
+	ctx context.Context,
+	container appflag.Container,
+	schema string,
+) (protoencoding.Resolver, error) {
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refParser.GetRef(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		bufcli.NewStorageosProvider(false),
+		command.NewRunner(),
+		clientConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		"",
+		nil,
+		nil,
+		false, // input files must exist
+		true,  // source info is not needed to decode messages
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileAnnotations) > 0 {
+		if err := bufanalysis.PrintFileAnnotations(container.Stderr(), fileAnnotations, bufanalysis.FormatText.String()); err != nil {
+			return nil, err
+		}
+		return nil, bufcli.ErrFileAnnotation
+	}
+	images := make([]bufimage.Image, 0, len(imageConfigs))
+	for _, imageConfig := range imageConfigs {
+		images = append(images, imageConfig.Image())
+	}
+	image, err := bufimage.MergeImages(images...)
+	if err != nil {
+		return nil, err
+	}
+	return protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+}
+
 func newTLSConfig(baseConfig *tls.Config, certFile, keyFile string) (*tls.Config, error) {
 	config := baseConfig.Clone()
 	if config == nil {
@@ -0,0 +1,503 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sunsetreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/protosource"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	errorFormatFlagName     = "error-format"
+	configFlagName          = "config"
+	disableSymlinksFlagName = "disable-symlinks"
+	consumerFlagName        = "consumer"
+	formatFlagName          = "format"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <source>",
+		Short: "List the sunset dates of deprecated elements of a source",
+		Long: `The sunset dates are read from the lint.sunset_dates key of the buf.yaml of each module, which maps the
+fully-qualified names of deprecated messages, fields, enums, enum values, services, and methods to the dates,
+in the form YYYY-MM-DD, after which they will be removed. Sunsets are listed by date, including those that have
+already passed; use the SUNSET_NOT_PASSED lint rule to fail once an element is past its sunset date.
+
+Each --consumer input, such as a module on the BSR that depends on the source, is built and checked for
+fields and RPCs that use a message or enum with a sunset date.
+
+` + bufcli.GetSourceLong(`the source to list sunsets for`),
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	ErrorFormat     string
+	Config          string
+	DisableSymlinks bool
+	Consumers       []string
+	Format          string
+	// special
+	InputHashtag string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+	flagSet.StringVar(
+		&f.Config,
+		configFlagName,
+		"",
+		`The buf.yaml file or data to use for configuration`,
+	)
+	flagSet.StringSliceVar(
+		&f.Consumers,
+		consumerFlagName,
+		nil,
+		`An input that consumes the source, such as a module on the BSR, to check for uses of elements with a sunset date. May be provided multiple times`,
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+type sunset struct {
+	Name          string               `json:"name"`
+	Type          string               `json:"type"`
+	Path          string               `json:"path"`
+	Line          int                  `json:"line,omitempty"`
+	Date          string               `json:"date"`
+	DaysRemaining int                  `json:"days_remaining"`
+	Deprecated    bool                 `json:"deprecated"`
+	References    []*consumerReference `json:"references,omitempty"`
+}
+
+type consumerReference struct {
+	Consumer string `json:"consumer"`
+	Path     string `json:"path"`
+	Line     int    `json:"line,omitempty"`
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
+	if err != nil {
+		return err
+	}
+	sourceRefParser, err := bufcli.NewSourceRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceRef, err := sourceRefParser.GetSourceRef(ctx, input)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
+	runner := command.NewRunner()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(
+		container,
+		storageosProvider,
+		runner,
+		clientConfig,
+	)
+	if err != nil {
+		return err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		sourceRef,
+		flags.Config,
+		nil,
+		nil,
+		false,
+		false,
+	)
+	if err != nil {
+		return err
+	}
+	if len(fileAnnotations) > 0 {
+		// stderr since we output to stdout
+		if err := bufanalysis.PrintFileAnnotations(
+			container.Stderr(),
+			fileAnnotations,
+			flags.ErrorFormat,
+		); err != nil {
+			return err
+		}
+		return bufcli.ErrFileAnnotation
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	// full name -> sunset
+	fullNameToSunset := make(map[string]*sunset)
+	for _, imageConfig := range imageConfigs {
+		lintConfig := imageConfig.Config().Lint
+		if lintConfig == nil || len(lintConfig.SunsetDates) == 0 {
+			continue
+		}
+		sunsetDates, err := buflintconfig.ParseSunsetDates(lintConfig.SunsetDates)
+		if err != nil {
+			return err
+		}
+		moduleSunsets, err := getSunsets(ctx, imageConfig.Image(), sunsetDates, today)
+		if err != nil {
+			return err
+		}
+		for _, moduleSunset := range moduleSunsets {
+			if !moduleSunset.Deprecated {
+				container.Logger().Warn(fmt.Sprintf("%q is in sunset_dates but is not deprecated", moduleSunset.Name))
+			}
+			fullNameToSunset[moduleSunset.Name] = moduleSunset
+		}
+		for fullName := range sunsetDates {
+			if _, ok := fullNameToSunset[fullName]; !ok {
+				container.Logger().Warn(fmt.Sprintf("%q is in sunset_dates but was not found", fullName))
+			}
+		}
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	for _, consumer := range flags.Consumers {
+		consumerRef, err := refParser.GetRef(ctx, consumer)
+		if err != nil {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %v", consumerFlagName, err)
+		}
+		consumerImageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+			ctx,
+			container,
+			consumerRef,
+			"",
+			nil,
+			nil,
+			false,
+			false,
+		)
+		if err != nil {
+			return err
+		}
+		if len(fileAnnotations) > 0 {
+			if err := bufanalysis.PrintFileAnnotations(
+				container.Stderr(),
+				fileAnnotations,
+				flags.ErrorFormat,
+			); err != nil {
+				return err
+			}
+			return bufcli.ErrFileAnnotation
+		}
+		for _, consumerImageConfig := range consumerImageConfigs {
+			if err := addConsumerReferences(ctx, consumer, consumerImageConfig.Image(), fullNameToSunset); err != nil {
+				return err
+			}
+		}
+	}
+	sunsets := make([]*sunset, 0, len(fullNameToSunset))
+	for _, sunset := range fullNameToSunset {
+		sunsets = append(sunsets, sunset)
+	}
+	sort.Slice(
+		sunsets,
+		func(i int, j int) bool {
+			if sunsets[i].Date != sunsets[j].Date {
+				return sunsets[i].Date < sunsets[j].Date
+			}
+			return sunsets[i].Name < sunsets[j].Name
+		},
+	)
+	return printSunsets(container, format, sunsets)
+}
+
+// getSunsets returns the sunsets of the elements of the non-import files of the image
+// that have a sunset date.
+func getSunsets(
+	ctx context.Context,
+	image bufimage.Image,
+	sunsetDates map[string]time.Time,
+	today time.Time,
+) ([]*sunset, error) {
+	files, err := protosource.NewFilesUnstable(ctx, bufimageutil.NewInputFiles(image.Files())...)
+	if err != nil {
+		return nil, err
+	}
+	var sunsets []*sunset
+	for _, file := range files {
+		if file.IsImport() {
+			continue
+		}
+		if err := protosource.ForEachDeprecatableDescriptor(
+			func(deprecatableDescriptor protosource.DeprecatableDescriptor) error {
+				fullName := deprecatableDescriptor.FullName()
+				sunsetDate, ok := sunsetDates[fullName]
+				if !ok {
+					return nil
+				}
+				sunsets = append(
+					sunsets,
+					&sunset{
+						Name:          fullName,
+						Type:          descriptorType(deprecatableDescriptor),
+						Path:          file.ExternalPath(),
+						Line:          startLine(deprecatableDescriptor.NameLocation()),
+						Date:          sunsetDate.Format(buflintconfig.SunsetDateLayout),
+						DaysRemaining: daysRemaining(sunsetDate, today),
+						Deprecated:    deprecatableDescriptor.Deprecated(),
+					},
+				)
+				return nil
+			},
+			file,
+		); err != nil {
+			return nil, err
+		}
+	}
+	return sunsets, nil
+}
+
+// addConsumerReferences adds the fields and RPCs of the non-import files of the consumer
+// image that use a message or enum with a sunset date to the references of the sunset.
+func addConsumerReferences(
+	ctx context.Context,
+	consumer string,
+	image bufimage.Image,
+	fullNameToSunset map[string]*sunset,
+) error {
+	files, err := protosource.NewFilesUnstable(ctx, bufimageutil.NewInputFiles(image.Files())...)
+	if err != nil {
+		return err
+	}
+	addReference := func(file protosource.File, typeName string, location protosource.Location) {
+		sunset, ok := fullNameToSunset[strings.TrimPrefix(typeName, ".")]
+		if !ok {
+			return
+		}
+		sunset.References = append(
+			sunset.References,
+			&consumerReference{
+				Consumer: consumer,
+				Path:     file.ExternalPath(),
+				Line:     startLine(location),
+			},
+		)
+	}
+	for _, file := range files {
+		if file.IsImport() {
+			continue
+		}
+		if err := protosource.ForEachDeprecatableDescriptor(
+			func(deprecatableDescriptor protosource.DeprecatableDescriptor) error {
+				switch t := deprecatableDescriptor.(type) {
+				case protosource.Field:
+					addReference(file, t.TypeName(), t.TypeNameLocation())
+				case protosource.Method:
+					addReference(file, t.InputTypeName(), t.InputTypeLocation())
+					addReference(file, t.OutputTypeName(), t.OutputTypeLocation())
+				}
+				return nil
+			},
+			file,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printSunsets(container appflag.Container, format bufprint.Format, sunsets []*sunset) error {
+	switch format {
+	case bufprint.FormatText:
+		if err := bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Date",
+				"Days Remaining",
+				"Name",
+				"Type",
+				"Location",
+				"Deprecated",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, sunset := range sunsets {
+					if err := tabWriter.Write(
+						sunset.Date,
+						strconv.Itoa(sunset.DaysRemaining),
+						sunset.Name,
+						sunset.Type,
+						location(sunset.Path, sunset.Line),
+						strconv.FormatBool(sunset.Deprecated),
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		); err != nil {
+			return err
+		}
+		var hasReferences bool
+		for _, sunset := range sunsets {
+			if len(sunset.References) > 0 {
+				hasReferences = true
+				break
+			}
+		}
+		if !hasReferences {
+			return nil
+		}
+		if _, err := fmt.Fprintln(container.Stdout()); err != nil {
+			return err
+		}
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Consumer",
+				"Location",
+				"Uses",
+				"Date",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, sunset := range sunsets {
+					for _, reference := range sunset.References {
+						if err := tabWriter.Write(
+							reference.Consumer,
+							location(reference.Path, reference.Line),
+							sunset.Name,
+							sunset.Date,
+						); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		encoder := json.NewEncoder(container.Stdout())
+		for _, sunset := range sunsets {
+			if err := encoder.Encode(sunset); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func descriptorType(deprecatableDescriptor protosource.DeprecatableDescriptor) string {
+	switch t := deprecatableDescriptor.(type) {
+	case protosource.Message:
+		return "message"
+	case protosource.Field:
+		if t.Extendee() != "" {
+			return "extension"
+		}
+		return "field"
+	case protosource.Enum:
+		return "enum"
+	case protosource.EnumValue:
+		return "enum_value"
+	case protosource.Service:
+		return "service"
+	case protosource.Method:
+		return "method"
+	default:
+		return ""
+	}
+}
+
+// daysRemaining returns the number of days from today until the sunset date.
+//
+// This does not use time.Time.Sub, as a time.Duration saturates after about 292 years.
+func daysRemaining(sunsetDate time.Time, today time.Time) int {
+	return int((sunsetDate.Unix() - today.Unix()) / (24 * 60 * 60))
+}
+
+func startLine(location protosource.Location) int {
+	if location == nil {
+		return 0
+	}
+	return location.StartLine()
+}
+
+func location(path string, line int) string {
+	if line == 0 {
+		return path
+	}
+	return path + ":" + strconv.Itoa(line)
+}
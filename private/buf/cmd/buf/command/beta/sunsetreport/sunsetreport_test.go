@@ -0,0 +1,131 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sunsetreport
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/buf/private/buf/cmd/buf/internal/internaltesting"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd/appcmdtesting"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSunsetReport(t *testing.T) {
+	t.Parallel()
+	passedDays, futureDays := testDaysRemaining(t)
+	testRunStdout(
+		t,
+		filepath.FromSlash(fmt.Sprintf(`
+		Date        Days Remaining  Name                             Type     Location                                          Deprecated
+		2000-01-01  %-14s  acme.weather.v1.Forecast         message  testdata/source/acme/weather/v1/weather.proto:5   true
+		2999-12-31  %-14s  acme.weather.v1.Forecast.temp_c  field    testdata/source/acme/weather/v1/weather.proto:7   false
+		2999-12-31  %-14s  acme.weather.v1.Unit             enum     testdata/source/acme/weather/v1/weather.proto:10  true
+		`,
+			passedDays,
+			futureDays,
+			futureDays,
+		)),
+		filepath.Join("testdata", "source"),
+	)
+}
+
+func TestSunsetReportConsumer(t *testing.T) {
+	t.Parallel()
+	passedDays, futureDays := testDaysRemaining(t)
+	testRunStdout(
+		t,
+		filepath.FromSlash(fmt.Sprintf(`
+		Date        Days Remaining  Name                             Type     Location                                          Deprecated
+		2000-01-01  %-14s  acme.weather.v1.Forecast         message  testdata/source/acme/weather/v1/weather.proto:5   true
+		2999-12-31  %-14s  acme.weather.v1.Forecast.temp_c  field    testdata/source/acme/weather/v1/weather.proto:7   false
+		2999-12-31  %-14s  acme.weather.v1.Unit             enum     testdata/source/acme/weather/v1/weather.proto:10  true
+
+		Consumer           Location                                    Uses                      Date
+		testdata/consumer  testdata/consumer/acme/app/v1/app.proto:8   acme.weather.v1.Forecast  2000-01-01
+		testdata/consumer  testdata/consumer/acme/app/v1/app.proto:13  acme.weather.v1.Forecast  2000-01-01
+		testdata/consumer  testdata/consumer/acme/app/v1/app.proto:9   acme.weather.v1.Unit      2999-12-31
+		`,
+			passedDays,
+			futureDays,
+			futureDays,
+		)),
+		filepath.Join("testdata", "source"),
+		"--consumer",
+		filepath.Join("testdata", "consumer"),
+	)
+}
+
+func TestSunsetReportConsumerJSON(t *testing.T) {
+	t.Parallel()
+	passedDays, futureDays := testDaysRemaining(t)
+	testRunStdout(
+		t,
+		fmt.Sprintf(`
+		{"name":"acme.weather.v1.Forecast","type":"message","path":"testdata/source/acme/weather/v1/weather.proto","line":5,"date":"2000-01-01","days_remaining":%s,"deprecated":true,"references":[{"consumer":"testdata/consumer","path":"testdata/consumer/acme/app/v1/app.proto","line":8},{"consumer":"testdata/consumer","path":"testdata/consumer/acme/app/v1/app.proto","line":13}]}
+		{"name":"acme.weather.v1.Forecast.temp_c","type":"field","path":"testdata/source/acme/weather/v1/weather.proto","line":7,"date":"2999-12-31","days_remaining":%s,"deprecated":false}
+		{"name":"acme.weather.v1.Unit","type":"enum","path":"testdata/source/acme/weather/v1/weather.proto","line":10,"date":"2999-12-31","days_remaining":%s,"deprecated":true,"references":[{"consumer":"testdata/consumer","path":"testdata/consumer/acme/app/v1/app.proto","line":9}]}
+		`,
+			passedDays,
+			futureDays,
+			futureDays,
+		),
+		filepath.Join("testdata", "source"),
+		"--consumer",
+		filepath.Join("testdata", "consumer"),
+		"--format",
+		"json",
+	)
+}
+
+func TestDaysRemaining(t *testing.T) {
+	t.Parallel()
+	today := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 0, daysRemaining(today, today))
+	assert.Equal(t, 31, daysRemaining(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), today))
+	assert.Equal(t, -29, daysRemaining(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), today))
+	// Past the range of a time.Duration.
+	assert.Equal(t, 356476, daysRemaining(time.Date(3000, 3, 1, 0, 0, 0, 0, time.UTC), today))
+}
+
+// testDaysRemaining returns the days remaining until the passed and future sunset
+// dates of the testdata.
+func testDaysRemaining(t *testing.T) (string, string) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return strconv.Itoa(daysRemaining(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), today)),
+		strconv.Itoa(daysRemaining(time.Date(2999, 12, 31, 0, 0, 0, 0, time.UTC), today))
+}
+
+func testRunStdout(t *testing.T, expectedStdout string, args ...string) {
+	appcmdtesting.RunCommandExitCodeStdout(
+		t,
+		func(name string) *appcmd.Command {
+			return NewCommand(
+				name,
+				appflag.NewBuilder(name),
+			)
+		},
+		0,
+		expectedStdout,
+		internaltesting.NewEnvFunc(t),
+		nil,
+		args...,
+	)
+}
@@ -0,0 +1,184 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetryreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/buf/buftelemetry"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatFlagName = "format"
+	sinceFlagName  = "since"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Print a report of locally recorded usage",
+		Long: `Print the number of invocations, failures, durations, and module cache hit rates of each command,
+as recorded on this machine.
+
+Usage is only recorded if telemetry is enabled in the user configuration file, for example
+$HOME/.config/buf/config.yaml on Linux:
+
+    telemetry:
+      enabled: true
+
+Usage is recorded within the cache directory, and is never sent over the network unless an
+endpoint is also configured:
+
+    telemetry:
+      enabled: true
+      endpoint: https://telemetry.example.com/buf
+
+If an endpoint is configured, each invocation is sent as JSON in the body of a POST request to it.`,
+		Args: cobra.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+	Since  time.Duration
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.DurationVar(
+		&f.Since,
+		sinceFlagName,
+		0,
+		`Only include invocations within this duration, such as 24h. Zero includes all invocations`,
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	if flags.Since < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s must not be negative", sinceFlagName)
+	}
+	config, err := bufcli.NewConfig(container)
+	if err != nil {
+		return err
+	}
+	if !config.TelemetryEnabled {
+		container.Logger().Warn("Telemetry is not enabled, set telemetry.enabled in your configuration file to record usage.")
+	}
+	events, err := buftelemetry.ReadEventsFile(bufcli.TelemetryEventsFilePath(container))
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if flags.Since > 0 {
+		since = time.Now().Add(-flags.Since)
+	}
+	return printReport(container, format, buftelemetry.NewReport(events, since))
+}
+
+func printReport(container appflag.Container, format bufprint.Format, report *buftelemetry.Report) error {
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Command",
+				"Count",
+				"Failures",
+				"Mean",
+				"Max",
+				"Total",
+				"Cache Hit Rate",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, commandReport := range report.Commands {
+					if err := tabWriter.Write(
+						commandReport.Command,
+						strconv.Itoa(commandReport.Count),
+						strconv.Itoa(commandReport.Failures),
+						formatDuration(commandReport.MeanDuration),
+						formatDuration(commandReport.MaxDuration),
+						formatDuration(commandReport.TotalDuration),
+						formatCacheHitRate(commandReport.CacheHitRate()),
+					); err != nil {
+						return err
+					}
+				}
+				return tabWriter.Write(
+					"total",
+					strconv.Itoa(report.Count),
+					strconv.Itoa(report.Failures),
+					"",
+					"",
+					"",
+					formatCacheHitRate(report.CacheHitRate()),
+				)
+			},
+		)
+	case bufprint.FormatJSON:
+		return json.NewEncoder(container.Stdout()).Encode(report)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func formatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
+
+func formatCacheHitRate(cacheHitRate float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", cacheHitRate*100)
+}
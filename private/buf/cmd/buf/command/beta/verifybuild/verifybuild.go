@@ -0,0 +1,484 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verifybuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	"github.com/bufbuild/buf/private/buf/bufbisect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/git"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagegit"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/zap"
+)
+
+const (
+	revFlagName         = "rev"
+	bisectFromFlagName  = "bisect-from"
+	subDirFlagName      = "subdir"
+	checkFlagName       = "check"
+	errorFormatFlagName = "error-format"
+
+	checkLint     = "lint"
+	checkBreaking = "breaking"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name,
+		Short: "Verify that a module builds at a git commit, or find the commit at which it stopped building",
+		Long: `This command must be run from the root of a git repository.
+
+The module in the --subdir directory is built as of the --rev commit, and optionally linted with
+--check lint. If the module does not build or has lint failures, the failures are printed and the
+command exits with a non-zero exit code.
+
+If --bisect-from is set, the commits on the first-parent history from --bisect-from, which must
+build and pass all checks, to --rev, which must not, are bisected to find the first commit at
+which the module stopped building or a check started failing. With --check breaking, each commit
+is also checked for breaking changes against --bisect-from. Commits at which the module has no
+configuration file are skipped.
+
+Commits are read directly from the git object database, so the working tree is not modified.`,
+		Args: cobra.NoArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Rev         string
+	BisectFrom  string
+	SubDir      string
+	Checks      []string
+	ErrorFormat string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Rev,
+		revFlagName,
+		"HEAD",
+		"The git revision to verify. When bisecting, the module must fail to build or fail a check at this revision",
+	)
+	flagSet.StringVar(
+		&f.BisectFrom,
+		bisectFromFlagName,
+		"",
+		"The git revision to bisect from. The module must build and pass all checks at this revision",
+	)
+	flagSet.StringVar(
+		&f.SubDir,
+		subDirFlagName,
+		".",
+		"The directory of the module relative to the root of the repository",
+	)
+	flagSet.StringSliceVar(
+		&f.Checks,
+		checkFlagName,
+		nil,
+		fmt.Sprintf(
+			"The checks to run in addition to building. Must be one of %s. May be provided multiple times",
+			stringutil.SliceToString([]string{checkLint, checkBreaking}),
+		),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors and check failures printed to stdout. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	var lint, breaking bool
+	for _, check := range flags.Checks {
+		switch check {
+		case checkLint:
+			lint = true
+		case checkBreaking:
+			breaking = true
+		default:
+			return appcmd.NewInvalidArgumentErrorf("--%s: unknown check %q", checkFlagName, check)
+		}
+	}
+	if breaking && flags.BisectFrom == "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s %s requires --%s", checkFlagName, checkBreaking, bisectFromFlagName)
+	}
+	subDirPath, err := normalpath.NormalizeAndValidate(flags.SubDir)
+	if err != nil {
+		return appcmd.NewInvalidArgumentErrorf("--%s: %v", subDirFlagName, err)
+	}
+	runner := command.NewRunner()
+	// Assume that this command is run from the repository root. If not, `OpenRepository` will return
+	// a dir not found error. The default branch is never used as commits are always walked from a hash,
+	// but is set so that repositories that have not been pushed can be opened.
+	repo, err := git.OpenRepository(ctx, git.DotGitDir, runner, git.OpenRepositoryWithDefaultBranch("HEAD"))
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+	defer repo.Close()
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	moduleReader, err := bufcli.NewModuleReaderAndCreateCacheDirs(container, clientConfig)
+	if err != nil {
+		return err
+	}
+	verifier := &verifier{
+		logger: container.Logger(),
+		storageGitProvider: storagegit.NewProvider(
+			repo.Objects(),
+			storagegit.ProviderWithSymlinks(),
+		),
+		imageBuilder: bufimagebuild.NewBuilder(container.Logger(), moduleReader),
+		subDirPath:   subDirPath,
+		lint:         lint,
+	}
+	revHash, err := resolveRev(ctx, container, runner, flags.Rev)
+	if err != nil {
+		return err
+	}
+	if flags.BisectFrom == "" {
+		commit, err := repo.Objects().Commit(revHash)
+		if err != nil {
+			return err
+		}
+		verification, err := verifier.verify(ctx, commit)
+		if err != nil {
+			return err
+		}
+		switch verification.status {
+		case bufbisect.StatusSkip:
+			return fmt.Errorf("no module found in %q at %s", subDirPath, revHash.Hex())
+		case bufbisect.StatusBad:
+			if err := verification.print(container.Stdout(), flags.ErrorFormat); err != nil {
+				return err
+			}
+			return bufcli.ErrFileAnnotation
+		}
+		return nil
+	}
+	bisectFromHash, err := resolveRev(ctx, container, runner, flags.BisectFrom)
+	if err != nil {
+		return err
+	}
+	commits, err := getCommitsBetween(repo, bisectFromHash, revHash)
+	if err != nil {
+		return err
+	}
+	return bisect(ctx, container, repo, verifier, breaking, bisectFromHash, commits, flags.ErrorFormat)
+}
+
+func bisect(
+	ctx context.Context,
+	container appflag.Container,
+	repo git.Repository,
+	verifier *verifier,
+	breaking bool,
+	bisectFromHash git.Hash,
+	commits []git.Commit,
+	errorFormat string,
+) error {
+	bisectFromCommit, err := repo.Objects().Commit(bisectFromHash)
+	if err != nil {
+		return err
+	}
+	bisectFromVerification, err := verifier.verify(ctx, bisectFromCommit)
+	if err != nil {
+		return err
+	}
+	if bisectFromVerification.status != bufbisect.StatusGood {
+		if bisectFromVerification.status == bufbisect.StatusBad {
+			if err := bisectFromVerification.print(container.Stderr(), errorFormat); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("--%s: the module must build and pass all checks at %s", bisectFromFlagName, bisectFromHash.Hex())
+	}
+	if breaking {
+		verifier.againstImage = bisectFromVerification.image
+	}
+	lastVerification, err := verifier.verify(ctx, commits[len(commits)-1])
+	if err != nil {
+		return err
+	}
+	if lastVerification.status != bufbisect.StatusBad {
+		return fmt.Errorf("--%s: the module must fail to build or fail a check at %s", revFlagName, commits[len(commits)-1].Hash().Hex())
+	}
+	if _, err := fmt.Fprintf(
+		container.Stderr(),
+		"Bisecting %d commits, roughly %d steps.\n",
+		len(commits),
+		bits.Len(uint(len(commits)-1)),
+	); err != nil {
+		return err
+	}
+	indexToVerification := map[int]*verification{
+		len(commits) - 1: lastVerification,
+	}
+	result, err := bufbisect.Bisect(
+		ctx,
+		len(commits),
+		func(ctx context.Context, index int) (bufbisect.Status, error) {
+			verification, err := verifier.verify(ctx, commits[index])
+			if err != nil {
+				return 0, err
+			}
+			indexToVerification[index] = verification
+			if _, err := fmt.Fprintf(container.Stderr(), "%s: %s\n", commits[index].Hash().Hex(), verification.status); err != nil {
+				return 0, err
+			}
+			return verification.status, nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	stdout := container.Stdout()
+	if len(result.Skipped) > 0 {
+		if _, err := fmt.Fprintln(stdout, "The first bad commit could be any of:"); err != nil {
+			return err
+		}
+		for _, index := range append(result.Skipped, result.FirstBad) {
+			if _, err := fmt.Fprintln(stdout, commits[index].Hash().Hex()); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(stdout); err != nil {
+			return err
+		}
+	}
+	firstBadCommit := commits[result.FirstBad]
+	if _, err := fmt.Fprintf(
+		stdout,
+		"%s is the first bad commit\nAuthor: %s <%s>\nDate:   %s\n\n    %s\n\n",
+		firstBadCommit.Hash().Hex(),
+		firstBadCommit.Author().Name(),
+		firstBadCommit.Author().Email(),
+		firstBadCommit.Author().Timestamp().Format("Mon Jan 2 15:04:05 2006 -0700"),
+		getSubject(firstBadCommit.Message()),
+	); err != nil {
+		return err
+	}
+	if err := indexToVerification[result.FirstBad].print(stdout, errorFormat); err != nil {
+		return err
+	}
+	return bufcli.ErrFileAnnotation
+}
+
+// getCommitsBetween returns the commits on the first-parent history after
+// fromHash up to and including toHash, from oldest to newest.
+func getCommitsBetween(repo git.Repository, fromHash git.Hash, toHash git.Hash) ([]git.Commit, error) {
+	var commits []git.Commit
+	var foundFrom bool
+	if err := repo.ForEachCommit(
+		func(commit git.Commit) error {
+			if commit.Hash().Hex() == fromHash.Hex() {
+				foundFrom = true
+				return git.ErrStopForEach
+			}
+			commits = append(commits, commit)
+			return nil
+		},
+		git.ForEachCommitWithHashStartPoint(toHash.Hex()),
+	); err != nil {
+		return nil, err
+	}
+	if !foundFrom {
+		return nil, fmt.Errorf("--%s: %s is not on the first-parent history of %s", bisectFromFlagName, fromHash.Hex(), toHash.Hex())
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("--%s and --%s are the same commit %s", bisectFromFlagName, revFlagName, toHash.Hex())
+	}
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+func resolveRev(
+	ctx context.Context,
+	container appflag.Container,
+	runner command.Runner,
+	rev string,
+) (git.Hash, error) {
+	output, err := command.RunStdout(
+		ctx,
+		container,
+		runner,
+		"git",
+		"rev-parse",
+		"--verify",
+		"--end-of-options",
+		rev+"^{commit}",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve git revision %q: %w", rev, err)
+	}
+	return git.NewHashFromHex(strings.TrimSpace(string(output)))
+}
+
+func getSubject(message string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return subject
+}
+
+type verifier struct {
+	logger             *zap.Logger
+	storageGitProvider storagegit.Provider
+	imageBuilder       bufimagebuild.Builder
+	subDirPath         string
+	lint               bool
+	// againstImage is the image to check for breaking changes against, if any.
+	againstImage bufimage.Image
+}
+
+// verification is the result of verifying a commit.
+type verification struct {
+	status bufbisect.Status
+	// Only one of failure and fileAnnotations is set if status is bufbisect.StatusBad.
+	failure         error
+	fileAnnotations []bufanalysis.FileAnnotation
+	// image is set if status is bufbisect.StatusGood.
+	image bufimage.Image
+}
+
+// verify builds and checks the module at the commit.
+//
+// Errors that are not specific to the commit, such as failing to download
+// dependencies, are returned as errors.
+func (v *verifier) verify(ctx context.Context, commit git.Commit) (*verification, error) {
+	commitBucket, err := v.storageGitProvider.NewReadBucket(commit.Tree(), storagegit.ReadBucketWithSymlinksIfSupported())
+	if err != nil {
+		return nil, fmt.Errorf("new read bucket: %w", err)
+	}
+	moduleBucket := storage.MapReadBucket(commitBucket, storage.MapOnPrefix(v.subDirPath))
+	foundModule, err := bufconfig.ExistingConfigFilePath(ctx, moduleBucket)
+	if err != nil {
+		return nil, err
+	}
+	if foundModule == "" {
+		return &verification{status: bufbisect.StatusSkip}, nil
+	}
+	config, err := bufconfig.GetConfigForBucket(ctx, moduleBucket)
+	if err != nil {
+		return newBadVerification(fmt.Errorf("invalid configuration: %w", err)), nil
+	}
+	builtModule, err := bufmodulebuild.NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		moduleBucket,
+		config.Build,
+		bufmodulebuild.WithModuleIdentity(config.ModuleIdentity),
+	)
+	if err != nil {
+		return newBadVerification(err), nil
+	}
+	image, fileAnnotations, err := v.imageBuilder.Build(
+		ctx,
+		builtModule,
+		bufimagebuild.WithExpectedDirectDependencies(builtModule.DeclaredDirectDependencies()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileAnnotations) > 0 {
+		return &verification{status: bufbisect.StatusBad, fileAnnotations: fileAnnotations}, nil
+	}
+	if v.lint {
+		fileAnnotations, err := buflint.NewHandler(v.logger).Check(ctx, config.Lint, image)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileAnnotations) > 0 {
+			return &verification{status: bufbisect.StatusBad, fileAnnotations: fileAnnotations}, nil
+		}
+	}
+	if v.againstImage != nil {
+		fileAnnotations, err := bufbreaking.NewHandler(v.logger).Check(ctx, config.Breaking, v.againstImage, image)
+		if err != nil {
+			return nil, err
+		}
+		if len(fileAnnotations) > 0 {
+			return &verification{status: bufbisect.StatusBad, fileAnnotations: fileAnnotations}, nil
+		}
+	}
+	return &verification{status: bufbisect.StatusGood, image: image}, nil
+}
+
+func newBadVerification(failure error) *verification {
+	return &verification{status: bufbisect.StatusBad, failure: failure}
+}
+
+// print prints why the verification failed.
+func (v *verification) print(writer io.Writer, errorFormat string) error {
+	if v.failure != nil {
+		_, err := fmt.Fprintln(writer, v.failure.Error())
+		return err
+	}
+	if len(v.fileAnnotations) == 0 {
+		return errors.New("no failures to print")
+	}
+	return bufanalysis.PrintFileAnnotations(
+		writer,
+		bufanalysis.DeduplicateAndSortFileAnnotations(v.fileAnnotations),
+		errorFormat,
+	)
+}
@@ -0,0 +1,287 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package whoami
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/connectclient"
+	"github.com/bufbuild/buf/private/pkg/netrc"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	formatFlagName = "format"
+
+	registryTimeout = 10 * time.Second
+	pageSize        = 250
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <remote...>",
+		Short: "Print the identity that the credentials for each remote authenticate as",
+		Long: `For each remote, print where its credentials were found, the user they authenticate as,
+the organizations of the user and the role of the user in each, and when the token expires.
+
+The expiry is only printed if the user has a single active token, as the registry does
+not report which of the tokens of a user was used. Token scopes are not reported by the
+registry and are not printed.
+
+Exits with a non-zero exit code if any remote could not be reached or rejected its credentials.
+
+Defaults to ` + bufconnect.DefaultRemote + ` if no remotes are given.`,
+		Args: cobra.ArbitraryArgs,
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+// remoteIdentity is the identity of the credentials for a remote.
+type remoteIdentity struct {
+	Remote        string                `json:"remote"`
+	TokenSource   string                `json:"token_source,omitempty"`
+	Username      string                `json:"username,omitempty"`
+	UserType      string                `json:"user_type,omitempty"`
+	Organizations []*organizationMember `json:"organizations,omitempty"`
+	ExpireTime    *time.Time            `json:"expire_time,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+type organizationMember struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	remotes := slicesext.ToUniqueSorted(app.Args(container))
+	if len(remotes) == 0 {
+		remotes = []string{bufconnect.DefaultRemote}
+	}
+	envTokenProvider, err := bufconnect.NewTokenProviderFromContainer(container)
+	if err != nil {
+		return err
+	}
+	netrcTokenProvider := bufconnect.NewNetrcTokenProvider(container, netrc.GetMachineForName)
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	identities := make([]*remoteIdentity, 0, len(remotes))
+	for _, remote := range remotes {
+		identity := &remoteIdentity{
+			Remote:      remote,
+			TokenSource: getTokenSource(remote, envTokenProvider, netrcTokenProvider),
+		}
+		if err := fillIdentity(ctx, clientConfig, identity); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", remote, err))
+			identity.Error = err.Error()
+			if connect.CodeOf(err) == connect.CodeUnauthenticated {
+				identity.Error = "not authenticated"
+				if identity.TokenSource != "" {
+					identity.Error = fmt.Sprintf("the token from %s was rejected, it may be expired or revoked", identity.TokenSource)
+				}
+			}
+		}
+		identities = append(identities, identity)
+	}
+	if err := printIdentities(container, format, identities); err != nil {
+		return err
+	}
+	// The errors are returned so that their codes determine the exit code.
+	return errors.Join(errs...)
+}
+
+func getTokenSource(remote string, tokenProviders ...bufconnect.TokenProvider) string {
+	for _, tokenProvider := range tokenProviders {
+		if tokenProvider.RemoteToken(remote) == "" {
+			continue
+		}
+		if tokenProvider.IsFromEnvVar() {
+			return "$BUF_TOKEN"
+		}
+		return ".netrc"
+	}
+	return ""
+}
+
+func fillIdentity(
+	ctx context.Context,
+	clientConfig *connectclient.Config,
+	identity *remoteIdentity,
+) error {
+	ctx, cancel := context.WithTimeout(ctx, registryTimeout)
+	defer cancel()
+	authnService := connectclient.Make(clientConfig, identity.Remote, registryv1alpha1connect.NewAuthnServiceClient)
+	currentUserResponse, err := authnService.GetCurrentUser(ctx, connect.NewRequest(&registryv1alpha1.GetCurrentUserRequest{}))
+	if err != nil {
+		return err
+	}
+	user := currentUserResponse.Msg.GetUser()
+	identity.Username = user.GetUsername()
+	identity.UserType = strings.ToLower(strings.TrimPrefix(user.GetUserType().String(), "USER_TYPE_"))
+	organizationService := connectclient.Make(clientConfig, identity.Remote, registryv1alpha1connect.NewOrganizationServiceClient)
+	var pageToken string
+	for {
+		organizationsResponse, err := organizationService.ListUserOrganizations(
+			ctx,
+			connect.NewRequest(
+				&registryv1alpha1.ListUserOrganizationsRequest{
+					UserId:    user.GetId(),
+					PageSize:  pageSize,
+					PageToken: pageToken,
+				},
+			),
+		)
+		if err != nil {
+			return err
+		}
+		for _, membership := range organizationsResponse.Msg.GetOrganizations() {
+			identity.Organizations = append(
+				identity.Organizations,
+				&organizationMember{
+					Name: membership.GetOrganization().GetName(),
+					Role: strings.ToLower(strings.TrimPrefix(membership.GetOrganizationRole().String(), "ORGANIZATION_ROLE_")),
+				},
+			)
+		}
+		pageToken = organizationsResponse.Msg.GetNextPageToken()
+		if pageToken == "" {
+			break
+		}
+	}
+	tokenService := connectclient.Make(clientConfig, identity.Remote, registryv1alpha1connect.NewTokenServiceClient)
+	// Two tokens are enough to tell whether the user has a single active token.
+	tokensResponse, err := tokenService.ListTokens(
+		ctx,
+		connect.NewRequest(
+			&registryv1alpha1.ListTokensRequest{
+				PageSize: 2,
+			},
+		),
+	)
+	if err != nil {
+		return err
+	}
+	if tokens := tokensResponse.Msg.GetTokens(); len(tokens) == 1 && tokens[0].GetExpireTime() != nil {
+		expireTime := tokens[0].GetExpireTime().AsTime()
+		identity.ExpireTime = &expireTime
+	}
+	return nil
+}
+
+func printIdentities(container appflag.Container, format bufprint.Format, identities []*remoteIdentity) error {
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Remote",
+				"Token Source",
+				"Username",
+				"User Type",
+				"Organizations",
+				"Expires",
+				"Error",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, identity := range identities {
+					organizations := make([]string, 0, len(identity.Organizations))
+					for _, organization := range identity.Organizations {
+						organizations = append(organizations, organization.Name+" ("+organization.Role+")")
+					}
+					var expireTime string
+					if identity.ExpireTime != nil {
+						expireTime = identity.ExpireTime.Format(time.RFC3339)
+					}
+					if err := tabWriter.Write(
+						identity.Remote,
+						identity.TokenSource,
+						identity.Username,
+						identity.UserType,
+						strings.Join(organizations, ", "),
+						expireTime,
+						identity.Error,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		encoder := json.NewEncoder(container.Stdout())
+		for _, identity := range identities {
+			if err := encoder.Encode(identity); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
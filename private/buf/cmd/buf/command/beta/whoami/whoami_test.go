@@ -0,0 +1,184 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package whoami
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/cmd/buf/internal/internaltesting"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoami(t *testing.T) {
+	t.Parallel()
+	remote := createServer(t, nil)
+	stdout, err := appRun(t, remote, "--format", "json")
+	require.NoError(t, err)
+	var identity remoteIdentity
+	require.NoError(t, json.Unmarshal(stdout, &identity))
+	assert.Equal(t, remote, identity.Remote)
+	assert.Equal(t, "$BUF_TOKEN", identity.TokenSource)
+	assert.Equal(t, "alice", identity.Username)
+	assert.Equal(t, "personal", identity.UserType)
+	assert.Equal(t, []*organizationMember{{Name: "acme", Role: "admin"}}, identity.Organizations)
+	assert.Empty(t, identity.Error)
+}
+
+func TestWhoamiUnauthenticated(t *testing.T) {
+	t.Parallel()
+	remote := createServer(t, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid token")))
+	stdout, err := appRun(t, remote, "--format", "json")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, bufcli.ErrFileAnnotation)
+	assert.ErrorContains(t, err, "environment variable is set, but is not valid")
+	var identity remoteIdentity
+	require.NoError(t, json.Unmarshal(stdout, &identity))
+	assert.Equal(t, "the token from $BUF_TOKEN was rejected, it may be expired or revoked", identity.Error)
+}
+
+func TestWhoamiUnavailable(t *testing.T) {
+	t.Parallel()
+	remote := createServer(t, connect.NewError(connect.CodeUnavailable, errors.New("down for maintenance")))
+	_, err := appRun(t, remote)
+	require.Error(t, err)
+	// The underlying error is kept, so that the exit code reflects it.
+	assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+}
+
+type mockAuthnService struct {
+	registryv1alpha1connect.UnimplementedAuthnServiceHandler
+
+	err error
+}
+
+func (m *mockAuthnService) GetCurrentUser(
+	context.Context,
+	*connect.Request[registryv1alpha1.GetCurrentUserRequest],
+) (*connect.Response[registryv1alpha1.GetCurrentUserResponse], error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return connect.NewResponse(
+		&registryv1alpha1.GetCurrentUserResponse{
+			User: &registryv1alpha1.User{
+				Id:       "user-id",
+				Username: "alice",
+				UserType: registryv1alpha1.UserType_USER_TYPE_PERSONAL,
+			},
+		},
+	), nil
+}
+
+type mockOrganizationService struct {
+	registryv1alpha1connect.UnimplementedOrganizationServiceHandler
+}
+
+func (m *mockOrganizationService) ListUserOrganizations(
+	context.Context,
+	*connect.Request[registryv1alpha1.ListUserOrganizationsRequest],
+) (*connect.Response[registryv1alpha1.ListUserOrganizationsResponse], error) {
+	return connect.NewResponse(
+		&registryv1alpha1.ListUserOrganizationsResponse{
+			Organizations: []*registryv1alpha1.OrganizationMembership{
+				{
+					Organization:     &registryv1alpha1.Organization{Name: "acme"},
+					OrganizationRole: registryv1alpha1.OrganizationRole_ORGANIZATION_ROLE_ADMIN,
+				},
+			},
+		},
+	), nil
+}
+
+type mockTokenService struct {
+	registryv1alpha1connect.UnimplementedTokenServiceHandler
+}
+
+func (m *mockTokenService) ListTokens(
+	context.Context,
+	*connect.Request[registryv1alpha1.ListTokensRequest],
+) (*connect.Response[registryv1alpha1.ListTokensResponse], error) {
+	return connect.NewResponse(&registryv1alpha1.ListTokensResponse{}), nil
+}
+
+// createServer returns the remote of a server that authenticates the user, or
+// returns authnErr if it is not nil.
+func createServer(t *testing.T, authnErr error) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle(registryv1alpha1connect.NewAuthnServiceHandler(&mockAuthnService{err: authnErr}))
+	mux.Handle(registryv1alpha1connect.NewOrganizationServiceHandler(&mockOrganizationService{}))
+	mux.Handle(registryv1alpha1connect.NewTokenServiceHandler(&mockTokenService{}))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return serverURL.Host
+}
+
+func appRun(t *testing.T, args ...string) ([]byte, error) {
+	const appName = "test"
+	env := internaltesting.NewEnvFunc(t)(appName)
+	env["BUF_TOKEN"] = "token"
+	injectConfig(t, appName, env)
+	stdout := bytes.NewBuffer(nil)
+	err := appcmd.Run(
+		context.Background(),
+		app.NewContainer(
+			env,
+			nil,
+			stdout,
+			os.Stderr,
+			append([]string{appName}, args...)...,
+		),
+		NewCommand(
+			appName,
+			appflag.NewBuilder(appName),
+		),
+	)
+	return stdout.Bytes(), err
+}
+
+// injectConfig writes an app's config.yaml that disables TLS.
+func injectConfig(t *testing.T, appName string, env map[string]string) {
+	configDir := env[strings.ToUpper(appName)+"_CONFIG_DIR"]
+	confFile, err := os.Create(path.Join(configDir, "config.yaml"))
+	require.NoError(t, err)
+	defer confFile.Close()
+	_, err = io.WriteString(confFile, `
+version: v1
+tls:
+  use: false
+`)
+	require.NoError(t, err)
+}
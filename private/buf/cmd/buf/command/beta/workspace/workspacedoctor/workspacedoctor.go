@@ -0,0 +1,213 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspacedoctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufdoctor"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/connectclient"
+	"github.com/bufbuild/buf/private/pkg/netrc"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	remoteFlagName          = "remote"
+	formatFlagName          = "format"
+	disableSymlinksFlagName = "disable-symlinks"
+
+	registryTimeout = 10 * time.Second
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory>",
+		Short: "Diagnose common problems with the environment and a workspace",
+		Long: `Run a series of checks over the environment and the workspace or module in the directory,
+and print what was found along with suggestions for fixing any problems:
+
+    cache:            the cache directory is writable, and no unused legacy cache directories exist
+    module_cache:     cached modules are laid out as expected, and their files match their digests
+    config:           buf.work.yaml and buf.yaml files exist and can be read
+    config_versions:  all modules use the same configuration version
+    duplicate_paths:  no two files of the workspace have the same path relative to their root
+    case_collisions:  no two files of the workspace have paths that differ only in case
+    credentials:      credentials are available for each remote, and are accepted by the remote
+    registry:         each remote can be reached
+
+The remotes checked are those of the module names and dependencies of the workspace,
+and those given by --remote. If there are none, ` + bufconnect.DefaultRemote + ` is checked.
+
+Exits with a non-zero exit code if any check found an error.
+
+The directory should contain a buf.work.yaml or buf.yaml, and defaults to the current directory.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Remotes         []string
+	Format          string
+	DisableSymlinks bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.StringSliceVar(
+		&f.Remotes,
+		remoteFlagName,
+		nil,
+		"A remote to check in addition to those referenced by the workspace. May be provided multiple times",
+	)
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	dirPath := "."
+	if container.NumArgs() > 0 {
+		dirPath = container.Arg(0)
+	}
+	readWriteBucket, err := bufcli.NewStorageosProvider(flags.DisableSymlinks).NewReadWriteBucket(
+		dirPath,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return err
+	}
+	findings := bufdoctor.DiagnoseCache(container.CacheDirPath(), bufcli.LegacyCacheModuleRelDirPaths)
+	findings = append(findings, bufdoctor.DiagnoseModuleCache(ctx, bufcli.ModuleCacheDirPath(container))...)
+	workspaceDiagnosis, err := bufdoctor.DiagnoseWorkspace(ctx, readWriteBucket)
+	if err != nil {
+		return err
+	}
+	findings = append(findings, workspaceDiagnosis.Findings...)
+	remotes := slicesext.ToUniqueSorted(append(workspaceDiagnosis.Remotes, flags.Remotes...))
+	if len(remotes) == 0 {
+		remotes = []string{bufconnect.DefaultRemote}
+	}
+	envTokenProvider, err := bufconnect.NewTokenProviderFromContainer(container)
+	if err != nil {
+		return err
+	}
+	netrcTokenProvider := bufconnect.NewNetrcTokenProvider(container, netrc.GetMachineForName)
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	getCurrentUser := func(ctx context.Context, remote string) (string, error) {
+		ctx, cancel := context.WithTimeout(ctx, registryTimeout)
+		defer cancel()
+		authnService := connectclient.Make(clientConfig, remote, registryv1alpha1connect.NewAuthnServiceClient)
+		response, err := authnService.GetCurrentUser(ctx, connect.NewRequest(&registryv1alpha1.GetCurrentUserRequest{}))
+		if err != nil {
+			return "", err
+		}
+		return response.Msg.GetUser().GetUsername(), nil
+	}
+	for _, remote := range remotes {
+		findings = append(
+			findings,
+			bufdoctor.DiagnoseRegistry(ctx, remote, getCurrentUser, envTokenProvider, netrcTokenProvider)...,
+		)
+	}
+	if err := printFindings(container, format, findings); err != nil {
+		return err
+	}
+	if bufdoctor.HasErrors(findings) {
+		return bufcli.ErrFileAnnotation
+	}
+	return nil
+}
+
+func printFindings(container appflag.Container, format bufprint.Format, findings []*bufdoctor.Finding) error {
+	switch format {
+	case bufprint.FormatText:
+		return bufprint.WithTabWriter(
+			container.Stdout(),
+			[]string{
+				"Check",
+				"Severity",
+				"Message",
+				"Suggestion",
+			},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, finding := range findings {
+					if err := tabWriter.Write(
+						finding.Check,
+						finding.Severity.String(),
+						finding.Message,
+						finding.Suggestion,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+	case bufprint.FormatJSON:
+		encoder := json.NewEncoder(container.Stdout())
+		for _, finding := range findings {
+			if err := encoder.Encode(finding); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspaceinfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufwork"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/encoding"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	writeFlagName           = "write"
+	disableSymlinksFlagName = "disable-symlinks"
+)
+
+// NewCommand returns a new Command.
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <directory>",
+		Short: "Infer a workspace from the directory structure",
+		Long: `Infer the directories of a workspace from the .proto files in a directory that has
+neither a buf.work.yaml nor a buf.yaml, and print the resulting buf.work.yaml.
+
+Each workspace directory is a package root, that is the directory of a file without the
+trailing directories that match the file's package. For example, a file in proto/acme/pet/v1
+with package acme.pet.v1 results in the directory proto.
+
+With --write, the buf.work.yaml is written to the directory, along with a buf.yaml for
+each workspace directory that does not have one.
+
+Other commands infer the workspace in the same way when BUF_INFER_WORKSPACE is set.
+
+The directory defaults to the current directory.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Write           bool
+	DisableSymlinks bool
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	flagSet.BoolVar(
+		&f.Write,
+		writeFlagName,
+		false,
+		"Write the inferred buf.work.yaml, and a buf.yaml for each workspace directory that does not have one",
+	)
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	dirPath := "."
+	if container.NumArgs() > 0 {
+		dirPath = container.Arg(0)
+	}
+	readWriteBucket, err := bufcli.NewStorageosProvider(flags.DisableSymlinks).NewReadWriteBucket(
+		dirPath,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return err
+	}
+	existingConfigFilePath, err := bufwork.ExistingConfigFilePath(ctx, readWriteBucket)
+	if err != nil {
+		return err
+	}
+	if existingConfigFilePath == "" {
+		existingConfigFilePath, err = bufconfig.ExistingConfigFilePath(ctx, readWriteBucket)
+		if err != nil {
+			return err
+		}
+	}
+	if existingConfigFilePath != "" {
+		return fmt.Errorf("%s already exists, the workspace does not need to be inferred", existingConfigFilePath)
+	}
+	directories, err := bufwork.InferDirectories(ctx, readWriteBucket)
+	if err != nil {
+		return err
+	}
+	if len(directories) == 0 {
+		return errors.New(`all .proto files are rooted at the directory, run "buf mod init" to create a buf.yaml instead`)
+	}
+	data, err := encoding.MarshalYAML(
+		&bufwork.ExternalConfigV1{
+			Version:     bufwork.V1Version,
+			Directories: directories,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if !flags.Write {
+		_, err := container.Stdout().Write(data)
+		return err
+	}
+	for _, directory := range directories {
+		directoryWriteBucket := storage.MapReadWriteBucket(readWriteBucket, storage.MapOnPrefix(directory))
+		existingConfigFilePath, err := bufconfig.ExistingConfigFilePath(ctx, directoryWriteBucket)
+		if err != nil {
+			return err
+		}
+		if existingConfigFilePath != "" {
+			continue
+		}
+		if err := bufconfig.WriteConfig(ctx, directoryWriteBucket); err != nil {
+			return err
+		}
+	}
+	return storage.PutPath(ctx, readWriteBucket, bufwork.ExternalConfigV1FilePath, data)
+}
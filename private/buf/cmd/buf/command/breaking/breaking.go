@@ -25,9 +25,11 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/diff/diffparse"
 	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/buf/private/pkg/stringutil"
 	"github.com/spf13/cobra"
@@ -44,6 +46,9 @@ const (
 	againstConfigFlagName     = "against-config"
 	excludePathsFlagName      = "exclude-path"
 	disableSymlinksFlagName   = "disable-symlinks"
+	moduleTagsFlagName        = "module-tags"
+	stdinDiffFlagName         = "stdin-diff"
+	typeFlagName              = "type"
 )
 
 // NewCommand returns a new Command.
@@ -78,6 +83,9 @@ type flags struct {
 	AgainstConfig     string
 	ExcludePaths      []string
 	DisableSymlinks   bool
+	ModuleTags        []string
+	StdinDiff         bool
+	Types             []string
 	// special
 	InputHashtag string
 }
@@ -91,6 +99,8 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindModuleTags(flagSet, &f.ModuleTags, moduleTagsFlagName)
+	bufcli.BindStdinDiff(flagSet, &f.StdinDiff, stdinDiffFlagName)
 	flagSet.StringVar(
 		&f.ErrorFormat,
 		errorFormatFlagName,
@@ -138,6 +148,14 @@ Overrides --%s`,
 		"",
 		`The buf.yaml file or data to use to configure the against source, module, or image`,
 	)
+	flagSet.StringSliceVar(
+		&f.Types,
+		typeFlagName,
+		nil,
+		`The types (package, message, enum, extension, service, method) to check for breaking changes.
+When specified, both the input and the against input are filtered to the same types, including every type they depend on in either input.
+Types that only exist in one of the inputs, for example because they were added or deleted, are allowed`,
+	)
 }
 
 func run(
@@ -155,7 +173,18 @@ func run(
 	if err != nil {
 		return err
 	}
-	ref, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, input)
+	var changedLines *diffparse.ChangedLines
+	if flags.StdinDiff {
+		changedLines, err = bufcli.ReadStdinDiff(container, stdinDiffFlagName, input, flags.Against)
+		if err != nil {
+			return err
+		}
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -183,6 +212,7 @@ func run(
 		flags.ExcludePaths, // we exclude these paths
 		false,              // files specified must exist on the main input
 		false,              // we must include source info for this side of the check
+		bufwire.GetImageConfigsWithModuleTags(flags.ModuleTags),
 	)
 	if err != nil {
 		return err
@@ -206,7 +236,7 @@ func run(
 			return err
 		}
 	}
-	againstRef, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, flags.Against)
+	againstRef, err := refParser.GetRef(ctx, flags.Against)
 	if err != nil {
 		return err
 	}
@@ -219,6 +249,8 @@ func run(
 		flags.ExcludePaths, // we exclude these paths
 		true,               // files are allowed to not exist on the against input
 		true,               // no need to include source info for against
+		// The against input is only a workspace if it is the same workspace at another commit.
+		bufwire.GetImageConfigsWithModuleTagsIfWorkspace(flags.ModuleTags),
 	)
 	if err != nil {
 		return err
@@ -242,21 +274,44 @@ func run(
 		// we're torched.
 		return fmt.Errorf("input contained %d images, whereas against contained %d images", len(imageConfigs), len(againstImageConfigs))
 	}
+	// If the input is a workspace, each type only needs to be declared in one of its images.
+	declaredTypes := make(map[string]struct{}, len(flags.Types))
 	var allFileAnnotations []bufanalysis.FileAnnotation
 	for i, imageConfig := range imageConfigs {
+		var imageTypes []string
+		if len(flags.Types) > 0 {
+			imageTypes, err = getDeclaredTypes(imageConfig, againstImageConfigs[i], flags.Types)
+			if err != nil {
+				return err
+			}
+			if len(imageTypes) == 0 {
+				continue
+			}
+			for _, imageType := range imageTypes {
+				declaredTypes[imageType] = struct{}{}
+			}
+		}
 		fileAnnotations, err := breakingForImage(
 			ctx,
 			container,
 			imageConfig,
 			againstImageConfigs[i],
 			flags.ExcludeImports,
-			flags.ErrorFormat,
+			imageTypes,
 		)
 		if err != nil {
 			return err
 		}
 		allFileAnnotations = append(allFileAnnotations, fileAnnotations...)
 	}
+	for _, typeName := range flags.Types {
+		if _, ok := declaredTypes[typeName]; !ok {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %q is not declared in the input or the against input", typeFlagName, typeName)
+		}
+	}
+	if changedLines != nil {
+		allFileAnnotations = bufanalysis.FilterFileAnnotationsForChangedLines(allFileAnnotations, *changedLines)
+	}
 	if len(allFileAnnotations) > 0 {
 		if err := bufanalysis.PrintFileAnnotations(
 			container.Stdout(),
@@ -265,7 +320,7 @@ func run(
 		); err != nil {
 			return err
 		}
-		return bufcli.ErrFileAnnotation
+		return bufcli.ErrBreakingViolation
 	}
 	return nil
 }
@@ -276,22 +331,52 @@ func breakingForImage(
 	imageConfig bufwire.ImageConfig,
 	againstImageConfig bufwire.ImageConfig,
 	excludeImports bool,
-	errorFormat string,
+	types []string,
 ) ([]bufanalysis.FileAnnotation, error) {
 	image := imageConfig.Image()
-	if excludeImports {
-		image = bufimage.ImageWithoutImports(image)
-	}
 	againstImage := againstImageConfig.Image()
+	if len(types) > 0 {
+		var err error
+		image, againstImage, err = bufimageutil.ImagesFilteredByTypes(image, againstImage, types...)
+		if err != nil {
+			return nil, err
+		}
+		if againstImage == nil {
+			// None of the types exist in the against input, so nothing can be broken.
+			return nil, nil
+		}
+	}
 	if excludeImports {
+		image = bufimage.ImageWithoutImports(image)
 		againstImage = bufimage.ImageWithoutImports(againstImage)
 	}
-	return bufbreaking.NewHandler(container.Logger()).Check(
+	fileAnnotations, err := bufbreaking.NewHandler(container.Logger()).Check(
 		ctx,
 		imageConfig.Config().Breaking,
 		againstImage,
 		image,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return bufwire.MapFileAnnotationExternalPaths(ctx, imageConfig, fileAnnotations), nil
+}
+
+// getDeclaredTypes returns the types that are declared in either the image or the against image.
+func getDeclaredTypes(imageConfig bufwire.ImageConfig, againstImageConfig bufwire.ImageConfig, types []string) ([]string, error) {
+	imageTypes, err := bufimageutil.ImageDeclaredTypes(imageConfig.Image(), types)
+	if err != nil {
+		return nil, err
+	}
+	againstImageTypes, err := bufimageutil.ImageDeclaredTypes(againstImageConfig.Image(), types)
+	if err != nil {
+		return nil, err
+	}
+	declaredTypes := make(map[string]struct{}, len(imageTypes)+len(againstImageTypes))
+	for _, typeName := range append(imageTypes, againstImageTypes...) {
+		declaredTypes[typeName] = struct{}{}
+	}
+	return slicesext.MapKeysToSortedSlice(declaredTypes), nil
 }
 
 func getExternalPathsForImages(imageConfigs []bufwire.ImageConfig, excludeImports bool) ([]string, error) {
@@ -17,10 +17,13 @@ package build
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufwire"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
@@ -43,6 +46,9 @@ const (
 	excludePathsFlagName                  = "exclude-path"
 	disableSymlinksFlagName               = "disable-symlinks"
 	typeFlagName                          = "type"
+	extensionRegistryFlagName             = "extension-registry"
+	requiredTypesFlagName                 = "required-types"
+	moduleTagsFlagName                    = "module-tags"
 )
 
 // NewCommand returns a new Command.
@@ -78,6 +84,9 @@ type flags struct {
 	ExcludePaths                  []string
 	DisableSymlinks               bool
 	Types                         []string
+	ExtensionRegistry             bool
+	RequiredTypes                 []string
+	ModuleTags                    []string
 	// special
 	InputHashtag string
 }
@@ -94,6 +103,7 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindModuleTags(flagSet, &f.ModuleTags, moduleTagsFlagName)
 	flagSet.BoolVar(
 		&f.ExcludeSourceRetentionOptions,
 		excludeSourceRetentionOptionsFlagName,
@@ -131,6 +141,21 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		nil,
 		"The types (package, message, enum, extension, service, method) that should be included in this image. When specified, the resulting image will only include descriptors to describe the requested types",
 	)
+	flagSet.BoolVar(
+		&f.ExtensionRegistry,
+		extensionRegistryFlagName,
+		false,
+		fmt.Sprintf(
+			"Only include the extensions (custom options and proto2 extensions) in the image, along with their dependencies. The result can be loaded as an extension registry. Cannot be used with --%s",
+			typeFlagName,
+		),
+	)
+	flagSet.StringSliceVar(
+		&f.RequiredTypes,
+		requiredTypesFlagName,
+		nil,
+		"The fully-qualified types (message, enum, extension, service, method) that must be present in the resulting image. The build fails if any of these are missing, which is useful when filtering with --path or --type",
+	)
 }
 
 func run(
@@ -144,6 +169,9 @@ func run(
 	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
 		return err
 	}
+	if flags.ExtensionRegistry && len(flags.Types) > 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s cannot be used with --%s", extensionRegistryFlagName, typeFlagName)
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
 	if err != nil {
 		return err
@@ -159,6 +187,7 @@ func run(
 		flags.ExcludePaths, // we exclude these paths
 		false,
 		flags.ExcludeSourceInfo,
+		bufwire.GetImageConfigsWithModuleTags(flags.ModuleTags),
 	)
 	if err != nil {
 		return err
@@ -173,12 +202,23 @@ func run(
 			return err
 		}
 	}
+	if flags.ExtensionRegistry {
+		image, err = bufimageutil.ImageFilteredByExtensions(image)
+		if err != nil {
+			return err
+		}
+	}
 	if flags.ExcludeSourceRetentionOptions {
 		image, err = bufimageutil.StripSourceRetentionOptions(image)
 		if err != nil {
 			return err
 		}
 	}
+	if len(flags.RequiredTypes) > 0 {
+		if err := checkRequiredTypes(image, flags.RequiredTypes, flags.ExcludeImports); err != nil {
+			return err
+		}
+	}
 	return bufcli.NewWireImageWriter(
 		container.Logger(),
 	).PutImage(
@@ -190,3 +230,21 @@ func run(
 		flags.ExcludeImports,
 	)
 }
+
+// checkRequiredTypes returns an error listing the required types that are not
+// present in the image that will be written.
+func checkRequiredTypes(image bufimage.Image, requiredTypes []string, excludeImports bool) error {
+	if excludeImports {
+		// Imports are stripped when the image is written, so types declared
+		// in imports will not be present in the output.
+		image = bufimage.ImageWithoutImports(image)
+	}
+	missingTypes, err := bufimageutil.ImageMissingTypes(image, requiredTypes)
+	if err != nil {
+		return err
+	}
+	if len(missingTypes) > 0 {
+		return fmt.Errorf("--%s: types not found in image:\n  %s", requiredTypesFlagName, strings.Join(missingTypes, "\n  "))
+	}
+	return nil
+}
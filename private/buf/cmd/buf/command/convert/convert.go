@@ -18,18 +18,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufconvert"
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufprint"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
+	"github.com/bufbuild/buf/private/bufpkg/bufreflect"
 	"github.com/bufbuild/buf/private/gen/data/datawkt"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/stringutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -38,6 +48,13 @@ const (
 	fromFlagName            = "from"
 	outputFlagName          = "to"
 	disableSymlinksFlagName = "disable-symlinks"
+	fieldMaskFlagName       = "field-mask"
+	toTypeFlagName          = "to-type"
+	fieldMappingFlagName    = "field-mapping"
+	inferTypeFlagName       = "infer-type"
+
+	// inferTypeMaxCandidates is the maximum number of candidate types printed with --infer-type.
+	inferTypeMaxCandidates = 10
 )
 
 // NewCommand returns a new Command.
@@ -79,6 +96,28 @@ Redirect from stdin to <input>:
 Use a module on the bsr:
 
     $ buf convert <buf.build/owner/repository> --type buf.Foo --from=payload.json
+
+Only keep some fields of the payload:
+
+    $ buf convert buf.proto --type buf.Foo --from=payload.json --field-mask=one,bar.two
+
+Convert the payload to a compatible type, renaming or dropping fields with a mapping file:
+
+    $ buf convert buf.proto --type buf.v1.Foo --to-type buf.v2.Foo --field-mapping=mapping.yaml --from=payload.json
+
+The mapping file maps fully-qualified source field names to target field names, where
+an empty target field name drops the field:
+
+    fields:
+      buf.v1.Foo.one: first
+      buf.v1.Foo.legacy: ""
+
+Identify the type of a binary payload, printing the most plausible message types of the input:
+
+    $ buf convert buf.proto --infer-type --from=payload.binpb
+
+Types are ranked by the fraction of the payload that decodes into known fields of the type.
+This is a heuristic, and should be confirmed by converting the payload with --type.
 `,
 		Args: cobra.MaximumNArgs(1),
 		Run: builder.NewRunFunc(
@@ -97,6 +136,10 @@ type flags struct {
 	From            string
 	To              string
 	DisableSymlinks bool
+	FieldMask       []string
+	ToType          string
+	FieldMapping    string
+	InferType       bool
 
 	// special
 	InputHashtag string
@@ -142,6 +185,37 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 			buffetch.MessageFormatsString,
 		),
 	)
+	flagSet.StringSliceVar(
+		&f.FieldMask,
+		fieldMaskFlagName,
+		nil,
+		`The field mask paths to apply to the payload before conversion (e.g. name,address.city). All other fields are cleared`,
+	)
+	flagSet.StringVar(
+		&f.ToType,
+		toTypeFlagName,
+		"",
+		`The full type name of a compatible message within the input to convert the payload to. Defaults to the value of --type`,
+	)
+	flagSet.StringVar(
+		&f.FieldMapping,
+		fieldMappingFlagName,
+		"",
+		fmt.Sprintf(
+			`The path to a JSON or YAML file that maps fields of the --%s message to fields of the --%s message`,
+			typeFlagName,
+			toTypeFlagName,
+		),
+	)
+	flagSet.BoolVar(
+		&f.InferType,
+		inferTypeFlagName,
+		false,
+		fmt.Sprintf(
+			`Print the message types of the input that the binary payload is most plausibly an instance of, instead of converting. Cannot be used with --%s`,
+			typeFlagName,
+		),
+	)
 }
 
 func run(
@@ -152,6 +226,12 @@ func run(
 	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
 		return err
 	}
+	if flags.FieldMapping != "" && flags.ToType == "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s requires --%s", fieldMappingFlagName, toTypeFlagName)
+	}
+	if flags.InferType && flags.Type != "" {
+		return appcmd.NewInvalidArgumentErrorf("--%s and --%s cannot be used together", inferTypeFlagName, typeFlagName)
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
 	if err != nil {
 		return err
@@ -204,6 +284,9 @@ func run(
 	}
 	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
 	runner := command.NewRunner()
+	if flags.InferType {
+		return inferType(ctx, container, storageosProvider, runner, image, fromMessageRef)
+	}
 	message, err := bufcli.NewWireProtoEncodingReader(
 		container.Logger(),
 		storageosProvider,
@@ -218,6 +301,17 @@ func run(
 	if err != nil {
 		return err
 	}
+	if len(flags.FieldMask) > 0 {
+		if err := bufconvert.ApplyFieldMask(message.ProtoReflect(), flags.FieldMask); err != nil {
+			return fmt.Errorf("--%s: %w", fieldMaskFlagName, err)
+		}
+	}
+	if flags.ToType != "" {
+		message, err = convertMessage(ctx, image, message, flags.ToType, flags.FieldMapping)
+		if err != nil {
+			return err
+		}
+	}
 	defaultToEncoding, err := inverseEncoding(fromMessageRef.MessageEncoding())
 	if err != nil {
 		return err
@@ -242,6 +336,99 @@ func run(
 	)
 }
 
+// convertMessage converts the message to a new message of the given type in the image,
+// using the field mapping at fieldMappingPath, if set.
+func convertMessage(
+	ctx context.Context,
+	image bufimage.Image,
+	message proto.Message,
+	toType string,
+	fieldMappingPath string,
+) (proto.Message, error) {
+	toMessage, err := bufreflect.NewMessage(ctx, image, toType)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", toTypeFlagName, err)
+	}
+	var fieldMapping bufconvert.FieldMapping
+	if fieldMappingPath != "" {
+		data, err := os.ReadFile(fieldMappingPath)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", fieldMappingFlagName, err)
+		}
+		fieldMapping, err = bufconvert.ReadFieldMapping(data)
+		if err != nil {
+			return nil, fmt.Errorf("--%s: %w", fieldMappingFlagName, err)
+		}
+	}
+	if err := bufconvert.Convert(message.ProtoReflect(), toMessage.ProtoReflect(), fieldMapping); err != nil {
+		return nil, err
+	}
+	return toMessage, nil
+}
+
+// inferType prints the message types of the image that the binary payload at
+// fromMessageRef is most plausibly an instance of.
+func inferType(
+	ctx context.Context,
+	container appflag.Container,
+	storageosProvider storageos.Provider,
+	runner command.Runner,
+	image bufimage.Image,
+	fromMessageRef buffetch.MessageRef,
+) (retErr error) {
+	if fromMessageRef.MessageEncoding() != buffetch.MessageEncodingBinpb {
+		return appcmd.NewInvalidArgumentErrorf("--%s requires a binary payload", inferTypeFlagName)
+	}
+	readCloser, err := bufcli.NewFetchMessageReader(
+		container.Logger(),
+		storageosProvider,
+		runner,
+	).GetMessageFile(ctx, container, fromMessageRef)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, readCloser.Close())
+	}()
+	data, err := io.ReadAll(readCloser)
+	if err != nil {
+		return err
+	}
+	messageDescriptors, err := bufreflect.MessageDescriptors(image)
+	if err != nil {
+		return err
+	}
+	typeCandidates := bufconvert.RankMessageTypes(data, messageDescriptors)
+	if len(typeCandidates) == 0 {
+		return errors.New("the payload is not plausibly an instance of any message type of the input")
+	}
+	if len(typeCandidates) > inferTypeMaxCandidates {
+		typeCandidates = typeCandidates[:inferTypeMaxCandidates]
+	}
+	return bufprint.WithTabWriter(
+		container.Stdout(),
+		[]string{
+			"Type",
+			"Score",
+			"Known fields",
+			"Unknown bytes",
+		},
+		func(tabWriter bufprint.TabWriter) error {
+			for _, typeCandidate := range typeCandidates {
+				if err := tabWriter.Write(
+					string(typeCandidate.FullName),
+					strconv.FormatFloat(typeCandidate.Score, 'f', 2, 64),
+					strconv.Itoa(typeCandidate.KnownFieldCount),
+					strconv.Itoa(typeCandidate.UnknownByteCount),
+				); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
 // inverseEncoding returns the opposite encoding of the provided encoding,
 // which will be the default output encoding for a given payload encoding.
 func inverseEncoding(encoding buffetch.MessageEncoding) (buffetch.MessageEncoding, error) {
@@ -397,4 +397,21 @@ func TestConvertDir(t *testing.T) {
 			"-#format=json",
 		)
 	})
+	t.Run("field-mask", func(t *testing.T) {
+		t.Parallel()
+		stdin := strings.NewReader(`{"name":"foo","requestTypeUrl":"bar"}`)
+		appcmdtesting.RunCommandExitCodeStdout(
+			t,
+			cmd,
+			0,
+			`{"name":"foo"}`,
+			nil,
+			stdin,
+			"--type=google.protobuf.Method",
+			"--from=-#format=json",
+			"--to",
+			"-#format=json",
+			"--field-mask=name",
+		)
+	})
 }
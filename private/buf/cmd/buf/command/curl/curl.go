@@ -33,7 +33,6 @@ import (
 	"connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/bufcurl"
-	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/pkg/app"
@@ -907,7 +906,11 @@ func run(ctx context.Context, container appflag.Container, f *flags) (err error)
 		resolvers = append(resolvers, res)
 	}
 	for _, schema := range f.Schemas {
-		ref, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, schema)
+		refParser, err := bufcli.NewRefParser(container)
+		if err != nil {
+			return err
+		}
+		ref, err := refParser.GetRef(ctx, schema)
 		if err != nil {
 			return err
 		}
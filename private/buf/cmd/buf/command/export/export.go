@@ -15,13 +15,20 @@
 package export
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufwire"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
@@ -31,6 +38,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
 	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -45,6 +53,12 @@ const (
 	configFlagName          = "config"
 	excludePathsFlagName    = "exclude-path"
 	disableSymlinksFlagName = "disable-symlinks"
+	againstLockFlagName     = "against-lock"
+
+	// exportManifestFileName is the name of the verification manifest written
+	// to the output directory when exporting with --against-lock.
+	exportManifestFileName = "buf.export.json"
+	exportManifestVersion  = "v1"
 )
 
 // NewCommand returns a new Command.
@@ -79,6 +93,14 @@ Export a specific version of a remote module to a local directory.
 Export a git repo to a local directory.
 
     $ buf export https://github.com/owner/repository.git --output=<output-dir>
+
+Export a module and verify that exported dependency files match the digests in buf.lock.
+
+    $ buf export . --output=<output-dir> --against-lock
+
+With --against-lock, the export fails if a dependency does not match the digest pinned
+in buf.lock, and a ` + exportManifestFileName + ` file listing the digest of every exported file
+and the pinned dependencies is written to the output directory.
 `,
 		Args: cobra.MaximumNArgs(1),
 		Run: builder.NewRunFunc(
@@ -98,6 +120,7 @@ type flags struct {
 	Config          string
 	ExcludePaths    []string
 	DisableSymlinks bool
+	AgainstLock     bool
 
 	// special
 	InputHashtag string
@@ -127,6 +150,15 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		"",
 		`The buf.yaml file or data to use for configuration`,
 	)
+	flagSet.BoolVar(
+		&f.AgainstLock,
+		againstLockFlagName,
+		false,
+		fmt.Sprintf(
+			`Verify that exported dependency files match the digests pinned in buf.lock, and write a %s verification manifest to the output directory`,
+			exportManifestFileName,
+		),
+	)
 }
 
 func run(
@@ -138,7 +170,11 @@ func run(
 	if err != nil {
 		return err
 	}
-	sourceOrModuleRef, err := buffetch.NewRefParser(container.Logger()).GetSourceOrModuleRef(ctx, input)
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -175,6 +211,13 @@ func run(
 		return err
 	}
 	moduleConfigs := moduleConfigSet.ModuleConfigs()
+	var lockedModules map[string]*lockedModule
+	if flags.AgainstLock {
+		lockedModules, err = getLockedModules(ctx, moduleReader, moduleConfigs)
+		if err != nil {
+			return err
+		}
+	}
 	moduleFileSetBuilder := bufmodulebuild.NewModuleFileSetBuilder(
 		container.Logger(),
 		moduleReader,
@@ -284,16 +327,9 @@ func run(
 	if err != nil {
 		return err
 	}
-	if err := os.MkdirAll(flags.Output, 0755); err != nil {
-		return err
-	}
-	readWriteBucket, err := storageosProvider.NewReadWriteBucket(
-		flags.Output,
-		storageos.ReadWriteBucketWithSymlinksIfSupported(),
-	)
-	if err != nil {
-		return err
-	}
+	// The files are exported to memory first, so that nothing is written to the
+	// output directory if they do not match buf.lock.
+	readWriteBucket := storagemem.NewReadWriteBucket()
 	fileInfosFunc := bufmodule.ModuleFileSet.AllFileInfos
 	// If we filtered on some paths, only use the targets.
 	// Otherwise, we want to print everything, including potentially imports.
@@ -307,7 +343,7 @@ func run(
 			return moduleFileSet.TargetFileInfos(ctx)
 		}
 	}
-	writtenPaths := make(map[string]struct{})
+	writtenPaths := make(map[string]bufmoduleref.FileInfo)
 	for _, moduleFileSet := range moduleFileSets {
 		// If the reference was a proto file reference, we will use the image files as the basis
 		// for outputting source files.
@@ -331,12 +367,12 @@ func run(
 				if err := moduleFile.Close(); err != nil {
 					return err
 				}
-				writtenPaths[path] = struct{}{}
+				writtenPaths[path] = moduleFile
 			}
 			if len(writtenPaths) == 0 {
 				return errors.New("no .proto target files found")
 			}
-			return nil
+			return writeExport(ctx, storageosProvider, flags, readWriteBucket, lockedModules, writtenPaths)
 		}
 		fileInfos, err := fileInfosFunc(moduleFileSet, ctx)
 		if err != nil {
@@ -369,11 +405,221 @@ func run(
 			if err := moduleFile.Close(); err != nil {
 				return err
 			}
-			writtenPaths[path] = struct{}{}
+			writtenPaths[path] = moduleFile
 		}
 	}
 	if len(writtenPaths) == 0 {
 		return errors.New("no .proto target files found")
 	}
-	return nil
+	return writeExport(ctx, storageosProvider, flags, readWriteBucket, lockedModules, writtenPaths)
+}
+
+// lockedModule is a dependency pinned in buf.lock along with its verified manifest.
+type lockedModule struct {
+	modulePin bufmoduleref.ModulePin
+	manifest  bufcas.Manifest
+}
+
+// getLockedModules reads every dependency pinned by the modules and verifies that
+// the manifest digest of the dependency matches the digest pinned in buf.lock.
+//
+// The returned map is keyed by the identity string of the dependency.
+func getLockedModules(
+	ctx context.Context,
+	moduleReader bufmodule.ModuleReader,
+	moduleConfigs []bufwire.ModuleConfig,
+) (map[string]*lockedModule, error) {
+	lockedModules := make(map[string]*lockedModule)
+	for _, moduleConfig := range moduleConfigs {
+		for _, modulePin := range moduleConfig.Module().DependencyModulePins() {
+			identityString := modulePin.IdentityString()
+			if existing, ok := lockedModules[identityString]; ok {
+				if existing.modulePin.Commit() != modulePin.Commit() {
+					return nil, fmt.Errorf(
+						"dependency %s is pinned to both commit %s and commit %s, cannot verify against buf.lock",
+						identityString,
+						existing.modulePin.Commit(),
+						modulePin.Commit(),
+					)
+				}
+				continue
+			}
+			if modulePin.Digest() == "" {
+				return nil, fmt.Errorf("dependency %s has no digest in buf.lock, run \"buf mod update\" to pin its digest", identityString)
+			}
+			pinnedDigest, err := bufcas.ParseDigest(modulePin.Digest())
+			if err != nil {
+				return nil, fmt.Errorf("dependency %s has a malformed digest in buf.lock: %w", identityString, err)
+			}
+			module, err := moduleReader.GetModule(ctx, modulePin)
+			if err != nil {
+				return nil, err
+			}
+			fileSet := module.FileSet()
+			if fileSet == nil {
+				return nil, fmt.Errorf("dependency %s has no manifest, cannot verify against buf.lock", identityString)
+			}
+			manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+			if err != nil {
+				return nil, err
+			}
+			if !bufcas.DigestEqual(manifestBlob.Digest(), pinnedDigest) {
+				return nil, fmt.Errorf(
+					"dependency %s does not match buf.lock: pinned digest %s, actual digest %s",
+					identityString,
+					pinnedDigest.String(),
+					manifestBlob.Digest().String(),
+				)
+			}
+			lockedModules[identityString] = &lockedModule{
+				modulePin: modulePin,
+				manifest:  fileSet.Manifest(),
+			}
+		}
+	}
+	return lockedModules, nil
+}
+
+// writeExport copies the exported files to the output directory. If --against-lock was
+// set, the files are verified against buf.lock before anything is written, and the
+// verification manifest is written along with them.
+func writeExport(
+	ctx context.Context,
+	storageosProvider storageos.Provider,
+	flags *flags,
+	exportReadBucket storage.ReadBucket,
+	lockedModules map[string]*lockedModule,
+	writtenPaths map[string]bufmoduleref.FileInfo,
+) error {
+	var manifestData []byte
+	if flags.AgainstLock {
+		manifest, err := newExportManifest(ctx, exportReadBucket, lockedModules, writtenPaths)
+		if err != nil {
+			return err
+		}
+		manifestData, err = json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(flags.Output, 0755); err != nil {
+		return err
+	}
+	readWriteBucket, err := storageosProvider.NewReadWriteBucket(
+		flags.Output,
+		storageos.ReadWriteBucketWithSymlinksIfSupported(),
+	)
+	if err != nil {
+		return err
+	}
+	if _, err := storage.Copy(ctx, exportReadBucket, readWriteBucket); err != nil {
+		return err
+	}
+	if manifestData == nil {
+		return nil
+	}
+	return storage.PutPath(ctx, readWriteBucket, exportManifestFileName, append(manifestData, '\n'))
+}
+
+type exportManifest struct {
+	Version      string                     `json:"version"`
+	Dependencies []exportManifestDependency `json:"dependencies,omitempty"`
+	Files        []exportManifestFile       `json:"files"`
+}
+
+type exportManifestDependency struct {
+	Name   string `json:"name"`
+	Commit string `json:"commit"`
+	Digest string `json:"digest"`
+}
+
+type exportManifestFile struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+	// Module and Commit are only set for files exported from a dependency pinned in buf.lock.
+	Module string `json:"module,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// newExportManifest digests the exported files as written to the bucket and
+// verifies that files exported from a pinned dependency match its manifest.
+func newExportManifest(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	lockedModules map[string]*lockedModule,
+	writtenPaths map[string]bufmoduleref.FileInfo,
+) (*exportManifest, error) {
+	manifest := &exportManifest{
+		Version: exportManifestVersion,
+	}
+	for _, lockedModule := range lockedModules {
+		manifest.Dependencies = append(
+			manifest.Dependencies,
+			exportManifestDependency{
+				Name:   lockedModule.modulePin.IdentityString(),
+				Commit: lockedModule.modulePin.Commit(),
+				Digest: lockedModule.modulePin.Digest(),
+			},
+		)
+	}
+	sort.Slice(
+		manifest.Dependencies,
+		func(i int, j int) bool {
+			return manifest.Dependencies[i].Name < manifest.Dependencies[j].Name
+		},
+	)
+	paths := make([]string, 0, len(writtenPaths))
+	for path := range writtenPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var mismatches []string
+	for _, path := range paths {
+		data, err := storage.ReadPath(ctx, readBucket, path)
+		if err != nil {
+			return nil, err
+		}
+		digest, err := bufcas.NewDigestForContent(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		manifestFile := exportManifestFile{
+			Path:   path,
+			Digest: digest.String(),
+		}
+		if lockedModule := lockedModuleForFileInfo(lockedModules, writtenPaths[path]); lockedModule != nil {
+			manifestFile.Module = lockedModule.modulePin.IdentityString()
+			manifestFile.Commit = writtenPaths[path].Commit()
+			if manifestFile.Commit != lockedModule.modulePin.Commit() {
+				mismatches = append(
+					mismatches,
+					fmt.Sprintf("%s: from commit %s of %s, but buf.lock pins commit %s", path, manifestFile.Commit, manifestFile.Module, lockedModule.modulePin.Commit()),
+				)
+			} else if expectedDigest := lockedModule.manifest.GetDigest(path); expectedDigest == nil {
+				mismatches = append(mismatches, fmt.Sprintf("%s: not in %s", path, manifestFile.Module))
+			} else if !bufcas.DigestEqual(digest, expectedDigest) {
+				mismatches = append(
+					mismatches,
+					fmt.Sprintf("%s: expected digest %s from %s, got %s", path, expectedDigest.String(), manifestFile.Module, digest.String()),
+				)
+			}
+		}
+		manifest.Files = append(manifest.Files, manifestFile)
+	}
+	if len(mismatches) > 0 {
+		return nil, fmt.Errorf("exported files do not match buf.lock:\n  %s", strings.Join(mismatches, "\n  "))
+	}
+	return manifest, nil
+}
+
+// lockedModuleForFileInfo returns the pinned dependency with the module identity the
+// file was read from, or nil if the file came from a module that is not pinned, such
+// as a local workspace module. The file may have been read from a different commit
+// than the pinned one.
+func lockedModuleForFileInfo(lockedModules map[string]*lockedModule, fileInfo bufmoduleref.FileInfo) *lockedModule {
+	moduleIdentity := fileInfo.ModuleIdentity()
+	if moduleIdentity == nil || fileInfo.Commit() == "" {
+		return nil
+	}
+	return lockedModules[moduleIdentity.IdentityString()]
 }
@@ -0,0 +1,120 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufcas"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPeoplePath    = "people/v1/people.proto"
+	testPeopleContent = "syntax = \"proto3\";\npackage people.v1;\n"
+	testPinnedCommit  = "00000000000000000000000000000001"
+)
+
+func TestNewExportManifest(t *testing.T) {
+	t.Parallel()
+	readBucket, lockedModules, writtenPaths := testGetExport(t, testPeopleContent, testPinnedCommit)
+	manifest, err := newExportManifest(context.Background(), readBucket, lockedModules, writtenPaths)
+	require.NoError(t, err)
+	assert.Equal(t, exportManifestVersion, manifest.Version)
+	require.Len(t, manifest.Dependencies, 1)
+	assert.Equal(t, "bufbuild.test/bufbot/people", manifest.Dependencies[0].Name)
+	assert.Equal(t, testPinnedCommit, manifest.Dependencies[0].Commit)
+	require.Len(t, manifest.Files, 2)
+	assert.Equal(t, "local.proto", manifest.Files[0].Path)
+	assert.Empty(t, manifest.Files[0].Module)
+	assert.Equal(t, testPeoplePath, manifest.Files[1].Path)
+	assert.Equal(t, "bufbuild.test/bufbot/people", manifest.Files[1].Module)
+	assert.Equal(t, testPinnedCommit, manifest.Files[1].Commit)
+}
+
+func TestNewExportManifestDigestMismatch(t *testing.T) {
+	t.Parallel()
+	readBucket, lockedModules, writtenPaths := testGetExport(t, testPeopleContent+"message Changed {}\n", testPinnedCommit)
+	_, err := newExportManifest(context.Background(), readBucket, lockedModules, writtenPaths)
+	assert.ErrorContains(t, err, testPeoplePath+": expected digest")
+}
+
+func TestNewExportManifestCommitMismatch(t *testing.T) {
+	t.Parallel()
+	readBucket, lockedModules, writtenPaths := testGetExport(t, testPeopleContent, "00000000000000000000000000000002")
+	_, err := newExportManifest(context.Background(), readBucket, lockedModules, writtenPaths)
+	assert.ErrorContains(
+		t,
+		err,
+		testPeoplePath+": from commit 00000000000000000000000000000002 of bufbuild.test/bufbot/people, but buf.lock pins commit "+testPinnedCommit,
+	)
+}
+
+// testGetExport returns the exported files, the pinned dependencies, and the file infos
+// of the exported files for an export of a local file and a file of a dependency pinned
+// to testPinnedCommit with testPeopleContent. The file of the dependency was exported
+// with exportedContent from exportedCommit.
+func testGetExport(
+	t *testing.T,
+	exportedContent string,
+	exportedCommit string,
+) (storage.ReadBucket, map[string]*lockedModule, map[string]bufmoduleref.FileInfo) {
+	ctx := context.Background()
+	dependencyBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			testPeoplePath: []byte(testPeopleContent),
+		},
+	)
+	require.NoError(t, err)
+	fileSet, err := bufcas.NewFileSetForBucket(ctx, dependencyBucket)
+	require.NoError(t, err)
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	modulePin, err := bufmoduleref.NewModulePin(
+		"bufbuild.test",
+		"bufbot",
+		"people",
+		testPinnedCommit,
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	exportBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"local.proto":  []byte("syntax = \"proto3\";\n"),
+			testPeoplePath: []byte(exportedContent),
+		},
+	)
+	require.NoError(t, err)
+	localFileInfo, err := bufmoduleref.NewFileInfo("local.proto", "local.proto", nil, "")
+	require.NoError(t, err)
+	dependencyFileInfo, err := bufmoduleref.NewFileInfo(testPeoplePath, testPeoplePath, modulePin, exportedCommit)
+	require.NoError(t, err)
+	return exportBucket,
+		map[string]*lockedModule{
+			modulePin.IdentityString(): {
+				modulePin: modulePin,
+				manifest:  fileSet.Manifest(),
+			},
+		},
+		map[string]bufmoduleref.FileInfo{
+			"local.proto":  localFileInfo,
+			testPeoplePath: dependencyFileInfo,
+		}
+}
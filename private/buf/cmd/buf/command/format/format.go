@@ -257,7 +257,10 @@ func run(
 	if err != nil {
 		return err
 	}
-	refParser := buffetch.NewRefParser(container.Logger())
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
 	sourceOrModuleRef, err := refParser.GetSourceOrModuleRef(ctx, source)
 	if err != nil {
 		return err
@@ -355,6 +358,11 @@ func run(
 			// (potentially including a debug log).
 			return errors.New("this command does not support including package files")
 		}
+		if protoFileRef.NumPaths() > 1 {
+			// TODO: Support formatting multiple files, which may be within different
+			// modules of a workspace.
+			return errors.New("this command does not support multiple proto files")
+		}
 		module := moduleConfigs[0].Module()
 		fileInfos, err := module.TargetFileInfos(ctx)
 		if err != nil {
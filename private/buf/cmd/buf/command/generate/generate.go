@@ -17,10 +17,11 @@ package generate
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
-	"github.com/bufbuild/buf/private/buf/buffetch"
 	"github.com/bufbuild/buf/private/buf/bufgen"
+	"github.com/bufbuild/buf/private/buf/bufwire"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimageutil"
@@ -44,8 +45,11 @@ const (
 	includeWKTFlagName          = "include-wkt"
 	excludePathsFlagName        = "exclude-path"
 	disableSymlinksFlagName     = "disable-symlinks"
+	moduleTagsFlagName          = "module-tags"
 	typeFlagName                = "type"
 	typeDeprecatedFlagName      = "include-types"
+	gracePeriodFlagName         = "grace-period"
+	errorPolicyFlagName         = "error-policy"
 )
 
 // NewCommand returns a new Command.
@@ -107,12 +111,25 @@ func NewCommand(
         # If omitted, "directory" is used. Most users should not need to set this option.
         # Optional.
         strategy: directory
+        # Whether to also generate imports, and the Well-Known Types, for this plugin.
+        # These override the --include-imports and --include-wkt flags for this plugin, so that
+        # for example one plugin can receive the Well-Known Types while others never do.
+        # include_wkt cannot be set without include_imports.
+        # Optional.
+        include_imports: true
+        include_wkt: true
       - plugin: java
         out: gen/java
         # Use the plugin hosted at buf.build/protocolbuffers/python at version v21.9.
         # If version is omitted, uses the latest version of the plugin.
       - plugin: buf.build/protocolbuffers/python:v21.9
         out: gen/python
+        # Only generate code if the registry runs the plugin from one of these
+        # container images.
+        # Optional, and only valid for remote plugins.
+        attestation:
+          container_image_digests:
+            - sha256:2f8c1f5b0d4e9a7c3b6d8e1f0a2c4e6b8d0f1a3c5e7b9d1f3a5c7e9b1d3f5a7c
 
 As an example, here's a typical "buf.gen.yaml" go and grpc, assuming
 "protoc-gen-go" and "protoc-gen-go-grpc" are on your "$PATH":
@@ -203,10 +220,13 @@ type flags struct {
 	IncludeWKT      bool
 	ExcludePaths    []string
 	DisableSymlinks bool
+	ModuleTags      []string
 	// We may be able to bind two flags to one string slice but I don't
 	// want to find out what will break if we do.
 	Types           []string
 	TypesDeprecated []string
+	GracePeriod     time.Duration
+	ErrorPolicy     string
 	// special
 	InputHashtag string
 }
@@ -217,6 +237,7 @@ func newFlags() *flags {
 
 func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindModuleTags(flagSet, &f.ModuleTags, moduleTagsFlagName)
 	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
 	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
@@ -277,6 +298,18 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	)
 	_ = flagSet.MarkDeprecated(typeDeprecatedFlagName, fmt.Sprintf("Use --%s instead", typeFlagName))
 	_ = flagSet.MarkHidden(typeDeprecatedFlagName)
+	flagSet.DurationVar(
+		&f.GracePeriod,
+		gracePeriodFlagName,
+		0,
+		"How long local plugins are given to exit after being interrupted when generation is cancelled, for example by Ctrl-C, before they are killed. By default, local plugins are killed immediately",
+	)
+	flagSet.StringVar(
+		&f.ErrorPolicy,
+		errorPolicyFlagName,
+		"",
+		"How generation handles plugins that fail. With fail-fast, generation stops at the first plugin that fails. With collect-all, all plugins are run, the stderr of each local plugin is labeled with the name of the plugin, and all failures are reported. Overrides error_policy in buf.gen.yaml, which defaults to fail-fast",
+	)
 }
 
 func run(
@@ -292,14 +325,29 @@ func run(
 		// in the context of including imports.
 		return appcmd.NewInvalidArgumentErrorf("Cannot set --%s without --%s", includeWKTFlagName, includeImportsFlagName)
 	}
+	if flags.GracePeriod < 0 {
+		return appcmd.NewInvalidArgumentErrorf("--%s cannot be negative", gracePeriodFlagName)
+	}
 	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
 		return err
 	}
+	var errorPolicy bufgen.ErrorPolicy
+	if flags.ErrorPolicy != "" {
+		parsedErrorPolicy, err := bufgen.ParseErrorPolicy(flags.ErrorPolicy)
+		if err != nil {
+			return appcmd.NewInvalidArgumentErrorf("--%s: %v", errorPolicyFlagName, err)
+		}
+		errorPolicy = parsedErrorPolicy
+	}
 	input, err := bufcli.GetInputValue(container, flags.InputHashtag, ".")
 	if err != nil {
 		return err
 	}
-	ref, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, input)
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -344,6 +392,7 @@ func run(
 		flags.ExcludePaths, // we exclude these paths
 		false,              // input files must exist
 		false,              // we must include source info for generation
+		bufwire.GetImageConfigsWithModuleTags(flags.ModuleTags),
 	)
 	if err != nil {
 		return err
@@ -377,6 +426,18 @@ func run(
 			bufgen.GenerateWithIncludeWellKnownTypes(),
 		)
 	}
+	if flags.GracePeriod > 0 {
+		generateOptions = append(
+			generateOptions,
+			bufgen.GenerateWithPluginGracePeriod(flags.GracePeriod),
+		)
+	}
+	if errorPolicy != 0 {
+		generateOptions = append(
+			generateOptions,
+			bufgen.GenerateWithErrorPolicy(errorPolicy),
+		)
+	}
 	var includedTypes []string
 	if len(flags.Types) > 0 || len(flags.TypesDeprecated) > 0 {
 		// command-line flags take precedence
@@ -17,18 +17,24 @@ package lint
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/buf/buffetch"
+	"github.com/bufbuild/buf/private/buf/bufwire"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulegoogleapis"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/diff/diffparse"
 	"github.com/bufbuild/buf/private/pkg/stringutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"go.uber.org/zap"
 )
 
 const (
@@ -37,6 +43,10 @@ const (
 	pathsFlagName           = "path"
 	excludePathsFlagName    = "exclude-path"
 	disableSymlinksFlagName = "disable-symlinks"
+	moduleTagsFlagName      = "module-tags"
+	stdinDiffFlagName       = "stdin-diff"
+	againstFlagName         = "against"
+	againstConfigFlagName   = "against-config"
 )
 
 // NewCommand returns a new Command.
@@ -66,6 +76,10 @@ type flags struct {
 	Paths           []string
 	ExcludePaths    []string
 	DisableSymlinks bool
+	ModuleTags      []string
+	StdinDiff       bool
+	Against         string
+	AgainstConfig   string
 	// special
 	InputHashtag string
 }
@@ -79,6 +93,8 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindPaths(flagSet, &f.Paths, pathsFlagName)
 	bufcli.BindExcludePaths(flagSet, &f.ExcludePaths, excludePathsFlagName)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
+	bufcli.BindModuleTags(flagSet, &f.ModuleTags, moduleTagsFlagName)
+	bufcli.BindStdinDiff(flagSet, &f.StdinDiff, stdinDiffFlagName)
 	flagSet.StringVar(
 		&f.ErrorFormat,
 		errorFormatFlagName,
@@ -94,6 +110,21 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		"",
 		`The buf.yaml file or data to use for configuration`,
 	)
+	flagSet.StringVar(
+		&f.Against,
+		againstFlagName,
+		"",
+		fmt.Sprintf(
+			`The source, module, or image with the previous version of the input, such as the latest commit of a module on the BSR. Rules that check the history of the input, such as FIELD_REMOVED_RESERVED, only report violations if this is set. Must be one of format %s`,
+			buffetch.AllFormatsString,
+		),
+	)
+	flagSet.StringVar(
+		&f.AgainstConfig,
+		againstConfigFlagName,
+		"",
+		`The buf.yaml file or data to use to configure the against source, module, or image`,
+	)
 }
 
 func run(
@@ -108,7 +139,18 @@ func run(
 	if err != nil {
 		return err
 	}
-	ref, err := buffetch.NewRefParser(container.Logger()).GetRef(ctx, input)
+	var changedLines *diffparse.ChangedLines
+	if flags.StdinDiff {
+		changedLines, err = bufcli.ReadStdinDiff(container, stdinDiffFlagName, input)
+		if err != nil {
+			return err
+		}
+	}
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return err
+	}
+	ref, err := refParser.GetRef(ctx, input)
 	if err != nil {
 		return err
 	}
@@ -136,31 +178,46 @@ func run(
 		flags.ExcludePaths, // we exclude these paths
 		false,              // input files must exist
 		false,              // we must include source info for linting
+		bufwire.GetImageConfigsWithModuleTags(flags.ModuleTags),
 	)
 	if err != nil {
 		return err
 	}
 	if len(fileAnnotations) > 0 {
-		formatString := flags.ErrorFormat
-		if formatString == "config-ignore-yaml" {
-			formatString = "text"
-		}
-		if err := bufanalysis.PrintFileAnnotations(container.Stdout(), fileAnnotations, formatString); err != nil {
+		return printBuildFileAnnotations(container, fileAnnotations, flags.ErrorFormat)
+	}
+	var checkOptions []buflint.CheckOption
+	if flags.Against != "" {
+		againstImage, fileAnnotations, err := getAgainstImage(ctx, container, refParser, imageConfigReader, flags)
+		if err != nil {
 			return err
 		}
-		return bufcli.ErrFileAnnotation
+		if len(fileAnnotations) > 0 {
+			return printBuildFileAnnotations(container, fileAnnotations, flags.ErrorFormat)
+		}
+		checkOptions = append(checkOptions, buflint.CheckWithAgainstImage(againstImage))
+	}
+	if buffetch.IsLocalRef(ref) {
+		// The external paths of the files of local inputs are paths on the local
+		// filesystem, so the fixes of the annotations can be resolved against them.
+		checkOptions = append(checkOptions, buflint.CheckWithReadFileFunc(readLocalFile))
 	}
 	var allFileAnnotations []bufanalysis.FileAnnotation
 	for _, imageConfig := range imageConfigs {
+		warnAutoGoogleapisImports(container.Logger(), imageConfig)
 		fileAnnotations, err := buflint.NewHandler(container.Logger()).Check(
 			ctx,
 			imageConfig.Config().Lint,
 			imageConfig.Image(),
+			checkOptions...,
 		)
 		if err != nil {
 			return err
 		}
-		allFileAnnotations = append(allFileAnnotations, fileAnnotations...)
+		allFileAnnotations = append(allFileAnnotations, bufwire.MapFileAnnotationExternalPaths(ctx, imageConfig, fileAnnotations)...)
+	}
+	if changedLines != nil {
+		allFileAnnotations = bufanalysis.FilterFileAnnotationsForChangedLines(allFileAnnotations, *changedLines)
 	}
 	if len(allFileAnnotations) > 0 {
 		if err := buflintconfig.PrintFileAnnotations(
@@ -170,7 +227,100 @@ func run(
 		); err != nil {
 			return err
 		}
-		return bufcli.ErrFileAnnotation
+		return bufcli.ErrLintViolation
 	}
 	return nil
 }
+
+// readLocalFile reads the file at the external path of the FileInfo.
+func readLocalFile(_ context.Context, fileInfo bufanalysis.FileInfo) ([]byte, error) {
+	return os.ReadFile(fileInfo.ExternalPath())
+}
+
+// getAgainstImage returns the images of the against input merged into one image,
+// or the build errors of the against input.
+func getAgainstImage(
+	ctx context.Context,
+	container appflag.Container,
+	refParser buffetch.RefParser,
+	imageConfigReader bufwire.ImageConfigReader,
+	flags *flags,
+) (bufimage.Image, []bufanalysis.FileAnnotation, error) {
+	againstRef, err := refParser.GetRef(ctx, flags.Against)
+	if err != nil {
+		return nil, nil, err
+	}
+	againstImageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		againstRef,
+		flags.AgainstConfig,
+		flags.Paths,        // we filter checks for files
+		flags.ExcludePaths, // we exclude these paths
+		true,               // files are allowed to not exist on the against input
+		true,               // no need to include source info for against
+		// The against input is only a workspace if it is the same workspace at another commit.
+		bufwire.GetImageConfigsWithModuleTagsIfWorkspace(flags.ModuleTags),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fileAnnotations) > 0 {
+		return nil, fileAnnotations, nil
+	}
+	againstImages := make([]bufimage.Image, 0, len(againstImageConfigs))
+	for _, againstImageConfig := range againstImageConfigs {
+		againstImages = append(againstImages, againstImageConfig.Image())
+	}
+	againstImage, err := bufimage.MergeImages(againstImages...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return againstImage, nil, nil
+}
+
+// printBuildFileAnnotations prints the build errors of an input.
+func printBuildFileAnnotations(
+	container appflag.Container,
+	fileAnnotations []bufanalysis.FileAnnotation,
+	errorFormat string,
+) error {
+	if errorFormat == "config-ignore-yaml" {
+		errorFormat = "text"
+	}
+	if err := bufanalysis.PrintFileAnnotations(container.Stdout(), fileAnnotations, errorFormat); err != nil {
+		return err
+	}
+	return bufcli.ErrFileAnnotation
+}
+
+// warnAutoGoogleapisImports notes the imports of the image that are satisfied by the
+// automatic googleapis dependency, so that the dependency can be declared explicitly.
+func warnAutoGoogleapisImports(logger *zap.Logger, imageConfig bufwire.ImageConfig) {
+	buildConfig := imageConfig.Config().Build
+	if !buildConfig.AutoGoogleapis || bufmodulegoogleapis.IsDeclared(buildConfig.DependencyModuleReferences) {
+		return
+	}
+	var importPaths []string
+	var moduleIdentityString string
+	for _, imageFile := range imageConfig.Image().Files() {
+		if !imageFile.IsImport() ||
+			!bufmodulegoogleapis.IsImportPath(imageFile.Path()) ||
+			!bufmodulegoogleapis.IsModuleIdentity(imageFile.ModuleIdentity()) {
+			continue
+		}
+		importPaths = append(importPaths, imageFile.Path())
+		moduleIdentityString = imageFile.ModuleIdentity().IdentityString()
+	}
+	if len(importPaths) == 0 {
+		return
+	}
+	logger.Warn(
+		fmt.Sprintf(
+			`Imports of %s are satisfied by %s because build.auto_googleapis is set. Add %s to deps to declare the dependency explicitly.`,
+			stringutil.SliceToHumanStringQuoted(importPaths),
+			moduleIdentityString,
+			moduleIdentityString,
+		),
+	)
+}
@@ -16,6 +16,7 @@ package modclearcache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,11 +24,17 @@ import (
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/app/appprompt"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
+const (
+	yesFlagName            = "yes"
+	nonInteractiveFlagName = "non-interactive"
+)
+
 // NewCommand returns a new Command.
 func NewCommand(
 	name string,
@@ -39,7 +46,10 @@ func NewCommand(
 		Use:     name,
 		Aliases: aliases,
 		Short:   "Clear Buf module cache",
-		Args:    cobra.NoArgs,
+		Long: `Clear the Buf module cache.
+
+If stdin is a terminal, confirmation is asked for before the cache is cleared, unless --yes is set.`,
+		Args: cobra.NoArgs,
 		Run: builder.NewRunFunc(
 			func(ctx context.Context, container appflag.Container) error {
 				return run(ctx, container, flags)
@@ -49,19 +59,40 @@ func NewCommand(
 	}
 }
 
-type flags struct{}
+type flags struct {
+	Yes            bool
+	NonInteractive bool
+}
 
 func newFlags() *flags {
 	return &flags{}
 }
 
-func (f *flags) Bind(flagSet *pflag.FlagSet) {}
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	bufcli.BindYes(flagSet, &f.Yes, yesFlagName)
+	bufcli.BindNonInteractive(flagSet, &f.NonInteractive, nonInteractiveFlagName)
+}
 
 func run(
 	ctx context.Context,
 	container appflag.Container,
 	flags *flags,
 ) error {
+	confirmed, err := bufcli.NewPrompter(container, flags.Yes, flags.NonInteractive).Confirm(
+		fmt.Sprintf("Clear the module cache in %q?", container.CacheDirPath()),
+	)
+	if err != nil {
+		// The cache has always been cleared without confirmation when not
+		// running in a terminal, so we only prompt if we can.
+		if !errors.Is(err, appprompt.ErrNotATTY) {
+			return err
+		}
+		confirmed = true
+	}
+	if !confirmed {
+		_, err := container.Stderr().Write([]byte("module cache not cleared\n"))
+		return err
+	}
 	for _, cacheModuleRelDirPath := range bufcli.AllCacheModuleRelDirPaths {
 		dirPath := filepath.Join(container.CacheDirPath(), normalpath.Unnormalize(cacheModuleRelDirPath))
 		fileInfo, err := os.Stat(dirPath)
@@ -24,6 +24,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
 	"github.com/bufbuild/buf/private/bufpkg/buflock"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulegoogleapis"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
@@ -91,6 +92,16 @@ func run(
 	if err != nil {
 		return err
 	}
+	if config.Build.AutoGoogleapis {
+		// Keep the googleapis pin added by mod update if the module still needs it.
+		autoGoogleapisReference, err := autoGoogleapisReferencePinnedByLock(ctx, module)
+		if err != nil {
+			return err
+		}
+		if autoGoogleapisReference != nil {
+			requestReferences = append(requestReferences, autoGoogleapisReference)
+		}
+	}
 	var dependencyModulePins []bufmoduleref.ModulePin
 	if len(requestReferences) > 0 {
 		var (
@@ -103,7 +114,7 @@ func run(
 		} else {
 			// At this point we know there's at least one dependency. If it's an unnamed module, select
 			// the right remote from the list of dependencies.
-			selectedRef := bufcli.SelectReferenceForRemote(config.Build.DependencyModuleReferences)
+			selectedRef := bufcli.SelectReferenceForRemote(requestReferences)
 			if selectedRef == nil {
 				return fmt.Errorf(`File %q has invalid "deps" references`, existingConfigFilePath)
 			}
@@ -146,6 +157,32 @@ func run(
 	return nil
 }
 
+// autoGoogleapisReferencePinnedByLock returns the googleapis module reference, set to the
+// commit of its pin, if the module imports the common googleapis files without declaring
+// the dependency.
+//
+// Returns nil if the module does not need the googleapis module, or if it is not pinned.
+func autoGoogleapisReferencePinnedByLock(ctx context.Context, module bufmodule.Module) (bufmoduleref.ModuleReference, error) {
+	importPaths, err := bufmodulegoogleapis.GetUndeclaredImportPaths(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	if len(importPaths) == 0 {
+		return nil, nil
+	}
+	for _, modulePin := range module.DependencyModulePins() {
+		if bufmodulegoogleapis.IsModuleIdentity(modulePin) {
+			return bufmoduleref.NewModuleReference(
+				modulePin.Remote(),
+				modulePin.Owner(),
+				modulePin.Repository(),
+				modulePin.Commit(),
+			)
+		}
+	}
+	return nil, nil
+}
+
 // referencesPinnedByLock takes moduleReferences and a list of pins, then
 // returns a new list of moduleReferences with the same identity, but their
 // reference set to the commit of the pin with the corresponding identity.
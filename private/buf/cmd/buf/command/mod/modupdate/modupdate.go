@@ -24,6 +24,8 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
 	"github.com/bufbuild/buf/private/bufpkg/buflock"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulegoogleapis"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	modulev1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/module/v1alpha1"
@@ -112,6 +114,11 @@ func run(
 	if err != nil {
 		return err
 	}
+	if moduleConfig.Build.AutoGoogleapis {
+		if err := addAutoGoogleapisDependency(ctx, container, moduleConfig, readWriteBucket, existingConfigFilePath); err != nil {
+			return err
+		}
+	}
 	clientConfig, err := bufcli.NewConnectClientConfig(container)
 	if err != nil {
 		return bufcli.NewInternalError(err)
@@ -203,6 +210,60 @@ func run(
 	return nil
 }
 
+// addAutoGoogleapisDependency adds the googleapis module to the dependencies of the moduleConfig
+// if the module imports the common googleapis files without declaring the dependency.
+//
+// The googleapis module is resolved on the remote of the module, or the default remote if the
+// module is unnamed.
+func addAutoGoogleapisDependency(
+	ctx context.Context,
+	container appflag.Container,
+	moduleConfig *bufconfig.Config,
+	readBucket storage.ReadBucket,
+	existingConfigFilePath string,
+) error {
+	// Build the module as the other commands do, so that files outside of the roots
+	// or within the excludes do not add the dependency.
+	builtModule, err := bufmodulebuild.NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		readBucket,
+		moduleConfig.Build,
+	)
+	if err != nil {
+		return err
+	}
+	importPaths, err := bufmodulegoogleapis.GetUndeclaredImportPaths(ctx, builtModule)
+	if err != nil {
+		return err
+	}
+	if len(importPaths) == 0 {
+		return nil
+	}
+	remote := bufconnect.DefaultRemote
+	if moduleConfig.ModuleIdentity != nil && moduleConfig.ModuleIdentity.Remote() != "" {
+		remote = moduleConfig.ModuleIdentity.Remote()
+	}
+	moduleReference, err := bufmodulegoogleapis.NewModuleReference(remote)
+	if err != nil {
+		return bufcli.NewInternalError(err)
+	}
+	moduleConfig.Build.DependencyModuleReferences = append(
+		moduleConfig.Build.DependencyModuleReferences,
+		moduleReference,
+	)
+	container.Logger().Warn(
+		fmt.Sprintf(
+			`Imports of %s are satisfied by %s because build.auto_googleapis is set in %q. Add %s to deps in %q to declare the dependency explicitly.`,
+			stringutil.SliceToHumanStringQuoted(importPaths),
+			moduleReference.IdentityString(),
+			existingConfigFilePath,
+			moduleReference.IdentityString(),
+			existingConfigFilePath,
+		),
+	)
+	return nil
+}
+
 func getDependencies(
 	ctx context.Context,
 	clientConfig *connectclient.Config,
@@ -15,20 +15,27 @@
 package push
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/buf/bufcli"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas/bufcasalpha"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
 	"github.com/bufbuild/buf/private/bufpkg/buflock"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appflag"
 	"github.com/bufbuild/buf/private/pkg/command"
@@ -47,8 +54,17 @@ const (
 	disableSymlinksFlagName  = "disable-symlinks"
 	createFlagName           = "create"
 	createVisibilityFlagName = "create-visibility"
+	verifyFlagName           = "verify"
 	// deprecated
 	trackFlagName = "track"
+
+	// pushMaxAttempts is the maximum number of times a push is attempted when the
+	// registry returns a transient error.
+	pushMaxAttempts = 5
+	// pushInitialBackoff is the delay before the first retry. The delay is doubled
+	// on each subsequent retry up to pushMaxBackoff.
+	pushInitialBackoff = 500 * time.Millisecond
+	pushMaxBackoff     = 8 * time.Second
 )
 
 // NewCommand returns a new Command.
@@ -80,6 +96,7 @@ type flags struct {
 	DisableSymlinks  bool
 	Create           bool
 	CreateVisibility string
+	Verify           bool
 	// Deprecated
 	Tracks []string
 	// special
@@ -94,13 +111,17 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 	bufcli.BindInputHashtag(flagSet, &f.InputHashtag)
 	bufcli.BindDisableSymlinks(flagSet, &f.DisableSymlinks, disableSymlinksFlagName)
 	bufcli.BindCreateVisibility(flagSet, &f.CreateVisibility, createVisibilityFlagName, createFlagName)
-	flagSet.StringSliceVarP(
+	// Tags are not split on commas, as templates may contain commas.
+	flagSet.StringArrayVarP(
 		&f.Tags,
 		tagFlagName,
 		tagFlagShortName,
 		nil,
 		fmt.Sprintf(
-			"Create a tag for the pushed commit. Multiple tags are created if specified multiple times. Cannot be used together with --%s",
+			"Create a tag for the pushed commit. Multiple tags are created if specified multiple times. "+
+				"Tags may be Go templates using {{.Date}}, {{.Timestamp}}, {{.GitCommit}}, {{.GitShortCommit}}, {{.GitDescribe}}, "+
+				"or {{env \"NAME\"}}, for example --%s 'v1-{{.Date}}'. Cannot be used together with --%s",
+			tagFlagName,
 			draftFlagName,
 		),
 	)
@@ -131,7 +152,8 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		errorFormatFlagName,
 		"text",
 		fmt.Sprintf(
-			"The format for build errors printed to stderr. Must be one of %s",
+			"The format for build errors and --%s check failures printed to stderr. Must be one of %s",
+			verifyFlagName,
 			stringutil.SliceToString(bufanalysis.AllFormatStrings),
 		),
 	)
@@ -141,6 +163,13 @@ func (f *flags) Bind(flagSet *pflag.FlagSet) {
 		false,
 		fmt.Sprintf("Create the repository if it does not exist. Must set a visibility using --%s", createVisibilityFlagName),
 	)
+	flagSet.BoolVar(
+		&f.Verify,
+		verifyFlagName,
+		false,
+		"Before pushing, build the module, run the configured lint checks, and run the configured breaking change checks "+
+			"against the latest commit of the module on the registry. Nothing is pushed if the module does not build or any check fails",
+	)
 	flagSet.StringSliceVar(
 		&f.Tracks,
 		trackFlagName,
@@ -190,6 +219,11 @@ func run(
 	}
 	storageosProvider := bufcli.NewStorageosProvider(flags.DisableSymlinks)
 	runner := command.NewRunner()
+	tags, err := expandTagTemplates(ctx, container, runner, sourceDirPath(source), flags.Tags)
+	if err != nil {
+		return err
+	}
+	flags.Tags = tags
 	// We are pushing to the BSR, this module has to be independently buildable
 	// given the configuration it has without any enclosing workspace.
 	sourceBucket, sourceConfig, err := bufcli.BucketAndConfigForSource(
@@ -215,6 +249,18 @@ func run(
 	if err != nil {
 		return err
 	}
+	if flags.Verify {
+		fileAnnotations, err := verify(ctx, container, storageosProvider, runner, sourceConfig, builtModule)
+		if err != nil {
+			return err
+		}
+		if len(fileAnnotations) > 0 {
+			if err := bufanalysis.PrintFileAnnotations(container.Stderr(), fileAnnotations, flags.ErrorFormat); err != nil {
+				return err
+			}
+			return bufcli.ErrFileAnnotation
+		}
+	}
 	modulePin, err := pushOrCreate(ctx, container, moduleIdentity, builtModule, flags)
 	if err != nil {
 		if connect.CodeOf(err) == connect.CodeAlreadyExists {
@@ -283,26 +329,178 @@ func push(
 	if err != nil {
 		return nil, err
 	}
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	if err != nil {
+		return nil, err
+	}
 	draftOrBranchName := flags.Draft
 	if draftOrBranchName == "" {
 		// If draft is not set, then we we set the draft name to branch.
 		draftOrBranchName = flags.Branch
 	}
-	resp, err := service.PushManifestAndBlobs(
+	var totalBytes int
+	for _, protoBlob := range protoBlobs {
+		totalBytes += len(protoBlob.Content)
+	}
+	container.VerbosePrinter().Printf(
+		"pushing %d blobs (%d bytes) to %s",
+		len(protoBlobs),
+		totalBytes,
+		moduleIdentity.IdentityString(),
+	)
+	request := &registryv1alpha1.PushManifestAndBlobsRequest{
+		Owner:      moduleIdentity.Owner(),
+		Repository: moduleIdentity.Repository(),
+		Manifest:   protoManifestBlob,
+		Blobs:      protoBlobs,
+		Tags:       flags.Tags,
+		DraftName:  draftOrBranchName,
+	}
+	// The manifest and all blobs are sent in a single PushManifestAndBlobs request.
+	// PushService has no RPC to ask the registry which blobs it already has or to
+	// upload blobs separately, so an interrupted push cannot be resumed and the whole
+	// request is sent again on retry. Blobs are content-addressed, so re-sending the
+	// same request after a transient failure is safe.
+	//
+	// PushManifestAndBlobsRequest has no idempotency field, so every attempt of this push
+	// carries the same idempotency key header for registries that support it. The key is
+	// new for every invocation, so that a later push of the same content is not mistaken
+	// for a retry. A registry that does not support the header may still have created the
+	// commit on an attempt whose response was lost, in which case a retry fails with
+	// AlreadyExists and we resolve the existing commit instead.
+	idempotencyKey, err := bufconnect.NewIdempotencyKey()
+	if err != nil {
+		return nil, err
+	}
+	backoff := pushInitialBackoff
+	for attempt := 1; ; attempt++ {
+		connectRequest := connect.NewRequest(request)
+		connectRequest.Header().Set(bufconnect.IdempotencyKeyHeaderName, idempotencyKey)
+		resp, err := service.PushManifestAndBlobs(ctx, connectRequest)
+		if err == nil {
+			return resp.Msg.LocalModulePin, nil
+		}
+		if attempt > 1 && connect.CodeOf(err) == connect.CodeAlreadyExists {
+			modulePin, resolveErr := resolvePushedCommit(
+				ctx,
+				clientConfig,
+				moduleIdentity,
+				pushedReference(draftOrBranchName, flags.Tags),
+				manifestBlob.Digest(),
+			)
+			if resolveErr != nil {
+				return nil, resolveErr
+			}
+			if modulePin != nil {
+				container.VerbosePrinter().Printf(
+					"push attempt %d of %d found commit %s created by a previous attempt",
+					attempt,
+					pushMaxAttempts,
+					modulePin.Commit,
+				)
+				return modulePin, nil
+			}
+			return nil, err
+		}
+		if attempt >= pushMaxAttempts || !isRetryablePushError(err) {
+			return nil, err
+		}
+		container.VerbosePrinter().Printf(
+			"push attempt %d of %d failed: %v, retrying in %v",
+			attempt,
+			pushMaxAttempts,
+			err,
+			backoff,
+		)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > pushMaxBackoff {
+			backoff = pushMaxBackoff
+		}
+	}
+}
+
+// resolvePushedCommit returns the pin of the commit that reference resolves to, if
+// the content of that commit has the given manifest digest.
+//
+// This is used when a retried push fails with AlreadyExists, to check whether the
+// commit was created by a previous attempt of the same push. If the commit has other
+// content, nil is returned.
+func resolvePushedCommit(
+	ctx context.Context,
+	clientConfig *connectclient.Config,
+	moduleIdentity bufmoduleref.ModuleIdentity,
+	reference string,
+	manifestDigest bufcas.Digest,
+) (*registryv1alpha1.LocalModulePin, error) {
+	service := connectclient.Make(clientConfig, moduleIdentity.Remote(), registryv1alpha1connect.NewRepositoryCommitServiceClient)
+	resp, err := service.GetRepositoryCommitByReference(
 		ctx,
-		connect.NewRequest(&registryv1alpha1.PushManifestAndBlobsRequest{
-			Owner:      moduleIdentity.Owner(),
-			Repository: moduleIdentity.Repository(),
-			Manifest:   protoManifestBlob,
-			Blobs:      protoBlobs,
-			Tags:       flags.Tags,
-			DraftName:  draftOrBranchName,
-		}),
+		connect.NewRequest(
+			&registryv1alpha1.GetRepositoryCommitByReferenceRequest{
+				RepositoryOwner: moduleIdentity.Owner(),
+				RepositoryName:  moduleIdentity.Repository(),
+				Reference:       reference,
+			},
+		),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Msg.LocalModulePin, nil
+	return pushedModulePin(moduleIdentity, resp.Msg.RepositoryCommit, manifestDigest), nil
+}
+
+// pushedReference returns the reference that a push with the given draft or branch name
+// and tags moves. An empty reference resolves to the default branch.
+func pushedReference(draftOrBranchName string, tags []string) string {
+	if draftOrBranchName != "" {
+		return draftOrBranchName
+	}
+	if len(tags) > 0 {
+		return tags[0]
+	}
+	return ""
+}
+
+// pushedModulePin returns the pin for repositoryCommit if it has the given manifest digest,
+// and nil otherwise.
+func pushedModulePin(
+	moduleIdentity bufmoduleref.ModuleIdentity,
+	repositoryCommit *registryv1alpha1.RepositoryCommit,
+	manifestDigest bufcas.Digest,
+) *registryv1alpha1.LocalModulePin {
+	if repositoryCommit == nil || repositoryCommit.ManifestDigest == "" {
+		return nil
+	}
+	commitManifestDigest, err := bufcas.ParseDigest(repositoryCommit.ManifestDigest)
+	if err != nil || !bufcas.DigestEqual(commitManifestDigest, manifestDigest) {
+		return nil
+	}
+	return &registryv1alpha1.LocalModulePin{
+		Owner:          moduleIdentity.Owner(),
+		Repository:     moduleIdentity.Repository(),
+		Commit:         repositoryCommit.Name,
+		ManifestDigest: repositoryCommit.ManifestDigest,
+	}
+}
+
+// isRetryablePushError returns true if the error returned from the registry is
+// transient and the push should be retried.
+//
+// ResourceExhausted is returned when the client is rate limited.
+func isRetryablePushError(err error) bool {
+	switch connect.CodeOf(err) {
+	case connect.CodeUnavailable, connect.CodeResourceExhausted, connect.CodeAborted:
+		return true
+	default:
+		return false
+	}
 }
 
 func create(
@@ -330,3 +528,134 @@ func create(
 	}
 	return err
 }
+
+// expandTagTemplates executes each tag as a Go template and returns the unique
+// resulting tags in the order they were given.
+//
+// Tags that do not contain a template action are returned as-is. Git values are
+// computed in gitDirPath, or in the current working directory if gitDirPath is empty.
+func expandTagTemplates(
+	ctx context.Context,
+	container appflag.Container,
+	runner command.Runner,
+	gitDirPath string,
+	tags []string,
+) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	data := newTagTemplateData(ctx, container, runner, gitDirPath)
+	expandedTags := make([]string, 0, len(tags))
+	seenTags := make(map[string]struct{}, len(tags))
+	addTag := func(tag string) {
+		if _, ok := seenTags[tag]; ok {
+			return
+		}
+		seenTags[tag] = struct{}{}
+		expandedTags = append(expandedTags, tag)
+	}
+	for _, tag := range tags {
+		if !strings.Contains(tag, "{{") {
+			addTag(tag)
+			continue
+		}
+		tmpl, err := template.New(tag).Funcs(
+			template.FuncMap{
+				"env": container.Env,
+			},
+		).Parse(tag)
+		if err != nil {
+			return nil, appcmd.NewInvalidArgumentErrorf("invalid --%s template %q: %v", tagFlagName, tag, err)
+		}
+		buffer := bytes.NewBuffer(nil)
+		if err := tmpl.Execute(buffer, data); err != nil {
+			return nil, appcmd.NewInvalidArgumentErrorf("could not execute --%s template %q: %v", tagFlagName, tag, err)
+		}
+		expandedTag := strings.TrimSpace(buffer.String())
+		if expandedTag == "" {
+			return nil, appcmd.NewInvalidArgumentErrorf("--%s template %q resulted in an empty tag", tagFlagName, tag)
+		}
+		addTag(expandedTag)
+	}
+	return expandedTags, nil
+}
+
+// sourceDirPath returns the local directory of the source, or empty if the
+// source is not a local directory.
+func sourceDirPath(source string) string {
+	path, _, _ := strings.Cut(source, "#")
+	fileInfo, err := os.Stat(path)
+	if err != nil || !fileInfo.IsDir() {
+		return ""
+	}
+	return path
+}
+
+// tagTemplateData is the data made available to --tag templates.
+//
+// Git values are only computed if the template references them. Git is run in
+// the source directory if the source is a local directory.
+type tagTemplateData struct {
+	ctx       context.Context
+	container appflag.Container
+	runner    command.Runner
+	dirPath   string
+	now       time.Time
+}
+
+func newTagTemplateData(
+	ctx context.Context,
+	container appflag.Container,
+	runner command.Runner,
+	dirPath string,
+) *tagTemplateData {
+	return &tagTemplateData{
+		ctx:       ctx,
+		container: container,
+		runner:    runner,
+		dirPath:   dirPath,
+		now:       time.Now().UTC(),
+	}
+}
+
+// Date returns the current UTC date in the form YYYY-MM-DD.
+func (d *tagTemplateData) Date() string {
+	return d.now.Format("2006-01-02")
+}
+
+// Timestamp returns the current UTC time in the form YYYYMMDDhhmmss.
+func (d *tagTemplateData) Timestamp() string {
+	return d.now.Format("20060102150405")
+}
+
+// GitCommit returns the full hash of the HEAD commit of the git repository containing the source.
+func (d *tagTemplateData) GitCommit() (string, error) {
+	return d.runGit("rev-parse", "HEAD")
+}
+
+// GitShortCommit returns the abbreviated hash of the HEAD commit of the git repository containing the source.
+func (d *tagTemplateData) GitShortCommit() (string, error) {
+	return d.runGit("rev-parse", "--short", "HEAD")
+}
+
+// GitDescribe returns the output of git describe --tags --always for the git repository containing the source.
+func (d *tagTemplateData) GitDescribe() (string, error) {
+	return d.runGit("describe", "--tags", "--always")
+}
+
+func (d *tagTemplateData) runGit(args ...string) (string, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	if err := d.runner.Run(
+		d.ctx,
+		"git",
+		command.RunWithArgs(args...),
+		command.RunWithEnv(app.EnvironMap(d.container)),
+		command.RunWithDir(d.dirPath),
+		command.RunWithStdout(stdout),
+		command.RunWithStderr(stderr),
+	); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/bufbuild/buf/private/buf/cmd/buf/internal/internaltesting"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas/bufcasalpha"
+	"github.com/bufbuild/buf/private/bufpkg/bufconnect"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
 	"github.com/bufbuild/buf/private/pkg/app"
@@ -77,6 +79,50 @@ func TestPushManifest(t *testing.T) {
 		"",
 		"bad request",
 	)
+	testPushManifest(
+		t,
+		"registry unavailable, successfully retry",
+		&registryv1alpha1.PushManifestAndBlobsResponse{
+			LocalModulePin: &registryv1alpha1.LocalModulePin{},
+		},
+		connect.NewError(connect.CodeUnavailable, errors.New("unavailable")),
+		false, // no --create flag
+		"",
+		"",
+	)
+	testPushManifest(
+		t,
+		"registry internal error, do not retry",
+		&registryv1alpha1.PushManifestAndBlobsResponse{
+			LocalModulePin: &registryv1alpha1.LocalModulePin{},
+		},
+		connect.NewError(connect.CodeInternal, errors.New("internal")),
+		false, // no --create flag
+		"",
+		"internal",
+	)
+}
+
+func TestPushIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	mock := newMockPushService(t)
+	mock.pushManifestResponse = &registryv1alpha1.PushManifestAndBlobsResponse{
+		LocalModulePin: &registryv1alpha1.LocalModulePin{},
+	}
+	mock.pushManifestResponseError = connect.NewError(connect.CodeUnavailable, errors.New("unavailable"))
+	server := createServer(t, mock, newMockRepositoryService(t))
+	files := map[string][]byte{
+		"buf.yaml":  bufYAML(t, server.URL, "owner", "repo"),
+		"foo.proto": nil,
+	}
+	// The first push is retried once, the second push of the same content is not retried.
+	require.NoError(t, appRun(t, files))
+	require.NoError(t, appRun(t, files))
+	idempotencyKeys := mock.IdempotencyKeys()
+	require.Len(t, idempotencyKeys, 3)
+	assert.NotEmpty(t, idempotencyKeys[0])
+	assert.Equal(t, idempotencyKeys[0], idempotencyKeys[1])
+	assert.NotEqual(t, idempotencyKeys[0], idempotencyKeys[2])
 }
 
 func TestPushManifestCreate(t *testing.T) {
@@ -143,6 +189,113 @@ func TestPushManifestIsSmallerBucket(t *testing.T) {
 	assert.Nil(t, manifest.GetDigest("baz.file"), "baz.file should not be pushed")
 }
 
+func TestPushManifestTagTemplates(t *testing.T) {
+	t.Parallel()
+	mock := newMockPushService(t)
+	mock.pushManifestResponse = &registryv1alpha1.PushManifestAndBlobsResponse{
+		LocalModulePin: &registryv1alpha1.LocalModulePin{},
+	}
+	server := createServer(t, mock, nil)
+	err := appRun(
+		t,
+		map[string][]byte{
+			"buf.yaml":  bufYAML(t, server.URL, "owner", "repo"),
+			"foo.proto": nil,
+		},
+		"--tag", "v1",
+		"--tag", "release-{{.Date}},rc",
+		"--tag", "v1",
+	)
+	require.NoError(t, err)
+	request := mock.PushManifestRequest()
+	require.NotNil(t, request)
+	// Tags are deduplicated, keep the order they were given in, and are not split on commas.
+	require.Len(t, request.Tags, 2)
+	assert.Equal(t, "v1", request.Tags[0])
+	assert.Contains(t, request.Tags[1], "release-")
+	assert.True(t, strings.HasSuffix(request.Tags[1], ",rc"))
+	assert.NotContains(t, request.Tags[1], "{{")
+}
+
+func TestPushManifestTagTemplatesInvalid(t *testing.T) {
+	t.Parallel()
+	mock := newMockPushService(t)
+	server := createServer(t, mock, nil)
+	err := appRun(
+		t,
+		map[string][]byte{
+			"buf.yaml":  bufYAML(t, server.URL, "owner", "repo"),
+			"foo.proto": nil,
+		},
+		"--tag", "{{.Unknown}}",
+	)
+	assert.ErrorContains(t, err, "could not execute --tag template")
+	assert.Nil(t, mock.PushManifestRequest())
+}
+
+func TestPushVerifyBuildFailure(t *testing.T) {
+	t.Parallel()
+	mock := newMockPushService(t)
+	mock.pushManifestResponse = &registryv1alpha1.PushManifestAndBlobsResponse{
+		LocalModulePin: &registryv1alpha1.LocalModulePin{},
+	}
+	server := createServer(t, mock, nil)
+	err := appRun(
+		t,
+		map[string][]byte{
+			"buf.yaml":  bufYAML(t, server.URL, "owner", "repo"),
+			"foo.proto": []byte("syntax = \"proto3\";\n\nmessage {}\n"),
+		},
+		"--verify",
+	)
+	assert.Error(t, err)
+	assert.Nil(t, mock.PushManifestRequest(), "nothing should be pushed if the module does not build")
+}
+
+func TestPushedModulePin(t *testing.T) {
+	t.Parallel()
+	moduleIdentity, err := bufmoduleref.NewModuleIdentity("buf.build", "foo", "bar")
+	require.NoError(t, err)
+	digest, err := bufcas.NewDigestForContent(strings.NewReader("foo"))
+	require.NoError(t, err)
+	otherDigest, err := bufcas.NewDigestForContent(strings.NewReader("bar"))
+	require.NoError(t, err)
+	modulePin := pushedModulePin(
+		moduleIdentity,
+		&registryv1alpha1.RepositoryCommit{
+			Name:           "1234",
+			ManifestDigest: digest.String(),
+		},
+		digest,
+	)
+	require.NotNil(t, modulePin)
+	assert.Equal(t, "foo", modulePin.Owner)
+	assert.Equal(t, "bar", modulePin.Repository)
+	assert.Equal(t, "1234", modulePin.Commit)
+	assert.Equal(t, digest.String(), modulePin.ManifestDigest)
+	// A commit with other content was not created by this push.
+	assert.Nil(
+		t,
+		pushedModulePin(
+			moduleIdentity,
+			&registryv1alpha1.RepositoryCommit{
+				Name:           "1234",
+				ManifestDigest: otherDigest.String(),
+			},
+			digest,
+		),
+	)
+	assert.Nil(t, pushedModulePin(moduleIdentity, &registryv1alpha1.RepositoryCommit{Name: "1234"}, digest))
+	assert.Nil(t, pushedModulePin(moduleIdentity, nil, digest))
+}
+
+func TestPushedReference(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "", pushedReference("", nil))
+	assert.Equal(t, "v1", pushedReference("", []string{"v1", "v2"}))
+	assert.Equal(t, "feature", pushedReference("feature", nil))
+}
+
 func TestBucketBlobs(t *testing.T) {
 	t.Parallel()
 	bucket, err := storagemem.NewReadBucket(
@@ -182,6 +335,7 @@ type mockPushService struct {
 	called int
 
 	pushManifestRequest       *registryv1alpha1.PushManifestAndBlobsRequest
+	idempotencyKeys           []string
 	pushManifestResponse      *registryv1alpha1.PushManifestAndBlobsResponse
 	pushManifestResponseError error
 }
@@ -209,6 +363,7 @@ func (m *mockPushService) PushManifestAndBlobs(
 	defer m.Unlock()
 	m.called++
 	m.pushManifestRequest = req.Msg
+	m.idempotencyKeys = append(m.idempotencyKeys, req.Header().Get(bufconnect.IdempotencyKeyHeaderName))
 	assert.NotNil(m.t, req.Msg.Manifest, "missing manifest")
 	resp := m.pushManifestResponse
 	if resp == nil {
@@ -222,6 +377,12 @@ func (m *mockPushService) PushManifestAndBlobs(
 	return connect.NewResponse(resp), nil
 }
 
+func (m *mockPushService) IdempotencyKeys() []string {
+	m.RLock()
+	defer m.RUnlock()
+	return m.idempotencyKeys
+}
+
 func (m *mockPushService) PushManifestRequest() *registryv1alpha1.PushManifestAndBlobsRequest {
 	m.RLock()
 	defer m.RUnlock()
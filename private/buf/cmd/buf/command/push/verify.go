@@ -0,0 +1,144 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package push
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmodulebuild"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+)
+
+// verify builds the module, and runs the configured lint checks and breaking change
+// checks against the latest commit of the module on the registry.
+//
+// Returns the file annotations of all checks. If the module does not build, only the
+// build file annotations are returned, as the checks cannot be run. Breaking change
+// checks are skipped if the repository does not exist or has no commits.
+func verify(
+	ctx context.Context,
+	container appflag.Container,
+	storageosProvider storageos.Provider,
+	runner command.Runner,
+	config *bufconfig.Config,
+	builtModule *bufmodulebuild.BuiltModule,
+) ([]bufanalysis.FileAnnotation, error) {
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	moduleReader, err := bufcli.NewModuleReaderAndCreateCacheDirs(container, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	image, fileAnnotations, err := bufimagebuild.NewBuilder(container.Logger(), moduleReader).Build(
+		ctx,
+		builtModule.Module,
+		bufimagebuild.WithExpectedDirectDependencies(builtModule.Module.DeclaredDirectDependencies()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(fileAnnotations) > 0 {
+		return fileAnnotations, nil
+	}
+	allFileAnnotations, err := buflint.NewHandler(container.Logger()).Check(ctx, config.Lint, image)
+	if err != nil {
+		return nil, err
+	}
+	againstImage, err := getRemoteHeadImage(ctx, container, storageosProvider, runner, config)
+	if err != nil {
+		return nil, err
+	}
+	if againstImage != nil {
+		fileAnnotations, err := bufbreaking.NewHandler(container.Logger()).Check(
+			ctx,
+			config.Breaking,
+			bufimage.ImageWithoutImports(againstImage),
+			bufimage.ImageWithoutImports(image),
+		)
+		if err != nil {
+			return nil, err
+		}
+		allFileAnnotations = append(allFileAnnotations, fileAnnotations...)
+	}
+	return bufanalysis.DeduplicateAndSortFileAnnotations(allFileAnnotations), nil
+}
+
+// getRemoteHeadImage builds the latest commit of the module on the registry.
+//
+// Returns nil if the repository does not exist or has no commits.
+func getRemoteHeadImage(
+	ctx context.Context,
+	container appflag.Container,
+	storageosProvider storageos.Provider,
+	runner command.Runner,
+	config *bufconfig.Config,
+) (bufimage.Image, error) {
+	moduleIdentityString := config.ModuleIdentity.IdentityString()
+	refParser, err := bufcli.NewRefParser(container)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := refParser.GetRef(ctx, moduleIdentityString)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	imageConfigReader, err := bufcli.NewWireImageConfigReader(container, storageosProvider, runner, clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	imageConfigs, fileAnnotations, err := imageConfigReader.GetImageConfigs(
+		ctx,
+		container,
+		ref,
+		"",
+		nil,
+		nil,
+		false,
+		true, // no need to include source info for against
+	)
+	if err != nil {
+		if connect.CodeOf(err) == connect.CodeNotFound {
+			container.Logger().Warn(
+				fmt.Sprintf("Skipping breaking change checks as %s has no commits.", moduleIdentityString),
+			)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read %s to check for breaking changes: %w", moduleIdentityString, err)
+	}
+	if len(fileAnnotations) > 0 {
+		return nil, fmt.Errorf("could not build %s to check for breaking changes", moduleIdentityString)
+	}
+	if len(imageConfigs) != 1 {
+		return nil, fmt.Errorf("expected 1 image for %s but got %d", moduleIdentityString, len(imageConfigs))
+	}
+	return imageConfigs[0].Image(), nil
+}
@@ -0,0 +1,335 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registrycommitdiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufprint"
+	"github.com/bufbuild/buf/private/buf/bufschemadiff"
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufapimodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufcas"
+	"github.com/bufbuild/buf/private/bufpkg/bufcas/bufcasalpha"
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/app/appcmd"
+	"github.com/bufbuild/buf/private/pkg/app/appflag"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	formatFlagName      = "format"
+	errorFormatFlagName = "error-format"
+
+	statusAdded    = "added"
+	statusRemoved  = "removed"
+	statusModified = "modified"
+)
+
+// NewCommand returns a new Command
+func NewCommand(
+	name string,
+	builder appflag.Builder,
+) *appcmd.Command {
+	flags := newFlags()
+	return &appcmd.Command{
+		Use:   name + " <buf.build/owner/repository:ref> <buf.build/owner/repository:ref>",
+		Short: "Show what changed between two commits of a module",
+		Long: `The first argument is the commit to compare from, and the second argument is the commit to compare to.
+
+Two diffs are printed. The file diff lists each added, removed, or modified file with its digests. Files are compared by digest, so only files whose content changed are listed.
+
+The schema diff lists each added, removed, or modified message, field, enum, enum value, service, method, and extension, matched by fully-qualified name. Only the files of the module itself are compared, not the files of its dependencies.`,
+		Args: cobra.ExactArgs(2),
+		Run: builder.NewRunFunc(
+			func(ctx context.Context, container appflag.Container) error {
+				return run(ctx, container, flags)
+			},
+			bufcli.NewErrorInterceptor(),
+		),
+		BindFlags: flags.Bind,
+	}
+}
+
+type flags struct {
+	Format      string
+	ErrorFormat string
+}
+
+func newFlags() *flags {
+	return &flags{}
+}
+
+func (f *flags) Bind(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(
+		&f.Format,
+		formatFlagName,
+		bufprint.FormatText.String(),
+		fmt.Sprintf(`The output format to use. Must be one of %s`, bufprint.AllFormatsString),
+	)
+	flagSet.StringVar(
+		&f.ErrorFormat,
+		errorFormatFlagName,
+		"text",
+		fmt.Sprintf(
+			"The format for build errors printed to stderr. Must be one of %s",
+			stringutil.SliceToString(bufanalysis.AllFormatStrings),
+		),
+	)
+}
+
+type output struct {
+	Files         []*outputFile           `json:"files,omitempty"`
+	SchemaChanges []*bufschemadiff.Change `json:"schema_changes,omitempty"`
+}
+
+type outputFile struct {
+	Path       string `json:"path,omitempty"`
+	Status     string `json:"status,omitempty"`
+	FromDigest string `json:"from_digest,omitempty"`
+	ToDigest   string `json:"to_digest,omitempty"`
+}
+
+func run(
+	ctx context.Context,
+	container appflag.Container,
+	flags *flags,
+) error {
+	if err := bufcli.ValidateErrorFormatFlag(flags.ErrorFormat, errorFormatFlagName); err != nil {
+		return err
+	}
+	fromModuleReference, err := bufmoduleref.ModuleReferenceForString(container.Arg(0))
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	toModuleReference, err := bufmoduleref.ModuleReferenceForString(container.Arg(1))
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	format, err := bufprint.ParseFormat(flags.Format)
+	if err != nil {
+		return appcmd.NewInvalidArgumentError(err.Error())
+	}
+	clientConfig, err := bufcli.NewConnectClientConfig(container)
+	if err != nil {
+		return err
+	}
+	downloadClientFactory := bufapimodule.NewDownloadServiceClientFactory(clientConfig)
+	fromManifest, err := getManifest(ctx, downloadClientFactory, fromModuleReference)
+	if err != nil {
+		return err
+	}
+	toManifest, err := getManifest(ctx, downloadClientFactory, toModuleReference)
+	if err != nil {
+		return err
+	}
+	fromFileDescriptors, err := getFileDescriptors(ctx, container, container.Arg(0), flags)
+	if err != nil {
+		return err
+	}
+	toFileDescriptors, err := getFileDescriptors(ctx, container, container.Arg(1), flags)
+	if err != nil {
+		return err
+	}
+	output := &output{
+		Files:         manifestDiffToOutputFiles(bufcas.DiffManifests(fromManifest, toManifest)),
+		SchemaChanges: bufschemadiff.Diff(fromFileDescriptors, toFileDescriptors),
+	}
+	switch format {
+	case bufprint.FormatText:
+		return printText(container.Stdout(), output)
+	case bufprint.FormatJSON:
+		return json.NewEncoder(container.Stdout()).Encode(output)
+	default:
+		return fmt.Errorf("unknown format: %v", format)
+	}
+}
+
+func getManifest(
+	ctx context.Context,
+	downloadClientFactory bufapimodule.DownloadServiceClientFactory,
+	moduleReference bufmoduleref.ModuleReference,
+) (bufcas.Manifest, error) {
+	downloadService := downloadClientFactory(moduleReference.Remote())
+	resp, err := downloadService.DownloadManifestAndBlobs(
+		ctx,
+		connect.NewRequest(&registryv1alpha1.DownloadManifestAndBlobsRequest{
+			Owner:      moduleReference.Owner(),
+			Repository: moduleReference.Repository(),
+			Reference:  moduleReference.Reference(),
+		}),
+	)
+	if err != nil {
+		if connect.CodeOf(err) == connect.CodeNotFound {
+			return nil, bufcli.NewModuleReferenceNotFoundError(moduleReference)
+		}
+		return nil, err
+	}
+	if resp.Msg.Manifest == nil {
+		return nil, errors.New("expected non-nil manifest")
+	}
+	return bufcasalpha.AlphaManifestBlobToManifest(resp.Msg.Manifest)
+}
+
+// getFileDescriptors returns the non-import files of the module the input references.
+func getFileDescriptors(
+	ctx context.Context,
+	container appflag.Container,
+	input string,
+	flags *flags,
+) ([]protoreflect.FileDescriptor, error) {
+	image, err := bufcli.NewImageForSource(
+		ctx,
+		container,
+		input,
+		flags.ErrorFormat,
+		false, // disableSymlinks
+		"",    // configOverride
+		nil,   // externalDirOrFilePaths
+		nil,   // externalExcludeDirOrFilePaths
+		false, // externalDirOrFilePathsAllowNotExist
+		true,  // excludeSourceCodeInfo
+	)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	if err != nil {
+		return nil, err
+	}
+	var fileDescriptors []protoreflect.FileDescriptor
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		fileDescriptor, err := resolver.FindFileByPath(imageFile.Path())
+		if err != nil {
+			return nil, err
+		}
+		fileDescriptors = append(fileDescriptors, fileDescriptor)
+	}
+	return fileDescriptors, nil
+}
+
+func manifestDiffToOutputFiles(manifestDiff *bufcas.ManifestDiff) []*outputFile {
+	outputFiles := make(
+		[]*outputFile,
+		0,
+		len(manifestDiff.Added)+len(manifestDiff.Removed)+len(manifestDiff.Modified),
+	)
+	for _, fileNode := range manifestDiff.Removed {
+		outputFiles = append(
+			outputFiles,
+			&outputFile{
+				Path:       fileNode.Path(),
+				Status:     statusRemoved,
+				FromDigest: fileNode.Digest().String(),
+			},
+		)
+	}
+	for _, modifiedFileNode := range manifestDiff.Modified {
+		outputFiles = append(
+			outputFiles,
+			&outputFile{
+				Path:       modifiedFileNode.Path,
+				Status:     statusModified,
+				FromDigest: modifiedFileNode.FromDigest.String(),
+				ToDigest:   modifiedFileNode.ToDigest.String(),
+			},
+		)
+	}
+	for _, fileNode := range manifestDiff.Added {
+		outputFiles = append(
+			outputFiles,
+			&outputFile{
+				Path:     fileNode.Path(),
+				Status:   statusAdded,
+				ToDigest: fileNode.Digest().String(),
+			},
+		)
+	}
+	sort.Slice(
+		outputFiles,
+		func(i int, j int) bool {
+			return outputFiles[i].Path < outputFiles[j].Path
+		},
+	)
+	return outputFiles
+}
+
+func printText(writer io.Writer, output *output) error {
+	if len(output.Files) > 0 {
+		if err := bufprint.WithTabWriter(
+			writer,
+			[]string{"Status", "Path", "From", "To"},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, outputFile := range output.Files {
+					if err := tabWriter.Write(
+						outputFile.Status,
+						outputFile.Path,
+						outputFile.FromDigest,
+						outputFile.ToDigest,
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		); err != nil {
+			return err
+		}
+	}
+	if len(output.SchemaChanges) > 0 {
+		if len(output.Files) > 0 {
+			if _, err := writer.Write([]byte("\n")); err != nil {
+				return err
+			}
+		}
+		if err := bufprint.WithTabWriter(
+			writer,
+			[]string{"Change", "Element", "Name", "Details"},
+			func(tabWriter bufprint.TabWriter) error {
+				for _, change := range output.SchemaChanges {
+					if err := tabWriter.Write(
+						string(change.Type),
+						string(change.ElementType),
+						change.Name,
+						strings.Join(change.Details, "; "),
+					); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
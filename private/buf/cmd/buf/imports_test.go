@@ -15,6 +15,7 @@
 package buf
 
 import (
+	"context"
 	"io"
 	"path/filepath"
 	"strings"
@@ -22,6 +23,9 @@ import (
 
 	"github.com/bufbuild/buf/private/pkg/app/appcmd"
 	"github.com/bufbuild/buf/private/pkg/app/appcmd/appcmdtesting"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/stretchr/testify/require"
 )
 
 func TestValidNoImports(t *testing.T) {
@@ -134,6 +138,14 @@ func TestGraphNoWarningsValidImportFromWorkspaceNamedModules(t *testing.T) {
 }
 
 func testRunStderrWithCache(t *testing.T, stdin io.Reader, expectedExitCode int, expectedStderrPartials []string, args ...string) {
+	// The cache is copied so that the lock files written by the command are not written to testdata.
+	cacheDirPath := t.TempDir()
+	readBucket, err := storageos.NewProvider().NewReadWriteBucket(filepath.Join("testdata", "imports", "cache"))
+	require.NoError(t, err)
+	writeBucket, err := storageos.NewProvider().NewReadWriteBucket(cacheDirPath)
+	require.NoError(t, err)
+	_, err = storage.Copy(context.Background(), readBucket, writeBucket)
+	require.NoError(t, err)
 	appcmdtesting.RunCommandExitCodeStderrContains(
 		t,
 		func(use string) *appcmd.Command { return NewRootCommand(use) },
@@ -141,7 +153,7 @@ func testRunStderrWithCache(t *testing.T, stdin io.Reader, expectedExitCode int,
 		expectedStderrPartials,
 		func(use string) map[string]string {
 			return map[string]string{
-				useEnvVar(use, "CACHE_DIR"): filepath.Join("testdata", "imports", "cache"),
+				useEnvVar(use, "CACHE_DIR"): cacheDirPath,
 			}
 		},
 		stdin,
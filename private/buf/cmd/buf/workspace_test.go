@@ -23,9 +23,11 @@ import (
 	"testing"
 
 	"github.com/bufbuild/buf/private/buf/bufcli"
+	"github.com/bufbuild/buf/private/buf/bufsnapshot"
 	"github.com/bufbuild/buf/private/pkg/osext"
 	"github.com/bufbuild/buf/private/pkg/storage/storagearchive"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -868,6 +870,85 @@ func TestWorkspaceBreakingFail(t *testing.T) {
 	)
 }
 
+func TestWorkspaceBreakingModuleTags(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	againstImagePath := filepath.Join(tempDirPath, "against.binpb")
+	testRunStdout(
+		t,
+		nil,
+		0,
+		``,
+		"build",
+		filepath.Join("testdata", "workspace", "success", "module_tags_previous"),
+		"-o",
+		againstImagePath,
+	)
+	// The module tags only apply to the input, as the against input is an image.
+	testRunStdout(
+		t,
+		nil,
+		bufcli.ExitCodeFileAnnotation,
+		filepath.FromSlash(`testdata/workspace/success/module_tags/a/a.proto:5:1:Previously present field "2" with name "two" on message "A" was deleted.`),
+		"breaking",
+		filepath.Join("testdata", "workspace", "success", "module_tags"),
+		"--against",
+		againstImagePath,
+		"--module-tags",
+		"public",
+	)
+	// The module tags apply to both inputs if the against input is the same workspace.
+	testRunStdout(
+		t,
+		nil,
+		0,
+		``,
+		"breaking",
+		filepath.Join("testdata", "workspace", "success", "module_tags"),
+		"--against",
+		filepath.Join("testdata", "workspace", "success", "module_tags"),
+		"--module-tags",
+		"internal",
+	)
+	// Without module tags, the workspace has more images than the against image.
+	testRunStdoutStderrNoWarn(
+		t,
+		nil,
+		1,
+		``,
+		`Failure: input contained 2 images, whereas against contained 1 images`,
+		"breaking",
+		filepath.Join("testdata", "workspace", "success", "module_tags"),
+		"--against",
+		againstImagePath,
+	)
+}
+
+func TestWorkspaceSnapshotCreateSubDir(t *testing.T) {
+	t.Parallel()
+	// The input is a module directory within the workspace, so the directory of the
+	// module and its configuration are read relative to the root of the workspace.
+	stdout := bytes.NewBuffer(nil)
+	testRun(
+		t,
+		0,
+		nil,
+		stdout,
+		"beta",
+		"snapshot",
+		"create",
+		filepath.Join("testdata", "workspace", "success", "dir", "proto"),
+	)
+	snapshot, err := bufsnapshot.ReadSnapshot(stdout)
+	require.NoError(t, err)
+	require.Len(t, snapshot.Modules, 1)
+	assert.Equal(t, "proto", snapshot.Modules[0].Directory)
+	assert.Equal(t, "buf.yaml", snapshot.Modules[0].ConfigPath)
+	config, err := os.ReadFile(filepath.Join("testdata", "workspace", "success", "dir", "proto", "buf.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(config), snapshot.Modules[0].Config)
+}
+
 func TestWorkspaceDuplicateFail(t *testing.T) {
 	t.Parallel()
 	// The workspace includes multiple images that define the same file.
@@ -876,7 +957,8 @@ func TestWorkspaceDuplicateFail(t *testing.T) {
 		nil,
 		1,
 		``,
-		filepath.FromSlash(`Failure: foo.proto exists in multiple locations: testdata/workspace/fail/duplicate/other/proto/foo.proto testdata/workspace/fail/duplicate/proto/foo.proto`),
+		filepath.FromSlash(`Failure: bar.proto exists in multiple modules: other/proto (testdata/workspace/fail/duplicate/other/proto/bar.proto), proto (testdata/workspace/fail/duplicate/proto/bar.proto)
+		foo.proto exists in multiple modules: other/proto (testdata/workspace/fail/duplicate/other/proto/foo.proto), proto (testdata/workspace/fail/duplicate/proto/foo.proto)`),
 		"build",
 		filepath.Join("testdata", "workspace", "fail", "duplicate"),
 	)
@@ -889,7 +971,8 @@ func TestWorkspaceDuplicateFailSpecificModule(t *testing.T) {
 		nil,
 		1,
 		``,
-		filepath.FromSlash(`Failure: foo.proto exists in multiple locations: testdata/workspace/fail/duplicate/other/proto/foo.proto testdata/workspace/fail/duplicate/proto/foo.proto`),
+		filepath.FromSlash(`Failure: bar.proto exists in multiple modules: other/proto (testdata/workspace/fail/duplicate/other/proto/bar.proto), proto (testdata/workspace/fail/duplicate/proto/bar.proto)
+		foo.proto exists in multiple modules: other/proto (testdata/workspace/fail/duplicate/other/proto/foo.proto), proto (testdata/workspace/fail/duplicate/proto/foo.proto)`),
 		"build",
 		filepath.Join("testdata", "workspace", "fail", "duplicate", "proto"),
 	)
@@ -21,6 +21,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/diff/diffparse"
 )
 
 const (
@@ -146,6 +148,36 @@ type FileAnnotation interface {
 	Type() string
 	// Message is the message of the annotation.
 	Message() string
+	// Fix is the machine-applicable fix for the annotation.
+	//
+	// This may be nil if no fix is known.
+	Fix() Fix
+}
+
+// Fix is a machine-applicable fix for a FileAnnotation.
+//
+// Applying a Fix replaces the bytes of the file from the start offset up to but not
+// including the end offset with the replacement text. Offsets are 0-based byte offsets
+// into the contents of the file. If the start and end offsets are equal, the replacement
+// text is inserted at the start offset. If the replacement text is empty, the bytes in
+// the range are deleted.
+type Fix interface {
+	StartOffset() int
+	EndOffset() int
+	Replacement() string
+}
+
+// NewFix returns a new Fix.
+func NewFix(
+	startOffset int,
+	endOffset int,
+	replacement string,
+) Fix {
+	return newFix(
+		startOffset,
+		endOffset,
+		replacement,
+	)
 }
 
 // NewFileAnnotation returns a new FileAnnotation.
@@ -169,6 +201,56 @@ func NewFileAnnotation(
 	)
 }
 
+// FileAnnotationWithFix returns a copy of the FileAnnotation with the Fix.
+func FileAnnotationWithFix(fileAnnotation FileAnnotation, fix Fix) FileAnnotation {
+	newFileAnnotation := newFileAnnotation(
+		fileAnnotation.FileInfo(),
+		fileAnnotation.StartLine(),
+		fileAnnotation.StartColumn(),
+		fileAnnotation.EndLine(),
+		fileAnnotation.EndColumn(),
+		fileAnnotation.Type(),
+		fileAnnotation.Message(),
+	)
+	newFileAnnotation.fix = fix
+	return newFileAnnotation
+}
+
+// MapFileAnnotationExternalPaths returns the FileAnnotations with external paths
+// mapped by externalPathForPath.
+//
+// Only FileAnnotations whose FileInfo has an ExternalPath equal to its Path are
+// mapped, as these were not resolved to a user-facing path when created. This is
+// the case for paths that do not exist within the bucket a module was read from.
+func MapFileAnnotationExternalPaths(
+	fileAnnotations []FileAnnotation,
+	externalPathForPath func(string) string,
+) []FileAnnotation {
+	if externalPathForPath == nil {
+		return fileAnnotations
+	}
+	mappedFileAnnotations := make([]FileAnnotation, len(fileAnnotations))
+	for i, fileAnnotation := range fileAnnotations {
+		fileInfo := fileAnnotation.FileInfo()
+		if fileInfo == nil || fileInfo.ExternalPath() != fileInfo.Path() {
+			mappedFileAnnotations[i] = fileAnnotation
+			continue
+		}
+		mappedFileAnnotation := newFileAnnotation(
+			newFileInfo(fileInfo.Path(), externalPathForPath(fileInfo.Path())),
+			fileAnnotation.StartLine(),
+			fileAnnotation.StartColumn(),
+			fileAnnotation.EndLine(),
+			fileAnnotation.EndColumn(),
+			fileAnnotation.Type(),
+			fileAnnotation.Message(),
+		)
+		mappedFileAnnotation.fix = fileAnnotation.Fix()
+		mappedFileAnnotations[i] = mappedFileAnnotation
+	}
+	return mappedFileAnnotations
+}
+
 // SortFileAnnotations sorts the FileAnnotations.
 //
 // The order of sorting is:
@@ -201,6 +283,40 @@ func DeduplicateAndSortFileAnnotations(fileAnnotations []FileAnnotation) []FileA
 	return deduplicated
 }
 
+// FilterFileAnnotationsForChangedLines returns the FileAnnotations that are
+// within the changed lines of a diff.
+//
+// FileAnnotations are matched to files in the diff by their external paths, and
+// to the hunks of the diff by their full spans. FileAnnotations on a message,
+// such as those for deleted fields, span the whole message, and are returned
+// if any line within the message changed.
+//
+// FileAnnotations without a FileInfo or without a location are always returned,
+// as are FileAnnotations for files that were deleted, as these cannot be matched
+// to the lines of the new versions of the files.
+func FilterFileAnnotationsForChangedLines(
+	fileAnnotations []FileAnnotation,
+	changedLines diffparse.ChangedLines,
+) []FileAnnotation {
+	var filtered []FileAnnotation
+	for _, fileAnnotation := range fileAnnotations {
+		fileInfo := fileAnnotation.FileInfo()
+		switch {
+		case fileInfo == nil,
+			fileAnnotation.StartLine() == 0,
+			changedLines.IsDeleted(fileInfo.ExternalPath()):
+			filtered = append(filtered, fileAnnotation)
+		case changedLines.Intersects(
+			fileInfo.ExternalPath(),
+			fileAnnotation.StartLine(),
+			fileAnnotation.EndLine(),
+		):
+			filtered = append(filtered, fileAnnotation)
+		}
+	}
+	return filtered
+}
+
 // PrintFileAnnotations prints the file annotations separated by newlines.
 func PrintFileAnnotations(writer io.Writer, fileAnnotations []FileAnnotation, formatString string) error {
 	format, err := ParseFormat(formatString)
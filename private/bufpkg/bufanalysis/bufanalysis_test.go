@@ -0,0 +1,136 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufanalysis
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/diff/diffparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintFileAnnotationsJSONWithFix(t *testing.T) {
+	t.Parallel()
+	fileInfo := testFileInfo{path: "foo/v1/foo.proto", externalPath: "proto/foo/v1/foo.proto"}
+	fileAnnotations := []FileAnnotation{
+		NewFileAnnotation(fileInfo, 3, 1, 3, 20, "IMPORT_USED", `Import "a.proto" is unused.`),
+		FileAnnotationWithFix(
+			NewFileAnnotation(fileInfo, 7, 10, 7, 16, "FIELD_LOWER_SNAKE_CASE", `Field name "fooBar" should be lower_snake_case, such as "foo_bar".`),
+			NewFix(112, 118, "foo_bar"),
+		),
+	}
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, PrintFileAnnotations(buffer, fileAnnotations, "json"))
+	assert.Equal(
+		t,
+		`{"path":"proto/foo/v1/foo.proto","start_line":3,"start_column":1,"end_line":3,"end_column":20,"type":"IMPORT_USED","message":"Import \"a.proto\" is unused."}
+{"path":"proto/foo/v1/foo.proto","start_line":7,"start_column":10,"end_line":7,"end_column":16,"type":"FIELD_LOWER_SNAKE_CASE","message":"Field name \"fooBar\" should be lower_snake_case, such as \"foo_bar\".","fix":{"start_offset":112,"end_offset":118,"replacement":"foo_bar"}}
+`,
+		buffer.String(),
+	)
+}
+
+func TestMapFileAnnotationExternalPaths(t *testing.T) {
+	t.Parallel()
+	mappedFileInfo := testFileInfo{path: "foo/v1/foo.proto", externalPath: "proto/foo/v1/foo.proto"}
+	unmappedFileInfo := testFileInfo{path: "bar/v1/bar.proto", externalPath: "bar/v1/bar.proto"}
+	fix := NewFix(0, 1, "")
+	fileAnnotations := MapFileAnnotationExternalPaths(
+		[]FileAnnotation{
+			NewFileAnnotation(nil, 0, 0, 0, 0, "COMPILE", "no file"),
+			NewFileAnnotation(mappedFileInfo, 1, 1, 1, 2, "FILE", "mapped"),
+			FileAnnotationWithFix(NewFileAnnotation(unmappedFileInfo, 1, 1, 1, 2, "FILE", "unmapped"), fix),
+		},
+		func(path string) string {
+			return "external/" + path
+		},
+	)
+	require.Len(t, fileAnnotations, 3)
+	assert.Nil(t, fileAnnotations[0].FileInfo())
+	assert.Equal(t, "proto/foo/v1/foo.proto", fileAnnotations[1].FileInfo().ExternalPath())
+	assert.Equal(t, "bar/v1/bar.proto", fileAnnotations[2].FileInfo().Path())
+	assert.Equal(t, "external/bar/v1/bar.proto", fileAnnotations[2].FileInfo().ExternalPath())
+	assert.Equal(t, "unmapped", fileAnnotations[2].Message())
+	assert.Equal(t, fix, fileAnnotations[2].Fix())
+}
+
+func TestFilterFileAnnotationsForChangedLines(t *testing.T) {
+	t.Parallel()
+	changedLines := diffparse.ChangedLines{
+		FileToLineRanges: map[string][]diffparse.LineRange{
+			"proto/foo/v1/foo.proto": {
+				{StartLine: 5, EndLine: 10},
+				// A field was deleted after line 20.
+				{StartLine: 20, EndLine: 21},
+			},
+		},
+		DeletedFiles: map[string]struct{}{
+			"proto/baz/v1/baz.proto": {},
+		},
+	}
+	fooFileInfo := testFileInfo{path: "foo/v1/foo.proto", externalPath: "proto/foo/v1/foo.proto"}
+	barFileInfo := testFileInfo{path: "bar/v1/bar.proto", externalPath: "proto/bar/v1/bar.proto"}
+	bazFileInfo := testFileInfo{path: "baz/v1/baz.proto", externalPath: "proto/baz/v1/baz.proto"}
+	noFile := NewFileAnnotation(nil, 0, 0, 0, 0, "FILE_NO_DELETE", "no file")
+	noLocationChanged := NewFileAnnotation(fooFileInfo, 0, 0, 0, 0, "FILE", "no location in changed file")
+	noLocationUnchanged := NewFileAnnotation(barFileInfo, 0, 0, 0, 0, "FILE", "no location in unchanged file")
+	deletedFile := NewFileAnnotation(bazFileInfo, 3, 1, 3, 10, "FILE_NO_DELETE", "deleted file")
+	inHunk := NewFileAnnotation(fooFileInfo, 7, 1, 7, 10, "FIELD", "in hunk")
+	overlappingHunk := NewFileAnnotation(fooFileInfo, 1, 1, 5, 2, "MESSAGE", "overlapping hunk")
+	messageWithDeletedField := NewFileAnnotation(fooFileInfo, 15, 1, 25, 2, "FIELD_NO_DELETE", "message with deleted field")
+	outsideHunk := NewFileAnnotation(fooFileInfo, 11, 1, 11, 10, "FIELD", "outside hunk")
+	unchangedFile := NewFileAnnotation(barFileInfo, 7, 1, 7, 10, "FIELD", "unchanged file")
+	assert.Equal(
+		t,
+		[]FileAnnotation{
+			noFile,
+			noLocationChanged,
+			noLocationUnchanged,
+			deletedFile,
+			inHunk,
+			overlappingHunk,
+			messageWithDeletedField,
+		},
+		FilterFileAnnotationsForChangedLines(
+			[]FileAnnotation{
+				noFile,
+				noLocationChanged,
+				noLocationUnchanged,
+				deletedFile,
+				inHunk,
+				overlappingHunk,
+				messageWithDeletedField,
+				outsideHunk,
+				unchangedFile,
+			},
+			changedLines,
+		),
+	)
+}
+
+type testFileInfo struct {
+	path         string
+	externalPath string
+}
+
+func (f testFileInfo) Path() string {
+	return f.path
+}
+
+func (f testFileInfo) ExternalPath() string {
+	return f.externalPath
+}
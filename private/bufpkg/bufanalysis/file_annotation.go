@@ -27,6 +27,7 @@ type fileAnnotation struct {
 	endColumn   int
 	typeString  string
 	message     string
+	fix         Fix
 }
 
 func newFileAnnotation(
@@ -77,6 +78,10 @@ func (f *fileAnnotation) Message() string {
 	return f.message
 }
 
+func (f *fileAnnotation) Fix() Fix {
+	return f.fix
+}
+
 func (f *fileAnnotation) String() string {
 	if f == nil {
 		return ""
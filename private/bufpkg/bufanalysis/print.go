@@ -250,13 +250,20 @@ func printFileAnnotationAsGithubActions(buffer *bytes.Buffer, f FileAnnotation)
 }
 
 type externalFileAnnotation struct {
-	Path        string `json:"path,omitempty" yaml:"path,omitempty"`
-	StartLine   int    `json:"start_line,omitempty" yaml:"start_line,omitempty"`
-	StartColumn int    `json:"start_column,omitempty" yaml:"start_column,omitempty"`
-	EndLine     int    `json:"end_line,omitempty" yaml:"end_line,omitempty"`
-	EndColumn   int    `json:"end_column,omitempty" yaml:"end_column,omitempty"`
-	Type        string `json:"type,omitempty" yaml:"type,omitempty"`
-	Message     string `json:"message,omitempty" yaml:"message,omitempty"`
+	Path        string       `json:"path,omitempty" yaml:"path,omitempty"`
+	StartLine   int          `json:"start_line,omitempty" yaml:"start_line,omitempty"`
+	StartColumn int          `json:"start_column,omitempty" yaml:"start_column,omitempty"`
+	EndLine     int          `json:"end_line,omitempty" yaml:"end_line,omitempty"`
+	EndColumn   int          `json:"end_column,omitempty" yaml:"end_column,omitempty"`
+	Type        string       `json:"type,omitempty" yaml:"type,omitempty"`
+	Message     string       `json:"message,omitempty" yaml:"message,omitempty"`
+	Fix         *externalFix `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+type externalFix struct {
+	StartOffset int    `json:"start_offset" yaml:"start_offset"`
+	EndOffset   int    `json:"end_offset" yaml:"end_offset"`
+	Replacement string `json:"replacement" yaml:"replacement"`
 }
 
 func newExternalFileAnnotation(f FileAnnotation) externalFileAnnotation {
@@ -264,6 +271,14 @@ func newExternalFileAnnotation(f FileAnnotation) externalFileAnnotation {
 	if f.FileInfo() != nil {
 		path = f.FileInfo().ExternalPath()
 	}
+	var fix *externalFix
+	if f.Fix() != nil {
+		fix = &externalFix{
+			StartOffset: f.Fix().StartOffset(),
+			EndOffset:   f.Fix().EndOffset(),
+			Replacement: f.Fix().Replacement(),
+		}
+	}
 	return externalFileAnnotation{
 		Path:        path,
 		StartLine:   atLeast1(f.StartLine()),
@@ -272,6 +287,7 @@ func newExternalFileAnnotation(f FileAnnotation) externalFileAnnotation {
 		EndColumn:   atLeast1(f.EndColumn()),
 		Type:        f.Type(),
 		Message:     f.Message(),
+		Fix:         fix,
 	}
 }
 
@@ -22,10 +22,17 @@ import (
 	"go.uber.org/zap"
 )
 
+type DocServiceClientFactory func(address string) registryv1alpha1connect.DocServiceClient
 type DownloadServiceClientFactory func(address string) registryv1alpha1connect.DownloadServiceClient
 type RepositoryCommitServiceClientFactory func(address string) registryv1alpha1connect.RepositoryCommitServiceClient
 type RepositoryServiceClientFactory func(address string) registryv1alpha1connect.RepositoryServiceClient
 
+func NewDocServiceClientFactory(clientConfig *connectclient.Config) DocServiceClientFactory {
+	return func(address string) registryv1alpha1connect.DocServiceClient {
+		return connectclient.Make(clientConfig, address, registryv1alpha1connect.NewDocServiceClient)
+	}
+}
+
 func NewDownloadServiceClientFactory(clientConfig *connectclient.Config) DownloadServiceClientFactory {
 	return func(address string) registryv1alpha1connect.DownloadServiceClient {
 		return connectclient.Make(clientConfig, address, registryv1alpha1connect.NewDownloadServiceClient)
@@ -45,11 +52,15 @@ func NewRepositoryServiceClientFactory(clientConfig *connectclient.Config) Repos
 }
 
 // NewModuleReader returns a new ModuleReader backed by the download service.
+//
+// The returned ModuleReader is also a bufmodule.PartialModuleReader. Partial modules
+// are only downloaded file by file if ModuleReaderWithPartialDownload is set, otherwise
+// the entire Module is downloaded.
 func NewModuleReader(
 	logger *zap.Logger,
 	downloadClientFactory DownloadServiceClientFactory,
 	opts ...ModuleReaderOption,
-) bufmodule.ModuleReader {
+) bufmodule.PartialModuleReader {
 	return newModuleReader(
 		logger,
 		downloadClientFactory,
@@ -70,6 +81,16 @@ func ModuleReaderWithDeprecationWarning(
 	}
 }
 
+// ModuleReaderWithPartialDownload makes the module reader download partial modules
+// file by file from the doc service, instead of downloading the entire module.
+func ModuleReaderWithPartialDownload(
+	docClientFactory DocServiceClientFactory,
+) ModuleReaderOption {
+	return func(reader *moduleReader) {
+		reader.docClientFactory = docClientFactory
+	}
+}
+
 // NewModuleResolver returns a new ModuleResolver backed by the resolve service.
 func NewModuleResolver(
 	logger *zap.Logger,
@@ -77,3 +98,11 @@ func NewModuleResolver(
 ) bufmodule.ModuleResolver {
 	return newModuleResolver(logger, repositoryCommitClientFactory)
 }
+
+// NewCommitHistoryProvider returns a new CommitHistoryProvider backed by the repository commit service.
+func NewCommitHistoryProvider(
+	logger *zap.Logger,
+	repositoryCommitClientFactory RepositoryCommitServiceClientFactory,
+) bufmodule.CommitHistoryProvider {
+	return newCommitHistoryProvider(logger, repositoryCommitClientFactory)
+}
@@ -0,0 +1,101 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufapimodule
+
+import (
+	"context"
+	"io/fs"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"go.uber.org/zap"
+)
+
+type commitHistoryProvider struct {
+	logger                        *zap.Logger
+	repositoryCommitClientFactory RepositoryCommitServiceClientFactory
+}
+
+func newCommitHistoryProvider(
+	logger *zap.Logger,
+	repositoryCommitClientFactory RepositoryCommitServiceClientFactory,
+) *commitHistoryProvider {
+	return &commitHistoryProvider{
+		logger:                        logger,
+		repositoryCommitClientFactory: repositoryCommitClientFactory,
+	}
+}
+
+func (c *commitHistoryProvider) GetCommitHistoryPage(
+	ctx context.Context,
+	moduleReference bufmoduleref.ModuleReference,
+	pageSize uint32,
+	pageToken string,
+	reverse bool,
+) (*bufmodule.CommitHistoryPage, error) {
+	reference := moduleReference.Reference()
+	if reference == "" {
+		reference = bufmoduleref.Main
+	}
+	repositoryCommitService := c.repositoryCommitClientFactory(moduleReference.Remote())
+	resp, err := repositoryCommitService.ListRepositoryCommitsByReference(
+		ctx,
+		connect.NewRequest(&registryv1alpha1.ListRepositoryCommitsByReferenceRequest{
+			RepositoryOwner: moduleReference.Owner(),
+			RepositoryName:  moduleReference.Repository(),
+			Reference:       reference,
+			PageSize:        pageSize,
+			PageToken:       pageToken,
+			Reverse:         reverse,
+		}),
+	)
+	if err != nil {
+		if connect.CodeOf(err) == connect.CodeNotFound {
+			// Required by CommitHistoryProvider interface spec
+			return nil, &fs.PathError{Op: "read", Path: moduleReference.String(), Err: fs.ErrNotExist}
+		}
+		return nil, err
+	}
+	commitInfos := make([]bufmodule.CommitInfo, 0, len(resp.Msg.RepositoryCommits))
+	for _, repositoryCommit := range resp.Msg.RepositoryCommits {
+		commitInfos = append(commitInfos, repositoryCommitToCommitInfo(repositoryCommit))
+	}
+	return &bufmodule.CommitHistoryPage{
+		CommitInfos:   commitInfos,
+		NextPageToken: resp.Msg.NextPageToken,
+	}, nil
+}
+
+func repositoryCommitToCommitInfo(repositoryCommit *registryv1alpha1.RepositoryCommit) bufmodule.CommitInfo {
+	labels := make([]string, 0, len(repositoryCommit.Tags))
+	for _, repositoryTag := range repositoryCommit.Tags {
+		labels = append(labels, repositoryTag.Name)
+	}
+	commitInfo := bufmodule.CommitInfo{
+		Commit:          repositoryCommit.Name,
+		Digest:          repositoryCommit.ManifestDigest,
+		Labels:          labels,
+		Author:          repositoryCommit.Author,
+		Branch:          repositoryCommit.Branch,
+		DraftName:       repositoryCommit.DraftName,
+		GitCommitsCount: repositoryCommit.GitCommitsCount,
+	}
+	if repositoryCommit.CreateTime != nil {
+		commitInfo.CreateTime = repositoryCommit.CreateTime.AsTime()
+	}
+	return commitInfo
+}
@@ -0,0 +1,133 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufapimodule
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
+	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type mockListCommitServiceClient struct {
+	registryv1alpha1connect.UnimplementedRepositoryCommitServiceHandler
+
+	listReq  *registryv1alpha1.ListRepositoryCommitsByReferenceRequest
+	listResp *registryv1alpha1.ListRepositoryCommitsByReferenceResponse
+	listErr  error
+}
+
+func (m *mockListCommitServiceClient) ListRepositoryCommitsByReference(
+	_ context.Context,
+	req *connect.Request[registryv1alpha1.ListRepositoryCommitsByReferenceRequest],
+) (*connect.Response[registryv1alpha1.ListRepositoryCommitsByReferenceResponse], error) {
+	m.listReq = req.Msg
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
+	return connect.NewResponse(m.listResp), nil
+}
+
+func TestGetCommitHistoryPage(t *testing.T) {
+	t.Parallel()
+	createTime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	client := &mockListCommitServiceClient{
+		listResp: &registryv1alpha1.ListRepositoryCommitsByReferenceResponse{
+			RepositoryCommits: []*registryv1alpha1.RepositoryCommit{
+				{
+					Name:            "commit2",
+					CreateTime:      timestamppb.New(createTime),
+					ManifestDigest:  "shake256:abc",
+					Author:          "jdoe",
+					GitCommitsCount: 3,
+					Tags: []*registryv1alpha1.RepositoryTag{
+						{Name: "v1.1.0"},
+						{Name: "latest"},
+					},
+				},
+				{
+					Name:           "commit1",
+					ManifestDigest: "shake256:def",
+					Branch:         "feature",
+					DraftName:      "wip",
+				},
+			},
+			NextPageToken: "next",
+		},
+	}
+	provider := newCommitHistoryProvider(nil, func(_ string) registryv1alpha1connect.RepositoryCommitServiceClient {
+		return client
+	})
+	// References default to main if not specified.
+	moduleReference, err := bufmoduleref.ModuleReferenceForString("buf.build/owner/repository")
+	require.NoError(t, err)
+	page, err := provider.GetCommitHistoryPage(context.Background(), moduleReference, 2, "token", true)
+	require.NoError(t, err)
+	assert.Equal(t, "owner", client.listReq.RepositoryOwner)
+	assert.Equal(t, "repository", client.listReq.RepositoryName)
+	assert.Equal(t, bufmoduleref.Main, client.listReq.Reference)
+	assert.Equal(t, uint32(2), client.listReq.PageSize)
+	assert.Equal(t, "token", client.listReq.PageToken)
+	assert.True(t, client.listReq.Reverse)
+	assert.Equal(
+		t,
+		&bufmodule.CommitHistoryPage{
+			CommitInfos: []bufmodule.CommitInfo{
+				{
+					Commit:          "commit2",
+					CreateTime:      createTime,
+					Digest:          "shake256:abc",
+					Labels:          []string{"v1.1.0", "latest"},
+					Author:          "jdoe",
+					GitCommitsCount: 3,
+				},
+				{
+					Commit:    "commit1",
+					Digest:    "shake256:def",
+					Labels:    []string{},
+					Branch:    "feature",
+					DraftName: "wip",
+				},
+			},
+			NextPageToken: "next",
+		},
+		page,
+	)
+}
+
+func TestGetCommitHistoryPageNotFound(t *testing.T) {
+	t.Parallel()
+	client := &mockListCommitServiceClient{
+		listErr: connect.NewError(connect.CodeNotFound, errors.New("not found")),
+	}
+	provider := newCommitHistoryProvider(nil, func(_ string) registryv1alpha1connect.RepositoryCommitServiceClient {
+		return client
+	})
+	moduleReference, err := bufmoduleref.NewModuleReference("remote", "owner", "repository", "v1")
+	require.NoError(t, err)
+	_, err = provider.GetCommitHistoryPage(context.Background(), moduleReference, 10, "", false)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, "v1", client.listReq.Reference)
+}
@@ -15,16 +15,27 @@
 package bufapimodule
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io/fs"
+	"sync"
 
 	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcas/bufcasalpha"
+	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
+	"github.com/bufbuild/buf/private/bufpkg/buflock"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleprotocompile"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/gen/proto/connect/buf/alpha/registry/v1alpha1/registryv1alpha1connect"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/cache"
+	"github.com/bufbuild/buf/private/pkg/interrupt"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/thread"
 	"go.uber.org/zap"
 )
 
@@ -33,6 +44,11 @@ type moduleReader struct {
 	downloadClientFactory DownloadServiceClientFactory
 	// repositoryClientFactory may be nil
 	repositoryClientFactory RepositoryServiceClientFactory
+	// docClientFactory may be nil
+	docClientFactory DocServiceClientFactory
+	// repositoryIdentityToDeprecationChecked makes sure each repository is only
+	// checked for deprecation once, however many of its commits are read.
+	repositoryIdentityToDeprecationChecked cache.SingleflightCache[string, struct{}]
 }
 
 func newModuleReader(
@@ -51,6 +67,7 @@ func newModuleReader(
 }
 
 func (m *moduleReader) GetModule(ctx context.Context, modulePin bufmoduleref.ModulePin) (bufmodule.Module, error) {
+	defer interrupt.StartPhase(ctx, "downloading "+modulePin.IdentityString()+":"+modulePin.Commit())()
 	moduleIdentity, err := bufmoduleref.NewModuleIdentity(
 		modulePin.Remote(),
 		modulePin.Owner(),
@@ -76,13 +93,100 @@ func (m *moduleReader) GetModule(ctx context.Context, modulePin bufmoduleref.Mod
 		return nil, err
 	}
 	if m.repositoryClientFactory != nil {
-		if err := warnIfDeprecated(ctx, m.repositoryClientFactory, modulePin, m.logger); err != nil {
+		if err := m.warnIfDeprecated(ctx, modulePin); err != nil {
 			return nil, err
 		}
 	}
 	return bufmodule.NewModuleForFileSet(ctx, fileSet, identityAndCommitOpt)
 }
 
+func (m *moduleReader) GetPartialModule(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+	paths []string,
+) (bufmodule.Module, error) {
+	if m.docClientFactory == nil {
+		return m.GetModule(ctx, modulePin)
+	}
+	moduleIdentity, err := bufmoduleref.NewModuleIdentity(
+		modulePin.Remote(),
+		modulePin.Owner(),
+		modulePin.Repository(),
+	)
+	if err != nil {
+		// malformed pin
+		return nil, err
+	}
+	docService := m.docClientFactory(modulePin.Remote())
+	pathToContent := make(map[string][]byte)
+	// We walk the imports of the requested files breadth-first, fetching each level
+	// of the walk in parallel. Imports that do not exist within this module are
+	// expected to come from dependencies.
+	requestedPaths := make(map[string]struct{}, len(paths))
+	seenPaths := make(map[string]struct{}, len(paths))
+	var level []string
+	for _, path := range paths {
+		path, err := normalpath.NormalizeAndValidate(path)
+		if err != nil {
+			return nil, err
+		}
+		requestedPaths[path] = struct{}{}
+		if _, ok := seenPaths[path]; !ok {
+			seenPaths[path] = struct{}{}
+			level = append(level, path)
+		}
+	}
+	// The configuration files are fetched with the first level, and are optional.
+	configFilePaths := append([]string{buflock.ExternalConfigFilePath}, bufconfig.AllConfigFilePaths...)
+	for _, configFilePath := range configFilePaths {
+		if _, ok := seenPaths[configFilePath]; !ok {
+			seenPaths[configFilePath] = struct{}{}
+			level = append(level, configFilePath)
+		}
+	}
+	for len(level) > 0 {
+		levelPathToContent, err := getSourceFiles(ctx, docService, modulePin, level)
+		if err != nil {
+			return nil, err
+		}
+		var nextLevel []string
+		for _, path := range level {
+			content, ok := levelPathToContent[path]
+			if !ok {
+				if _, ok := requestedPaths[path]; ok {
+					return nil, &fs.PathError{Op: "read", Path: modulePin.String() + ":" + path, Err: fs.ErrNotExist}
+				}
+				continue
+			}
+			pathToContent[path] = content
+			if normalpath.Ext(path) != ".proto" {
+				continue
+			}
+			for _, importPath := range bufmoduleprotocompile.GetImportPaths(content) {
+				if _, ok := seenPaths[importPath]; !ok {
+					seenPaths[importPath] = struct{}{}
+					nextLevel = append(nextLevel, importPath)
+				}
+			}
+		}
+		level = nextLevel
+	}
+	fileSet, err := newFileSetForPathToContent(pathToContent)
+	if err != nil {
+		return nil, err
+	}
+	if m.repositoryClientFactory != nil {
+		if err := m.warnIfDeprecated(ctx, modulePin); err != nil {
+			return nil, err
+		}
+	}
+	return bufmodule.NewModuleForFileSet(
+		ctx,
+		fileSet,
+		bufmodule.ModuleWithModuleIdentityAndCommit(moduleIdentity, modulePin.Commit()),
+	)
+}
+
 func (m *moduleReader) downloadManifestAndBlobs(
 	ctx context.Context,
 	modulePin bufmoduleref.ModulePin,
@@ -106,6 +210,91 @@ func (m *moduleReader) downloadManifestAndBlobs(
 	return resp.Msg, err
 }
 
+// getSourceFiles gets the contents of the files at the paths within the module.
+//
+// The files are fetched in parallel. Paths that do not exist are not present in the
+// returned map.
+func getSourceFiles(
+	ctx context.Context,
+	docService registryv1alpha1connect.DocServiceClient,
+	modulePin bufmoduleref.ModulePin,
+	paths []string,
+) (map[string][]byte, error) {
+	var lock sync.Mutex
+	pathToContent := make(map[string][]byte, len(paths))
+	jobs := make([]func(context.Context) error, len(paths))
+	for i, path := range paths {
+		path := path
+		jobs[i] = func(ctx context.Context) error {
+			resp, err := docService.GetSourceFile(
+				ctx,
+				connect.NewRequest(&registryv1alpha1.GetSourceFileRequest{
+					Owner:      modulePin.Owner(),
+					Repository: modulePin.Repository(),
+					Reference:  modulePin.Commit(),
+					Path:       path,
+				}),
+			)
+			if err != nil {
+				if connect.CodeOf(err) == connect.CodeNotFound {
+					return nil
+				}
+				return err
+			}
+			lock.Lock()
+			pathToContent[path] = resp.Msg.Content
+			lock.Unlock()
+			return nil
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if err := thread.Parallelize(ctx, jobs, thread.ParallelizeWithCancel(cancel)); err != nil {
+		return nil, err
+	}
+	return pathToContent, nil
+}
+
+// newFileSetForPathToContent returns a new FileSet for the contents of the files,
+// with a Manifest that only contains these files.
+func newFileSetForPathToContent(pathToContent map[string][]byte) (bufcas.FileSet, error) {
+	fileNodes := make([]bufcas.FileNode, 0, len(pathToContent))
+	blobs := make([]bufcas.Blob, 0, len(pathToContent))
+	for path, content := range pathToContent {
+		blob, err := bufcas.NewBlobForContent(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		fileNode, err := bufcas.NewFileNode(path, blob.Digest())
+		if err != nil {
+			return nil, err
+		}
+		fileNodes = append(fileNodes, fileNode)
+		blobs = append(blobs, blob)
+	}
+	manifest, err := bufcas.NewManifest(fileNodes)
+	if err != nil {
+		return nil, err
+	}
+	blobSet, err := bufcas.NewBlobSet(blobs)
+	if err != nil {
+		return nil, err
+	}
+	return bufcas.NewFileSet(manifest, blobSet)
+}
+
+// warnIfDeprecated is warnIfDeprecated, but only checks each repository once.
+func (m *moduleReader) warnIfDeprecated(ctx context.Context, modulePin bufmoduleref.ModulePin) error {
+	_, err := m.repositoryIdentityToDeprecationChecked.GetOrAdd(
+		ctx,
+		modulePin.IdentityString(),
+		func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, warnIfDeprecated(ctx, m.repositoryClientFactory, modulePin, m.logger)
+		},
+	)
+	return err
+}
+
 // warnIfDeprecated emits a warning message to logger if the repository
 // is deprecated on the BSR.
 func warnIfDeprecated(
@@ -17,6 +17,8 @@ package bufapimodule
 import (
 	"context"
 	"errors"
+	"io/fs"
+	"sync"
 	"testing"
 
 	"connectrpc.com/connect"
@@ -30,6 +32,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestDownload(t *testing.T) {
@@ -149,6 +153,172 @@ func testDownload(
 	})
 }
 
+func TestDeprecationCheckedOnce(t *testing.T) {
+	t.Parallel()
+	repositoryService := &deprecatedRepositoryServiceClient{}
+	core, observedLogs := observer.New(zapcore.WarnLevel)
+	moduleReader := newModuleReader(
+		zap.New(core),
+		newMockDownloadService(t, withBlobsFromMap(map[string][]byte{})).factory,
+		ModuleReaderWithDeprecationWarning(
+			func(string) registryv1alpha1connect.RepositoryServiceClient {
+				return repositoryService
+			},
+		),
+	)
+	for _, commit := range []string{"commit1", "commit2"} {
+		pin, err := bufmoduleref.NewModulePin(
+			"remote",
+			"owner",
+			"repository",
+			commit,
+			"",
+		)
+		require.NoError(t, err)
+		_, err = moduleReader.GetModule(context.Background(), pin)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, repositoryService.calls)
+	assert.Equal(t, 1, observedLogs.Len())
+}
+
+func TestGetPartialModule(t *testing.T) {
+	t.Parallel()
+	docService := &mockDocService{
+		files: map[string][]byte{
+			"buf.yaml": []byte("version: v1\n"),
+			"a/a.proto": []byte(`syntax = "proto3";
+package a;
+import "b/b.proto";
+import "dep/dep.proto";
+import "google/protobuf/empty.proto";
+message A {}
+`),
+			"b/b.proto": []byte(`syntax = "proto3";
+package b;
+import public "c/c.proto";
+message B {}
+`),
+			"c/c.proto": []byte(`syntax = "proto3";
+package c;
+message C {}
+`),
+			"d/d.proto": []byte(`syntax = "proto3";
+package d;
+message D {}
+`),
+		},
+	}
+	moduleReader := newModuleReader(
+		zap.NewNop(),
+		newMockDownloadService(t).factory,
+		ModuleReaderWithPartialDownload(docService.factory),
+	)
+	ctx := context.Background()
+	pin, err := bufmoduleref.NewModulePin(
+		"remote",
+		"owner",
+		"repository",
+		"commit",
+		"digest",
+	)
+	require.NoError(t, err)
+	module, err := moduleReader.GetPartialModule(ctx, pin, []string{"a/a.proto"})
+	require.NoError(t, err)
+	fileInfos, err := module.SourceFileInfos(ctx)
+	require.NoError(t, err)
+	paths := make([]string, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		paths = append(paths, fileInfo.Path())
+		assert.Equal(t, pin.Commit(), fileInfo.Commit())
+	}
+	assert.Equal(t, []string{"a/a.proto", "b/b.proto", "c/c.proto"}, paths)
+	require.NotNil(t, module.FileSet())
+	for _, fileNode := range module.FileSet().Manifest().FileNodes() {
+		blob := module.FileSet().BlobSet().GetBlob(fileNode.Digest())
+		require.NotNil(t, blob)
+		assert.Equal(t, docService.files[fileNode.Path()], blob.Content())
+	}
+	assert.Len(t, module.FileSet().Manifest().FileNodes(), 4)
+	assert.ElementsMatch(
+		t,
+		[]string{
+			"buf.lock",
+			"buf.yaml",
+			"buf.mod",
+			"a/a.proto",
+			"b/b.proto",
+			"dep/dep.proto",
+			"google/protobuf/empty.proto",
+			"c/c.proto",
+		},
+		docService.requestedPaths,
+	)
+	_, err = moduleReader.GetPartialModule(ctx, pin, []string{"e/e.proto"})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestGetPartialModuleWithoutPartialDownload(t *testing.T) {
+	t.Parallel()
+	moduleReader := newModuleReader(
+		zap.NewNop(),
+		newMockDownloadService(
+			t,
+			withBlobsFromMap(map[string][]byte{
+				"a.proto": []byte(`syntax = "proto3";
+message A {}
+`),
+				"b.proto": []byte(`syntax = "proto3";
+message B {}
+`),
+			}),
+		).factory,
+	)
+	ctx := context.Background()
+	pin, err := bufmoduleref.NewModulePin(
+		"remote",
+		"owner",
+		"repository",
+		"commit",
+		"digest",
+	)
+	require.NoError(t, err)
+	module, err := moduleReader.GetPartialModule(ctx, pin, []string{"a.proto"})
+	require.NoError(t, err)
+	fileInfos, err := module.SourceFileInfos(ctx)
+	require.NoError(t, err)
+	assert.Len(t, fileInfos, 2)
+}
+
+type mockDocService struct {
+	registryv1alpha1connect.UnimplementedDocServiceHandler
+
+	files map[string][]byte
+
+	lock           sync.Mutex
+	requestedPaths []string
+}
+
+func (m *mockDocService) factory(_ string) registryv1alpha1connect.DocServiceClient {
+	return m
+}
+
+func (m *mockDocService) GetSourceFile(
+	_ context.Context,
+	req *connect.Request[registryv1alpha1.GetSourceFileRequest],
+) (*connect.Response[registryv1alpha1.GetSourceFileResponse], error) {
+	m.lock.Lock()
+	m.requestedPaths = append(m.requestedPaths, req.Msg.Path)
+	m.lock.Unlock()
+	content, ok := m.files[req.Msg.Path]
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("not found"))
+	}
+	return connect.NewResponse(&registryv1alpha1.GetSourceFileResponse{
+		Content: content,
+	}), nil
+}
+
 type mockDownloadService struct {
 	module       *modulev1alpha1.Module
 	manifestBlob *modulev1alpha1.Blob
@@ -253,3 +423,23 @@ func (t *nopRepositoryServiceClient) GetRepositoryByFullName(
 		Repository: &registryv1alpha1.Repository{},
 	}), nil
 }
+
+type deprecatedRepositoryServiceClient struct {
+	registryv1alpha1connect.UnimplementedRepositoryServiceHandler
+
+	calls int
+}
+
+var _ registryv1alpha1connect.RepositoryServiceClient = (*deprecatedRepositoryServiceClient)(nil)
+
+func (d *deprecatedRepositoryServiceClient) GetRepositoryByFullName(
+	_ context.Context,
+	_ *connect.Request[registryv1alpha1.GetRepositoryByFullNameRequest],
+) (*connect.Response[registryv1alpha1.GetRepositoryByFullNameResponse], error) {
+	d.calls++
+	return connect.NewResponse(&registryv1alpha1.GetRepositoryByFullNameResponse{
+		Repository: &registryv1alpha1.Repository{
+			Deprecated: true,
+		},
+	}), nil
+}
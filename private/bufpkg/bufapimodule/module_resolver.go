@@ -22,12 +22,16 @@ import (
 	"connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	registryv1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/registry/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/cache"
 	"go.uber.org/zap"
 )
 
 type moduleResolver struct {
 	logger                        *zap.Logger
 	repositoryCommitClientFactory RepositoryCommitServiceClientFactory
+	// moduleReferenceToModulePin makes sure each reference is only resolved
+	// once, so that every caller sees the same commit for it.
+	moduleReferenceToModulePin cache.SingleflightCache[string, bufmoduleref.ModulePin]
 }
 
 func newModuleResolver(
@@ -41,6 +45,16 @@ func newModuleResolver(
 }
 
 func (m *moduleResolver) GetModulePin(ctx context.Context, moduleReference bufmoduleref.ModuleReference) (bufmoduleref.ModulePin, error) {
+	return m.moduleReferenceToModulePin.GetOrAdd(
+		ctx,
+		moduleReference.String(),
+		func(ctx context.Context) (bufmoduleref.ModulePin, error) {
+			return m.getModulePinUncached(ctx, moduleReference)
+		},
+	)
+}
+
+func (m *moduleResolver) getModulePinUncached(ctx context.Context, moduleReference bufmoduleref.ModuleReference) (bufmoduleref.ModulePin, error) {
 	repositoryCommitService := m.repositoryCommitClientFactory(moduleReference.Remote())
 	resp, err := repositoryCommitService.GetRepositoryCommitByReference(
 		ctx,
@@ -33,12 +33,14 @@ type mockCommitServiceClient struct {
 
 	t       *testing.T
 	refResp *registryv1alpha1.GetRepositoryCommitByReferenceResponse
+	calls   int
 }
 
 func (m *mockCommitServiceClient) GetRepositoryCommitByReference(
 	_ context.Context,
 	_ *connect.Request[registryv1alpha1.GetRepositoryCommitByReferenceRequest],
 ) (*connect.Response[registryv1alpha1.GetRepositoryCommitByReferenceResponse], error) {
+	m.calls++
 	return connect.NewResponse(m.refResp), nil
 }
 
@@ -68,6 +70,35 @@ func TestGetModulePin(t *testing.T) {
 	)
 }
 
+func TestGetModulePinResolvesOnce(t *testing.T) {
+	t.Parallel()
+	client := &mockCommitServiceClient{
+		t: t,
+		refResp: &registryv1alpha1.GetRepositoryCommitByReferenceResponse{
+			RepositoryCommit: &registryv1alpha1.RepositoryCommit{
+				Name: "commit",
+			},
+		},
+	}
+	clientFactory := func(_ string) registryv1alpha1connect.RepositoryCommitServiceClient {
+		return client
+	}
+	mr := newModuleResolver(nil, clientFactory) // logger is unused
+	moduleReference, err := bufmoduleref.NewModuleReference(
+		"remote",
+		"owner",
+		"repository",
+		"reference",
+	)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		pin, err := mr.GetModulePin(context.Background(), moduleReference)
+		require.NoError(t, err)
+		assert.Equal(t, "commit", pin.Commit())
+	}
+	assert.Equal(t, 1, client.calls)
+}
+
 func testGetModulePin(
 	t *testing.T,
 	desc string,
@@ -0,0 +1,73 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcas
+
+// ManifestDiff is the difference between two Manifests.
+//
+// All slices are sorted by path.
+type ManifestDiff struct {
+	// Added are the FileNodes whose paths only exist in the second Manifest.
+	Added []FileNode
+	// Removed are the FileNodes whose paths only exist in the first Manifest.
+	Removed []FileNode
+	// Modified are the paths that exist in both Manifests with different Digests.
+	Modified []*ModifiedFileNode
+}
+
+// IsEmpty returns true if there is no difference between the two Manifests.
+func (m *ManifestDiff) IsEmpty() bool {
+	return len(m.Added) == 0 && len(m.Removed) == 0 && len(m.Modified) == 0
+}
+
+// ModifiedFileNode is a path whose Digest differs between two Manifests.
+type ModifiedFileNode struct {
+	Path       string
+	FromDigest Digest
+	ToDigest   Digest
+}
+
+// DiffManifests returns the difference between the from and to Manifests.
+func DiffManifests(from Manifest, to Manifest) *ManifestDiff {
+	manifestDiff := &ManifestDiff{}
+	for _, fromFileNode := range from.FileNodes() {
+		toDigest := to.GetDigest(fromFileNode.Path())
+		switch {
+		case toDigest == nil:
+			manifestDiff.Removed = append(manifestDiff.Removed, fromFileNode)
+		case !DigestEqual(fromFileNode.Digest(), toDigest):
+			manifestDiff.Modified = append(
+				manifestDiff.Modified,
+				&ModifiedFileNode{
+					Path:       fromFileNode.Path(),
+					FromDigest: fromFileNode.Digest(),
+					ToDigest:   toDigest,
+				},
+			)
+		}
+	}
+	for _, toFileNode := range to.FileNodes() {
+		if from.GetFileNode(toFileNode.Path()) == nil {
+			manifestDiff.Added = append(manifestDiff.Added, toFileNode)
+		}
+	}
+	return manifestDiff
+}
+
+// DiffFileSets returns the difference between the Manifests of the from and to FileSets.
+//
+// Since Blobs are content-addressed, comparing Manifests is sufficient.
+func DiffFileSets(from FileSet, to FileSet) *ManifestDiff {
+	return DiffManifests(from.Manifest(), to.Manifest())
+}
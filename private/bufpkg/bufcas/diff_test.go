@@ -0,0 +1,120 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufcas
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffManifests(t *testing.T) {
+	t.Parallel()
+	from := testNewManifest(
+		t,
+		"a.proto", "a",
+		"b.proto", "b",
+		"c.proto", "c",
+	)
+	to := testNewManifest(
+		t,
+		"b.proto", "b",
+		"c.proto", "c2",
+		"d.proto", "d",
+	)
+	manifestDiff := DiffManifests(from, to)
+	assert.False(t, manifestDiff.IsEmpty())
+	require.Len(t, manifestDiff.Added, 1)
+	assert.Equal(t, "d.proto", manifestDiff.Added[0].Path())
+	assert.Equal(t, to.GetDigest("d.proto"), manifestDiff.Added[0].Digest())
+	require.Len(t, manifestDiff.Removed, 1)
+	assert.Equal(t, "a.proto", manifestDiff.Removed[0].Path())
+	assert.Equal(t, from.GetDigest("a.proto"), manifestDiff.Removed[0].Digest())
+	assert.Equal(
+		t,
+		[]*ModifiedFileNode{
+			{
+				Path:       "c.proto",
+				FromDigest: from.GetDigest("c.proto"),
+				ToDigest:   to.GetDigest("c.proto"),
+			},
+		},
+		manifestDiff.Modified,
+	)
+
+	reverseManifestDiff := DiffManifests(to, from)
+	assert.Equal(t, manifestDiff.Added, reverseManifestDiff.Removed)
+	assert.Equal(t, manifestDiff.Removed, reverseManifestDiff.Added)
+}
+
+func TestDiffManifestsEmpty(t *testing.T) {
+	t.Parallel()
+	from := testNewManifest(t, "a.proto", "a", "b.proto", "b")
+	to := testNewManifest(t, "b.proto", "b", "a.proto", "a")
+	assert.True(t, DiffManifests(from, to).IsEmpty())
+	emptyManifest, err := NewManifest(nil)
+	require.NoError(t, err)
+	assert.True(t, DiffManifests(emptyManifest, emptyManifest).IsEmpty())
+	manifestDiff := DiffManifests(emptyManifest, from)
+	assert.Len(t, manifestDiff.Added, 2)
+	assert.Empty(t, manifestDiff.Removed)
+	assert.Empty(t, manifestDiff.Modified)
+}
+
+func TestDiffFileSets(t *testing.T) {
+	t.Parallel()
+	from := testNewFileSet(t, "a.proto", "a", "b.proto", "b")
+	to := testNewFileSet(t, "a.proto", "a", "b.proto", "b2")
+	manifestDiff := DiffFileSets(from, to)
+	assert.Empty(t, manifestDiff.Added)
+	assert.Empty(t, manifestDiff.Removed)
+	require.Len(t, manifestDiff.Modified, 1)
+	assert.Equal(t, "b.proto", manifestDiff.Modified[0].Path)
+	assert.True(t, DiffFileSets(from, from).IsEmpty())
+}
+
+// testNewManifest takes alternating paths and contents.
+func testNewManifest(t *testing.T, pathsAndContents ...string) Manifest {
+	manifest, _ := testNewManifestAndBlobs(t, pathsAndContents...)
+	return manifest
+}
+
+func testNewFileSet(t *testing.T, pathsAndContents ...string) FileSet {
+	manifest, blobs := testNewManifestAndBlobs(t, pathsAndContents...)
+	blobSet, err := NewBlobSet(blobs)
+	require.NoError(t, err)
+	fileSet, err := NewFileSet(manifest, blobSet)
+	require.NoError(t, err)
+	return fileSet
+}
+
+func testNewManifestAndBlobs(t *testing.T, pathsAndContents ...string) (Manifest, []Blob) {
+	require.Equal(t, 0, len(pathsAndContents)%2)
+	var fileNodes []FileNode
+	var blobs []Blob
+	for i := 0; i < len(pathsAndContents); i += 2 {
+		blob, err := NewBlobForContent(strings.NewReader(pathsAndContents[i+1]))
+		require.NoError(t, err)
+		blobs = append(blobs, blob)
+		fileNode, err := NewFileNode(pathsAndContents[i], blob.Digest())
+		require.NoError(t, err)
+		fileNodes = append(fileNodes, fileNode)
+	}
+	manifest, err := NewManifest(fileNodes)
+	require.NoError(t, err)
+	return manifest, blobs
+}
@@ -109,23 +109,72 @@ func GetAllRulesAndCategoriesV1() []string {
 	return internal.AllCategoriesAndIDsForVersionSpec(bufbreakingv1.VersionSpec)
 }
 
-func internalConfigForConfig(config *bufbreakingconfig.Config) (*internal.Config, error) {
-	var versionSpec *internal.VersionSpec
-	switch config.Version {
-	case bufconfig.V1Beta1Version:
-		versionSpec = bufbreakingv1beta1.VersionSpec
-	case bufconfig.V1Version:
-		versionSpec = bufbreakingv1.VersionSpec
+// GetConfigWarnings returns the warnings for the settings of the config that have
+// no effect or that contradict other settings, such as excepted rules that are not
+// in use.
+//
+// The result is sorted by key, then value.
+func GetConfigWarnings(config *bufbreakingconfig.Config) []*bufconfig.Warning {
+	versionSpec := versionSpecForVersion(config.Version)
+	if versionSpec == nil {
+		return nil
 	}
+	return warningsForInternalConfigWarnings(
+		configBuilderForConfig(config).RuleWarnings(versionSpec, nil),
+	)
+}
+
+// GetIgnorePathWarnings returns the warnings for the ignore paths of the config that
+// do not match any of the given file paths.
+//
+// The file paths should be the paths of all files of the module, relative to its root.
+// The result is sorted by key, then value.
+func GetIgnorePathWarnings(config *bufbreakingconfig.Config, filePaths []string) []*bufconfig.Warning {
+	return warningsForInternalConfigWarnings(
+		configBuilderForConfig(config).IgnorePathWarnings(filePaths),
+	)
+}
+
+func internalConfigForConfig(config *bufbreakingconfig.Config) (*internal.Config, error) {
+	return configBuilderForConfig(config).NewConfig(
+		versionSpecForVersion(config.Version),
+	)
+}
+
+func configBuilderForConfig(config *bufbreakingconfig.Config) internal.ConfigBuilder {
 	return internal.ConfigBuilder{
 		Use:                           config.Use,
 		Except:                        config.Except,
 		IgnoreRootPaths:               config.IgnoreRootPaths,
 		IgnoreIDOrCategoryToRootPaths: config.IgnoreIDOrCategoryToRootPaths,
 		IgnoreUnstablePackages:        config.IgnoreUnstablePackages,
-	}.NewConfig(
-		versionSpec,
-	)
+	}
+}
+
+func versionSpecForVersion(version string) *internal.VersionSpec {
+	switch version {
+	case bufconfig.V1Beta1Version:
+		return bufbreakingv1beta1.VersionSpec
+	case bufconfig.V1Version:
+		return bufbreakingv1.VersionSpec
+	default:
+		return nil
+	}
+}
+
+func warningsForInternalConfigWarnings(configWarnings []*internal.ConfigWarning) []*bufconfig.Warning {
+	if configWarnings == nil {
+		return nil
+	}
+	warnings := make([]*bufconfig.Warning, len(configWarnings))
+	for i, configWarning := range configWarnings {
+		warnings[i] = &bufconfig.Warning{
+			Key:     "breaking." + configWarning.Key,
+			Value:   configWarning.Value,
+			Message: configWarning.Message,
+		}
+	}
+	return warnings
 }
 
 func rulesForInternalRules(rules []*internal.Rule) []bufcheck.Rule {
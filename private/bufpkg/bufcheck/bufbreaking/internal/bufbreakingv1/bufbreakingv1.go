@@ -31,7 +31,8 @@ import "github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 // FIRE_WIRE_JSON_COMPATIBLE_TYPE for WIRE_JSON, and
 // FIELD_WIRE_COMPATIBLE_TYPE for WIRE.
 var VersionSpec = &internal.VersionSpec{
-	RuleBuilders:      v1RuleBuilders,
-	DefaultCategories: v1DefaultCategories,
-	IDToCategories:    v1IDToCategories,
+	RuleBuilders:         v1RuleBuilders,
+	DefaultCategories:    v1DefaultCategories,
+	IDToCategories:       v1IDToCategories,
+	DocumentationBaseURL: "https://buf.build/docs/breaking/rules",
 }
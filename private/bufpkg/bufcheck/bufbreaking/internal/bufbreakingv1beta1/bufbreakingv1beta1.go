@@ -21,7 +21,8 @@ import "github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 
 // VersionSpec is the version specification for v1beta1.
 var VersionSpec = &internal.VersionSpec{
-	RuleBuilders:      v1beta1RuleBuilders,
-	DefaultCategories: v1beta1DefaultCategories,
-	IDToCategories:    v1beta1IDToCategories,
+	RuleBuilders:         v1beta1RuleBuilders,
+	DefaultCategories:    v1beta1DefaultCategories,
+	IDToCategories:       v1beta1IDToCategories,
+	DocumentationBaseURL: "https://buf.build/docs/breaking/rules",
 }
@@ -33,6 +33,7 @@ import (
 var AllRuleFormatStrings = []string{
 	"text",
 	"json",
+	"markdown",
 }
 
 // Rule is a rule.
@@ -53,6 +54,13 @@ type Rule interface {
 	//
 	// Full sentence.
 	Purpose() string
+	// Default returns true if the Rule is in one of the default categories
+	// for its configuration version.
+	Default() bool
+	// DocumentationURL returns the URL of the documentation for the Rule.
+	//
+	// May be empty.
+	DocumentationURL() string
 }
 
 // PrintRules prints the rules to the writer.
@@ -62,35 +70,36 @@ func PrintRules(writer io.Writer, rules []Rule, formatString string) (retErr err
 	if len(rules) == 0 {
 		return nil
 	}
-	var asJSON bool
 	switch s := strings.ToLower(strings.TrimSpace(formatString)); s {
 	case "", "text":
-		asJSON = false
+		return printRulesText(writer, rules)
 	case "json":
-		asJSON = true
+		return printRulesJSON(writer, rules)
+	case "markdown":
+		return printRulesMarkdown(writer, rules)
 	default:
 		return fmt.Errorf("unknown format: %q", s)
 	}
-	if !asJSON {
-		tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
-		defer func() {
-			retErr = multierr.Append(retErr, tabWriter.Flush())
-		}()
-		writer = tabWriter
-		if _, err := fmt.Fprintln(writer, "ID\tCATEGORIES\tPURPOSE"); err != nil {
-			return err
-		}
+}
+
+func printRulesText(writer io.Writer, rules []Rule) (retErr error) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	defer func() {
+		retErr = multierr.Append(retErr, tabWriter.Flush())
+	}()
+	if _, err := fmt.Fprintln(tabWriter, "ID\tCATEGORIES\tPURPOSE"); err != nil {
+		return err
 	}
 	for _, rule := range rules {
-		if err := printRule(writer, rule, asJSON); err != nil {
+		if _, err := fmt.Fprintf(tabWriter, "%s\t%s\t%s\n", rule.ID(), strings.Join(rule.Categories(), ", "), rule.Purpose()); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func printRule(writer io.Writer, rule Rule, asJSON bool) error {
-	if asJSON {
+func printRulesJSON(writer io.Writer, rules []Rule) error {
+	for _, rule := range rules {
 		data, err := json.Marshal(rule)
 		if err != nil {
 			return err
@@ -98,10 +107,67 @@ func printRule(writer io.Writer, rule Rule, asJSON bool) error {
 		if _, err := fmt.Fprintln(writer, string(data)); err != nil {
 			return err
 		}
-		return nil
-	}
-	if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", rule.ID(), strings.Join(rule.Categories(), ", "), rule.Purpose()); err != nil {
-		return err
 	}
 	return nil
 }
+
+// printRulesMarkdown prints a table of the rules, followed by each category
+// and the rules within it.
+func printRulesMarkdown(writer io.Writer, rules []Rule) error {
+	var lines []string
+	lines = append(
+		lines,
+		"# Rules",
+		"",
+		"| ID | Categories | Default | Purpose |",
+		"| --- | --- | --- | --- |",
+	)
+	// Rules are sorted by category priority, so the order of first
+	// appearance is the order of the categories.
+	var categories []string
+	categoryToRules := make(map[string][]Rule)
+	for _, rule := range rules {
+		lines = append(
+			lines,
+			fmt.Sprintf(
+				"| %s | %s | %s | %s |",
+				getMarkdownRuleLink(rule),
+				strings.Join(rule.Categories(), ", "),
+				getDefaultString(rule),
+				// Some purposes contain regular expressions with alternations.
+				strings.ReplaceAll(rule.Purpose(), "|", `\|`),
+			),
+		)
+		for _, category := range rule.Categories() {
+			if _, ok := categoryToRules[category]; !ok {
+				categories = append(categories, category)
+			}
+			categoryToRules[category] = append(categoryToRules[category], rule)
+		}
+	}
+	if len(categories) > 0 {
+		lines = append(lines, "", "# Categories")
+		for _, category := range categories {
+			lines = append(lines, "", "## "+category, "")
+			for _, rule := range categoryToRules[category] {
+				lines = append(lines, "- "+getMarkdownRuleLink(rule))
+			}
+		}
+	}
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+func getMarkdownRuleLink(rule Rule) string {
+	if documentationURL := rule.DocumentationURL(); documentationURL != "" {
+		return fmt.Sprintf("[`%s`](%s)", rule.ID(), documentationURL)
+	}
+	return "`" + rule.ID() + "`"
+}
+
+func getDefaultString(rule Rule) string {
+	if rule.Default() {
+		return "yes"
+	}
+	return "no"
+}
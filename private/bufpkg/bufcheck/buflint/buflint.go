@@ -28,6 +28,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
 	"go.uber.org/zap"
 )
 
@@ -48,9 +49,36 @@ type Handler interface {
 		ctx context.Context,
 		config *buflintconfig.Config,
 		image bufimage.Image,
+		options ...CheckOption,
 	) ([]bufanalysis.FileAnnotation, error)
 }
 
+// CheckOption is an option for Check.
+type CheckOption func(*checkOptions)
+
+// CheckWithAgainstImage returns a new CheckOption that checks the image against the
+// previous version of its files in the against image.
+//
+// Rules that check the history of the files, such as FIELD_REMOVED_RESERVED, only
+// report violations if this is set.
+func CheckWithAgainstImage(againstImage bufimage.Image) CheckOption {
+	return func(checkOptions *checkOptions) {
+		checkOptions.againstImage = againstImage
+	}
+}
+
+// CheckWithReadFileFunc returns a new CheckOption that reads the contents of files
+// with readFile to attach machine-applicable fixes to the FileAnnotations.
+//
+// readFile should return an error that fulfills errors.Is(err, fs.ErrNotExist) if the
+// file cannot be found, in which case the FileAnnotations for the file have no fix.
+// If this is not set, no FileAnnotations have a fix.
+func CheckWithReadFileFunc(readFile func(context.Context, bufanalysis.FileInfo) ([]byte, error)) CheckOption {
+	return func(checkOptions *checkOptions) {
+		checkOptions.readFile = readFile
+	}
+}
+
 // NewHandler returns a new Handler.
 func NewHandler(logger *zap.Logger) Handler {
 	return newHandler(logger)
@@ -113,14 +141,100 @@ func GetAllRulesAndCategoriesV1() []string {
 	return internal.AllCategoriesAndIDsForVersionSpec(buflintv1.VersionSpec)
 }
 
-func internalConfigForConfig(config *buflintconfig.Config) (*internal.Config, error) {
-	var versionSpec *internal.VersionSpec
-	switch config.Version {
-	case bufconfig.V1Beta1Version:
-		versionSpec = buflintv1beta1.VersionSpec
-	case bufconfig.V1Version:
-		versionSpec = buflintv1.VersionSpec
+// GetReplacedRulesAndCategoriesV1 returns the rules and categories that are no longer
+// valid for v1, mapped to the v1 rules that replace them.
+//
+// A nil value means the rule was removed without replacement.
+func GetReplacedRulesAndCategoriesV1() map[string][]string {
+	return buflintv1.VersionSpec.ReplacedIDsOrCategories
+}
+
+// GetDeprecations returns the deprecations for the rules and categories that are
+// referenced by the config but are no longer valid for its version.
+//
+// The result is sorted by name.
+func GetDeprecations(config *buflintconfig.Config) []*bufconfig.Deprecation {
+	versionSpec := versionSpecForVersion(config.Version)
+	if versionSpec == nil {
+		return nil
+	}
+	idsOrCategories := append(append([]string{}, config.Use...), config.Except...)
+	for idOrCategory := range config.IgnoreIDOrCategoryToRootPaths {
+		idsOrCategories = append(idsOrCategories, idOrCategory)
+	}
+	var deprecations []*bufconfig.Deprecation
+	for _, idOrCategory := range stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(idsOrCategories) {
+		replacements, ok := versionSpec.ReplacedIDsOrCategories[idOrCategory]
+		if !ok {
+			continue
+		}
+		deprecations = append(
+			deprecations,
+			&bufconfig.Deprecation{
+				Type:            bufconfig.DeprecationTypeLintRule,
+				Name:            idOrCategory,
+				DeprecatedSince: config.Version,
+				RemovalVersion:  bufconfig.DeprecationRemovalVersion,
+				Replacements:    replacements,
+			},
+		)
+	}
+	return deprecations
+}
+
+// GetConfigWarnings returns the warnings for the settings of the config that have
+// no effect or that contradict other settings, such as excepted rules that are not
+// in use, or options for rules that are not run.
+//
+// The result is sorted by key, then value.
+func GetConfigWarnings(config *buflintconfig.Config) []*bufconfig.Warning {
+	versionSpec := versionSpecForVersion(config.Version)
+	if versionSpec == nil {
+		return nil
+	}
+	optionKeyToIDs := make(map[string][]string)
+	if config.EnumZeroValueSuffix != "" {
+		optionKeyToIDs["enum_zero_value_suffix"] = []string{"ENUM_ZERO_VALUE_SUFFIX"}
+	}
+	if config.RPCAllowSameRequestResponse {
+		optionKeyToIDs["rpc_allow_same_request_response"] = []string{"RPC_REQUEST_RESPONSE_UNIQUE"}
+	}
+	if config.RPCAllowGoogleProtobufEmptyRequests {
+		optionKeyToIDs["rpc_allow_google_protobuf_empty_requests"] = []string{"RPC_REQUEST_RESPONSE_UNIQUE", "RPC_REQUEST_STANDARD_NAME"}
 	}
+	if config.RPCAllowGoogleProtobufEmptyResponses {
+		optionKeyToIDs["rpc_allow_google_protobuf_empty_responses"] = []string{"RPC_REQUEST_RESPONSE_UNIQUE", "RPC_RESPONSE_STANDARD_NAME"}
+	}
+	if config.ServiceSuffix != "" {
+		optionKeyToIDs["service_suffix"] = []string{"SERVICE_SUFFIX"}
+	}
+	if len(config.PackageOwners) > 0 {
+		optionKeyToIDs["package_owners"] = []string{"PACKAGE_OWNER_DEFINED"}
+	}
+	// sunset_dates is not checked, as it is also read by buf beta sunset-report.
+	return warningsForInternalConfigWarnings(
+		configBuilderForConfig(config).RuleWarnings(versionSpec, optionKeyToIDs),
+	)
+}
+
+// GetIgnorePathWarnings returns the warnings for the ignore paths of the config that
+// do not match any of the given file paths.
+//
+// The file paths should be the paths of all files of the module, relative to its root.
+// The result is sorted by key, then value.
+func GetIgnorePathWarnings(config *buflintconfig.Config, filePaths []string) []*bufconfig.Warning {
+	return warningsForInternalConfigWarnings(
+		configBuilderForConfig(config).IgnorePathWarnings(filePaths),
+	)
+}
+
+func internalConfigForConfig(config *buflintconfig.Config) (*internal.Config, error) {
+	return configBuilderForConfig(config).NewConfig(
+		versionSpecForVersion(config.Version),
+	)
+}
+
+func configBuilderForConfig(config *buflintconfig.Config) internal.ConfigBuilder {
 	return internal.ConfigBuilder{
 		Use:                                  config.Use,
 		Except:                               config.Except,
@@ -132,9 +246,35 @@ func internalConfigForConfig(config *buflintconfig.Config) (*internal.Config, er
 		RPCAllowGoogleProtobufEmptyRequests:  config.RPCAllowGoogleProtobufEmptyRequests,
 		RPCAllowGoogleProtobufEmptyResponses: config.RPCAllowGoogleProtobufEmptyResponses,
 		ServiceSuffix:                        config.ServiceSuffix,
-	}.NewConfig(
-		versionSpec,
-	)
+		PackageOwners:                        config.PackageOwners,
+		SunsetDates:                          config.SunsetDates,
+	}
+}
+
+func versionSpecForVersion(version string) *internal.VersionSpec {
+	switch version {
+	case bufconfig.V1Beta1Version:
+		return buflintv1beta1.VersionSpec
+	case bufconfig.V1Version:
+		return buflintv1.VersionSpec
+	default:
+		return nil
+	}
+}
+
+func warningsForInternalConfigWarnings(configWarnings []*internal.ConfigWarning) []*bufconfig.Warning {
+	if configWarnings == nil {
+		return nil
+	}
+	warnings := make([]*bufconfig.Warning, len(configWarnings))
+	for i, configWarning := range configWarnings {
+		warnings[i] = &bufconfig.Warning{
+			Key:     "lint." + configWarning.Key,
+			Value:   configWarning.Value,
+			Message: configWarning.Message,
+		}
+	}
+	return warnings
 }
 
 func rulesForInternalRules(rules []*internal.Rule) []bufcheck.Rule {
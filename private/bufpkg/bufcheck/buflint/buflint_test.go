@@ -17,12 +17,14 @@ package buflint_test
 import (
 	"context"
 	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis/bufanalysistesting"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage/bufimagebuild"
@@ -33,8 +35,75 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+func TestRulesForConfigReplaced(t *testing.T) {
+	t.Parallel()
+	rules, err := buflint.RulesForConfig(
+		&buflintconfig.Config{
+			Use:     []string{"FILE_LAYOUT", "FIELD_NO_DESCRIPTOR"},
+			Version: bufconfig.V1Version,
+		},
+	)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		ids = append(ids, rule.ID())
+	}
+	assert.Equal(t, []string{"DIRECTORY_SAME_PACKAGE", "PACKAGE_DIRECTORY_MATCH", "PACKAGE_SAME_DIRECTORY"}, ids)
+	_, err = buflint.RulesForConfig(
+		&buflintconfig.Config{
+			Use:     []string{"FIELD_NO_DESCRIPTOR"},
+			Version: bufconfig.V1Version,
+		},
+	)
+	assert.Error(t, err)
+}
+
+func TestGetDeprecations(t *testing.T) {
+	t.Parallel()
+	deprecations := buflint.GetDeprecations(
+		&buflintconfig.Config{
+			Use:    []string{"FILE_LAYOUT", "DEFAULT"},
+			Except: []string{"FIELD_NO_DESCRIPTOR"},
+			IgnoreIDOrCategoryToRootPaths: map[string][]string{
+				"FILE_LAYOUT": {"foo"},
+			},
+			Version: bufconfig.V1Version,
+		},
+	)
+	assert.Equal(
+		t,
+		[]*bufconfig.Deprecation{
+			{
+				Type:            bufconfig.DeprecationTypeLintRule,
+				Name:            "FIELD_NO_DESCRIPTOR",
+				DeprecatedSince: bufconfig.V1Version,
+				RemovalVersion:  bufconfig.DeprecationRemovalVersion,
+			},
+			{
+				Type:            bufconfig.DeprecationTypeLintRule,
+				Name:            "FILE_LAYOUT",
+				DeprecatedSince: bufconfig.V1Version,
+				RemovalVersion:  bufconfig.DeprecationRemovalVersion,
+				Replacements:    []string{"DIRECTORY_SAME_PACKAGE", "PACKAGE_DIRECTORY_MATCH", "PACKAGE_SAME_DIRECTORY"},
+			},
+		},
+		deprecations,
+	)
+	assert.Empty(
+		t,
+		buflint.GetDeprecations(
+			&buflintconfig.Config{
+				Use:     []string{"FILE_LAYOUT"},
+				Version: bufconfig.V1Beta1Version,
+			},
+		),
+	)
+}
+
 // Hint on how to get these:
 // 1. cd into the specific directory
 // 2. buf lint --error-format=json | jq '[.path, .start_line, .start_column, .end_line, .end_column, .type] | @csv' --raw-output
@@ -485,6 +554,17 @@ func TestRunPackageNoImportCycle(t *testing.T) {
 	)
 }
 
+func TestRunPackageOwnerDefined(t *testing.T) {
+	t.Parallel()
+	testLint(
+		t,
+		"package_owner_defined",
+		bufanalysistesting.NewFileAnnotation(t, "c.proto", 3, 1, 3, 18, "PACKAGE_OWNER_DEFINED"),
+		bufanalysistesting.NewFileAnnotation(t, "d.proto", 3, 1, 3, 22, "PACKAGE_OWNER_DEFINED"),
+		bufanalysistesting.NewFileAnnotation(t, "e.proto", 3, 1, 3, 23, "PACKAGE_OWNER_DEFINED"),
+	)
+}
+
 func TestRunPackageSameDirectory(t *testing.T) {
 	t.Parallel()
 	testLint(
@@ -886,6 +966,42 @@ func TestRunServiceSuffixCustom(t *testing.T) {
 	)
 }
 
+func TestRunFieldRemovedReserved(t *testing.T) {
+	t.Parallel()
+	testLintWithAgainst(
+		t,
+		"field_removed_reserved",
+		bufanalysistesting.NewFileAnnotation(t, "a.proto", 5, 1, 11, 2, "FIELD_REMOVED_RESERVED"),
+		bufanalysistesting.NewFileAnnotation(t, "a.proto", 13, 1, 15, 2, "FIELD_REMOVED_RESERVED"),
+	)
+	// Without history, there is nothing to check.
+	testLint(t, "field_removed_reserved")
+}
+
+func TestRunSunsetNotPassed(t *testing.T) {
+	t.Parallel()
+	testLint(
+		t,
+		"sunset_not_passed",
+		bufanalysistesting.NewFileAnnotation(t, "a.proto", 7, 10, 7, 16, "SUNSET_NOT_PASSED"),
+		bufanalysistesting.NewFileAnnotation(t, "a.proto", 13, 3, 13, 14, "SUNSET_NOT_PASSED"),
+		bufanalysistesting.NewFileAnnotation(t, "a.proto", 17, 7, 17, 18, "SUNSET_NOT_PASSED"),
+	)
+}
+
+func TestRunSunsetNotPassedWarnsNotDeprecated(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image, config := testBuildImage(ctx, t, filepath.Join("testdata", "sunset_not_passed"))
+	core, logs := observer.New(zapcore.WarnLevel)
+	_, err := buflint.NewHandler(zap.New(core)).Check(ctx, config.Lint, image)
+	require.NoError(t, err)
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "acme.weather.v1.Forecast.temp_c", entries[0].ContextMap()["name"])
+}
+
 func TestRunSyntaxSpecified(t *testing.T) {
 	t.Parallel()
 	testLint(
@@ -1087,6 +1203,36 @@ func TestCommentIgnoresCascadeOn(t *testing.T) {
 	)
 }
 
+func TestRunEnumValuePrefixFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "enum_value_prefix", "ENUM_VALUE_PREFIX")
+}
+
+func TestRunEnumValueUpperSnakeCaseFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "enum_value_upper_snake_case", "ENUM_VALUE_UPPER_SNAKE_CASE")
+}
+
+func TestRunFieldLowerSnakeCaseFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "field_lower_snake_case", "FIELD_LOWER_SNAKE_CASE")
+}
+
+func TestRunImportUsedFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "import_used", "IMPORT_USED")
+}
+
+func TestRunRPCPascalCaseFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "rpc_pascal_case", "RPC_PASCAL_CASE")
+}
+
+func TestRunServicePascalCaseFix(t *testing.T) {
+	t.Parallel()
+	testLintFix(t, "service_pascal_case", "SERVICE_PASCAL_CASE")
+}
+
 func testLint(
 	t *testing.T,
 	relDirPath string,
@@ -1194,6 +1340,130 @@ func testLintWithModifiers(
 	)
 }
 
+// testLintWithAgainst lints the module in testdata against the previous version of
+// the module in testdata_previous.
+func testLintWithAgainst(
+	t *testing.T,
+	relDirPath string,
+	expectedFileAnnotations ...bufanalysis.FileAnnotation,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	image, config := testBuildImage(ctx, t, filepath.Join("testdata", relDirPath))
+	againstImage, _ := testBuildImage(ctx, t, filepath.Join("testdata_previous", relDirPath))
+	fileAnnotations, err := buflint.NewHandler(zap.NewNop()).Check(
+		ctx,
+		config.Lint,
+		image,
+		buflint.CheckWithAgainstImage(againstImage),
+	)
+	assert.NoError(t, err)
+	bufanalysistesting.AssertFileAnnotationsEqual(
+		t,
+		expectedFileAnnotations,
+		fileAnnotations,
+	)
+}
+
+// testLintFix applies the fixes of the FileAnnotations of the rule with the id to a copy
+// of the directory, and checks that the rule no longer has any FileAnnotations.
+func testLintFix(
+	t *testing.T,
+	relDirPath string,
+	id string,
+) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	storageosProvider := storageos.NewProvider()
+	readBucket, err := storageosProvider.NewReadWriteBucket(filepath.Join("testdata", relDirPath))
+	require.NoError(t, err)
+	dirPath := t.TempDir()
+	readWriteBucket, err := storageosProvider.NewReadWriteBucket(dirPath)
+	require.NoError(t, err)
+	_, err = storage.Copy(ctx, readBucket, readWriteBucket)
+	require.NoError(t, err)
+	image, config := testBuildImage(ctx, t, dirPath)
+	fileAnnotations, err := buflint.NewHandler(zap.NewNop()).Check(
+		ctx,
+		config.Lint,
+		image,
+		buflint.CheckWithReadFileFunc(
+			func(ctx context.Context, fileInfo bufanalysis.FileInfo) ([]byte, error) {
+				return storage.ReadPath(ctx, readWriteBucket, fileInfo.Path())
+			},
+		),
+	)
+	require.NoError(t, err)
+	pathToFixes := make(map[string][]bufanalysis.Fix)
+	for _, fileAnnotation := range fileAnnotations {
+		if fileAnnotation.Type() != id {
+			continue
+		}
+		require.NotNil(t, fileAnnotation.Fix(), fileAnnotation.String())
+		path := fileAnnotation.FileInfo().Path()
+		pathToFixes[path] = append(pathToFixes[path], fileAnnotation.Fix())
+	}
+	require.NotEmpty(t, pathToFixes)
+	for path, fixes := range pathToFixes {
+		data, err := storage.ReadPath(ctx, readWriteBucket, path)
+		require.NoError(t, err)
+		// Apply the fixes from the end of the file so that the offsets of the
+		// remaining fixes are not changed.
+		sort.Slice(
+			fixes,
+			func(i int, j int) bool {
+				return fixes[i].StartOffset() > fixes[j].StartOffset()
+			},
+		)
+		for _, fix := range fixes {
+			data = append(
+				append(
+					append([]byte{}, data[:fix.StartOffset()]...),
+					fix.Replacement()...,
+				),
+				data[fix.EndOffset():]...,
+			)
+		}
+		require.NoError(t, storage.PutPath(ctx, readWriteBucket, path, data))
+	}
+	image, config = testBuildImage(ctx, t, dirPath)
+	fileAnnotations, err = buflint.NewHandler(zap.NewNop()).Check(
+		ctx,
+		config.Lint,
+		image,
+	)
+	require.NoError(t, err)
+	for _, fileAnnotation := range fileAnnotations {
+		assert.NotEqual(t, id, fileAnnotation.Type(), fileAnnotation.String())
+	}
+}
+
+func testBuildImage(
+	ctx context.Context,
+	t *testing.T,
+	dirPath string,
+) (bufimage.Image, *bufconfig.Config) {
+	readWriteBucket, err := storageos.NewProvider().NewReadWriteBucket(dirPath)
+	require.NoError(t, err)
+	config := testGetConfig(t, readWriteBucket)
+	module, err := bufmodulebuild.NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		readWriteBucket,
+		config.Build,
+	)
+	require.NoError(t, err)
+	image, fileAnnotations, err := bufimagebuild.NewBuilder(
+		zap.NewNop(),
+		bufmodule.NewNopModuleReader(),
+	).Build(
+		ctx,
+		module,
+	)
+	require.NoError(t, err)
+	require.Empty(t, fileAnnotations)
+	return image, config
+}
+
 func testGetConfig(
 	t *testing.T,
 	readBucket storage.ReadBucket,
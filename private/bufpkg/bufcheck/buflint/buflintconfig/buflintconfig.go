@@ -17,14 +17,20 @@ package buflintconfig
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	lintv1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/lint/v1"
 )
 
+// SunsetDateLayout is the time layout of the dates in the sunset dates of a Config.
+const SunsetDateLayout = "2006-01-02"
+
 const (
 	// These versions match the versions in bufconfig. We cannot take an explicit dependency
 	// on bufconfig without creating a circular dependency.
@@ -57,6 +63,14 @@ type Config struct {
 	// ServiceSuffix applies to the SERVICE_SUFFIX rule ID. By default, the rule verifies that all service names
 	// end with the suffix Service. This allows users to override the value with the given string.
 	ServiceSuffix string
+	// PackageOwners is a map of package names to the owners of those packages, and applies to the
+	// PACKAGE_OWNER_DEFINED rule ID. A package is owned by the owners of the longest matching
+	// package name, where "acme" matches both "acme" and "acme.weather.v1".
+	PackageOwners map[string][]string
+	// SunsetDates is a map of the fully-qualified names of deprecated elements to the dates,
+	// in the form YYYY-MM-DD, after which they will be removed, and applies to the
+	// SUNSET_NOT_PASSED rule ID.
+	SunsetDates map[string]string
 	// AllowCommentIgnores turns on comment-driven ignores.
 	AllowCommentIgnores bool
 	// Version represents the version of the lint rule and category IDs that should be used with this config.
@@ -92,6 +106,8 @@ func NewConfigV1(externalConfig ExternalConfigV1) *Config {
 		RPCAllowGoogleProtobufEmptyRequests:  externalConfig.RPCAllowGoogleProtobufEmptyRequests,
 		RPCAllowGoogleProtobufEmptyResponses: externalConfig.RPCAllowGoogleProtobufEmptyResponses,
 		ServiceSuffix:                        externalConfig.ServiceSuffix,
+		PackageOwners:                        externalConfig.PackageOwners,
+		SunsetDates:                          externalConfig.SunsetDates,
 		AllowCommentIgnores:                  externalConfig.AllowCommentIgnores,
 		Version:                              v1Version,
 	}
@@ -160,6 +176,8 @@ type ExternalConfigV1 struct {
 	RPCAllowGoogleProtobufEmptyRequests  bool                `json:"rpc_allow_google_protobuf_empty_requests,omitempty" yaml:"rpc_allow_google_protobuf_empty_requests,omitempty"`
 	RPCAllowGoogleProtobufEmptyResponses bool                `json:"rpc_allow_google_protobuf_empty_responses,omitempty" yaml:"rpc_allow_google_protobuf_empty_responses,omitempty"`
 	ServiceSuffix                        string              `json:"service_suffix,omitempty" yaml:"service_suffix,omitempty"`
+	PackageOwners                        map[string][]string `json:"package_owners,omitempty" yaml:"package_owners,omitempty"`
+	SunsetDates                          map[string]string   `json:"sunset_dates,omitempty" yaml:"sunset_dates,omitempty"`
 	AllowCommentIgnores                  bool                `json:"allow_comment_ignores,omitempty" yaml:"allow_comment_ignores,omitempty"`
 }
 
@@ -191,10 +209,54 @@ func ExternalConfigV1ForConfig(config *Config) ExternalConfigV1 {
 		RPCAllowGoogleProtobufEmptyRequests:  config.RPCAllowGoogleProtobufEmptyRequests,
 		RPCAllowGoogleProtobufEmptyResponses: config.RPCAllowGoogleProtobufEmptyResponses,
 		ServiceSuffix:                        config.ServiceSuffix,
+		PackageOwners:                        config.PackageOwners,
+		SunsetDates:                          config.SunsetDates,
 		AllowCommentIgnores:                  config.AllowCommentIgnores,
 	}
 }
 
+// OwnersForPackage returns the owners of the package given the package owners of a Config.
+//
+// The owners of the longest package in packageOwners that is equal to pkg, or that pkg
+// is a sub-package of, are returned. Returns nil if the package has no owners.
+func OwnersForPackage(packageOwners map[string][]string, pkg string) []string {
+	if pkg == "" {
+		return nil
+	}
+	var longestOwnedPkg string
+	var owners []string
+	for ownedPkg, ownedPkgOwners := range packageOwners {
+		if pkg != ownedPkg && !strings.HasPrefix(pkg, ownedPkg+".") {
+			continue
+		}
+		if len(ownedPkg) >= len(longestOwnedPkg) {
+			longestOwnedPkg = ownedPkg
+			owners = ownedPkgOwners
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+	return owners
+}
+
+// ParseSunsetDates parses the sunset dates of a Config into a map of fully-qualified names
+// to the dates, as UTC midnight, after which the elements will be removed.
+func ParseSunsetDates(sunsetDates map[string]string) (map[string]time.Time, error) {
+	fullNameToSunsetDate := make(map[string]time.Time, len(sunsetDates))
+	for fullName, sunsetDate := range sunsetDates {
+		if fullName == "" {
+			return nil, errors.New("sunset_dates cannot contain an empty name")
+		}
+		date, err := time.Parse(SunsetDateLayout, sunsetDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunset date %q for %q: must be in the form YYYY-MM-DD", sunsetDate, fullName)
+		}
+		fullNameToSunsetDate[fullName] = date
+	}
+	return fullNameToSunsetDate, nil
+}
+
 // BytesForConfig takes a *Config and returns the deterministic []byte representation.
 // We use an unexported intermediary JSON form and sort all fields to ensure that the bytes
 // associated with the *Config are deterministic.
@@ -206,17 +268,19 @@ func BytesForConfig(config *Config) ([]byte, error) {
 }
 
 type configJSON struct {
-	Use                                  []string      `json:"use,omitempty"`
-	Except                               []string      `json:"except,omitempty"`
-	IgnoreRootPaths                      []string      `json:"ignore_root_paths,omitempty"`
-	IgnoreIDOrCategoryToRootPaths        []idPathsJSON `json:"ignore_id_to_root_paths,omitempty"`
-	EnumZeroValueSuffix                  string        `json:"enum_zero_value_suffix,omitempty"`
-	RPCAllowSameRequestResponse          bool          `json:"rpc_allow_same_request_response,omitempty"`
-	RPCAllowGoogleProtobufEmptyRequests  bool          `json:"rpc_allow_google_protobuf_empty_requests,omitempty"`
-	RPCAllowGoogleProtobufEmptyResponses bool          `json:"rpc_allow_google_protobuf_empty_response,omitempty"`
-	ServiceSuffix                        string        `json:"service_suffix,omitempty"`
-	AllowCommentIgnores                  bool          `json:"allow_comment_ignores,omitempty"`
-	Version                              string        `json:"version,omitempty"`
+	Use                                  []string            `json:"use,omitempty"`
+	Except                               []string            `json:"except,omitempty"`
+	IgnoreRootPaths                      []string            `json:"ignore_root_paths,omitempty"`
+	IgnoreIDOrCategoryToRootPaths        []idPathsJSON       `json:"ignore_id_to_root_paths,omitempty"`
+	EnumZeroValueSuffix                  string              `json:"enum_zero_value_suffix,omitempty"`
+	RPCAllowSameRequestResponse          bool                `json:"rpc_allow_same_request_response,omitempty"`
+	RPCAllowGoogleProtobufEmptyRequests  bool                `json:"rpc_allow_google_protobuf_empty_requests,omitempty"`
+	RPCAllowGoogleProtobufEmptyResponses bool                `json:"rpc_allow_google_protobuf_empty_response,omitempty"`
+	ServiceSuffix                        string              `json:"service_suffix,omitempty"`
+	PackageOwners                        []packageOwnersJSON `json:"package_owners,omitempty"`
+	SunsetDates                          []sunsetDateJSON    `json:"sunset_dates,omitempty"`
+	AllowCommentIgnores                  bool                `json:"allow_comment_ignores,omitempty"`
+	Version                              string              `json:"version,omitempty"`
 }
 
 type idPathsJSON struct {
@@ -224,6 +288,16 @@ type idPathsJSON struct {
 	Paths []string `json:"paths,omitempty"`
 }
 
+type sunsetDateJSON struct {
+	Name string `json:"name,omitempty"`
+	Date string `json:"date,omitempty"`
+}
+
+type packageOwnersJSON struct {
+	Package string   `json:"package,omitempty"`
+	Owners  []string `json:"owners,omitempty"`
+}
+
 func configToJSON(config *Config) *configJSON {
 	ignoreIDPathsJSON := make([]idPathsJSON, 0, len(config.IgnoreIDOrCategoryToRootPaths))
 	for ignoreID, rootPaths := range config.IgnoreIDOrCategoryToRootPaths {
@@ -236,6 +310,25 @@ func configToJSON(config *Config) *configJSON {
 		})
 	}
 	sort.Slice(ignoreIDPathsJSON, func(i, j int) bool { return ignoreIDPathsJSON[i].ID < ignoreIDPathsJSON[j].ID })
+	var packageOwners []packageOwnersJSON
+	for pkg, owners := range config.PackageOwners {
+		ownersCopy := make([]string, len(owners))
+		copy(ownersCopy, owners)
+		sort.Strings(ownersCopy)
+		packageOwners = append(packageOwners, packageOwnersJSON{
+			Package: pkg,
+			Owners:  ownersCopy,
+		})
+	}
+	sort.Slice(packageOwners, func(i, j int) bool { return packageOwners[i].Package < packageOwners[j].Package })
+	var sunsetDates []sunsetDateJSON
+	for fullName, sunsetDate := range config.SunsetDates {
+		sunsetDates = append(sunsetDates, sunsetDateJSON{
+			Name: fullName,
+			Date: sunsetDate,
+		})
+	}
+	sort.Slice(sunsetDates, func(i, j int) bool { return sunsetDates[i].Name < sunsetDates[j].Name })
 	// We should not be sorting in place for the config structure, since it will mutate the
 	// underlying config ordering.
 	use := make([]string, len(config.Use))
@@ -257,6 +350,8 @@ func configToJSON(config *Config) *configJSON {
 		RPCAllowGoogleProtobufEmptyRequests:  config.RPCAllowGoogleProtobufEmptyRequests,
 		RPCAllowGoogleProtobufEmptyResponses: config.RPCAllowGoogleProtobufEmptyResponses,
 		ServiceSuffix:                        config.ServiceSuffix,
+		PackageOwners:                        packageOwners,
+		SunsetDates:                          sunsetDates,
 		AllowCommentIgnores:                  config.AllowCommentIgnores,
 		Version:                              config.Version,
 	}
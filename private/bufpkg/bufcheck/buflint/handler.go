@@ -50,14 +50,70 @@ func (h *handler) Check(
 	ctx context.Context,
 	config *buflintconfig.Config,
 	image bufimage.Image,
+	options ...CheckOption,
 ) ([]bufanalysis.FileAnnotation, error) {
+	checkOptions := newCheckOptions()
+	for _, option := range options {
+		option(checkOptions)
+	}
 	files, err := protosource.NewFilesUnstable(ctx, bufimageutil.NewInputFiles(image.Files())...)
 	if err != nil {
 		return nil, err
 	}
+	var againstFiles []protosource.File
+	if checkOptions.againstImage != nil {
+		againstFiles, err = protosource.NewFilesUnstable(ctx, bufimageutil.NewInputFiles(checkOptions.againstImage.Files())...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(config.SunsetDates) > 0 {
+		if err := h.warnSunsetDatesNotDeprecated(files, config.SunsetDates); err != nil {
+			return nil, err
+		}
+	}
 	internalConfig, err := internalConfigForConfig(config)
 	if err != nil {
 		return nil, err
 	}
-	return h.runner.Check(ctx, internalConfig, nil, files)
+	fileAnnotations, err := h.runner.Check(ctx, internalConfig, againstFiles, files)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ResolveFixes(ctx, fileAnnotations, checkOptions.readFile)
+}
+
+// warnSunsetDatesNotDeprecated warns for each element of the non-import files that has
+// a sunset date but is not deprecated, as sunsets only apply to deprecated elements.
+func (h *handler) warnSunsetDatesNotDeprecated(files []protosource.File, sunsetDates map[string]string) error {
+	for _, file := range files {
+		if file.IsImport() {
+			continue
+		}
+		if err := protosource.ForEachDeprecatableDescriptor(
+			func(deprecatableDescriptor protosource.DeprecatableDescriptor) error {
+				fullName := deprecatableDescriptor.FullName()
+				if _, ok := sunsetDates[fullName]; ok && !deprecatableDescriptor.Deprecated() {
+					h.logger.Warn(
+						"element in lint.sunset_dates is not deprecated, SUNSET_NOT_PASSED does not apply to it",
+						zap.String("name", fullName),
+					)
+				}
+				return nil
+			},
+			file,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checkOptions struct {
+	againstImage bufimage.Image
+	readFile     func(context.Context, bufanalysis.FileInfo) ([]byte, error)
+}
+
+func newCheckOptions() *checkOptions {
+	return &checkOptions{}
 }
@@ -17,8 +17,10 @@ package buflintbuild
 
 import (
 	"errors"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/internal/buflintcheck"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 	"github.com/bufbuild/buf/private/pkg/protosource"
@@ -133,6 +135,12 @@ var (
 		`field names are not name capitalization of "descriptor" with any number of prefix or suffix underscores`,
 		newAdapter(buflintcheck.CheckFieldNoDescriptor),
 	)
+	// FieldRemovedReservedRuleBuilder is a rule builder.
+	FieldRemovedReservedRuleBuilder = internal.NewNopRuleBuilder(
+		"FIELD_REMOVED_RESERVED",
+		"fields removed since the against input have their numbers and names reserved",
+		buflintcheck.CheckFieldRemovedReserved,
+	)
 	// FileLowerSnakeCaseRuleBuilder is a rule builder.
 	FileLowerSnakeCaseRuleBuilder = internal.NewNopRuleBuilder(
 		"FILE_LOWER_SNAKE_CASE",
@@ -193,6 +201,18 @@ var (
 		"packages do not have import cycles",
 		newAdapter(buflintcheck.CheckPackageNoImportCycle),
 	)
+	// PackageOwnerDefinedRuleBuilder is a rule builder.
+	PackageOwnerDefinedRuleBuilder = internal.NewRuleBuilder(
+		"PACKAGE_OWNER_DEFINED",
+		func(configBuilder internal.ConfigBuilder) (string, error) {
+			return "all packages have an owner defined in package_owners", nil
+		},
+		func(configBuilder internal.ConfigBuilder) (internal.CheckFunc, error) {
+			return internal.CheckFunc(func(id string, ignoreFunc internal.IgnoreFunc, _ []protosource.File, files []protosource.File) ([]bufanalysis.FileAnnotation, error) {
+				return buflintcheck.CheckPackageOwnerDefined(id, ignoreFunc, files, configBuilder.PackageOwners)
+			}), nil
+		},
+	)
 	// PackageSameCsharpNamespaceRuleBuilder is a rule builder.
 	PackageSameCsharpNamespaceRuleBuilder = internal.NewNopRuleBuilder(
 		"PACKAGE_SAME_CSHARP_NAMESPACE",
@@ -348,6 +368,22 @@ var (
 			}), nil
 		},
 	)
+	// SunsetNotPassedRuleBuilder is a rule builder.
+	SunsetNotPassedRuleBuilder = internal.NewRuleBuilder(
+		"SUNSET_NOT_PASSED",
+		func(configBuilder internal.ConfigBuilder) (string, error) {
+			return "no deprecated elements in sunset_dates are past their sunset date", nil
+		},
+		func(configBuilder internal.ConfigBuilder) (internal.CheckFunc, error) {
+			sunsetDates, err := buflintconfig.ParseSunsetDates(configBuilder.SunsetDates)
+			if err != nil {
+				return nil, err
+			}
+			return internal.CheckFunc(func(id string, ignoreFunc internal.IgnoreFunc, _ []protosource.File, files []protosource.File) ([]bufanalysis.FileAnnotation, error) {
+				return buflintcheck.CheckSunsetNotPassed(id, ignoreFunc, files, sunsetDates, time.Now())
+			}), nil
+		},
+	)
 	// SyntaxSpecifiedRuleBuilder is a rule builder.
 	SyntaxSpecifiedRuleBuilder = internal.NewNopRuleBuilder(
 		"SYNTAX_SPECIFIED",
@@ -22,8 +22,10 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/buflintconfig"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/buflint/internal/buflintvalidate"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
@@ -182,7 +184,10 @@ func checkEnumFirstValueZero(add addFunc, enum protosource.Enum) error {
 }
 
 // CheckEnumValuePrefix is a check function.
-var CheckEnumValuePrefix = newEnumValueCheckFunc(checkEnumValuePrefix)
+var CheckEnumValuePrefix = newEnumValueCheckFunc(
+	checkEnumValuePrefix,
+	withFixFunc(fixEnumValuePrefix),
+)
 
 func checkEnumValuePrefix(add addFunc, enumValue protosource.EnumValue) error {
 	name := enumValue.Name()
@@ -205,8 +210,20 @@ func checkEnumValuePrefix(add addFunc, enumValue protosource.EnumValue) error {
 	return nil
 }
 
+func fixEnumValuePrefix(descriptor protosource.Descriptor, location protosource.Location) *internal.Edit {
+	enumValue, ok := descriptor.(protosource.EnumValue)
+	if !ok {
+		return nil
+	}
+	// The location is the name of the enum value.
+	return newEditForLocation(location, fieldToUpperSnakeCase(enumValue.Enum().Name())+"_"+enumValue.Name())
+}
+
 // CheckEnumValueUpperSnakeCase is a check function.
-var CheckEnumValueUpperSnakeCase = newEnumValueCheckFunc(checkEnumValueUpperSnakeCase)
+var CheckEnumValueUpperSnakeCase = newEnumValueCheckFunc(
+	checkEnumValueUpperSnakeCase,
+	withFixFunc(fixEnumValueUpperSnakeCase),
+)
 
 func checkEnumValueUpperSnakeCase(add addFunc, enumValue protosource.EnumValue) error {
 	name := enumValue.Name()
@@ -228,6 +245,15 @@ func checkEnumValueUpperSnakeCase(add addFunc, enumValue protosource.EnumValue)
 	return nil
 }
 
+func fixEnumValueUpperSnakeCase(descriptor protosource.Descriptor, location protosource.Location) *internal.Edit {
+	enumValue, ok := descriptor.(protosource.EnumValue)
+	if !ok {
+		return nil
+	}
+	// The location is the name of the enum value.
+	return newEditForLocation(location, fieldToUpperSnakeCase(enumValue.Name()))
+}
+
 // CheckEnumZeroValueSuffix is a check function.
 var CheckEnumZeroValueSuffix = func(
 	id string,
@@ -265,7 +291,10 @@ func checkEnumZeroValueSuffix(add addFunc, enumValue protosource.EnumValue, suff
 }
 
 // CheckFieldLowerSnakeCase is a check function.
-var CheckFieldLowerSnakeCase = newFieldCheckFunc(checkFieldLowerSnakeCase)
+var CheckFieldLowerSnakeCase = newFieldCheckFunc(
+	checkFieldLowerSnakeCase,
+	withFixFunc(fixFieldLowerSnakeCase),
+)
 
 func checkFieldLowerSnakeCase(add addFunc, field protosource.Field) error {
 	message := field.ParentMessage()
@@ -296,6 +325,15 @@ func checkFieldLowerSnakeCase(add addFunc, field protosource.Field) error {
 	return nil
 }
 
+func fixFieldLowerSnakeCase(descriptor protosource.Descriptor, location protosource.Location) *internal.Edit {
+	field, ok := descriptor.(protosource.Field)
+	if !ok {
+		return nil
+	}
+	// The location is the name of the field.
+	return newEditForLocation(location, fieldToLowerSnakeCase(field.Name()))
+}
+
 // CheckFieldNoDescriptor is a check function.
 var CheckFieldNoDescriptor = newFieldCheckFunc(checkFieldNoDescriptor)
 
@@ -317,6 +355,56 @@ func checkFieldNoDescriptor(add addFunc, field protosource.Field) error {
 	return nil
 }
 
+// CheckFieldRemovedReserved is a check function.
+var CheckFieldRemovedReserved = newMessagePairCheckFunc(checkFieldRemovedReserved)
+
+func checkFieldRemovedReserved(add addFunc, previousMessage protosource.Message, message protosource.Message) error {
+	previousNumberToField, err := protosource.NumberToMessageField(previousMessage)
+	if err != nil {
+		return err
+	}
+	numberToField, err := protosource.NumberToMessageField(message)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]struct{}, len(numberToField))
+	for _, field := range numberToField {
+		names[field.Name()] = struct{}{}
+	}
+	for _, previousNumber := range slicesext.MapKeysToSortedSlice(previousNumberToField) {
+		if _, ok := numberToField[previousNumber]; ok {
+			continue
+		}
+		previousName := previousNumberToField[previousNumber].Name()
+		numberReserved := protosource.NumberInReservedRanges(previousNumber, message.ReservedTagRanges()...)
+		// The name cannot be reserved if a field with another number now uses it.
+		_, nameUsed := names[previousName]
+		nameReserved := nameUsed || protosource.NameInReservedNames(previousName, message.ReservedNames()...)
+		var unreserved string
+		switch {
+		case numberReserved && nameReserved:
+			continue
+		case numberReserved:
+			unreserved = fmt.Sprintf("the name %q", previousName)
+		case nameReserved:
+			unreserved = fmt.Sprintf(`the number "%d"`, previousNumber)
+		default:
+			unreserved = fmt.Sprintf(`the number "%d" and the name %q`, previousNumber, previousName)
+		}
+		add(
+			message,
+			message.Location(),
+			nil,
+			`Field "%d" with name %q on message %q was removed without reserving %s.`,
+			previousNumber,
+			previousName,
+			message.Name(),
+			unreserved,
+		)
+	}
+	return nil
+}
+
 // CheckFileLowerSnakeCase is a check function.
 var CheckFileLowerSnakeCase = newFileCheckFunc(checkFileLowerSnakeCase)
 
@@ -338,7 +426,10 @@ var (
 	// CheckImportNoWeak is a check function.
 	CheckImportNoWeak = newFileImportCheckFunc(checkImportNoWeak)
 	// CheckImportUsed is a check function.
-	CheckImportUsed = newFileImportCheckFunc(checkImportUsed)
+	CheckImportUsed = newFileImportCheckFunc(
+		checkImportUsed,
+		withFixFunc(fixImportUsed),
+	)
 )
 
 func checkImportNoPublic(add addFunc, fileImport protosource.FileImport) error {
@@ -363,6 +454,12 @@ func checkImportUsed(add addFunc, fileImport protosource.FileImport) error {
 	return nil
 }
 
+func fixImportUsed(_ protosource.Descriptor, location protosource.Location) *internal.Edit {
+	// The location of an import is the entire import statement, delete it along
+	// with its line terminator so that no blank line is left behind.
+	return newDeleteLinesEditForLocation(location)
+}
+
 // CheckMessagePascalCase is a check function.
 var CheckMessagePascalCase = newMessageCheckFunc(checkMessagePascalCase)
 
@@ -534,6 +631,73 @@ func checkPackageSameDirectory(add addFunc, pkg string, files []protosource.File
 	return nil
 }
 
+// CheckPackageOwnerDefined is a check function.
+var CheckPackageOwnerDefined = func(
+	id string,
+	ignoreFunc internal.IgnoreFunc,
+	files []protosource.File,
+	packageOwners map[string][]string,
+) ([]bufanalysis.FileAnnotation, error) {
+	return newFileCheckFunc(
+		func(add addFunc, file protosource.File) error {
+			return checkPackageOwnerDefined(add, file, packageOwners)
+		},
+	)(id, ignoreFunc, files)
+}
+
+func checkPackageOwnerDefined(add addFunc, file protosource.File, packageOwners map[string][]string) error {
+	pkg := file.Package()
+	// PACKAGE_DEFINED handles files without a package
+	if pkg == "" {
+		return nil
+	}
+	if len(buflintconfig.OwnersForPackage(packageOwners, pkg)) == 0 {
+		add(file, file.PackageLocation(), nil, `Package %q does not have an owner defined in package_owners.`, pkg)
+	}
+	return nil
+}
+
+// CheckSunsetNotPassed is a check function.
+var CheckSunsetNotPassed = func(
+	id string,
+	ignoreFunc internal.IgnoreFunc,
+	files []protosource.File,
+	sunsetDates map[string]time.Time,
+	now time.Time,
+) ([]bufanalysis.FileAnnotation, error) {
+	return newFileCheckFunc(
+		func(add addFunc, file protosource.File) error {
+			return checkSunsetNotPassed(add, file, sunsetDates, now)
+		},
+	)(id, ignoreFunc, files)
+}
+
+func checkSunsetNotPassed(add addFunc, file protosource.File, sunsetDates map[string]time.Time, now time.Time) error {
+	if len(sunsetDates) == 0 {
+		return nil
+	}
+	return protosource.ForEachDeprecatableDescriptor(
+		func(deprecatableDescriptor protosource.DeprecatableDescriptor) error {
+			fullName := deprecatableDescriptor.FullName()
+			sunsetDate, ok := sunsetDates[fullName]
+			// sunsets only apply to deprecated elements
+			if !ok || !deprecatableDescriptor.Deprecated() || now.Before(sunsetDate) {
+				return nil
+			}
+			add(
+				deprecatableDescriptor,
+				deprecatableDescriptor.NameLocation(),
+				nil,
+				`%q passed its sunset date of %s and should be removed.`,
+				fullName,
+				sunsetDate.Format(buflintconfig.SunsetDateLayout),
+			)
+			return nil
+		},
+		file,
+	)
+}
+
 var (
 	// CheckPackageSameCsharpNamespace is a check function.
 	CheckPackageSameCsharpNamespace = newPackageToFilesCheckFunc(checkPackageSameCsharpNamespace)
@@ -677,7 +841,10 @@ func checkRPCNoServerStreaming(add addFunc, method protosource.Method) error {
 }
 
 // CheckRPCPascalCase is a check function.
-var CheckRPCPascalCase = newMethodCheckFunc(checkRPCPascalCase)
+var CheckRPCPascalCase = newMethodCheckFunc(
+	checkRPCPascalCase,
+	withFixFunc(fixRPCPascalCase),
+)
 
 func checkRPCPascalCase(add addFunc, method protosource.Method) error {
 	name := method.Name()
@@ -699,6 +866,15 @@ func checkRPCPascalCase(add addFunc, method protosource.Method) error {
 	return nil
 }
 
+func fixRPCPascalCase(descriptor protosource.Descriptor, location protosource.Location) *internal.Edit {
+	method, ok := descriptor.(protosource.Method)
+	if !ok {
+		return nil
+	}
+	// The location is the name of the method.
+	return newEditForLocation(location, stringutil.ToPascalCase(method.Name()))
+}
+
 // CheckRPCRequestResponseUnique is a check function.
 var CheckRPCRequestResponseUnique = func(
 	id string,
@@ -939,7 +1115,10 @@ func checkRPCResponseStandardName(add addFunc, method protosource.Method, allowG
 }
 
 // CheckServicePascalCase is a check function.
-var CheckServicePascalCase = newServiceCheckFunc(checkServicePascalCase)
+var CheckServicePascalCase = newServiceCheckFunc(
+	checkServicePascalCase,
+	withFixFunc(fixServicePascalCase),
+)
 
 func checkServicePascalCase(add addFunc, service protosource.Service) error {
 	name := service.Name()
@@ -950,6 +1129,15 @@ func checkServicePascalCase(add addFunc, service protosource.Service) error {
 	return nil
 }
 
+func fixServicePascalCase(descriptor protosource.Descriptor, location protosource.Location) *internal.Edit {
+	service, ok := descriptor.(protosource.Service)
+	if !ok {
+		return nil
+	}
+	// The location is the name of the service.
+	return newEditForLocation(location, stringutil.ToPascalCase(service.Name()))
+}
+
 // CheckServiceSuffix is a check function.
 var CheckServiceSuffix = func(
 	id string,
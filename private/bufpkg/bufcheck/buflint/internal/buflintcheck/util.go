@@ -28,6 +28,61 @@ import (
 // Both the Descriptor and Locations can be nil.
 type addFunc func(protosource.Descriptor, protosource.Location, []protosource.Location, string, ...interface{})
 
+// fixFunc returns the Edit that fixes a FileAnnotation added for the Descriptor at the Location.
+//
+// Returns nil if no fix is known.
+type fixFunc func(protosource.Descriptor, protosource.Location) *internal.Edit
+
+// checkFuncOption is an option for a new check function.
+type checkFuncOption func(*checkFuncOptions)
+
+// withFixFunc attaches the Edit returned by the fixFunc to every FileAnnotation added by the check.
+func withFixFunc(fixFunc fixFunc) checkFuncOption {
+	return func(checkFuncOptions *checkFuncOptions) {
+		checkFuncOptions.fixFunc = fixFunc
+	}
+}
+
+type checkFuncOptions struct {
+	fixFunc fixFunc
+}
+
+func newCheckFuncOptions() *checkFuncOptions {
+	return &checkFuncOptions{}
+}
+
+// newEditForLocation returns an Edit that replaces the text at the location with the replacement.
+//
+// Returns nil if the location is nil, that is if the file has no source code info.
+func newEditForLocation(location protosource.Location, replacement string) *internal.Edit {
+	if location == nil {
+		return nil
+	}
+	return &internal.Edit{
+		StartLine:   location.StartLine(),
+		StartColumn: location.StartColumn(),
+		EndLine:     location.EndLine(),
+		EndColumn:   location.EndColumn(),
+		Replacement: replacement,
+	}
+}
+
+// newDeleteLinesEditForLocation returns an Edit that deletes the text at the location
+// through the end of its last line, including the line terminator.
+//
+// Returns nil if the location is nil, that is if the file has no source code info.
+func newDeleteLinesEditForLocation(location protosource.Location) *internal.Edit {
+	if location == nil {
+		return nil
+	}
+	return &internal.Edit{
+		StartLine:   location.StartLine(),
+		StartColumn: location.StartColumn(),
+		EndLine:     location.EndLine() + 1,
+		EndColumn:   1,
+	}
+}
+
 func fieldToLowerSnakeCase(s string) string {
 	// Try running this on googleapis and watch
 	// We allow both effectively by not passing the option
@@ -114,7 +169,12 @@ func newFilesWithImportsCheckFunc(
 
 func newFilesCheckFunc(
 	f func(addFunc, []protosource.File) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
+	checkFuncOptions := newCheckFuncOptions()
+	for _, option := range options {
+		option(checkFuncOptions)
+	}
 	return func(id string, ignoreFunc internal.IgnoreFunc, files []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 		filesWithoutImports := make([]protosource.File, 0, len(files))
 		for _, file := range files {
@@ -123,7 +183,26 @@ func newFilesCheckFunc(
 			}
 		}
 		helper := internal.NewHelper(id, ignoreFunc)
-		if err := f(helper.AddFileAnnotationWithExtraIgnoreLocationsf, filesWithoutImports); err != nil {
+		add := helper.AddFileAnnotationWithExtraIgnoreLocationsf
+		if fixFunc := checkFuncOptions.fixFunc; fixFunc != nil {
+			add = func(
+				descriptor protosource.Descriptor,
+				location protosource.Location,
+				extraIgnoreLocations []protosource.Location,
+				format string,
+				args ...interface{},
+			) {
+				helper.AddFileAnnotationWithEditf(
+					descriptor,
+					location,
+					extraIgnoreLocations,
+					fixFunc(descriptor, location),
+					format,
+					args...,
+				)
+			}
+		}
+		if err := f(add, filesWithoutImports); err != nil {
 			return nil, err
 		}
 		return helper.FileAnnotations(), nil
@@ -170,6 +249,7 @@ func newDirToFilesCheckFunc(
 
 func newFileCheckFunc(
 	f func(addFunc, protosource.File) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newFilesCheckFunc(
 		func(add addFunc, files []protosource.File) error {
@@ -180,11 +260,13 @@ func newFileCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
 
 func newFileImportCheckFunc(
 	f func(addFunc, protosource.FileImport) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newFileCheckFunc(
 		func(add addFunc, file protosource.File) error {
@@ -195,11 +277,13 @@ func newFileImportCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
 
 func newEnumCheckFunc(
 	f func(addFunc, protosource.Enum) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newFileCheckFunc(
 		func(add addFunc, file protosource.File) error {
@@ -210,11 +294,13 @@ func newEnumCheckFunc(
 				file,
 			)
 		},
+		options...,
 	)
 }
 
 func newEnumValueCheckFunc(
 	f func(addFunc, protosource.EnumValue) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newEnumCheckFunc(
 		func(add addFunc, enum protosource.Enum) error {
@@ -225,11 +311,13 @@ func newEnumValueCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
 
 func newMessageCheckFunc(
 	f func(addFunc, protosource.Message) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newFileCheckFunc(
 		func(add addFunc, file protosource.File) error {
@@ -240,11 +328,40 @@ func newMessageCheckFunc(
 				file,
 			)
 		},
+		options...,
 	)
 }
 
+// newMessagePairCheckFunc returns a check function for the messages of the files that
+// are also in the previous files, that is the history of the files.
+//
+// If there are no previous files, nothing is checked.
+func newMessagePairCheckFunc(
+	f func(addFunc, protosource.Message, protosource.Message) error,
+) func(string, internal.IgnoreFunc, []protosource.File, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
+	return func(id string, ignoreFunc internal.IgnoreFunc, previousFiles []protosource.File, files []protosource.File) ([]bufanalysis.FileAnnotation, error) {
+		if len(previousFiles) == 0 {
+			return nil, nil
+		}
+		previousFullNameToMessage, err := protosource.FullNameToMessage(previousFiles...)
+		if err != nil {
+			return nil, err
+		}
+		return newMessageCheckFunc(
+			func(add addFunc, message protosource.Message) error {
+				previousMessage, ok := previousFullNameToMessage[message.FullName()]
+				if !ok {
+					return nil
+				}
+				return f(add, previousMessage, message)
+			},
+		)(id, ignoreFunc, files)
+	}
+}
+
 func newFieldCheckFunc(
 	f func(addFunc, protosource.Field) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newMessageCheckFunc(
 		func(add addFunc, message protosource.Message) error {
@@ -261,6 +378,7 @@ func newFieldCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
 
@@ -281,6 +399,7 @@ func newOneofCheckFunc(
 
 func newServiceCheckFunc(
 	f func(addFunc, protosource.Service) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newFileCheckFunc(
 		func(add addFunc, file protosource.File) error {
@@ -291,11 +410,13 @@ func newServiceCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
 
 func newMethodCheckFunc(
 	f func(addFunc, protosource.Method) error,
+	options ...checkFuncOption,
 ) func(string, internal.IgnoreFunc, []protosource.File) ([]bufanalysis.FileAnnotation, error) {
 	return newServiceCheckFunc(
 		func(add addFunc, service protosource.Service) error {
@@ -306,5 +427,6 @@ func newMethodCheckFunc(
 			}
 			return nil
 		},
+		options...,
 	)
 }
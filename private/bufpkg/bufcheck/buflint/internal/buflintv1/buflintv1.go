@@ -28,6 +28,9 @@ import "github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 // The IMPORT_USED rule was added to BASIC, DEFAULT.
 // ENUM_FIRST_VALUE_ZERO was added to BASIC, DEFAULT.
 // PACKAGE_NO_IMPORT_CYCLE was added as an uncategorized lint rule.
+// PACKAGE_OWNER_DEFINED was added as an uncategorized lint rule.
+// SUNSET_NOT_PASSED was added as an uncategorized lint rule.
+// FIELD_REMOVED_RESERVED was added as an uncategorized lint rule.
 // The FIELD_NO_DESCRIPTOR rule was removed altogether.
 //
 // A number of categories were removed between v1beta1 and v1. The difference
@@ -74,7 +77,9 @@ import "github.com/bufbuild/buf/private/bufpkg/bufcheck/internal"
 //   - PACKAGE_DIRECTORY_MATCH
 //   - PACKAGE_SAME_DIRECTORY
 var VersionSpec = &internal.VersionSpec{
-	RuleBuilders:      v1RuleBuilders,
-	DefaultCategories: v1DefaultCategories,
-	IDToCategories:    v1IDToCategories,
+	RuleBuilders:            v1RuleBuilders,
+	DefaultCategories:       v1DefaultCategories,
+	IDToCategories:          v1IDToCategories,
+	ReplacedIDsOrCategories: v1ReplacedIDsOrCategories,
+	DocumentationBaseURL:    "https://buf.build/docs/lint/rules",
 }
@@ -37,6 +37,7 @@ var (
 		buflintbuild.EnumValueUpperSnakeCaseRuleBuilder,
 		buflintbuild.EnumZeroValueSuffixRuleBuilder,
 		buflintbuild.FieldLowerSnakeCaseRuleBuilder,
+		buflintbuild.FieldRemovedReservedRuleBuilder,
 		buflintbuild.FileLowerSnakeCaseRuleBuilder,
 		buflintbuild.ImportNoPublicRuleBuilder,
 		buflintbuild.ImportNoWeakRuleBuilder,
@@ -47,6 +48,7 @@ var (
 		buflintbuild.PackageDirectoryMatchRuleBuilder,
 		buflintbuild.PackageLowerSnakeCaseRuleBuilder,
 		buflintbuild.PackageNoImportCycleRuleBuilder,
+		buflintbuild.PackageOwnerDefinedRuleBuilder,
 		buflintbuild.PackageSameCsharpNamespaceRuleBuilder,
 		buflintbuild.PackageSameDirectoryRuleBuilder,
 		buflintbuild.PackageSameGoPackageRuleBuilder,
@@ -65,6 +67,7 @@ var (
 		buflintbuild.RPCResponseStandardNameRuleBuilder,
 		buflintbuild.ServicePascalCaseRuleBuilder,
 		buflintbuild.ServiceSuffixRuleBuilder,
+		buflintbuild.SunsetNotPassedRuleBuilder,
 		buflintbuild.SyntaxSpecifiedRuleBuilder,
 	}
 
@@ -126,6 +129,7 @@ var (
 			"BASIC",
 			"DEFAULT",
 		},
+		"FIELD_REMOVED_RESERVED": {},
 		"FILE_LOWER_SNAKE_CASE": {
 			"DEFAULT",
 		},
@@ -164,6 +168,7 @@ var (
 			"DEFAULT",
 		},
 		"PACKAGE_NO_IMPORT_CYCLE": {},
+		"PACKAGE_OWNER_DEFINED":   {},
 		"PACKAGE_SAME_CSHARP_NAMESPACE": {
 			"BASIC",
 			"DEFAULT",
@@ -229,9 +234,69 @@ var (
 		"SERVICE_SUFFIX": {
 			"DEFAULT",
 		},
+		"SUNSET_NOT_PASSED": {},
 		"SYNTAX_SPECIFIED": {
 			"BASIC",
 			"DEFAULT",
 		},
 	}
+	// v1ReplacedIDsOrCategories associates the v1beta1 IDs and categories that were
+	// removed in v1 with the v1 IDs that replace them.
+	//
+	// Categories are replaced with the v1beta1 IDs they contained. FIELD_NO_DESCRIPTOR
+	// was removed altogether and has no replacement.
+	v1ReplacedIDsOrCategories = map[string][]string{
+		"FIELD_NO_DESCRIPTOR": nil,
+		"FILE_LAYOUT": {
+			"DIRECTORY_SAME_PACKAGE",
+			"PACKAGE_DIRECTORY_MATCH",
+			"PACKAGE_SAME_DIRECTORY",
+		},
+		"OTHER": {
+			"ENUM_FIRST_VALUE_ZERO",
+		},
+		"PACKAGE_AFFINITY": {
+			"PACKAGE_SAME_CSHARP_NAMESPACE",
+			"PACKAGE_SAME_GO_PACKAGE",
+			"PACKAGE_SAME_JAVA_MULTIPLE_FILES",
+			"PACKAGE_SAME_JAVA_PACKAGE",
+			"PACKAGE_SAME_PHP_NAMESPACE",
+			"PACKAGE_SAME_RUBY_PACKAGE",
+			"PACKAGE_SAME_SWIFT_PREFIX",
+		},
+		"SENSIBLE": {
+			"ENUM_NO_ALLOW_ALIAS",
+			"IMPORT_NO_PUBLIC",
+			"IMPORT_NO_WEAK",
+			"PACKAGE_DEFINED",
+		},
+		"STYLE_BASIC": {
+			"ENUM_PASCAL_CASE",
+			"ENUM_VALUE_UPPER_SNAKE_CASE",
+			"FIELD_LOWER_SNAKE_CASE",
+			"MESSAGE_PASCAL_CASE",
+			"ONEOF_LOWER_SNAKE_CASE",
+			"PACKAGE_LOWER_SNAKE_CASE",
+			"RPC_PASCAL_CASE",
+			"SERVICE_PASCAL_CASE",
+		},
+		"STYLE_DEFAULT": {
+			"ENUM_PASCAL_CASE",
+			"ENUM_VALUE_PREFIX",
+			"ENUM_VALUE_UPPER_SNAKE_CASE",
+			"ENUM_ZERO_VALUE_SUFFIX",
+			"FIELD_LOWER_SNAKE_CASE",
+			"FILE_LOWER_SNAKE_CASE",
+			"MESSAGE_PASCAL_CASE",
+			"ONEOF_LOWER_SNAKE_CASE",
+			"PACKAGE_LOWER_SNAKE_CASE",
+			"PACKAGE_VERSION_SUFFIX",
+			"RPC_PASCAL_CASE",
+			"RPC_REQUEST_RESPONSE_UNIQUE",
+			"RPC_REQUEST_STANDARD_NAME",
+			"RPC_RESPONSE_STANDARD_NAME",
+			"SERVICE_PASCAL_CASE",
+			"SERVICE_SUFFIX",
+		},
+	}
 )
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/buf/private/pkg/stringutil"
 )
 
@@ -59,6 +60,8 @@ type ConfigBuilder struct {
 	RPCAllowGoogleProtobufEmptyRequests  bool
 	RPCAllowGoogleProtobufEmptyResponses bool
 	ServiceSuffix                        string
+	PackageOwners                        map[string][]string
+	SunsetDates                          map[string]string
 }
 
 // NewConfig returns a new Config.
@@ -69,6 +72,38 @@ func (b ConfigBuilder) NewConfig(versionSpec *VersionSpec) (*Config, error) {
 func newConfig(configBuilder ConfigBuilder, versionSpec *VersionSpec) (*Config, error) {
 	configBuilder.Use = stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(configBuilder.Use)
 	configBuilder.Except = stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(configBuilder.Except)
+	replacedIDOrCategoryToReplacements := make(map[string][]string)
+	replaceIDsOrCategories := func(idsOrCategories []string) []string {
+		var result []string
+		for _, idOrCategory := range idsOrCategories {
+			replacements, ok := versionSpec.ReplacedIDsOrCategories[idOrCategory]
+			if !ok {
+				result = append(result, idOrCategory)
+				continue
+			}
+			replacedIDOrCategoryToReplacements[idOrCategory] = replacements
+			result = append(result, replacements...)
+		}
+		return stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(result)
+	}
+	if len(configBuilder.Use) > 0 {
+		configBuilder.Use = replaceIDsOrCategories(configBuilder.Use)
+		if len(configBuilder.Use) == 0 {
+			// Do not fall back to the default categories if every configured
+			// rule was removed, as this would silently enable other rules.
+			return nil, fmt.Errorf("all rules and categories in use were removed: %s", strings.Join(slicesext.MapKeysToSortedSlice(replacedIDOrCategoryToReplacements), ", "))
+		}
+	}
+	configBuilder.Except = replaceIDsOrCategories(configBuilder.Except)
+	if len(configBuilder.IgnoreIDOrCategoryToRootPaths) > 0 {
+		ignoreIDOrCategoryToRootPaths := make(map[string][]string, len(configBuilder.IgnoreIDOrCategoryToRootPaths))
+		for idOrCategory, rootPaths := range configBuilder.IgnoreIDOrCategoryToRootPaths {
+			for _, replacement := range replaceIDsOrCategories([]string{idOrCategory}) {
+				ignoreIDOrCategoryToRootPaths[replacement] = append(ignoreIDOrCategoryToRootPaths[replacement], rootPaths...)
+			}
+		}
+		configBuilder.IgnoreIDOrCategoryToRootPaths = ignoreIDOrCategoryToRootPaths
+	}
 	if len(configBuilder.Use) == 0 {
 		// default behavior
 		configBuilder.Use = versionSpec.DefaultCategories
@@ -79,11 +114,30 @@ func newConfig(configBuilder ConfigBuilder, versionSpec *VersionSpec) (*Config,
 	if configBuilder.ServiceSuffix == "" {
 		configBuilder.ServiceSuffix = defaultServiceSuffix
 	}
-	return newConfigForRuleBuilders(
+	config, err := newConfigForRuleBuilders(
 		configBuilder,
 		versionSpec.RuleBuilders,
 		versionSpec.IDToCategories,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defaultCategories := make(map[string]struct{}, len(versionSpec.DefaultCategories))
+	for _, category := range versionSpec.DefaultCategories {
+		defaultCategories[category] = struct{}{}
+	}
+	for _, rule := range config.Rules {
+		for _, category := range rule.categories {
+			if _, ok := defaultCategories[category]; ok {
+				rule.isDefault = true
+				break
+			}
+		}
+		if versionSpec.DocumentationBaseURL != "" {
+			rule.documentationURL = versionSpec.DocumentationBaseURL + "#" + strings.ToLower(rule.id)
+		}
+	}
+	return config, nil
 }
 
 func newConfigForRuleBuilders(
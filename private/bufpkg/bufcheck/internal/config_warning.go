@@ -0,0 +1,271 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+)
+
+// ConfigWarning is a setting of a ConfigBuilder that is valid but has no effect,
+// or that contradicts another setting.
+type ConfigWarning struct {
+	// Key is the configuration key of the setting, such as "except" or "ignore_only.FIELD_LOWER_SNAKE_CASE".
+	Key string
+	// Value is the value within the key that the warning applies to, such as a rule ID or path.
+	//
+	// May be empty if the warning applies to the key as a whole.
+	Value string
+	// Message describes why the setting is ineffective.
+	Message string
+}
+
+// RuleWarnings returns the warnings for the rules and categories referenced by the builder.
+//
+// optionKeyToIDs maps the configuration keys of the options that are set to the IDs
+// of the rules the options affect. A warning is returned for each option for which
+// none of the rules are run.
+//
+// Rules and categories that are not known are skipped, as these are errors when
+// creating the Config. The result is sorted by key, then value.
+func (b ConfigBuilder) RuleWarnings(versionSpec *VersionSpec, optionKeyToIDs map[string][]string) []*ConfigWarning {
+	categoryToIDs := getCategoryToIDs(versionSpec.IDToCategories)
+	getIDs := func(idOrCategory string) map[string]struct{} {
+		ids := make(map[string]struct{})
+		replacements, ok := versionSpec.ReplacedIDsOrCategories[idOrCategory]
+		if !ok {
+			replacements = []string{idOrCategory}
+		}
+		for _, replacement := range replacements {
+			if _, ok := versionSpec.IDToCategories[replacement]; ok {
+				ids[replacement] = struct{}{}
+				continue
+			}
+			for _, id := range categoryToIDs[replacement] {
+				ids[id] = struct{}{}
+			}
+		}
+		return ids
+	}
+	use := stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(b.Use)
+	except := stringutil.SliceToUniqueSortedSliceFilterEmptyStrings(b.Except)
+	useIDs := make(map[string]struct{})
+	for _, idOrCategory := range use {
+		for id := range getIDs(idOrCategory) {
+			useIDs[id] = struct{}{}
+		}
+	}
+	if len(use) == 0 {
+		for _, category := range versionSpec.DefaultCategories {
+			for id := range getIDs(category) {
+				useIDs[id] = struct{}{}
+			}
+		}
+	}
+	useMap := slicesext.ToStructMap(use)
+	runIDs := make(map[string]struct{}, len(useIDs))
+	for id := range useIDs {
+		runIDs[id] = struct{}{}
+	}
+	var warnings []*ConfigWarning
+	for _, idOrCategory := range except {
+		ids := getIDs(idOrCategory)
+		for id := range ids {
+			delete(runIDs, id)
+		}
+		switch {
+		case len(ids) == 0:
+			// Unknown, this is an error when creating the Config.
+		case isInMap(useMap, idOrCategory):
+			warnings = append(
+				warnings,
+				&ConfigWarning{
+					Key:     "except",
+					Value:   idOrCategory,
+					Message: "is also listed in use, so it is not run",
+				},
+			)
+		case !intersects(ids, useIDs):
+			warnings = append(
+				warnings,
+				&ConfigWarning{
+					Key:     "except",
+					Value:   idOrCategory,
+					Message: "has no effect as none of its rules are in use",
+				},
+			)
+		}
+	}
+	if len(useIDs) > 0 && len(runIDs) == 0 {
+		warnings = append(
+			warnings,
+			&ConfigWarning{
+				Key:     "except",
+				Message: "removes every rule in use, so no rules are run",
+			},
+		)
+	}
+	ignoreRootPaths := normalizeRootPaths(b.IgnoreRootPaths)
+	for _, idOrCategory := range slicesext.MapKeysToSortedSlice(b.IgnoreIDOrCategoryToRootPaths) {
+		if idOrCategory == "" {
+			continue
+		}
+		ids := getIDs(idOrCategory)
+		if len(ids) == 0 {
+			continue
+		}
+		if !intersects(ids, runIDs) {
+			warnings = append(
+				warnings,
+				&ConfigWarning{
+					Key:     "ignore_only",
+					Value:   idOrCategory,
+					Message: "has no effect as none of its rules are run",
+				},
+			)
+			continue
+		}
+		for _, rootPath := range normalizeRootPaths(b.IgnoreIDOrCategoryToRootPaths[idOrCategory]) {
+			for _, ignoreRootPath := range ignoreRootPaths {
+				if normalpath.EqualsOrContainsPath(ignoreRootPath, rootPath, normalpath.Relative) {
+					warnings = append(
+						warnings,
+						&ConfigWarning{
+							Key:     "ignore_only." + idOrCategory,
+							Value:   rootPath,
+							Message: fmt.Sprintf("is already ignored for all rules by %q in ignore", ignoreRootPath),
+						},
+					)
+					break
+				}
+			}
+		}
+	}
+	for _, optionKey := range slicesext.MapKeysToSortedSlice(optionKeyToIDs) {
+		ids := optionKeyToIDs[optionKey]
+		if intersects(slicesext.ToStructMap(ids), runIDs) {
+			continue
+		}
+		warnings = append(
+			warnings,
+			&ConfigWarning{
+				Key:     optionKey,
+				Message: fmt.Sprintf("has no effect as %s %s not run", strings.Join(ids, ", "), pluralIsAre(len(ids))),
+			},
+		)
+	}
+	sortConfigWarnings(warnings)
+	return warnings
+}
+
+// IgnorePathWarnings returns the warnings for the ignore paths of the builder that
+// do not match any of the given file paths.
+//
+// The file paths should be the paths of all files of the module, relative to the root.
+// Paths that are not valid are skipped, as these are errors when creating the Config.
+// The result is sorted by key, then value.
+func (b ConfigBuilder) IgnorePathWarnings(filePaths []string) []*ConfigWarning {
+	matchesFile := func(rootPath string) bool {
+		for _, filePath := range filePaths {
+			if normalpath.EqualsOrContainsPath(rootPath, filePath, normalpath.Relative) {
+				return true
+			}
+		}
+		return false
+	}
+	var warnings []*ConfigWarning
+	for _, rootPath := range normalizeRootPaths(b.IgnoreRootPaths) {
+		if !matchesFile(rootPath) {
+			warnings = append(
+				warnings,
+				&ConfigWarning{
+					Key:     "ignore",
+					Value:   rootPath,
+					Message: "does not match any file",
+				},
+			)
+		}
+	}
+	for idOrCategory, rootPaths := range b.IgnoreIDOrCategoryToRootPaths {
+		for _, rootPath := range normalizeRootPaths(rootPaths) {
+			if !matchesFile(rootPath) {
+				warnings = append(
+					warnings,
+					&ConfigWarning{
+						Key:     "ignore_only." + idOrCategory,
+						Value:   rootPath,
+						Message: "does not match any file",
+					},
+				)
+			}
+		}
+	}
+	sortConfigWarnings(warnings)
+	return warnings
+}
+
+// normalizeRootPaths normalizes, dedupes, and sorts the paths, skipping any that are not valid.
+func normalizeRootPaths(rootPaths []string) []string {
+	normalizedRootPaths := make([]string, 0, len(rootPaths))
+	for _, rootPath := range rootPaths {
+		if rootPath == "" {
+			continue
+		}
+		rootPath, err := normalpath.NormalizeAndValidate(rootPath)
+		if err != nil || rootPath == "." {
+			continue
+		}
+		normalizedRootPaths = append(normalizedRootPaths, rootPath)
+	}
+	return slicesext.ToUniqueSorted(normalizedRootPaths)
+}
+
+func intersects(one map[string]struct{}, two map[string]struct{}) bool {
+	for key := range one {
+		if _, ok := two[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isInMap(m map[string]struct{}, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func pluralIsAre(count int) string {
+	if count == 1 {
+		return "is"
+	}
+	return "are"
+}
+
+func sortConfigWarnings(warnings []*ConfigWarning) {
+	sort.SliceStable(
+		warnings,
+		func(i int, j int) bool {
+			if warnings[i].Key != warnings[j].Key {
+				return warnings[i].Key < warnings[j].Key
+			}
+			return warnings[i].Value < warnings[j].Value
+		},
+	)
+}
@@ -0,0 +1,178 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testVersionSpec = &VersionSpec{
+	DefaultCategories: []string{"MINIMAL"},
+	ReplacedIDsOrCategories: map[string][]string{
+		"OLD_RULE": {"RULE_A"},
+	},
+	IDToCategories: map[string][]string{
+		"RULE_A": {"MINIMAL", "BASIC"},
+		"RULE_B": {"BASIC"},
+		"RULE_C": {"OTHER"},
+	},
+}
+
+func TestRuleWarningsNone(t *testing.T) {
+	t.Parallel()
+	assert.Empty(
+		t,
+		ConfigBuilder{
+			Use:    []string{"BASIC"},
+			Except: []string{"OLD_RULE"},
+			IgnoreIDOrCategoryToRootPaths: map[string][]string{
+				"RULE_B": {"a"},
+			},
+			IgnoreRootPaths: []string{"b"},
+		}.RuleWarnings(
+			testVersionSpec,
+			map[string][]string{
+				"rule_b_option": {"RULE_B", "RULE_C"},
+			},
+		),
+	)
+}
+
+func TestRuleWarningsExcept(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		[]*ConfigWarning{
+			{
+				Key:     "except",
+				Value:   "RULE_B",
+				Message: "is also listed in use, so it is not run",
+			},
+			{
+				Key:     "except",
+				Value:   "RULE_C",
+				Message: "has no effect as none of its rules are in use",
+			},
+		},
+		ConfigBuilder{
+			Use:    []string{"MINIMAL", "RULE_B"},
+			Except: []string{"RULE_B", "RULE_C", "UNKNOWN"},
+		}.RuleWarnings(testVersionSpec, nil),
+	)
+	assert.Equal(
+		t,
+		[]*ConfigWarning{
+			{
+				Key:     "except",
+				Message: "removes every rule in use, so no rules are run",
+			},
+			{
+				Key:     "except",
+				Value:   "MINIMAL",
+				Message: "is also listed in use, so it is not run",
+			},
+		},
+		ConfigBuilder{
+			Use:    []string{"MINIMAL"},
+			Except: []string{"MINIMAL"},
+		}.RuleWarnings(testVersionSpec, nil),
+	)
+}
+
+func TestRuleWarningsIgnoreOnly(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		[]*ConfigWarning{
+			{
+				Key:     "ignore_only",
+				Value:   "RULE_B",
+				Message: "has no effect as none of its rules are run",
+			},
+			{
+				Key:     "ignore_only.RULE_A",
+				Value:   "a/b",
+				Message: `is already ignored for all rules by "a" in ignore`,
+			},
+		},
+		// The default categories are used.
+		ConfigBuilder{
+			IgnoreRootPaths: []string{"a"},
+			IgnoreIDOrCategoryToRootPaths: map[string][]string{
+				"RULE_A": {"./a/b", "c"},
+				"RULE_B": {"d"},
+			},
+		}.RuleWarnings(testVersionSpec, nil),
+	)
+}
+
+func TestRuleWarningsOptions(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		[]*ConfigWarning{
+			{
+				Key:     "rule_b_c_option",
+				Message: "has no effect as RULE_B, RULE_C are not run",
+			},
+			{
+				Key:     "rule_c_option",
+				Message: "has no effect as RULE_C is not run",
+			},
+		},
+		ConfigBuilder{
+			Use: []string{"MINIMAL"},
+		}.RuleWarnings(
+			testVersionSpec,
+			map[string][]string{
+				"rule_a_option":   {"RULE_A"},
+				"rule_b_c_option": {"RULE_B", "RULE_C"},
+				"rule_c_option":   {"RULE_C"},
+			},
+		),
+	)
+}
+
+func TestIgnorePathWarnings(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		[]*ConfigWarning{
+			{
+				Key:     "ignore",
+				Value:   "c",
+				Message: "does not match any file",
+			},
+			{
+				Key:     "ignore_only.RULE_A",
+				Value:   "a/c.proto",
+				Message: "does not match any file",
+			},
+		},
+		ConfigBuilder{
+			IgnoreRootPaths: []string{"a", "b/b.proto", "c"},
+			IgnoreIDOrCategoryToRootPaths: map[string][]string{
+				"RULE_A": {"a/a.proto", "a/c.proto"},
+			},
+		}.IgnorePathWarnings(
+			[]string{
+				"a/a.proto",
+				"b/b.proto",
+			},
+		),
+	)
+}
@@ -0,0 +1,161 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"unicode/utf8"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+)
+
+// tabStop is the width of a tab stop when computing columns.
+//
+// This matches the columns of the source code info produced by the compiler.
+const tabStop = 8
+
+// Edit is a machine-applicable fix expressed as positions within a file.
+//
+// Rules only have access to the locations of descriptors, not to the contents of files,
+// so an Edit is resolved to the byte range of a bufanalysis.Fix by ResolveFixes once the
+// contents of the file are read.
+//
+// Lines and columns are 1-based, as with FileAnnotations. Columns count runes, with
+// tabs advancing to the next multiple of 8. An end line past the last line of the file
+// refers to the end of the file.
+type Edit struct {
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+	Replacement string
+}
+
+// ReadFileFunc reads the contents of the file for the FileInfo.
+//
+// Returns an error that fulfills errors.Is(err, fs.ErrNotExist) if the file cannot
+// be found.
+type ReadFileFunc func(ctx context.Context, fileInfo bufanalysis.FileInfo) ([]byte, error)
+
+// ResolveFixes resolves the Edits of the FileAnnotations added by rules to
+// bufanalysis.Fixes, using readFile to read the contents of files.
+//
+// If readFile is nil, or the file of a FileAnnotation cannot be found, or the Edit
+// does not refer to a position within the file, the FileAnnotation is returned
+// without a Fix.
+func ResolveFixes(
+	ctx context.Context,
+	fileAnnotations []bufanalysis.FileAnnotation,
+	readFile ReadFileFunc,
+) ([]bufanalysis.FileAnnotation, error) {
+	pathToData := make(map[string][]byte)
+	resolvedFileAnnotations := make([]bufanalysis.FileAnnotation, len(fileAnnotations))
+	for i, fileAnnotation := range fileAnnotations {
+		fileAnnotationWithEdit, ok := fileAnnotation.(*fileAnnotationWithEdit)
+		if !ok {
+			resolvedFileAnnotations[i] = fileAnnotation
+			continue
+		}
+		resolvedFileAnnotations[i] = fileAnnotationWithEdit.FileAnnotation
+		fileInfo := fileAnnotationWithEdit.FileInfo()
+		if readFile == nil || fileInfo == nil {
+			continue
+		}
+		data, ok := pathToData[fileInfo.Path()]
+		if !ok {
+			var err error
+			data, err = readFile(ctx, fileInfo)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+			pathToData[fileInfo.Path()] = data
+		}
+		if data == nil {
+			continue
+		}
+		if fix := newFixForEdit(data, fileAnnotationWithEdit.edit); fix != nil {
+			resolvedFileAnnotations[i] = bufanalysis.FileAnnotationWithFix(fileAnnotationWithEdit.FileAnnotation, fix)
+		}
+	}
+	return resolvedFileAnnotations, nil
+}
+
+type fileAnnotationWithEdit struct {
+	bufanalysis.FileAnnotation
+
+	edit *Edit
+}
+
+// newFixForEdit returns the Fix for the Edit within data.
+//
+// Returns nil if the Edit does not refer to a position within data.
+func newFixForEdit(data []byte, edit *Edit) bufanalysis.Fix {
+	startOffset, ok := offsetForPosition(data, edit.StartLine, edit.StartColumn)
+	if !ok {
+		return nil
+	}
+	endOffset, ok := offsetForPosition(data, edit.EndLine, edit.EndColumn)
+	if !ok || endOffset < startOffset {
+		return nil
+	}
+	return bufanalysis.NewFix(startOffset, endOffset, edit.Replacement)
+}
+
+// offsetForPosition returns the byte offset of the 1-based line and column within data.
+//
+// A line past the last line of data refers to the end of data. Returns false if the
+// position is not within data.
+func offsetForPosition(data []byte, line int, column int) (int, bool) {
+	if line < 1 || column < 1 {
+		return 0, false
+	}
+	offset := 0
+	for currentLine := 1; currentLine < line; currentLine++ {
+		newlineIndex := bytes.IndexByte(data[offset:], '\n')
+		if newlineIndex < 0 {
+			return len(data), true
+		}
+		offset += newlineIndex + 1
+	}
+	if offset == len(data) {
+		// The line after the last line terminator, which only has the end of data.
+		if column == 1 {
+			return offset, true
+		}
+		return 0, false
+	}
+	currentColumn := 1
+	for currentColumn < column {
+		if offset >= len(data) || data[offset] == '\n' {
+			return 0, false
+		}
+		if data[offset] == '\t' {
+			currentColumn += tabStop - (currentColumn-1)%tabStop
+			offset++
+			continue
+		}
+		_, size := utf8.DecodeRune(data[offset:])
+		currentColumn++
+		offset += size
+	}
+	if currentColumn != column {
+		// The column is within a tab.
+		return 0, false
+	}
+	return offset, true
+}
@@ -0,0 +1,132 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetForPosition(t *testing.T) {
+	t.Parallel()
+	data := []byte("ab\n\tc\nχd\n")
+	testOffsetForPosition(t, data, 1, 1, 0)
+	testOffsetForPosition(t, data, 1, 3, 2)
+	// The tab advances to column 9.
+	testOffsetForPosition(t, data, 2, 1, 3)
+	testOffsetForPosition(t, data, 2, 9, 4)
+	testOffsetForPosition(t, data, 2, 10, 5)
+	// "χ" is two bytes but one column.
+	testOffsetForPosition(t, data, 3, 2, 8)
+	testOffsetForPosition(t, data, 3, 3, 9)
+	// Lines past the last line refer to the end of the file.
+	testOffsetForPosition(t, data, 4, 1, 10)
+	testOffsetForPosition(t, data, 5, 1, 10)
+	testOffsetForPosition(t, []byte("ab"), 2, 1, 2)
+	testOffsetForPositionInvalid(t, data, 0, 1)
+	testOffsetForPositionInvalid(t, data, 1, 0)
+	testOffsetForPositionInvalid(t, data, 1, 5)
+	testOffsetForPositionInvalid(t, data, 2, 5)
+	testOffsetForPositionInvalid(t, data, 4, 2)
+}
+
+func TestResolveFixes(t *testing.T) {
+	t.Parallel()
+	fileInfo := testFileInfo{path: "a.proto"}
+	missingFileInfo := testFileInfo{path: "b.proto"}
+	fileAnnotation := bufanalysis.NewFileAnnotation(fileInfo, 2, 9, 2, 10, "RULE", "message")
+	fileAnnotations := []bufanalysis.FileAnnotation{
+		&fileAnnotationWithEdit{
+			FileAnnotation: fileAnnotation,
+			edit: &Edit{
+				StartLine:   2,
+				StartColumn: 9,
+				EndLine:     2,
+				EndColumn:   10,
+				Replacement: "d",
+			},
+		},
+		&fileAnnotationWithEdit{
+			FileAnnotation: fileAnnotation,
+			edit: &Edit{
+				StartLine:   2,
+				StartColumn: 20,
+				EndLine:     2,
+				EndColumn:   21,
+			},
+		},
+		&fileAnnotationWithEdit{
+			FileAnnotation: bufanalysis.NewFileAnnotation(missingFileInfo, 1, 1, 1, 2, "RULE", "message"),
+			edit: &Edit{
+				StartLine:   1,
+				StartColumn: 1,
+				EndLine:     1,
+				EndColumn:   2,
+			},
+		},
+		fileAnnotation,
+	}
+	readFile := func(_ context.Context, fileInfo bufanalysis.FileInfo) ([]byte, error) {
+		if fileInfo.Path() == "a.proto" {
+			return []byte("ab\n\tc\n"), nil
+		}
+		return nil, fs.ErrNotExist
+	}
+	resolvedFileAnnotations, err := ResolveFixes(context.Background(), fileAnnotations, readFile)
+	require.NoError(t, err)
+	require.Len(t, resolvedFileAnnotations, 4)
+	assert.Equal(t, bufanalysis.NewFix(4, 5, "d"), resolvedFileAnnotations[0].Fix())
+	assert.Equal(t, "message", resolvedFileAnnotations[0].Message())
+	// The edit is not within the file.
+	assert.Nil(t, resolvedFileAnnotations[1].Fix())
+	// The file does not exist.
+	assert.Nil(t, resolvedFileAnnotations[2].Fix())
+	assert.Equal(t, fileAnnotation, resolvedFileAnnotations[3])
+	resolvedFileAnnotations, err = ResolveFixes(context.Background(), fileAnnotations, nil)
+	require.NoError(t, err)
+	for i, resolvedFileAnnotation := range resolvedFileAnnotations {
+		assert.Nil(t, resolvedFileAnnotation.Fix())
+		_, ok := resolvedFileAnnotation.(*fileAnnotationWithEdit)
+		assert.False(t, ok, i)
+	}
+}
+
+func testOffsetForPosition(t *testing.T, data []byte, line int, column int, expectedOffset int) {
+	offset, ok := offsetForPosition(data, line, column)
+	assert.True(t, ok, "%d:%d", line, column)
+	assert.Equal(t, expectedOffset, offset, "%d:%d", line, column)
+}
+
+func testOffsetForPositionInvalid(t *testing.T, data []byte, line int, column int) {
+	_, ok := offsetForPosition(data, line, column)
+	assert.False(t, ok, "%d:%d", line, column)
+}
+
+type testFileInfo struct {
+	path string
+}
+
+func (f testFileInfo) Path() string {
+	return f.path
+}
+
+func (f testFileInfo) ExternalPath() string {
+	return f.path
+}
@@ -51,6 +51,7 @@ func (h *Helper) AddFileAnnotationf(
 		nil,
 		location,
 		nil,
+		nil,
 		format,
 		args...,
 	)
@@ -74,6 +75,7 @@ func (h *Helper) AddFileAnnotationWithExtraIgnoreDescriptorsf(
 		extraIgnoreDescriptors,
 		location,
 		nil,
+		nil,
 		format,
 		args...,
 	)
@@ -97,6 +99,35 @@ func (h *Helper) AddFileAnnotationWithExtraIgnoreLocationsf(
 		nil,
 		location,
 		extraIgnoreLocations,
+		nil,
+		format,
+		args...,
+	)
+}
+
+// AddFileAnnotationWithEditf adds a FileAnnotation with the id as the Type and the Edit.
+//
+// extraIgnoreLocations are extra locations to check for comment ignores.
+//
+// The Edit is resolved to the Fix of the FileAnnotation by ResolveFixes.
+//
+// If descriptor is nil, no filename information is added.
+// If location is nil, no line or column information will be added.
+// If edit is nil, no fix is added.
+func (h *Helper) AddFileAnnotationWithEditf(
+	descriptor protosource.Descriptor,
+	location protosource.Location,
+	extraIgnoreLocations []protosource.Location,
+	edit *Edit,
+	format string,
+	args ...interface{},
+) {
+	h.addFileAnnotationf(
+		descriptor,
+		nil,
+		location,
+		extraIgnoreLocations,
+		edit,
 		format,
 		args...,
 	)
@@ -107,6 +138,7 @@ func (h *Helper) addFileAnnotationf(
 	extraIgnoreDescriptors []protosource.Descriptor,
 	location protosource.Location,
 	extraIgnoreLocations []protosource.Location,
+	edit *Edit,
 	format string,
 	args ...interface{},
 ) {
@@ -117,16 +149,20 @@ func (h *Helper) addFileAnnotationf(
 	) {
 		return
 	}
-	h.fileAnnotations = append(
-		h.fileAnnotations,
-		newFileAnnotationf(
-			h.id,
-			descriptor,
-			location,
-			format,
-			args...,
-		),
+	fileAnnotation := newFileAnnotationf(
+		h.id,
+		descriptor,
+		location,
+		format,
+		args...,
 	)
+	if edit != nil {
+		fileAnnotation = &fileAnnotationWithEdit{
+			FileAnnotation: fileAnnotation,
+			edit:           edit,
+		}
+	}
+	h.fileAnnotations = append(h.fileAnnotations, fileAnnotation)
 }
 
 // FileAnnotations returns the added FileAnnotations.
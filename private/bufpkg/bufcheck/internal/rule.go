@@ -44,6 +44,9 @@ type Rule struct {
 	categories []string
 	purpose    string
 	checkFunc  CheckFunc
+	// Set by newConfig, as these depend on the VersionSpec.
+	isDefault        bool
+	documentationURL string
 }
 
 // newRule returns a new Rule.
@@ -87,9 +90,27 @@ func (c *Rule) Purpose() string {
 	return c.purpose
 }
 
+// Default implements Rule.
+func (c *Rule) Default() bool {
+	return c.isDefault
+}
+
+// DocumentationURL implements Rule.
+func (c *Rule) DocumentationURL() string {
+	return c.documentationURL
+}
+
 // MarshalJSON implements Rule.
 func (c *Rule) MarshalJSON() ([]byte, error) {
-	return json.Marshal(ruleJSON{ID: c.id, Categories: c.categories, Purpose: c.purpose})
+	return json.Marshal(
+		ruleJSON{
+			ID:               c.id,
+			Categories:       c.categories,
+			Default:          c.isDefault,
+			Purpose:          c.purpose,
+			DocumentationURL: c.documentationURL,
+		},
+	)
 }
 
 func (c *Rule) check(ignoreFunc IgnoreFunc, previousFiles []protosource.File, files []protosource.File) ([]bufanalysis.FileAnnotation, error) {
@@ -97,7 +118,9 @@ func (c *Rule) check(ignoreFunc IgnoreFunc, previousFiles []protosource.File, fi
 }
 
 type ruleJSON struct {
-	ID         string   `json:"id" yaml:"id"`
-	Categories []string `json:"categories" yaml:"categories"`
-	Purpose    string   `json:"purpose" yaml:"purpose"`
+	ID               string   `json:"id" yaml:"id"`
+	Categories       []string `json:"categories" yaml:"categories"`
+	Default          bool     `json:"default" yaml:"default"`
+	Purpose          string   `json:"purpose" yaml:"purpose"`
+	DocumentationURL string   `json:"documentation_url,omitempty" yaml:"documentation_url,omitempty"`
 }
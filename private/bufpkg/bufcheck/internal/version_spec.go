@@ -24,6 +24,16 @@ import (
 type VersionSpec struct {
 	RuleBuilders      []*RuleBuilder
 	DefaultCategories []string
+	// ReplacedIDsOrCategories associates IDs and categories that are no longer valid
+	// for this version with the IDs that replace them.
+	//
+	// A nil or empty value means the ID or category was removed without replacement.
+	// May be nil.
+	ReplacedIDsOrCategories map[string][]string
+	// DocumentationBaseURL is the URL of the page documenting the rules.
+	//
+	// Each rule is documented at the anchor of its lowercase ID.
+	DocumentationBaseURL string
 	// May include IDs without any categories.
 	// To get all categories, use AllCategoriesForVersionSpec.
 	IDToCategories map[string][]string
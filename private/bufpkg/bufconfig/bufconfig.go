@@ -17,6 +17,7 @@ package bufconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking/bufbreakingconfig"
@@ -39,6 +40,10 @@ const (
 	// V1Beta1Version is the v1beta1 version.
 	V1Beta1Version = "v1beta1"
 
+	// DeprecationRemovalVersion is the configuration version that elements that are
+	// deprecated in the current configuration versions are removed in.
+	DeprecationRemovalVersion = "v2"
+
 	// backupExternalConfigV1FilePath is another acceptable configuration file path for v1.
 	//
 	// Originally we thought we were going to move to buf.mod, and had this around for
@@ -70,6 +75,10 @@ type Config struct {
 	Build          *bufmoduleconfig.Config
 	Breaking       *bufbreakingconfig.Config
 	Lint           *buflintconfig.Config
+	// Deprecations are the deprecated versions, files, and keys used by the Config.
+	//
+	// Deprecated lint and breaking rules are not included, see buflint.GetDeprecations.
+	Deprecations []*Deprecation
 }
 
 // GetConfigForBucket gets the Config for the YAML data at ConfigFilePath.
@@ -79,6 +88,16 @@ func GetConfigForBucket(ctx context.Context, readBucket storage.ReadBucket) (*Co
 	return getConfigForBucket(ctx, readBucket)
 }
 
+// IsInvalidConfigError returns true if the error is, or wraps, an error returned
+// by GetConfigForBucket, GetConfigForData, or ReadConfigOS because the configuration
+// could not be parsed or is not valid.
+//
+// Errors reading the configuration, such as permission errors, are not invalid config errors.
+func IsInvalidConfigError(err error) bool {
+	asErr := &invalidConfigError{}
+	return errors.As(err, &asErr)
+}
+
 // GetConfigForData gets the Config for the given JSON or YAML data.
 //
 // If the data is of length 0, returns the default config.
@@ -33,12 +33,17 @@ func newConfigV1Beta1(externalConfig ExternalConfigV1Beta1) (*Config, error) {
 			return nil, err
 		}
 	}
+	deprecations := []*Deprecation{deprecationV1Beta1Version}
+	if len(externalConfig.Build.Roots) > 0 {
+		deprecations = append(deprecations, deprecationV1Beta1BuildRoots)
+	}
 	return &Config{
 		Version:        V1Beta1Version,
 		ModuleIdentity: moduleIdentity,
 		Build:          buildConfig,
 		Breaking:       bufbreakingconfig.NewConfigV1Beta1(externalConfig.Breaking),
 		Lint:           buflintconfig.NewConfigV1Beta1(externalConfig.Lint),
+		Deprecations:   deprecations,
 	}, nil
 }
 
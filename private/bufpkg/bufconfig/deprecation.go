@@ -0,0 +1,126 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfig
+
+import (
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DeprecationTypeConfigVersion is a deprecated configuration version.
+	DeprecationTypeConfigVersion DeprecationType = iota + 1
+	// DeprecationTypeConfigFile is a deprecated configuration file path.
+	DeprecationTypeConfigFile
+	// DeprecationTypeConfigKey is a deprecated key within a configuration file.
+	DeprecationTypeConfigKey
+	// DeprecationTypeLintRule is a deprecated lint rule or category.
+	DeprecationTypeLintRule
+	// DeprecationTypeBreakingRule is a deprecated breaking rule or category.
+	DeprecationTypeBreakingRule
+)
+
+var (
+	deprecationTypeToString = map[DeprecationType]string{
+		DeprecationTypeConfigVersion: "config_version",
+		DeprecationTypeConfigFile:    "config_file",
+		DeprecationTypeConfigKey:     "config_key",
+		DeprecationTypeLintRule:      "lint_rule",
+		DeprecationTypeBreakingRule:  "breaking_rule",
+	}
+
+	deprecationV1Beta1Version = &Deprecation{
+		Type:            DeprecationTypeConfigVersion,
+		Name:            V1Beta1Version,
+		DeprecatedSince: V1Version,
+		RemovalVersion:  DeprecationRemovalVersion,
+		Replacements:    []string{V1Version},
+	}
+	deprecationBackupExternalConfigV1FilePath = &Deprecation{
+		Type:            DeprecationTypeConfigFile,
+		Name:            backupExternalConfigV1FilePath,
+		DeprecatedSince: V1Version,
+		RemovalVersion:  DeprecationRemovalVersion,
+		Replacements:    []string{ExternalConfigV1FilePath},
+	}
+	deprecationV1Beta1BuildRoots = &Deprecation{
+		Type:            DeprecationTypeConfigKey,
+		Name:            "build.roots",
+		DeprecatedSince: V1Version,
+		RemovalVersion:  DeprecationRemovalVersion,
+		Replacements:    []string{"directories in buf.work.yaml"},
+	}
+)
+
+// DeprecationType is the type of a deprecated configuration element.
+type DeprecationType int
+
+// String implements fmt.Stringer.
+func (d DeprecationType) String() string {
+	s, ok := deprecationTypeToString[d]
+	if !ok {
+		return strconv.Itoa(int(d))
+	}
+	return s
+}
+
+// Deprecation is the deprecation metadata for a configuration version, file, key, or rule.
+type Deprecation struct {
+	Type DeprecationType
+	// Name is the name of the deprecated element, such as the key or rule ID.
+	Name string
+	// DeprecatedSince is the configuration version the element was deprecated in.
+	DeprecatedSince string
+	// RemovalVersion is the configuration version the element will be removed in.
+	//
+	// Empty if removal has not been scheduled.
+	RemovalVersion string
+	// Replacements are what should be used instead of the element.
+	//
+	// Empty if the element was removed without replacement.
+	Replacements []string
+}
+
+// WarnDeprecations logs a warning for each of the Deprecations on the Config.
+//
+// This should only be called for configurations read from local sources, that is
+// not for configurations read for module inputs or dependencies from the BSR.
+func WarnDeprecations(logger *zap.Logger, config *Config) {
+	for _, deprecation := range config.Deprecations {
+		logger.Warn(
+			"configuration uses a deprecated element",
+			DeprecationZapFields(deprecation)...,
+		)
+	}
+}
+
+// DeprecationZapFields returns the structured logging fields for the Deprecation.
+//
+// All deprecation warnings should use these fields so that they are consistent.
+func DeprecationZapFields(deprecation *Deprecation) []zap.Field {
+	fields := []zap.Field{
+		zap.String("type", deprecation.Type.String()),
+		zap.String("name", deprecation.Name),
+		zap.String("deprecated_since", deprecation.DeprecatedSince),
+	}
+	if deprecation.RemovalVersion != "" {
+		fields = append(fields, zap.String("removal_version", deprecation.RemovalVersion))
+	}
+	if len(deprecation.Replacements) > 0 {
+		fields = append(fields, zap.Strings("replacements", deprecation.Replacements))
+	}
+	return fields
+}
@@ -67,15 +67,22 @@ func getConfigForBucket(ctx context.Context, readBucket storage.ReadBucket) (_ *
 		if err != nil {
 			return nil, err
 		}
-		return getConfigForDataInternal(
+		config, err := getConfigForDataInternal(
 			ctx,
 			encoding.UnmarshalYAMLNonStrict,
 			encoding.UnmarshalYAMLStrict,
 			data,
 			readObjectCloser.ExternalPath(),
 		)
+		if err != nil {
+			return nil, newInvalidConfigError(err)
+		}
+		if foundConfigFilePaths[0] == backupExternalConfigV1FilePath {
+			config.Deprecations = append(config.Deprecations, deprecationBackupExternalConfigV1FilePath)
+		}
+		return config, nil
 	default:
-		return nil, fmt.Errorf("only one configuration file can exist but found multiple configuration files: %s", stringutil.SliceToString(foundConfigFilePaths))
+		return nil, newInvalidConfigError(fmt.Errorf("only one configuration file can exist but found multiple configuration files: %s", stringutil.SliceToString(foundConfigFilePaths)))
 	}
 }
 
@@ -92,8 +99,9 @@ func getConfigForData(ctx context.Context, data []byte) (*Config, error) {
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
+		return nil, newInvalidConfigError(err)
 	}
-	return config, err
+	return config, nil
 }
 
 func getConfigForDataInternal(
@@ -131,3 +139,20 @@ func getConfigForDataInternal(
 		)
 	}
 }
+
+// invalidConfigError is returned when configuration data cannot be parsed or is not valid.
+type invalidConfigError struct {
+	cause error
+}
+
+func newInvalidConfigError(cause error) *invalidConfigError {
+	return &invalidConfigError{cause: cause}
+}
+
+func (e *invalidConfigError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *invalidConfigError) Unwrap() error {
+	return e.cause
+}
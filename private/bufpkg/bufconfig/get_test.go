@@ -0,0 +1,48 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfigInvalidConfigError(t *testing.T) {
+	t.Parallel()
+	_, err := GetConfigForData(context.Background(), []byte(`version: v2`))
+	require.Error(t, err)
+	assert.True(t, IsInvalidConfigError(err))
+	_, err = GetConfigForData(context.Background(), []byte("version: v1\nname: foo\n"))
+	require.Error(t, err)
+	assert.True(t, IsInvalidConfigError(err))
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			ExternalConfigV1FilePath:       []byte(`version: v1`),
+			backupExternalConfigV1FilePath: []byte(`version: v1`),
+		},
+	)
+	require.NoError(t, err)
+	_, err = GetConfigForBucket(context.Background(), readBucket)
+	require.Error(t, err)
+	assert.True(t, IsInvalidConfigError(err))
+	_, err = GetConfigForData(context.Background(), []byte(`version: v1`))
+	require.NoError(t, err)
+	assert.False(t, IsInvalidConfigError(errors.New("foo")))
+}
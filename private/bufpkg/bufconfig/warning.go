@@ -0,0 +1,51 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconfig
+
+import (
+	"go.uber.org/zap"
+)
+
+// Warning is a setting within a configuration file that is valid, but that has
+// no effect or contradicts another setting.
+type Warning struct {
+	// Key is the full key of the setting, such as "lint.except" or "breaking.ignore".
+	Key string
+	// Value is the value within the key that the warning applies to, such as a rule ID or path.
+	//
+	// Empty if the warning applies to the key as a whole.
+	Value string
+	// Message describes why the setting has no effect.
+	Message string
+}
+
+// String implements fmt.Stringer.
+func (w *Warning) String() string {
+	if w.Value == "" {
+		return w.Key + ": " + w.Message
+	}
+	return w.Key + ": " + w.Value + " " + w.Message
+}
+
+// WarningZapFields returns the structured logging fields for the Warning.
+func WarningZapFields(warning *Warning) []zap.Field {
+	fields := []zap.Field{
+		zap.String("key", warning.Key),
+	}
+	if warning.Value != "" {
+		fields = append(fields, zap.String("value", warning.Value))
+	}
+	return append(fields, zap.String("reason", warning.Message))
+}
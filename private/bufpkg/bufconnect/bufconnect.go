@@ -15,6 +15,10 @@
 // Package bufconnect provides buf-specific Connect functionality.
 package bufconnect
 
+import (
+	"github.com/bufbuild/buf/private/pkg/uuidutil"
+)
+
 const (
 	// AuthenticationHeader is the standard OAuth header used for authenticating
 	// a user. Ignore the misnomer.
@@ -27,6 +31,22 @@ const (
 	// CLIWarningHeaderName is the name of the header carrying a base64-encoded warning message
 	// from the server to the CLI.
 	CLIWarningHeaderName = "buf-warning-bin"
+	// IdempotencyKeyHeaderName is the name of the header carrying a key that is the same for
+	// every attempt of an upload, so that a server that supports it does not create duplicate
+	// commits or tags when a request is retried.
+	IdempotencyKeyHeaderName = "idempotency-key"
 	// DefaultRemote is the default remote if none can be inferred from a module name.
 	DefaultRemote = "buf.build"
 )
+
+// NewIdempotencyKey returns a new random key for the IdempotencyKeyHeaderName header.
+//
+// A new key should be created for each upload, and reused for all retries of the
+// upload, but never for another upload, even if it has the same content.
+func NewIdempotencyKey() (string, error) {
+	id, err := uuidutil.New()
+	if err != nil {
+		return "", err
+	}
+	return uuidutil.ToDashless(id)
+}
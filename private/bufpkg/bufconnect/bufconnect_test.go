@@ -0,0 +1,32 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufconnect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	key, err := NewIdempotencyKey()
+	require.NoError(t, err)
+	assert.Len(t, key, 32)
+	otherKey, err := NewIdempotencyKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+}
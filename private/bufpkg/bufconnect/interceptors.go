@@ -18,17 +18,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"connectrpc.com/connect"
 	"github.com/bufbuild/buf/private/pkg/app/applog"
+	"go.uber.org/zap"
 )
 
 const (
 	// tokenEnvKey is the environment variable key for the auth token
 	tokenEnvKey = "BUF_TOKEN"
+	// redactedHeaderValue replaces the values of redacted headers.
+	redactedHeaderValue = "[REDACTED]"
 )
 
+// redactedHeaderNames are the canonical names of the headers whose values are redacted
+// by NewDebugLoggingInterceptor.
+var redactedHeaderNames = map[string]struct{}{
+	AuthenticationHeader:  {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+}
+
 // NewSetCLIVersionInterceptor returns a new Connect Interceptor that sets the Buf CLI version into all request headers
 func NewSetCLIVersionInterceptor(version string) connect.UnaryInterceptorFunc {
 	interceptor := func(next connect.UnaryFunc) connect.UnaryFunc {
@@ -72,6 +88,151 @@ func logWarningFromHeader(container applog.Container, header http.Header) {
 	}
 }
 
+// NewDebugLoggingInterceptor returns a new Connect Interceptor that logs the procedure, peer,
+// duration, status code, and headers of every RPC, for debugging connectivity problems.
+//
+// Streaming RPCs are logged when the response is closed. The values of headers that carry
+// credentials are redacted.
+func NewDebugLoggingInterceptor(container applog.Container) connect.Interceptor {
+	return &debugLoggingInterceptor{
+		container: container,
+	}
+}
+
+type debugLoggingInterceptor struct {
+	container applog.Container
+}
+
+func (d *debugLoggingInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		// Interceptors later in the chain, such as the authorization interceptor, modify the
+		// request headers in place, so we log them after the call to see what was sent.
+		var responseHeader http.Header
+		if resp != nil {
+			responseHeader = resp.Header()
+		}
+		d.log(req.Spec(), req.Peer(), time.Since(start), req.Header(), responseHeader, err)
+		return resp, err
+	}
+}
+
+func (d *debugLoggingInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		return &debugLoggingStreamingClientConn{
+			StreamingClientConn: next(ctx, spec),
+			interceptor:         d,
+			start:               time.Now(),
+		}
+	}
+}
+
+func (*debugLoggingInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	// We only log the RPCs we make as a client.
+	return next
+}
+
+func (d *debugLoggingInterceptor) log(
+	spec connect.Spec,
+	peer connect.Peer,
+	duration time.Duration,
+	requestHeader http.Header,
+	responseHeader http.Header,
+	err error,
+) {
+	code := "ok"
+	if err != nil {
+		code = connect.CodeOf(err).String()
+		if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+			responseHeader = connectErr.Meta()
+		}
+	}
+	fields := []zap.Field{
+		zap.String("procedure", spec.Procedure),
+		zap.String("peer", peer.Addr),
+		zap.Duration("duration", duration),
+		zap.String("code", code),
+		zap.Any("request_headers", redactHeader(requestHeader)),
+		zap.Any("response_headers", redactHeader(responseHeader)),
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	d.container.Logger().Info("rpc", fields...)
+}
+
+type debugLoggingStreamingClientConn struct {
+	connect.StreamingClientConn
+
+	interceptor *debugLoggingInterceptor
+	start       time.Time
+
+	// Send and Receive may be called concurrently.
+	lock sync.Mutex
+	// err is the first error of the stream other than io.EOF.
+	err    error
+	logged bool
+}
+
+func (d *debugLoggingStreamingClientConn) Send(msg any) error {
+	err := d.StreamingClientConn.Send(msg)
+	d.recordError(err)
+	return err
+}
+
+func (d *debugLoggingStreamingClientConn) Receive(msg any) error {
+	err := d.StreamingClientConn.Receive(msg)
+	d.recordError(err)
+	return err
+}
+
+func (d *debugLoggingStreamingClientConn) CloseResponse() error {
+	err := d.StreamingClientConn.CloseResponse()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if !d.logged {
+		d.logged = true
+		d.interceptor.log(
+			d.Spec(),
+			d.Peer(),
+			time.Since(d.start),
+			d.RequestHeader(),
+			d.ResponseHeader(),
+			d.err,
+		)
+	}
+	return err
+}
+
+func (d *debugLoggingStreamingClientConn) recordError(err error) {
+	// A send error of io.EOF means the server closed the stream, and the actual
+	// error is returned from Receive.
+	if err == nil || errors.Is(err, io.EOF) {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// redactHeader returns the header as a map from canonical header name to the
+// comma-separated values, with the values of sensitive headers redacted.
+func redactHeader(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for key, values := range header {
+		canonicalKey := http.CanonicalHeaderKey(key)
+		if _, ok := redactedHeaderNames[canonicalKey]; ok {
+			redacted[canonicalKey] = redactedHeaderValue
+			continue
+		}
+		redacted[canonicalKey] = strings.Join(values, ",")
+	}
+	return redacted
+}
+
 // TokenProvider finds the token for NewAuthorizationInterceptorProvider.
 type TokenProvider interface {
 	// RemoteToken returns the remote token from the remote address.
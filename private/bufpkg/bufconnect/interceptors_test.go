@@ -18,8 +18,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 
 	"connectrpc.com/connect"
@@ -146,3 +148,133 @@ func TestCLIWarningInterceptorFromError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, fmt.Sprintf("WARN\t%s\n", warningMessage), buf.String())
 }
+
+func TestDebugLoggingInterceptor(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger, err := applog.NewLogger(&buf, "info", "json")
+	require.NoError(t, err)
+	request := connect.NewRequest(&bytes.Buffer{})
+	request.Header().Set(CliVersionHeaderName, "1.0.0")
+	_, err = NewDebugLoggingInterceptor(applog.NewContainer(logger)).WrapUnary(func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		// Set as an interceptor later in the chain would.
+		req.Header().Set(AuthenticationHeader, AuthenticationTokenPrefix+"secret")
+		err := connect.NewError(connect.CodeUnauthenticated, errors.New("unauthenticated"))
+		err.Meta().Set("Set-Cookie", "session=secret")
+		err.Meta().Set("Content-Type", "application/proto")
+		return nil, err
+	})(context.Background(), request)
+	assert.Error(t, err)
+	assert.NotContains(t, buf.String(), "secret")
+	var entry struct {
+		Message         string            `json:"message"`
+		Code            string            `json:"code"`
+		RequestHeaders  map[string]string `json:"request_headers"`
+		ResponseHeaders map[string]string `json:"response_headers"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "rpc", entry.Message)
+	assert.Equal(t, connect.CodeUnauthenticated.String(), entry.Code)
+	assert.Equal(
+		t,
+		map[string]string{
+			AuthenticationHeader: redactedHeaderValue,
+			"Buf-Version":        "1.0.0",
+		},
+		entry.RequestHeaders,
+	)
+	assert.Equal(
+		t,
+		map[string]string{
+			"Set-Cookie":   redactedHeaderValue,
+			"Content-Type": "application/proto",
+		},
+		entry.ResponseHeaders,
+	)
+}
+
+func TestDebugLoggingInterceptorStreamingClient(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	logger, err := applog.NewLogger(&buf, "info", "json")
+	require.NoError(t, err)
+	testConn := &testStreamingClientConn{
+		requestHeader:  make(http.Header),
+		responseHeader: make(http.Header),
+		receiveErr:     connect.NewError(connect.CodeResourceExhausted, errors.New("too large")),
+	}
+	conn := NewDebugLoggingInterceptor(applog.NewContainer(logger)).WrapStreamingClient(
+		func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+			testConn.spec = spec
+			return testConn
+		},
+	)(context.Background(), connect.Spec{Procedure: "/buf.test.v1.TestService/Upload"})
+	conn.RequestHeader().Set(AuthenticationHeader, AuthenticationTokenPrefix+"secret")
+	require.NoError(t, conn.Send(&bytes.Buffer{}))
+	require.NoError(t, conn.CloseRequest())
+	assert.Error(t, conn.Receive(&bytes.Buffer{}))
+	// Nothing is logged until the response is closed.
+	assert.Empty(t, buf.String())
+	require.NoError(t, conn.CloseResponse())
+	assert.NotContains(t, buf.String(), "secret")
+	var entry struct {
+		Message        string            `json:"message"`
+		Procedure      string            `json:"procedure"`
+		Code           string            `json:"code"`
+		RequestHeaders map[string]string `json:"request_headers"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "rpc", entry.Message)
+	assert.Equal(t, "/buf.test.v1.TestService/Upload", entry.Procedure)
+	assert.Equal(t, connect.CodeResourceExhausted.String(), entry.Code)
+	assert.Equal(
+		t,
+		map[string]string{
+			AuthenticationHeader: redactedHeaderValue,
+		},
+		entry.RequestHeaders,
+	)
+}
+
+type testStreamingClientConn struct {
+	spec           connect.Spec
+	requestHeader  http.Header
+	responseHeader http.Header
+	receiveErr     error
+}
+
+func (c *testStreamingClientConn) Spec() connect.Spec {
+	return c.spec
+}
+
+func (*testStreamingClientConn) Peer() connect.Peer {
+	return connect.Peer{}
+}
+
+func (*testStreamingClientConn) Send(any) error {
+	return nil
+}
+
+func (c *testStreamingClientConn) RequestHeader() http.Header {
+	return c.requestHeader
+}
+
+func (*testStreamingClientConn) CloseRequest() error {
+	return nil
+}
+
+func (c *testStreamingClientConn) Receive(any) error {
+	return c.receiveErr
+}
+
+func (c *testStreamingClientConn) ResponseHeader() http.Header {
+	return c.responseHeader
+}
+
+func (*testStreamingClientConn) ResponseTrailer() http.Header {
+	return nil
+}
+
+func (*testStreamingClientConn) CloseResponse() error {
+	return nil
+}
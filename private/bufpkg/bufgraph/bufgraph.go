@@ -16,6 +16,8 @@ package bufgraph
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
@@ -53,9 +55,78 @@ func (n *Node) String() string {
 	return s
 }
 
+// FileImport is an import of a file in one module by a file in another module.
+type FileImport struct {
+	// Path is the path of the importing file.
+	Path string
+	// ImportPath is the path of the imported file.
+	ImportPath string
+}
+
+// CycleError is returned from Build when modules import each other in a cycle.
+//
+// A cycle between modules can exist without a cycle between files, for example
+// when a file in module A imports a file in module B, and another file in
+// module B imports another file in module A.
+type CycleError struct {
+	// Nodes are the nodes in the cycle, with the first node repeated at the end.
+	Nodes []Node
+	// FileImports are the imports that create each edge of the cycle, that is
+	// FileImports[i] are the imports of files in Nodes[i+1] by files in Nodes[i].
+	FileImports [][]FileImport
+}
+
+// Error implements error.
+//
+// The message includes the file imports that create each edge of the cycle, and
+// suggests the edge with the fewest file imports as the point to break the cycle.
+func (c *CycleError) Error() string {
+	nodeStrings := make([]string, len(c.Nodes))
+	for i, node := range c.Nodes {
+		nodeStrings[i] = node.String()
+	}
+	var builder strings.Builder
+	_, _ = fmt.Fprintf(&builder, "modules have an import cycle: %s", strings.Join(nodeStrings, " -> "))
+	breakIndex := -1
+	for i, fileImports := range c.FileImports {
+		if len(fileImports) == 0 {
+			continue
+		}
+		if breakIndex < 0 || len(fileImports) < len(c.FileImports[breakIndex]) {
+			breakIndex = i
+		}
+		_, _ = fmt.Fprintf(&builder, "\n  %s -> %s:", nodeStrings[i], nodeStrings[i+1])
+		for _, fileImport := range fileImports {
+			_, _ = fmt.Fprintf(&builder, "\n    %s imports %s", fileImport.Path, fileImport.ImportPath)
+		}
+	}
+	if breakIndex >= 0 {
+		var paths []string
+		var importPaths []string
+		for _, fileImport := range c.FileImports[breakIndex] {
+			paths = appendIfNotContains(paths, fileImport.Path)
+			importPaths = appendIfNotContains(importPaths, fileImport.ImportPath)
+		}
+		_, _ = fmt.Fprintf(
+			&builder,
+			"\nTo break the cycle, remove the imports from %s to %s, which has the fewest imports in the cycle. "+
+				"For example, move %s to %s, move %s to %s, or invert the dependency by moving the shared types to a new module.",
+			nodeStrings[breakIndex],
+			nodeStrings[breakIndex+1],
+			strings.Join(importPaths, ", "),
+			nodeStrings[breakIndex],
+			strings.Join(paths, ", "),
+			nodeStrings[breakIndex+1],
+		)
+	}
+	return builder.String()
+}
+
 // Builder builds dependency graphs.
 type Builder interface {
 	// Build builds the dependency graph.
+	//
+	// Returns a *CycleError if the modules import each other in a cycle.
 	Build(
 		ctx context.Context,
 		modules []bufmodule.Module,
@@ -86,3 +157,12 @@ func BuildWithWorkspace(workspace bufmodule.Workspace) BuildOption {
 		buildOptions.workspace = workspace
 	}
 }
+
+func appendIfNotContains(values []string, value string) []string {
+	for _, existingValue := range values {
+		if existingValue == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
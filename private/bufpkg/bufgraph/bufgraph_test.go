@@ -74,6 +74,56 @@ func TestBasic(t *testing.T) {
 	)
 }
 
+func TestCycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	workspace, err := testBuildWorkspace(ctx, filepath.Join("testdata", "cycle"))
+	require.NoError(t, err)
+	builder := NewBuilder(
+		zap.NewNop(),
+		bufmodule.NewNopModuleResolver(),
+		bufmodule.NewNopModuleReader(),
+	)
+	_, _, err = builder.Build(
+		ctx,
+		workspace.GetModules(),
+		BuildWithWorkspace(workspace),
+	)
+	var cycleError *CycleError
+	require.ErrorAs(t, err, &cycleError)
+	nodeA := Node{Remote: "bsr.internal", Owner: "foo", Repository: "test-a"}
+	nodeB := Node{Remote: "bsr.internal", Owner: "foo", Repository: "test-b"}
+	require.Equal(
+		t,
+		&CycleError{
+			Nodes: []Node{nodeA, nodeB, nodeA},
+			FileImports: [][]FileImport{
+				{
+					{Path: "a/v1/a.proto", ImportPath: "b/v1/b.proto"},
+					{Path: "a/v1/a3.proto", ImportPath: "b/v1/b.proto"},
+				},
+				{
+					{Path: "b/v1/b2.proto", ImportPath: "a/v1/a2.proto"},
+				},
+			},
+		},
+		cycleError,
+	)
+	require.Equal(
+		t,
+		`modules have an import cycle: bsr.internal/foo/test-a -> bsr.internal/foo/test-b -> bsr.internal/foo/test-a
+  bsr.internal/foo/test-a -> bsr.internal/foo/test-b:
+    a/v1/a.proto imports b/v1/b.proto
+    a/v1/a3.proto imports b/v1/b.proto
+  bsr.internal/foo/test-b -> bsr.internal/foo/test-a:
+    b/v1/b2.proto imports a/v1/a2.proto
+To break the cycle, remove the imports from bsr.internal/foo/test-b to bsr.internal/foo/test-a, which has the fewest imports in the cycle. `+
+			`For example, move a/v1/a2.proto to bsr.internal/foo/test-b, move b/v1/b2.proto to bsr.internal/foo/test-a, or invert the dependency by moving the shared types to a new module.`,
+		cycleError.Error(),
+	)
+}
+
 // TODO: This entire function is all you should need to do to build workspaces, and even
 // this is overly complicated because of the wonkiness of bufmodulebuild and NewWorkspace.
 // We should have this in a common place for at least testing.
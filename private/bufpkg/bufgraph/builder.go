@@ -16,7 +16,9 @@ package bufgraph
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
@@ -73,6 +75,7 @@ func (b *builder) build(
 ) (*dag.Graph[Node], []bufanalysis.FileAnnotation, error) {
 	graph := dag.NewGraph[Node]()
 	alreadyProcessedNodes := make(map[Node]struct{})
+	edgeToFileImports := make(map[edge][]FileImport)
 	for _, module := range modules {
 		fileAnnotations, err := b.buildForModule(
 			ctx,
@@ -81,6 +84,7 @@ func (b *builder) build(
 			workspace,
 			graph,
 			alreadyProcessedNodes,
+			edgeToFileImports,
 		)
 		if err != nil {
 			return nil, nil, err
@@ -89,6 +93,13 @@ func (b *builder) build(
 			return nil, fileAnnotations, nil
 		}
 	}
+	if err := graph.WalkEdges(func(Node, Node) error { return nil }); err != nil {
+		var dagCycleError *dag.CycleError[Node]
+		if errors.As(err, &dagCycleError) {
+			return nil, nil, newCycleError(dagCycleError.Keys, edgeToFileImports)
+		}
+		return nil, nil, err
+	}
 	return graph, nil, nil
 }
 
@@ -99,6 +110,7 @@ func (b *builder) buildForModule(
 	workspace bufmodule.Workspace,
 	graph *dag.Graph[Node],
 	alreadyProcessedNodes map[Node]struct{},
+	edgeToFileImports map[edge][]FileImport,
 ) ([]bufanalysis.FileAnnotation, error) {
 	// We can't rely on the existence of a node in the graph for this, as when we add an edge
 	// to the graph, the node is added, and we still need to process the node as a potential
@@ -120,6 +132,7 @@ func (b *builder) buildForModule(
 	if len(fileAnnotations) > 0 {
 		return fileAnnotations, nil
 	}
+	addFileImports(image, node, edgeToFileImports)
 	for _, imageModuleDependency := range bufimage.ImageModuleDependencies(image) {
 		dependencyNode := newNodeForImageModuleDependency(imageModuleDependency)
 		if imageModuleDependency.IsDirect() {
@@ -141,6 +154,7 @@ func (b *builder) buildForModule(
 			workspace,
 			graph,
 			alreadyProcessedNodes,
+			edgeToFileImports,
 		)
 		if err != nil {
 			return nil, err
@@ -217,6 +231,63 @@ func newNodeForModule(module bufmodule.Module) Node {
 	return node
 }
 
+// edge is an edge in the dependency graph.
+type edge struct {
+	from Node
+	to   Node
+}
+
+// addFileImports adds the imports of files in other modules by the non-import
+// files of the image for the node to edgeToFileImports.
+func addFileImports(image bufimage.Image, node Node, edgeToFileImports map[edge][]FileImport) {
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		for _, dependency := range imageFile.FileDescriptorProto().GetDependency() {
+			dependencyImageFile := image.GetFile(dependency)
+			if dependencyImageFile == nil || !dependencyImageFile.IsImport() || dependencyImageFile.ModuleIdentity() == nil {
+				continue
+			}
+			dependencyNode := Node{
+				Remote:     dependencyImageFile.ModuleIdentity().Remote(),
+				Owner:      dependencyImageFile.ModuleIdentity().Owner(),
+				Repository: dependencyImageFile.ModuleIdentity().Repository(),
+				Commit:     dependencyImageFile.Commit(),
+			}
+			key := edge{from: node, to: dependencyNode}
+			edgeToFileImports[key] = append(
+				edgeToFileImports[key],
+				FileImport{
+					Path:       imageFile.Path(),
+					ImportPath: dependency,
+				},
+			)
+		}
+	}
+}
+
+func newCycleError(nodes []Node, edgeToFileImports map[edge][]FileImport) *CycleError {
+	fileImports := make([][]FileImport, len(nodes)-1)
+	for i := 0; i < len(nodes)-1; i++ {
+		edgeFileImports := edgeToFileImports[edge{from: nodes[i], to: nodes[i+1]}]
+		sort.Slice(
+			edgeFileImports,
+			func(i int, j int) bool {
+				if edgeFileImports[i].Path != edgeFileImports[j].Path {
+					return edgeFileImports[i].Path < edgeFileImports[j].Path
+				}
+				return edgeFileImports[i].ImportPath < edgeFileImports[j].ImportPath
+			},
+		)
+		fileImports[i] = edgeFileImports
+	}
+	return &CycleError{
+		Nodes:       nodes,
+		FileImports: fileImports,
+	}
+}
+
 type buildOptions struct {
 	workspace bufmodule.Workspace
 }
@@ -369,6 +369,19 @@ func ImageWithOnlyPathsAllowNotExist(
 	return imageWithOnlyPaths(image, paths, excludePaths, true)
 }
 
+// ExplainImport returns the shortest chain of imports from the file with the
+// root relative path fromPath to the file with the root relative path toPath.
+//
+// The chain starts with fromPath and ends with toPath, each file in the chain
+// importing the next one. If there are multiple shortest chains, the one that
+// follows the earliest imports of each file is returned.
+//
+// Returns nil if fromPath does not import toPath, either directly or transitively.
+// Returns an error if either path does not exist in the Image.
+func ExplainImport(image Image, fromPath string, toPath string) ([]string, error) {
+	return explainImport(image, fromPath, toPath)
+}
+
 // ImageByDir returns multiple images that have non-imports split
 // by directory.
 //
@@ -16,6 +16,7 @@ package bufimagebuild
 
 import (
 	"context"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
@@ -66,6 +67,26 @@ func WithExpectedDirectDependencies(expectedDirectDependencies []bufmoduleref.Mo
 	}
 }
 
+// WithTimings returns a BuildOption that records the durations of the phases of the build to the Timings.
+//
+// With this option, all files are parsed before any are linked, instead of as they are
+// imported, so that the phases can be timed separately.
+func WithTimings(timings *Timings) BuildOption {
+	return func(buildOptions *buildOptions) {
+		buildOptions.timings = timings
+	}
+}
+
+// Timings are the durations of the phases of a build.
+type Timings struct {
+	// Dependencies is the time spent reading the dependencies of the module.
+	Dependencies time.Duration
+	// Parse is the time spent parsing the files of the module and its dependencies.
+	Parse time.Duration
+	// Link is the time spent linking the parsed files into descriptors.
+	Link time.Duration
+}
+
 // WithWorkspace sets the workspace to be read from instead of ModuleReader, and to not warn imports for.
 //
 // TODO: this can probably be dealt with by finding out if an ImageFile has a commit
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufanalysis"
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
@@ -76,6 +77,7 @@ func (b *builder) Build(
 		buildOptions.excludeSourceCodeInfo,
 		buildOptions.expectedDirectDependencies,
 		buildOptions.workspace,
+		buildOptions.timings,
 	)
 }
 
@@ -85,6 +87,7 @@ func (b *builder) build(
 	excludeSourceCodeInfo bool,
 	expectedDirectDeps []bufmoduleref.ModuleReference,
 	workspace bufmodule.Workspace,
+	timings *Timings,
 ) (_ bufimage.Image, _ []bufanalysis.FileAnnotation, retErr error) {
 	ctx, span := b.tracer.Start(ctx, "build")
 	defer span.End()
@@ -95,6 +98,7 @@ func (b *builder) build(
 		}
 	}()
 
+	start := time.Now()
 	// TODO: remove this once bufmodule.ModuleFileSet is deleted or no longer inherits from Module
 	// We still need to handle the ModuleFileSet case for buf export, as we actually need the
 	// ModuleFileSet there.
@@ -112,6 +116,9 @@ func (b *builder) build(
 		}
 	}
 
+	if timings != nil {
+		timings.Dependencies = time.Since(start)
+	}
 	parserAccessorHandler := bufmoduleprotocompile.NewParserAccessorHandler(ctx, moduleFileSet)
 	targetFileInfos, err := moduleFileSet.TargetFileInfos(ctx)
 	if err != nil {
@@ -125,12 +132,27 @@ func (b *builder) build(
 		paths[i] = targetFileInfo.Path()
 	}
 
+	var parsedFiles *parsedFiles
+	if timings != nil {
+		// Parse all files before compiling so that parsing and linking can be timed separately.
+		start = time.Now()
+		parsedFiles, err = parseAll(ctx, parserAccessorHandler, paths)
+		if err != nil {
+			return nil, nil, err
+		}
+		timings.Parse = time.Since(start)
+		start = time.Now()
+	}
 	buildResult := getBuildResult(
 		ctx,
 		parserAccessorHandler,
 		paths,
 		excludeSourceCodeInfo,
+		parsedFiles,
 	)
+	if timings != nil {
+		timings.Link = time.Since(start)
+	}
 	if buildResult.Err != nil {
 		return nil, nil, buildResult.Err
 	}
@@ -307,9 +329,16 @@ func getBuildResult(
 	parserAccessorHandler bufmoduleprotocompile.ParserAccessorHandler,
 	paths []string,
 	excludeSourceCodeInfo bool,
+	parsedFiles *parsedFiles,
 ) *buildResult {
 	var errorsWithPos []reporter.ErrorWithPos
 	var warningErrorsWithPos []reporter.ErrorWithPos
+	var resolver protocompile.Resolver = &protocompile.SourceResolver{Accessor: parserAccessorHandler.Open}
+	if parsedFiles != nil {
+		resolver = parsedFiles.resolver(resolver)
+		// Warnings for the parsed files were reported when they were parsed.
+		warningErrorsWithPos = append(warningErrorsWithPos, parsedFiles.warningErrorsWithPos...)
+	}
 	// With "extra option locations", buf can include more comments
 	// for an option value than protoc can. In particular, this allows
 	// it to preserve comments inside of message literals.
@@ -320,7 +349,7 @@ func getBuildResult(
 	compiler := protocompile.Compiler{
 		MaxParallelism: thread.Parallelism(),
 		SourceInfoMode: sourceInfoMode,
-		Resolver:       &protocompile.SourceResolver{Accessor: parserAccessorHandler.Open},
+		Resolver:       resolver,
 		Reporter: reporter.NewReporter(
 			func(errorWithPos reporter.ErrorWithPos) error {
 				errorsWithPos = append(errorsWithPos, errorWithPos)
@@ -645,6 +674,7 @@ type buildOptions struct {
 	excludeSourceCodeInfo      bool
 	expectedDirectDependencies []bufmoduleref.ModuleReference
 	workspace                  bufmodule.Workspace
+	timings                    *Timings
 }
 
 func newBuildOptions() *buildOptions {
@@ -0,0 +1,127 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufimagebuild
+
+import (
+	"context"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleprotocompile"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"github.com/bufbuild/buf/private/pkg/thread"
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+	"go.uber.org/multierr"
+)
+
+// parsedFiles are files parsed ahead of compilation.
+type parsedFiles struct {
+	pathToResult         map[string]parser.Result
+	warningErrorsWithPos []reporter.ErrorWithPos
+}
+
+// resolver returns a Resolver that returns the parsed files, and otherwise delegates
+// to the given Resolver.
+func (p *parsedFiles) resolver(delegate protocompile.Resolver) protocompile.Resolver {
+	return protocompile.ResolverFunc(
+		func(path string) (protocompile.SearchResult, error) {
+			if result, ok := p.pathToResult[path]; ok {
+				return protocompile.SearchResult{ParseResult: result}, nil
+			}
+			return delegate.FindFileByPath(path)
+		},
+	)
+}
+
+// parseAll parses the files at the paths and all of the files they import, transitively.
+//
+// Files that cannot be read or parsed are not included, so that the compiler reads and
+// reports them exactly as it would without parsing ahead of time.
+func parseAll(
+	ctx context.Context,
+	parserAccessorHandler bufmoduleprotocompile.ParserAccessorHandler,
+	paths []string,
+) (*parsedFiles, error) {
+	parsedFiles := &parsedFiles{
+		pathToResult: make(map[string]parser.Result),
+	}
+	seenPaths := slicesext.ToStructMap(paths)
+	for len(paths) > 0 {
+		results := make([]parser.Result, len(paths))
+		warningErrorsWithPos := make([][]reporter.ErrorWithPos, len(paths))
+		jobs := make([]func(context.Context) error, len(paths))
+		for i, path := range paths {
+			i := i
+			path := path
+			jobs[i] = func(context.Context) error {
+				results[i], warningErrorsWithPos[i] = parseFile(parserAccessorHandler, path)
+				return nil
+			}
+		}
+		if err := thread.Parallelize(ctx, jobs); err != nil {
+			return nil, err
+		}
+		var importPaths []string
+		for i, result := range results {
+			if result == nil {
+				continue
+			}
+			parsedFiles.pathToResult[paths[i]] = result
+			parsedFiles.warningErrorsWithPos = append(parsedFiles.warningErrorsWithPos, warningErrorsWithPos[i]...)
+			for _, importPath := range result.FileDescriptorProto().GetDependency() {
+				if _, ok := seenPaths[importPath]; !ok {
+					seenPaths[importPath] = struct{}{}
+					importPaths = append(importPaths, importPath)
+				}
+			}
+		}
+		paths = importPaths
+	}
+	return parsedFiles, nil
+}
+
+// parseFile parses the file at the path.
+//
+// Returns nil if the file cannot be read or has any errors.
+func parseFile(
+	parserAccessorHandler bufmoduleprotocompile.ParserAccessorHandler,
+	path string,
+) (parser.Result, []reporter.ErrorWithPos) {
+	var warningErrorsWithPos []reporter.ErrorWithPos
+	handler := reporter.NewHandler(
+		reporter.NewReporter(
+			func(errorWithPos reporter.ErrorWithPos) error {
+				// Abort on the first error, the compiler will report all errors.
+				return errorWithPos
+			},
+			func(errorWithPos reporter.ErrorWithPos) {
+				warningErrorsWithPos = append(warningErrorsWithPos, errorWithPos)
+			},
+		),
+	)
+	readCloser, err := parserAccessorHandler.Open(path)
+	if err != nil {
+		return nil, nil
+	}
+	fileNode, err := parser.Parse(path, readCloser, handler)
+	if err = multierr.Append(err, readCloser.Close()); err != nil {
+		return nil, nil
+	}
+	result, err := parser.ResultFromAST(fileNode, true, handler)
+	if err != nil {
+		return nil, nil
+	}
+	return result, warningErrorsWithPos
+}
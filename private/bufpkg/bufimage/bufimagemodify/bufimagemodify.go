@@ -306,6 +306,23 @@ func RubyPackage(
 	)
 }
 
+// FileOptionDefault returns a Modifier that sets the custom file option with the given
+// fully-qualified name to the value in all of the non-import files contained in the Image
+// that do not already set it.
+//
+// The option must be a non-repeated scalar or enum extension of google.protobuf.FileOptions
+// that is declared in the Image. The value is parsed according to the type of the option,
+// and enum values are given by name. If paths are given, only the files that are equal to
+// or contained within one of the paths are modified.
+func FileOptionDefault(
+	logger *zap.Logger,
+	optionName string,
+	value string,
+	paths []string,
+) Modifier {
+	return fileOptionDefault(logger, optionName, value, paths)
+}
+
 // isWellKnownType returns true if the given path is one of the well-known types.
 func isWellKnownType(ctx context.Context, imageFile bufimage.ImageFile) bool {
 	return datawkt.Exists(imageFile.Path())
@@ -0,0 +1,203 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufimagemodify
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// fileOptionsFullName is the full name of the message that custom file options extend.
+const fileOptionsFullName protoreflect.FullName = "google.protobuf.FileOptions"
+
+func fileOptionDefault(
+	logger *zap.Logger,
+	optionName string,
+	value string,
+	paths []string,
+) Modifier {
+	pathsMap := slicesext.ToStructMap(paths)
+	return ModifierFunc(
+		func(ctx context.Context, image bufimage.Image) error {
+			var matchedImageFiles []bufimage.ImageFile
+			for _, imageFile := range image.Files() {
+				if imageFile.IsImport() || isWellKnownType(ctx, imageFile) {
+					continue
+				}
+				if len(paths) > 0 && !normalpath.MapHasEqualOrContainingPath(pathsMap, imageFile.Path(), normalpath.Relative) {
+					continue
+				}
+				matchedImageFiles = append(matchedImageFiles, imageFile)
+			}
+			if len(matchedImageFiles) == 0 {
+				if len(paths) > 0 {
+					logger.Sugar().Warnf("default for option %q did not match any files", optionName)
+				}
+				return nil
+			}
+			resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+			if err != nil {
+				return err
+			}
+			extensionType, err := resolver.FindExtensionByName(protoreflect.FullName(optionName))
+			if err != nil {
+				return fmt.Errorf("default for option %q: extension not found in image: %w", optionName, err)
+			}
+			extensionDescriptor := extensionType.TypeDescriptor()
+			if extensionDescriptor.ContainingMessage().FullName() != fileOptionsFullName {
+				return fmt.Errorf("default for option %q: %q does not extend %s", optionName, optionName, fileOptionsFullName)
+			}
+			optionValue, err := parseOptionValue(extensionDescriptor, value)
+			if err != nil {
+				return fmt.Errorf("default for option %q: %w", optionName, err)
+			}
+			// The extension is marshaled once, and appended to the unknown fields of the options
+			// of each file, as the file options are not aware of extensions within the image.
+			message := dynamicpb.NewMessage(extensionDescriptor.ContainingMessage())
+			message.Set(extensionDescriptor, optionValue)
+			data, err := proto.Marshal(message)
+			if err != nil {
+				return err
+			}
+			for _, imageFile := range matchedImageFiles {
+				fileOptionDefaultForFile(imageFile, extensionDescriptor.Number(), extensionDescriptor.ParentFile().Path(), data)
+			}
+			return nil
+		},
+	)
+}
+
+func fileOptionDefaultForFile(
+	imageFile bufimage.ImageFile,
+	number protoreflect.FieldNumber,
+	extensionFilePath string,
+	data []byte,
+) {
+	descriptor := imageFile.FileDescriptorProto()
+	if descriptor.Options == nil {
+		descriptor.Options = &descriptorpb.FileOptions{}
+	}
+	optionsMessage := descriptor.Options.ProtoReflect()
+	if fileOptionsHasField(optionsMessage, number) {
+		// The option is already set, don't do anything.
+		return
+	}
+	unknown := make(protoreflect.RawFields, 0, len(optionsMessage.GetUnknown())+len(data))
+	unknown = append(unknown, optionsMessage.GetUnknown()...)
+	optionsMessage.SetUnknown(append(unknown, data...))
+	// The file must import the file that declares the extension for the option to resolve.
+	if imageFile.Path() != extensionFilePath && !slicesext.ElementsContained(descriptor.Dependency, []string{extensionFilePath}) {
+		descriptor.Dependency = append(descriptor.Dependency, extensionFilePath)
+	}
+}
+
+// fileOptionsHasField returns true if the field with the number is set on the options,
+// either as a known extension or as an unknown field.
+func fileOptionsHasField(optionsMessage protoreflect.Message, number protoreflect.FieldNumber) bool {
+	var found bool
+	optionsMessage.Range(func(fieldDescriptor protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		found = fieldDescriptor.Number() == number
+		return !found
+	})
+	if found {
+		return true
+	}
+	unknown := optionsMessage.GetUnknown()
+	for len(unknown) > 0 {
+		fieldNumber, _, n := protowire.ConsumeField(unknown)
+		if n < 0 {
+			return false
+		}
+		if fieldNumber == number {
+			return true
+		}
+		unknown = unknown[n:]
+	}
+	return false
+}
+
+func parseOptionValue(extensionDescriptor protoreflect.ExtensionTypeDescriptor, value string) (protoreflect.Value, error) {
+	if extensionDescriptor.Cardinality() == protoreflect.Repeated {
+		return protoreflect.Value{}, fmt.Errorf("repeated options are not supported")
+	}
+	switch kind := extensionDescriptor.Kind(); kind {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(value)), nil
+	case protoreflect.BoolKind:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid bool value %q", value)
+		}
+		return protoreflect.ValueOfBool(parsed), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		parsed, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfInt32(int32(parsed)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfInt64(parsed), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfUint32(uint32(parsed)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfUint64(parsed), nil
+	case protoreflect.FloatKind:
+		parsed, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfFloat32(float32(parsed)), nil
+	case protoreflect.DoubleKind:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("invalid %s value %q", kind, value)
+		}
+		return protoreflect.ValueOfFloat64(parsed), nil
+	case protoreflect.EnumKind:
+		enumValueDescriptor := extensionDescriptor.Enum().Values().ByName(protoreflect.Name(value))
+		if enumValueDescriptor == nil {
+			return protoreflect.Value{}, fmt.Errorf("%q is not a value of enum %s", value, extensionDescriptor.Enum().FullName())
+		}
+		return protoreflect.ValueOfEnum(enumValueDescriptor.Number()), nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("options of kind %s are not supported", kind)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufimagemodify
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestFileOptionDefault(t *testing.T) {
+	t.Parallel()
+	dirPath := filepath.Join("testdata", "customoptions")
+	image := testGetImage(t, dirPath, false)
+	modifier := NewMultiModifier(
+		FileOptionDefault(zap.NewNop(), "acme.tier.tier", "TIER_GOLD", nil),
+		FileOptionDefault(zap.NewNop(), "acme.tier.owner", "platform-team", []string{"acme/billing"}),
+	)
+	require.NoError(t, modifier.Modify(context.Background(), image))
+
+	// Options that are already set are not overridden.
+	assert.Equal(t, "TIER_GOLD", testGetFileOptionValue(t, image, "acme/payments/payments.proto", "acme.tier.tier"))
+	assert.Equal(t, "payments-team", testGetFileOptionValue(t, image, "acme/payments/payments.proto", "acme.tier.owner"))
+	assert.Equal(t, "TIER_BRONZE", testGetFileOptionValue(t, image, "acme/billing/billing.proto", "acme.tier.tier"))
+	assert.Equal(t, "platform-team", testGetFileOptionValue(t, image, "acme/billing/billing.proto", "acme.tier.owner"))
+	// The file that declares the options has them set as well, except where limited by paths.
+	assert.Equal(t, "TIER_GOLD", testGetFileOptionValue(t, image, "acme/tier/tier.proto", "acme.tier.tier"))
+	assert.Equal(t, "", testGetFileOptionValue(t, image, "acme/tier/tier.proto", "acme.tier.owner"))
+	// Files that did not import the file that declares the options now import it.
+	assert.Equal(t, "TIER_GOLD", testGetFileOptionValue(t, image, "acme/orders/orders.proto", "acme.tier.tier"))
+	assert.Equal(t, []string{"acme/tier/tier.proto"}, image.GetFile("acme/orders/orders.proto").FileDescriptorProto().GetDependency())
+	assert.Equal(t, []string{"acme/tier/tier.proto"}, image.GetFile("acme/billing/billing.proto").FileDescriptorProto().GetDependency())
+	// The image is still valid.
+	_, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	require.NoError(t, err)
+}
+
+func TestFileOptionDefaultError(t *testing.T) {
+	t.Parallel()
+	dirPath := filepath.Join("testdata", "customoptions")
+	for _, testCase := range []struct {
+		optionName string
+		value      string
+	}{
+		{optionName: "acme.tier.missing", value: "TIER_GOLD"},
+		{optionName: "acme.tier.tier", value: "TIER_PLATINUM"},
+		{optionName: "acme.tier.Tier", value: "TIER_GOLD"},
+	} {
+		testCase := testCase
+		t.Run(testCase.optionName, func(t *testing.T) {
+			t.Parallel()
+			image := testGetImage(t, dirPath, false)
+			err := FileOptionDefault(zap.NewNop(), testCase.optionName, testCase.value, nil).Modify(context.Background(), image)
+			require.Error(t, err)
+		})
+	}
+}
+
+// testGetFileOptionValue returns the value of the custom file option as a string, with
+// enum values as their name, or the empty string if the option is not set.
+func testGetFileOptionValue(t *testing.T, image bufimage.Image, path string, optionName string) string {
+	resolver, err := protoencoding.NewResolver(bufimage.ImageToFileDescriptorProtos(image)...)
+	require.NoError(t, err)
+	extensionType, err := resolver.FindExtensionByName(protoreflect.FullName(optionName))
+	require.NoError(t, err)
+	imageFile := image.GetFile(path)
+	require.NotNil(t, imageFile)
+	options := imageFile.FileDescriptorProto().GetOptions()
+	if options == nil {
+		return ""
+	}
+	data, err := protoencoding.NewWireMarshaler().Marshal(options)
+	require.NoError(t, err)
+	message := dynamicpb.NewMessage(extensionType.TypeDescriptor().ContainingMessage())
+	require.NoError(t, proto.UnmarshalOptions{Resolver: resolver}.Unmarshal(data, message))
+	if !message.Has(extensionType.TypeDescriptor()) {
+		return ""
+	}
+	value := message.Get(extensionType.TypeDescriptor())
+	if enumDescriptor := extensionType.TypeDescriptor().Enum(); enumDescriptor != nil {
+		return string(enumDescriptor.Values().ByNumber(value.Enum()).Name())
+	}
+	return value.String()
+}
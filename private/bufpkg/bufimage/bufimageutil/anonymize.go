@@ -0,0 +1,436 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufimageutil
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/gen/data/datawkt"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// versionPackageComponentRegexp matches package components that are versions, such as
+// v1 or v1beta1. These are not anonymized, as they affect lint and breaking change rules.
+var versionPackageComponentRegexp = regexp.MustCompile(`^v\d+((alpha|beta)\d*)?$`)
+
+type anonymizer struct {
+	// oldPackageComponent -> newPackageComponent
+	packageComponents map[string]string
+	// oldPath -> newPath
+	paths map[string]string
+	// oldFullName -> newFullName, for messages and enums, with leading dots
+	typeNames map[string]string
+	// oldEnumFullName -> oldValueName -> newValueName, with leading dots
+	enumValueNames map[string]map[string]string
+	fileCount      int
+	messageCount   int
+	enumCount      int
+	serviceCount   int
+	extensionCount int
+}
+
+func newAnonymizer() *anonymizer {
+	return &anonymizer{
+		packageComponents: make(map[string]string),
+		paths:             make(map[string]string),
+		typeNames:         make(map[string]string),
+		enumValueNames:    make(map[string]map[string]string),
+	}
+}
+
+func (a *anonymizer) anonymizeImage(image bufimage.Image) (bufimage.Image, error) {
+	imageFiles := image.Files()
+	fileDescriptorProtos := make([]*descriptorpb.FileDescriptorProto, len(imageFiles))
+	for i, imageFile := range imageFiles {
+		fileDescriptorProto, ok := proto.Clone(imageFile.FileDescriptorProto()).(*descriptorpb.FileDescriptorProto)
+		if !ok {
+			return nil, fmt.Errorf("could not clone %q", imageFile.Path())
+		}
+		fileDescriptorProtos[i] = fileDescriptorProto
+	}
+	a.anonymizeFileDescriptorProtos(fileDescriptorProtos)
+	anonymizedImageFiles := make([]bufimage.ImageFile, len(imageFiles))
+	for i, imageFile := range imageFiles {
+		// The module identity, commit, and external path are dropped, as they may identify the schema.
+		anonymizedImageFile, err := bufimage.NewImageFile(
+			fileDescriptorProtos[i],
+			nil,
+			"",
+			"",
+			imageFile.IsImport(),
+			imageFile.IsSyntaxUnspecified(),
+			imageFile.UnusedDependencyIndexes(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		anonymizedImageFiles[i] = anonymizedImageFile
+	}
+	return bufimage.NewImage(anonymizedImageFiles)
+}
+
+// anonymizeFileDescriptorProtos anonymizes the files in place.
+//
+// The declarations of all files are renamed first, so that references to types
+// in any file can then be updated.
+func (a *anonymizer) anonymizeFileDescriptorProtos(fileDescriptorProtos []*descriptorpb.FileDescriptorProto) {
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		if datawkt.Exists(fileDescriptorProto.GetName()) {
+			continue
+		}
+		a.renameFile(fileDescriptorProto)
+	}
+	for _, fileDescriptorProto := range fileDescriptorProtos {
+		if datawkt.Exists(fileDescriptorProto.GetName()) {
+			continue
+		}
+		a.updateFileReferences(fileDescriptorProto)
+	}
+}
+
+func (a *anonymizer) renameFile(fileDescriptorProto *descriptorpb.FileDescriptorProto) {
+	oldPackage := fileDescriptorProto.GetPackage()
+	var newPackageComponents []string
+	if oldPackage != "" {
+		for _, component := range strings.Split(oldPackage, ".") {
+			newPackageComponents = append(newPackageComponents, a.renamePackageComponent(component))
+		}
+	}
+	newPackage := strings.Join(newPackageComponents, ".")
+	a.fileCount++
+	newPath := normalpath.Join(normalpath.Join(newPackageComponents...), "file"+strconv.Itoa(a.fileCount)+".proto")
+	a.paths[fileDescriptorProto.GetName()] = newPath
+	fileDescriptorProto.Name = proto.String(newPath)
+	if fileDescriptorProto.Package != nil {
+		fileDescriptorProto.Package = proto.String(newPackage)
+	}
+	for _, messageDescriptorProto := range fileDescriptorProto.GetMessageType() {
+		a.renameMessage(messageDescriptorProto, oldPackage, newPackage, "")
+	}
+	for _, enumDescriptorProto := range fileDescriptorProto.GetEnumType() {
+		a.renameEnum(enumDescriptorProto, oldPackage, newPackage)
+	}
+	for _, extensionDescriptorProto := range fileDescriptorProto.GetExtension() {
+		a.renameExtension(extensionDescriptorProto)
+	}
+	for _, serviceDescriptorProto := range fileDescriptorProto.GetService() {
+		a.serviceCount++
+		serviceDescriptorProto.Name = proto.String("Service" + strconv.Itoa(a.serviceCount))
+		for i, methodDescriptorProto := range serviceDescriptorProto.GetMethod() {
+			methodDescriptorProto.Name = proto.String("Method" + strconv.Itoa(i+1))
+		}
+	}
+	fileDescriptorProto.SourceCodeInfo = nil
+	stripFileOptions(fileDescriptorProto)
+	if fileOptions := fileDescriptorProto.GetOptions(); fileOptions != nil {
+		// These options are derived from the package and path, and would leak them.
+		fileOptions.GoPackage = nil
+		fileOptions.JavaPackage = nil
+		fileOptions.JavaOuterClassname = nil
+		fileOptions.CsharpNamespace = nil
+		fileOptions.ObjcClassPrefix = nil
+		fileOptions.PhpNamespace = nil
+		fileOptions.PhpMetadataNamespace = nil
+		fileOptions.PhpClassPrefix = nil
+		fileOptions.RubyPackage = nil
+		fileOptions.SwiftPrefix = nil
+	}
+}
+
+// renameMessage renames the message and all of its fields, nested types, and extensions.
+//
+// If mapEntryName is not empty, the message is a map entry and is renamed to it.
+func (a *anonymizer) renameMessage(
+	messageDescriptorProto *descriptorpb.DescriptorProto,
+	oldScope string,
+	newScope string,
+	mapEntryName string,
+) {
+	oldFullName := joinFullName(oldScope, messageDescriptorProto.GetName())
+	newName := mapEntryName
+	if newName == "" {
+		a.messageCount++
+		newName = "Message" + strconv.Itoa(a.messageCount)
+	}
+	newFullName := joinFullName(newScope, newName)
+	a.typeNames["."+oldFullName] = "." + newFullName
+	messageDescriptorProto.Name = proto.String(newName)
+	messageDescriptorProto.ReservedName = nil
+	isMapEntry := messageDescriptorProto.GetOptions().GetMapEntry()
+	// oldTypeName -> newFieldName, to name map entries after the fields that use them
+	mapEntryTypeNameToFieldName := make(map[string]string)
+	for i, oneofDescriptorProto := range messageDescriptorProto.GetOneofDecl() {
+		oneofDescriptorProto.Name = proto.String("oneof_" + strconv.Itoa(i+1))
+	}
+	for _, fieldDescriptorProto := range messageDescriptorProto.GetField() {
+		if isMapEntry {
+			// Map entry fields must be named key and value.
+			continue
+		}
+		fieldName := "field_" + strconv.Itoa(int(fieldDescriptorProto.GetNumber()))
+		mapEntryTypeNameToFieldName[fieldDescriptorProto.GetTypeName()] = fieldName
+		renameField(fieldDescriptorProto, fieldName)
+		if fieldDescriptorProto.GetProto3Optional() && fieldDescriptorProto.OneofIndex != nil {
+			// Synthetic oneofs are named after their field.
+			messageDescriptorProto.GetOneofDecl()[fieldDescriptorProto.GetOneofIndex()].Name = proto.String("_" + fieldName)
+		}
+	}
+	for _, nestedMessageDescriptorProto := range messageDescriptorProto.GetNestedType() {
+		var nestedMapEntryName string
+		if nestedMessageDescriptorProto.GetOptions().GetMapEntry() {
+			if fieldName, ok := mapEntryTypeNameToFieldName["."+joinFullName(oldFullName, nestedMessageDescriptorProto.GetName())]; ok {
+				nestedMapEntryName = mapEntryNameForFieldName(fieldName)
+			}
+		}
+		a.renameMessage(nestedMessageDescriptorProto, oldFullName, newFullName, nestedMapEntryName)
+	}
+	for _, nestedEnumDescriptorProto := range messageDescriptorProto.GetEnumType() {
+		a.renameEnum(nestedEnumDescriptorProto, oldFullName, newFullName)
+	}
+	for _, extensionDescriptorProto := range messageDescriptorProto.GetExtension() {
+		a.renameExtension(extensionDescriptorProto)
+	}
+}
+
+func (a *anonymizer) renameEnum(
+	enumDescriptorProto *descriptorpb.EnumDescriptorProto,
+	oldScope string,
+	newScope string,
+) {
+	oldFullName := joinFullName(oldScope, enumDescriptorProto.GetName())
+	a.enumCount++
+	newName := "Enum" + strconv.Itoa(a.enumCount)
+	a.typeNames["."+oldFullName] = "." + joinFullName(newScope, newName)
+	enumDescriptorProto.Name = proto.String(newName)
+	enumDescriptorProto.ReservedName = nil
+	valueNames := make(map[string]string)
+	a.enumValueNames["."+oldFullName] = valueNames
+	for i, enumValueDescriptorProto := range enumDescriptorProto.GetValue() {
+		// Enum values are scoped to the enclosing scope of the enum, so they are prefixed with the enum name.
+		newValueName := strings.ToUpper(newName) + "_VALUE_" + strconv.Itoa(i)
+		valueNames[enumValueDescriptorProto.GetName()] = newValueName
+		enumValueDescriptorProto.Name = proto.String(newValueName)
+	}
+}
+
+func (a *anonymizer) renameExtension(fieldDescriptorProto *descriptorpb.FieldDescriptorProto) {
+	a.extensionCount++
+	renameField(fieldDescriptorProto, "extension_"+strconv.Itoa(a.extensionCount))
+}
+
+func (a *anonymizer) renamePackageComponent(component string) string {
+	if versionPackageComponentRegexp.MatchString(component) {
+		return component
+	}
+	newComponent, ok := a.packageComponents[component]
+	if !ok {
+		newComponent = "pkg" + strconv.Itoa(len(a.packageComponents)+1)
+		a.packageComponents[component] = newComponent
+	}
+	return newComponent
+}
+
+func (a *anonymizer) updateFileReferences(fileDescriptorProto *descriptorpb.FileDescriptorProto) {
+	for i, dependency := range fileDescriptorProto.GetDependency() {
+		if newDependency, ok := a.paths[dependency]; ok {
+			fileDescriptorProto.Dependency[i] = newDependency
+		}
+	}
+	for _, messageDescriptorProto := range fileDescriptorProto.GetMessageType() {
+		a.updateMessageReferences(messageDescriptorProto)
+	}
+	for _, extensionDescriptorProto := range fileDescriptorProto.GetExtension() {
+		a.updateFieldReferences(extensionDescriptorProto)
+	}
+	for _, serviceDescriptorProto := range fileDescriptorProto.GetService() {
+		for _, methodDescriptorProto := range serviceDescriptorProto.GetMethod() {
+			methodDescriptorProto.InputType = a.updateTypeName(methodDescriptorProto.InputType)
+			methodDescriptorProto.OutputType = a.updateTypeName(methodDescriptorProto.OutputType)
+		}
+	}
+}
+
+func (a *anonymizer) updateMessageReferences(messageDescriptorProto *descriptorpb.DescriptorProto) {
+	for _, fieldDescriptorProto := range messageDescriptorProto.GetField() {
+		a.updateFieldReferences(fieldDescriptorProto)
+	}
+	for _, extensionDescriptorProto := range messageDescriptorProto.GetExtension() {
+		a.updateFieldReferences(extensionDescriptorProto)
+	}
+	for _, nestedMessageDescriptorProto := range messageDescriptorProto.GetNestedType() {
+		a.updateMessageReferences(nestedMessageDescriptorProto)
+	}
+}
+
+func (a *anonymizer) updateFieldReferences(fieldDescriptorProto *descriptorpb.FieldDescriptorProto) {
+	if fieldDescriptorProto.GetType() == descriptorpb.FieldDescriptorProto_TYPE_ENUM && fieldDescriptorProto.DefaultValue != nil {
+		if newValueName, ok := a.enumValueNames[fieldDescriptorProto.GetTypeName()][fieldDescriptorProto.GetDefaultValue()]; ok {
+			fieldDescriptorProto.DefaultValue = proto.String(newValueName)
+		}
+	}
+	switch fieldDescriptorProto.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		if fieldDescriptorProto.DefaultValue != nil {
+			// String and bytes default values may contain anything.
+			fieldDescriptorProto.DefaultValue = proto.String("")
+		}
+	}
+	fieldDescriptorProto.TypeName = a.updateTypeName(fieldDescriptorProto.TypeName)
+	fieldDescriptorProto.Extendee = a.updateTypeName(fieldDescriptorProto.Extendee)
+}
+
+// stripFileOptions strips the custom options, uninterpreted options, and unknown fields
+// from the options of the file and all of its elements, as these may contain anything.
+func stripFileOptions(fileDescriptorProto *descriptorpb.FileDescriptorProto) {
+	stripOptions(fileDescriptorProto.GetOptions())
+	for _, messageDescriptorProto := range fileDescriptorProto.GetMessageType() {
+		stripMessageOptions(messageDescriptorProto)
+	}
+	for _, enumDescriptorProto := range fileDescriptorProto.GetEnumType() {
+		stripEnumOptions(enumDescriptorProto)
+	}
+	for _, extensionDescriptorProto := range fileDescriptorProto.GetExtension() {
+		stripOptions(extensionDescriptorProto.GetOptions())
+	}
+	for _, serviceDescriptorProto := range fileDescriptorProto.GetService() {
+		stripOptions(serviceDescriptorProto.GetOptions())
+		for _, methodDescriptorProto := range serviceDescriptorProto.GetMethod() {
+			stripOptions(methodDescriptorProto.GetOptions())
+		}
+	}
+}
+
+func stripMessageOptions(messageDescriptorProto *descriptorpb.DescriptorProto) {
+	stripOptions(messageDescriptorProto.GetOptions())
+	for _, fieldDescriptorProto := range messageDescriptorProto.GetField() {
+		stripOptions(fieldDescriptorProto.GetOptions())
+	}
+	for _, oneofDescriptorProto := range messageDescriptorProto.GetOneofDecl() {
+		stripOptions(oneofDescriptorProto.GetOptions())
+	}
+	for _, extensionRange := range messageDescriptorProto.GetExtensionRange() {
+		stripOptions(extensionRange.GetOptions())
+	}
+	for _, extensionDescriptorProto := range messageDescriptorProto.GetExtension() {
+		stripOptions(extensionDescriptorProto.GetOptions())
+	}
+	for _, nestedMessageDescriptorProto := range messageDescriptorProto.GetNestedType() {
+		stripMessageOptions(nestedMessageDescriptorProto)
+	}
+	for _, nestedEnumDescriptorProto := range messageDescriptorProto.GetEnumType() {
+		stripEnumOptions(nestedEnumDescriptorProto)
+	}
+}
+
+func stripEnumOptions(enumDescriptorProto *descriptorpb.EnumDescriptorProto) {
+	stripOptions(enumDescriptorProto.GetOptions())
+	for _, enumValueDescriptorProto := range enumDescriptorProto.GetValue() {
+		stripOptions(enumValueDescriptorProto.GetOptions())
+	}
+}
+
+// stripOptions strips the extensions, uninterpreted options, and unknown fields from
+// the options message, and from every message within it.
+//
+// Custom options are extensions if they were resolved when the image was read, and
+// unknown fields otherwise.
+func stripOptions(options proto.Message) {
+	if options == nil {
+		return
+	}
+	stripMessage(options.ProtoReflect())
+}
+
+func stripMessage(message protoreflect.Message) {
+	if !message.IsValid() {
+		return
+	}
+	message.SetUnknown(nil)
+	message.Range(func(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if fieldDescriptor.IsExtension() || fieldDescriptor.Name() == "uninterpreted_option" {
+			message.Clear(fieldDescriptor)
+			return true
+		}
+		if fieldDescriptor.Message() == nil || fieldDescriptor.IsMap() {
+			return true
+		}
+		if fieldDescriptor.IsList() {
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				stripMessage(list.Get(i).Message())
+			}
+			return true
+		}
+		stripMessage(value.Message())
+		return true
+	})
+}
+
+func (a *anonymizer) updateTypeName(typeName *string) *string {
+	if typeName == nil {
+		return nil
+	}
+	if newTypeName, ok := a.typeNames[*typeName]; ok {
+		return proto.String(newTypeName)
+	}
+	return typeName
+}
+
+func renameField(fieldDescriptorProto *descriptorpb.FieldDescriptorProto, name string) {
+	fieldDescriptorProto.Name = proto.String(name)
+	if fieldDescriptorProto.JsonName != nil {
+		fieldDescriptorProto.JsonName = proto.String(jsonNameForFieldName(name))
+	}
+}
+
+// jsonNameForFieldName returns the default JSON name of the field, which is the
+// name in lower camel case.
+func jsonNameForFieldName(fieldName string) string {
+	var builder strings.Builder
+	afterUnderscore := false
+	for _, r := range fieldName {
+		switch {
+		case r == '_':
+			afterUnderscore = true
+		case afterUnderscore:
+			builder.WriteString(strings.ToUpper(string(r)))
+			afterUnderscore = false
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// mapEntryNameForFieldName returns the name of the map entry message for the
+// field, which is the name in upper camel case followed by Entry.
+func mapEntryNameForFieldName(fieldName string) string {
+	jsonName := jsonNameForFieldName(fieldName)
+	return strings.ToUpper(jsonName[:1]) + jsonName[1:] + "Entry"
+}
+
+func joinFullName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
@@ -22,7 +22,9 @@ import (
 
 	"github.com/bufbuild/buf/private/bufpkg/bufimage"
 	"github.com/bufbuild/buf/private/pkg/protosource"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/protocompile/options"
+	"github.com/bufbuild/protocompile/walk"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -40,6 +42,10 @@ var (
 	// ErrImageFilterTypeIsImport is returned from ImageFilteredByTypes when
 	// a specified type name is declared in a module dependency.
 	ErrImageFilterTypeIsImport = errors.New("type declared in imported module")
+
+	// ErrImageFilterNoExtensions is returned from ImageFilteredByExtensions when
+	// an image does not contain any extensions.
+	ErrImageFilterNoExtensions = errors.New("image contains no extensions")
 )
 
 // NewInputFiles converts the ImageFiles to InputFiles.
@@ -110,6 +116,15 @@ func WithAllowFilterByImportedType() ImageFilterOption {
 	}
 }
 
+// withIncludeFilePaths returns an option for ImageFilteredByTypesWithOptions that
+// includes the files at the paths in the filtered image, even if they contain no
+// element of the closure of the types.
+func withIncludeFilePaths(includeFilePaths map[string]struct{}) ImageFilterOption {
+	return func(opts *imageFilterOptions) {
+		opts.includeFilePaths = includeFilePaths
+	}
+}
+
 // ImageFilteredByTypes returns a minimal image containing only the descriptors
 // required to define those types. The resulting contains only files in which
 // those descriptors and their transitive closure of required descriptors, with
@@ -248,6 +263,11 @@ func ImageFilteredByTypesWithOptions(image bufimage.Image, types []string, opts
 	if err := closure.addExtensions(imageIndex, options); err != nil {
 		return nil, err
 	}
+	for path := range options.includeFilePaths {
+		if image.GetFile(path) != nil {
+			closure.files[path] = struct{}{}
+		}
+	}
 	// Create a new image with only the required descriptors.
 	var includedFiles []bufimage.ImageFile
 	for _, imageFile := range image.Files() {
@@ -373,6 +393,188 @@ func ImageFilteredByTypesWithOptions(image bufimage.Image, types []string, opts
 	return bufimage.NewImage(includedFiles)
 }
 
+// ImagesFilteredByTypes filters the image and the against image by the same types, so that
+// the two can be compared, for example for breaking change detection.
+//
+// Each type must be declared in at least one of the images. Types that are only declared in
+// one image, for example because they were added or deleted, are only used to filter that
+// image. The images are then filtered again by every element within the transitive closure
+// of the types in either image, so that an element that is present in both images is never
+// absent from one of the filtered images purely because it is only referenced on the other
+// side.
+//
+// The files of the filtered against image are kept in the filtered image if they exist in
+// the image, even if they no longer declare any of the elements, so that a type that was
+// deleted from a file is reported as deleted from that file. If none of the types are
+// declared in the image, and none of these files exist in the image, the filtered image
+// contains every file of the image that is not an import, without any elements.
+//
+// The returned against image is nil if none of the types are declared in the against image,
+// in which case there is nothing to compare against.
+//
+// Like ImageFilteredByTypes, filtering is destructive, and the given images may be modified.
+func ImagesFilteredByTypes(image bufimage.Image, againstImage bufimage.Image, types ...string) (bufimage.Image, bufimage.Image, error) {
+	options := newImageFilterOptions()
+	imageIndex, err := newImageIndexForImage(image, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	againstImageIndex, err := newImageIndexForImage(againstImage, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	var imageTypes []string
+	var againstImageTypes []string
+	for _, typeName := range types {
+		inImage := containsTypeOrPackage(imageIndex, typeName)
+		inAgainstImage := containsTypeOrPackage(againstImageIndex, typeName)
+		if !inImage && !inAgainstImage {
+			return nil, nil, fmt.Errorf("filtering by type %q: %w", typeName, ErrImageFilterTypeNotFound)
+		}
+		if inImage {
+			imageTypes = append(imageTypes, typeName)
+		}
+		if inAgainstImage {
+			againstImageTypes = append(againstImageTypes, typeName)
+		}
+	}
+	if len(againstImageTypes) == 0 {
+		filteredImage, err := ImageFilteredByTypes(image, imageTypes...)
+		if err != nil {
+			return nil, nil, err
+		}
+		return filteredImage, nil, nil
+	}
+	// The first pass computes the transitive closure of the types within each image. The
+	// images are cloned as the second pass filters the original images.
+	closureNames := make(map[string]struct{})
+	for _, imageAndTypes := range []struct {
+		image bufimage.Image
+		types []string
+	}{
+		{image: image, types: imageTypes},
+		{image: againstImage, types: againstImageTypes},
+	} {
+		if len(imageAndTypes.types) == 0 {
+			continue
+		}
+		clonedImage, err := bufimage.CloneImage(imageAndTypes.image)
+		if err != nil {
+			return nil, nil, err
+		}
+		filteredImage, err := ImageFilteredByTypes(clonedImage, imageAndTypes.types...)
+		if err != nil {
+			return nil, nil, err
+		}
+		filteredImageIndex, err := newImageIndexForImage(filteredImage, options)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name := range filteredImageIndex.ByName {
+			closureNames[name] = struct{}{}
+		}
+	}
+	// The closure of one image may contain imported types of the other, so imported types
+	// are allowed in the second pass. The given types were validated by the first pass.
+	filteredAgainstImage, err := ImageFilteredByTypesWithOptions(
+		againstImage,
+		typesForClosure(againstImageIndex, againstImageTypes, closureNames),
+		WithAllowFilterByImportedType(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	includeFilePaths := make(map[string]struct{})
+	for _, againstImageFile := range filteredAgainstImage.Files() {
+		if image.GetFile(againstImageFile.Path()) != nil {
+			includeFilePaths[againstImageFile.Path()] = struct{}{}
+		}
+	}
+	imageClosureTypes := typesForClosure(imageIndex, imageTypes, closureNames)
+	if len(imageClosureTypes) == 0 && len(includeFilePaths) == 0 {
+		// Every file that declared the types was deleted. The files of the image are
+		// kept so that the filtered image is not empty, and the deletions are reported.
+		for _, imageFile := range image.Files() {
+			if !imageFile.IsImport() {
+				includeFilePaths[imageFile.Path()] = struct{}{}
+			}
+		}
+	}
+	filteredImage, err := ImageFilteredByTypesWithOptions(
+		image,
+		imageClosureTypes,
+		WithAllowFilterByImportedType(),
+		withIncludeFilePaths(includeFilePaths),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filteredImage, filteredAgainstImage, nil
+}
+
+// ImageFilteredByExtensions returns a minimal image containing only the extensions
+// in the image, including custom options, and the descriptors required to define them.
+//
+// The extensions of both the non-import and import files are included. The result can
+// be used as an extension registry, so that systems can resolve extensions without
+// the full image. See ImageFilteredByTypes for what is required to define an extension.
+//
+// Returns ErrImageFilterNoExtensions if the image contains no extensions.
+func ImageFilteredByExtensions(image bufimage.Image) (bufimage.Image, error) {
+	var extensionNames []string
+	for _, imageFile := range image.Files() {
+		if err := walk.DescriptorProtos(imageFile.FileDescriptorProto(), func(name protoreflect.FullName, message proto.Message) error {
+			if field, ok := message.(*descriptorpb.FieldDescriptorProto); ok && field.Extendee != nil {
+				extensionNames = append(extensionNames, string(name))
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if len(extensionNames) == 0 {
+		return nil, ErrImageFilterNoExtensions
+	}
+	return ImageFilteredByTypesWithOptions(image, extensionNames, WithAllowFilterByImportedType())
+}
+
+// ImageMissingTypes returns the types that are not declared in the image, in the
+// order given.
+//
+// Types are fully-qualified names of messages, enums, extensions, services, methods,
+// or any other element that can be passed to ImageFilteredByTypes, except packages.
+// Returns an empty slice if all types are declared in the image.
+func ImageMissingTypes(image bufimage.Image, types []string) ([]string, error) {
+	imageIndex, err := newImageIndexForImage(image, newImageFilterOptions())
+	if err != nil {
+		return nil, err
+	}
+	var missingTypes []string
+	for _, typeName := range types {
+		if _, ok := imageIndex.ByName[typeName]; !ok {
+			missingTypes = append(missingTypes, typeName)
+		}
+	}
+	return missingTypes, nil
+}
+
+// ImageDeclaredTypes returns the types that are declared in the image, in the order given.
+//
+// Unlike ImageMissingTypes, types may also be packages, as with ImageFilteredByTypes.
+func ImageDeclaredTypes(image bufimage.Image, types []string) ([]string, error) {
+	imageIndex, err := newImageIndexForImage(image, newImageFilterOptions())
+	if err != nil {
+		return nil, err
+	}
+	var declaredTypes []string
+	for _, typeName := range types {
+		if containsTypeOrPackage(imageIndex, typeName) {
+			declaredTypes = append(declaredTypes, typeName)
+		}
+	}
+	return declaredTypes, nil
+}
+
 // StripSourceRetentionOptions strips any options with a retention of "source" from
 // the descriptors in the given image. The image is not mutated but instead a new
 // image is returned. The returned image may share state with the original.
@@ -388,6 +590,32 @@ func StripSourceRetentionOptions(image bufimage.Image) (bufimage.Image, error) {
 	return bufimage.NewImage(updatedFiles)
 }
 
+// ImageAnonymized returns a copy of the image with all comments stripped and all names
+// replaced with opaque names, so that the image can be shared to reproduce an issue
+// without revealing the schema it was built from.
+//
+// Names are replaced consistently, so the structure of the image is preserved: all
+// references between types and files are updated, and the field numbers and types are
+// unchanged. Version components of packages, such as v1, and the well-known types are kept.
+// String and bytes default values and reserved names are cleared, and the module identity,
+// commit, and external path of each file are dropped.
+//
+// Options derived from names, such as go_package, custom options, and unknown fields of
+// options are removed, as they may contain anything. Other options are unchanged.
+func ImageAnonymized(image bufimage.Image) (bufimage.Image, error) {
+	return newAnonymizer().anonymizeImage(image)
+}
+
+// ImageWithSourceCodeInfoOnlyForPaths returns a copy of the image with source code
+// info stripped from all files whose paths do not match any of the glob patterns.
+//
+// Patterns are matched with path.Match against the path of each file and each of its
+// parent directories, so acme/* keeps source code info for all files within the acme
+// directory. Files whose source code info is kept are shared with the original image.
+func ImageWithSourceCodeInfoOnlyForPaths(image bufimage.Image, patterns []string) (bufimage.Image, error) {
+	return imageWithSourceCodeInfoOnlyForPaths(image, patterns)
+}
+
 // trimMessageDescriptors removes (nested) messages and nested enums from a slice
 // of message descriptors if their type names are not found in the toKeep map.
 func trimMessageDescriptors(
@@ -661,6 +889,30 @@ func (t *transitiveClosure) addElement(
 	return nil
 }
 
+// containsTypeOrPackage returns true if the type or package is declared in the image.
+func containsTypeOrPackage(imageIndex *imageIndex, typeName string) bool {
+	if _, ok := imageIndex.ByName[typeName]; ok {
+		return true
+	}
+	_, ok := imageIndex.Packages[typeName]
+	return ok
+}
+
+// typesForClosure returns the given types, along with every name within the closure
+// that is declared in the image, sorted.
+func typesForClosure(imageIndex *imageIndex, types []string, closureNames map[string]struct{}) []string {
+	typeNames := make(map[string]struct{}, len(types)+len(closureNames))
+	for _, typeName := range types {
+		typeNames[typeName] = struct{}{}
+	}
+	for name := range closureNames {
+		if _, ok := imageIndex.ByName[name]; ok {
+			typeNames[name] = struct{}{}
+		}
+	}
+	return slicesext.MapKeysToSortedSlice(typeNames)
+}
+
 func errorUnsupportedFilterType(descriptor namedDescriptor, fullName string) error {
 	var descriptorType string
 	switch d := descriptor.(type) {
@@ -924,6 +1176,9 @@ type imageFilterOptions struct {
 	includeCustomOptions   bool
 	includeKnownExtensions bool
 	allowImportedTypes     bool
+	// includeFilePaths are the paths of the files that are included in the filtered
+	// image even if they contain no element of the closure.
+	includeFilePaths map[string]struct{}
 }
 
 func newImageFilterOptions() *imageFilterOptions {
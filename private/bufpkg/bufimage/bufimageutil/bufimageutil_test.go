@@ -27,6 +27,7 @@ import (
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduletesting"
+	"github.com/bufbuild/buf/private/bufpkg/bufreflect"
 	"github.com/bufbuild/buf/private/pkg/protoencoding"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
@@ -130,6 +131,33 @@ func TestExtensions(t *testing.T) {
 	runDiffTest(t, "testdata/extensions", []string{"pkg.Foo"}, "extensions-excluded.txtar", WithExcludeKnownExtensions())
 }
 
+func TestImageFilteredByExtensions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, image, err := getImage(ctx, zaptest.NewLogger(t), "testdata/extensions", bufimagebuild.WithExcludeSourceCodeInfo())
+	require.NoError(t, err)
+	filteredImage, err := ImageFilteredByExtensions(image)
+	require.NoError(t, err)
+	data, err := protoencoding.NewWireMarshaler().Marshal(bufimage.ImageToFileDescriptorSet(filteredImage))
+	require.NoError(t, err)
+	extensionTypes, err := bufreflect.NewExtensionTypes(data)
+	require.NoError(t, err)
+	assert.Equal(t, 3, extensionTypes.NumExtensions())
+	for _, extensionName := range []protoreflect.FullName{
+		"pkg.ext",
+		"other.Embedded.from_other_file",
+		"other.from_other_file",
+	} {
+		_, err := extensionTypes.FindExtensionByName(extensionName)
+		assert.NoError(t, err, extensionName)
+	}
+
+	_, image, err = getImage(ctx, zaptest.NewLogger(t), "testdata/nesting", bufimagebuild.WithExcludeSourceCodeInfo())
+	require.NoError(t, err)
+	_, err = ImageFilteredByExtensions(image)
+	assert.ErrorIs(t, err, ErrImageFilterNoExtensions)
+}
+
 func TestPackages(t *testing.T) {
 	t.Parallel()
 	runDiffTest(t, "testdata/packages", []string{""}, "root.txtar")
@@ -191,6 +219,111 @@ func TestTransitivePublic(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestImageAnonymized(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bucket, err := storagemem.NewReadBucket(map[string][]byte{
+		"acme/secret/v1/a.proto": []byte(`syntax = "proto3";package acme.secret.v1;message Secret{enum Kind{KIND_UNSPECIFIED=0;}Kind kind=1;map<string,Secret> children=2;optional string label=3;}`),
+		"acme/secret/v1/b.proto": []byte(`syntax = "proto3";package acme.secret.v1;import "acme/secret/v1/a.proto";import "google/protobuf/timestamp.proto";option go_package="acme.com/secret";service SecretService{rpc GetSecret(Secret) returns (google.protobuf.Timestamp);}`),
+		"acme/secret/v1/c.proto": []byte(`syntax = "proto2";package acme.secret.v1;import "google/protobuf/descriptor.proto";extend google.protobuf.MessageOptions{optional string tag=50000;}extend google.protobuf.FieldOptions{optional string field_tag=50001;}message Config{option (tag)="hunter2";optional bytes key=1[default="password",(field_tag)="swordfish"];optional string name=2[default="classified"];}`),
+	})
+	require.NoError(t, err)
+	module, err := bufmodule.NewModuleForBucket(ctx, bucket)
+	require.NoError(t, err)
+	image, analysis, err := bufimagebuild.NewBuilder(
+		zaptest.NewLogger(t),
+		bufmodule.NewNopModuleReader(),
+	).Build(
+		ctx,
+		module,
+	)
+	require.NoError(t, err)
+	require.Empty(t, analysis)
+
+	anonymizedImage, err := ImageAnonymized(image)
+	require.NoError(t, err)
+	_, err = desc.CreateFileDescriptorsFromSet(bufimage.ImageToFileDescriptorSet(anonymizedImage))
+	require.NoError(t, err)
+	fileDescriptorProto := anonymizedImage.GetFile("pkg1/pkg2/v1/file1.proto").FileDescriptorProto()
+	assert.Equal(t, "pkg1.pkg2.v1", fileDescriptorProto.GetPackage())
+	assert.Nil(t, fileDescriptorProto.GetSourceCodeInfo())
+	messageDescriptorProto := fileDescriptorProto.GetMessageType()[0]
+	assert.Equal(t, "Message1", messageDescriptorProto.GetName())
+	assert.Equal(t, "Field2Entry", messageDescriptorProto.GetNestedType()[0].GetName())
+	assert.Equal(t, "ENUM1_VALUE_0", messageDescriptorProto.GetEnumType()[0].GetValue()[0].GetName())
+	assert.Equal(t, "_field_3", messageDescriptorProto.GetOneofDecl()[0].GetName())
+	fileDescriptorProto = anonymizedImage.GetFile("pkg1/pkg2/v1/file2.proto").FileDescriptorProto()
+	assert.Equal(t, []string{"pkg1/pkg2/v1/file1.proto", "google/protobuf/timestamp.proto"}, fileDescriptorProto.GetDependency())
+	assert.Equal(t, ".pkg1.pkg2.v1.Message1", fileDescriptorProto.GetService()[0].GetMethod()[0].GetInputType())
+	assert.Equal(t, ".google.protobuf.Timestamp", fileDescriptorProto.GetService()[0].GetMethod()[0].GetOutputType())
+	assert.Empty(t, fileDescriptorProto.GetOptions().GetGoPackage())
+	for _, imageFile := range anonymizedImage.Files() {
+		if imageFile.IsImport() {
+			continue
+		}
+		data, err := protoencoding.NewWireMarshaler().Marshal(imageFile.FileDescriptorProto())
+		require.NoError(t, err)
+		for _, name := range []string{"acme", "secret", "Secret", "Kind", "children", "label", "hunter2", "password", "swordfish", "classified"} {
+			assert.NotContains(t, string(data), name)
+		}
+	}
+}
+
+func TestImageWithSourceCodeInfoOnlyForPaths(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bucket, err := storagemem.NewReadBucket(map[string][]byte{
+		"acme/v1/a.proto": []byte(`syntax = "proto3";package acme.v1;// A.
+message A{}`),
+		"other/v1/b.proto": []byte(`syntax = "proto3";package other.v1;import "acme/v1/a.proto";// B.
+message B{acme.v1.A a=1;}`),
+	})
+	require.NoError(t, err)
+	module, err := bufmodule.NewModuleForBucket(ctx, bucket)
+	require.NoError(t, err)
+	image, analysis, err := bufimagebuild.NewBuilder(
+		zaptest.NewLogger(t),
+		bufmodule.NewNopModuleReader(),
+	).Build(
+		ctx,
+		module,
+	)
+	require.NoError(t, err)
+	require.Empty(t, analysis)
+
+	prunedImage, err := ImageWithSourceCodeInfoOnlyForPaths(image, []string{"acme/*"})
+	require.NoError(t, err)
+	assert.NotNil(t, prunedImage.GetFile("acme/v1/a.proto").FileDescriptorProto().GetSourceCodeInfo())
+	assert.Nil(t, prunedImage.GetFile("other/v1/b.proto").FileDescriptorProto().GetSourceCodeInfo())
+	// The original image is not modified.
+	assert.NotNil(t, image.GetFile("other/v1/b.proto").FileDescriptorProto().GetSourceCodeInfo())
+
+	prunedImage, err = ImageWithSourceCodeInfoOnlyForPaths(image, []string{"other/v1/b.proto"})
+	require.NoError(t, err)
+	assert.Nil(t, prunedImage.GetFile("acme/v1/a.proto").FileDescriptorProto().GetSourceCodeInfo())
+	assert.NotNil(t, prunedImage.GetFile("other/v1/b.proto").FileDescriptorProto().GetSourceCodeInfo())
+
+	_, err = ImageWithSourceCodeInfoOnlyForPaths(image, []string{"acme/["})
+	require.Error(t, err)
+}
+
+func TestImageMissingTypes(t *testing.T) {
+	t.Parallel()
+	_, image, err := getImage(context.Background(), zaptest.NewLogger(t), "testdata/nesting", bufimagebuild.WithExcludeSourceCodeInfo())
+	require.NoError(t, err)
+	missingTypes, err := ImageMissingTypes(image, []string{"pkg.Foo", "pkg.Foo.NestedFoo", "pkg.Missing", "pkg.Foo.Missing"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg.Missing", "pkg.Foo.Missing"}, missingTypes)
+	filteredImage, err := ImageFilteredByTypes(image, "pkg.Foo")
+	require.NoError(t, err)
+	missingTypes, err = ImageMissingTypes(filteredImage, []string{"pkg.Foo", "pkg.Bar"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg.Bar"}, missingTypes)
+	declaredTypes, err := ImageDeclaredTypes(filteredImage, []string{"pkg.Missing", "pkg", "pkg.Foo", "pkg.Bar"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg", "pkg.Foo"}, declaredTypes)
+}
+
 func TestTypesFromMainModule(t *testing.T) {
 	t.Parallel()
 
@@ -240,6 +373,107 @@ func TestTypesFromMainModule(t *testing.T) {
 	assert.ErrorIs(t, err, ErrImageFilterTypeNotFound)
 }
 
+func TestImagesFilteredByTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	newImage := func() bufimage.Image {
+		return getImageForFiles(
+			ctx,
+			t,
+			map[string]string{
+				// Baz is no longer referenced by Foo, but is still declared.
+				"a.proto": `syntax = "proto3";package pkg;message Foo { Bar bar = 1; reserved 2; } message Bar {} message Baz {} message New {}`,
+			},
+		)
+	}
+	newAgainstImage := func() bufimage.Image {
+		return getImageForFiles(
+			ctx,
+			t,
+			map[string]string{
+				"a.proto": `syntax = "proto3";package pkg;message Foo { Bar bar = 1; Baz baz = 2; } message Bar {} message Baz {} message Other {}`,
+			},
+		)
+	}
+
+	filteredImage, filteredAgainstImage, err := ImagesFilteredByTypes(newImage(), newAgainstImage(), "pkg.Foo", "pkg.New")
+	require.NoError(t, err)
+	require.NotNil(t, filteredAgainstImage)
+	// Baz is within the closure of Foo in the against image, so it is in both filtered images.
+	missingTypes, err := ImageMissingTypes(filteredImage, []string{"pkg.Foo", "pkg.Bar", "pkg.Baz", "pkg.New", "pkg.Other"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg.Other"}, missingTypes)
+	missingTypes, err = ImageMissingTypes(filteredAgainstImage, []string{"pkg.Foo", "pkg.Bar", "pkg.Baz", "pkg.New", "pkg.Other"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg.New", "pkg.Other"}, missingTypes)
+
+	filteredImage, filteredAgainstImage, err = ImagesFilteredByTypes(newImage(), newAgainstImage(), "pkg.New")
+	require.NoError(t, err)
+	assert.Nil(t, filteredAgainstImage)
+	missingTypes, err = ImageMissingTypes(filteredImage, []string{"pkg.New", "pkg.Foo"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pkg.Foo"}, missingTypes)
+
+	// Other was deleted, so the filtered image contains the file it was declared in,
+	// without Other.
+	filteredImage, filteredAgainstImage, err = ImagesFilteredByTypes(newImage(), newAgainstImage(), "pkg.Other")
+	require.NoError(t, err)
+	require.NotNil(t, filteredAgainstImage)
+	require.NotNil(t, filteredImage.GetFile("a.proto"))
+	assert.Empty(t, filteredImage.GetFile("a.proto").FileDescriptorProto().GetMessageType())
+	missingTypes, err = ImageMissingTypes(filteredAgainstImage, []string{"pkg.Other"})
+	require.NoError(t, err)
+	assert.Empty(t, missingTypes)
+
+	// The file that declared Other was deleted, so the filtered image contains the other
+	// files of the image, without any elements.
+	filteredImage, filteredAgainstImage, err = ImagesFilteredByTypes(
+		getImageForFiles(
+			ctx,
+			t,
+			map[string]string{
+				"a.proto": `syntax = "proto3";package pkg;message Foo {}`,
+			},
+		),
+		getImageForFiles(
+			ctx,
+			t,
+			map[string]string{
+				"a.proto": `syntax = "proto3";package pkg;message Foo {}`,
+				"b.proto": `syntax = "proto3";package pkg;message Other {}`,
+			},
+		),
+		"pkg.Other",
+	)
+	require.NoError(t, err)
+	require.NotNil(t, filteredAgainstImage)
+	assert.Len(t, filteredImage.Files(), 1)
+	assert.Empty(t, filteredImage.GetFile("a.proto").FileDescriptorProto().GetMessageType())
+	require.NotNil(t, filteredAgainstImage.GetFile("b.proto"))
+
+	_, _, err = ImagesFilteredByTypes(newImage(), newAgainstImage(), "pkg.Missing")
+	assert.ErrorIs(t, err, ErrImageFilterTypeNotFound)
+}
+
+func getImageForFiles(ctx context.Context, t *testing.T, pathToData map[string]string) bufimage.Image {
+	pathToBytes := make(map[string][]byte, len(pathToData))
+	for path, data := range pathToData {
+		pathToBytes[path] = []byte(data)
+	}
+	bucket, err := storagemem.NewReadBucket(pathToBytes)
+	require.NoError(t, err)
+	module, err := bufmodule.NewModuleForBucket(ctx, bucket)
+	require.NoError(t, err)
+	image, analysis, err := bufimagebuild.NewBuilder(zaptest.NewLogger(t), bufmodule.NewNopModuleReader()).Build(
+		ctx,
+		module,
+		bufimagebuild.WithExcludeSourceCodeInfo(),
+	)
+	require.NoError(t, err)
+	require.Empty(t, analysis)
+	return image
+}
+
 func getImage(ctx context.Context, logger *zap.Logger, testdataDir string, options ...bufimagebuild.BuildOption) (storage.ReadWriteBucket, bufimage.Image, error) {
 	bucket, err := storageos.NewProvider().NewReadWriteBucket(testdataDir)
 	if err != nil {
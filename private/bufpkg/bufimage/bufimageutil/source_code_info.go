@@ -0,0 +1,89 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufimageutil
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newSourceCodeInfoPathMatcher returns a function that reports whether a file path
+// is matched by any of the glob patterns.
+//
+// A pattern matches a path if it matches the path itself or any of its parent
+// directories, so that acme/* matches both acme/a.proto and acme/v1/a.proto.
+func newSourceCodeInfoPathMatcher(patterns []string) (func(string) bool, error) {
+	normalizedPatterns := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		normalizedPattern, err := normalpath.NormalizeAndValidate(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := path.Match(normalizedPattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		normalizedPatterns[i] = normalizedPattern
+	}
+	return func(filePath string) bool {
+		for _, pattern := range normalizedPatterns {
+			for currentPath := filePath; currentPath != "."; currentPath = normalpath.Dir(currentPath) {
+				// The pattern was validated above, so Match cannot return an error.
+				if matched, _ := path.Match(pattern, currentPath); matched {
+					return true
+				}
+			}
+		}
+		return false
+	}, nil
+}
+
+func imageWithSourceCodeInfoOnlyForPaths(image bufimage.Image, patterns []string) (bufimage.Image, error) {
+	matches, err := newSourceCodeInfoPathMatcher(patterns)
+	if err != nil {
+		return nil, err
+	}
+	imageFiles := image.Files()
+	newImageFiles := make([]bufimage.ImageFile, len(imageFiles))
+	for i, imageFile := range imageFiles {
+		if matches(imageFile.Path()) || imageFile.FileDescriptorProto().GetSourceCodeInfo() == nil {
+			newImageFiles[i] = imageFile
+			continue
+		}
+		fileDescriptorProto, ok := proto.Clone(imageFile.FileDescriptorProto()).(*descriptorpb.FileDescriptorProto)
+		if !ok {
+			return nil, fmt.Errorf("could not clone %q", imageFile.Path())
+		}
+		fileDescriptorProto.SourceCodeInfo = nil
+		newImageFile, err := bufimage.NewImageFile(
+			fileDescriptorProto,
+			imageFile.ModuleIdentity(),
+			imageFile.Commit(),
+			imageFile.ExternalPath(),
+			imageFile.IsImport(),
+			imageFile.IsSyntaxUnspecified(),
+			imageFile.UnusedDependencyIndexes(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		newImageFiles[i] = newImageFile
+	}
+	return bufimage.NewImage(newImageFiles)
+}
@@ -259,3 +259,57 @@ func TestCloneImage(t *testing.T) {
 		}
 	}
 }
+
+func TestExplainImport(t *testing.T) {
+	t.Parallel()
+	protoImage := &imagev1.Image{
+		File: []*imagev1.ImageFile{
+			{
+				Syntax: proto.String("proto3"),
+				Name:   proto.String("e.proto"),
+			},
+			{
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("d.proto"),
+				Dependency: []string{"e.proto"},
+			},
+			{
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("c.proto"),
+				Dependency: []string{"d.proto"},
+			},
+			{
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("b.proto"),
+				Dependency: []string{"d.proto"},
+			},
+			{
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("a.proto"),
+				Dependency: []string{"b.proto", "c.proto", "e.proto"},
+			},
+			{
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("f.proto"),
+				Dependency: []string{"c.proto"},
+			},
+		},
+	}
+	image, err := NewImageForProto(protoImage)
+	require.NoError(t, err)
+
+	chain, err := ExplainImport(image, "a.proto", "e.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.proto", "e.proto"}, chain)
+	chain, err = ExplainImport(image, "a.proto", "d.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.proto", "b.proto", "d.proto"}, chain)
+	chain, err = ExplainImport(image, "f.proto", "e.proto")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f.proto", "c.proto", "d.proto", "e.proto"}, chain)
+	chain, err = ExplainImport(image, "e.proto", "a.proto")
+	require.NoError(t, err)
+	assert.Nil(t, chain)
+	_, err = ExplainImport(image, "a.proto", "g.proto")
+	assert.Error(t, err)
+}
@@ -275,6 +275,48 @@ func addFileWithImports(
 	return accumulator
 }
 
+func explainImport(image Image, fromPath string, toPath string) ([]string, error) {
+	for _, path := range []string{fromPath, toPath} {
+		if image.GetFile(path) == nil {
+			return nil, fmt.Errorf("%s is not in the image", path)
+		}
+	}
+	// Breadth-first search, so that the first chain found is the shortest.
+	//
+	// path -> the path that the path was first found to be imported by
+	importedBy := map[string]string{
+		fromPath: "",
+	}
+	queue := []string{fromPath}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if path == toPath {
+			var chain []string
+			for ; path != ""; path = importedBy[path] {
+				chain = append(chain, path)
+			}
+			for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+				chain[i], chain[j] = chain[j], chain[i]
+			}
+			return chain, nil
+		}
+		imageFile := image.GetFile(path)
+		if imageFile == nil {
+			// Images are self-contained, but be defensive.
+			continue
+		}
+		for _, dependency := range imageFile.FileDescriptorProto().GetDependency() {
+			if _, ok := importedBy[dependency]; ok {
+				continue
+			}
+			importedBy[dependency] = path
+			queue = append(queue, dependency)
+		}
+	}
+	return nil, nil
+}
+
 func checkExcludePathsExistInImage(image Image, excludeFileOrDirPaths []string) error {
 	for _, excludeFileOrDirPath := range excludeFileOrDirPaths {
 		var foundPath bool
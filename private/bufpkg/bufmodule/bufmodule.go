@@ -20,6 +20,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking/bufbreakingconfig"
@@ -99,6 +100,13 @@ type Module interface {
 	//
 	// Returns fs.ErrNotExist error if the file does not exist.
 	GetModuleFile(ctx context.Context, path string) (ModuleFile, error)
+	// ExcludedPathReason returns an explanation of why the given path is not part of the
+	// module, if the path exists in the module's source but was excluded by the module's
+	// configuration, for example via build.excludes.
+	//
+	// Returns empty if the path was not excluded by configuration, or if the Module was
+	// not constructed with ModuleWithExcludedPathReasonFunc.
+	ExcludedPathReason(ctx context.Context, path string) (string, error)
 	// DeclaredDirectDependencies returns the direct dependencies declared in the configuration file.
 	//
 	// The returned ModuleReferences are sorted by remote, owner, repository, and reference (if
@@ -165,6 +173,11 @@ type Module interface {
 	// the CLI to have Workspaces as a first-class citizen, where the typical case is a Workspace with
 	// a single Module, we will no longer need to do this type of check, and this can be removed.
 	WorkspaceDirectory() string
+	// Patch returns the local patch applied to the Module, if the Module was
+	// constructed with NewPatchedModule.
+	//
+	// This will be nil for all other Modules.
+	Patch() *ModulePatch
 
 	getSourceReadBucket() storage.ReadBucket
 	isModule()
@@ -200,6 +213,18 @@ func ModuleWithWorkspaceDirectory(workspaceDirectory string) ModuleOption {
 	}
 }
 
+// ModuleWithExcludedPathReasonFunc returns a new ModuleOption that sets the function used
+// to explain why a path was excluded from the Module by its configuration.
+//
+// See the comment on Module.ExcludedPathReason() for more details.
+func ModuleWithExcludedPathReasonFunc(
+	excludedPathReasonFunc func(ctx context.Context, path string) (string, error),
+) ModuleOption {
+	return func(module *module) {
+		module.excludedPathReasonFunc = excludedPathReasonFunc
+	}
+}
+
 // NewModuleForBucket returns a new Module. It attempts to read dependencies
 // from a lock file in the read bucket.
 func NewModuleForBucket(
@@ -309,6 +334,75 @@ func NewNopModuleReader() ModuleReader {
 	return newNopModuleReader()
 }
 
+// PartialModuleReader is a ModuleReader that can also read part of a resolved module.
+type PartialModuleReader interface {
+	ModuleReader
+	// GetPartialModule gets a Module for the ModulePin that only contains the .proto files
+	// at the given paths, the .proto files within the same module that they transitively
+	// import, and the configuration files of the module.
+	//
+	// Paths must be paths to .proto files relative to the root of the module.
+	// Implementations may return the entire Module.
+	//
+	// Returns an error with fs.ErrNotExist if the Module or any of the paths do not exist.
+	GetPartialModule(ctx context.Context, modulePin bufmoduleref.ModulePin, paths []string) (Module, error)
+}
+
+// CommitInfo is information about a single commit of a module.
+type CommitInfo struct {
+	// Commit is the name of the commit.
+	Commit string
+	// CreateTime is the time the commit was created.
+	CreateTime time.Time
+	// Digest is the manifest digest of the commit.
+	Digest string
+	// Labels are the labels that currently point to the commit, such as tags.
+	Labels []string
+	// Author is the username of the user who authored the commit.
+	Author string
+	// Branch is the name of the branch the commit was pushed to, if any.
+	Branch string
+	// DraftName is the name of the draft the commit belongs to, if the commit is a draft.
+	DraftName string
+	// GitCommitsCount is the number of git commits associated with the commit.
+	//
+	// This is greater than zero if the commit was created by syncing from a git repository.
+	GitCommitsCount int64
+}
+
+// CommitHistoryPage is a single page of commits returned by a CommitHistoryProvider.
+type CommitHistoryPage struct {
+	// CommitInfos are the commits within the page.
+	CommitInfos []CommitInfo
+	// NextPageToken is the token to pass to get the next page.
+	//
+	// Empty if there are no more results.
+	NextPageToken string
+}
+
+// CommitHistoryProvider provides the commit history of modules.
+type CommitHistoryProvider interface {
+	// GetCommitHistoryPage gets a page of the commit history for the ModuleReference.
+	//
+	// The history starts at the commit the reference resolves to and goes back in time,
+	// unless reverse is set. If the ModuleReference has no reference, the history of
+	// bufmoduleref.Main is returned.
+	//
+	// Returns an error with fs.ErrNotExist if the named Module or reference does not exist.
+	GetCommitHistoryPage(
+		ctx context.Context,
+		moduleReference bufmoduleref.ModuleReference,
+		pageSize uint32,
+		pageToken string,
+		reverse bool,
+	) (*CommitHistoryPage, error)
+}
+
+// NewNopCommitHistoryProvider returns a new CommitHistoryProvider that always returns a fs.ErrNotExist error.
+func NewNopCommitHistoryProvider() CommitHistoryProvider {
+	return newNopCommitHistoryProvider()
+}
+
 // ModuleFileSet is a Protobuf module file set.
 //
 // It contains the files for both targets, sources and dependencies.
@@ -316,6 +410,7 @@ func NewNopModuleReader() ModuleReader {
 // TODO: we should not have ModuleFileSet inherit from Module, this is confusing
 type ModuleFileSet interface {
 	// Note that GetModuleFile will pull from All files instead of just Source Files!
+	// Likewise, ExcludedPathReason explains paths excluded from any of the modules.
 	Module
 	// AllFileInfos gets all FileInfos associated with the module, including dependencies.
 	//
@@ -342,23 +437,87 @@ type Workspace interface {
 	GetModule(moduleIdentity bufmoduleref.ModuleIdentity) (Module, bool)
 	// GetModules returns all of the modules found in the workspace.
 	GetModules() []Module
+	// ListModules returns a page of at most pageSize of the modules found in the
+	// workspace, in the same order as GetModules, and the token for the next page.
+	//
+	// The first page is returned for an empty pageToken. The returned token is empty
+	// if there are no more pages. Page tokens are opaque, and only valid for the
+	// Workspace that returned them.
+	ListModules(pageToken string, pageSize int) ([]Module, string, error)
+	// WalkSourceFileInfos calls f for the SourceFileInfos of every module in the
+	// workspace, without reading all of them up front.
+	//
+	// Modules are walked in the same order as GetModules, and the FileInfos of each
+	// Module are walked sorted by path. Walking stops at the first error returned by f.
+	WalkSourceFileInfos(ctx context.Context, f func(Module, bufmoduleref.FileInfo) error) error
+	// ListSourceFileInfos returns a page of at most pageSize of the SourceFileInfos of
+	// all modules in the workspace, in the same order as WalkSourceFileInfos, and the
+	// token for the next page.
+	//
+	// The first page is returned for an empty pageToken. The returned token is empty
+	// if there are no more pages. Page tokens are opaque, and only valid for the
+	// Workspace that returned them.
+	ListSourceFileInfos(ctx context.Context, pageToken string, pageSize int) ([]bufmoduleref.FileInfo, string, error)
+	// GetModulePatch gets the local patch for the remote module identified by the
+	// given ModuleIdentity, if one was configured.
+	//
+	// The patch contains .proto files that are overlaid onto the remote module
+	// when it is read as a dependency, see NewPatchedModule.
+	GetModulePatch(moduleIdentity bufmoduleref.ModuleIdentity) (storage.ReadBucket, bool)
+}
+
+// WorkspaceOption is an option for a new Workspace.
+type WorkspaceOption func(*workspace)
+
+// WorkspaceWithModulePatches returns a new WorkspaceOption that sets the local patches
+// for remote modules, keyed by the IdentityString of the ModuleIdentity.
+func WorkspaceWithModulePatches(identityStringToPatchReadBucket map[string]storage.ReadBucket) WorkspaceOption {
+	return func(workspace *workspace) {
+		workspace.modulePatches = identityStringToPatchReadBucket
+	}
 }
 
 // NewWorkspace returns a new module workspace.
 //
 // The Context is not retained, and is only used for validation during construction.
+//
+// Returns an error listing every path that exists in more than one of the Modules.
 func NewWorkspace(
 	ctx context.Context,
 	namedModules map[string]Module,
 	allModules []Module,
+	options ...WorkspaceOption,
 ) (Workspace, error) {
 	return newWorkspace(
 		ctx,
 		namedModules,
 		allModules,
+		options...,
 	)
 }
 
+// ModulePatch records a local patch applied to a Module, see NewPatchedModule.
+type ModulePatch struct {
+	// Digest is the ModuleDigestB3 of the Module before the patch was applied.
+	Digest string
+	// PatchedDigest is the ModuleDigestB3 of the Module after the patch was applied.
+	PatchedDigest string
+	// PatchedPaths are the sorted paths of the .proto files in the patch.
+	PatchedPaths []string
+}
+
+// NewPatchedModule returns a new Module with the .proto files in patchReadBucket
+// overlaid onto the sources of the Module.
+//
+// Files in patchReadBucket replace the files of the Module with the same path, and
+// files that do not exist in the Module are added. The ModuleIdentity, commit,
+// dependencies, and configuration of the Module are kept, so the patched Module
+// still satisfies the pins of the Module. The digests of the Module before and
+// after the patch are recorded in the ModulePatch returned by Patch.
+func NewPatchedModule(ctx context.Context, module Module, patchReadBucket storage.ReadBucket) (Module, error) {
+	return newPatchedModule(ctx, module, patchReadBucket)
+}
+
 // ModuleToProtoModule converts the Module to a proto Module.
 //
 // This takes all Sources and puts them in the Module, not just Targets.
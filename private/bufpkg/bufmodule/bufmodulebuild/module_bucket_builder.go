@@ -17,7 +17,9 @@ package bufmodulebuild
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
+	"sort"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufconfig"
 	"github.com/bufbuild/buf/private/bufpkg/buflock"
@@ -26,6 +28,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"golang.org/x/exp/slices"
 )
 
 type moduleBucketBuilder struct {
@@ -62,20 +65,42 @@ func (b *moduleBucketBuilder) buildForBucket(
 	// proxy plain files
 	externalPaths := []string{
 		buflock.ExternalConfigFilePath,
-		bufmodule.LicenseFilePath,
+	}
+	if config.LicensePath == "" {
+		externalPaths = append(externalPaths, bufmodule.LicenseFilePath)
 	}
 	externalPaths = append(externalPaths, bufconfig.AllConfigFilePaths...)
-	rootBuckets := make([]storage.ReadBucket, 0, len(externalPaths)+1)
-	for _, docPath := range bufmodule.AllDocumentationPaths {
-		bucket, err := getFileReadBucket(ctx, readBucket, docPath)
+	rootBuckets := make([]storage.ReadBucket, 0, len(externalPaths)+2)
+	if config.DocumentationPath != "" {
+		// The documentation is served from the default path, as if the file was there.
+		bucket, err := getRequiredFileReadBucketAtPath(ctx, readBucket, config.DocumentationPath, bufmodule.DefaultDocumentationPath, "build.documentation_path")
 		if err != nil {
 			return nil, err
 		}
-		if bucket != nil {
-			rootBuckets = append(rootBuckets, bucket)
-			break
+		rootBuckets = append(rootBuckets, bucket)
+	} else {
+		for _, docPath := range bufmodule.AllDocumentationPaths {
+			bucket, err := getFileReadBucket(ctx, readBucket, docPath)
+			if err != nil {
+				return nil, err
+			}
+			if bucket != nil {
+				rootBuckets = append(rootBuckets, bucket)
+				break
+			}
+		}
+	}
+	if config.LicensePath != "" {
+		// The license is served from the default path, as if the file was there.
+		bucket, err := getRequiredFileReadBucketAtPath(ctx, readBucket, config.LicensePath, bufmodule.LicenseFilePath, "build.license_path")
+		if err != nil {
+			return nil, err
 		}
+		rootBuckets = append(rootBuckets, bucket)
 	}
+	// configFilePath is used to explain where excludes and roots came from,
+	// it is empty if the configuration was not read from a file in the bucket.
+	var configFilePath string
 	for _, path := range externalPaths {
 		bucket, err := getFileReadBucket(ctx, readBucket, path)
 		if err != nil {
@@ -83,6 +108,9 @@ func (b *moduleBucketBuilder) buildForBucket(
 		}
 		if bucket != nil {
 			rootBuckets = append(rootBuckets, bucket)
+			if configFilePath == "" && slices.Contains(bufconfig.AllConfigFilePaths, path) {
+				configFilePath = path
+			}
 		}
 	}
 
@@ -146,6 +174,13 @@ func (b *moduleBucketBuilder) buildForBucket(
 		bufmodule.ModuleWithWorkspaceDirectory(
 			buildOptions.workspaceDirectory,
 		),
+		bufmodule.ModuleWithExcludedPathReasonFunc(
+			newExcludedPathReasonFunc(
+				readBucket,
+				rootToExcludes,
+				configFilePath,
+			),
+		),
 	)
 	if err != nil {
 		return nil, err
@@ -189,3 +224,95 @@ func getFileReadBucket(
 		},
 	)
 }
+
+// getRequiredFileReadBucketAtPath returns a ReadBucket that contains the file at filePath
+// within readBucket at the path bucketPath.
+//
+// Returns an error if the file does not exist.
+func getRequiredFileReadBucketAtPath(
+	ctx context.Context,
+	readBucket storage.ReadBucket,
+	filePath string,
+	bucketPath string,
+	configKey string,
+) (storage.ReadBucket, error) {
+	fileData, err := storage.ReadPath(ctx, readBucket, filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%s: file %q does not exist", configKey, filePath)
+		}
+		return nil, err
+	}
+	return storagemem.NewReadBucket(
+		map[string][]byte{
+			bucketPath: fileData,
+		},
+	)
+}
+
+// newExcludedPathReasonFunc returns a function that explains why a root-relative path
+// does not exist in the module built from readBucket, if the path does exist within
+// readBucket but was excluded by build.excludes, or is only reachable by its path
+// relative to the module instead of its path relative to a root in build.roots.
+func newExcludedPathReasonFunc(
+	readBucket storage.ReadBucket,
+	rootToExcludes map[string][]string,
+	configFilePath string,
+) func(context.Context, string) (string, error) {
+	configSource := "the module configuration"
+	if configFilePath != "" {
+		configSource = configFilePath
+	}
+	roots := make([]string, 0, len(rootToExcludes))
+	for root := range rootToExcludes {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	return func(ctx context.Context, path string) (string, error) {
+		for _, root := range roots {
+			// Excludes are relative to the root they map to.
+			for _, exclude := range rootToExcludes[root] {
+				if !normalpath.ContainsPath(exclude, path, normalpath.Relative) {
+					continue
+				}
+				fullPath := normalpath.Join(root, path)
+				exists, err := storage.Exists(ctx, readBucket, fullPath)
+				if err != nil {
+					return "", err
+				}
+				if exists {
+					return fmt.Sprintf(
+						"%q is excluded by %q in the build.excludes key in %s",
+						fullPath,
+						normalpath.Join(root, exclude),
+						configSource,
+					), nil
+				}
+			}
+		}
+		for _, root := range roots {
+			if root == "." || !normalpath.ContainsPath(root, path, normalpath.Relative) {
+				continue
+			}
+			exists, err := storage.Exists(ctx, readBucket, path)
+			if err != nil {
+				return "", err
+			}
+			if !exists {
+				continue
+			}
+			relPath, err := normalpath.Rel(root, path)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf(
+				"%q is within root %q in the build.roots key in %s and must be imported as %q",
+				path,
+				root,
+				configSource,
+				relPath,
+			), nil
+		}
+		return "", nil
+	}
+}
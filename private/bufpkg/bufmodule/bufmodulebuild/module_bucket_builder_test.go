@@ -335,6 +335,51 @@ func TestLicense(t *testing.T) {
 	)
 }
 
+func TestDocumentationAndLicensePaths(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bucket, err := memBucket(ctx,
+		"buf.md", "default documentation",
+		"LICENSE", "default license",
+		"docs/PROTO_README.md", "documentation",
+		"legal/LICENSE.txt", "license",
+		"a/1.proto", "",
+	)
+	require.NoError(t, err)
+	config, err := bufmoduleconfig.NewConfigV1(
+		bufmoduleconfig.ExternalConfigV1{
+			DocumentationPath: "docs/PROTO_README.md",
+			LicensePath:       "legal/LICENSE.txt",
+		},
+	)
+	require.NoError(t, err)
+	module, err := NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		bucket,
+		config,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "documentation", module.Documentation())
+	assert.Equal(t, bufmodule.DefaultDocumentationPath, module.DocumentationPath())
+	assert.Equal(t, "license", module.License())
+	fileInfos, err := module.TargetFileInfos(ctx)
+	require.NoError(t, err)
+	assert.Len(t, fileInfos, 1)
+
+	config, err = bufmoduleconfig.NewConfigV1(
+		bufmoduleconfig.ExternalConfigV1{
+			DocumentationPath: "docs/README.md",
+		},
+	)
+	require.NoError(t, err)
+	_, err = NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		bucket,
+		config,
+	)
+	assert.EqualError(t, err, `build.documentation_path: file "docs/README.md" does not exist`)
+}
+
 func TestConfigInclusion(t *testing.T) {
 	t.Parallel()
 	t.Run("buf.yaml", func(t *testing.T) {
@@ -392,6 +437,95 @@ lint:
 	assert.NotEqual(t, zeroLint, module.LintConfig(), "empty LintConfig")
 }
 
+func TestExcludedPathReason(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bucket, err := memBucket(ctx,
+		"buf.yaml", "version: v1beta1\n",
+		"proto/a/1.proto", "",
+		"proto/b/1.proto", "",
+	)
+	require.NoError(t, err)
+	config, err := bufmoduleconfig.NewConfigV1Beta1(
+		bufmoduleconfig.ExternalConfigV1Beta1{
+			Roots: []string{
+				"proto",
+			},
+			Excludes: []string{
+				"proto/b",
+			},
+		},
+	)
+	require.NoError(t, err)
+	module, err := NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		bucket,
+		config,
+	)
+	require.NoError(t, err)
+	reason, err := module.ExcludedPathReason(ctx, "a/1.proto")
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+	reason, err = module.ExcludedPathReason(ctx, "b/1.proto")
+	require.NoError(t, err)
+	assert.Equal(t, `"proto/b/1.proto" is excluded by "proto/b" in the build.excludes key in buf.yaml`, reason)
+	reason, err = module.ExcludedPathReason(ctx, "proto/a/1.proto")
+	require.NoError(t, err)
+	assert.Equal(t, `"proto/a/1.proto" is within root "proto" in the build.roots key in buf.yaml and must be imported as "a/1.proto"`, reason)
+	reason, err = module.ExcludedPathReason(ctx, "c/1.proto")
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+}
+
+func TestExcludedPathReasonModuleFileSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	bucketA, err := memBucket(ctx,
+		"buf.yaml", "version: v1\n",
+		"a/1.proto", "",
+	)
+	require.NoError(t, err)
+	configA, err := bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{})
+	require.NoError(t, err)
+	moduleA, err := NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		bucketA,
+		configA,
+		WithWorkspaceDirectory("a"),
+	)
+	require.NoError(t, err)
+	bucketB, err := memBucket(ctx,
+		"buf.yaml", "version: v1\n",
+		"b/1.proto", "",
+		"b/internal/2.proto", "",
+	)
+	require.NoError(t, err)
+	configB, err := bufmoduleconfig.NewConfigV1(
+		bufmoduleconfig.ExternalConfigV1{
+			Excludes: []string{
+				"b/internal",
+			},
+		},
+	)
+	require.NoError(t, err)
+	moduleB, err := NewModuleBucketBuilder().BuildForBucket(
+		ctx,
+		bucketB,
+		configB,
+		WithWorkspaceDirectory("b"),
+	)
+	require.NoError(t, err)
+	// A file excluded from a dependency, such as another module of the workspace,
+	// is explained as well.
+	moduleFileSet := bufmodule.NewModuleFileSet(moduleA, []bufmodule.Module{moduleB})
+	reason, err := moduleFileSet.ExcludedPathReason(ctx, "b/internal/2.proto")
+	require.NoError(t, err)
+	assert.Equal(t, `"b/internal/2.proto" is excluded by "b/internal" in the build.excludes key in buf.yaml of the module in directory "b"`, reason)
+	reason, err = moduleFileSet.ExcludedPathReason(ctx, "c/1.proto")
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+}
+
 func memBucket(ctx context.Context, pathcontent ...string) (storage.ReadBucket, error) {
 	membucket := storagemem.NewReadWriteBucket()
 	for i := 0; i < len(pathcontent); i += 2 {
@@ -18,6 +18,8 @@ import (
 	"context"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/storage"
 	"go.uber.org/zap"
 )
 
@@ -105,7 +107,47 @@ func (m *moduleFileSetBuilder) build(
 		if err != nil {
 			return nil, err
 		}
+		if workspace != nil {
+			if patchReadBucket, ok := workspace.GetModulePatch(dependencyModulePin); ok {
+				dependencyModule, err = m.patchModule(ctx, dependencyModulePin, dependencyModule, patchReadBucket)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
 		dependencyModules = append(dependencyModules, dependencyModule)
 	}
 	return bufmodule.NewModuleFileSet(module, dependencyModules), nil
 }
+
+// patchModule overlays the local patch onto the dependency Module.
+//
+// The digests before and after patching are logged, as the patched Module no
+// longer matches the digest pinned in the buf.lock.
+func (m *moduleFileSetBuilder) patchModule(
+	ctx context.Context,
+	dependencyModulePin bufmoduleref.ModulePin,
+	dependencyModule bufmodule.Module,
+	patchReadBucket storage.ReadBucket,
+) (bufmodule.Module, error) {
+	patchedModule, err := bufmodule.NewPatchedModule(ctx, dependencyModule, patchReadBucket)
+	if err != nil {
+		return nil, err
+	}
+	modulePatch := patchedModule.Patch()
+	if modulePatch.PatchedDigest == modulePatch.Digest {
+		m.logger.Warn(
+			"local patch does not change dependency, it can be removed",
+			zap.String("module", dependencyModulePin.String()),
+		)
+		return dependencyModule, nil
+	}
+	m.logger.Warn(
+		"using local patch for dependency",
+		zap.String("module", dependencyModulePin.String()),
+		zap.String("digest", modulePatch.Digest),
+		zap.String("patched_digest", modulePatch.PatchedDigest),
+		zap.Strings("patched_paths", modulePatch.PatchedPaths),
+	)
+	return patchedModule, nil
+}
@@ -15,23 +15,144 @@
 package bufmodulecache
 
 import (
+	"context"
+
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/verbose"
 	"go.uber.org/zap"
 )
 
 // NewModuleReader creates a new module reader using content addressable storage.
+//
+// The locker is used to coordinate access to the cache across processes, so that
+// concurrent invocations do not download the same module twice or read partially
+// written cache entries. Lock files are created relative to the root of the locker,
+// which should not be within the bucket.
+//
+// If the delegate is a bufmodule.PartialModuleReader, partial modules that are not
+// in the cache are read from the delegate. The files of partial modules are cached
+// per commit, and are checked against the manifest of the module if it is cached.
 func NewModuleReader(
 	logger *zap.Logger,
 	verbosePrinter verbose.Printer,
+	progressReporter progress.Reporter,
 	bucket storage.ReadWriteBucket,
+	locker filelock.Locker,
 	delegate bufmodule.ModuleReader,
-) bufmodule.ModuleReader {
-	return newCASModuleReader(
+	options ...ModuleReaderOption,
+) bufmodule.PartialModuleReader {
+	moduleReader := newCASModuleReader(
 		bucket,
+		locker,
 		delegate,
 		logger,
 		verbosePrinter,
+		progressReporter,
 	)
+	for _, option := range options {
+		option(moduleReader)
+	}
+	return moduleReader
+}
+
+// ModuleReaderOption is an option for a new ModuleReader.
+type ModuleReaderOption func(*casModuleReader)
+
+// CacheStatsRecorder records cache hits and misses.
+type CacheStatsRecorder interface {
+	MarkHit()
+	MarkMiss()
+}
+
+// ModuleReaderWithCacheStatsRecorder returns a new ModuleReaderOption that records
+// cache hits and misses to the recorder, in addition to the statistics kept by the
+// ModuleReader itself.
+func ModuleReaderWithCacheStatsRecorder(recorder CacheStatsRecorder) ModuleReaderOption {
+	return func(moduleReader *casModuleReader) {
+		moduleReader.statsRecorder = recorder
+	}
+}
+
+// ModuleStats are the statistics for a single module within the cache.
+type ModuleStats struct {
+	// ModuleIdentity is the module identity in the form remote/owner/repository.
+	ModuleIdentity string `json:"module_identity"`
+	// CommitCount is the number of commits cached for the module.
+	CommitCount int `json:"commit_count"`
+	// BlobCount is the number of blobs cached for the module, including manifests.
+	BlobCount int `json:"blob_count"`
+	// SizeBytes is the total size of all cached commits and blobs for the module.
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// GetModuleStats returns the statistics for every module within the cache bucket,
+// sorted by size descending.
+//
+// The bucket should be the same bucket that was given to NewModuleReader. The sizes
+// of the objects are read from the file system, so the bucket must be a local bucket.
+func GetModuleStats(ctx context.Context, bucket storage.ReadBucket) ([]*ModuleStats, error) {
+	return getModuleStats(ctx, bucket)
+}
+
+// CommitExplanation explains the cache state of a single commit.
+type CommitExplanation struct {
+	// CommitCached is true if the commit is present in the cache.
+	CommitCached bool `json:"commit_cached"`
+	// CommitPath is the path of the commit within the cache. This is an
+	// external path if the commit is cached.
+	CommitPath string `json:"commit_path"`
+	// ManifestDigest is the digest of the manifest for the commit, if the commit is cached.
+	ManifestDigest string `json:"manifest_digest,omitempty"`
+	// ManifestCached is true if the manifest for the commit is present in the cache.
+	ManifestCached bool `json:"manifest_cached"`
+	// ManifestPath is the path of the manifest within the cache, if the commit is cached.
+	ManifestPath string `json:"manifest_path,omitempty"`
+	// FileCount is the number of files within the manifest, if the manifest is cached.
+	FileCount int `json:"file_count"`
+	// MissingFilePaths are the paths of files within the manifest that are not
+	// present in the cache.
+	MissingFilePaths []string `json:"missing_file_paths,omitempty"`
+}
+
+// Complete returns true if the commit, its manifest, and all of its files are cached.
+func (c *CommitExplanation) Complete() bool {
+	return c.CommitCached && c.ManifestCached && len(c.MissingFilePaths) == 0
+}
+
+// ExplainCommit explains whether the given commit of the module is cached within
+// the cache bucket, and where.
+//
+// The bucket and locker should be the same as were given to NewModuleReader. The
+// commit is read-locked while it is explained.
+func ExplainCommit(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	locker filelock.Locker,
+	moduleIdentity bufmoduleref.ModuleIdentity,
+	commit string,
+) (*CommitExplanation, error) {
+	return explainCommit(ctx, bucket, locker, moduleIdentity, commit)
+}
+
+// CacheIssue is a problem with an object within the cache found by CheckCache.
+type CacheIssue struct {
+	// Path is the external path of the object.
+	Path string `json:"path"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// CheckCache checks that every object within the cache bucket is where the layout of
+// the cache expects it and can be read, that every blob matches the digest it is stored
+// under, and that the manifest and files of every commit and partial module are cached.
+// The files of a partial module must also match the manifest of the commit, if cached.
+//
+// The bucket should be the same bucket that was given to NewModuleReader. The issues
+// are sorted by path. An error is only returned if the bucket cannot be walked.
+func CheckCache(ctx context.Context, bucket storage.ReadBucket) ([]*CacheIssue, error) {
+	return checkCache(ctx, bucket)
 }
@@ -0,0 +1,360 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmodulecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufcas"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/storage"
+	"github.com/bufbuild/buf/private/pkg/stringutil"
+	"go.uber.org/multierr"
+)
+
+func getModuleStats(ctx context.Context, bucket storage.ReadBucket) ([]*ModuleStats, error) {
+	moduleStatsMap := make(map[string]*ModuleStats)
+	if err := bucket.Walk(
+		ctx,
+		"",
+		func(objectInfo storage.ObjectInfo) error {
+			components := normalpath.Components(objectInfo.Path())
+			// {remote}/{owner}/{repository}/{blobs|commits|partials}/...
+			if len(components) < 5 {
+				return nil
+			}
+			moduleIdentity := normalpath.Join(components[0], components[1], components[2])
+			moduleStats, ok := moduleStatsMap[moduleIdentity]
+			if !ok {
+				moduleStats = &ModuleStats{
+					ModuleIdentity: moduleIdentity,
+				}
+				moduleStatsMap[moduleIdentity] = moduleStats
+			}
+			switch components[3] {
+			case blobsDir:
+				moduleStats.BlobCount++
+			case commitsDir:
+				moduleStats.CommitCount++
+			case partialsDir:
+				// Partial modules only count towards the size.
+			default:
+				return nil
+			}
+			size, err := objectSize(objectInfo)
+			if err != nil {
+				return err
+			}
+			moduleStats.SizeBytes += size
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	moduleStatsSlice := make([]*ModuleStats, 0, len(moduleStatsMap))
+	for _, moduleStats := range moduleStatsMap {
+		moduleStatsSlice = append(moduleStatsSlice, moduleStats)
+	}
+	sort.Slice(moduleStatsSlice, func(i int, j int) bool {
+		if moduleStatsSlice[i].SizeBytes != moduleStatsSlice[j].SizeBytes {
+			return moduleStatsSlice[i].SizeBytes > moduleStatsSlice[j].SizeBytes
+		}
+		return moduleStatsSlice[i].ModuleIdentity < moduleStatsSlice[j].ModuleIdentity
+	})
+	return moduleStatsSlice, nil
+}
+
+func explainCommit(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	locker filelock.Locker,
+	moduleIdentity bufmoduleref.ModuleIdentity,
+	commit string,
+) (_ *CommitExplanation, retErr error) {
+	moduleBasedir := normalpath.Join(moduleIdentity.Remote(), moduleIdentity.Owner(), moduleIdentity.Repository())
+	// The same lock as for reading the module from the cache, so that a commit
+	// that is being written is not reported as incomplete.
+	unlocker, err := locker.RLock(ctx, normalpath.Join(moduleBasedir, commit), filelock.LockWithTimeout(moduleLockTimeout))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, unlocker.Unlock())
+	}()
+	commitPath := normalpath.Join(moduleBasedir, commitsDir, commit)
+	commitExplanation := &CommitExplanation{
+		CommitPath: externalPath(ctx, bucket, commitPath),
+	}
+	digestBytes, err := storage.ReadPath(ctx, bucket, commitPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return commitExplanation, nil
+		}
+		return nil, err
+	}
+	commitExplanation.CommitCached = true
+	manifestDigest, err := bufcas.ParseDigest(string(digestBytes))
+	if err != nil {
+		return nil, err
+	}
+	commitExplanation.ManifestDigest = manifestDigest.String()
+	manifestPath := blobPath(moduleBasedir, manifestDigest)
+	commitExplanation.ManifestPath = externalPath(ctx, bucket, manifestPath)
+	manifestData, err := storage.ReadPath(ctx, bucket, manifestPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return commitExplanation, nil
+		}
+		return nil, err
+	}
+	commitExplanation.ManifestCached = true
+	manifestBlob, err := bufcas.NewBlobForContent(
+		bytes.NewReader(manifestData),
+		bufcas.BlobWithKnownDigest(manifestDigest),
+	)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := bufcas.BlobToManifest(manifestBlob)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileNode := range manifest.FileNodes() {
+		exists, err := storage.Exists(ctx, bucket, blobPath(moduleBasedir, fileNode.Digest()))
+		if err != nil {
+			return nil, err
+		}
+		commitExplanation.FileCount++
+		if !exists {
+			commitExplanation.MissingFilePaths = append(commitExplanation.MissingFilePaths, fileNode.Path())
+		}
+	}
+	return commitExplanation, nil
+}
+
+func checkCache(ctx context.Context, bucket storage.ReadBucket) ([]*CacheIssue, error) {
+	var cacheIssues []*CacheIssue
+	addCacheIssue := func(objectInfo storage.ObjectInfo, format string, args ...interface{}) {
+		cacheIssues = append(
+			cacheIssues,
+			&CacheIssue{
+				Path:    objectInfo.ExternalPath(),
+				Message: fmt.Sprintf(format, args...),
+			},
+		)
+	}
+	if err := bucket.Walk(
+		ctx,
+		"",
+		func(objectInfo storage.ObjectInfo) error {
+			components := normalpath.Components(objectInfo.Path())
+			// {remote}/{owner}/{repository}/{blobs|commits|partials}/...
+			if len(components) < 5 {
+				addCacheIssue(objectInfo, "unexpected file in the cache")
+				return nil
+			}
+			moduleBasedir := normalpath.Join(components[0], components[1], components[2])
+			switch components[3] {
+			case blobsDir:
+				// {moduleBasedir}/blobs/{first two characters of digest}/{rest of digest}
+				if len(components) != 6 {
+					addCacheIssue(objectInfo, "unexpected file in the blobs directory")
+					return nil
+				}
+				message, err := checkBlob(ctx, bucket, objectInfo.Path(), components[4]+components[5])
+				if err != nil {
+					return err
+				}
+				if message != "" {
+					addCacheIssue(objectInfo, message)
+				}
+			case commitsDir, partialsDir:
+				// {moduleBasedir}/{commits|partials}/{commit}
+				if len(components) != 5 {
+					addCacheIssue(objectInfo, "unexpected file in the %s directory", components[3])
+					return nil
+				}
+				message, err := checkCommit(ctx, bucket, moduleBasedir, objectInfo.Path(), components[3] == partialsDir)
+				if err != nil {
+					return err
+				}
+				if message != "" {
+					addCacheIssue(objectInfo, message)
+				}
+			default:
+				addCacheIssue(objectInfo, "unexpected file in the cache")
+			}
+			return nil
+		},
+	); err != nil {
+		return nil, err
+	}
+	sort.Slice(cacheIssues, func(i int, j int) bool {
+		return cacheIssues[i].Path < cacheIssues[j].Path
+	})
+	return cacheIssues, nil
+}
+
+// checkBlob returns a message describing the problem with the blob at the path,
+// or empty if the blob can be read and its content matches the digest.
+//
+// An error is only returned if the context is done.
+func checkBlob(ctx context.Context, bucket storage.ReadBucket, path string, digestHex string) (string, error) {
+	data, err := storage.ReadPath(ctx, bucket, path)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return fmt.Sprintf("blob could not be read: %v", err), nil
+	}
+	digest, err := bufcas.NewDigestForContent(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if actualDigestHex := hex.EncodeToString(digest.Value()); actualDigestHex != digestHex {
+		return fmt.Sprintf("blob content has digest %q, which does not match its path", actualDigestHex), nil
+	}
+	return "", nil
+}
+
+// checkCommit returns a message describing the problem with the commit or partial
+// module at the path, or empty if its manifest and all of the files of its manifest
+// are cached. The files of a partial module must also match the manifest of the
+// commit, if it is cached.
+//
+// An error is only returned if the context is done.
+func checkCommit(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	moduleBasedir string,
+	path string,
+	isPartial bool,
+) (string, error) {
+	manifest, message, err := readCachedManifest(ctx, bucket, moduleBasedir, path)
+	if err != nil || message != "" {
+		return message, err
+	}
+	var missingFilePaths []string
+	for _, fileNode := range manifest.FileNodes() {
+		exists, err := storage.Exists(ctx, bucket, blobPath(moduleBasedir, fileNode.Digest()))
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return "", ctxErr
+			}
+			return fmt.Sprintf("file %q could not be checked: %v", fileNode.Path(), err), nil
+		}
+		if !exists {
+			missingFilePaths = append(missingFilePaths, fileNode.Path())
+		}
+	}
+	if len(missingFilePaths) > 0 {
+		return fmt.Sprintf("files %s of the manifest are not cached", stringutil.SliceToHumanStringQuoted(missingFilePaths)), nil
+	}
+	if !isPartial {
+		return "", nil
+	}
+	commitPath := normalpath.Join(moduleBasedir, commitsDir, normalpath.Base(path))
+	commitManifest, commitMessage, err := readCachedManifest(ctx, bucket, moduleBasedir, commitPath)
+	if err != nil || commitMessage != "" {
+		// The commit is not cached, or its problem is reported for the commit itself.
+		return "", err
+	}
+	var mismatchedFilePaths []string
+	for _, fileNode := range manifest.FileNodes() {
+		if !bufcas.DigestEqual(commitManifest.GetDigest(fileNode.Path()), fileNode.Digest()) {
+			mismatchedFilePaths = append(mismatchedFilePaths, fileNode.Path())
+		}
+	}
+	if len(mismatchedFilePaths) > 0 {
+		return fmt.Sprintf("files %s do not match the manifest of the commit", stringutil.SliceToHumanStringQuoted(mismatchedFilePaths)), nil
+	}
+	return "", nil
+}
+
+// readCachedManifest reads the manifest whose digest is stored at the path.
+//
+// If the manifest cannot be read, a message describing the problem is returned
+// instead. An error is only returned if the context is done.
+func readCachedManifest(
+	ctx context.Context,
+	bucket storage.ReadBucket,
+	moduleBasedir string,
+	path string,
+) (bufcas.Manifest, string, error) {
+	digestBytes, err := storage.ReadPath(ctx, bucket, path)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, "", ctxErr
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "not cached", nil
+		}
+		return nil, fmt.Sprintf("could not be read: %v", err), nil
+	}
+	manifestDigest, err := bufcas.ParseDigest(string(digestBytes))
+	if err != nil {
+		return nil, fmt.Sprintf("does not contain a valid manifest digest: %v", err), nil
+	}
+	manifestData, err := storage.ReadPath(ctx, bucket, blobPath(moduleBasedir, manifestDigest))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, "", ctxErr
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Sprintf("manifest %q is not cached", manifestDigest.String()), nil
+		}
+		return nil, fmt.Sprintf("manifest %q could not be read: %v", manifestDigest.String(), err), nil
+	}
+	manifestBlob, err := bufcas.NewBlobForContent(
+		bytes.NewReader(manifestData),
+		bufcas.BlobWithKnownDigest(manifestDigest),
+	)
+	if err != nil {
+		return nil, fmt.Sprintf("manifest %q does not match its digest: %v", manifestDigest.String(), err), nil
+	}
+	manifest, err := bufcas.BlobToManifest(manifestBlob)
+	if err != nil {
+		return nil, fmt.Sprintf("manifest %q is invalid: %v", manifestDigest.String(), err), nil
+	}
+	return manifest, "", nil
+}
+
+// externalPath returns the external path of the path within the bucket, or the path
+// itself if it does not exist.
+func externalPath(ctx context.Context, bucket storage.ReadBucket, path string) string {
+	objectInfo, err := bucket.Stat(ctx, path)
+	if err != nil {
+		return path
+	}
+	return objectInfo.ExternalPath()
+}
+
+// objectSize returns the size of the object from the file system, without reading it.
+func objectSize(objectInfo storage.ObjectInfo) (int64, error) {
+	fileInfo, err := os.Stat(objectInfo.ExternalPath())
+	if err != nil {
+		return 0, err
+	}
+	return fileInfo.Size(), nil
+}
@@ -37,6 +37,9 @@ import (
 const (
 	blobsDir   = "blobs"
 	commitsDir = "commits"
+	// partialsDir contains the digests of the manifests of the partial modules read
+	// for commits, see GetPartialModule.
+	partialsDir = "partials"
 )
 
 type casModuleCacher struct {
@@ -136,13 +139,179 @@ func (c *casModuleCacher) PutModule(
 	return nil
 }
 
+// GetPartialModule gets the partial module cached for the commit of the ModulePin,
+// if it contains all of the paths.
+//
+// The files of a partial module are closed under their imports that exist within the
+// module, so the returned Module contains the imports of the paths as well.
+func (c *casModuleCacher) GetPartialModule(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+	paths []string,
+) (bufmodule.Module, error) {
+	moduleBasedir := normalpath.Join(modulePin.Remote(), modulePin.Owner(), modulePin.Repository())
+	manifest, err := c.readPartialManifest(ctx, moduleBasedir, modulePin.Commit())
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		path, err := normalpath.NormalizeAndValidate(path)
+		if err != nil {
+			return nil, err
+		}
+		if manifest.GetDigest(path) == nil {
+			return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+		}
+	}
+	blobs := make([]bufcas.Blob, 0, len(manifest.FileNodes()))
+	for _, fileNode := range manifest.FileNodes() {
+		blob, err := c.readBlob(ctx, moduleBasedir, fileNode.Digest())
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, blob)
+	}
+	blobSet, err := bufcas.NewBlobSet(blobs)
+	if err != nil {
+		return nil, err
+	}
+	fileSet, err := bufcas.NewFileSet(manifest, blobSet)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.CheckPartialFileSet(ctx, modulePin, fileSet); err != nil {
+		return nil, err
+	}
+	return bufmodule.NewModuleForFileSet(
+		ctx,
+		fileSet,
+		bufmodule.ModuleWithModuleIdentityAndCommit(
+			modulePin,
+			modulePin.Commit(),
+		),
+	)
+}
+
+// PutPartialModule adds the files of the partial FileSet to the partial module cached
+// for the commit of the ModulePin.
+func (c *casModuleCacher) PutPartialModule(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+	fileSet bufcas.FileSet,
+) error {
+	moduleBasedir := normalpath.Join(modulePin.Remote(), modulePin.Owner(), modulePin.Repository())
+	pathToFileNode := make(map[string]bufcas.FileNode)
+	existingManifest, err := c.readPartialManifest(ctx, moduleBasedir, modulePin.Commit())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Debug(
+				"replacing partial cache entry",
+				zap.String("basedir", moduleBasedir),
+				zap.String("commit", modulePin.Commit()),
+				zap.Error(err),
+			)
+		}
+	} else {
+		for _, fileNode := range existingManifest.FileNodes() {
+			pathToFileNode[fileNode.Path()] = fileNode
+		}
+	}
+	for _, fileNode := range fileSet.Manifest().FileNodes() {
+		pathToFileNode[fileNode.Path()] = fileNode
+	}
+	for _, blob := range fileSet.BlobSet().Blobs() {
+		if err := c.writeBlob(ctx, moduleBasedir, blob); err != nil {
+			return err
+		}
+	}
+	fileNodes := make([]bufcas.FileNode, 0, len(pathToFileNode))
+	for _, fileNode := range pathToFileNode {
+		fileNodes = append(fileNodes, fileNode)
+	}
+	manifest, err := bufcas.NewManifest(fileNodes)
+	if err != nil {
+		return err
+	}
+	manifestBlob, err := bufcas.ManifestToBlob(manifest)
+	if err != nil {
+		return err
+	}
+	if err := c.writeBlob(ctx, moduleBasedir, manifestBlob); err != nil {
+		return err
+	}
+	partialPath := normalpath.Join(moduleBasedir, partialsDir, modulePin.Commit())
+	return c.atomicWrite(ctx, strings.NewReader(manifestBlob.Digest().String()), partialPath)
+}
+
+// CheckPartialFileSet checks the digests of the files of the partial FileSet against
+// the manifest of the ModulePin.
+//
+// The files can only be checked if the manifest of the ModulePin is cached, as the BSR
+// only serves the manifest of a module together with all of its files. If it is not,
+// this returns nil.
+func (c *casModuleCacher) CheckPartialFileSet(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+	fileSet bufcas.FileSet,
+) error {
+	if modulePin.Digest() == "" {
+		return nil
+	}
+	modulePinDigest, err := bufcas.ParseDigest(modulePin.Digest())
+	if err != nil {
+		return fmt.Errorf("invalid module pin digest %q: %w", modulePin.Digest(), err)
+	}
+	moduleBasedir := normalpath.Join(modulePin.Remote(), modulePin.Owner(), modulePin.Repository())
+	manifest, err := c.readManifest(ctx, moduleBasedir, modulePinDigest)
+	if err != nil {
+		c.logger.Debug(
+			"cannot check partial module against manifest",
+			zap.String("module", modulePin.String()),
+			zap.Error(err),
+		)
+		return nil
+	}
+	for _, fileNode := range fileSet.Manifest().FileNodes() {
+		expectedDigest := manifest.GetDigest(fileNode.Path())
+		if expectedDigest == nil {
+			return fmt.Errorf("file %q of %s is not in the manifest %q", fileNode.Path(), modulePin.String(), modulePinDigest.String())
+		}
+		if !bufcas.DigestEqual(expectedDigest, fileNode.Digest()) {
+			return fmt.Errorf(
+				"file digest mismatch for %q of %s - expected: %q, found: %q",
+				fileNode.Path(),
+				modulePin.String(),
+				expectedDigest.String(),
+				fileNode.Digest().String(),
+			)
+		}
+	}
+	return nil
+}
+
+func (c *casModuleCacher) readPartialManifest(
+	ctx context.Context,
+	moduleBasedir string,
+	commit string,
+) (bufcas.Manifest, error) {
+	partialPath := normalpath.Join(moduleBasedir, partialsDir, commit)
+	digestBytes, err := storage.ReadPath(ctx, c.bucket, partialPath)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := bufcas.ParseDigest(string(digestBytes))
+	if err != nil {
+		return nil, err
+	}
+	return c.readManifest(ctx, moduleBasedir, digest)
+}
+
 func (c *casModuleCacher) readBlob(
 	ctx context.Context,
 	moduleBasedir string,
 	digest bufcas.Digest,
 ) (_ bufcas.Blob, retErr error) {
-	digestHex := hex.EncodeToString(digest.Value())
-	blobPath := normalpath.Join(moduleBasedir, blobsDir, digestHex[:2], digestHex[2:])
+	blobPath := blobPath(moduleBasedir, digest)
 	readObjectCloser, err := c.bucket.Get(ctx, blobPath)
 	if err != nil {
 		return nil, err
@@ -162,9 +331,7 @@ func (c *casModuleCacher) validateBlob(
 	moduleBasedir string,
 	digest bufcas.Digest,
 ) (_ bool, retErr error) {
-	digestHex := hex.EncodeToString(digest.Value())
-	blobPath := normalpath.Join(moduleBasedir, blobsDir, digestHex[:2], digestHex[2:])
-	readObjectCloser, err := c.bucket.Get(ctx, blobPath)
+	readObjectCloser, err := c.bucket.Get(ctx, blobPath(moduleBasedir, digest))
 	if err != nil {
 		return false, err
 	}
@@ -211,9 +378,7 @@ func (c *casModuleCacher) writeBlob(
 			zap.String("digest", blob.Digest().String()),
 		)
 	}
-	digestHex := hex.EncodeToString(blob.Digest().Value())
-	blobPath := normalpath.Join(moduleBasedir, blobsDir, digestHex[:2], digestHex[2:])
-	return c.atomicWrite(ctx, bytes.NewReader(blob.Content()), blobPath)
+	return c.atomicWrite(ctx, bytes.NewReader(blob.Content()), blobPath(moduleBasedir, blob.Digest()))
 }
 
 func (c *casModuleCacher) atomicWrite(ctx context.Context, contents io.Reader, path string) (retErr error) {
@@ -229,3 +394,9 @@ func (c *casModuleCacher) atomicWrite(ctx context.Context, contents io.Reader, p
 	}
 	return nil
 }
+
+// blobPath returns the path of the blob with the digest within the module basedir.
+func blobPath(moduleBasedir string, digest bufcas.Digest) string {
+	digestHex := hex.EncodeToString(digest.Value())
+	return normalpath.Join(moduleBasedir, blobsDir, digestHex[:2], digestHex[2:])
+}
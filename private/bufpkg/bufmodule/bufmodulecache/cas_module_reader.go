@@ -18,37 +18,60 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/cache"
+	"github.com/bufbuild/buf/private/pkg/filelock"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/verbose"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
+// moduleLockTimeout is the timeout for acquiring the lock for a module in the cache.
+//
+// This is longer than filelock.DefaultLockTimeout as the holder of the write lock may
+// be downloading the module.
+const moduleLockTimeout = 2 * time.Minute
+
 type casModuleReader struct {
 	// required parameters
-	delegate       bufmodule.ModuleReader
-	logger         *zap.Logger
-	verbosePrinter verbose.Printer
+	locker           filelock.Locker
+	delegate         bufmodule.ModuleReader
+	logger           *zap.Logger
+	verbosePrinter   verbose.Printer
+	progressReporter progress.Reporter
+	// optional parameters
+	statsRecorder CacheStatsRecorder
 	// initialized in newCASModuleReader
 	cache *casModuleCacher
 	stats *cacheStats
+	// modulePinToModule makes concurrent reads of the same module share one read
+	// of the cache or download, and keeps the modules read for the life of the reader.
+	modulePinToModule cache.SingleflightCache[string, bufmodule.Module]
 }
 
-var _ bufmodule.ModuleReader = (*casModuleReader)(nil)
+var _ bufmodule.PartialModuleReader = (*casModuleReader)(nil)
 
 func newCASModuleReader(
 	bucket storage.ReadWriteBucket,
+	locker filelock.Locker,
 	delegate bufmodule.ModuleReader,
 	logger *zap.Logger,
 	verbosePrinter verbose.Printer,
+	progressReporter progress.Reporter,
 ) *casModuleReader {
 	return &casModuleReader{
-		delegate:       delegate,
-		logger:         logger,
-		verbosePrinter: verbosePrinter,
+		locker:           locker,
+		delegate:         delegate,
+		logger:           logger,
+		verbosePrinter:   verbosePrinter,
+		progressReporter: progressReporter,
 		cache: &casModuleCacher{
 			logger: logger,
 			bucket: bucket,
@@ -61,6 +84,27 @@ func (c *casModuleReader) GetModule(
 	ctx context.Context,
 	modulePin bufmoduleref.ModulePin,
 ) (bufmodule.Module, error) {
+	var read bool
+	module, err := c.modulePinToModule.GetOrAdd(
+		ctx,
+		// The digest is part of the key so that a module read for a pin without
+		// a digest is not returned for a pin whose digest has to be verified.
+		modulePin.String()+"@"+modulePin.Digest(),
+		func(ctx context.Context) (bufmodule.Module, error) {
+			read = true
+			return c.getModuleUncached(ctx, modulePin)
+		},
+	)
+	if err == nil && !read {
+		c.markHit()
+	}
+	return module, err
+}
+
+func (c *casModuleReader) getModuleUncached(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+) (_ bufmodule.Module, retErr error) {
 	var modulePinDigest bufcas.Digest
 	if digest := modulePin.Digest(); digest != "" {
 		var err error
@@ -70,17 +114,48 @@ func (c *casModuleReader) GetModule(
 			return nil, fmt.Errorf("malformed module digest %q: %w", digest, err)
 		}
 	}
-	cachedModule, err := c.cache.GetModule(ctx, modulePin)
+	lockPath := normalpath.Join(modulePin.Remote(), modulePin.Owner(), modulePin.Repository(), modulePin.Commit())
+	cachedModule, err := c.getCachedModule(
+		ctx,
+		lockPath,
+		func() (bufmodule.Module, error) {
+			return c.cache.GetModule(ctx, modulePin)
+		},
+	)
 	if err == nil {
-		c.stats.MarkHit()
+		c.markHit()
 		return cachedModule, nil
 	}
+	if errors.Is(err, filelock.ErrLockTimeout) {
+		return nil, err
+	}
 	c.logger.Debug("module cache miss", zap.Error(err))
-	c.stats.MarkMiss()
+	unlocker, err := c.lock(ctx, lockPath, c.locker.Lock)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, unlocker.Unlock())
+	}()
+	// Another process may have populated the cache while we were waiting for the lock.
+	cachedModule, err = c.cache.GetModule(ctx, modulePin)
+	if err == nil {
+		c.markHit()
+		return cachedModule, nil
+	}
+	c.markMiss()
+	tracker := c.progressReporter.Start("downloading "+modulePin.String(), -1)
 	remoteModule, err := c.delegate.GetModule(ctx, modulePin)
 	if err != nil {
+		tracker.Done()
 		return nil, err
 	}
+	if fileSet := remoteModule.FileSet(); fileSet != nil {
+		for _, blob := range fileSet.BlobSet().Blobs() {
+			tracker.Add(int64(len(blob.Content())))
+		}
+	}
+	tracker.Done()
 	// FileSet should always be set.
 	if remoteModule.FileSet() == nil {
 		return nil, errors.New("required FileSet not set on Module")
@@ -99,5 +174,123 @@ func (c *casModuleReader) GetModule(
 	if err := c.cache.PutModule(ctx, modulePin, remoteModule); err != nil {
 		return nil, err
 	}
+	// The module is kept for later reads of the pin, so give it the identity and
+	// commit that a read from the cache would.
+	return bufmodule.NewModuleForFileSet(
+		ctx,
+		remoteModule.FileSet(),
+		bufmodule.ModuleWithModuleIdentityAndCommit(
+			modulePin,
+			modulePin.Commit(),
+		),
+	)
+}
+
+func (c *casModuleReader) GetPartialModule(
+	ctx context.Context,
+	modulePin bufmoduleref.ModulePin,
+	paths []string,
+) (_ bufmodule.Module, retErr error) {
+	partialDelegate, ok := c.delegate.(bufmodule.PartialModuleReader)
+	if !ok {
+		return c.GetModule(ctx, modulePin)
+	}
+	lockPath := normalpath.Join(modulePin.Remote(), modulePin.Owner(), modulePin.Repository(), modulePin.Commit())
+	getCachedModule := func() (bufmodule.Module, error) {
+		cachedModule, err := c.cache.GetModule(ctx, modulePin)
+		if err == nil {
+			return cachedModule, nil
+		}
+		return c.cache.GetPartialModule(ctx, modulePin, paths)
+	}
+	cachedModule, err := c.getCachedModule(ctx, lockPath, getCachedModule)
+	if err == nil {
+		c.markHit()
+		return cachedModule, nil
+	}
+	if errors.Is(err, filelock.ErrLockTimeout) {
+		return nil, err
+	}
+	c.logger.Debug("module cache miss, reading partial module", zap.Error(err))
+	unlocker, err := c.lock(ctx, lockPath, c.locker.Lock)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, unlocker.Unlock())
+	}()
+	// Another process may have populated the cache while we were waiting for the lock.
+	cachedModule, err = getCachedModule()
+	if err == nil {
+		c.markHit()
+		return cachedModule, nil
+	}
+	c.markMiss()
+	remoteModule, err := partialDelegate.GetPartialModule(ctx, modulePin, paths)
+	if err != nil {
+		return nil, err
+	}
+	// FileSet should always be set.
+	if remoteModule.FileSet() == nil {
+		return nil, errors.New("required FileSet not set on Module")
+	}
+	// Check the files before writing them to the cache.
+	if err := c.cache.CheckPartialFileSet(ctx, modulePin, remoteModule.FileSet()); err != nil {
+		return nil, err
+	}
+	if err := c.cache.PutPartialModule(ctx, modulePin, remoteModule.FileSet()); err != nil {
+		return nil, err
+	}
 	return remoteModule, nil
 }
+
+// getCachedModule reads the module from the cache with the function while holding a
+// read lock for the module, so that it does not observe a partially written entry.
+func (c *casModuleReader) getCachedModule(
+	ctx context.Context,
+	lockPath string,
+	getModule func() (bufmodule.Module, error),
+) (_ bufmodule.Module, retErr error) {
+	unlocker, err := c.lock(ctx, lockPath, c.locker.RLock)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, unlocker.Unlock())
+	}()
+	return getModule()
+}
+
+func (c *casModuleReader) lock(
+	ctx context.Context,
+	lockPath string,
+	lockFunc func(context.Context, string, ...filelock.LockOption) (filelock.Unlocker, error),
+) (filelock.Unlocker, error) {
+	start := time.Now()
+	unlocker, err := lockFunc(ctx, lockPath, filelock.LockWithTimeout(moduleLockTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if waited := time.Since(start); waited >= filelock.DefaultLockRetryDelay {
+		c.logger.Debug(
+			"waited for module cache lock",
+			zap.String("path", lockPath),
+			zap.Duration("duration", waited),
+		)
+	}
+	return unlocker, nil
+}
+
+func (c *casModuleReader) markHit() {
+	c.stats.MarkHit()
+	if c.statsRecorder != nil {
+		c.statsRecorder.MarkHit()
+	}
+}
+
+func (c *casModuleReader) markMiss() {
+	c.stats.MarkMiss()
+	if c.statsRecorder != nil {
+		c.statsRecorder.MarkMiss()
+	}
+}
@@ -19,12 +19,15 @@ import (
 	"encoding/hex"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/filelock"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/verbose"
@@ -65,10 +68,14 @@ func TestCASModuleReaderHappyPath(t *testing.T) {
 
 	moduleReader := newCASModuleReader(
 		storageBucket,
+		newTestLocker(t),
 		&testModuleReader{module: testModule},
 		zaptest.NewLogger(t),
 		&testVerbosePrinter{t: t},
+		progress.NopReporter,
 	)
+	recorder := &cacheStats{}
+	ModuleReaderWithCacheStatsRecorder(recorder)(moduleReader)
 	pin, err := bufmoduleref.NewModulePin(
 		"buf.build",
 		"test",
@@ -89,6 +96,9 @@ func TestCASModuleReaderHappyPath(t *testing.T) {
 	assert.Equal(t, 2, moduleReader.stats.Count())
 	assert.Equal(t, 1, moduleReader.stats.Hits()) // We should have a cache hit the second time
 	verifyCache(t, storageBucket, pin, fileSet)
+	// The recorder sees the same hits and misses as the reader.
+	assert.Equal(t, 2, recorder.Count())
+	assert.Equal(t, 1, recorder.Hits())
 }
 
 func TestCASModuleReaderNoDigest(t *testing.T) {
@@ -101,9 +111,11 @@ func TestCASModuleReaderNoDigest(t *testing.T) {
 	require.NoError(t, err)
 	moduleReader := newCASModuleReader(
 		storageBucket,
+		newTestLocker(t),
 		&testModuleReader{module: testModule},
 		zaptest.NewLogger(t),
 		&testVerbosePrinter{t: t},
+		progress.NopReporter,
 	)
 	pin, err := bufmoduleref.NewModulePin(
 		"buf.build",
@@ -130,9 +142,11 @@ func TestCASModuleReaderDigestMismatch(t *testing.T) {
 	require.NoError(t, err)
 	moduleReader := newCASModuleReader(
 		storageBucket,
+		newTestLocker(t),
 		&testModuleReader{module: testModule},
 		zaptest.NewLogger(t),
 		&testVerbosePrinter{t: t},
+		progress.NopReporter,
 	)
 	pin, err := bufmoduleref.NewModulePin(
 		"buf.build",
@@ -153,6 +167,358 @@ func TestCASModuleReaderDigestMismatch(t *testing.T) {
 	assert.Equal(t, 0, numFiles) // Verify nothing written to cache on digest mismatch
 }
 
+func TestCASModuleReaderConcurrent(t *testing.T) {
+	t.Parallel()
+	fileSet := createSampleFileSet(t)
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	testModule, err := bufmodule.NewModuleForFileSet(context.Background(), fileSet)
+	require.NoError(t, err)
+	storageProvider := storageos.NewProvider()
+	storageBucket, err := storageProvider.NewReadWriteBucket(t.TempDir())
+	require.NoError(t, err)
+	locker := newTestLocker(t)
+	delegate := &testModuleReader{module: testModule}
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	// Each reader simulates a separate buf process sharing the same cache.
+	const numReaders = 8
+	var waitGroup sync.WaitGroup
+	errs := make([]error, numReaders)
+	for i := 0; i < numReaders; i++ {
+		moduleReader := newCASModuleReader(
+			storageBucket,
+			locker,
+			delegate,
+			zaptest.NewLogger(t),
+			&testVerbosePrinter{t: t},
+			progress.NopReporter,
+		)
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			_, errs[i] = moduleReader.GetModule(context.Background(), pin)
+		}(i)
+	}
+	waitGroup.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, delegate.getModuleCount()) // Only one reader should download the module
+	verifyCache(t, storageBucket, pin, fileSet)
+}
+
+func TestCASModuleReaderConcurrentSameReader(t *testing.T) {
+	t.Parallel()
+	fileSet := createSampleFileSet(t)
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	testModule, err := bufmodule.NewModuleForFileSet(context.Background(), fileSet)
+	require.NoError(t, err)
+	storageProvider := storageos.NewProvider()
+	storageBucket, err := storageProvider.NewReadWriteBucket(t.TempDir())
+	require.NoError(t, err)
+	delegate := &testModuleReader{module: testModule}
+	moduleReader := newCASModuleReader(
+		storageBucket,
+		newTestLocker(t),
+		delegate,
+		zaptest.NewLogger(t),
+		&testVerbosePrinter{t: t},
+		progress.NopReporter,
+	)
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	const numCallers = 8
+	var waitGroup sync.WaitGroup
+	modules := make([]bufmodule.Module, numCallers)
+	errs := make([]error, numCallers)
+	for i := 0; i < numCallers; i++ {
+		waitGroup.Add(1)
+		go func(i int) {
+			defer waitGroup.Done()
+			modules[i], errs[i] = moduleReader.GetModule(context.Background(), pin)
+		}(i)
+	}
+	waitGroup.Wait()
+	for i := 0; i < numCallers; i++ {
+		require.NoError(t, errs[i])
+		// Every caller gets the module from the one read.
+		assert.Same(t, modules[0], modules[i])
+	}
+	assert.Equal(t, 1, delegate.getModuleCount())
+	assert.Equal(t, numCallers, moduleReader.stats.Count())
+	assert.Equal(t, numCallers-1, moduleReader.stats.Hits())
+}
+
+func TestCASModuleReaderStatsAndExplain(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fileSet := createSampleFileSet(t)
+	testModule, err := bufmodule.NewModuleForFileSet(ctx, fileSet)
+	require.NoError(t, err)
+	storageProvider := storageos.NewProvider()
+	storageBucket, err := storageProvider.NewReadWriteBucket(t.TempDir())
+	require.NoError(t, err)
+	locker := newTestLocker(t)
+	moduleReader := newCASModuleReader(
+		storageBucket,
+		locker,
+		&testModuleReader{module: testModule},
+		zaptest.NewLogger(t),
+		&testVerbosePrinter{t: t},
+		progress.NopReporter,
+	)
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		"",
+	)
+	require.NoError(t, err)
+	commitExplanation, err := ExplainCommit(ctx, storageBucket, locker, pin, pin.Commit())
+	require.NoError(t, err)
+	assert.False(t, commitExplanation.CommitCached)
+	assert.False(t, commitExplanation.Complete())
+	_, err = moduleReader.GetModule(ctx, pin)
+	require.NoError(t, err)
+
+	moduleStats, err := GetModuleStats(ctx, storageBucket)
+	require.NoError(t, err)
+	require.Len(t, moduleStats, 1)
+	assert.Equal(t, "buf.build/test/ping", moduleStats[0].ModuleIdentity)
+	assert.Equal(t, 1, moduleStats[0].CommitCount)
+	assert.Equal(t, 2, moduleStats[0].BlobCount) // the file and the manifest
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	// The file, the manifest, and the manifest digest of the commit.
+	assert.Equal(
+		t,
+		int64(len(pingProto)+len(manifestBlob.Content())+len(manifestBlob.Digest().String())),
+		moduleStats[0].SizeBytes,
+	)
+
+	commitExplanation, err = ExplainCommit(ctx, storageBucket, locker, pin, pin.Commit())
+	require.NoError(t, err)
+	assert.True(t, commitExplanation.Complete())
+	assert.Equal(t, 1, commitExplanation.FileCount)
+	assert.Equal(t, manifestBlob.Digest().String(), commitExplanation.ManifestDigest)
+
+	// Remove the file blob and verify it is reported as missing.
+	blobDigestHex := hex.EncodeToString(fileSet.Manifest().FileNodes()[0].Digest().Value())
+	require.NoError(
+		t,
+		storageBucket.Delete(
+			ctx,
+			normalpath.Join("buf.build/test/ping", blobsDir, blobDigestHex[:2], blobDigestHex[2:]),
+		),
+	)
+	commitExplanation, err = ExplainCommit(ctx, storageBucket, locker, pin, pin.Commit())
+	require.NoError(t, err)
+	assert.False(t, commitExplanation.Complete())
+	assert.Equal(t, []string{"connect/ping/v1/ping.proto"}, commitExplanation.MissingFilePaths)
+}
+
+func TestCASModuleReaderPartial(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fileSet := createSampleFileSet(t)
+	testModule, err := bufmodule.NewModuleForFileSet(ctx, fileSet)
+	require.NoError(t, err)
+	storageBucket, err := storageos.NewProvider().NewReadWriteBucket(t.TempDir())
+	require.NoError(t, err)
+	delegate := &testPartialModuleReader{partialModule: testModule}
+	moduleReader := newCASModuleReader(
+		storageBucket,
+		newTestLocker(t),
+		delegate,
+		zaptest.NewLogger(t),
+		&testVerbosePrinter{t: t},
+		progress.NopReporter,
+	)
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		"",
+	)
+	require.NoError(t, err)
+	paths := []string{"connect/ping/v1/ping.proto"}
+	_, err = moduleReader.GetPartialModule(ctx, pin, paths)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delegate.getPartialModuleCount())
+	assert.Equal(t, 0, moduleReader.stats.Hits())
+	cachedModule, err := moduleReader.GetPartialModule(ctx, pin, paths)
+	require.NoError(t, err)
+	assertModuleIdentity(t, cachedModule, pin.IdentityString(), pin.Commit())
+	assert.Equal(t, 1, delegate.getPartialModuleCount())
+	assert.Equal(t, 1, moduleReader.stats.Hits())
+	// A path that is not in the cached partial module is read from the delegate.
+	_, err = moduleReader.GetPartialModule(ctx, pin, []string{"other.proto"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, delegate.getPartialModuleCount())
+}
+
+func TestCASModuleReaderPartialDigestMismatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fileSet := createSampleFileSet(t)
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	testModule, err := bufmodule.NewModuleForFileSet(ctx, fileSet)
+	require.NoError(t, err)
+	blob, err := bufcas.NewBlobForContent(strings.NewReader(pingProto + "// modified\n"))
+	require.NoError(t, err)
+	fileNode, err := bufcas.NewFileNode("connect/ping/v1/ping.proto", blob.Digest())
+	require.NoError(t, err)
+	manifest, err := bufcas.NewManifest([]bufcas.FileNode{fileNode})
+	require.NoError(t, err)
+	blobSet, err := bufcas.NewBlobSet([]bufcas.Blob{blob})
+	require.NoError(t, err)
+	modifiedFileSet, err := bufcas.NewFileSet(manifest, blobSet)
+	require.NoError(t, err)
+	modifiedModule, err := bufmodule.NewModuleForFileSet(ctx, modifiedFileSet)
+	require.NoError(t, err)
+	storageBucket, err := storageos.NewProvider().NewReadWriteBucket(t.TempDir())
+	require.NoError(t, err)
+	moduleReader := newCASModuleReader(
+		storageBucket,
+		newTestLocker(t),
+		&testPartialModuleReader{
+			testModuleReader: testModuleReader{module: testModule},
+			partialModule:    modifiedModule,
+		},
+		zaptest.NewLogger(t),
+		&testVerbosePrinter{t: t},
+		progress.NopReporter,
+	)
+	// Cache the manifest of the module, so that the partial module can be checked against it.
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	_, err = moduleReader.GetModule(ctx, pin)
+	require.NoError(t, err)
+	otherPin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"efgh",
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	// Remove the file blob, so that the module is not read from the cache.
+	blobDigestHex := hex.EncodeToString(fileSet.Manifest().FileNodes()[0].Digest().Value())
+	require.NoError(
+		t,
+		storageBucket.Delete(
+			ctx,
+			normalpath.Join("buf.build/test/ping", blobsDir, blobDigestHex[:2], blobDigestHex[2:]),
+		),
+	)
+	_, err = moduleReader.GetPartialModule(ctx, otherPin, []string{"connect/ping/v1/ping.proto"})
+	require.ErrorContains(t, err, "file digest mismatch")
+	exists, err := storage.Exists(ctx, storageBucket, normalpath.Join("buf.build/test/ping", partialsDir, otherPin.Commit()))
+	require.NoError(t, err)
+	assert.False(t, exists) // Verify nothing written to the cache on digest mismatch
+}
+
+func TestCheckCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fileSet := createSampleFileSet(t)
+	manifestBlob, err := bufcas.ManifestToBlob(fileSet.Manifest())
+	require.NoError(t, err)
+	testModule, err := bufmodule.NewModuleForFileSet(ctx, fileSet)
+	require.NoError(t, err)
+	cacheDirPath := t.TempDir()
+	storageBucket, err := storageos.NewProvider().NewReadWriteBucket(cacheDirPath)
+	require.NoError(t, err)
+	moduleReader := newCASModuleReader(
+		storageBucket,
+		newTestLocker(t),
+		&testPartialModuleReader{
+			testModuleReader: testModuleReader{module: testModule},
+			partialModule:    testModule,
+		},
+		zaptest.NewLogger(t),
+		&testVerbosePrinter{t: t},
+		progress.NopReporter,
+	)
+	pin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"abcd",
+		manifestBlob.Digest().String(),
+	)
+	require.NoError(t, err)
+	_, err = moduleReader.GetModule(ctx, pin)
+	require.NoError(t, err)
+	partialPin, err := bufmoduleref.NewModulePin(
+		"buf.build",
+		"test",
+		"ping",
+		"efgh",
+		"",
+	)
+	require.NoError(t, err)
+	_, err = moduleReader.GetPartialModule(ctx, partialPin, []string{"connect/ping/v1/ping.proto"})
+	require.NoError(t, err)
+	cacheIssues, err := CheckCache(ctx, storageBucket)
+	require.NoError(t, err)
+	assert.Empty(t, cacheIssues)
+
+	// A partial module for the commit whose file does not match the manifest of the commit.
+	modifiedBlob, err := bufcas.NewBlobForContent(strings.NewReader(pingProto + "// modified\n"))
+	require.NoError(t, err)
+	modifiedFileNode, err := bufcas.NewFileNode("connect/ping/v1/ping.proto", modifiedBlob.Digest())
+	require.NoError(t, err)
+	modifiedManifest, err := bufcas.NewManifest([]bufcas.FileNode{modifiedFileNode})
+	require.NoError(t, err)
+	modifiedBlobSet, err := bufcas.NewBlobSet([]bufcas.Blob{modifiedBlob})
+	require.NoError(t, err)
+	modifiedFileSet, err := bufcas.NewFileSet(modifiedManifest, modifiedBlobSet)
+	require.NoError(t, err)
+	require.NoError(t, moduleReader.cache.PutPartialModule(ctx, pin, modifiedFileSet))
+	// A blob whose content does not match its digest, a commit that does not contain
+	// a digest, and a file outside of the layout of the cache.
+	require.NoError(t, storage.PutPath(ctx, storageBucket, "buf.build/test/ping/blobs/00/1234", []byte("corrupt")))
+	require.NoError(t, storage.PutPath(ctx, storageBucket, "buf.build/test/ping/commits/ijkl", []byte("corrupt")))
+	require.NoError(t, storage.PutPath(ctx, storageBucket, "buf.build/test/other", []byte("other")))
+	cacheIssues, err = CheckCache(ctx, storageBucket)
+	require.NoError(t, err)
+	pathToMessage := make(map[string]string, len(cacheIssues))
+	for _, cacheIssue := range cacheIssues {
+		relPath, err := normalpath.Rel(normalpath.Normalize(cacheDirPath), normalpath.Normalize(cacheIssue.Path))
+		require.NoError(t, err)
+		pathToMessage[relPath] = cacheIssue.Message
+	}
+	assert.Len(t, pathToMessage, 4)
+	assert.Contains(t, pathToMessage["buf.build/test/ping/partials/abcd"], `files "connect/ping/v1/ping.proto" do not match the manifest of the commit`)
+	assert.Contains(t, pathToMessage["buf.build/test/ping/blobs/00/1234"], "does not match its path")
+	assert.Contains(t, pathToMessage["buf.build/test/ping/commits/ijkl"], "does not contain a valid manifest digest")
+	assert.Equal(t, "unexpected file in the cache", pathToMessage["buf.build/test/other"])
+}
+
 func verifyCache(
 	t *testing.T,
 	bucket storage.ReadWriteBucket,
@@ -226,16 +592,60 @@ func assertModuleIdentity(t *testing.T, module bufmodule.Module, expectedModuleI
 	}
 }
 
+func newTestLocker(t *testing.T) filelock.Locker {
+	locker, err := filelock.NewLocker(t.TempDir())
+	require.NoError(t, err)
+	return locker
+}
+
 type testModuleReader struct {
 	module bufmodule.Module
+
+	lock  sync.Mutex
+	count int
 }
 
 var _ bufmodule.ModuleReader = (*testModuleReader)(nil)
 
 func (t *testModuleReader) GetModule(_ context.Context, _ bufmoduleref.ModulePin) (bufmodule.Module, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.count++
 	return t.module, nil
 }
 
+func (t *testModuleReader) getModuleCount() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.count
+}
+
+type testPartialModuleReader struct {
+	testModuleReader
+
+	partialModule bufmodule.Module
+	partialCount  int
+}
+
+var _ bufmodule.PartialModuleReader = (*testPartialModuleReader)(nil)
+
+func (t *testPartialModuleReader) GetPartialModule(
+	_ context.Context,
+	_ bufmoduleref.ModulePin,
+	_ []string,
+) (bufmodule.Module, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.partialCount++
+	return t.partialModule, nil
+}
+
+func (t *testPartialModuleReader) getPartialModuleCount() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.partialCount
+}
+
 type testVerbosePrinter struct {
 	t *testing.T
 }
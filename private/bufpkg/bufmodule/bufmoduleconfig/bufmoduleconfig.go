@@ -39,6 +39,23 @@ type Config struct {
 	// If RootToExcludes is empty, the default is "." with no excludes.
 	RootToExcludes             map[string][]string
 	DependencyModuleReferences []bufmoduleref.ModuleReference
+	// AutoGoogleapis says to automatically depend on googleapis if the module imports
+	// the common google/api, google/rpc, or google/type files without declaring it.
+	//
+	// The dependency is resolved and pinned in buf.lock by buf mod update.
+	AutoGoogleapis bool
+	// DocumentationPath is the path to the documentation file of the module, relative
+	// to the root of the module.
+	//
+	// If empty, the first of bufmodule.AllDocumentationPaths that exists is used.
+	// All paths will be normalized and validated.
+	DocumentationPath string
+	// LicensePath is the path to the license file of the module, relative to the root
+	// of the module.
+	//
+	// If empty, bufmodule.LicenseFilePath is used.
+	// All paths will be normalized and validated.
+	LicensePath string
 }
 
 // NewConfigV1Beta1 returns a new, validated Config for the ExternalConfig.
@@ -59,5 +76,8 @@ type ExternalConfigV1Beta1 struct {
 
 // ExternalConfigV1 is an external config.
 type ExternalConfigV1 struct {
-	Excludes []string `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	Excludes          []string `json:"excludes,omitempty" yaml:"excludes,omitempty"`
+	AutoGoogleapis    bool     `json:"auto_googleapis,omitempty" yaml:"auto_googleapis,omitempty"`
+	DocumentationPath string   `json:"documentation_path,omitempty" yaml:"documentation_path,omitempty"`
+	LicensePath       string   `json:"license_path,omitempty" yaml:"license_path,omitempty"`
 }
@@ -133,15 +133,45 @@ func newConfigV1(externalConfig ExternalConfigV1, deps ...string) (*Config, erro
 		// this should never happen, but just in case
 		return nil, fmt.Errorf("excludes %v are not unique (system error)", excludes)
 	}
+	documentationPath, err := normalizeAndValidateFilePath(externalConfig.DocumentationPath, "documentation_path")
+	if err != nil {
+		return nil, err
+	}
+	licensePath, err := normalizeAndValidateFilePath(externalConfig.LicensePath, "license_path")
+	if err != nil {
+		return nil, err
+	}
 	rootToExcludes := map[string][]string{
 		".": excludes, // all excludes are relative to the root
 	}
 	return &Config{
 		RootToExcludes:             rootToExcludes,
 		DependencyModuleReferences: dependencyModuleReferences,
+		AutoGoogleapis:             externalConfig.AutoGoogleapis,
+		DocumentationPath:          documentationPath,
+		LicensePath:                licensePath,
 	}, nil
 }
 
+// normalizeAndValidateFilePath normalizes and validates the relative path of a non-.proto
+// file within the module. Returns empty if the path is empty.
+func normalizeAndValidateFilePath(path string, name string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	normalizedPath, err := normalpath.NormalizeAndValidate(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: %w", name, path, err)
+	}
+	if normalizedPath == "." {
+		return "", fmt.Errorf("invalid %s %q: must be a file", name, path)
+	}
+	if normalpath.Ext(normalizedPath) == ".proto" {
+		return "", fmt.Errorf("invalid %s %q: cannot be a .proto file", name, path)
+	}
+	return normalizedPath, nil
+}
+
 func parseDependencyModuleReferences(deps ...string) ([]bufmoduleref.ModuleReference, error) {
 	if len(deps) == 0 {
 		return nil, nil
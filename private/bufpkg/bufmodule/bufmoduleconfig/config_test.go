@@ -398,6 +398,41 @@ func testNewConfigV1Beta1Error(t *testing.T, roots []string, excludes []string,
 	assert.Error(t, err, fmt.Sprintf("%v %v %v", roots, excludes, deps))
 }
 
+func TestNewConfigV1AutoGoogleapis(t *testing.T) {
+	t.Parallel()
+	config, err := bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{AutoGoogleapis: true})
+	require.NoError(t, err)
+	assert.True(t, config.AutoGoogleapis)
+	config, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{})
+	require.NoError(t, err)
+	assert.False(t, config.AutoGoogleapis)
+}
+
+func TestNewConfigV1DocumentationAndLicensePaths(t *testing.T) {
+	t.Parallel()
+	config, err := bufmoduleconfig.NewConfigV1(
+		bufmoduleconfig.ExternalConfigV1{
+			DocumentationPath: "./docs/PROTO_README.md",
+			LicensePath:       "legal/LICENSE.txt",
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/PROTO_README.md", config.DocumentationPath)
+	assert.Equal(t, "legal/LICENSE.txt", config.LicensePath)
+	config, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{})
+	require.NoError(t, err)
+	assert.Empty(t, config.DocumentationPath)
+	assert.Empty(t, config.LicensePath)
+	_, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{DocumentationPath: "../README.md"})
+	assert.Error(t, err)
+	_, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{DocumentationPath: "."})
+	assert.Error(t, err)
+	_, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{LicensePath: "/LICENSE"})
+	assert.Error(t, err)
+	_, err = bufmoduleconfig.NewConfigV1(bufmoduleconfig.ExternalConfigV1{LicensePath: "a.proto"})
+	assert.Error(t, err)
+}
+
 func testNewConfigV1Beta1Equal(
 	t *testing.T,
 	roots []string,
@@ -0,0 +1,86 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufmodulegoogleapis supports automatically depending on googleapis for
+// modules that import the common google/api, google/rpc, and google/type files
+// without declaring the dependency.
+package bufmodulegoogleapis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+)
+
+const (
+	// Owner is the owner of the googleapis module.
+	Owner = "googleapis"
+	// Repository is the repository of the googleapis module.
+	Repository = "googleapis"
+)
+
+// importPathPrefixes are the prefixes of the import paths provided by the googleapis module
+// that are satisfied automatically.
+var importPathPrefixes = []string{
+	"google/api/",
+	"google/rpc/",
+	"google/type/",
+}
+
+// IsImportPath returns true if the import path is one of the common googleapis files
+// that are satisfied automatically.
+func IsImportPath(importPath string) bool {
+	for _, importPathPrefix := range importPathPrefixes {
+		if strings.HasPrefix(importPath, importPathPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsModuleIdentity returns true if the ModuleIdentity is the googleapis module on any remote.
+func IsModuleIdentity(moduleIdentity bufmoduleref.ModuleIdentity) bool {
+	return moduleIdentity != nil &&
+		moduleIdentity.Owner() == Owner &&
+		moduleIdentity.Repository() == Repository
+}
+
+// IsDeclared returns true if the googleapis module is one of the ModuleReferences.
+func IsDeclared(moduleReferences []bufmoduleref.ModuleReference) bool {
+	for _, moduleReference := range moduleReferences {
+		if IsModuleIdentity(moduleReference) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewModuleReference returns a new ModuleReference for the latest commit of the
+// googleapis module on the remote.
+func NewModuleReference(remote string) (bufmoduleref.ModuleReference, error) {
+	return bufmoduleref.NewModuleReference(remote, Owner, Repository, bufmoduleref.Main)
+}
+
+// GetUndeclaredImportPaths returns the sorted import paths of the source files of the
+// Module that would be satisfied automatically by the googleapis module.
+//
+// Returns empty if the googleapis module is declared as a dependency of the Module, or
+// if the Module provides the imported files itself.
+//
+// Files that cannot be parsed are ignored, they are reported when the module is built.
+func GetUndeclaredImportPaths(ctx context.Context, module bufmodule.Module) ([]string, error) {
+	return getUndeclaredImportPaths(ctx, module)
+}
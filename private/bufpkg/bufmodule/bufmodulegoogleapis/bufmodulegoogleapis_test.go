@@ -0,0 +1,134 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmodulegoogleapis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/storage/storagemem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsImportPath(t *testing.T) {
+	t.Parallel()
+	assert.True(t, IsImportPath("google/api/annotations.proto"))
+	assert.True(t, IsImportPath("google/rpc/status.proto"))
+	assert.True(t, IsImportPath("google/type/date.proto"))
+	assert.False(t, IsImportPath("google/protobuf/timestamp.proto"))
+	assert.False(t, IsImportPath("google/longrunning/operations.proto"))
+	assert.False(t, IsImportPath("acme/api/v1/api.proto"))
+}
+
+func TestIsDeclared(t *testing.T) {
+	t.Parallel()
+	googleapis, err := bufmoduleref.ModuleReferenceForString("buf.example.com/googleapis/googleapis")
+	require.NoError(t, err)
+	other, err := bufmoduleref.ModuleReferenceForString("buf.build/acme/googleapis")
+	require.NoError(t, err)
+	assert.True(t, IsDeclared([]bufmoduleref.ModuleReference{other, googleapis}))
+	assert.False(t, IsDeclared([]bufmoduleref.ModuleReference{other}))
+	assert.False(t, IsDeclared(nil))
+}
+
+func TestNewModuleReference(t *testing.T) {
+	t.Parallel()
+	moduleReference, err := NewModuleReference("buf.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "buf.example.com/googleapis/googleapis", moduleReference.String())
+	assert.True(t, IsModuleIdentity(moduleReference))
+}
+
+func TestGetUndeclaredImportPaths(t *testing.T) {
+	t.Parallel()
+	testGetUndeclaredImportPaths(
+		t,
+		"undeclared",
+		map[string][]byte{
+			"buf.yaml": []byte(`version: v1
+build:
+  auto_googleapis: true
+`),
+			"acme/v1/a.proto": []byte(`syntax = "proto3";
+package acme.v1;
+import "google/api/annotations.proto";
+import "google/protobuf/timestamp.proto";
+import "google/type/date.proto";
+import "acme/v1/b.proto";
+`),
+			"acme/v1/b.proto": []byte(`syntax = "proto3";
+package acme.v1;
+import "google/api/annotations.proto";
+import "google/rpc/status.proto";
+`),
+		},
+		[]string{
+			"google/api/annotations.proto",
+			"google/rpc/status.proto",
+			"google/type/date.proto",
+		},
+	)
+	testGetUndeclaredImportPaths(
+		t,
+		"declared",
+		map[string][]byte{
+			"buf.yaml": []byte(`version: v1
+deps:
+  - buf.build/googleapis/googleapis
+`),
+			"acme/v1/a.proto": []byte(`syntax = "proto3";
+package acme.v1;
+import "google/api/annotations.proto";
+`),
+		},
+		nil,
+	)
+	testGetUndeclaredImportPaths(
+		t,
+		"vendored",
+		map[string][]byte{
+			"acme/v1/a.proto": []byte(`syntax = "proto3";
+package acme.v1;
+import "google/api/annotations.proto";
+`),
+			"google/api/annotations.proto": []byte(`syntax = "proto3";
+package google.api;
+`),
+		},
+		nil,
+	)
+}
+
+func testGetUndeclaredImportPaths(
+	t *testing.T,
+	desc string,
+	files map[string][]byte,
+	expected []string,
+) {
+	t.Run(desc, func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		bucket, err := storagemem.NewReadBucket(files)
+		require.NoError(t, err)
+		module, err := bufmodule.NewModuleForBucket(ctx, bucket)
+		require.NoError(t, err)
+		importPaths, err := GetUndeclaredImportPaths(ctx, module)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, expected, importPaths)
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmodulegoogleapis
+
+import (
+	"context"
+	"io"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule"
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleprotocompile"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
+	"go.uber.org/multierr"
+)
+
+func getUndeclaredImportPaths(ctx context.Context, module bufmodule.Module) ([]string, error) {
+	if IsDeclared(module.DeclaredDirectDependencies()) {
+		return nil, nil
+	}
+	sourceFileInfos, err := module.SourceFileInfos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sourcePaths := make(map[string]struct{}, len(sourceFileInfos))
+	for _, sourceFileInfo := range sourceFileInfos {
+		sourcePaths[sourceFileInfo.Path()] = struct{}{}
+	}
+	importPaths := make(map[string]struct{})
+	for _, sourceFileInfo := range sourceFileInfos {
+		content, err := readModuleFile(ctx, module, sourceFileInfo.Path())
+		if err != nil {
+			return nil, err
+		}
+		for _, importPath := range bufmoduleprotocompile.GetImportPaths(content) {
+			if _, ok := sourcePaths[importPath]; ok || !IsImportPath(importPath) {
+				continue
+			}
+			importPaths[importPath] = struct{}{}
+		}
+	}
+	return slicesext.MapKeysToSortedSlice(importPaths), nil
+}
+
+func readModuleFile(ctx context.Context, module bufmodule.Module, path string) (_ []byte, retErr error) {
+	moduleFile, err := module.GetModuleFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, moduleFile.Close())
+	}()
+	return io.ReadAll(moduleFile)
+}
@@ -48,6 +48,13 @@ func NewParserAccessorHandler(ctx context.Context, moduleFileSet bufmodule.Modul
 	return newParserAccessorHandler(ctx, moduleFileSet)
 }
 
+// GetImportPaths returns the normalized paths imported by the .proto file content.
+//
+// Syntax errors are ignored, they are reported when the file is compiled.
+func GetImportPaths(content []byte) []string {
+	return getImportPaths(content)
+}
+
 // GetFileAnnotations gets the FileAnnotations for the ErrorWithPos errors.
 func GetFileAnnotations(
 	ctx context.Context,
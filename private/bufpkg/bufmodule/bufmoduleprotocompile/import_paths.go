@@ -0,0 +1,56 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmoduleprotocompile
+
+import (
+	"bytes"
+
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/protocompile/ast"
+	"github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/reporter"
+)
+
+// getImportPaths returns the paths imported by the .proto file content.
+//
+// Syntax errors are ignored, they are reported when the module is built.
+func getImportPaths(content []byte) []string {
+	handler := reporter.NewHandler(
+		reporter.NewReporter(
+			func(reporter.ErrorWithPos) error {
+				// never aborts
+				return nil
+			},
+			nil,
+		),
+	)
+	fileNode, _ := parser.Parse("", bytes.NewReader(content), handler)
+	if fileNode == nil {
+		return nil
+	}
+	var importPaths []string
+	for _, decl := range fileNode.Decls {
+		importNode, ok := decl.(*ast.ImportNode)
+		if !ok || importNode.Name == nil {
+			continue
+		}
+		importPath, err := normalpath.NormalizeAndValidate(importNode.Name.AsString())
+		if err != nil {
+			continue
+		}
+		importPaths = append(importPaths, importPath)
+	}
+	return importPaths
+}
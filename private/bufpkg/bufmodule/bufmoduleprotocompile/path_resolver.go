@@ -31,6 +31,7 @@ import (
 // TODO: remove when we remove ModuleFileSet
 type moduleFileReader interface {
 	GetModuleFile(context.Context, string) (bufmodule.ModuleFile, error)
+	ExcludedPathReason(context.Context, string) (string, error)
 }
 
 type parserAccessorHandler struct {
@@ -73,6 +74,17 @@ func (p *parserAccessorHandler) Open(path string) (_ io.ReadCloser, retErr error
 			}
 			return wktModuleFile, nil
 		}
+		excludedPathReason, err := p.moduleFileReader.ExcludedPathReason(p.ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if excludedPathReason != "" {
+			return nil, &fs.PathError{
+				Op:   "read",
+				Path: path,
+				Err:  &excludedPathError{reason: excludedPathReason},
+			}
+		}
 		return nil, moduleErr
 	}
 	defer func() {
@@ -140,3 +152,17 @@ func (p *parserAccessorHandler) addPath(
 	}
 	return nil
 }
+
+// excludedPathError is returned for a path that does not exist in the module because
+// it was excluded by configuration. It is treated as fs.ErrNotExist.
+type excludedPathError struct {
+	reason string
+}
+
+func (e *excludedPathError) Error() string {
+	return "file is not part of the module, " + e.reason
+}
+
+func (e *excludedPathError) Is(err error) bool {
+	return err == fs.ErrNotExist
+}
@@ -17,6 +17,7 @@ package bufmodule
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
 	"github.com/bufbuild/buf/private/bufpkg/bufcheck/bufbreaking/bufbreakingconfig"
@@ -44,6 +45,8 @@ type module struct {
 	lintConfig                 *buflintconfig.Config
 	fileSet                    bufcas.FileSet
 	workspaceDirectory         string
+	excludedPathReasonFunc     func(context.Context, string) (string, error)
+	patch                      *ModulePatch
 }
 
 func newModuleForProto(
@@ -216,6 +219,52 @@ func newModuleForFileSet(
 	return module, nil
 }
 
+func newPatchedModule(
+	ctx context.Context,
+	module Module,
+	patchReadBucket storage.ReadBucket,
+) (*module, error) {
+	patchReadBucket = storage.MapReadBucket(patchReadBucket, storage.MatchPathExt(".proto"))
+	patchedPaths, err := storage.AllPaths(ctx, patchReadBucket, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(patchedPaths)
+	digest, err := ModuleDigestB3(ctx, module)
+	if err != nil {
+		return nil, err
+	}
+	patchedModule, err := newModule(
+		storage.OverlayReadBucket(
+			patchReadBucket,
+			module.getSourceReadBucket(),
+		),
+		module.DeclaredDirectDependencies(),
+		module.DependencyModulePins(),
+		module.ModuleIdentity(),
+		module.Documentation(),
+		module.DocumentationPath(),
+		module.License(),
+		module.BreakingConfig(),
+		module.LintConfig(),
+		ModuleWithModuleIdentityAndCommit(module.ModuleIdentity(), module.Commit()),
+		ModuleWithWorkspaceDirectory(module.WorkspaceDirectory()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	patchedDigest, err := ModuleDigestB3(ctx, patchedModule)
+	if err != nil {
+		return nil, err
+	}
+	patchedModule.patch = &ModulePatch{
+		Digest:        digest,
+		PatchedDigest: patchedDigest,
+		PatchedPaths:  patchedPaths,
+	}
+	return patchedModule, nil
+}
+
 // this should only be called by other newModule constructors
 func newModule(
 	// must only contain .proto files
@@ -309,6 +358,13 @@ func (m *module) GetModuleFile(ctx context.Context, path string) (ModuleFile, er
 	return newModuleFile(fileInfo, readObjectCloser), nil
 }
 
+func (m *module) ExcludedPathReason(ctx context.Context, path string) (string, error) {
+	if m.excludedPathReasonFunc == nil {
+		return "", nil
+	}
+	return m.excludedPathReasonFunc(ctx, path)
+}
+
 func (m *module) DeclaredDirectDependencies() []bufmoduleref.ModuleReference {
 	// already sorted in constructor
 	return m.declaredDirectDependencies
@@ -355,6 +411,10 @@ func (m *module) WorkspaceDirectory() string {
 	return m.workspaceDirectory
 }
 
+func (m *module) Patch() *ModulePatch {
+	return m.patch
+}
+
 func (m *module) getSourceReadBucket() storage.ReadBucket {
 	return m.sourceReadBucket
 }
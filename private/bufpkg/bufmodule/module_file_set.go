@@ -16,6 +16,7 @@ package bufmodule
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
 )
@@ -26,6 +27,8 @@ type moduleFileSet struct {
 	Module
 
 	allModuleReadBucket moduleReadBucket
+	// allModules are the module followed by its dependencies.
+	allModules []Module
 }
 
 func newModuleFileSet(
@@ -59,6 +62,7 @@ func newModuleFileSet(
 	return &moduleFileSet{
 		Module:              module,
 		allModuleReadBucket: newMultiModuleReadBucket(moduleReadBuckets...),
+		allModules:          append([]Module{module}, dependencies...),
 	}
 }
 
@@ -110,4 +114,26 @@ func (m *moduleFileSet) GetModuleFile(ctx context.Context, path string) (ModuleF
 	return newModuleFile(fileInfo, readObjectCloser), nil
 }
 
+// ExcludedPathReason explains why the path is not part of the module or any of its
+// dependencies, so that an import of a file excluded from a dependency, for example
+// another module of the workspace, is explained as well.
+func (m *moduleFileSet) ExcludedPathReason(ctx context.Context, path string) (string, error) {
+	for _, module := range m.allModules {
+		reason, err := module.ExcludedPathReason(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		if reason == "" {
+			continue
+		}
+		// The paths in the reason are relative to the module, so say which module
+		// of the workspace it is.
+		if workspaceDirectory := module.WorkspaceDirectory(); workspaceDirectory != "" && workspaceDirectory != "." {
+			reason = fmt.Sprintf("%s of the module in directory %q", reason, workspaceDirectory)
+		}
+		return reason, nil
+	}
+	return "", nil
+}
+
 func (*moduleFileSet) isModuleFileSet() {}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"testing"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufcas"
@@ -129,3 +130,143 @@ func testNewModuleForBucket(
 		assert.Equal(t, license, module.License(), "license")
 	})
 }
+
+func TestNewPatchedModule(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	moduleIdentity, err := bufmoduleref.NewModuleIdentity("buf.build", "acme", "petapis")
+	require.NoError(t, err)
+	readBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"acme/pet/v1/pet.proto":   []byte(`syntax = "proto3";`),
+			"acme/pet/v1/store.proto": []byte(`syntax = "proto3";`),
+		},
+	)
+	require.NoError(t, err)
+	module, err := bufmodule.NewModuleForBucket(
+		ctx,
+		readBucket,
+		bufmodule.ModuleWithModuleIdentityAndCommit(moduleIdentity, "62f35d8aed1149c291d606d958a7ce32"),
+	)
+	require.NoError(t, err)
+	patchReadBucket, err := storagemem.NewReadBucket(
+		map[string][]byte{
+			"acme/pet/v1/pet.proto": []byte(`syntax = "proto3"; package acme.pet.v1;`),
+			"README.md":             []byte("not a proto file"),
+		},
+	)
+	require.NoError(t, err)
+	patchedModule, err := bufmodule.NewPatchedModule(ctx, module, patchReadBucket)
+	require.NoError(t, err)
+	assert.Equal(t, moduleIdentity, patchedModule.ModuleIdentity())
+	assert.Equal(t, module.Commit(), patchedModule.Commit())
+	fileInfos, err := patchedModule.SourceFileInfos(ctx)
+	require.NoError(t, err)
+	require.Len(t, fileInfos, 2)
+	moduleFile, err := patchedModule.GetModuleFile(ctx, "acme/pet/v1/pet.proto")
+	require.NoError(t, err)
+	data, err := io.ReadAll(moduleFile)
+	require.NoError(t, err)
+	require.NoError(t, moduleFile.Close())
+	assert.Equal(t, `syntax = "proto3"; package acme.pet.v1;`, string(data))
+	digest, err := bufmodule.ModuleDigestB3(ctx, module)
+	require.NoError(t, err)
+	patchedDigest, err := bufmodule.ModuleDigestB3(ctx, patchedModule)
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, patchedDigest)
+	assert.Nil(t, module.Patch())
+	assert.Equal(
+		t,
+		&bufmodule.ModulePatch{
+			Digest:        digest,
+			PatchedDigest: patchedDigest,
+			PatchedPaths:  []string{"acme/pet/v1/pet.proto"},
+		},
+		patchedModule.Patch(),
+	)
+}
+
+func TestWorkspacePagination(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	newModule := func(workspaceDirectory string, paths ...string) bufmodule.Module {
+		pathToData := make(map[string][]byte, len(paths))
+		for _, path := range paths {
+			pathToData[path] = []byte(`syntax = "proto3";`)
+		}
+		readBucket, err := storagemem.NewReadBucket(pathToData)
+		require.NoError(t, err)
+		module, err := bufmodule.NewModuleForBucket(
+			ctx,
+			readBucket,
+			bufmodule.ModuleWithWorkspaceDirectory(workspaceDirectory),
+		)
+		require.NoError(t, err)
+		return module
+	}
+	// The modules keep the order they were given in.
+	workspace, err := bufmodule.NewWorkspace(
+		ctx,
+		nil,
+		[]bufmodule.Module{
+			newModule("proto/c", "c/c.proto"),
+			newModule("proto/a", "a/b.proto", "a/a.proto"),
+			newModule("proto/b"),
+			newModule("proto/d", "d/d.proto"),
+		},
+	)
+	require.NoError(t, err)
+	var workspaceDirectories []string
+	for _, module := range workspace.GetModules() {
+		workspaceDirectories = append(workspaceDirectories, module.WorkspaceDirectory())
+	}
+	assert.Equal(t, []string{"proto/c", "proto/a", "proto/b", "proto/d"}, workspaceDirectories)
+
+	modules, pageToken, err := workspace.ListModules("", 3)
+	require.NoError(t, err)
+	require.Len(t, modules, 3)
+	assert.Equal(t, "proto/a", modules[1].WorkspaceDirectory())
+	require.NotEmpty(t, pageToken)
+	modules, pageToken, err = workspace.ListModules(pageToken, 3)
+	require.NoError(t, err)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "proto/d", modules[0].WorkspaceDirectory())
+	assert.Empty(t, pageToken)
+
+	var walkedPaths []string
+	require.NoError(
+		t,
+		workspace.WalkSourceFileInfos(
+			ctx,
+			func(_ bufmodule.Module, fileInfo bufmoduleref.FileInfo) error {
+				walkedPaths = append(walkedPaths, fileInfo.Path())
+				return nil
+			},
+		),
+	)
+	assert.Equal(t, []string{"c/c.proto", "a/a.proto", "a/b.proto", "d/d.proto"}, walkedPaths)
+	for _, pageSize := range []int{1, 2, 3, 4, 5} {
+		var listedPaths []string
+		pageToken := ""
+		for {
+			fileInfos, nextPageToken, err := workspace.ListSourceFileInfos(ctx, pageToken, pageSize)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(fileInfos), pageSize)
+			for _, fileInfo := range fileInfos {
+				listedPaths = append(listedPaths, fileInfo.Path())
+			}
+			if nextPageToken == "" {
+				break
+			}
+			pageToken = nextPageToken
+		}
+		assert.Equal(t, walkedPaths, listedPaths, "page size %d", pageSize)
+	}
+
+	_, _, err = workspace.ListSourceFileInfos(ctx, "", 0)
+	assert.Error(t, err)
+	_, _, err = workspace.ListSourceFileInfos(ctx, "not-a-token", 1)
+	assert.Error(t, err)
+	_, _, err = workspace.ListSourceFileInfos(ctx, "0:2", 1)
+	assert.Error(t, err)
+}
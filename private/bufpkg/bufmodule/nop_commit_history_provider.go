@@ -0,0 +1,38 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufmodule
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+)
+
+type nopCommitHistoryProvider struct{}
+
+func newNopCommitHistoryProvider() *nopCommitHistoryProvider {
+	return &nopCommitHistoryProvider{}
+}
+
+func (*nopCommitHistoryProvider) GetCommitHistoryPage(
+	_ context.Context,
+	moduleReference bufmoduleref.ModuleReference,
+	_ uint32,
+	_ string,
+	_ bool,
+) (*CommitHistoryPage, error) {
+	return nil, &fs.PathError{Op: "read", Path: moduleReference.String(), Err: fs.ErrNotExist}
+}
@@ -16,8 +16,13 @@ package bufmodule
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/bufbuild/buf/private/bufpkg/bufmodule/bufmoduleref"
+	"github.com/bufbuild/buf/private/pkg/normalpath"
 	"github.com/bufbuild/buf/private/pkg/storage"
 )
 
@@ -25,33 +30,71 @@ type workspace struct {
 	// bufmoduleref.ModuleIdentity -> bufmodule.Module
 	namedModules map[string]Module
 	allModules   []Module
+	// bufmoduleref.ModuleIdentity -> storage.ReadBucket
+	modulePatches map[string]storage.ReadBucket
 }
 
 func newWorkspace(
 	ctx context.Context,
 	namedModules map[string]Module,
 	allModules []Module,
+	options ...WorkspaceOption,
 ) (*workspace, error) {
-	pathToExternalPaths := make(map[string][]string)
+	pathToLocations := make(map[string][]*duplicatePathLocation)
 	for _, module := range allModules {
 		fileInfos, err := module.SourceFileInfos(ctx)
 		if err != nil {
 			return nil, err
 		}
 		for _, fileInfo := range fileInfos {
-			pathToExternalPaths[fileInfo.Path()] = append(pathToExternalPaths[fileInfo.Path()], fileInfo.ExternalPath())
+			pathToLocations[fileInfo.Path()] = append(
+				pathToLocations[fileInfo.Path()],
+				&duplicatePathLocation{
+					workspaceDirectory: module.WorkspaceDirectory(),
+					externalPath:       fileInfo.ExternalPath(),
+				},
+			)
 		}
 	}
-	for path, externalPaths := range pathToExternalPaths {
+	var duplicatePaths []*duplicatePath
+	for path, locations := range pathToLocations {
 		// Will be >1 even if the externalPaths are equal, we mostly care about the count
-		if len(externalPaths) > 1 {
-			return nil, storage.NewErrExistsMultipleLocations(path, externalPaths...)
+		if len(locations) > 1 {
+			sort.Slice(
+				locations,
+				func(i int, j int) bool {
+					return locations[i].externalPath < locations[j].externalPath
+				},
+			)
+			duplicatePaths = append(
+				duplicatePaths,
+				&duplicatePath{
+					path:      path,
+					locations: locations,
+				},
+			)
 		}
 	}
-	return &workspace{
+	if len(duplicatePaths) > 0 {
+		// Report every duplicate at once so that all can be fixed in one pass.
+		sort.Slice(
+			duplicatePaths,
+			func(i int, j int) bool {
+				return duplicatePaths[i].path < duplicatePaths[j].path
+			},
+		)
+		return nil, &duplicatePathsError{
+			duplicatePaths: duplicatePaths,
+		}
+	}
+	workspace := &workspace{
 		namedModules: namedModules,
 		allModules:   allModules,
-	}, nil
+	}
+	for _, option := range options {
+		option(workspace)
+	}
+	return workspace, nil
 }
 
 func (w *workspace) GetModule(moduleIdentity bufmoduleref.ModuleIdentity) (Module, bool) {
@@ -62,3 +105,143 @@ func (w *workspace) GetModule(moduleIdentity bufmoduleref.ModuleIdentity) (Modul
 func (w *workspace) GetModules() []Module {
 	return w.allModules
 }
+
+func (w *workspace) ListModules(pageToken string, pageSize int) ([]Module, string, error) {
+	moduleIndex, _, err := parseWorkspacePageToken(pageToken, pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if moduleIndex >= len(w.allModules) {
+		return nil, "", nil
+	}
+	end := moduleIndex + pageSize
+	if end >= len(w.allModules) {
+		return w.allModules[moduleIndex:], "", nil
+	}
+	return w.allModules[moduleIndex:end], newWorkspacePageToken(end, 0), nil
+}
+
+func (w *workspace) WalkSourceFileInfos(ctx context.Context, f func(Module, bufmoduleref.FileInfo) error) error {
+	for _, module := range w.allModules {
+		fileInfos, err := module.SourceFileInfos(ctx)
+		if err != nil {
+			return err
+		}
+		for _, fileInfo := range fileInfos {
+			if err := f(module, fileInfo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *workspace) ListSourceFileInfos(ctx context.Context, pageToken string, pageSize int) ([]bufmoduleref.FileInfo, string, error) {
+	moduleIndex, fileInfoIndex, err := parseWorkspacePageToken(pageToken, pageSize)
+	if err != nil {
+		return nil, "", err
+	}
+	var fileInfos []bufmoduleref.FileInfo
+	// Only the modules from the one the page starts in are read.
+	for ; moduleIndex < len(w.allModules); moduleIndex, fileInfoIndex = moduleIndex+1, 0 {
+		moduleFileInfos, err := w.allModules[moduleIndex].SourceFileInfos(ctx)
+		if err != nil {
+			return nil, "", err
+		}
+		if fileInfoIndex > len(moduleFileInfos) {
+			return nil, "", fmt.Errorf("invalid page token: %q", pageToken)
+		}
+		for ; fileInfoIndex < len(moduleFileInfos); fileInfoIndex++ {
+			if len(fileInfos) == pageSize {
+				return fileInfos, newWorkspacePageToken(moduleIndex, fileInfoIndex), nil
+			}
+			fileInfos = append(fileInfos, moduleFileInfos[fileInfoIndex])
+		}
+	}
+	return fileInfos, "", nil
+}
+
+func (w *workspace) GetModulePatch(moduleIdentity bufmoduleref.ModuleIdentity) (storage.ReadBucket, bool) {
+	patchReadBucket, ok := w.modulePatches[moduleIdentity.IdentityString()]
+	return patchReadBucket, ok
+}
+
+// newWorkspacePageToken returns the page token for the page starting at the
+// module index, and at the FileInfo index within that module.
+func newWorkspacePageToken(moduleIndex int, fileInfoIndex int) string {
+	return strconv.Itoa(moduleIndex) + ":" + strconv.Itoa(fileInfoIndex)
+}
+
+// parseWorkspacePageToken returns the module index and the FileInfo index for the
+// page token, validating the page size.
+func parseWorkspacePageToken(pageToken string, pageSize int) (int, int, error) {
+	if pageSize <= 0 {
+		return 0, 0, fmt.Errorf("page size must be positive: %d", pageSize)
+	}
+	if pageToken == "" {
+		return 0, 0, nil
+	}
+	moduleIndexString, fileInfoIndexString, ok := strings.Cut(pageToken, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid page token: %q", pageToken)
+	}
+	moduleIndex, err := strconv.Atoi(moduleIndexString)
+	if err != nil || moduleIndex < 0 {
+		return 0, 0, fmt.Errorf("invalid page token: %q", pageToken)
+	}
+	fileInfoIndex, err := strconv.Atoi(fileInfoIndexString)
+	if err != nil || fileInfoIndex < 0 {
+		return 0, 0, fmt.Errorf("invalid page token: %q", pageToken)
+	}
+	return moduleIndex, fileInfoIndex, nil
+}
+
+// duplicatePathsError is the error returned if paths exist in multiple Modules of a workspace.
+type duplicatePathsError struct {
+	// sorted by path
+	duplicatePaths []*duplicatePath
+}
+
+type duplicatePath struct {
+	path string
+	// sorted by externalPath
+	locations []*duplicatePathLocation
+}
+
+type duplicatePathLocation struct {
+	// may be empty
+	workspaceDirectory string
+	externalPath       string
+}
+
+// Error implements error.
+//
+// Each duplicate path is printed on its own line.
+func (e *duplicatePathsError) Error() string {
+	lines := make([]string, len(e.duplicatePaths))
+	for i, duplicatePath := range e.duplicatePaths {
+		locationStrings := make([]string, len(duplicatePath.locations))
+		for j, location := range duplicatePath.locations {
+			if location.workspaceDirectory == "" {
+				locationStrings[j] = location.externalPath
+				continue
+			}
+			locationStrings[j] = normalpath.Unnormalize(location.workspaceDirectory) + " (" + location.externalPath + ")"
+		}
+		lines[i] = duplicatePath.path + " exists in multiple modules: " + strings.Join(locationStrings, ", ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns an error for each duplicate path, for which storage.IsExistsMultipleLocations is true.
+func (e *duplicatePathsError) Unwrap() []error {
+	errs := make([]error, len(e.duplicatePaths))
+	for i, duplicatePath := range e.duplicatePaths {
+		externalPaths := make([]string, len(duplicatePath.locations))
+		for j, location := range duplicatePath.locations {
+			externalPaths[j] = location.externalPath
+		}
+		errs[i] = storage.NewErrExistsMultipleLocations(duplicatePath.path, externalPaths...)
+	}
+	return errs
+}
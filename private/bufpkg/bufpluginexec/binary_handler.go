@@ -37,18 +37,21 @@ type binaryHandler struct {
 	pluginPath string
 	tracer     trace.Tracer
 	pluginArgs []string
+	runOptions []command.RunOption
 }
 
 func newBinaryHandler(
 	runner command.Runner,
 	pluginPath string,
 	pluginArgs []string,
+	runOptions []command.RunOption,
 ) *binaryHandler {
 	return &binaryHandler{
 		runner:     runner,
 		pluginPath: pluginPath,
 		tracer:     otel.GetTracerProvider().Tracer("bufbuild/buf"),
 		pluginArgs: pluginArgs,
+		runOptions: runOptions,
 	}
 }
 
@@ -79,6 +82,7 @@ func (h *binaryHandler) Handle(
 	if len(h.pluginArgs) > 0 {
 		runOptions = append(runOptions, command.RunWithArgs(h.pluginArgs...))
 	}
+	runOptions = append(runOptions, h.runOptions...)
 	if err := h.runner.Run(
 		ctx,
 		h.pluginPath,
@@ -116,6 +116,17 @@ func GenerateWithProtocPath(protocPath string) GenerateOption {
 	}
 }
 
+// GenerateWithRunOptions returns a new GenerateOption that applies the given
+// command.RunOptions when executing a plugin binary, for example to set a timeout
+// or resource limits.
+//
+// These are not applied to plugins that are proxied through protoc.
+func GenerateWithRunOptions(runOptions ...command.RunOption) GenerateOption {
+	return func(generateOptions *generateOptions) {
+		generateOptions.runOptions = runOptions
+	}
+}
+
 // NewHandler returns a new Handler based on the plugin name and optional path.
 //
 // protocPath and pluginPath are optional.
@@ -139,11 +150,16 @@ func NewHandler(
 	// Initialize binary plugin handler when path is specified with optional args. Return
 	// on error as something is wrong with the supplied pluginPath option.
 	if len(handlerOptions.pluginPath) > 0 {
-		return NewBinaryHandler(runner, handlerOptions.pluginPath[0], handlerOptions.pluginPath[1:])
+		return newBinaryHandlerForPath(
+			runner,
+			handlerOptions.pluginPath[0],
+			handlerOptions.pluginPath[1:],
+			handlerOptions.runOptions,
+		)
 	}
 
 	// Initialize binary plugin handler based on plugin name.
-	if handler, err := NewBinaryHandler(runner, "protoc-gen-"+pluginName, nil); err == nil {
+	if handler, err := newBinaryHandlerForPath(runner, "protoc-gen-"+pluginName, nil, handlerOptions.runOptions); err == nil {
 		return handler, nil
 	}
 
@@ -190,21 +206,28 @@ func HandlerWithPluginPath(pluginPath ...string) HandlerOption {
 	}
 }
 
+// HandlerWithRunOptions returns a new HandlerOption that applies the given
+// command.RunOptions when executing a plugin binary.
+//
+// These are not applied to plugins that are proxied through protoc.
+func HandlerWithRunOptions(runOptions ...command.RunOption) HandlerOption {
+	return func(handlerOptions *handlerOptions) {
+		handlerOptions.runOptions = runOptions
+	}
+}
+
 // NewBinaryHandler returns a new Handler that invokes the specific plugin
 // specified by pluginPath.
 //
 // Used by other repositories.
 func NewBinaryHandler(runner command.Runner, pluginPath string, pluginArgs []string) (appproto.Handler, error) {
-	pluginPath, err := unsafeLookPath(pluginPath)
-	if err != nil {
-		return nil, err
-	}
-	return newBinaryHandler(runner, pluginPath, pluginArgs), nil
+	return newBinaryHandlerForPath(runner, pluginPath, pluginArgs, nil)
 }
 
 type handlerOptions struct {
 	protocPath string
 	pluginPath []string
+	runOptions []command.RunOption
 }
 
 func newHandlerOptions() *handlerOptions {
@@ -223,3 +246,16 @@ func unsafeLookPath(file string) (string, error) {
 	}
 	return path, err
 }
+
+func newBinaryHandlerForPath(
+	runner command.Runner,
+	pluginPath string,
+	pluginArgs []string,
+	runOptions []command.RunOption,
+) (appproto.Handler, error) {
+	pluginPath, err := unsafeLookPath(pluginPath)
+	if err != nil {
+		return nil, err
+	}
+	return newBinaryHandler(runner, pluginPath, pluginArgs, runOptions), nil
+}
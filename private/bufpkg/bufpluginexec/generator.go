@@ -57,6 +57,7 @@ func (g *generator) Generate(
 	handlerOptions := []HandlerOption{
 		HandlerWithPluginPath(generateOptions.pluginPath...),
 		HandlerWithProtocPath(generateOptions.protocPath),
+		HandlerWithRunOptions(generateOptions.runOptions...),
 	}
 	handler, err := NewHandler(
 		g.storageosProvider,
@@ -80,6 +81,7 @@ func (g *generator) Generate(
 type generateOptions struct {
 	pluginPath []string
 	protocPath string
+	runOptions []command.RunOption
 }
 
 func newGenerateOptions() *generateOptions {
@@ -22,6 +22,8 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
@@ -50,6 +52,48 @@ func NewMessage(
 	return dynamicpb.NewMessage(typedDescriptor), nil
 }
 
+// NewExtensionTypes returns the extension types within the serialized extension registry.
+//
+// The data is a binary FileDescriptorSet or Image, such as one built with
+// buf build --extension-registry. The returned types can be used as the resolver
+// when unmarshaling messages, so that extensions are not left as unknown fields.
+func NewExtensionTypes(data []byte) (*protoregistry.Types, error) {
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+	// Images are wire-compatible with FileDescriptorSets.
+	if err := proto.Unmarshal(data, fileDescriptorSet); err != nil {
+		return nil, fmt.Errorf("could not read extension registry: %w", err)
+	}
+	files, err := protodesc.NewFiles(fileDescriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("could not read extension registry: %w", err)
+	}
+	types := &protoregistry.Types{}
+	var rangeErr error
+	files.RangeFiles(func(fileDescriptor protoreflect.FileDescriptor) bool {
+		rangeErr = registerExtensions(types, fileDescriptor.Extensions(), fileDescriptor.Messages())
+		return rangeErr == nil
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	return types, nil
+}
+
+// MessageDescriptors returns the descriptors of all messages in the bufimage.Image,
+// including nested messages.
+func MessageDescriptors(image bufimage.Image) ([]protoreflect.MessageDescriptor, error) {
+	files, err := protodesc.NewFiles(bufimage.ImageToFileDescriptorSet(image))
+	if err != nil {
+		return nil, err
+	}
+	var messageDescriptors []protoreflect.MessageDescriptor
+	files.RangeFiles(func(fileDescriptor protoreflect.FileDescriptor) bool {
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, fileDescriptor.Messages())
+		return true
+	})
+	return messageDescriptors, nil
+}
+
 // ValidateTypeName validates that the typeName is well-formed, such that it has one or more
 // '.'-delimited package components and no '/' elements.
 func ValidateTypeName(typeName string) error {
@@ -58,3 +102,34 @@ func ValidateTypeName(typeName string) error {
 	}
 	return nil
 }
+
+func registerExtensions(
+	types *protoregistry.Types,
+	extensionDescriptors protoreflect.ExtensionDescriptors,
+	messageDescriptors protoreflect.MessageDescriptors,
+) error {
+	for i := 0; i < extensionDescriptors.Len(); i++ {
+		if err := types.RegisterExtension(dynamicpb.NewExtensionType(extensionDescriptors.Get(i))); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < messageDescriptors.Len(); i++ {
+		messageDescriptor := messageDescriptors.Get(i)
+		if err := registerExtensions(types, messageDescriptor.Extensions(), messageDescriptor.Messages()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendMessageDescriptors(
+	messageDescriptors []protoreflect.MessageDescriptor,
+	toAppend protoreflect.MessageDescriptors,
+) []protoreflect.MessageDescriptor {
+	for i := 0; i < toAppend.Len(); i++ {
+		messageDescriptor := toAppend.Get(i)
+		messageDescriptors = append(messageDescriptors, messageDescriptor)
+		messageDescriptors = appendMessageDescriptors(messageDescriptors, messageDescriptor.Messages())
+	}
+	return messageDescriptors
+}
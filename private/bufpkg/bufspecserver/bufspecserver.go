@@ -0,0 +1,92 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bufspecserver serves the contents of an image as a browsable JSON API.
+package bufspecserver
+
+import (
+	"net/http"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protodesc"
+)
+
+const (
+	// PackagesPath is the path that lists all packages.
+	PackagesPath = "/v1/packages"
+	// TypesPath is the path prefix that describes a single type or service by
+	// its fully-qualified name.
+	TypesPath = "/v1/types/"
+	// SearchPath is the path that searches types and services by name.
+	SearchPath = "/v1/search"
+
+	// DefaultSearchLimit is the default maximum number of search results.
+	DefaultSearchLimit = 100
+	// MaxSearchLimit is the maximum value of the limit query parameter of SearchPath.
+	MaxSearchLimit = 1000
+)
+
+// NewHandler returns a new http.Handler that serves the files of the image as JSON.
+//
+// The handler only accepts GET requests, and serves:
+//
+//	/v1/packages                       all packages, with their files and number of types.
+//	/v1/packages/{package}             the messages, enums, and services of a package.
+//	/v1/types/{name}                   the message, enum, or service with the fully-qualified name.
+//	/v1/search?q={query}&limit={limit} the messages, enums, and services whose fully-qualified
+//	                                   name contains the query, ignoring case.
+//
+// Errors are served as a JSON object with a single "error" field.
+//
+// The image must include its imports, so that the types of fields and methods can
+// be resolved. Imports are marked as such, or not served with HandlerWithExcludeImports.
+func NewHandler(logger *zap.Logger, image bufimage.Image, options ...HandlerOption) (http.Handler, error) {
+	handlerOptions := newHandlerOptions()
+	for _, option := range options {
+		option(handlerOptions)
+	}
+	files, err := protodesc.NewFiles(bufimage.ImageToFileDescriptorSet(image))
+	if err != nil {
+		return nil, err
+	}
+	importPaths := make(map[string]struct{})
+	for _, imageFile := range image.Files() {
+		if imageFile.IsImport() {
+			importPaths[imageFile.Path()] = struct{}{}
+		}
+	}
+	return newHandler(logger, newIndex(files, importPaths, handlerOptions.excludeImports)), nil
+}
+
+// HandlerOption is an option for a new Handler.
+type HandlerOption func(*handlerOptions)
+
+// HandlerWithExcludeImports returns a new HandlerOption that does not serve the
+// packages and types of the files of the image that are imports.
+//
+// Fields and methods of the other files still refer to types of imports by name.
+func HandlerWithExcludeImports() HandlerOption {
+	return func(handlerOptions *handlerOptions) {
+		handlerOptions.excludeImports = true
+	}
+}
+
+type handlerOptions struct {
+	excludeImports bool
+}
+
+func newHandlerOptions() *handlerOptions {
+	return &handlerOptions{}
+}
@@ -0,0 +1,317 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufspecserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/buf/private/bufpkg/bufimage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestPackages(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodGet, "/v1/packages")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"packages": [
+				{"name": "acme.common.v1", "files": [{"path": "acme/common/v1/common.proto", "import": true}], "type_count": 1},
+				{"name": "acme.user.v1", "files": [{"path": "acme/user/v1/user.proto"}], "type_count": 5}
+			]
+		}`,
+		body,
+	)
+}
+
+func TestExcludeImports(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodGet, "/v1/packages", HandlerWithExcludeImports())
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"packages": [
+				{"name": "acme.user.v1", "files": [{"path": "acme/user/v1/user.proto"}], "type_count": 5}
+			]
+		}`,
+		body,
+	)
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/packages/acme.common.v1", HandlerWithExcludeImports())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/types/acme.common.v1.Empty", HandlerWithExcludeImports())
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	// The files that depend on the imports are still served.
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/types/acme.user.v1.User", HandlerWithExcludeImports())
+	assert.Equal(t, http.StatusOK, statusCode)
+}
+
+func TestPackage(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodGet, "/v1/packages/acme.user.v1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"name": "acme.user.v1",
+			"files": [{"path": "acme/user/v1/user.proto"}],
+			"type_count": 5,
+			"messages": ["acme.user.v1.GetUserRequest", "acme.user.v1.User", "acme.user.v1.User.Address"],
+			"enums": ["acme.user.v1.User.Status"],
+			"services": ["acme.user.v1.UserService"]
+		}`,
+		body,
+	)
+	statusCode, body = testGet(t, http.MethodGet, "/v1/packages/acme.unknown.v1")
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.JSONEq(t, `{"error": "package \"acme.unknown.v1\" not found"}`, body)
+}
+
+func TestTypes(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodGet, "/v1/types/acme.user.v1.User")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"name": "acme.user.v1.User",
+			"kind": "message",
+			"file": "acme/user/v1/user.proto",
+			"package": "acme.user.v1",
+			"fields": [
+				{"name": "id", "number": 1, "json_name": "id", "type": "string", "cardinality": "optional"},
+				{"name": "status", "number": 2, "json_name": "status", "type": "acme.user.v1.User.Status", "cardinality": "optional", "deprecated": true},
+				{"name": "labels", "number": 3, "json_name": "labels", "type": "map<string, string>", "cardinality": "repeated"},
+				{"name": "address", "number": 4, "json_name": "address", "type": "acme.user.v1.User.Address", "cardinality": "optional"}
+			],
+			"nested_types": ["acme.user.v1.User.Address", "acme.user.v1.User.Status"]
+		}`,
+		body,
+	)
+	statusCode, body = testGet(t, http.MethodGet, "/v1/types/acme.user.v1.User.Status")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"name": "acme.user.v1.User.Status",
+			"kind": "enum",
+			"file": "acme/user/v1/user.proto",
+			"package": "acme.user.v1",
+			"values": [
+				{"name": "STATUS_UNSPECIFIED", "number": 0},
+				{"name": "STATUS_ACTIVE", "number": 1}
+			]
+		}`,
+		body,
+	)
+	statusCode, body = testGet(t, http.MethodGet, "/v1/types/acme.user.v1.UserService")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"name": "acme.user.v1.UserService",
+			"kind": "service",
+			"file": "acme/user/v1/user.proto",
+			"package": "acme.user.v1",
+			"methods": [
+				{"name": "GetUser", "input_type": "acme.user.v1.GetUserRequest", "output_type": "acme.user.v1.User", "server_streaming": true}
+			]
+		}`,
+		body,
+	)
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/types/acme.user.v1.User.LabelsEntry")
+	assert.Equal(t, http.StatusNotFound, statusCode)
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodGet, "/v1/search?q=USER")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"results": [
+				{"name": "acme.user.v1.GetUserRequest", "kind": "message"},
+				{"name": "acme.user.v1.User", "kind": "message"},
+				{"name": "acme.user.v1.User.Address", "kind": "message"},
+				{"name": "acme.user.v1.User.Status", "kind": "enum"},
+				{"name": "acme.user.v1.UserService", "kind": "service"}
+			]
+		}`,
+		body,
+	)
+	statusCode, body = testGet(t, http.MethodGet, "/v1/search?q=user&limit=2")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(
+		t,
+		`{
+			"results": [
+				{"name": "acme.user.v1.GetUserRequest", "kind": "message"},
+				{"name": "acme.user.v1.User", "kind": "message"}
+			],
+			"truncated": true
+		}`,
+		body,
+	)
+	statusCode, body = testGet(t, http.MethodGet, "/v1/search?q=nothing")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.JSONEq(t, `{"results": []}`, body)
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/search")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	statusCode, _ = testGet(t, http.MethodGet, "/v1/search?q=user&limit=0")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	statusCode, body := testGet(t, http.MethodPost, "/v1/packages")
+	assert.Equal(t, http.StatusMethodNotAllowed, statusCode)
+	assert.JSONEq(t, `{"error": "method POST is not allowed"}`, body)
+	statusCode, body = testGet(t, http.MethodGet, "/v2/packages")
+	assert.Equal(t, http.StatusNotFound, statusCode)
+	assert.JSONEq(t, `{"error": "unknown path \"/v2/packages\""}`, body)
+}
+
+func testGet(t *testing.T, method string, target string, options ...HandlerOption) (int, string) {
+	commonImageFile, err := bufimage.NewImageFile(testCommonFileDescriptorProto(), nil, "", "", true, false, nil)
+	require.NoError(t, err)
+	userImageFile, err := bufimage.NewImageFile(testUserFileDescriptorProto(), nil, "", "", false, false, nil)
+	require.NoError(t, err)
+	image, err := bufimage.NewImage([]bufimage.ImageFile{commonImageFile, userImageFile})
+	require.NoError(t, err)
+	handler, err := NewHandler(zap.NewNop(), image, options...)
+	require.NoError(t, err)
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(method, target, nil))
+	response := responseRecorder.Result()
+	defer response.Body.Close()
+	assert.Equal(t, "application/json", response.Header.Get("Content-Type"))
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, strings.TrimSpace(string(body))
+}
+
+func testCommonFileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("acme/common/v1/common.proto"),
+		Package: proto.String("acme.common.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Empty"),
+			},
+		},
+	}
+}
+
+func testUserFileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("acme/user/v1/user.proto"),
+		Package:    proto.String("acme.user.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"acme/common/v1/common.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					testField("id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+					{
+						Name:     proto.String("status"),
+						Number:   proto.Int32(2),
+						JsonName: proto.String("status"),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						TypeName: proto.String(".acme.user.v1.User.Status"),
+						Options: &descriptorpb.FieldOptions{
+							Deprecated: proto.Bool(true),
+						},
+					},
+					{
+						Name:     proto.String("labels"),
+						Number:   proto.Int32(3),
+						JsonName: proto.String("labels"),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+						TypeName: proto.String(".acme.user.v1.User.LabelsEntry"),
+					},
+					testField("address", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, ".acme.user.v1.User.Address"),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("LabelsEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							testField("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+							testField("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, ""),
+						},
+						Options: &descriptorpb.MessageOptions{
+							MapEntry: proto.Bool(true),
+						},
+					},
+					{
+						Name: proto.String("Address"),
+					},
+				},
+				EnumType: []*descriptorpb.EnumDescriptorProto{
+					{
+						Name: proto.String("Status"),
+						Value: []*descriptorpb.EnumValueDescriptorProto{
+							{Name: proto.String("STATUS_UNSPECIFIED"), Number: proto.Int32(0)},
+							{Name: proto.String("STATUS_ACTIVE"), Number: proto.Int32(1)},
+						},
+					},
+				},
+			},
+			{
+				Name: proto.String("GetUserRequest"),
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("UserService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:            proto.String("GetUser"),
+						InputType:       proto.String(".acme.user.v1.GetUserRequest"),
+						OutputType:      proto.String(".acme.user.v1.User"),
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+}
+
+func testField(name string, number int32, fieldType descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+	field := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(number),
+		JsonName: proto.String(name),
+		Type:     fieldType.Enum(),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+	}
+	if typeName != "" {
+		field.TypeName = proto.String(typeName)
+	}
+	return field
+}
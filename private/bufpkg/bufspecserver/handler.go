@@ -0,0 +1,157 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufspecserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const packagePathPrefix = PackagesPath + "/"
+
+type handler struct {
+	logger *zap.Logger
+	index  *index
+	mux    *http.ServeMux
+}
+
+func newHandler(logger *zap.Logger, index *index) *handler {
+	handler := &handler{
+		logger: logger,
+		index:  index,
+		mux:    http.NewServeMux(),
+	}
+	handler.mux.HandleFunc("/", handler.handleNotFound)
+	handler.mux.HandleFunc(PackagesPath, handler.handlePackages)
+	handler.mux.HandleFunc(packagePathPrefix, handler.handlePackage)
+	handler.mux.HandleFunc(TypesPath, handler.handleType)
+	handler.mux.HandleFunc(SearchPath, handler.handleSearch)
+	return handler
+}
+
+func (h *handler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		responseWriter.Header().Set("Allow", http.MethodGet)
+		h.writeError(responseWriter, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", request.Method))
+		return
+	}
+	h.mux.ServeHTTP(responseWriter, request)
+}
+
+func (h *handler) handleNotFound(responseWriter http.ResponseWriter, request *http.Request) {
+	h.writeError(responseWriter, http.StatusNotFound, fmt.Errorf("unknown path %q", request.URL.Path))
+}
+
+func (h *handler) handlePackages(responseWriter http.ResponseWriter, _ *http.Request) {
+	packages := make([]*outputPackage, 0, len(h.index.packages))
+	for _, indexedPackage := range h.index.packages {
+		// The contents of each package are only listed when describing a single package.
+		packages = append(
+			packages,
+			&outputPackage{
+				Name:      indexedPackage.Name,
+				Files:     indexedPackage.Files,
+				TypeCount: indexedPackage.TypeCount,
+			},
+		)
+	}
+	h.writeJSON(
+		responseWriter,
+		http.StatusOK,
+		struct {
+			Packages []*outputPackage `json:"packages"`
+		}{
+			Packages: packages,
+		},
+	)
+}
+
+func (h *handler) handlePackage(responseWriter http.ResponseWriter, request *http.Request) {
+	packageName := strings.TrimPrefix(request.URL.Path, packagePathPrefix)
+	indexedPackage, ok := h.index.nameToPackage[packageName]
+	if !ok {
+		h.writeError(responseWriter, http.StatusNotFound, fmt.Errorf("package %q not found", packageName))
+		return
+	}
+	h.writeJSON(responseWriter, http.StatusOK, indexedPackage)
+}
+
+func (h *handler) handleType(responseWriter http.ResponseWriter, request *http.Request) {
+	name := strings.TrimPrefix(request.URL.Path, TypesPath)
+	descriptor, ok := h.index.nameToDescriptor[name]
+	if !ok {
+		h.writeError(responseWriter, http.StatusNotFound, fmt.Errorf("type %q not found", name))
+		return
+	}
+	h.writeJSON(responseWriter, http.StatusOK, newOutputType(descriptor))
+}
+
+func (h *handler) handleSearch(responseWriter http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query().Get("q")
+	if query == "" {
+		h.writeError(responseWriter, http.StatusBadRequest, fmt.Errorf("query parameter %q is required", "q"))
+		return
+	}
+	limit := DefaultSearchLimit
+	if limitString := request.URL.Query().Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > MaxSearchLimit {
+			h.writeError(
+				responseWriter,
+				http.StatusBadRequest,
+				fmt.Errorf("query parameter %q must be an integer between 1 and %d, got %q", "limit", MaxSearchLimit, limitString),
+			)
+			return
+		}
+	}
+	results, truncated := h.index.search(query, limit)
+	h.writeJSON(
+		responseWriter,
+		http.StatusOK,
+		struct {
+			Results   []outputSearchResult `json:"results"`
+			Truncated bool                 `json:"truncated,omitempty"`
+		}{
+			Results:   results,
+			Truncated: truncated,
+		},
+	)
+}
+
+func (h *handler) writeError(responseWriter http.ResponseWriter, statusCode int, err error) {
+	h.writeJSON(
+		responseWriter,
+		statusCode,
+		struct {
+			Error string `json:"error"`
+		}{
+			Error: err.Error(),
+		},
+	)
+}
+
+func (h *handler) writeJSON(responseWriter http.ResponseWriter, statusCode int, value interface{}) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	if err := json.NewEncoder(responseWriter).Encode(value); err != nil {
+		h.logger.Debug("failed to write response", zap.Error(err))
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufspecserver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+const (
+	kindMessage = "message"
+	kindEnum    = "enum"
+	kindService = "service"
+)
+
+// index is the precomputed view of the files that the handler serves.
+type index struct {
+	// packages are sorted by name.
+	packages         []*outputPackage
+	nameToPackage    map[string]*outputPackage
+	nameToDescriptor map[string]protoreflect.Descriptor
+	// sortedNames are the sorted keys of nameToDescriptor.
+	sortedNames []string
+}
+
+// newIndex returns a new index of the files.
+//
+// If excludeImports is true, the files of importPaths are not indexed.
+func newIndex(files *protoregistry.Files, importPaths map[string]struct{}, excludeImports bool) *index {
+	index := &index{
+		nameToPackage:    make(map[string]*outputPackage),
+		nameToDescriptor: make(map[string]protoreflect.Descriptor),
+	}
+	files.RangeFiles(
+		func(fileDescriptor protoreflect.FileDescriptor) bool {
+			if _, isImport := importPaths[fileDescriptor.Path()]; isImport && excludeImports {
+				return true
+			}
+			index.addFile(fileDescriptor, importPaths)
+			return true
+		},
+	)
+	for _, indexedPackage := range index.packages {
+		sort.Slice(
+			indexedPackage.Files,
+			func(i int, j int) bool {
+				return indexedPackage.Files[i].Path < indexedPackage.Files[j].Path
+			},
+		)
+		sort.Strings(indexedPackage.Messages)
+		sort.Strings(indexedPackage.Enums)
+		sort.Strings(indexedPackage.Services)
+		indexedPackage.TypeCount = len(indexedPackage.Messages) + len(indexedPackage.Enums) + len(indexedPackage.Services)
+	}
+	sort.Slice(
+		index.packages,
+		func(i int, j int) bool {
+			return index.packages[i].Name < index.packages[j].Name
+		},
+	)
+	index.sortedNames = make([]string, 0, len(index.nameToDescriptor))
+	for name := range index.nameToDescriptor {
+		index.sortedNames = append(index.sortedNames, name)
+	}
+	sort.Strings(index.sortedNames)
+	return index
+}
+
+func (i *index) addFile(fileDescriptor protoreflect.FileDescriptor, importPaths map[string]struct{}) {
+	packageName := string(fileDescriptor.Package())
+	indexedPackage, ok := i.nameToPackage[packageName]
+	if !ok {
+		indexedPackage = &outputPackage{
+			Name: packageName,
+		}
+		i.nameToPackage[packageName] = indexedPackage
+		i.packages = append(i.packages, indexedPackage)
+	}
+	_, isImport := importPaths[fileDescriptor.Path()]
+	indexedPackage.Files = append(
+		indexedPackage.Files,
+		outputFile{
+			Path:   fileDescriptor.Path(),
+			Import: isImport,
+		},
+	)
+	i.addMessages(indexedPackage, fileDescriptor.Messages())
+	i.addEnums(indexedPackage, fileDescriptor.Enums())
+	services := fileDescriptor.Services()
+	for j := 0; j < services.Len(); j++ {
+		service := services.Get(j)
+		i.nameToDescriptor[string(service.FullName())] = service
+		indexedPackage.Services = append(indexedPackage.Services, string(service.FullName()))
+	}
+}
+
+func (i *index) addMessages(indexedPackage *outputPackage, messages protoreflect.MessageDescriptors) {
+	for j := 0; j < messages.Len(); j++ {
+		message := messages.Get(j)
+		if message.IsMapEntry() {
+			// Map entries are shown as the type of their map field.
+			continue
+		}
+		i.nameToDescriptor[string(message.FullName())] = message
+		indexedPackage.Messages = append(indexedPackage.Messages, string(message.FullName()))
+		i.addMessages(indexedPackage, message.Messages())
+		i.addEnums(indexedPackage, message.Enums())
+	}
+}
+
+func (i *index) addEnums(indexedPackage *outputPackage, enums protoreflect.EnumDescriptors) {
+	for j := 0; j < enums.Len(); j++ {
+		enum := enums.Get(j)
+		i.nameToDescriptor[string(enum.FullName())] = enum
+		indexedPackage.Enums = append(indexedPackage.Enums, string(enum.FullName()))
+	}
+}
+
+// search returns the names that contain the query, ignoring case, in sorted order.
+//
+// At most limit results are returned, and truncated is true if there were more.
+func (i *index) search(query string, limit int) (_ []outputSearchResult, truncated bool) {
+	query = strings.ToLower(query)
+	results := make([]outputSearchResult, 0)
+	for _, name := range i.sortedNames {
+		if !strings.Contains(strings.ToLower(name), query) {
+			continue
+		}
+		if len(results) == limit {
+			return results, true
+		}
+		results = append(
+			results,
+			outputSearchResult{
+				Name: name,
+				Kind: descriptorKind(i.nameToDescriptor[name]),
+			},
+		)
+	}
+	return results, false
+}
+
+func newOutputType(descriptor protoreflect.Descriptor) *outputType {
+	outputType := &outputType{
+		Name:       string(descriptor.FullName()),
+		Kind:       descriptorKind(descriptor),
+		File:       descriptor.ParentFile().Path(),
+		Package:    string(descriptor.ParentFile().Package()),
+		Deprecated: isDeprecated(descriptor),
+		Comments:   leadingComments(descriptor),
+	}
+	switch descriptor := descriptor.(type) {
+	case protoreflect.MessageDescriptor:
+		fields := descriptor.Fields()
+		outputType.Fields = make([]outputField, 0, fields.Len())
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			outputField := outputField{
+				Name:        string(field.Name()),
+				Number:      int32(field.Number()),
+				JSONName:    field.JSONName(),
+				Type:        fieldTypeString(field),
+				Cardinality: field.Cardinality().String(),
+				Deprecated:  isDeprecated(field),
+				Comments:    leadingComments(field),
+			}
+			if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+				outputField.Oneof = string(oneof.Name())
+			}
+			outputType.Fields = append(outputType.Fields, outputField)
+		}
+		messages := descriptor.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			if !messages.Get(i).IsMapEntry() {
+				outputType.NestedTypes = append(outputType.NestedTypes, string(messages.Get(i).FullName()))
+			}
+		}
+		enums := descriptor.Enums()
+		for i := 0; i < enums.Len(); i++ {
+			outputType.NestedTypes = append(outputType.NestedTypes, string(enums.Get(i).FullName()))
+		}
+	case protoreflect.EnumDescriptor:
+		values := descriptor.Values()
+		outputType.Values = make([]outputEnumValue, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			value := values.Get(i)
+			outputType.Values = append(
+				outputType.Values,
+				outputEnumValue{
+					Name:       string(value.Name()),
+					Number:     int32(value.Number()),
+					Deprecated: isDeprecated(value),
+					Comments:   leadingComments(value),
+				},
+			)
+		}
+	case protoreflect.ServiceDescriptor:
+		methods := descriptor.Methods()
+		outputType.Methods = make([]outputMethod, 0, methods.Len())
+		for i := 0; i < methods.Len(); i++ {
+			method := methods.Get(i)
+			outputType.Methods = append(
+				outputType.Methods,
+				outputMethod{
+					Name:            string(method.Name()),
+					InputType:       string(method.Input().FullName()),
+					OutputType:      string(method.Output().FullName()),
+					ClientStreaming: method.IsStreamingClient(),
+					ServerStreaming: method.IsStreamingServer(),
+					Deprecated:      isDeprecated(method),
+					Comments:        leadingComments(method),
+				},
+			)
+		}
+	}
+	return outputType
+}
+
+func descriptorKind(descriptor protoreflect.Descriptor) string {
+	switch descriptor.(type) {
+	case protoreflect.MessageDescriptor:
+		return kindMessage
+	case protoreflect.EnumDescriptor:
+		return kindEnum
+	case protoreflect.ServiceDescriptor:
+		return kindService
+	default:
+		return ""
+	}
+}
+
+func fieldTypeString(field protoreflect.FieldDescriptor) string {
+	if field.IsMap() {
+		return fmt.Sprintf("map<%s, %s>", fieldTypeString(field.MapKey()), fieldTypeString(field.MapValue()))
+	}
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(field.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(field.Enum().FullName())
+	default:
+		return field.Kind().String()
+	}
+}
+
+func isDeprecated(descriptor protoreflect.Descriptor) bool {
+	options, ok := descriptor.Options().(interface{ GetDeprecated() bool })
+	return ok && options.GetDeprecated()
+}
+
+func leadingComments(descriptor protoreflect.Descriptor) string {
+	return strings.TrimSpace(descriptor.ParentFile().SourceLocations().ByDescriptor(descriptor).LeadingComments)
+}
+
+type outputPackage struct {
+	Name      string       `json:"name"`
+	Files     []outputFile `json:"files"`
+	TypeCount int          `json:"type_count"`
+	// Messages, Enums, and Services are only set when describing a single package.
+	Messages []string `json:"messages,omitempty"`
+	Enums    []string `json:"enums,omitempty"`
+	Services []string `json:"services,omitempty"`
+}
+
+type outputFile struct {
+	Path   string `json:"path"`
+	Import bool   `json:"import,omitempty"`
+}
+
+type outputType struct {
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"`
+	File        string            `json:"file"`
+	Package     string            `json:"package,omitempty"`
+	Deprecated  bool              `json:"deprecated,omitempty"`
+	Comments    string            `json:"comments,omitempty"`
+	Fields      []outputField     `json:"fields,omitempty"`
+	NestedTypes []string          `json:"nested_types,omitempty"`
+	Values      []outputEnumValue `json:"values,omitempty"`
+	Methods     []outputMethod    `json:"methods,omitempty"`
+}
+
+type outputField struct {
+	Name        string `json:"name"`
+	Number      int32  `json:"number"`
+	JSONName    string `json:"json_name"`
+	Type        string `json:"type"`
+	Cardinality string `json:"cardinality"`
+	Oneof       string `json:"oneof,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Comments    string `json:"comments,omitempty"`
+}
+
+type outputEnumValue struct {
+	Name       string `json:"name"`
+	Number     int32  `json:"number"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	Comments   string `json:"comments,omitempty"`
+}
+
+type outputMethod struct {
+	Name            string `json:"name"`
+	InputType       string `json:"input_type"`
+	OutputType      string `json:"output_type"`
+	ClientStreaming bool   `json:"client_streaming,omitempty"`
+	ServerStreaming bool   `json:"server_streaming,omitempty"`
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	Comments        string `json:"comments,omitempty"`
+}
+
+type outputSearchResult struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
@@ -16,8 +16,11 @@ package bufstudioagent
 
 import (
 	"crypto/tls"
+	"io"
 	"net/http"
+	"net/textproto"
 
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
 	"github.com/rs/cors"
 	"go.uber.org/zap"
 )
@@ -31,7 +34,12 @@ func NewHandler(
 	disallowedHeaders map[string]struct{},
 	forwardHeaders map[string]string,
 	privateNetwork bool,
+	options ...HandlerOption,
 ) http.Handler {
+	handlerOptions := newHandlerOptions()
+	for _, option := range options {
+		option(handlerOptions)
+	}
 	corsHandlerOptions := cors.Options{
 		AllowedOrigins:   []string{origin},
 		AllowedMethods:   []string{http.MethodPost, http.MethodOptions},
@@ -41,7 +49,7 @@ func NewHandler(
 		corsHandlerOptions.AllowPrivateNetwork = true
 	}
 	corsHandler := cors.New(corsHandlerOptions)
-	plainHandler := corsHandler.Handler(newPlainPostHandler(logger, disallowedHeaders, forwardHeaders, tlsClientConfig))
+	plainHandler := corsHandler.Handler(newPlainPostHandler(logger, disallowedHeaders, forwardHeaders, tlsClientConfig, handlerOptions.recorder))
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -65,3 +73,103 @@ func NewHandler(
 	})
 	return mux
 }
+
+// HandlerOption is an option for a new Handler.
+type HandlerOption func(*handlerOptions)
+
+// HandlerWithRecorder returns a new HandlerOption that records every forwarded
+// request and its response with the Recorder.
+//
+// Recording errors are logged and do not fail the forwarded request.
+func HandlerWithRecorder(recorder Recorder) HandlerOption {
+	return func(handlerOptions *handlerOptions) {
+		handlerOptions.recorder = recorder
+	}
+}
+
+// Exchange is a request forwarded by the agent and the response of the target
+// server.
+type Exchange struct {
+	// Target is the URL the request was forwarded to.
+	Target string
+	// RequestHeaders are the headers sent to the target server, including the Content-Type.
+	RequestHeaders http.Header
+	// RequestBody is the encoded request message.
+	RequestBody []byte
+	// ResponseHeaders are the headers received from the target server.
+	ResponseHeaders http.Header
+	// ResponseTrailers are the trailers received from the target server.
+	ResponseTrailers http.Header
+	// ResponseBody is the encoded response message.
+	ResponseBody []byte
+	// Err is the error returned for the request, if any.
+	Err error
+}
+
+// Recorder records Exchanges.
+type Recorder interface {
+	// Record records the Exchange.
+	//
+	// Record is safe to call concurrently.
+	Record(exchange *Exchange) error
+}
+
+// NewRecorder returns a new Recorder that writes a session file to the writer.
+//
+// The session file has one JSON object per line, each describing one Exchange
+// along with the buf curl invocation that replays the request. If the method
+// can be resolved with the schema set by RecorderWithSchema, the request and
+// response types are recorded and the messages are recorded as JSON.
+func NewRecorder(writer io.Writer, options ...RecorderOption) Recorder {
+	return newRecorder(writer, options...)
+}
+
+// RecorderOption is an option for a new Recorder.
+type RecorderOption func(*recorder)
+
+// RecorderWithSchema returns a new RecorderOption that resolves the request
+// and response types of methods with the resolver.
+//
+// The schema is the input that the resolver was built from, and is passed as
+// the --schema flag of the recorded buf curl invocations.
+func RecorderWithSchema(schema string, resolver protoencoding.Resolver) RecorderOption {
+	return func(recorder *recorder) {
+		recorder.schema = schema
+		recorder.resolver = resolver
+	}
+}
+
+// RecorderWithRedactedFields returns a new RecorderOption that clears the
+// fields with the given fully-qualified names, such as "acme.user.v1.LoginRequest.password",
+// from recorded messages.
+//
+// When any fields are redacted, messages that cannot be decoded with the schema
+// are not recorded.
+func RecorderWithRedactedFields(fieldNames ...string) RecorderOption {
+	return func(recorder *recorder) {
+		for _, fieldName := range fieldNames {
+			recorder.redactedFields[fieldName] = struct{}{}
+		}
+	}
+}
+
+// RecorderWithRedactedHeaders returns a new RecorderOption that replaces the
+// values of the given headers in recorded requests and responses.
+//
+// The Authorization, Cookie, Proxy-Authorization, and Set-Cookie headers are
+// always redacted.
+func RecorderWithRedactedHeaders(headerNames ...string) RecorderOption {
+	return func(recorder *recorder) {
+		for _, headerName := range headerNames {
+			recorder.redactedHeaders[textproto.CanonicalMIMEHeaderKey(headerName)] = struct{}{}
+		}
+	}
+}
+
+type handlerOptions struct {
+	recorder Recorder
+}
+
+func newHandlerOptions() *handlerOptions {
+	return &handlerOptions{}
+}
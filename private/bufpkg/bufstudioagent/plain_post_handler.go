@@ -57,6 +57,8 @@ type plainPostHandler struct {
 	H2CClient           *http.Client
 	DisallowedHeaders   map[string]struct{}
 	ForwardHeaders      map[string]string
+	// Recorder is optional.
+	Recorder Recorder
 }
 
 func newPlainPostHandler(
@@ -64,6 +66,7 @@ func newPlainPostHandler(
 	disallowedHeaders map[string]struct{},
 	forwardHeaders map[string]string,
 	tlsClientConfig *tls.Config,
+	recorder Recorder,
 ) *plainPostHandler {
 	canonicalDisallowedHeaders := make(map[string]struct{}, len(disallowedHeaders))
 	for k := range disallowedHeaders {
@@ -87,6 +90,7 @@ func newPlainPostHandler(
 		},
 		Logger:              logger,
 		MaxMessageSizeBytes: MaxMessageSizeBytesDefault,
+		Recorder:            recorder,
 		TLSClient: &http.Client{
 			Transport: &http2.Transport{
 				TLSClientConfig: tlsClientConfig,
@@ -169,6 +173,7 @@ func (i *plainPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	)
 	// TODO(rvanginkel) should this context be cloned to remove attached values (but keep timeout)?
 	response, err := client.CallUnary(r.Context(), request)
+	i.record(envelopeRequest, request, response, err)
 	if err != nil {
 		// We need to differentiate client errors from server errors. In the former,
 		// trigger a `StatusBadGateway` result, and in the latter surface whatever
@@ -213,6 +218,38 @@ func (i *plainPostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (i *plainPostHandler) record(
+	envelopeRequest *studiov1alpha1.InvokeRequest,
+	request *connect.Request[bytes.Buffer],
+	response *connect.Response[bytes.Buffer],
+	err error,
+) {
+	if i.Recorder == nil {
+		return
+	}
+	exchange := &Exchange{
+		Target:         envelopeRequest.GetTarget(),
+		RequestHeaders: request.Header(),
+		RequestBody:    envelopeRequest.GetBody(),
+		Err:            err,
+	}
+	if response != nil {
+		exchange.ResponseHeaders = response.Header()
+		exchange.ResponseTrailers = response.Trailer()
+		exchange.ResponseBody = response.Msg.Bytes()
+	}
+	if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+		exchange.ResponseTrailers = connectErr.Meta()
+	}
+	if err := i.Recorder.Record(exchange); err != nil {
+		i.Logger.Warn(
+			"record_error",
+			zap.String("target", exchange.Target),
+			zap.Error(err),
+		)
+	}
+}
+
 func connectClientOptionsFromContentType(contentType string) ([]connect.ClientOption, error) {
 	switch contentType {
 	case "application/grpc", "application/grpc+proto":
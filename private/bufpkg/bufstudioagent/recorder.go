@@ -0,0 +1,355 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufstudioagent
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// redactedValue replaces the values of redacted headers in the session file.
+const redactedValue = "<redacted>"
+
+// defaultRedactedHeaders are the headers that carry credentials. They are
+// always redacted in addition to the headers given with RecorderWithRedactedHeaders.
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+}
+
+type recorder struct {
+	writer          io.Writer
+	schema          string
+	resolver        protoencoding.Resolver
+	redactedFields  map[string]struct{}
+	redactedHeaders map[string]struct{}
+	now             func() time.Time
+	lock            sync.Mutex
+}
+
+func newRecorder(writer io.Writer, options ...RecorderOption) *recorder {
+	recorder := &recorder{
+		writer:          writer,
+		redactedFields:  make(map[string]struct{}),
+		redactedHeaders: make(map[string]struct{}, len(defaultRedactedHeaders)),
+		now:             time.Now,
+	}
+	for _, headerName := range defaultRedactedHeaders {
+		recorder.redactedHeaders[headerName] = struct{}{}
+	}
+	for _, option := range options {
+		option(recorder)
+	}
+	return recorder
+}
+
+func (r *recorder) Record(exchange *Exchange) error {
+	sessionRecord, err := r.newSessionRecord(exchange)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(sessionRecord)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	_, err = r.writer.Write(append(data, '\n'))
+	return err
+}
+
+func (r *recorder) newSessionRecord(exchange *Exchange) (*sessionRecord, error) {
+	targetURL, err := url.Parse(exchange.Target)
+	if err != nil {
+		return nil, err
+	}
+	contentType := exchange.RequestHeaders.Get("Content-Type")
+	protocol, codecName, err := protocolAndCodecNameForContentType(contentType)
+	if err != nil {
+		return nil, err
+	}
+	sessionRecord := &sessionRecord{
+		Time:             r.now().UTC(),
+		Target:           exchange.Target,
+		Protocol:         protocol,
+		ContentType:      contentType,
+		RequestHeaders:   r.redactHeaders(exchange.RequestHeaders),
+		ResponseHeaders:  r.redactHeaders(exchange.ResponseHeaders),
+		ResponseTrailers: r.redactHeaders(exchange.ResponseTrailers),
+	}
+	if exchange.Err != nil {
+		sessionRecord.ErrorCode = connect.CodeOf(exchange.Err).String()
+		sessionRecord.ErrorMessage = exchange.Err.Error()
+		if connectErr := new(connect.Error); errors.As(exchange.Err, &connectErr) {
+			sessionRecord.ErrorMessage = connectErr.Message()
+		}
+	}
+	methodDescriptor := r.findMethodDescriptor(targetURL.Path)
+	var requestMessageDescriptor protoreflect.MessageDescriptor
+	var responseMessageDescriptor protoreflect.MessageDescriptor
+	if methodDescriptor != nil {
+		requestMessageDescriptor = methodDescriptor.Input()
+		responseMessageDescriptor = methodDescriptor.Output()
+		sessionRecord.RequestType = string(requestMessageDescriptor.FullName())
+		sessionRecord.ResponseType = string(responseMessageDescriptor.FullName())
+	}
+	redactedFieldNames := make(map[string]struct{})
+	sessionRecord.RequestBody, sessionRecord.RequestBodyBase64 = r.recordBody(
+		exchange.RequestBody,
+		codecName,
+		requestMessageDescriptor,
+		redactedFieldNames,
+	)
+	if exchange.Err == nil {
+		sessionRecord.ResponseBody, sessionRecord.ResponseBodyBase64 = r.recordBody(
+			exchange.ResponseBody,
+			codecName,
+			responseMessageDescriptor,
+			redactedFieldNames,
+		)
+	}
+	for redactedFieldName := range redactedFieldNames {
+		sessionRecord.RedactedFields = append(sessionRecord.RedactedFields, redactedFieldName)
+	}
+	sort.Strings(sessionRecord.RedactedFields)
+	sessionRecord.Curl = r.curlArgs(targetURL, protocol, sessionRecord.RequestHeaders, sessionRecord.RequestBody)
+	return sessionRecord, nil
+}
+
+// findMethodDescriptor returns the MethodDescriptor for the procedure path, or
+// nil if there is no resolver or the method cannot be resolved.
+func (r *recorder) findMethodDescriptor(path string) protoreflect.MethodDescriptor {
+	if r.resolver == nil {
+		return nil
+	}
+	path = strings.TrimSuffix(path, "/")
+	methodIndex := strings.LastIndex(path, "/")
+	if methodIndex <= 0 {
+		return nil
+	}
+	serviceIndex := strings.LastIndex(path[:methodIndex], "/")
+	serviceName := protoreflect.FullName(path[serviceIndex+1 : methodIndex])
+	methodName := protoreflect.Name(path[methodIndex+1:])
+	descriptor, err := r.resolver.FindDescriptorByName(serviceName)
+	if err != nil {
+		return nil
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil
+	}
+	return serviceDescriptor.Methods().ByName(methodName)
+}
+
+// recordBody returns the body as JSON if it can be decoded with the schema, and
+// as base64 otherwise.
+//
+// If any fields are configured to be redacted, a body that cannot be decoded is
+// not recorded at all, as it cannot be redacted.
+func (r *recorder) recordBody(
+	body []byte,
+	codecName string,
+	messageDescriptor protoreflect.MessageDescriptor,
+	redactedFieldNames map[string]struct{},
+) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if messageDescriptor != nil {
+		message := dynamicpb.NewMessage(messageDescriptor)
+		var unmarshaler protoencoding.Unmarshaler
+		switch codecName {
+		case "json":
+			unmarshaler = protoencoding.NewJSONUnmarshaler(r.resolver)
+		default:
+			unmarshaler = protoencoding.NewWireUnmarshaler(r.resolver)
+		}
+		if err := unmarshaler.Unmarshal(body, message); err == nil {
+			redactMessage(message, r.redactedFields, redactedFieldNames)
+			if data, err := protoencoding.NewJSONMarshaler(r.resolver).Marshal(message); err == nil {
+				return compactJSON(data), ""
+			}
+		}
+	}
+	if len(r.redactedFields) > 0 {
+		return nil, ""
+	}
+	if codecName == "json" && json.Valid(body) {
+		return compactJSON(body), ""
+	}
+	return nil, base64.StdEncoding.EncodeToString(body)
+}
+
+func (r *recorder) redactHeaders(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	redactedHeader := make(http.Header, len(header))
+	for key, values := range header {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if _, ok := r.redactedHeaders[key]; ok {
+			redactedHeader[key] = []string{redactedValue}
+			continue
+		}
+		redactedHeader[key] = append(redactedHeader[key], values...)
+	}
+	return redactedHeader
+}
+
+// curlArgs returns the buf curl invocation that replays the request.
+func (r *recorder) curlArgs(
+	targetURL *url.URL,
+	protocol string,
+	requestHeaders http.Header,
+	requestBody json.RawMessage,
+) []string {
+	args := []string{"buf", "curl", "--protocol", protocol}
+	if targetURL.Scheme == "http" {
+		args = append(args, "--http2-prior-knowledge")
+	}
+	if r.schema != "" {
+		args = append(args, "--schema", r.schema)
+	}
+	keys := make([]string, 0, len(requestHeaders))
+	for key := range requestHeaders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !isReplayableHeader(key) {
+			continue
+		}
+		for _, value := range requestHeaders[key] {
+			args = append(args, "--header", key+": "+value)
+		}
+	}
+	if len(requestBody) > 0 {
+		args = append(args, "--data", string(requestBody))
+	}
+	return append(args, targetURL.String())
+}
+
+// sessionRecord is a single line of the session file.
+type sessionRecord struct {
+	Time               time.Time       `json:"time"`
+	Target             string          `json:"target"`
+	Protocol           string          `json:"protocol"`
+	ContentType        string          `json:"content_type"`
+	RequestType        string          `json:"request_type,omitempty"`
+	ResponseType       string          `json:"response_type,omitempty"`
+	RequestHeaders     http.Header     `json:"request_headers,omitempty"`
+	RequestBody        json.RawMessage `json:"request_body,omitempty"`
+	RequestBodyBase64  string          `json:"request_body_base64,omitempty"`
+	ResponseHeaders    http.Header     `json:"response_headers,omitempty"`
+	ResponseTrailers   http.Header     `json:"response_trailers,omitempty"`
+	ResponseBody       json.RawMessage `json:"response_body,omitempty"`
+	ResponseBodyBase64 string          `json:"response_body_base64,omitempty"`
+	ErrorCode          string          `json:"error_code,omitempty"`
+	ErrorMessage       string          `json:"error_message,omitempty"`
+	RedactedFields     []string        `json:"redacted_fields,omitempty"`
+	Curl               []string        `json:"curl"`
+}
+
+// redactMessage clears all fields of the message, including those of nested
+// messages, whose fully-qualified names are in redactedFields. The names of the
+// cleared fields are added to redactedFieldNames.
+func redactMessage(
+	message protoreflect.Message,
+	redactedFields map[string]struct{},
+	redactedFieldNames map[string]struct{},
+) {
+	if len(redactedFields) == 0 {
+		return
+	}
+	message.Range(func(fieldDescriptor protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		fieldName := string(fieldDescriptor.FullName())
+		if _, ok := redactedFields[fieldName]; ok {
+			message.Clear(fieldDescriptor)
+			redactedFieldNames[fieldName] = struct{}{}
+			return true
+		}
+		switch {
+		case fieldDescriptor.IsMap():
+			if fieldDescriptor.MapValue().Message() != nil {
+				value.Map().Range(func(_ protoreflect.MapKey, mapValue protoreflect.Value) bool {
+					redactMessage(mapValue.Message(), redactedFields, redactedFieldNames)
+					return true
+				})
+			}
+		case fieldDescriptor.Message() != nil && fieldDescriptor.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				redactMessage(list.Get(i).Message(), redactedFields, redactedFieldNames)
+			}
+		case fieldDescriptor.Message() != nil:
+			redactMessage(value.Message(), redactedFields, redactedFieldNames)
+		}
+		return true
+	})
+}
+
+func protocolAndCodecNameForContentType(contentType string) (string, string, error) {
+	switch contentType {
+	case "application/grpc", "application/grpc+proto":
+		return connect.ProtocolGRPC, "proto", nil
+	case "application/grpc+json":
+		return connect.ProtocolGRPC, "json", nil
+	case "application/json":
+		return connect.ProtocolConnect, "json", nil
+	case "application/proto":
+		return connect.ProtocolConnect, "proto", nil
+	default:
+		return "", "", fmt.Errorf("unknown Content-Type: %q", contentType)
+	}
+}
+
+// isReplayableHeader returns true if the header should be passed to buf curl,
+// that is if it is not set by the protocol itself.
+func isReplayableHeader(key string) bool {
+	switch key = strings.ToLower(key); {
+	case key == "content-type", key == "content-encoding", key == "accept-encoding", key == "te", key == "user-agent":
+		return false
+	case strings.HasPrefix(key, "grpc-"), strings.HasPrefix(key, "connect-"):
+		return false
+	default:
+		return true
+	}
+}
+
+func compactJSON(data []byte) json.RawMessage {
+	buffer := &bytes.Buffer{}
+	if err := json.Compact(buffer, data); err != nil {
+		return data
+	}
+	return buffer.Bytes()
+}
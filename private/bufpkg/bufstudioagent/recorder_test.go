@@ -0,0 +1,231 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bufstudioagent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	studiov1alpha1 "github.com/bufbuild/buf/private/gen/proto/go/buf/alpha/studio/v1alpha1"
+	"github.com/bufbuild/buf/private/pkg/protoencoding"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestPlainPostHandlerRecorder(t *testing.T) {
+	upstreamServer := newTestConnectServer(t, false)
+	defer upstreamServer.Close()
+	sessionBuffer := &bytes.Buffer{}
+	agentServer := httptest.NewTLSServer(
+		NewHandler(
+			zaptest.NewLogger(t),
+			"https://example.buf.build",
+			nil,
+			nil,
+			nil,
+			false,
+			HandlerWithRecorder(
+				NewRecorder(
+					sessionBuffer,
+					RecorderWithRedactedHeaders("x-api-key"),
+				),
+			),
+		),
+	)
+	defer agentServer.Close()
+
+	requestProto := &studiov1alpha1.InvokeRequest{
+		Target: upstreamServer.URL + echoPath,
+		Headers: goHeadersToProtoHeaders(http.Header{
+			"Content-Type":  []string{"application/proto"},
+			"Authorization": []string{"Bearer secret"},
+			"Cookie":        []string{"session=secret"},
+			"X-Api-Key":     []string{"secret"},
+			"X-Request-Id":  []string{"1"},
+		}),
+		Body: []byte("echothis"),
+	}
+	request, err := http.NewRequest(http.MethodPost, agentServer.URL, bytes.NewReader(protoMarshalBase64(t, requestProto)))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "text/plain")
+	response, err := agentServer.Client().Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	record := &sessionRecord{}
+	require.NoError(t, json.Unmarshal(sessionBuffer.Bytes(), record))
+	assert.Equal(t, upstreamServer.URL+echoPath, record.Target)
+	assert.Equal(t, connect.ProtocolConnect, record.Protocol)
+	assert.Equal(t, []string{redactedValue}, record.RequestHeaders.Values("Authorization"))
+	assert.Equal(t, []string{redactedValue}, record.RequestHeaders.Values("Cookie"))
+	assert.Equal(t, []string{redactedValue}, record.RequestHeaders.Values("X-Api-Key"))
+	assert.Equal(t, []string{"1"}, record.RequestHeaders.Values("X-Request-Id"))
+	assert.Equal(t, "ZWNob3RoaXM=", record.RequestBodyBase64)
+	assert.Equal(t, "ZWNobzogZWNob3RoaXM=", record.ResponseBodyBase64)
+	assert.Equal(
+		t,
+		[]string{
+			"buf", "curl", "--protocol", "connect", "--http2-prior-knowledge",
+			"--header", "Authorization: " + redactedValue,
+			"--header", "Cookie: " + redactedValue,
+			"--header", "X-Api-Key: " + redactedValue,
+			"--header", "X-Request-Id: 1",
+			upstreamServer.URL + echoPath,
+		},
+		record.Curl,
+	)
+}
+
+func TestRecorderWithSchema(t *testing.T) {
+	resolver, err := protoencoding.NewResolver(
+		&descriptorpb.FileDescriptorProto{
+			Name:    proto.String("acme/user/v1/user.proto"),
+			Package: proto.String("acme.user.v1"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{
+				{
+					Name: proto.String("Credentials"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						newTestStringField("username", 1),
+						newTestStringField("password", 2),
+					},
+				},
+				{
+					Name: proto.String("LoginRequest"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						{
+							Name:     proto.String("credentials"),
+							JsonName: proto.String("credentials"),
+							Number:   proto.Int32(1),
+							Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+							Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+							TypeName: proto.String(".acme.user.v1.Credentials"),
+						},
+					},
+				},
+				{
+					Name: proto.String("LoginResponse"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						newTestStringField("token", 1),
+					},
+				},
+			},
+			Service: []*descriptorpb.ServiceDescriptorProto{
+				{
+					Name: proto.String("UserService"),
+					Method: []*descriptorpb.MethodDescriptorProto{
+						{
+							Name:       proto.String("Login"),
+							InputType:  proto.String(".acme.user.v1.LoginRequest"),
+							OutputType: proto.String(".acme.user.v1.LoginResponse"),
+						},
+					},
+				},
+			},
+		},
+	)
+	require.NoError(t, err)
+	requestMessageType, err := resolver.FindMessageByName("acme.user.v1.LoginRequest")
+	require.NoError(t, err)
+	credentialsMessageType, err := resolver.FindMessageByName("acme.user.v1.Credentials")
+	require.NoError(t, err)
+	credentials := dynamicpb.NewMessage(credentialsMessageType.Descriptor())
+	credentials.Set(credentials.Descriptor().Fields().ByName("username"), protoreflect.ValueOfString("alice"))
+	credentials.Set(credentials.Descriptor().Fields().ByName("password"), protoreflect.ValueOfString("hunter2"))
+	requestMessage := dynamicpb.NewMessage(requestMessageType.Descriptor())
+	requestMessage.Set(requestMessage.Descriptor().Fields().ByName("credentials"), protoreflect.ValueOfMessage(credentials))
+	requestBody, err := protoencoding.NewWireMarshaler().Marshal(requestMessage)
+	require.NoError(t, err)
+
+	sessionBuffer := &bytes.Buffer{}
+	recorder := NewRecorder(
+		sessionBuffer,
+		RecorderWithSchema("buf.build/acme/user", resolver),
+		RecorderWithRedactedFields("acme.user.v1.Credentials.password"),
+	)
+	require.NoError(
+		t,
+		recorder.Record(
+			&Exchange{
+				Target: "https://example.com/acme.user.v1.UserService/Login",
+				RequestHeaders: http.Header{
+					"Content-Type": []string{"application/grpc"},
+				},
+				RequestBody: requestBody,
+				Err:         connect.NewError(connect.CodeUnauthenticated, errors.New("invalid credentials")),
+			},
+		),
+	)
+	record := &sessionRecord{}
+	require.NoError(t, json.Unmarshal(sessionBuffer.Bytes(), record))
+	assert.Equal(t, connect.ProtocolGRPC, record.Protocol)
+	assert.Equal(t, "acme.user.v1.LoginRequest", record.RequestType)
+	assert.Equal(t, "acme.user.v1.LoginResponse", record.ResponseType)
+	assert.JSONEq(t, `{"credentials":{"username":"alice"}}`, string(record.RequestBody))
+	assert.Empty(t, record.RequestBodyBase64)
+	assert.Equal(t, []string{"acme.user.v1.Credentials.password"}, record.RedactedFields)
+	assert.Equal(t, connect.CodeUnauthenticated.String(), record.ErrorCode)
+	assert.Equal(t, "invalid credentials", record.ErrorMessage)
+	assert.Equal(
+		t,
+		[]string{
+			"buf", "curl", "--protocol", "grpc", "--schema", "buf.build/acme/user",
+			"--data", `{"credentials":{"username":"alice"}}`,
+			"https://example.com/acme.user.v1.UserService/Login",
+		},
+		record.Curl,
+	)
+
+	// A body that cannot be decoded is not recorded if fields are redacted.
+	sessionBuffer.Reset()
+	require.NoError(
+		t,
+		recorder.Record(
+			&Exchange{
+				Target: "https://example.com/acme.user.v1.UserService/Unknown",
+				RequestHeaders: http.Header{
+					"Content-Type": []string{"application/proto"},
+				},
+				RequestBody: requestBody,
+			},
+		),
+	)
+	record = &sessionRecord{}
+	require.NoError(t, json.Unmarshal(sessionBuffer.Bytes(), record))
+	assert.Empty(t, record.RequestType)
+	assert.Empty(t, record.RequestBody)
+	assert.Empty(t, record.RequestBodyBase64)
+}
+
+func newTestStringField(name string, number int32) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+	}
+}
@@ -314,7 +314,11 @@ func Main(ctx context.Context, f func(context.Context, Container) error) {
 func Run(ctx context.Context, container Container, f func(context.Context, Container) error) error {
 	ctx, cancel := interrupt.WithCancel(ctx)
 	defer cancel()
-	if err := f(ctx, container); err != nil {
+	err := f(ctx, container)
+	if errors.Is(context.Cause(ctx), interrupt.ErrInterrupted) {
+		printInterrupted(container, interrupt.InterruptedPhases(ctx))
+	}
+	if err != nil {
 		printError(container, err)
 		return err
 	}
@@ -335,10 +339,25 @@ func NewErrorf(exitCode int, format string, args ...interface{}) error {
 	return newAppError(exitCode, fmt.Sprintf(format, args...))
 }
 
+// WrapError returns a new error that contains an exit code and wraps the given error.
+//
+// The message of the returned error is the message of the given error, and the
+// given error can be recovered with errors.Is and errors.As. This is used to
+// change the exit code of an existing error, including one created by NewError.
+//
+// The exit code cannot be 0. If err is nil, this returns nil.
+func WrapError(exitCode int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return newAppErrorForCause(exitCode, err)
+}
+
 // GetExitCode gets the exit code.
 //
 // If err == nil, this returns 0.
 // If err was created by this package, this returns the exit code from the error.
+// If err wraps multiple errors created by this package, the outermost exit code is used.
 // Otherwise, this returns 1.
 func GetExitCode(err error) int {
 	if err == nil {
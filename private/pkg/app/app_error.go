@@ -16,11 +16,14 @@ package app
 
 import (
 	"fmt"
+	"strings"
 )
 
 type appError struct {
 	exitCode int
 	message  string
+	// cause is the wrapped error, if any.
+	cause error
 }
 
 func newAppError(exitCode int, message string) *appError {
@@ -38,10 +41,30 @@ func newAppError(exitCode int, message string) *appError {
 	}
 }
 
+func newAppErrorForCause(exitCode int, cause error) *appError {
+	appError := newAppError(exitCode, cause.Error())
+	appError.cause = cause
+	return appError
+}
+
 func (e *appError) Error() string {
 	return e.message
 }
 
+func (e *appError) Unwrap() error {
+	return e.cause
+}
+
+// printInterrupted prints that the run was interrupted, and the phases that
+// were running at the time.
+func printInterrupted(container StderrContainer, phases []string) {
+	if len(phases) == 0 {
+		_, _ = fmt.Fprintln(container.Stderr(), "Interrupted.")
+		return
+	}
+	_, _ = fmt.Fprintf(container.Stderr(), "Interrupted while %s.\n", strings.Join(phases, ", "))
+}
+
 func printError(container StderrContainer, err error) {
 	if errString := err.Error(); errString != "" {
 		_, _ = fmt.Fprintln(container.Stderr(), errString)
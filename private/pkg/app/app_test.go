@@ -15,6 +15,8 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -160,3 +162,18 @@ func TestEnvBool(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, true, val)
 }
+
+func TestWrapError(t *testing.T) {
+	t.Parallel()
+	assert.NoError(t, WrapError(2, nil))
+	cause := errors.New("foo")
+	err := WrapError(2, cause)
+	assert.Equal(t, "foo", err.Error())
+	assert.Equal(t, 2, GetExitCode(err))
+	assert.ErrorIs(t, err, cause)
+	appErr := NewError(100, "")
+	err = WrapError(101, fmt.Errorf("bar: %w", appErr))
+	assert.Equal(t, 101, GetExitCode(err))
+	assert.ErrorIs(t, err, appErr)
+	assert.Equal(t, 1, GetExitCode(WrapError(0, cause)))
+}
@@ -96,6 +96,14 @@ func Run(ctx context.Context, container app.Container, command *Command) error {
 	return app.Run(ctx, container, newRunFunc(command))
 }
 
+// CommandPath returns the full path of the command being run, such as "buf mod update".
+//
+// Returns empty if the context was not created by Run.
+func CommandPath(ctx context.Context) string {
+	commandPath, _ := ctx.Value(commandPathContextKey{}).(string)
+	return commandPath
+}
+
 // BindMultiple is a convenience function for binding multiple flag functions.
 func BindMultiple(bindFuncs ...func(*pflag.FlagSet)) func(*pflag.FlagSet) {
 	return func(flagSet *pflag.FlagSet) {
@@ -105,6 +113,8 @@ func BindMultiple(bindFuncs ...func(*pflag.FlagSet)) func(*pflag.FlagSet) {
 	}
 }
 
+type commandPathContextKey struct{}
+
 func newRunFunc(command *Command) func(context.Context, app.Container) error {
 	return func(ctx context.Context, container app.Container) error {
 		return run(ctx, container, command)
@@ -299,8 +309,11 @@ func commandToCobra(
 		cobraCommand.PersistentFlags().SetNormalizeFunc(normalizeFunc(command.NormalizePersistentFlag))
 	}
 	if command.Run != nil {
-		cobraCommand.Run = func(_ *cobra.Command, args []string) {
-			runErr := command.Run(ctx, app.NewContainerForArgs(container, args...))
+		cobraCommand.Run = func(cmd *cobra.Command, args []string) {
+			runErr := command.Run(
+				context.WithValue(ctx, commandPathContextKey{}, cmd.CommandPath()),
+				app.NewContainerForArgs(container, args...),
+			)
 			if asErr := (&invalidArgumentError{}); errors.As(runErr, &asErr) {
 				// Print usage for failing command if an args error is returned.
 				// This has to be done at this level since the usage must relate
@@ -110,6 +110,40 @@ func TestError(t *testing.T) {
 	require.Equal(t, app.NewError(5, "bar"), Run(context.Background(), container, rootCommand))
 }
 
+func TestCommandPath(t *testing.T) {
+	t.Parallel()
+	var commandPath string
+	rootCommand := &Command{
+		Use: "test",
+		SubCommands: []*Command{
+			{
+				Use: "foo",
+				SubCommands: []*Command{
+					{
+						Use: "bar",
+						Run: func(ctx context.Context, container app.Container) error {
+							commandPath = CommandPath(ctx)
+							return nil
+						},
+					},
+				},
+			},
+		},
+	}
+	container := app.NewContainer(
+		nil,
+		nil,
+		nil,
+		nil,
+		"test",
+		"foo",
+		"bar",
+	)
+	require.NoError(t, Run(context.Background(), container, rootCommand))
+	assert.Equal(t, "test foo bar", commandPath)
+	assert.Equal(t, "", CommandPath(context.Background()))
+}
+
 func TestVersionToStdout(t *testing.T) {
 	t.Parallel()
 	version := "0.0.1-dev"
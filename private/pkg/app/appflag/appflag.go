@@ -22,6 +22,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/app/applog"
 	"github.com/bufbuild/buf/private/pkg/app/appname"
+	"github.com/bufbuild/buf/private/pkg/app/appprogress"
 	"github.com/bufbuild/buf/private/pkg/app/appverbose"
 	"github.com/spf13/pflag"
 )
@@ -32,6 +33,7 @@ type Container interface {
 	appname.Container
 	applog.Container
 	appverbose.Container
+	appprogress.Container
 }
 
 // Interceptor intercepts and adapts the request or response of run functions.
@@ -58,6 +60,15 @@ func BuilderWithTimeout(defaultTimeout time.Duration) BuilderOption {
 	}
 }
 
+// BuilderWithInterceptor adds the interceptor to all run functions created by the builder.
+//
+// Interceptors added with this option wrap the interceptors passed to NewRunFunc.
+func BuilderWithInterceptor(interceptor Interceptor) BuilderOption {
+	return func(builder *builder) {
+		builder.interceptors = append(builder.interceptors, interceptor)
+	}
+}
+
 // BuilderWithTracing enables zap tracing for the builder.
 func BuilderWithTracing() BuilderOption {
 	return func(builder *builder) {
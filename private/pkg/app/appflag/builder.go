@@ -22,8 +22,10 @@ import (
 
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/app/applog"
+	"github.com/bufbuild/buf/private/pkg/app/appprogress"
 	"github.com/bufbuild/buf/private/pkg/app/appverbose"
 	"github.com/bufbuild/buf/private/pkg/observabilityzap"
+	"github.com/bufbuild/buf/private/pkg/slicesext"
 	"github.com/bufbuild/buf/private/pkg/thread"
 	"github.com/pkg/profile"
 	"github.com/spf13/pflag"
@@ -38,6 +40,7 @@ type builder struct {
 	debug     bool
 	noWarn    bool
 	logFormat string
+	progress  string
 
 	profile           bool
 	profilePath       string
@@ -52,6 +55,8 @@ type builder struct {
 	defaultTimeout time.Duration
 
 	tracing bool
+
+	interceptors []Interceptor
 }
 
 func newBuilder(appName string, options ...BuilderOption) *builder {
@@ -68,6 +73,20 @@ func (b *builder) BindRoot(flagSet *pflag.FlagSet) {
 	flagSet.BoolVarP(&b.verbose, "verbose", "v", false, "Turn on verbose mode")
 	flagSet.BoolVar(&b.debug, "debug", false, "Turn on debug logging")
 	flagSet.StringVar(&b.logFormat, "log-format", "color", "The log format [text,color,json]")
+	flagSet.StringVar(
+		&b.progress,
+		"progress",
+		appprogress.ProgressAuto,
+		fmt.Sprintf(
+			"Report the progress of downloads [%s,%s,%s]. %s only reports progress when stderr is a terminal",
+			appprogress.ProgressAuto,
+			appprogress.ProgressTrue,
+			appprogress.ProgressFalse,
+			appprogress.ProgressAuto,
+		),
+	)
+	// Allow --progress to be used without a value.
+	flagSet.Lookup("progress").NoOptDefVal = appprogress.ProgressTrue
 	if b.defaultTimeout > 0 {
 		flagSet.DurationVar(&b.timeout, "timeout", b.defaultTimeout, `The duration until timing out, setting it to zero means no timeout`)
 	}
@@ -94,7 +113,7 @@ func (b *builder) NewRunFunc(
 	f func(context.Context, Container) error,
 	interceptors ...Interceptor,
 ) func(context.Context, app.Container) error {
-	interceptor := chainInterceptors(interceptors...)
+	interceptor := chainInterceptors(append(slicesext.Copy(b.interceptors), interceptors...)...)
 	return func(ctx context.Context, appContainer app.Container) error {
 		if interceptor != nil {
 			return b.run(ctx, appContainer, interceptor(f))
@@ -120,7 +139,11 @@ func (b *builder) run(
 		retErr = multierr.Append(retErr, logger.Sync())
 	}()
 	verbosePrinter := appverbose.NewVerbosePrinter(appContainer.Stderr(), b.appName, b.verbose)
-	container, err := newContainer(appContainer, b.appName, logger, verbosePrinter)
+	progressReporter, err := appprogress.NewProgressReporter(appContainer.Stderr(), b.appName, b.progress)
+	if err != nil {
+		return fmt.Errorf("--progress: %w", err)
+	}
+	container, err := newContainer(appContainer, b.appName, logger, verbosePrinter, progressReporter)
 	if err != nil {
 		return err
 	}
@@ -18,16 +18,19 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/app/applog"
 	"github.com/bufbuild/buf/private/pkg/app/appname"
+	"github.com/bufbuild/buf/private/pkg/app/appprogress"
 	"github.com/bufbuild/buf/private/pkg/app/appverbose"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/verbose"
 	"go.uber.org/zap"
 )
 
 type container struct {
 	app.Container
-	nameContainer    appname.Container
-	logContainer     applog.Container
-	verboseContainer appverbose.Container
+	nameContainer     appname.Container
+	logContainer      applog.Container
+	verboseContainer  appverbose.Container
+	progressContainer appprogress.Container
 }
 
 func newContainer(
@@ -35,16 +38,18 @@ func newContainer(
 	appName string,
 	logger *zap.Logger,
 	verbosePrinter verbose.Printer,
+	progressReporter progress.Reporter,
 ) (*container, error) {
 	nameContainer, err := appname.NewContainer(baseContainer, appName)
 	if err != nil {
 		return nil, err
 	}
 	return &container{
-		Container:        baseContainer,
-		nameContainer:    nameContainer,
-		logContainer:     applog.NewContainer(logger),
-		verboseContainer: appverbose.NewContainer(verbosePrinter),
+		Container:         baseContainer,
+		nameContainer:     nameContainer,
+		logContainer:      applog.NewContainer(logger),
+		verboseContainer:  appverbose.NewContainer(verbosePrinter),
+		progressContainer: appprogress.NewContainer(progressReporter),
 	}, nil
 }
 
@@ -75,3 +80,7 @@ func (c *container) Logger() *zap.Logger {
 func (c *container) VerbosePrinter() verbose.Printer {
 	return c.verboseContainer.VerbosePrinter()
 }
+
+func (c *container) ProgressReporter() progress.Reporter {
+	return c.progressContainer.ProgressReporter()
+}
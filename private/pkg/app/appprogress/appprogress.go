@@ -0,0 +1,71 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appprogress contains functionality to work with progress reporting.
+package appprogress
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bufbuild/buf/private/pkg/progress"
+	"golang.org/x/term"
+)
+
+const (
+	// ProgressAuto reports progress only if the writer is a terminal.
+	ProgressAuto = "auto"
+	// ProgressTrue always reports progress.
+	ProgressTrue = "true"
+	// ProgressFalse never reports progress.
+	ProgressFalse = "false"
+)
+
+// Container is a container.
+type Container interface {
+	ProgressReporter() progress.Reporter
+}
+
+// NewContainer returns a new Container.
+func NewContainer(progressReporter progress.Reporter) Container {
+	return newContainer(progressReporter)
+}
+
+// NewProgressReporter returns a new progress.Reporter depending on the value of progressValue.
+//
+// progressValue must be one of ProgressAuto, ProgressTrue, or ProgressFalse. An empty
+// progressValue is treated as ProgressAuto. Progress is redrawn in place if the writer
+// is a terminal, and logged periodically otherwise.
+func NewProgressReporter(writer io.Writer, prefix string, progressValue string) (progress.Reporter, error) {
+	interactive := isTerminal(writer)
+	switch progressValue {
+	case ProgressAuto, "":
+		if !interactive {
+			return progress.NopReporter, nil
+		}
+		return progress.NewWriteReporter(writer, prefix, true), nil
+	case ProgressTrue:
+		return progress.NewWriteReporter(writer, prefix, interactive), nil
+	case ProgressFalse:
+		return progress.NopReporter, nil
+	default:
+		return nil, fmt.Errorf("unknown progress value %q, must be one of %s, %s, %s", progressValue, ProgressAuto, ProgressTrue, ProgressFalse)
+	}
+}
+
+func isTerminal(writer io.Writer) bool {
+	file, ok := writer.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
@@ -0,0 +1,100 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appprompt contains functionality to interactively prompt the user.
+package appprompt
+
+import (
+	"errors"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+)
+
+var (
+	// ErrNotATTY is returned when a prompt is required but stdin is not a terminal.
+	ErrNotATTY = errors.New("reader was not a TTY as expected")
+	// ErrNonInteractive is returned when a prompt is required but prompting was disabled
+	// with PrompterWithNonInteractive.
+	ErrNonInteractive = errors.New("input is required but prompting is disabled")
+)
+
+// Container is the container needed to prompt the user.
+type Container interface {
+	app.StdinContainer
+	app.StdoutContainer
+}
+
+// Prompter prompts the user for input.
+//
+// All prompts return ErrNotATTY if stdin is not a terminal, and ErrNonInteractive
+// if PrompterWithNonInteractive was used, unless the prompt is skipped by
+// PrompterWithAssumeYes.
+type Prompter interface {
+	// Confirm asks the user a yes or no question, returning true if the answer was yes.
+	//
+	// An empty answer is no. Returns true without prompting if PrompterWithAssumeYes was used.
+	Confirm(prompt string) (bool, error)
+	// ConfirmValue asks the user to enter the expected value to confirm an action,
+	// returning an error if the user entered anything else.
+	//
+	// Returns nil without prompting if PrompterWithAssumeYes was used.
+	ConfirmValue(prompt string, expectedValue string) error
+	// Select asks the user to select one of the options, by number or by value,
+	// returning the selected option.
+	Select(prompt string, options []string) (string, error)
+	// Input reads a line, prompting the user with the prompt first.
+	//
+	// The prompt is repeatedly shown until the user provides a non-empty response.
+	Input(prompt string) (string, error)
+	// Secret reads a line without echoing it, prompting the user with the prompt first.
+	//
+	// The prompt is repeatedly shown until the user provides a non-empty response.
+	Secret(prompt string) (string, error)
+}
+
+// NewPrompter returns a new Prompter.
+func NewPrompter(container Container, options ...PrompterOption) Prompter {
+	return newPrompter(container, options...)
+}
+
+// PrompterOption is an option for a new Prompter.
+type PrompterOption func(*prompter)
+
+// PrompterWithAssumeYes returns a new PrompterOption that answers yes to all
+// confirmations without prompting.
+//
+// Prompts that require a value, such as Select and Input, are still shown.
+func PrompterWithAssumeYes() PrompterOption {
+	return func(prompter *prompter) {
+		prompter.assumeYes = true
+	}
+}
+
+// PrompterWithNonInteractive returns a new PrompterOption that never prompts,
+// returning ErrNonInteractive instead.
+func PrompterWithNonInteractive() PrompterOption {
+	return func(prompter *prompter) {
+		prompter.nonInteractive = true
+	}
+}
+
+// PrompterWithIOErrorWrapper returns a new PrompterOption that wraps the errors
+// encountered when writing a prompt or reading an answer.
+//
+// An EOF submitted by the user is not wrapped. The default is to return the errors as-is.
+func PrompterWithIOErrorWrapper(wrapIOError func(error) error) PrompterOption {
+	return func(prompter *prompter) {
+		prompter.wrapIOError = wrapIOError
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appprompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const maxAttempts = 3
+
+type prompter struct {
+	writer         io.Writer
+	scanner        *bufio.Scanner
+	isTerminal     bool
+	readPassword   func() (string, error)
+	assumeYes      bool
+	nonInteractive bool
+	wrapIOError    func(error) error
+}
+
+func newPrompter(container Container, options ...PrompterOption) *prompter {
+	stdin := container.Stdin()
+	prompter := &prompter{
+		writer:  container.Stdout(),
+		scanner: bufio.NewScanner(stdin),
+		wrapIOError: func(err error) error {
+			return err
+		},
+	}
+	if file, ok := stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		prompter.isTerminal = true
+		prompter.readPassword = func() (string, error) {
+			data, err := term.ReadPassword(int(file.Fd()))
+			return string(data), err
+		}
+	}
+	for _, option := range options {
+		option(prompter)
+	}
+	return prompter
+}
+
+func (p *prompter) Confirm(prompt string) (bool, error) {
+	if p.assumeYes {
+		return true, nil
+	}
+	for attempts := 1; attempts <= maxAttempts; attempts++ {
+		value, err := p.readLine(prompt+" [y/N]: ", false)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+		if attempts < maxAttempts {
+			if err := p.println(`Please answer "y" or "n".`); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, fmt.Errorf("did not receive a valid answer in %d attempts", maxAttempts)
+}
+
+func (p *prompter) ConfirmValue(prompt string, expectedValue string) error {
+	if p.assumeYes {
+		return nil
+	}
+	value, err := p.readNonEmptyLine(prompt, false)
+	if err != nil {
+		return err
+	}
+	if value != expectedValue {
+		return fmt.Errorf("expected %q, but received %q", expectedValue, value)
+	}
+	return nil
+}
+
+func (p *prompter) Select(prompt string, options []string) (string, error) {
+	if len(options) == 0 {
+		return "", errors.New("no options to select from")
+	}
+	if err := p.checkInteractive(); err != nil {
+		return "", err
+	}
+	if err := p.println(prompt); err != nil {
+		return "", err
+	}
+	for i, option := range options {
+		if err := p.println(fmt.Sprintf("  %d) %s", i+1, option)); err != nil {
+			return "", err
+		}
+	}
+	for attempts := 1; attempts <= maxAttempts; attempts++ {
+		value, err := p.readLine(fmt.Sprintf("Enter a number (1-%d): ", len(options)), false)
+		if err != nil {
+			return "", err
+		}
+		value = strings.TrimSpace(value)
+		if index, err := strconv.Atoi(value); err == nil && index >= 1 && index <= len(options) {
+			return options[index-1], nil
+		}
+		for _, option := range options {
+			if value == option {
+				return option, nil
+			}
+		}
+		if attempts < maxAttempts {
+			if err := p.println("Invalid selection. Please try again."); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "", fmt.Errorf("did not receive a valid selection in %d attempts", maxAttempts)
+}
+
+func (p *prompter) Input(prompt string) (string, error) {
+	return p.readNonEmptyLine(prompt, false)
+}
+
+func (p *prompter) Secret(prompt string) (string, error) {
+	return p.readNonEmptyLine(prompt, true)
+}
+
+// readNonEmptyLine reads a line, repeatedly showing the prompt until the user
+// provides a non-empty response.
+func (p *prompter) readNonEmptyLine(prompt string, secret bool) (string, error) {
+	for attempts := 1; attempts <= maxAttempts; attempts++ {
+		value, err := p.readLine(prompt, secret)
+		if err != nil {
+			return "", err
+		}
+		if len(strings.TrimSpace(value)) != 0 {
+			// We want to preserve spaces in user input, so we only apply
+			// strings.TrimSpace to verify an answer was provided.
+			return value, nil
+		}
+		if attempts < maxAttempts {
+			// We only want to ask the user to try again if they actually
+			// have another attempt.
+			if err := p.println("No answer was provided. Please try again."); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "", fmt.Errorf("did not receive an answer in %d attempts", maxAttempts)
+}
+
+func (p *prompter) readLine(prompt string, secret bool) (string, error) {
+	if err := p.checkInteractive(); err != nil {
+		return "", err
+	}
+	if _, err := fmt.Fprint(p.writer, prompt); err != nil {
+		return "", p.wrapIOError(err)
+	}
+	if secret {
+		value, err := p.readPassword()
+		if err != nil {
+			// If the user submitted an EOF (e.g. via ^D) then we
+			// should not treat it as an I/O error; returning
+			// the error directly makes it more clear as to
+			// why the command failed.
+			if errors.Is(err, io.EOF) {
+				return "", err
+			}
+			return "", p.wrapIOError(err)
+		}
+		return value, nil
+	}
+	if !p.scanner.Scan() {
+		// scanner.Err() returns nil on EOF.
+		if err := p.scanner.Err(); err != nil {
+			return "", p.wrapIOError(err)
+		}
+		return "", io.EOF
+	}
+	return p.scanner.Text(), nil
+}
+
+func (p *prompter) checkInteractive() error {
+	if p.nonInteractive {
+		return ErrNonInteractive
+	}
+	if !p.isTerminal {
+		return ErrNotATTY
+	}
+	return nil
+}
+
+func (p *prompter) println(message string) error {
+	if _, err := fmt.Fprintln(p.writer, message); err != nil {
+		return p.wrapIOError(err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appprompt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfirm(t *testing.T) {
+	t.Parallel()
+	prompter, stdout := newTestPrompter("maybe\ny\n")
+	confirmed, err := prompter.Confirm("Continue?")
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	assert.Equal(t, "Continue? [y/N]: Please answer \"y\" or \"n\".\nContinue? [y/N]: ", stdout.String())
+
+	prompter, _ = newTestPrompter("\n")
+	confirmed, err = prompter.Confirm("Continue?")
+	require.NoError(t, err)
+	assert.False(t, confirmed)
+
+	prompter, _ = newTestPrompter("a\nb\nc\n")
+	_, err = prompter.Confirm("Continue?")
+	assert.Error(t, err)
+}
+
+func TestConfirmValue(t *testing.T) {
+	t.Parallel()
+	prompter, stdout := newTestPrompter("\nfoo\n")
+	require.NoError(t, prompter.ConfirmValue("Name: ", "foo"))
+	assert.Equal(t, "Name: No answer was provided. Please try again.\nName: ", stdout.String())
+
+	prompter, _ = newTestPrompter("bar\n")
+	assert.Error(t, prompter.ConfirmValue("Name: ", "foo"))
+
+	prompter, _ = newTestPrompter("", PrompterWithAssumeYes())
+	assert.NoError(t, prompter.ConfirmValue("Name: ", "foo"))
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	prompter, stdout := newTestPrompter("3\n2\n")
+	option, err := prompter.Select("Pick one:", []string{"foo", "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", option)
+	assert.Equal(
+		t,
+		"Pick one:\n  1) foo\n  2) bar\nEnter a number (1-2): Invalid selection. Please try again.\nEnter a number (1-2): ",
+		stdout.String(),
+	)
+
+	prompter, _ = newTestPrompter("foo\n")
+	option, err = prompter.Select("Pick one:", []string{"foo", "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", option)
+}
+
+func TestInputAndSecret(t *testing.T) {
+	t.Parallel()
+	prompter, _ := newTestPrompter(" foo \n")
+	value, err := prompter.Input("Value: ")
+	require.NoError(t, err)
+	assert.Equal(t, " foo ", value)
+
+	prompter, _ = newTestPrompter("")
+	_, err = prompter.Input("Value: ")
+	assert.ErrorIs(t, err, io.EOF)
+
+	prompter, stdout := newTestPrompter("")
+	prompter.readPassword = func() (string, error) {
+		return "secret", nil
+	}
+	value, err = prompter.Secret("Password: ")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+	assert.Equal(t, "Password: ", stdout.String())
+}
+
+func TestNotInteractive(t *testing.T) {
+	t.Parallel()
+	prompter, _ := newTestPrompter("y\n", PrompterWithNonInteractive())
+	_, err := prompter.Confirm("Continue?")
+	assert.ErrorIs(t, err, ErrNonInteractive)
+
+	prompter, _ = newTestPrompter("y\n", PrompterWithNonInteractive(), PrompterWithAssumeYes())
+	confirmed, err := prompter.Confirm("Continue?")
+	require.NoError(t, err)
+	assert.True(t, confirmed)
+	_, err = prompter.Input("Value: ")
+	assert.ErrorIs(t, err, ErrNonInteractive)
+
+	// Not a terminal.
+	stdout := bytes.NewBuffer(nil)
+	_, err = newPrompter(
+		app.NewContainer(nil, strings.NewReader("y\n"), stdout, nil),
+	).Confirm("Continue?")
+	assert.ErrorIs(t, err, ErrNotATTY)
+	assert.Empty(t, stdout.String())
+}
+
+func TestIOErrorWrapper(t *testing.T) {
+	t.Parallel()
+	errWrapped := errors.New("wrapped")
+	wrapIOError := func(err error) error {
+		return fmt.Errorf("%w: %v", errWrapped, err)
+	}
+	errRead := errors.New("read")
+	prompter, _ := newTestPrompter("", PrompterWithIOErrorWrapper(wrapIOError))
+	prompter.readPassword = func() (string, error) {
+		return "", errRead
+	}
+	_, err := prompter.Secret("Password: ")
+	assert.ErrorIs(t, err, errWrapped)
+	assert.ErrorContains(t, err, errRead.Error())
+
+	prompter.readPassword = func() (string, error) {
+		return "", io.EOF
+	}
+	_, err = prompter.Secret("Password: ")
+	assert.Equal(t, io.EOF, err)
+
+	prompter, _ = newTestPrompter("y\n", PrompterWithIOErrorWrapper(wrapIOError))
+	prompter.writer = errorWriter{}
+	_, err = prompter.Confirm("Continue?")
+	assert.ErrorIs(t, err, errWrapped)
+}
+
+func newTestPrompter(stdin string, options ...PrompterOption) (*prompter, *bytes.Buffer) {
+	stdout := bytes.NewBuffer(nil)
+	prompter := newPrompter(
+		app.NewContainer(nil, strings.NewReader(stdin), stdout, nil),
+		options...,
+	)
+	prompter.isTerminal = true
+	return prompter, stdout
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write")
+}
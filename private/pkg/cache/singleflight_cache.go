@@ -0,0 +1,269 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errGetUncachedPanicked = errors.New("cache: call to get uncached value panicked")
+
+// SingleflightCache is a cache from K to V that is safe for concurrent use.
+//
+// Concurrent calls to GetOrAdd for the same key share a single call to get the
+// uncached value, while calls for different keys do not block each other. Errors
+// are returned to every caller sharing the call, but are never cached.
+//
+// Entries optionally expire after a TTL, and the cache optionally holds a maximum
+// number of entries, evicting the least recently used entry when full.
+//
+// The zero value is a cache without a TTL or a maximum number of entries.
+type SingleflightCache[K comparable, V any] struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	lock sync.Mutex
+	// keyToElement values are *singleflightEntry[K, V].
+	keyToElement map[K]*list.Element
+	// recency has the most recently used entry at the front.
+	recency     *list.List
+	keyToCall   map[K]*singleflightCall[V]
+	initialized bool
+}
+
+// NewSingleflightCache returns a new SingleflightCache.
+func NewSingleflightCache[K comparable, V any](options ...SingleflightCacheOption) *SingleflightCache[K, V] {
+	singleflightCacheOptions := newSingleflightCacheOptions()
+	for _, option := range options {
+		option(singleflightCacheOptions)
+	}
+	return &SingleflightCache[K, V]{
+		ttl:        singleflightCacheOptions.ttl,
+		maxEntries: singleflightCacheOptions.maxEntries,
+	}
+}
+
+// SingleflightCacheOption is an option for a new SingleflightCache.
+type SingleflightCacheOption func(*singleflightCacheOptions)
+
+// SingleflightCacheWithTTL returns a new SingleflightCacheOption that expires
+// entries the given duration after they were added.
+//
+// The default is to never expire entries.
+func SingleflightCacheWithTTL(ttl time.Duration) SingleflightCacheOption {
+	return func(singleflightCacheOptions *singleflightCacheOptions) {
+		singleflightCacheOptions.ttl = ttl
+	}
+}
+
+// SingleflightCacheWithMaxEntries returns a new SingleflightCacheOption that limits
+// the cache to the given number of entries, evicting the least recently used entry
+// when the limit is exceeded.
+//
+// The default is no limit.
+func SingleflightCacheWithMaxEntries(maxEntries int) SingleflightCacheOption {
+	return func(singleflightCacheOptions *singleflightCacheOptions) {
+		singleflightCacheOptions.maxEntries = maxEntries
+	}
+}
+
+// GetOrAdd gets the value for the key, or calls getUncached to get a new value,
+// and then caches the value if getUncached did not return an error.
+//
+// If a call to getUncached for the key is already in flight, GetOrAdd waits for
+// it and returns its result instead of calling getUncached again. getUncached
+// is called with the context of the caller that started the call, and a caller
+// that is waiting returns early with the error of its context if its context is
+// done first. If the call fails after the context of the caller that started it
+// is done, waiters whose contexts are not done try again instead of returning
+// that error.
+func (c *SingleflightCache[K, V]) GetOrAdd(
+	ctx context.Context,
+	key K,
+	getUncached func(context.Context) (V, error),
+) (V, error) {
+	return c.GetOrAddIf(ctx, key, getUncached, nil)
+}
+
+// GetOrAddIf is GetOrAdd, but only caches values for which shouldCache returns true.
+//
+// This is useful for values that can only change in one direction, for example
+// a resource that may come to exist but will not be deleted.
+//
+// If shouldCache is nil, all values are cached.
+func (c *SingleflightCache[K, V]) GetOrAddIf(
+	ctx context.Context,
+	key K,
+	getUncached func(context.Context) (V, error),
+	shouldCache func(V) bool,
+) (V, error) {
+	for {
+		c.lock.Lock()
+		c.initInsideLock()
+		if value, ok := c.getInsideLock(key); ok {
+			c.lock.Unlock()
+			return value, nil
+		}
+		if call, ok := c.keyToCall[key]; ok {
+			c.lock.Unlock()
+			select {
+			case <-call.done:
+				if call.err != nil && call.ctxDone && ctx.Err() == nil {
+					// The call failed because the context of the caller that started
+					// it is done, but ours is not. Try again rather than returning
+					// an error that has nothing to do with us.
+					continue
+				}
+				return call.value, call.err
+			case <-ctx.Done():
+				var zero V
+				return zero, ctx.Err()
+			}
+		}
+		call := &singleflightCall[V]{
+			done: make(chan struct{}),
+		}
+		c.keyToCall[key] = call
+		c.lock.Unlock()
+		c.doCall(ctx, key, call, getUncached, shouldCache)
+		return call.value, call.err
+	}
+}
+
+// Add adds the value for the key, replacing any value already cached for the key.
+//
+// This is useful when the value for a key is learned as a side effect of getting
+// the value for another key.
+func (c *SingleflightCache[K, V]) Add(key K, value V) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.initInsideLock()
+	c.addInsideLock(key, value)
+}
+
+func (c *SingleflightCache[K, V]) doCall(
+	ctx context.Context,
+	key K,
+	call *singleflightCall[V],
+	getUncached func(context.Context) (V, error),
+	shouldCache func(V) bool,
+) {
+	completed := false
+	defer func() {
+		if !completed {
+			// getUncached panicked, make sure waiters do not hang or see a zero value
+			// as a valid result. The panic continues to propagate to our caller.
+			call.err = errGetUncachedPanicked
+		}
+		c.lock.Lock()
+		delete(c.keyToCall, key)
+		if call.err == nil && (shouldCache == nil || shouldCache(call.value)) {
+			c.addInsideLock(key, call.value)
+		}
+		c.lock.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = getUncached(ctx)
+	call.ctxDone = ctx.Err() != nil
+	completed = true
+}
+
+func (c *SingleflightCache[K, V]) initInsideLock() {
+	if c.initialized {
+		return
+	}
+	c.keyToElement = make(map[K]*list.Element)
+	c.recency = list.New()
+	c.keyToCall = make(map[K]*singleflightCall[V])
+	if c.now == nil {
+		c.now = time.Now
+	}
+	c.initialized = true
+}
+
+func (c *SingleflightCache[K, V]) getInsideLock(key K) (V, bool) {
+	element, ok := c.keyToElement[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	entry := element.Value.(*singleflightEntry[K, V])
+	if !entry.expireTime.IsZero() && !c.now().Before(entry.expireTime) {
+		c.removeInsideLock(element)
+		var zero V
+		return zero, false
+	}
+	c.recency.MoveToFront(element)
+	return entry.value, true
+}
+
+func (c *SingleflightCache[K, V]) addInsideLock(key K, value V) {
+	var expireTime time.Time
+	if c.ttl > 0 {
+		expireTime = c.now().Add(c.ttl)
+	}
+	if element, ok := c.keyToElement[key]; ok {
+		entry := element.Value.(*singleflightEntry[K, V])
+		entry.value = value
+		entry.expireTime = expireTime
+		c.recency.MoveToFront(element)
+		return
+	}
+	c.keyToElement[key] = c.recency.PushFront(
+		&singleflightEntry[K, V]{
+			key:        key,
+			value:      value,
+			expireTime: expireTime,
+		},
+	)
+	if c.maxEntries > 0 && c.recency.Len() > c.maxEntries {
+		c.removeInsideLock(c.recency.Back())
+	}
+}
+
+func (c *SingleflightCache[K, V]) removeInsideLock(element *list.Element) {
+	entry := c.recency.Remove(element).(*singleflightEntry[K, V])
+	delete(c.keyToElement, entry.key)
+}
+
+type singleflightEntry[K comparable, V any] struct {
+	key   K
+	value V
+	// expireTime is zero if the entry does not expire.
+	expireTime time.Time
+}
+
+type singleflightCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// ctxDone is true if the context of the caller that started the call was
+	// done when the call returned.
+	ctxDone bool
+}
+
+type singleflightCacheOptions struct {
+	ttl        time.Duration
+	maxEntries int
+}
+
+func newSingleflightCacheOptions() *singleflightCacheOptions {
+	return &singleflightCacheOptions{}
+}
@@ -0,0 +1,283 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleflightCacheGetOrAdd(t *testing.T) {
+	t.Parallel()
+	var cache SingleflightCache[string, int]
+	var calls int
+	getUncached := func(context.Context) (int, error) {
+		calls++
+		return 1, nil
+	}
+	value, err := cache.GetOrAdd(context.Background(), "a", getUncached)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	value, err = cache.GetOrAdd(context.Background(), "a", getUncached)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	assert.Equal(t, 1, calls)
+}
+
+func TestSingleflightCacheErrorsNotCached(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	_, err := cache.GetOrAdd(
+		context.Background(),
+		"a",
+		func(context.Context) (int, error) {
+			return 0, errors.New("failed")
+		},
+	)
+	require.Error(t, err)
+	value, err := cache.GetOrAdd(
+		context.Background(),
+		"a",
+		func(context.Context) (int, error) {
+			return 2, nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
+
+func TestSingleflightCacheGetOrAddIf(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, bool]()
+	var calls int
+	getUncached := func(context.Context) (bool, error) {
+		calls++
+		return calls > 1, nil
+	}
+	isTrue := func(value bool) bool { return value }
+	value, err := cache.GetOrAddIf(context.Background(), "a", getUncached, isTrue)
+	require.NoError(t, err)
+	assert.False(t, value)
+	value, err = cache.GetOrAddIf(context.Background(), "a", getUncached, isTrue)
+	require.NoError(t, err)
+	assert.True(t, value)
+	value, err = cache.GetOrAddIf(context.Background(), "a", getUncached, isTrue)
+	require.NoError(t, err)
+	assert.True(t, value)
+	assert.Equal(t, 2, calls)
+}
+
+func TestSingleflightCacheConcurrent(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	getUncached := func(context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 1, nil
+	}
+	const numCallers = 10
+	var started sync.WaitGroup
+	var finished sync.WaitGroup
+	values := make([]int, numCallers)
+	for i := 0; i < numCallers; i++ {
+		i := i
+		started.Add(1)
+		finished.Add(1)
+		go func() {
+			defer finished.Done()
+			started.Done()
+			value, err := cache.GetOrAdd(context.Background(), "a", getUncached)
+			assert.NoError(t, err)
+			values[i] = value
+		}()
+	}
+	started.Wait()
+	// Give the callers a chance to join the in-flight call before releasing it.
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	finished.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+	for _, value := range values {
+		assert.Equal(t, 1, value)
+	}
+}
+
+func TestSingleflightCacheWaiterContextDone(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	release := make(chan struct{})
+	inFlight := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.GetOrAdd(
+			context.Background(),
+			"a",
+			func(context.Context) (int, error) {
+				close(inFlight)
+				<-release
+				return 1, nil
+			},
+		)
+	}()
+	<-inFlight
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.GetOrAdd(
+		ctx,
+		"a",
+		func(context.Context) (int, error) {
+			return 2, nil
+		},
+	)
+	assert.ErrorIs(t, err, context.Canceled)
+	close(release)
+	<-done
+}
+
+func TestSingleflightCacheStarterContextDone(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	inFlight := make(chan struct{})
+	var starterErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, starterErr = cache.GetOrAdd(
+			ctx,
+			"a",
+			func(ctx context.Context) (int, error) {
+				close(inFlight)
+				<-ctx.Done()
+				return 0, ctx.Err()
+			},
+		)
+	}()
+	<-inFlight
+	var waiterValue int
+	var waiterErr error
+	waiterDone := make(chan struct{})
+	go func() {
+		defer close(waiterDone)
+		waiterValue, waiterErr = cache.GetOrAdd(
+			context.Background(),
+			"a",
+			func(context.Context) (int, error) {
+				return 2, nil
+			},
+		)
+	}()
+	// Give the waiter a chance to join the in-flight call before cancelling it.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	<-done
+	<-waiterDone
+	assert.ErrorIs(t, starterErr, context.Canceled)
+	require.NoError(t, waiterErr)
+	assert.Equal(t, 2, waiterValue)
+}
+
+func TestSingleflightCacheTTL(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int](SingleflightCacheWithTTL(time.Minute))
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	var calls int
+	getUncached := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	value, err := cache.GetOrAdd(context.Background(), "a", getUncached)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	now = now.Add(59 * time.Second)
+	value, err = cache.GetOrAdd(context.Background(), "a", getUncached)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+	now = now.Add(time.Second)
+	value, err = cache.GetOrAdd(context.Background(), "a", getUncached)
+	require.NoError(t, err)
+	assert.Equal(t, 2, value)
+}
+
+func TestSingleflightCacheMaxEntries(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, string](SingleflightCacheWithMaxEntries(2))
+	var calls []string
+	getUncached := func(key string) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			calls = append(calls, key)
+			return key, nil
+		}
+	}
+	for _, key := range []string{"a", "b", "a", "c", "a", "b"} {
+		value, err := cache.GetOrAdd(context.Background(), key, getUncached(key))
+		require.NoError(t, err)
+		assert.Equal(t, key, value)
+	}
+	// "b" is evicted when "c" is added since "a" was used more recently.
+	assert.Equal(t, []string{"a", "b", "c", "b"}, calls)
+}
+
+func TestSingleflightCacheAdd(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	cache.Add("a", 1)
+	value, err := cache.GetOrAdd(
+		context.Background(),
+		"a",
+		func(context.Context) (int, error) {
+			return 2, nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
+
+func TestSingleflightCachePanic(t *testing.T) {
+	t.Parallel()
+	cache := NewSingleflightCache[string, int]()
+	assert.Panics(
+		t,
+		func() {
+			_, _ = cache.GetOrAdd(
+				context.Background(),
+				"a",
+				func(context.Context) (int, error) {
+					panic("boom")
+				},
+			)
+		},
+	)
+	value, err := cache.GetOrAdd(
+		context.Background(),
+		"a",
+		func(context.Context) (int, error) {
+			return 1, nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, value)
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"time"
 
 	"github.com/bufbuild/buf/private/pkg/app"
 )
@@ -108,6 +109,80 @@ func RunWithDir(dir string) RunOption {
 	}
 }
 
+// RunWithTimeout returns a new RunOption that kills the command if it does
+// not exit within the given wall-clock duration.
+//
+// The default is no timeout other than any deadline set on the context.
+func RunWithTimeout(timeout time.Duration) RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.timeout = timeout
+	}
+}
+
+// RunWithGracePeriod returns a new RunOption that interrupts the command when
+// the context is done, and only kills it if it does not exit within the grace
+// period, so that the command can clean up.
+//
+// The command is killed immediately on platforms that cannot send interrupt
+// signals to processes, such as Windows.
+// The default is to kill the command immediately when the context is done.
+func RunWithGracePeriod(gracePeriod time.Duration) RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.gracePeriod = gracePeriod
+	}
+}
+
+// RunWithMaxMemoryBytes returns a new RunOption that limits the virtual memory
+// of the command to the given number of bytes. The limit is rounded up to the
+// nearest kibibyte.
+//
+// The limit is set by /bin/sh before the command is executed. This is only
+// supported on Linux, Run returns an error on other platforms.
+// The default is no limit.
+func RunWithMaxMemoryBytes(maxMemoryBytes uint64) RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.maxMemoryBytes = maxMemoryBytes
+	}
+}
+
+// RunWithMaxCPUTime returns a new RunOption that limits the CPU time of the
+// command. The limit is rounded up to the nearest second.
+//
+// The limit is set by /bin/sh before the command is executed. This is only
+// supported on Linux, Run returns an error on other platforms.
+// The default is no limit.
+func RunWithMaxCPUTime(maxCPUTime time.Duration) RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.maxCPUTime = maxCPUTime
+	}
+}
+
+// RunWithIsolatedTempDir returns a new RunOption that creates a new temporary
+// directory for the command and points TMPDIR, TMP, and TEMP at it. The
+// directory is removed after the command exits.
+//
+// This only sets the environment variables, the command can still access any
+// other directory.
+//
+// The default is to use the temporary directory of the environment.
+func RunWithIsolatedTempDir() RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.isolatedTempDir = true
+	}
+}
+
+// RunWithNoNetwork returns a new RunOption that runs the command in a new
+// network namespace with no network interfaces other than loopback.
+//
+// This is only supported on Linux, where it requires unprivileged user namespaces
+// to be enabled. Run returns an error if user namespaces are disabled, or on
+// other platforms. The default is to allow network access.
+func RunWithNoNetwork() RunOption {
+	return func(execOptions *execOptions) {
+		execOptions.noNetwork = true
+	}
+}
+
 // StartOption is an option for Start.
 type StartOption func(*execOptions)
 
@@ -16,12 +16,16 @@ package command
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"sort"
+	"time"
 
 	"github.com/bufbuild/buf/private/pkg/ioext"
 	"github.com/bufbuild/buf/private/pkg/thread"
+	"go.uber.org/multierr"
 )
 
 var emptyEnv = envSlice(
@@ -47,16 +51,53 @@ func newRunner(options ...RunnerOption) *runner {
 	return runner
 }
 
-func (r *runner) Run(ctx context.Context, name string, options ...RunOption) error {
+func (r *runner) Run(ctx context.Context, name string, options ...RunOption) (retErr error) {
 	execOptions := newExecOptions()
 	for _, option := range options {
 		option(execOptions)
 	}
-	cmd := exec.CommandContext(ctx, name, execOptions.args...)
+	if execOptions.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, execOptions.timeout)
+		defer cancel()
+	}
+	if execOptions.isolatedTempDir {
+		tempDirPath, err := os.MkdirTemp("", "buf-command-")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			retErr = multierr.Append(retErr, os.RemoveAll(tempDirPath))
+		}()
+		execOptions.env = envWithTempDir(execOptions.env, tempDirPath)
+	}
+	name, args, err := commandWithResourceLimits(name, execOptions)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, name, args...)
 	execOptions.ApplyToCmd(cmd)
+	if execOptions.gracePeriod > 0 {
+		cmd.Cancel = func() error {
+			if err := cmd.Process.Signal(os.Interrupt); err != nil {
+				return cmd.Process.Kill()
+			}
+			return nil
+		}
+		cmd.WaitDelay = execOptions.gracePeriod
+	}
+	if err := applySandboxToCmd(cmd, execOptions); err != nil {
+		return err
+	}
 	r.increment()
-	err := cmd.Run()
-	r.decrement()
+	defer r.decrement()
+	if err := cmd.Start(); err != nil {
+		return sandboxStartError(err, execOptions)
+	}
+	err = cmd.Wait()
+	if err != nil && execOptions.timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v: %w", execOptions.timeout, err)
+	}
 	return err
 }
 
@@ -91,6 +132,14 @@ type execOptions struct {
 	stdout io.Writer
 	stderr io.Writer
 	dir    string
+
+	// Only used by Run.
+	timeout         time.Duration
+	gracePeriod     time.Duration
+	maxMemoryBytes  uint64
+	maxCPUTime      time.Duration
+	isolatedTempDir bool
+	noNetwork       bool
 }
 
 func newExecOptions() *execOptions {
@@ -129,3 +178,16 @@ func envSlice(env map[string]string) []string {
 	sort.Strings(environ)
 	return environ
 }
+
+// envWithTempDir returns a copy of env with the temporary directory variables
+// set to tempDirPath.
+func envWithTempDir(env map[string]string, tempDirPath string) map[string]string {
+	newEnv := make(map[string]string, len(env)+3)
+	for key, value := range env {
+		newEnv[key] = value
+	}
+	for _, key := range []string{"TMPDIR", "TMP", "TEMP"} {
+		newEnv[key] = tempDirPath
+	}
+	return newEnv
+}
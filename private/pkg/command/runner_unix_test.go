@@ -18,9 +18,14 @@
 package command
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -50,3 +55,70 @@ func TestNoDeadlock(t *testing.T) {
 		require.NoError(t, process.Wait(ctx))
 	}
 }
+
+func TestRunWithTimeout(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner()
+	ctx := context.Background()
+	err := runner.Run(ctx, "sleep", RunWithArgs("10"), RunWithTimeout(100*time.Millisecond))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 100ms")
+	require.NoError(t, runner.Run(ctx, "true", RunWithTimeout(10*time.Second)))
+}
+
+func TestRunWithIsolatedTempDir(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner()
+	stdout := bytes.NewBuffer(nil)
+	require.NoError(
+		t,
+		runner.Run(
+			context.Background(),
+			"sh",
+			RunWithArgs("-c", "echo $TMPDIR"),
+			RunWithStdout(stdout),
+			RunWithIsolatedTempDir(),
+		),
+	)
+	tempDirPath := strings.TrimSpace(stdout.String())
+	require.NotEmpty(t, tempDirPath)
+	assert.NotEqual(t, os.TempDir(), tempDirPath)
+	_, err := os.Stat(tempDirPath)
+	assert.True(t, os.IsNotExist(err), "temporary directory should be removed")
+}
+
+func TestRunWithGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner()
+	// The command exits on its own when interrupted.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	stdout := bytes.NewBuffer(nil)
+	start := time.Now()
+	err := runner.Run(
+		ctx,
+		"sh",
+		RunWithArgs("-c", `trap 'kill $!; echo cleaned up; exit 3' INT; sleep 10 & wait`),
+		RunWithStdout(stdout),
+		RunWithGracePeriod(5*time.Second),
+	)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, "cleaned up\n", stdout.String())
+
+	// The command is killed when the grace period expires.
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	err = runner.Run(
+		ctx,
+		"sh",
+		RunWithArgs("-c", `trap "" INT; sleep 10 & wait; wait`),
+		RunWithGracePeriod(200*time.Millisecond),
+	)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,139 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// shellPath is the shell used to apply resource limits before the command is executed.
+const shellPath = "/bin/sh"
+
+// userNamespaceSysctlPaths are the sysctl files that disable unprivileged user
+// namespaces when set to 0.
+//
+// kernel/unprivileged_userns_clone only exists on Debian and Ubuntu kernels.
+var userNamespaceSysctlPaths = []string{
+	"/proc/sys/kernel/unprivileged_userns_clone",
+	"/proc/sys/user/max_user_namespaces",
+}
+
+// commandWithResourceLimits returns the name and args to execute so that the
+// resource limits are in effect before the command starts.
+//
+// The limits are applied by a shell that sets them with ulimit and then replaces
+// itself with the command via exec, so the command never runs without them.
+func commandWithResourceLimits(name string, execOptions *execOptions) (string, []string, error) {
+	if execOptions.maxMemoryBytes == 0 && execOptions.maxCPUTime == 0 {
+		return name, execOptions.args, nil
+	}
+	// The command is resolved with the PATH of this process, as with exec.Command,
+	// since the environment of the command may not contain a PATH.
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", nil, err
+	}
+	var ulimitCommands []string
+	if execOptions.maxMemoryBytes > 0 {
+		// ulimit -v is in kibibytes, round up so that a limit is never lowered.
+		maxMemoryKibibytes := (execOptions.maxMemoryBytes + 1023) / 1024
+		ulimitCommands = append(ulimitCommands, "ulimit -v "+strconv.FormatUint(maxMemoryKibibytes, 10))
+	}
+	if execOptions.maxCPUTime > 0 {
+		// Round up so that a sub-second limit does not become no limit.
+		maxCPUSeconds := uint64((execOptions.maxCPUTime + time.Second - 1) / time.Second)
+		ulimitCommands = append(ulimitCommands, "ulimit -t "+strconv.FormatUint(maxCPUSeconds, 10))
+	}
+	script := strings.Join(append(ulimitCommands, `exec "$0" "$@"`), " && ")
+	return shellPath, append([]string{"-c", script, path}, execOptions.args...), nil
+}
+
+func applySandboxToCmd(cmd *exec.Cmd, execOptions *execOptions) error {
+	if !execOptions.noNetwork {
+		return nil
+	}
+	if err := checkUserNamespacesEnabled(); err != nil {
+		return err
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// A new user namespace allows an unprivileged user to create a new network
+	// namespace. The current user and group are mapped to themselves so that file
+	// permissions are unaffected.
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{
+		{
+			ContainerID: os.Getuid(),
+			HostID:      os.Getuid(),
+			Size:        1,
+		},
+	}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{
+		{
+			ContainerID: os.Getgid(),
+			HostID:      os.Getgid(),
+			Size:        1,
+		},
+	}
+	return nil
+}
+
+// sandboxStartError returns a clearer error if the command could not be started
+// because a user namespace could not be created.
+func sandboxStartError(err error, execOptions *execOptions) error {
+	if !execOptions.noNetwork {
+		return err
+	}
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOSPC) {
+		return newUserNamespacesDisabledError(err)
+	}
+	return err
+}
+
+// checkUserNamespacesEnabled returns an error if unprivileged user namespaces
+// are known to be disabled on this host.
+func checkUserNamespacesEnabled() error {
+	for _, sysctlPath := range userNamespaceSysctlPaths {
+		data, err := os.ReadFile(sysctlPath)
+		if err != nil {
+			// The sysctl does not exist on this kernel, rely on the error from
+			// starting the command instead.
+			continue
+		}
+		if strings.TrimSpace(string(data)) == "0" {
+			return newUserNamespacesDisabledError(fmt.Errorf("%s is 0", sysctlPath))
+		}
+	}
+	return nil
+}
+
+func newUserNamespacesDisabledError(err error) error {
+	return fmt.Errorf(
+		"cannot deny network access: unprivileged user namespaces are disabled on this host, "+
+			"enable them or remove the network restriction: %w",
+		err,
+	)
+}
@@ -0,0 +1,50 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunWithResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner()
+	stdout := bytes.NewBuffer(nil)
+	require.NoError(
+		t,
+		runner.Run(
+			context.Background(),
+			"sh",
+			RunWithArgs("-c", `ulimit -v; ulimit -t; echo "$@"`, "sh", "foo", "bar"),
+			RunWithStdout(stdout),
+			RunWithMaxMemoryBytes(10*1024*1024+1),
+			RunWithMaxCPUTime(1500*time.Millisecond),
+		),
+	)
+	// The limits are in effect from the start of the command and the
+	// arguments are passed through unchanged.
+	assert.Equal(t, []string{"10241", "2", "foo bar"}, strings.Split(strings.TrimSpace(stdout.String()), "\n"))
+}
@@ -0,0 +1,41 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package command
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func commandWithResourceLimits(name string, execOptions *execOptions) (string, []string, error) {
+	if execOptions.maxMemoryBytes > 0 || execOptions.maxCPUTime > 0 {
+		return "", nil, errors.New("memory and CPU time limits are only supported on Linux")
+	}
+	return name, execOptions.args, nil
+}
+
+func applySandboxToCmd(_ *exec.Cmd, execOptions *execOptions) error {
+	if execOptions.noNetwork {
+		return errors.New("denying network access is only supported on Linux")
+	}
+	return nil
+}
+
+func sandboxStartError(err error, _ *execOptions) error {
+	return err
+}
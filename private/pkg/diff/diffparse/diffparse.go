@@ -0,0 +1,256 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diffparse parses unified diffs.
+package diffparse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/buf/private/pkg/normalpath"
+)
+
+// hunkHeaderRegexp matches hunk headers such as "@@ -1,5 +1,6 @@".
+var hunkHeaderRegexp = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// LineRange is an inclusive range of 1-indexed lines.
+type LineRange struct {
+	StartLine int
+	EndLine   int
+}
+
+// ChangedLines are the lines within the hunks of a unified diff.
+type ChangedLines struct {
+	// FileToLineRanges are the line ranges of the hunks within the new versions
+	// of the files, keyed by the normalized paths of the new versions of the files.
+	//
+	// Deleted files are not included.
+	FileToLineRanges map[string][]LineRange
+	// DeletedFiles are the normalized paths of the files that were deleted.
+	DeletedFiles map[string]struct{}
+}
+
+// ContainsFile returns true if the file at the path has any changed lines.
+//
+// See LineRanges for how paths are matched.
+func (c ChangedLines) ContainsFile(path string) bool {
+	return len(c.LineRanges(path)) > 0
+}
+
+// IsDeleted returns true if the file at the path was deleted.
+//
+// See LineRanges for how paths are matched.
+func (c ChangedLines) IsDeleted(path string) bool {
+	_, ok := c.DeletedFiles[normalpath.Normalize(path)]
+	return ok
+}
+
+// Intersects returns true if any of the lines from startLine to endLine,
+// inclusive, of the file at the path are within a hunk.
+//
+// If endLine is less than startLine, only startLine is checked.
+// See LineRanges for how paths are matched.
+func (c ChangedLines) Intersects(path string, startLine int, endLine int) bool {
+	if endLine < startLine {
+		endLine = startLine
+	}
+	for _, lineRange := range c.LineRanges(path) {
+		if startLine <= lineRange.EndLine && lineRange.StartLine <= endLine {
+			return true
+		}
+	}
+	return false
+}
+
+// LineRanges returns the line ranges of the hunks for the file at the path.
+//
+// The path matches a file in the diff only if it is equal to the path of the
+// file after normalization, so paths must be relative to the same directory
+// as the paths within the diff.
+func (c ChangedLines) LineRanges(path string) []LineRange {
+	return c.FileToLineRanges[normalpath.Normalize(path)]
+}
+
+// ParseUnified parses the unified diff read from the reader.
+//
+// Both git-style diffs with "a/" and "b/" path prefixes and diffs without
+// prefixes are supported.
+func ParseUnified(reader io.Reader) (ChangedLines, error) {
+	changedLines := ChangedLines{
+		FileToLineRanges: make(map[string][]LineRange),
+		DeletedFiles:     make(map[string]struct{}),
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var oldPath string
+	var newPath string
+	var inFile bool
+	var oldRemaining int
+	var newRemaining int
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if oldRemaining > 0 || newRemaining > 0 {
+			// We are within a hunk, every line is content.
+			switch {
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			case strings.HasPrefix(line, "-"):
+				oldRemaining--
+			case strings.HasPrefix(line, "+"):
+				newRemaining--
+			case line == "", strings.HasPrefix(line, " "):
+				// Some tools strip the trailing space from empty context lines.
+				oldRemaining--
+				newRemaining--
+			default:
+				return ChangedLines{}, fmt.Errorf("line %d: unexpected line within hunk: %q", lineNumber, line)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			path, err := parseFilePath(strings.TrimPrefix(line, "--- "))
+			if err != nil {
+				return ChangedLines{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			oldPath = path
+			newPath = ""
+			inFile = false
+		case strings.HasPrefix(line, "+++ "):
+			path, err := parseFilePath(strings.TrimPrefix(line, "+++ "))
+			if err != nil {
+				return ChangedLines{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			newPath = stripGitPrefix(oldPath, path)
+			inFile = true
+		case strings.HasPrefix(line, "@@"):
+			if !inFile {
+				return ChangedLines{}, fmt.Errorf("line %d: hunk found before file header", lineNumber)
+			}
+			newStart, newCount, oldCount, err := parseHunkHeader(line)
+			if err != nil {
+				return ChangedLines{}, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			oldRemaining = oldCount
+			newRemaining = newCount
+			if newPath == "" {
+				// The file was deleted.
+				if oldPath != "" {
+					changedLines.DeletedFiles[stripGitOldPrefix(oldPath)] = struct{}{}
+				}
+				continue
+			}
+			changedLines.FileToLineRanges[newPath] = append(changedLines.FileToLineRanges[newPath], newLineRange(newStart, newCount))
+		}
+		// All other lines, such as "diff --git" and "index" lines, are ignored.
+	}
+	if err := scanner.Err(); err != nil {
+		return ChangedLines{}, err
+	}
+	return changedLines, nil
+}
+
+// parseFilePath parses the path from a "---" or "+++" line with the prefix removed.
+//
+// Returns the empty string for "/dev/null".
+func parseFilePath(value string) (string, error) {
+	// Some tools append a tab followed by a timestamp.
+	if index := strings.IndexByte(value, '\t'); index >= 0 {
+		value = value[:index]
+	}
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted path %s: %w", value, err)
+		}
+		value = unquoted
+	}
+	if value == "/dev/null" {
+		return "", nil
+	}
+	if value == "" {
+		return "", errors.New("empty path")
+	}
+	return normalpath.Normalize(value), nil
+}
+
+// stripGitPrefix strips the "b/" prefix from the new path if the diff uses
+// git-style prefixes, that is if the old path is "/dev/null" or has the "a/" prefix.
+func stripGitPrefix(oldPath string, newPath string) string {
+	if newPath == "" || !strings.HasPrefix(newPath, "b/") {
+		return newPath
+	}
+	if oldPath == "" || strings.HasPrefix(oldPath, "a/") {
+		return strings.TrimPrefix(newPath, "b/")
+	}
+	return newPath
+}
+
+// stripGitOldPrefix strips the "a/" prefix from the old path of a deleted file.
+//
+// Deleted files have "/dev/null" as the new path, so we cannot tell from the new
+// path whether the diff uses git-style prefixes, and assume it does if the old
+// path has the "a/" prefix.
+func stripGitOldPrefix(oldPath string) string {
+	return strings.TrimPrefix(oldPath, "a/")
+}
+
+func parseHunkHeader(line string) (newStart int, newCount int, oldCount int, _ error) {
+	matches := hunkHeaderRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return 0, 0, 0, fmt.Errorf("invalid hunk header: %q", line)
+	}
+	// Counts default to 1 when omitted.
+	oldCount, err := parseCount(matches[2])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	newStart, err = strconv.Atoi(matches[3])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	newCount, err = parseCount(matches[4])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return newStart, newCount, oldCount, nil
+}
+
+func parseCount(value string) (int, error) {
+	if value == "" {
+		return 1, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func newLineRange(start int, count int) LineRange {
+	if count == 0 {
+		// Pure deletions have a count of zero, and start is the line before
+		// the deletion. We cover the lines on both sides of the deletion.
+		if start < 1 {
+			start = 1
+		}
+		return LineRange{StartLine: start, EndLine: start + 1}
+	}
+	return LineRange{StartLine: start, EndLine: start + count - 1}
+}
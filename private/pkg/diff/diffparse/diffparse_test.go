@@ -0,0 +1,141 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diffparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnifiedGit(t *testing.T) {
+	t.Parallel()
+	changedLines, err := ParseUnified(strings.NewReader(`diff --git a/proto/foo/v1/foo.proto b/proto/foo/v1/foo.proto
+index 1234567..89abcde 100644
+--- a/proto/foo/v1/foo.proto
++++ b/proto/foo/v1/foo.proto
+@@ -3,5 +3,6 @@ package foo.v1;
+ message Foo {
+   string one = 1;
+--- string removed = 2;
++  string two = 2;
++  string three = 3;
+ }
+ 
+@@ -20 +21,0 @@ message Bar {
+-  string old = 1;
+diff --git a/proto/bar/v1/bar.proto b/proto/bar/v1/bar.proto
+deleted file mode 100644
+--- a/proto/bar/v1/bar.proto
++++ /dev/null
+@@ -1,2 +0,0 @@
+-syntax = "proto3";
+-package bar.v1;
+diff --git a/proto/baz/v1/baz.proto b/proto/baz/v1/baz.proto
+new file mode 100644
+--- /dev/null
++++ b/proto/baz/v1/baz.proto
+@@ -0,0 +1,2 @@
++syntax = "proto3";
++package baz.v1;
+\ No newline at end of file
+`))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		ChangedLines{
+			FileToLineRanges: map[string][]LineRange{
+				"proto/foo/v1/foo.proto": {
+					{StartLine: 3, EndLine: 8},
+					{StartLine: 21, EndLine: 22},
+				},
+				"proto/baz/v1/baz.proto": {
+					{StartLine: 1, EndLine: 2},
+				},
+			},
+			DeletedFiles: map[string]struct{}{
+				"proto/bar/v1/bar.proto": {},
+			},
+		},
+		changedLines,
+	)
+}
+
+func TestParseUnifiedNoPrefix(t *testing.T) {
+	t.Parallel()
+	changedLines, err := ParseUnified(strings.NewReader(`--- b/foo.proto	2023-01-01 00:00:00.000000000 +0000
++++ b/foo.proto	2023-01-02 00:00:00.000000000 +0000
+@@ -1 +1 @@
+-syntax = "proto2";
++syntax = "proto3";
+`))
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		ChangedLines{
+			FileToLineRanges: map[string][]LineRange{
+				"b/foo.proto": {
+					{StartLine: 1, EndLine: 1},
+				},
+			},
+			DeletedFiles: map[string]struct{}{},
+		},
+		changedLines,
+	)
+}
+
+func TestParseUnifiedErrors(t *testing.T) {
+	t.Parallel()
+	_, err := ParseUnified(strings.NewReader("@@ -1 +1 @@\n"))
+	assert.Error(t, err)
+	_, err = ParseUnified(strings.NewReader("--- a/foo.proto\n+++ b/foo.proto\n@@ -1 +1 invalid\n"))
+	assert.Error(t, err)
+	_, err = ParseUnified(strings.NewReader("--- a/foo.proto\n+++ b/foo.proto\n@@ -1,2 +1,2 @@\n*invalid\n"))
+	assert.Error(t, err)
+}
+
+func TestChangedLines(t *testing.T) {
+	t.Parallel()
+	changedLines := ChangedLines{
+		FileToLineRanges: map[string][]LineRange{
+			"proto/foo/v1/foo.proto": {
+				{StartLine: 3, EndLine: 9},
+				{StartLine: 21, EndLine: 22},
+			},
+			"other/foo/v1/foo.proto": {
+				{StartLine: 1, EndLine: 1},
+			},
+		},
+		DeletedFiles: map[string]struct{}{
+			"proto/bar/v1/bar.proto": {},
+		},
+	}
+	assert.True(t, changedLines.ContainsFile("proto/foo/v1/foo.proto"))
+	assert.True(t, changedLines.ContainsFile("./proto/foo/v1/foo.proto"))
+	assert.False(t, changedLines.ContainsFile("foo/v1/foo.proto"))
+	assert.False(t, changedLines.ContainsFile("proto/bar/v1/bar.proto"))
+	assert.True(t, changedLines.IsDeleted("proto/bar/v1/bar.proto"))
+	assert.False(t, changedLines.IsDeleted("proto/foo/v1/foo.proto"))
+	assert.True(t, changedLines.Intersects("proto/foo/v1/foo.proto", 9, 12))
+	assert.False(t, changedLines.Intersects("foo/v1/foo.proto", 1, 3))
+	// Files with the same name in other directories do not match.
+	assert.False(t, changedLines.Intersects("proto/foo/v1/foo.proto", 1, 2))
+	assert.Equal(t, []LineRange{{StartLine: 1, EndLine: 1}}, changedLines.LineRanges("other/foo/v1/foo.proto"))
+	assert.True(t, changedLines.Intersects("proto/foo/v1/foo.proto", 22, 0))
+	assert.False(t, changedLines.Intersects("proto/foo/v1/foo.proto", 10, 20))
+	assert.False(t, changedLines.Intersects("proto/bar/v1/bar.proto", 3, 3))
+}
@@ -16,6 +16,7 @@ package filelock
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
@@ -26,6 +27,9 @@ const (
 	DefaultLockRetryDelay = 200 * time.Millisecond
 )
 
+// ErrLockTimeout is returned when a file lock could not be acquired within the lock timeout.
+var ErrLockTimeout = errors.New("timed out waiting for file lock")
+
 // Unlocker unlocks a file lock.
 type Unlocker interface {
 	Unlock() error
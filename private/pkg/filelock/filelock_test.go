@@ -16,6 +16,7 @@ package filelock
 
 import (
 	"context"
+	"errors"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -77,3 +78,21 @@ func TestLockerBasic(t *testing.T) {
 	_, err = locker.Lock(ctx, absolutePath)
 	require.Error(t, err)
 }
+
+func TestLockTimeout(t *testing.T) {
+	t.Parallel()
+	tempDirPath := t.TempDir()
+	filePath := filepath.Join(tempDirPath, "path/to/lock")
+	unlocker, err := Lock(context.Background(), filePath)
+	require.NoError(t, err)
+	_, err = Lock(context.Background(), filePath, LockWithTimeout(100*time.Millisecond), LockWithRetryDelay(10*time.Millisecond))
+	require.ErrorIs(t, err, ErrLockTimeout)
+	require.ErrorContains(t, err, filePath)
+	// A cancelled caller context is not reported as a lock timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Lock(ctx, filePath, LockWithTimeout(100*time.Millisecond), LockWithRetryDelay(10*time.Millisecond))
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrLockTimeout))
+	require.NoError(t, unlocker.Unlock())
+}
@@ -16,6 +16,7 @@ package filelock
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -64,14 +65,25 @@ func lockForFunc(
 	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return nil, err
 	}
+	parentCtx := ctx
 	var cancel context.CancelFunc
 	if lockOptions.timeout != 0 {
 		ctx, cancel = context.WithTimeout(ctx, lockOptions.timeout)
 		defer cancel()
 	}
+	start := time.Now()
 	flock := flock.New(filePath)
 	locked, err := tryLockContextFunc(flock, ctx, lockOptions.retryDelay)
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && parentCtx.Err() == nil {
+			// Our own timeout fired, not the caller's context.
+			return nil, fmt.Errorf(
+				"%w: waited %v for %q, another process may be holding the lock",
+				ErrLockTimeout,
+				time.Since(start).Round(time.Millisecond),
+				filePath,
+			)
+		}
 		return nil, fmt.Errorf("could not get file lock %q: %w", filePath, err)
 	}
 	if !locked {
@@ -19,11 +19,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/command"
+	"github.com/bufbuild/buf/private/pkg/interrupt"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"github.com/bufbuild/buf/private/pkg/tmp"
@@ -94,6 +98,7 @@ func (c *cloner) CloneToBucket(
 	default:
 		return fmt.Errorf("invalid git url: %q", url)
 	}
+	defer interrupt.StartPhase(ctx, "cloning "+redactURL(url))()
 
 	if depth == 0 {
 		err := errors.New("depth must be > 0")
@@ -167,6 +172,17 @@ func (c *cloner) CloneToBucket(
 		"--git-dir="+bareDir.AbsPath(),
 		"fetch",
 		"--depth", depthArg,
+	)
+	var fetchStderr io.Writer = buffer
+	if c.options.ProgressReporter != nil && c.options.ProgressReporter != progress.NopReporter {
+		// git only writes progress when stderr is a terminal unless --progress is set.
+		fetchArgs = append(fetchArgs, "--progress")
+		tracker := c.options.ProgressReporter.Start("cloning "+redactURL(url), -1)
+		defer tracker.Done()
+		fetchStderr = io.MultiWriter(buffer, progress.NewStatusWriter(tracker))
+	}
+	fetchArgs = append(
+		fetchArgs,
 		bufCloneOrigin,
 		fetchRef,
 	)
@@ -183,7 +199,7 @@ func (c *cloner) CloneToBucket(
 		"git",
 		command.RunWithArgs(fetchArgs...),
 		command.RunWithEnv(app.EnvironMap(envContainer)),
-		command.RunWithStderr(buffer),
+		command.RunWithStderr(fetchStderr),
 	); err != nil {
 		return newGitCommandError(err, buffer, bareDir)
 	}
@@ -358,6 +374,16 @@ func getSSHKnownHostsFilePaths(sshKnownHostsFiles string) []string {
 	return filePaths
 }
 
+// redactURL removes any user information from the url, which may contain credentials.
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+	parsedURL.User = nil
+	return parsedURL.String()
+}
+
 // getRefspecsForName decides the refspecs to use in the subsequent git fetch,
 // git worktree add and git checkout. When checkoutRefspec is empty, Name
 // explicitly refer to a named ref and the checkout isn't a necessary step.
@@ -23,6 +23,7 @@ import (
 	"github.com/bufbuild/buf/private/pkg/app"
 	"github.com/bufbuild/buf/private/pkg/command"
 	"github.com/bufbuild/buf/private/pkg/normalpath"
+	"github.com/bufbuild/buf/private/pkg/progress"
 	"github.com/bufbuild/buf/private/pkg/storage"
 	"github.com/bufbuild/buf/private/pkg/storage/storageos"
 	"go.uber.org/zap"
@@ -130,6 +131,11 @@ type ClonerOptions struct {
 	HTTPSPasswordEnvKey      string
 	SSHKeyFileEnvKey         string
 	SSHKnownHostsFilesEnvKey string
+	// ProgressReporter reports the progress of git fetches.
+	//
+	// If nil or progress.NopReporter, progress is not reported, and git is not
+	// asked to write progress.
+	ProgressReporter progress.Reporter
 }
 
 // Lister lists files in git repositories.
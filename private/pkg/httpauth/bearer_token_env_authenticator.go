@@ -0,0 +1,58 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpauth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+)
+
+type bearerTokenEnvAuthenticator struct {
+	tokenKey string
+	hosts    []string
+}
+
+func newBearerTokenEnvAuthenticator(
+	tokenKey string,
+	hosts []string,
+) *bearerTokenEnvAuthenticator {
+	return &bearerTokenEnvAuthenticator{
+		tokenKey: tokenKey,
+		hosts:    hosts,
+	}
+}
+
+func (a *bearerTokenEnvAuthenticator) SetAuth(envContainer app.EnvContainer, request *http.Request) (bool, error) {
+	if request.URL == nil {
+		return false, errors.New("malformed request: no url")
+	}
+	if request.URL.Scheme == "" {
+		return false, errors.New("malformed request: no url scheme")
+	}
+	if request.URL.Scheme != "https" {
+		return false, nil
+	}
+	if !containsHost(a.hosts, request.URL.Hostname()) {
+		return false, nil
+	}
+	token := envContainer.Env(a.tokenKey)
+	if token == "" {
+		return false, nil
+	}
+	request.Header.Set("Authorization", "Bearer "+token)
+	return true, nil
+}
@@ -43,6 +43,50 @@ func NewEnvAuthenticator(usernameKey string, passwordKey string) Authenticator {
 	)
 }
 
+// NewBearerTokenEnvAuthenticator returns a new Authenticator that sets the token
+// read from the environment using the given key as a bearer token.
+//
+// Tokens are only sent to the given hosts. Does nothing if the token is not set.
+func NewBearerTokenEnvAuthenticator(tokenKey string, hosts ...string) Authenticator {
+	return newBearerTokenEnvAuthenticator(
+		tokenKey,
+		hosts,
+	)
+}
+
+// OAuth2ClientCredentialsEnvKeys are the environment variable keys used to configure
+// an OAuth2 client credentials Authenticator.
+type OAuth2ClientCredentialsEnvKeys struct {
+	// TokenURLKey is the key for the https token endpoint of the identity provider.
+	TokenURLKey string
+	// ClientIDKey is the key for the OAuth2 client ID.
+	ClientIDKey string
+	// ClientSecretKey is the key for the OAuth2 client secret.
+	ClientSecretKey string
+	// ScopesKey is the key for the optional comma or space-separated scopes to request.
+	ScopesKey string
+	// HostsKey is the key for the optional comma or space-separated hosts to send
+	// tokens to. If the value is empty, tokens are only sent to the host of the
+	// token endpoint.
+	HostsKey string
+}
+
+// NewOAuth2ClientCredentialsAuthenticator returns a new Authenticator that uses the OAuth2
+// client credentials flow to get a bearer token from a token endpoint.
+//
+// The token endpoint and credentials are read from the environment using the given keys.
+// Does nothing if none of the token URL, client ID, and client secret are set, and errors
+// if only some of them are set. Tokens are cached until they expire.
+func NewOAuth2ClientCredentialsAuthenticator(
+	httpClient *http.Client,
+	envKeys OAuth2ClientCredentialsEnvKeys,
+) Authenticator {
+	return newOAuth2ClientCredentialsAuthenticator(
+		httpClient,
+		envKeys,
+	)
+}
+
 // NewNetrcAuthenticator returns a new netrc Authenticator.
 func NewNetrcAuthenticator() Authenticator {
 	return newNetrcAuthenticator()
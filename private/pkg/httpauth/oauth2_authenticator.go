@@ -0,0 +1,217 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+)
+
+// oauth2ExpiryDelta is subtracted from the token expiry so that we do not
+// send a token that expires while the request is in flight.
+const oauth2ExpiryDelta = 10 * time.Second
+
+type oauth2ClientCredentialsAuthenticator struct {
+	httpClient *http.Client
+	envKeys    OAuth2ClientCredentialsEnvKeys
+
+	lock sync.Mutex
+	// tokens are cached by token URL, client ID, and scopes.
+	tokens map[string]*oauth2Token
+}
+
+func newOAuth2ClientCredentialsAuthenticator(
+	httpClient *http.Client,
+	envKeys OAuth2ClientCredentialsEnvKeys,
+) *oauth2ClientCredentialsAuthenticator {
+	return &oauth2ClientCredentialsAuthenticator{
+		httpClient: httpClient,
+		envKeys:    envKeys,
+		tokens:     make(map[string]*oauth2Token),
+	}
+}
+
+func (a *oauth2ClientCredentialsAuthenticator) SetAuth(envContainer app.EnvContainer, request *http.Request) (bool, error) {
+	if request.URL == nil {
+		return false, errors.New("malformed request: no url")
+	}
+	if request.URL.Scheme == "" {
+		return false, errors.New("malformed request: no url scheme")
+	}
+	if request.URL.Scheme != "https" {
+		return false, nil
+	}
+	tokenURL := envContainer.Env(a.envKeys.TokenURLKey)
+	clientID := envContainer.Env(a.envKeys.ClientIDKey)
+	clientSecret := envContainer.Env(a.envKeys.ClientSecretKey)
+	if tokenURL == "" && clientID == "" && clientSecret == "" {
+		return false, nil
+	}
+	if tokenURL == "" || clientID == "" || clientSecret == "" {
+		return false, fmt.Errorf(
+			"%s, %s, and %s must all be set to use OAuth2 client credentials",
+			a.envKeys.TokenURLKey,
+			a.envKeys.ClientIDKey,
+			a.envKeys.ClientSecretKey,
+		)
+	}
+	parsedTokenURL, err := url.Parse(tokenURL)
+	if err != nil {
+		return false, fmt.Errorf("%s is invalid: %w", a.envKeys.TokenURLKey, err)
+	}
+	if parsedTokenURL.Scheme != "https" {
+		return false, fmt.Errorf("%s must use https: %s", a.envKeys.TokenURLKey, tokenURL)
+	}
+	hosts := splitEnvList(envContainer.Env(a.envKeys.HostsKey))
+	if len(hosts) == 0 {
+		// Never send the token to hosts that were not explicitly allowed. The
+		// identity provider is the only host known to be trusted with it.
+		hosts = []string{parsedTokenURL.Hostname()}
+	}
+	if !containsHost(hosts, request.URL.Hostname()) {
+		return false, nil
+	}
+	scopes := splitEnvList(envContainer.Env(a.envKeys.ScopesKey))
+	token, err := a.getToken(request, tokenURL, clientID, clientSecret, scopes)
+	if err != nil {
+		return false, err
+	}
+	request.Header.Set("Authorization", token.tokenType+" "+token.accessToken)
+	return true, nil
+}
+
+func (a *oauth2ClientCredentialsAuthenticator) getToken(
+	request *http.Request,
+	tokenURL string,
+	clientID string,
+	clientSecret string,
+	scopes []string,
+) (*oauth2Token, error) {
+	cacheKey := strings.Join([]string{tokenURL, clientID, strings.Join(scopes, " ")}, "\n")
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if token, ok := a.tokens[cacheKey]; ok && token.valid() {
+		return token, nil
+	}
+	values := url.Values{
+		"grant_type": {"client_credentials"},
+	}
+	if len(scopes) > 0 {
+		values.Set("scope", strings.Join(scopes, " "))
+	}
+	tokenRequest, err := http.NewRequestWithContext(
+		request.Context(),
+		http.MethodPost,
+		tokenURL,
+		strings.NewReader(values.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenRequest.Header.Set("Accept", "application/json")
+	// RFC 6749 section 2.3.1 requires the client credentials to be form-encoded
+	// before being used as the basic auth username and password.
+	tokenRequest.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	response, err := a.httpClient.Do(tokenRequest)
+	if err != nil {
+		return nil, fmt.Errorf("could not get OAuth2 token from %s: %w", tokenURL, err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("could not read OAuth2 token response from %s: %w", tokenURL, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get OAuth2 token from %s: %s", tokenURL, getOAuth2ErrorMessage(response.Status, data))
+	}
+	var externalToken externalOAuth2Token
+	if err := json.Unmarshal(data, &externalToken); err != nil {
+		return nil, fmt.Errorf("could not parse OAuth2 token response from %s: %w", tokenURL, err)
+	}
+	if externalToken.AccessToken == "" {
+		return nil, fmt.Errorf("OAuth2 token response from %s did not contain an access_token", tokenURL)
+	}
+	token := &oauth2Token{
+		accessToken: externalToken.AccessToken,
+		tokenType:   "Bearer",
+	}
+	// The token type is case-insensitive, but many servers only accept "Bearer".
+	if externalToken.TokenType != "" && !strings.EqualFold(externalToken.TokenType, "bearer") {
+		token.tokenType = externalToken.TokenType
+	}
+	if externalToken.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(externalToken.ExpiresIn) * time.Second)
+	}
+	a.tokens[cacheKey] = token
+	return token, nil
+}
+
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	// zero if the token does not expire.
+	expiry time.Time
+}
+
+func (t *oauth2Token) valid() bool {
+	return t.expiry.IsZero() || time.Now().Add(oauth2ExpiryDelta).Before(t.expiry)
+}
+
+type externalOAuth2Token struct {
+	AccessToken string `json:"access_token,omitempty"`
+	TokenType   string `json:"token_type,omitempty"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+}
+
+type externalOAuth2Error struct {
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func getOAuth2ErrorMessage(status string, data []byte) string {
+	var externalError externalOAuth2Error
+	if err := json.Unmarshal(data, &externalError); err != nil || externalError.Error == "" {
+		return status
+	}
+	if externalError.ErrorDescription != "" {
+		return fmt.Sprintf("%s: %s: %s", status, externalError.Error, externalError.ErrorDescription)
+	}
+	return fmt.Sprintf("%s: %s", status, externalError.Error)
+}
+
+func splitEnvList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+func containsHost(hosts []string, host string) bool {
+	for _, candidate := range hosts {
+		if strings.EqualFold(candidate, host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,279 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bufbuild/buf/private/pkg/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTokenURLKey     = "TOKEN_URL"
+	testClientIDKey     = "CLIENT_ID"
+	testClientSecretKey = "CLIENT_SECRET"
+	testScopesKey       = "SCOPES"
+	testHostsKey        = "HOSTS"
+)
+
+func TestOAuth2ClientCredentialsAuthenticator(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name string
+		// env is the environment, "{{server}}" in the token URL is replaced
+		// with the URL of the token server.
+		env map[string]string
+		// requestURL is the URL of the authenticated request, if empty a URL on
+		// the token server is used.
+		requestURL            string
+		tokenStatus           int
+		tokenResponse         string
+		expectedOK            bool
+		expectedAuthorization string
+		expectedError         string
+		expectedTokenRequests int32
+	}{
+		{
+			name:                  "token sent to token endpoint host by default",
+			env:                   testOAuth2Env(nil),
+			tokenResponse:         `{"access_token":"abc","token_type":"bearer","expires_in":3600}`,
+			expectedOK:            true,
+			expectedAuthorization: "Bearer abc",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:          "token not sent to other hosts by default",
+			env:           testOAuth2Env(nil),
+			requestURL:    "https://example.com/archive.tar.gz",
+			tokenResponse: `{"access_token":"abc"}`,
+		},
+		{
+			name:                  "token sent to listed hosts",
+			env:                   testOAuth2Env(map[string]string{testHostsKey: "foo.com, EXAMPLE.com"}),
+			requestURL:            "https://example.com/archive.tar.gz",
+			tokenResponse:         `{"access_token":"abc"}`,
+			expectedOK:            true,
+			expectedAuthorization: "Bearer abc",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:          "token not sent to token endpoint host if not listed",
+			env:           testOAuth2Env(map[string]string{testHostsKey: "example.com"}),
+			tokenResponse: `{"access_token":"abc"}`,
+		},
+		{
+			name:          "token not sent over http",
+			env:           testOAuth2Env(map[string]string{testHostsKey: "example.com"}),
+			requestURL:    "http://example.com/archive.tar.gz",
+			tokenResponse: `{"access_token":"abc"}`,
+		},
+		{
+			name:       "no credentials",
+			env:        map[string]string{},
+			requestURL: "https://example.com/archive.tar.gz",
+		},
+		{
+			name:                  "custom token type",
+			env:                   testOAuth2Env(nil),
+			tokenResponse:         `{"access_token":"abc","token_type":"MAC"}`,
+			expectedOK:            true,
+			expectedAuthorization: "MAC abc",
+			expectedTokenRequests: 1,
+		},
+		{
+			name: "partial credentials",
+			env: map[string]string{
+				testTokenURLKey: "{{server}}/token",
+				testClientIDKey: "id",
+			},
+			expectedError: "TOKEN_URL, CLIENT_ID, and CLIENT_SECRET must all be set",
+		},
+		{
+			name:          "token URL not https",
+			env:           testOAuth2Env(map[string]string{testTokenURLKey: "http://example.com/token"}),
+			expectedError: "TOKEN_URL must use https",
+		},
+		{
+			name:                  "token error response",
+			env:                   testOAuth2Env(nil),
+			tokenStatus:           http.StatusUnauthorized,
+			tokenResponse:         `{"error":"invalid_client","error_description":"bad secret"}`,
+			expectedError:         "401 Unauthorized: invalid_client: bad secret",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "token response without access token",
+			env:                   testOAuth2Env(nil),
+			tokenResponse:         `{"token_type":"bearer"}`,
+			expectedError:         "did not contain an access_token",
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "invalid token response",
+			env:                   testOAuth2Env(nil),
+			tokenResponse:         `not json`,
+			expectedError:         "could not parse OAuth2 token response",
+			expectedTokenRequests: 1,
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			server, tokenRequests := newTestOAuth2Server(t, testCase.tokenStatus, testCase.tokenResponse)
+			env := make(map[string]string, len(testCase.env))
+			for key, value := range testCase.env {
+				if key == testTokenURLKey && value == "{{server}}/token" {
+					value = server.URL + "/token"
+				}
+				env[key] = value
+			}
+			requestURL := testCase.requestURL
+			if requestURL == "" {
+				requestURL = server.URL + "/archive.tar.gz"
+			}
+			request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+			require.NoError(t, err)
+			authenticator := newOAuth2ClientCredentialsAuthenticator(server.Client(), testOAuth2EnvKeys())
+			ok, err := authenticator.SetAuth(app.NewEnvContainer(env), request)
+			if testCase.expectedError != "" {
+				assert.ErrorContains(t, err, testCase.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, testCase.expectedOK, ok)
+			assert.Equal(t, testCase.expectedAuthorization, request.Header.Get("Authorization"))
+			assert.Equal(t, testCase.expectedTokenRequests, tokenRequests.Load())
+		})
+	}
+}
+
+func TestOAuth2ClientCredentialsAuthenticatorCache(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                  string
+		tokenResponse         string
+		expectedTokenRequests int32
+	}{
+		{
+			name:                  "token cached until expiry",
+			tokenResponse:         `{"access_token":"abc","expires_in":3600}`,
+			expectedTokenRequests: 1,
+		},
+		{
+			name:                  "token without expiry cached",
+			tokenResponse:         `{"access_token":"abc"}`,
+			expectedTokenRequests: 1,
+		},
+		{
+			// The token expires within oauth2ExpiryDelta, so it is refreshed on every request.
+			name:                  "token refreshed when about to expire",
+			tokenResponse:         `{"access_token":"abc","expires_in":1}`,
+			expectedTokenRequests: 3,
+		},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			server, tokenRequests := newTestOAuth2Server(t, http.StatusOK, testCase.tokenResponse)
+			envContainer := app.NewEnvContainer(
+				testOAuth2Env(
+					map[string]string{
+						testTokenURLKey: server.URL + "/token",
+						testScopesKey:   "read write",
+					},
+				),
+			)
+			authenticator := newOAuth2ClientCredentialsAuthenticator(server.Client(), testOAuth2EnvKeys())
+			for i := 0; i < 3; i++ {
+				request, err := http.NewRequest(http.MethodGet, server.URL+"/archive.tar.gz", nil)
+				require.NoError(t, err)
+				ok, err := authenticator.SetAuth(envContainer, request)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				assert.Equal(t, "Bearer abc", request.Header.Get("Authorization"))
+			}
+			assert.Equal(t, testCase.expectedTokenRequests, tokenRequests.Load())
+		})
+	}
+}
+
+// newTestOAuth2Server returns a new https server with a client credentials token
+// endpoint at /token that accepts the client ID "id" and secret "secret".
+//
+// The returned counter is the number of requests made to the token endpoint.
+func newTestOAuth2Server(t *testing.T, tokenStatus int, tokenResponse string) (*httptest.Server, *atomic.Int32) {
+	tokenRequests := &atomic.Int32{}
+	server := httptest.NewTLSServer(
+		http.HandlerFunc(
+			func(responseWriter http.ResponseWriter, request *http.Request) {
+				if request.URL.Path != "/token" || request.Method != http.MethodPost {
+					http.NotFound(responseWriter, request)
+					return
+				}
+				tokenRequests.Add(1)
+				clientID, clientSecret, ok := request.BasicAuth()
+				if !ok || clientID != "id" || clientSecret != "secret" {
+					http.Error(responseWriter, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+					return
+				}
+				if request.FormValue("grant_type") != "client_credentials" {
+					http.Error(responseWriter, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+					return
+				}
+				if scopes := request.FormValue("scope"); scopes != "" && scopes != "read write" {
+					http.Error(responseWriter, `{"error":"invalid_scope"}`, http.StatusBadRequest)
+					return
+				}
+				responseWriter.Header().Set("Content-Type", "application/json")
+				if tokenStatus != 0 {
+					responseWriter.WriteHeader(tokenStatus)
+				}
+				_, _ = responseWriter.Write([]byte(tokenResponse))
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server, tokenRequests
+}
+
+func testOAuth2EnvKeys() OAuth2ClientCredentialsEnvKeys {
+	return OAuth2ClientCredentialsEnvKeys{
+		TokenURLKey:     testTokenURLKey,
+		ClientIDKey:     testClientIDKey,
+		ClientSecretKey: testClientSecretKey,
+		ScopesKey:       testScopesKey,
+		HostsKey:        testHostsKey,
+	}
+}
+
+// testOAuth2Env returns a complete environment with the overrides applied.
+func testOAuth2Env(overrides map[string]string) map[string]string {
+	env := map[string]string{
+		testTokenURLKey:     "{{server}}/token",
+		testClientIDKey:     "id",
+		testClientSecretKey: "secret",
+	}
+	for key, value := range overrides {
+		env[key] = value
+	}
+	return env
+}
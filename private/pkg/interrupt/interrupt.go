@@ -16,10 +16,19 @@ package interrupt
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
+	"sort"
+	"sync"
 )
 
+// ErrInterrupted is the cause of the cancellation of contexts returned by
+// WithCancel when an interrupt signal is sent.
+//
+// Use context.Cause to check whether a context was cancelled by an interrupt.
+var ErrInterrupted = errors.New("interrupted")
+
 var signals = append(
 	[]os.Signal{
 		os.Interrupt,
@@ -28,15 +37,39 @@ var signals = append(
 )
 
 // WithCancel returns a context that is cancelled if interrupt signals are sent.
+//
+// The context is cancelled with the cause ErrInterrupted, and records the
+// phases started with StartPhase, so that InterruptedPhases can report what
+// was running when the interrupt signal was sent.
 func WithCancel(ctx context.Context) (context.Context, context.CancelFunc) {
 	signalC, closer := NewSignalChannel()
-	ctx, cancel := context.WithCancel(ctx)
-	go func() {
-		<-signalC
-		closer()
-		cancel()
-	}()
-	return ctx, cancel
+	return withCancel(ctx, signalC, closer)
+}
+
+// StartPhase records that the phase, such as "cloning https://github.com/foo/bar",
+// started, and returns a function to call when it ends.
+//
+// Phases are only recorded for contexts derived from WithCancel. Phases may
+// run concurrently.
+func StartPhase(ctx context.Context, phase string) func() {
+	phaseTracker, ok := ctx.Value(phaseTrackerContextKey{}).(*phaseTracker)
+	if !ok {
+		return func() {}
+	}
+	return phaseTracker.start(phase)
+}
+
+// InterruptedPhases returns the sorted phases that were running when an
+// interrupt signal was sent.
+//
+// Returns empty if no interrupt signal was sent, the context was not derived
+// from WithCancel, or no phase was running.
+func InterruptedPhases(ctx context.Context) []string {
+	phaseTracker, ok := ctx.Value(phaseTrackerContextKey{}).(*phaseTracker)
+	if !ok {
+		return nil
+	}
+	return phaseTracker.interruptedPhases()
 }
 
 // NewSignalChannel returns a new channel for interrupt signals.
@@ -50,3 +83,73 @@ func NewSignalChannel() (<-chan os.Signal, func()) {
 		close(signalC)
 	}
 }
+
+func withCancel(
+	ctx context.Context,
+	signalC <-chan os.Signal,
+	closer func(),
+) (context.Context, context.CancelFunc) {
+	phaseTracker := newPhaseTracker()
+	ctx, cancel := context.WithCancelCause(context.WithValue(ctx, phaseTrackerContextKey{}, phaseTracker))
+	var closeOnce sync.Once
+	go func() {
+		if _, ok := <-signalC; ok {
+			phaseTracker.interrupt()
+			closeOnce.Do(closer)
+			cancel(ErrInterrupted)
+		}
+	}()
+	return ctx, func() {
+		// Closing the signal channel stops the goroutine above.
+		closeOnce.Do(closer)
+		cancel(context.Canceled)
+	}
+}
+
+type phaseTrackerContextKey struct{}
+
+type phaseTracker struct {
+	// phase -> number of times the phase is running
+	activePhases map[string]int
+	// nil until interrupted
+	interrupted []string
+	lock        sync.Mutex
+}
+
+func newPhaseTracker() *phaseTracker {
+	return &phaseTracker{
+		activePhases: make(map[string]int),
+	}
+}
+
+func (p *phaseTracker) start(phase string) func() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.activePhases[phase]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.lock.Lock()
+			defer p.lock.Unlock()
+			if p.activePhases[phase]--; p.activePhases[phase] == 0 {
+				delete(p.activePhases, phase)
+			}
+		})
+	}
+}
+
+func (p *phaseTracker) interrupt() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.interrupted = make([]string, 0, len(p.activePhases))
+	for phase := range p.activePhases {
+		p.interrupted = append(p.interrupted, phase)
+	}
+	sort.Strings(p.interrupted)
+}
+
+func (p *phaseTracker) interruptedPhases() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.interrupted
+}
@@ -0,0 +1,62 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interrupt
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCancelInterrupted(t *testing.T) {
+	t.Parallel()
+	signalC := make(chan os.Signal, 1)
+	ctx, cancel := withCancel(context.Background(), signalC, func() { close(signalC) })
+	defer cancel()
+
+	endGenerate := StartPhase(ctx, "running plugin go")
+	endClone := StartPhase(ctx, "cloning https://github.com/foo/bar")
+	endClone()
+	endClone()
+	endFirstPush := StartPhase(ctx, "pushing module")
+	endSecondPush := StartPhase(ctx, "pushing module")
+	endFirstPush()
+	signalC <- os.Interrupt
+	<-ctx.Done()
+	endGenerate()
+	endSecondPush()
+
+	assert.ErrorIs(t, context.Cause(ctx), ErrInterrupted)
+	assert.Equal(t, []string{"pushing module", "running plugin go"}, InterruptedPhases(ctx))
+}
+
+func TestWithCancelNotInterrupted(t *testing.T) {
+	t.Parallel()
+	signalC := make(chan os.Signal, 1)
+	ctx, cancel := withCancel(context.Background(), signalC, func() { close(signalC) })
+	StartPhase(ctx, "running plugin go")()
+	cancel()
+	cancel()
+	<-ctx.Done()
+
+	require.ErrorIs(t, context.Cause(ctx), context.Canceled)
+	assert.Empty(t, InterruptedPhases(ctx))
+	// Phases of contexts not derived from WithCancel are ignored.
+	StartPhase(context.Background(), "running plugin go")()
+	assert.Empty(t, InterruptedPhases(context.Background()))
+}
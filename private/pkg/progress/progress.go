@@ -0,0 +1,114 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress reports the progress of long-running transfers such as downloads.
+package progress
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// NopReporter is a no-op Reporter.
+	//
+	// This generally aligns with the --progress flag being disabled.
+	NopReporter = nopReporter{}
+)
+
+// Reporter reports the progress of operations.
+type Reporter interface {
+	// Start starts reporting the progress of a new operation with the given name.
+	//
+	// total is the expected number of bytes, or -1 if unknown. Done must be called
+	// on the returned Tracker when the operation completes.
+	Start(name string, total int64) Tracker
+}
+
+// Tracker tracks the progress of a single operation.
+type Tracker interface {
+	// Add records that n additional bytes were transferred.
+	Add(n int64)
+	// SetStatus sets a free-form status for the operation.
+	//
+	// This is used for operations that report their own progress, such as
+	// external commands, and is displayed in place of the byte counts.
+	SetStatus(status string)
+	// Done marks the operation as complete.
+	//
+	// Done may be called multiple times.
+	Done()
+}
+
+// NewWriteReporter returns a new Reporter that writes to the given Writer.
+//
+// If interactive is true, progress is redrawn in place on a single line, and a
+// final line is written when each operation completes. This should only be used
+// when the writer is a terminal. Otherwise, a line is written periodically for
+// operations that take longer than a few seconds, so that slow downloads do not
+// look like hangs.
+//
+// The trimmed prefix is printed with a : before each line.
+func NewWriteReporter(writer io.Writer, prefix string, interactive bool) Reporter {
+	if interactive {
+		return newWriteReporter(writer, prefix, true, interactiveInterval)
+	}
+	return newWriteReporter(writer, prefix, false, logInterval)
+}
+
+// NewReadCloser returns a new ReadCloser that records bytes read with the Tracker.
+//
+// Done is called on the Tracker when the ReadCloser is closed.
+func NewReadCloser(readCloser io.ReadCloser, tracker Tracker) io.ReadCloser {
+	return newReadCloser(readCloser, tracker)
+}
+
+// NewStatusWriter returns a new Writer that sets the status of the Tracker to the
+// last non-empty line written.
+//
+// Lines may be terminated by either \n or \r, which matches the progress output of
+// commands such as git.
+func NewStatusWriter(tracker Tracker) io.Writer {
+	return newStatusWriter(tracker)
+}
+
+// NewTransport returns a new http.RoundTripper that reports the progress of reading
+// response bodies of successful GET requests.
+//
+// The expected size is taken from the Content-Length of the response, which may not
+// be set when the response is chunked or modified by a proxy. In that case, progress
+// is reported without a total.
+func NewTransport(delegate http.RoundTripper, reporter Reporter) http.RoundTripper {
+	return newTransport(delegate, reporter)
+}
+
+const (
+	interactiveInterval = 100 * time.Millisecond
+	logInterval         = 5 * time.Second
+)
+
+type nopReporter struct{}
+
+func (nopReporter) Start(string, int64) Tracker {
+	return nopTracker{}
+}
+
+type nopTracker struct{}
+
+func (nopTracker) Add(int64) {}
+
+func (nopTracker) SetStatus(string) {}
+
+func (nopTracker) Done() {}
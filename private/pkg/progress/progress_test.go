@@ -0,0 +1,178 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatProgress(t *testing.T) {
+	t.Parallel()
+	assert.Equal(
+		t,
+		"foo: 1.0 MiB / 4.0 MiB (25%), 512.0 KiB/s, ETA 6s, 2s elapsed",
+		formatProgress("foo", 1<<20, 4<<20, "", 2*time.Second),
+	)
+	assert.Equal(
+		t,
+		"foo: 1.0 MiB, 512.0 KiB/s, 2s elapsed",
+		formatProgress("foo", 1<<20, -1, "", 2*time.Second),
+	)
+	assert.Equal(
+		t,
+		"foo: 10s elapsed",
+		formatProgress("foo", 0, 4<<20, "", 10*time.Second),
+	)
+	assert.Equal(
+		t,
+		"foo: Receiving objects: 50% (5/10), 10s elapsed",
+		formatProgress("foo", 0, -1, "Receiving objects: 50% (5/10)", 10*time.Second),
+	)
+	assert.Equal(t, "foo: done, 1.5 KiB in 3s", formatDone("foo", 1536, 3*time.Second))
+	assert.Equal(t, "foo: done in 300ms", formatDone("foo", 0, 300*time.Millisecond))
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "0 B", formatBytes(0))
+	assert.Equal(t, "1023 B", formatBytes(1023))
+	assert.Equal(t, "1.0 KiB", formatBytes(1024))
+	assert.Equal(t, "1.5 MiB", formatBytes(3<<19))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
+
+func TestWriteReporter(t *testing.T) {
+	t.Parallel()
+	writer := &lockedBuffer{}
+	reporter := newWriteReporter(writer, "buf", false, 10*time.Millisecond)
+	tracker := reporter.Start("downloading foo", 100)
+	tracker.Add(50)
+	time.Sleep(50 * time.Millisecond)
+	tracker.Done()
+	tracker.Done()
+	lines := strings.Split(strings.TrimSuffix(writer.String(), "\n"), "\n")
+	require.Greater(t, len(lines), 1)
+	assert.True(t, strings.HasPrefix(lines[0], "buf: downloading foo: 50 B / 100 B (50%)"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[len(lines)-1], "buf: downloading foo: done, 50 B in "), lines[len(lines)-1])
+
+	// Quick operations are not reported when not interactive.
+	writer = &lockedBuffer{}
+	reporter = newWriteReporter(writer, "buf", false, time.Hour)
+	tracker = reporter.Start("downloading foo", 100)
+	tracker.Add(100)
+	tracker.Done()
+	assert.Empty(t, writer.String())
+
+	// Interactive reporting always writes a final line.
+	writer = &lockedBuffer{}
+	reporter = newWriteReporter(writer, "", true, time.Hour)
+	tracker = reporter.Start("downloading foo", 100)
+	tracker.Add(100)
+	tracker.Done()
+	assert.True(t, strings.HasPrefix(writer.String(), "\rdownloading foo: done, 100 B in "), writer.String())
+	assert.True(t, strings.HasSuffix(writer.String(), "\x1b[K\n"), writer.String())
+}
+
+func TestStatusWriter(t *testing.T) {
+	t.Parallel()
+	tracker := &testTracker{}
+	writer := NewStatusWriter(tracker)
+	_, err := writer.Write([]byte("Receiving objects:  10% (1/10)\rReceiving objects:  50% (5/10)\rReceiving"))
+	require.NoError(t, err)
+	assert.Equal(t, "Receiving objects:  50% (5/10)", tracker.status)
+	_, err = writer.Write([]byte(" objects: 100% (10/10), done.\n\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Receiving objects: 100% (10/10), done.", tracker.status)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(responseWriter http.ResponseWriter, request *http.Request) {
+		_, _ = responseWriter.Write([]byte("hello world"))
+	}))
+	t.Cleanup(server.Close)
+	reporter := &testReporter{}
+	client := &http.Client{Transport: NewTransport(nil, reporter)}
+	response, err := client.Get(server.URL + "/path/to/file.tar?token=secret")
+	require.NoError(t, err)
+	data, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	assert.Equal(t, "hello world", string(data))
+	require.Len(t, reporter.trackers, 1)
+	assert.Equal(t, "downloading "+strings.TrimPrefix(server.URL, "http://")+"/path/to/file.tar", reporter.names[0])
+	assert.Equal(t, int64(11), reporter.totals[0])
+	assert.Equal(t, int64(11), reporter.trackers[0].current)
+	assert.True(t, reporter.trackers[0].done)
+}
+
+type lockedBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.buffer.Write(p)
+}
+
+func (l *lockedBuffer) String() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.buffer.String()
+}
+
+type testReporter struct {
+	names    []string
+	totals   []int64
+	trackers []*testTracker
+}
+
+func (r *testReporter) Start(name string, total int64) Tracker {
+	tracker := &testTracker{}
+	r.names = append(r.names, name)
+	r.totals = append(r.totals, total)
+	r.trackers = append(r.trackers, tracker)
+	return tracker
+}
+
+type testTracker struct {
+	current int64
+	status  string
+	done    bool
+}
+
+func (t *testTracker) Add(n int64) {
+	t.current += n
+}
+
+func (t *testTracker) SetStatus(status string) {
+	t.status = status
+}
+
+func (t *testTracker) Done() {
+	t.done = true
+}
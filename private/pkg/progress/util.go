@@ -0,0 +1,99 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+type readCloser struct {
+	readCloser io.ReadCloser
+	tracker    Tracker
+}
+
+func newReadCloser(delegate io.ReadCloser, tracker Tracker) *readCloser {
+	return &readCloser{
+		readCloser: delegate,
+		tracker:    tracker,
+	}
+}
+
+func (r *readCloser) Read(p []byte) (int, error) {
+	n, err := r.readCloser.Read(p)
+	r.tracker.Add(int64(n))
+	return n, err
+}
+
+func (r *readCloser) Close() error {
+	r.tracker.Done()
+	return r.readCloser.Close()
+}
+
+type statusWriter struct {
+	tracker Tracker
+	// partial is the current line that has not yet been terminated.
+	partial strings.Builder
+}
+
+func newStatusWriter(tracker Tracker) *statusWriter {
+	return &statusWriter{
+		tracker: tracker,
+	}
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	for _, b := range p {
+		if b != '\n' && b != '\r' {
+			s.partial.WriteByte(b)
+			continue
+		}
+		if line := strings.TrimSpace(s.partial.String()); line != "" {
+			s.tracker.SetStatus(line)
+		}
+		s.partial.Reset()
+	}
+	return len(p), nil
+}
+
+type transport struct {
+	delegate http.RoundTripper
+	reporter Reporter
+}
+
+func newTransport(delegate http.RoundTripper, reporter Reporter) *transport {
+	if delegate == nil {
+		delegate = http.DefaultTransport
+	}
+	return &transport{
+		delegate: delegate,
+		reporter: reporter,
+	}
+}
+
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.delegate.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	if request.Method != http.MethodGet || response.StatusCode != http.StatusOK || response.Body == nil {
+		return response, nil
+	}
+	// Do not report credentials or query parameters, which may contain tokens.
+	name := "downloading " + request.URL.Host + request.URL.EscapedPath()
+	response.Body = newReadCloser(response.Body, t.reporter.Start(name, response.ContentLength))
+	return response, nil
+}
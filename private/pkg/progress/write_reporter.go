@@ -0,0 +1,212 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type writeReporter struct {
+	writer      io.Writer
+	prefix      string
+	interactive bool
+	interval    time.Duration
+
+	// lock guards writes to writer so that lines from concurrent
+	// operations are not interleaved.
+	lock sync.Mutex
+}
+
+func newWriteReporter(
+	writer io.Writer,
+	prefix string,
+	interactive bool,
+	interval time.Duration,
+) *writeReporter {
+	prefix = strings.TrimSpace(prefix)
+	if prefix != "" {
+		prefix = prefix + ": "
+	}
+	return &writeReporter{
+		writer:      writer,
+		prefix:      prefix,
+		interactive: interactive,
+		interval:    interval,
+	}
+}
+
+func (w *writeReporter) Start(name string, total int64) Tracker {
+	return newWriteTracker(w, name, total)
+}
+
+// printLine prints the line. If final is false and the reporter is interactive,
+// the line is redrawn in place.
+//
+// Errors are ignored as progress reporting is best-effort.
+func (w *writeReporter) printLine(line string, final bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.interactive {
+		_, _ = w.writer.Write([]byte(w.prefix + line + "\n"))
+		return
+	}
+	// \r returns to the start of the line and \x1b[K clears the rest of it.
+	value := "\r" + w.prefix + line + "\x1b[K"
+	if final {
+		value += "\n"
+	}
+	_, _ = w.writer.Write([]byte(value))
+}
+
+type writeTracker struct {
+	reporter *writeReporter
+	name     string
+	total    int64
+	start    time.Time
+	done     chan struct{}
+	doneOnce sync.Once
+	stopped  chan struct{}
+
+	lock    sync.Mutex
+	current int64
+	status  string
+	printed bool
+}
+
+func newWriteTracker(reporter *writeReporter, name string, total int64) *writeTracker {
+	writeTracker := &writeTracker{
+		reporter: reporter,
+		name:     name,
+		total:    total,
+		start:    time.Now(),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go writeTracker.run()
+	return writeTracker
+}
+
+func (w *writeTracker) Add(n int64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.current += n
+}
+
+func (w *writeTracker) SetStatus(status string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.status = status
+}
+
+func (w *writeTracker) Done() {
+	w.doneOnce.Do(func() {
+		close(w.done)
+		<-w.stopped
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		// Quick operations are not reported when not interactive, to avoid
+		// noise in logs.
+		if w.reporter.interactive || w.printed {
+			w.reporter.printLine(
+				formatDone(w.name, w.current, time.Since(w.start)),
+				true,
+			)
+		}
+	})
+}
+
+func (w *writeTracker) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.reporter.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.lock.Lock()
+			w.reporter.printLine(
+				formatProgress(w.name, w.current, w.total, w.status, time.Since(w.start)),
+				false,
+			)
+			w.printed = true
+			w.lock.Unlock()
+		}
+	}
+}
+
+// formatProgress formats the progress of an operation.
+//
+// total is -1 if unknown.
+func formatProgress(name string, current int64, total int64, status string, elapsed time.Duration) string {
+	var parts []string
+	switch {
+	case status != "":
+		parts = append(parts, status)
+	case current > 0:
+		var rate float64
+		if seconds := elapsed.Seconds(); seconds > 0 {
+			rate = float64(current) / seconds
+		}
+		if total > 0 {
+			parts = append(
+				parts,
+				fmt.Sprintf("%s / %s (%d%%)", formatBytes(current), formatBytes(total), current*100/total),
+			)
+		} else {
+			parts = append(parts, formatBytes(current))
+		}
+		if rate > 0 {
+			parts = append(parts, formatBytes(int64(rate))+"/s")
+			if total > current {
+				parts = append(parts, "ETA "+formatDuration(time.Duration(float64(total-current)/rate*float64(time.Second))))
+			}
+		}
+	}
+	parts = append(parts, formatDuration(elapsed)+" elapsed")
+	return name + ": " + strings.Join(parts, ", ")
+}
+
+// formatDone formats the completion of an operation.
+func formatDone(name string, current int64, elapsed time.Duration) string {
+	if current > 0 {
+		return fmt.Sprintf("%s: done, %s in %s", name, formatBytes(current), formatDuration(elapsed))
+	}
+	return fmt.Sprintf("%s: done in %s", name, formatDuration(elapsed))
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
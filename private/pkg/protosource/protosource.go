@@ -444,6 +444,15 @@ type Oneof interface {
 	Fields() []Field
 }
 
+// DeprecatableDescriptor is a NamedDescriptor that can be deprecated.
+//
+// Messages, Fields, Enums, EnumValues, Services, and Methods are DeprecatableDescriptors.
+type DeprecatableDescriptor interface {
+	NamedDescriptor
+
+	Deprecated() bool
+}
+
 // Service is a service descriptor.
 type Service interface {
 	NamedDescriptor
@@ -578,6 +587,68 @@ func ForEachMessage(f func(Message) error, containerDescriptor ContainerDescript
 	return nil
 }
 
+// ForEachDeprecatableDescriptor calls f on each Message, Field, Enum, EnumValue,
+// Service, and Method in the given File, including nested Messages and Enums and
+// extensions.
+//
+// Returns error and stops iterating if f returns error
+// Never returns error unless f returns error.
+func ForEachDeprecatableDescriptor(f func(DeprecatableDescriptor) error, file File) error {
+	if err := ForEachEnum(
+		func(enum Enum) error {
+			if err := f(enum); err != nil {
+				return err
+			}
+			for _, enumValue := range enum.Values() {
+				if err := f(enumValue); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return err
+	}
+	if err := ForEachMessage(
+		func(message Message) error {
+			if err := f(message); err != nil {
+				return err
+			}
+			for _, field := range message.Fields() {
+				if err := f(field); err != nil {
+					return err
+				}
+			}
+			for _, extension := range message.Extensions() {
+				if err := f(extension); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		file,
+	); err != nil {
+		return err
+	}
+	for _, extension := range file.Extensions() {
+		if err := f(extension); err != nil {
+			return err
+		}
+	}
+	for _, service := range file.Services() {
+		if err := f(service); err != nil {
+			return err
+		}
+		for _, method := range service.Methods() {
+			if err := f(method); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // NestedNameToEnum maps the Enums in the ContainerDescriptor to a map from
 // nested name to Enum.
 //
@@ -0,0 +1,203 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type circuitBreakerRoundTripper struct {
+	delegate         http.RoundTripper
+	failureThreshold int
+	openDuration     time.Duration
+	// attemptTimeout is only used in errors, the timeouts are applied by the delegate.
+	attemptTimeout time.Duration
+	hostToMirrors  map[string][]string
+	now            func() time.Time
+
+	lock          sync.Mutex
+	hostToCircuit map[string]*circuit
+}
+
+func newCircuitBreakerRoundTripper(
+	delegate http.RoundTripper,
+	failureThreshold int,
+	openDuration time.Duration,
+	attemptTimeout time.Duration,
+	hostToMirrors map[string][]string,
+	now func() time.Time,
+) *circuitBreakerRoundTripper {
+	return &circuitBreakerRoundTripper{
+		delegate:         delegate,
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		attemptTimeout:   attemptTimeout,
+		hostToMirrors:    hostToMirrors,
+		now:              now,
+		hostToCircuit:    make(map[string]*circuit),
+	}
+}
+
+func (c *circuitBreakerRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	host, err := c.acquireHost(request.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	attemptRequest := request
+	if host != request.URL.Host {
+		// RoundTrippers must not modify the given request.
+		attemptRequest = request.Clone(request.Context())
+		attemptRequest.URL.Host = host
+		attemptRequest.Host = ""
+		// Mirrors serve the same registry and accept its credentials, so the
+		// Authorization header is kept. Cookies are scoped to the original host.
+		attemptRequest.Header.Del("Cookie")
+	}
+	response, err := c.delegate.RoundTrip(attemptRequest)
+	if err != nil {
+		if request.Context().Err() != nil {
+			// The caller gave up, this says nothing about the health of the host.
+			c.release(host)
+			return nil, err
+		}
+		if c.attemptTimeout > 0 && isTimeoutError(err) {
+			err = fmt.Errorf("%s did not respond within %v: %w", host, c.attemptTimeout, err)
+		}
+		c.record(host, false)
+		return nil, err
+	}
+	c.record(host, !isFailureStatusCode(response.StatusCode))
+	return response, nil
+}
+
+// acquireHost returns the host to send the request to, or an error if neither
+// the host nor any of its mirrors are available.
+func (c *circuitBreakerRoundTripper) acquireHost(host string) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	if c.getCircuit(host).acquire(now, c.openDuration) {
+		return host, nil
+	}
+	for _, mirror := range c.hostToMirrors[host] {
+		if c.getCircuit(mirror).acquire(now, c.openDuration) {
+			return mirror, nil
+		}
+	}
+	hostCircuit := c.getCircuit(host)
+	return "", fmt.Errorf(
+		"%s is unavailable after %d consecutive failures, not retrying until %s",
+		host,
+		hostCircuit.failures,
+		hostCircuit.openedAt.Add(c.openDuration).Format(time.RFC3339),
+	)
+}
+
+func (c *circuitBreakerRoundTripper) record(host string, success bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getCircuit(host).record(success, c.now(), c.failureThreshold)
+}
+
+// release undoes acquireHost without recording a result.
+func (c *circuitBreakerRoundTripper) release(host string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getCircuit(host).release()
+}
+
+// getCircuit must be called with the lock held.
+func (c *circuitBreakerRoundTripper) getCircuit(host string) *circuit {
+	hostCircuit, ok := c.hostToCircuit[host]
+	if !ok {
+		hostCircuit = &circuit{}
+		c.hostToCircuit[host] = hostCircuit
+	}
+	return hostCircuit
+}
+
+type circuitState int
+
+const (
+	circuitStateClosed circuitState = iota
+	circuitStateOpen
+	circuitStateHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// acquire returns true if a request may be sent.
+func (c *circuit) acquire(now time.Time, openDuration time.Duration) bool {
+	switch c.state {
+	case circuitStateClosed:
+		return true
+	case circuitStateOpen:
+		if now.Before(c.openedAt.Add(openDuration)) {
+			return false
+		}
+		// Let a single request through to probe the host.
+		c.state = circuitStateHalfOpen
+		return true
+	default:
+		// A probe is already in flight.
+		return false
+	}
+}
+
+func (c *circuit) release() {
+	if c.state == circuitStateHalfOpen {
+		// Allow another probe immediately.
+		c.state = circuitStateOpen
+		c.openedAt = time.Time{}
+	}
+}
+
+func (c *circuit) record(success bool, now time.Time, failureThreshold int) {
+	if success {
+		c.state = circuitStateClosed
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.state == circuitStateHalfOpen || c.failures >= failureThreshold {
+		c.state = circuitStateOpen
+		c.openedAt = now
+	}
+}
+
+func isFailureStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTimeoutError returns true if the error is a timeout of the transport, such as
+// a dial, TLS handshake, or response header timeout.
+func isTimeoutError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
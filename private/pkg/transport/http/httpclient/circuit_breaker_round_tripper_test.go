@@ -0,0 +1,212 @@
+// Copyright 2020-2024 Buf Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerRoundTripper(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	delegate := newTestRoundTripper()
+	delegate.hostToStatusCode["buf.example.com"] = http.StatusServiceUnavailable
+	roundTripper := newCircuitBreakerRoundTripper(delegate, 2, time.Minute, 0, nil, func() time.Time { return now })
+	for i := 0; i < 2; i++ {
+		response, err := roundTrip(roundTripper, "buf.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	}
+	_, err := roundTrip(roundTripper, "buf.example.com")
+	assert.ErrorContains(t, err, "buf.example.com is unavailable after 2 consecutive failures")
+	assert.Equal(t, 2, delegate.hostToRequests["buf.example.com"])
+	// Other hosts are not affected.
+	_, err = roundTrip(roundTripper, "other.example.com")
+	require.NoError(t, err)
+	// After the open duration, a probe is let through and closes the circuit on success.
+	now = now.Add(time.Minute)
+	delegate.hostToStatusCode["buf.example.com"] = http.StatusOK
+	_, err = roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+	_, err = roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 4, delegate.hostToRequests["buf.example.com"])
+}
+
+func TestCircuitBreakerRoundTripperHalfOpenFailure(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	delegate := newTestRoundTripper()
+	delegate.hostToErr["buf.example.com"] = errors.New("connection refused")
+	roundTripper := newCircuitBreakerRoundTripper(delegate, 1, time.Minute, 0, nil, func() time.Time { return now })
+	_, err := roundTrip(roundTripper, "buf.example.com")
+	assert.ErrorContains(t, err, "connection refused")
+	now = now.Add(time.Minute)
+	_, err = roundTrip(roundTripper, "buf.example.com")
+	assert.ErrorContains(t, err, "connection refused")
+	// The failed probe opens the circuit again.
+	_, err = roundTrip(roundTripper, "buf.example.com")
+	assert.ErrorContains(t, err, "is unavailable")
+	assert.Equal(t, 2, delegate.hostToRequests["buf.example.com"])
+}
+
+func TestCircuitBreakerRoundTripperMirrors(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	delegate := newTestRoundTripper()
+	delegate.hostToStatusCode["buf.example.com"] = http.StatusBadGateway
+	delegate.hostToStatusCode["mirror1.example.com"] = http.StatusGatewayTimeout
+	roundTripper := newCircuitBreakerRoundTripper(
+		delegate,
+		1,
+		time.Minute,
+		0,
+		map[string][]string{
+			"buf.example.com": {"mirror1.example.com", "mirror2.example.com"},
+		},
+		func() time.Time { return now },
+	)
+	response, err := roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	response, err = roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, response.StatusCode)
+	response, err = roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "mirror2.example.com", response.Request.URL.Host)
+	// The mirror serves the same registry, so it gets the Authorization header, but not
+	// the cookies of the original host.
+	assert.Equal(t, "Bearer token", response.Request.Header.Get("Authorization"))
+	assert.Empty(t, response.Request.Header.Get("Cookie"))
+	assert.Equal(t, 1, delegate.hostToRequests["buf.example.com"])
+	assert.Equal(t, 1, delegate.hostToRequests["mirror1.example.com"])
+	assert.Equal(t, 1, delegate.hostToRequests["mirror2.example.com"])
+}
+
+func TestClientCircuitBreakerAttemptTimeout(t *testing.T) {
+	t.Parallel()
+	hangingServer := httptest.NewServer(
+		http.HandlerFunc(func(_ http.ResponseWriter, request *http.Request) {
+			<-request.Context().Done()
+		}),
+	)
+	defer hangingServer.Close()
+	mirrorServer := httptest.NewServer(
+		http.HandlerFunc(func(responseWriter http.ResponseWriter, _ *http.Request) {
+			// The headers are sent immediately, but the body takes longer than the attempt timeout.
+			responseWriter.WriteHeader(http.StatusOK)
+			responseWriter.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			_, _ = responseWriter.Write([]byte("body"))
+		}),
+	)
+	defer mirrorServer.Close()
+	hangingHost := strings.TrimPrefix(hangingServer.URL, "http://")
+	mirrorHost := strings.TrimPrefix(mirrorServer.URL, "http://")
+	client := newClient(
+		nil,
+		WithCircuitBreaker(2, time.Minute, 20*time.Millisecond),
+		WithMirrors(map[string][]string{hangingHost: {mirrorHost}}),
+	)
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(hangingServer.URL)
+		assert.ErrorContains(t, err, hangingHost+" did not respond within 20ms")
+	}
+	// The timeouts opened the circuit, so the mirror is used, and the timeout does not
+	// apply to reading the body.
+	response, err := client.Get(hangingServer.URL)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "body", string(body))
+	assert.Equal(t, mirrorHost, response.Request.URL.Host)
+}
+
+func TestCircuitBreakerRoundTripperCallerCanceled(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	delegate := newTestRoundTripper()
+	delegate.hostToHang["buf.example.com"] = true
+	roundTripper := newCircuitBreakerRoundTripper(delegate, 1, time.Minute, time.Minute, nil, func() time.Time { return now })
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://buf.example.com/buf.registry.v1.Service/Method", nil)
+	require.NoError(t, err)
+	_, err = roundTripper.RoundTrip(request)
+	assert.ErrorIs(t, err, context.Canceled)
+	// A request canceled by the caller is not a failure of the host.
+	delete(delegate.hostToHang, "buf.example.com")
+	_, err = roundTrip(roundTripper, "buf.example.com")
+	require.NoError(t, err)
+}
+
+func roundTrip(roundTripper http.RoundTripper, host string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodPost, "https://"+host+"/buf.registry.v1.Service/Method", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer token")
+	request.Header.Set("Cookie", "session=secret")
+	return roundTripper.RoundTrip(request)
+}
+
+type testRoundTripper struct {
+	hostToStatusCode map[string]int
+	hostToErr        map[string]error
+	// hostToHang are the hosts that do not respond until the request is canceled.
+	hostToHang     map[string]bool
+	hostToRequests map[string]int
+}
+
+func newTestRoundTripper() *testRoundTripper {
+	return &testRoundTripper{
+		hostToStatusCode: make(map[string]int),
+		hostToErr:        make(map[string]error),
+		hostToHang:       make(map[string]bool),
+		hostToRequests:   make(map[string]int),
+	}
+}
+
+func (r *testRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	r.hostToRequests[request.URL.Host]++
+	if err := r.hostToErr[request.URL.Host]; err != nil {
+		return nil, err
+	}
+	if r.hostToHang[request.URL.Host] {
+		<-request.Context().Done()
+		return nil, request.Context().Err()
+	}
+	statusCode := r.hostToStatusCode[request.URL.Host]
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Request:    request,
+	}, nil
+}
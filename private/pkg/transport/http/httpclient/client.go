@@ -16,14 +16,50 @@ package httpclient
 
 import (
 	"crypto/tls"
+	"net"
 	"net/http"
+	"time"
 )
 
-func newClient(clientTLSConfig *tls.Config) *http.Client {
+func newClient(clientTLSConfig *tls.Config, options ...ClientOption) *http.Client {
+	clientOptions := newClientOptions()
+	for _, option := range options {
+		option(clientOptions)
+	}
+	httpTransport := &http.Transport{
+		TLSClientConfig: clientTLSConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	var transport http.RoundTripper = httpTransport
+	if clientOptions.failureThreshold > 0 {
+		if attemptTimeout := clientOptions.attemptTimeout; attemptTimeout > 0 {
+			// The timeouts only cover connecting and waiting for the response headers,
+			// so that reading a large response body is never cut off.
+			httpTransport.DialContext = (&net.Dialer{Timeout: attemptTimeout}).DialContext
+			httpTransport.TLSHandshakeTimeout = attemptTimeout
+			httpTransport.ResponseHeaderTimeout = attemptTimeout
+		}
+		transport = newCircuitBreakerRoundTripper(
+			transport,
+			clientOptions.failureThreshold,
+			clientOptions.openDuration,
+			clientOptions.attemptTimeout,
+			clientOptions.hostToMirrors,
+			time.Now,
+		)
+	}
 	return &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: clientTLSConfig,
-			Proxy:           http.ProxyFromEnvironment,
-		},
+		Transport: transport,
 	}
 }
+
+type clientOptions struct {
+	failureThreshold int
+	openDuration     time.Duration
+	attemptTimeout   time.Duration
+	hostToMirrors    map[string][]string
+}
+
+func newClientOptions() *clientOptions {
+	return &clientOptions{}
+}
@@ -17,9 +17,46 @@ package httpclient
 import (
 	"crypto/tls"
 	"net/http"
+	"time"
 )
 
 // NewClient returns a new Client.
-func NewClient(clientTLSConfig *tls.Config) *http.Client {
-	return newClient(clientTLSConfig)
+func NewClient(clientTLSConfig *tls.Config, options ...ClientOption) *http.Client {
+	return newClient(clientTLSConfig, options...)
+}
+
+// ClientOption is an option for a new Client.
+type ClientOption func(*clientOptions)
+
+// WithCircuitBreaker returns a new ClientOption that stops sending requests to a host
+// after failureThreshold consecutive failures.
+//
+// A failure is a transport error, a 502, 503, or 504 response, or a timeout. The
+// attemptTimeout separately limits connecting to the host, the TLS handshake, and
+// waiting for the response headers after the request is sent. It does not limit
+// reading the response body. While the circuit is open, requests to the host fail
+// immediately. After openDuration, a single request is let through to probe the host,
+// and the circuit closes again if it succeeds.
+//
+// A failureThreshold of 0 disables the circuit breaker. An attemptTimeout of 0 means
+// requests are only limited by their context.
+func WithCircuitBreaker(failureThreshold int, openDuration time.Duration, attemptTimeout time.Duration) ClientOption {
+	return func(clientOptions *clientOptions) {
+		clientOptions.failureThreshold = failureThreshold
+		clientOptions.openDuration = openDuration
+		clientOptions.attemptTimeout = attemptTimeout
+	}
+}
+
+// WithMirrors returns a new ClientOption that sends requests for a host to the first of
+// its mirror hosts with a closed circuit when the circuit for the host is open.
+//
+// The keys and values are hosts, optionally with ports. Mirrors must serve the same
+// registry as the host, as the Authorization header for the host is sent to them. The
+// Cookie header is removed from requests sent to a mirror. This has no effect unless
+// WithCircuitBreaker is also set.
+func WithMirrors(hostToMirrors map[string][]string) ClientOption {
+	return func(clientOptions *clientOptions) {
+		clientOptions.hostToMirrors = hostToMirrors
+	}
 }